@@ -0,0 +1,305 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// AgentIDHeader carries the agent's identifier on every request to the dispatcher.
+	AgentIDHeader = "X-PlugsConc-Agent"
+	// DefaultPollInterval is how long an agent waits before polling again when the queue is empty.
+	DefaultPollInterval = time.Second
+	// DefaultRequestTimeout bounds each request an agent makes to the dispatcher.
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// ErrUnexpectedStatus indicates that the dispatcher responded with an unexpected HTTP status.
+var ErrUnexpectedStatus = errors.New("unexpected response status from dispatcher")
+
+// Agent pulls serialized jobs from a primary host's Dispatcher, runs them on its local pool, and streams the results
+// back to the host.
+type Agent struct {
+	agentLogger  hclog.Logger
+	id           string
+	hostURL      string
+	token        string
+	pollInterval time.Duration
+	client       *http.Client
+	pool         *worker.Pool
+	results      <-chan *worker.JobResult
+	spool        *ResultSpool
+	mu           sync.Mutex
+	acks         map[string]string // job ID -> ack ID
+}
+
+// NewAgent creates an Agent that pulls jobs from the dispatcher at hostURL and runs them on pool.
+// The pool must be running and its Results channel must not be consumed elsewhere; Run shuts it down when it returns.
+func NewAgent(id string, hostURL string, token string, pool *worker.Pool, agentLogger hclog.Logger) *Agent {
+	if agentLogger == nil {
+		agentLogger = hclog.Default()
+	}
+	return &Agent{
+		agentLogger:  agentLogger.With(logger.KeyAgentID, id),
+		id:           id,
+		hostURL:      strings.TrimSuffix(hostURL, "/"),
+		token:        token,
+		pollInterval: DefaultPollInterval,
+		client:       &http.Client{Timeout: DefaultRequestTimeout},
+		pool:         pool,
+		results:      pool.Results(),
+		acks:         make(map[string]string),
+	}
+}
+
+// WithPollInterval sets how long the agent waits between polls of an empty queue and returns the updated Agent.
+func (a *Agent) WithPollInterval(interval time.Duration) *Agent {
+	if interval > 0 {
+		a.pollInterval = interval
+	}
+	return a
+}
+
+// WithResults streams the results read from results instead of the pool's Results channel, e.g. a history.Store's
+// Tee of it recording every result locally, and returns the updated Agent.
+func (a *Agent) WithResults(results <-chan *worker.JobResult) *Agent {
+	if results != nil {
+		a.results = results
+	}
+	return a
+}
+
+// WithSpool spools the results the agent cannot report to spool, reporting them on its next run, and returns the
+// updated Agent. Without a spool such results are dropped and their jobs run again once their leases expire.
+func (a *Agent) WithSpool(spool *ResultSpool) *Agent {
+	a.spool = spool
+	return a
+}
+
+// Run reports the results spooled by a previous run, then pulls and executes jobs until ctx is canceled, streaming
+// each result back to the dispatcher. When it stops it shuts the pool down, waiting for the jobs it holds, and reports
+// or spools their results before returning.
+func (a *Agent) Run(ctx context.Context) error {
+	a.agentLogger.Info("Agent started", "host", a.hostURL)
+	defer a.agentLogger.Info("Agent stopped")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.streamResults(ctx)
+	}()
+	if a.spool != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.flushSpool(ctx)
+		}()
+	}
+	// shut the pool down before waiting for the streamer, which reads results until the pool closes its channel
+	defer func() {
+		a.pool.Shutdown()
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		pulled, err := a.pull(ctx)
+		if err != nil && !errors.Is(err, ErrNoJob) && ctx.Err() == nil {
+			a.agentLogger.Error("Failed to pull job", logger.KeyError, err)
+		}
+		if pulled == nil {
+			t := time.NewTimer(a.pollInterval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+			continue
+		}
+		job, err := worker.JobFromEnvelope(context.Background(), pulled.Envelope)
+		if err != nil {
+			a.agentLogger.Error("Failed to rebuild job", logger.KeyAckID, pulled.AckID, logger.KeyError, err)
+			a.report(ctx, &RemoteResult{
+				AckID: pulled.AckID,
+				JobID: pulled.Envelope.JobID,
+				Type:  pulled.Envelope.Type,
+				Error: err.Error(),
+			})
+			continue
+		}
+		a.mu.Lock()
+		a.acks[job.ID] = pulled.AckID
+		a.mu.Unlock()
+		if err := a.pool.Submit(job); err != nil {
+			a.agentLogger.Error("Failed to submit pulled job", logger.KeyJobID, job.ID, logger.KeyError, err)
+			return err
+		}
+	}
+}
+
+// pull requests the next job from the dispatcher, returning ErrNoJob when the queue is empty.
+func (a *Agent) pull(ctx context.Context) (*PullResponse, error) {
+	resp, err := a.do(ctx, PullPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, ErrNoJob
+	case http.StatusOK:
+		var pulled PullResponse
+		if err := json.NewDecoder(resp.Body).Decode(&pulled); err != nil {
+			return nil, err
+		}
+		return &pulled, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+}
+
+// streamResults forwards every result produced by the local pool to the dispatcher until the pool closes its results
+// channel, so the results of the jobs still running when ctx is canceled are reported or spooled too.
+func (a *Agent) streamResults(ctx context.Context) {
+	for res := range a.results {
+		a.mu.Lock()
+		ackID := a.acks[res.JobID]
+		delete(a.acks, res.JobID)
+		a.mu.Unlock()
+		remote := &RemoteResult{
+			AckID:   ackID,
+			AgentID: a.id,
+			JobID:   res.JobID,
+			Type:    res.Type,
+			Plugin:  res.Plugin,
+		}
+		if res.Metrics != nil {
+			remote.Attempts = res.Metrics.Attempts + 1
+			remote.StartedAt = res.Metrics.StartedAt
+			remote.FinishedAt = res.Metrics.FinishedAt
+		}
+		if res.Err != nil {
+			remote.Error = res.Err.Error()
+		}
+		if res.Value != nil {
+			value, err := json.Marshal(res.Value)
+			if err != nil {
+				remote.Error = errors.Join(res.Err, err).Error()
+			}
+			remote.Value = value
+		}
+		a.report(ctx, remote)
+	}
+}
+
+// report sends a result to the dispatcher, retrying while the dispatcher asks the agent to back off. A result that
+// is not accepted before ctx is canceled gets one last attempt and is otherwise spooled for the next run.
+func (a *Agent) report(ctx context.Context, res *RemoteResult) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		a.agentLogger.Error("Failed to encode result", logger.KeyJobID, res.JobID, logger.KeyError, err)
+		return
+	}
+	if a.send(ctx, res.JobID, body) == nil {
+		return
+	}
+	lastCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultRequestTimeout)
+	err = a.post(lastCtx, body)
+	cancel()
+	if err == nil {
+		return
+	}
+	if a.spool == nil {
+		a.agentLogger.Error("Dropped unreported result", logger.KeyJobID, res.JobID, logger.KeyError, err)
+		return
+	}
+	if err := a.spool.put(body); err != nil {
+		a.agentLogger.Error("Failed to spool result", logger.KeyJobID, res.JobID, logger.KeyError, err)
+		return
+	}
+	a.agentLogger.Warn("Result spooled for the next run", logger.KeyJobID, res.JobID)
+}
+
+// flushSpool reports the spooled results, oldest first, until the spool is empty or ctx is canceled. A result being
+// reported when ctx is canceled stays in the spool.
+func (a *Agent) flushSpool(ctx context.Context) {
+	for {
+		body, ackID, ok := a.spool.next()
+		if !ok {
+			return
+		}
+		var res RemoteResult
+		_ = json.Unmarshal(body, &res)
+		if a.send(ctx, res.JobID, body) != nil {
+			return
+		}
+		a.spool.ack(ackID)
+		a.agentLogger.Info("Reported spooled result", logger.KeyJobID, res.JobID)
+	}
+}
+
+// send posts an encoded result to the dispatcher, retrying every poll interval until it is accepted or ctx is
+// canceled, in which case the context's error is returned.
+func (a *Agent) send(ctx context.Context, jobID string, body []byte) error {
+	for {
+		err := a.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		a.agentLogger.Warn("Failed to report result, retrying", logger.KeyJobID, jobID, logger.KeyError, err)
+		t := time.NewTimer(a.pollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// post makes one attempt at reporting an encoded result.
+func (a *Agent) post(ctx context.Context, body []byte) error {
+	resp, err := a.do(ctx, ResultsPath, body)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+	return nil
+}
+
+// do issues an authenticated POST to the dispatcher.
+func (a *Agent) do(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.hostURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentIDHeader, a.id)
+	if a.token != "" {
+		req.Header.Set("Authorization", bearerPrefix+a.token)
+	}
+	return a.client.Do(req)
+}
@@ -0,0 +1,370 @@
+// Package agent implements remote worker agents. The primary host runs a Dispatcher that holds serialized jobs in a
+// persistent queue, and agents on other machines pull those jobs, run them on their local pool, and stream the
+// results back.
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/goptics/sqliteq"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// PullPath is the dispatcher endpoint agents call to pull the next job.
+	PullPath = "/agent/v1/pull"
+	// ResultsPath is the dispatcher endpoint agents call to report a job result.
+	ResultsPath = "/agent/v1/results"
+	// JobsPath is the dispatcher endpoint a client calls with a worker.Envelope to queue a job for the agents.
+	JobsPath = "/agent/v1/jobs"
+	// JobQueueName is the name of the persistent queue holding serialized jobs.
+	JobQueueName = "agent-jobs"
+	// DefaultResultsBuffer is the capacity of the dispatcher's results channel.
+	DefaultResultsBuffer = 100
+	// DefaultLease is how long an agent may hold a pulled job before it is handed out again.
+	DefaultLease = 5 * time.Minute
+	// DefaultMaxRequestBytes bounds the body of each request to the dispatcher, large enough for a result at the
+	// default result size limit.
+	DefaultMaxRequestBytes = 32 << 20
+	// bearerPrefix is the expected prefix of the Authorization header value.
+	bearerPrefix = "Bearer "
+)
+
+var (
+	// ErrEnqueueFailed indicates that a job could not be written to the persistent queue.
+	ErrEnqueueFailed = errors.New("failed to enqueue job")
+	// ErrNoJob indicates that the queue has no pending jobs.
+	ErrNoJob = errors.New("no pending job")
+)
+
+// PullResponse is returned to an agent for each pulled job. The AckID must be echoed back with the result.
+type PullResponse struct {
+	AckID    string           `json:"ack_id"`
+	Envelope *worker.Envelope `json:"envelope"`
+}
+
+// RemoteResult is the result of a job executed by a remote agent.
+type RemoteResult struct {
+	AckID      string          `json:"ack_id"`
+	AgentID    string          `json:"agent_id"`
+	JobID      string          `json:"job_id"`
+	Type       string          `json:"job_type,omitempty"`
+	Plugin     string          `json:"plugin,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Attempts   int             `json:"attempts"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+}
+
+// JobResult converts the remote result into the worker.JobResult the job would have produced on a local pool, e.g. to
+// record it in a history.Store. Value holds the raw JSON of the result's value.
+func (r *RemoteResult) JobResult() *worker.JobResult {
+	res := &worker.JobResult{
+		JobID:  r.JobID,
+		Type:   r.Type,
+		Plugin: r.Plugin,
+		Metrics: &worker.JobMetrics{
+			StartedAt:  r.StartedAt,
+			FinishedAt: r.FinishedAt,
+			Duration:   r.FinishedAt.Sub(r.StartedAt),
+			Attempts:   max(r.Attempts-1, 0),
+		},
+	}
+	if len(r.Value) > 0 {
+		res.Value = r.Value
+	}
+	if r.Error != "" {
+		res.Err = errors.New(r.Error)
+	}
+	return res
+}
+
+// Dispatcher queues serialized jobs in a persistent sqlite queue and serves them to remote agents over HTTP.
+// Jobs pulled by an agent stay in the queue until their result is acknowledged. A job whose result is not reported
+// within the lease, e.g. because its agent died, is handed out again by RunLeaseExpiry, so a job runs at least once
+// but may run more than once.
+type Dispatcher struct {
+	dispatchLogger hclog.Logger
+	token          string
+	db             sqliteq.Queues
+	queue          *sqliteq.Queue
+	results        chan *RemoteResult
+	lease          time.Duration
+	maxBody        int64
+	mu             sync.Mutex
+	leases         map[string]*jobLease // ack ID -> lease, guarded by mu
+}
+
+// jobLease is a job pulled by an agent and not yet acknowledged.
+type jobLease struct {
+	jobID   string
+	data    []byte
+	expires time.Time
+}
+
+// NewDispatcher opens, or creates, the persistent job queue at dbPath. When token is set, agents must present it as a
+// bearer token; without one every request is accepted, which is logged as a warning.
+func NewDispatcher(dbPath string, token string, dispatchLogger hclog.Logger) (*Dispatcher, error) {
+	if dispatchLogger == nil {
+		dispatchLogger = hclog.Default()
+	}
+	if token == "" {
+		dispatchLogger.Warn("No agent token is set, the dispatcher accepts unauthenticated requests")
+	}
+	db := sqliteq.New(dbPath)
+	queue, err := db.NewQueue(JobQueueName, sqliteq.WithRemoveOnComplete(true))
+	if err != nil {
+		dispatchLogger.Error("Failed to create agent job queue", logger.KeyError, err)
+		return nil, errors.Join(err, db.Close())
+	}
+	// jobs left in processing by a previous run were never acknowledged
+	queue.RequeueNoAckRows()
+	return &Dispatcher{
+		dispatchLogger: dispatchLogger,
+		token:          token,
+		db:             db,
+		queue:          queue,
+		results:        make(chan *RemoteResult, DefaultResultsBuffer),
+		lease:          DefaultLease,
+		maxBody:        DefaultMaxRequestBytes,
+		leases:         make(map[string]*jobLease),
+	}, nil
+}
+
+// WithLease sets how long an agent may hold a pulled job before RunLeaseExpiry hands it out again and returns the
+// updated Dispatcher.
+func (d *Dispatcher) WithLease(lease time.Duration) *Dispatcher {
+	if lease > 0 {
+		d.lease = lease
+	}
+	return d
+}
+
+// WithMaxRequestBytes bounds the body of each request to the dispatcher, e.g. a queued envelope or a reported result,
+// to n bytes and returns the updated Dispatcher. Larger requests are refused with 413.
+func (d *Dispatcher) WithMaxRequestBytes(n int64) *Dispatcher {
+	if n > 0 {
+		d.maxBody = n
+	}
+	return d
+}
+
+// Enqueue serializes the job and adds it to the persistent queue for a remote agent to pull.
+func (d *Dispatcher) Enqueue(job *worker.Job) error {
+	job.SetSubmittedAt()
+	env, err := job.Envelope()
+	if err != nil {
+		return err
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		return err
+	}
+	if !d.queue.Enqueue(data) {
+		return ErrEnqueueFailed
+	}
+	return nil
+}
+
+// Pending returns the number of jobs waiting to be pulled.
+func (d *Dispatcher) Pending() int {
+	return d.queue.Len()
+}
+
+// Requeue returns every pulled but unacknowledged job to the pending state, whether or not its lease has expired.
+func (d *Dispatcher) Requeue() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue.RequeueNoAckRows()
+	clear(d.leases)
+}
+
+// RequeueExpired hands out again every pulled job whose lease has expired and returns how many were requeued. The
+// expired pull is acknowledged and the job enqueued anew, so a late result for it is still published but no longer
+// acknowledges anything.
+func (d *Dispatcher) RequeueExpired() int {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	requeued := 0
+	for ackID, lease := range d.leases {
+		if now.Before(lease.expires) {
+			continue
+		}
+		delete(d.leases, ackID)
+		if !d.queue.Acknowledge(ackID) {
+			continue
+		}
+		if !d.queue.Enqueue(lease.data) {
+			d.dispatchLogger.Error("Failed to requeue job with an expired lease", logger.KeyJobID, lease.jobID)
+			continue
+		}
+		d.dispatchLogger.Warn("Lease expired, job requeued", logger.KeyJobID, lease.jobID, logger.KeyAckID, ackID)
+		requeued++
+	}
+	return requeued
+}
+
+// RunLeaseExpiry calls RequeueExpired every interval until ctx is canceled.
+func (d *Dispatcher) RunLeaseExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.RequeueExpired()
+		}
+	}
+}
+
+// Results returns a channel of results reported by remote agents.
+func (d *Dispatcher) Results() <-chan *RemoteResult {
+	return d.results
+}
+
+// Close closes the persistent queue. Unacknowledged jobs remain in the queue for the next run.
+func (d *Dispatcher) Close() error {
+	return errors.Join(d.queue.Close(), d.db.Close())
+}
+
+// Handler returns the HTTP handler serving the agent pull and results endpoints and the endpoint queuing jobs.
+func (d *Dispatcher) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+JobsPath, d.enqueue)
+	mux.HandleFunc("POST "+PullPath, d.pull)
+	mux.HandleFunc("POST "+ResultsPath, d.result)
+	return d.authorize(d.limitBody(mux))
+}
+
+// limitBody bounds the body of each request to the dispatcher's maximum request size.
+func (d *Dispatcher) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, d.maxBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize rejects requests that do not carry the configured bearer token.
+func (d *Dispatcher) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.token != "" {
+			auth := r.Header.Get("Authorization")
+			token := strings.TrimPrefix(auth, bearerPrefix)
+			if !strings.HasPrefix(auth, bearerPrefix) ||
+				subtle.ConstantTimeCompare([]byte(token), []byte(d.token)) != 1 {
+				d.dispatchLogger.Warn("Rejected unauthorized agent request", "remote", r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// enqueue queues the job described by the worker.Envelope in the request body, responding 202 with its ID, or 400
+// when the envelope names an unknown job type or its payload does not decode.
+func (d *Dispatcher) enqueue(w http.ResponseWriter, r *http.Request) {
+	var env worker.Envelope
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		decodeError(w, err)
+		return
+	}
+	job, err := worker.JobFromEnvelope(context.Background(), &env)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := d.Enqueue(job); err != nil {
+		d.dispatchLogger.Error("Failed to enqueue job", logger.KeyJobID, job.ID, logger.KeyError, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]string{"job_id": job.ID}); err != nil {
+		d.dispatchLogger.Error("Failed to write response", logger.KeyError, err)
+	}
+}
+
+// pull hands the oldest pending job to the calling agent, responding 204 when the queue is empty.
+func (d *Dispatcher) pull(w http.ResponseWriter, r *http.Request) {
+	item, ok, ackID := d.queue.DequeueWithAckId()
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	data, _ := item.([]byte)
+	env, err := worker.UnmarshalEnvelope(data)
+	if err != nil {
+		// an undecodable envelope can never succeed, acknowledge it so it is not handed out again
+		d.queue.Acknowledge(ackID)
+		d.dispatchLogger.Error("Dropped undecodable job envelope", logger.KeyAckID, ackID, logger.KeyError, err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	d.mu.Lock()
+	d.leases[ackID] = &jobLease{jobID: env.JobID, data: data, expires: time.Now().Add(d.lease)}
+	d.mu.Unlock()
+	d.dispatchLogger.Debug("Job pulled by agent",
+		logger.KeyJobID, env.JobID,
+		logger.KeyAckID, ackID,
+		logger.KeyAgentID, r.Header.Get(AgentIDHeader))
+	writeJSON(w, d.dispatchLogger, &PullResponse{AckID: ackID, Envelope: env})
+}
+
+// result acknowledges a pulled job and publishes its result. When the results channel is full the job is left
+// unacknowledged and the agent is asked to retry later. The result of a job whose lease expired is published too,
+// though the job has been handed out again.
+func (d *Dispatcher) result(w http.ResponseWriter, r *http.Request) {
+	var res RemoteResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		decodeError(w, err)
+		return
+	}
+	select {
+	case d.results <- &res:
+	default:
+		d.dispatchLogger.Warn("Results channel full, asking agent to retry", logger.KeyJobID, res.JobID)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	d.mu.Lock()
+	delete(d.leases, res.AckID)
+	acked := d.queue.Acknowledge(res.AckID)
+	d.mu.Unlock()
+	if !acked {
+		d.dispatchLogger.Warn("Result for unknown ack id", logger.KeyJobID, res.JobID, logger.KeyAckID, res.AckID)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeError responds to a request body that could not be decoded: 413 when it exceeded the maximum request size,
+// 400 otherwise.
+func decodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, jsonLogger hclog.Logger, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		jsonLogger.Error("Failed to write response", logger.KeyError, err)
+	}
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/jobtypes"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+// testToken is the bearer token shared by the test dispatcher and its agents.
+const testToken = "secret"
+
+// newTestDispatcher returns a dispatcher requiring testToken, with its queue in a temporary directory, and a server
+// serving its handler.
+func newTestDispatcher(t *testing.T) (*Dispatcher, *httptest.Server) {
+	t.Helper()
+	d, err := NewDispatcher(filepath.Join(t.TempDir(), "dispatcher.db"), testToken, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	srv := httptest.NewServer(d.Handler())
+	t.Cleanup(func() {
+		srv.Close()
+		_ = d.Close()
+	})
+	return d, srv
+}
+
+// postJSON posts body to the dispatcher at path, with token as the bearer token unless it is empty, and returns the
+// response status.
+func postJSON(t *testing.T, srv *httptest.Server, path string, token string, body []byte) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", bearerPrefix+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+// httpCheckEnvelope returns the envelope of an http_check job probing target.
+func httpCheckEnvelope(t *testing.T, target string) *worker.Envelope {
+	t.Helper()
+	job, err := worker.NewSerializableJob(context.Background(), jobtypes.HTTPCheck,
+		jobtypes.HTTPCheckRequest{URL: target, ExpectStatus: http.StatusNoContent})
+	if err != nil {
+		t.Fatalf("NewSerializableJob: %v", err)
+	}
+	env, err := job.Envelope()
+	if err != nil {
+		t.Fatalf("Envelope: %v", err)
+	}
+	return env
+}
+
+// TestDispatcherEnqueue checks that the jobs endpoint requires the bearer token, refuses unknown job types and bodies
+// over the maximum request size, and queues registered jobs.
+func TestDispatcherEnqueue(t *testing.T) {
+	jobtypes.RegisterHTTPCheck(nil)
+	d, srv := newTestDispatcher(t)
+	d.WithMaxRequestBytes(1024)
+	valid, err := httpCheckEnvelope(t, "http://127.0.0.1/health").Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	unknown, err := json.Marshal(&worker.Envelope{Type: "missing", Encoding: worker.EncodingJSON})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	large := append([]byte(`{"value":"`), bytes.Repeat([]byte("x"), 2048)...)
+	tests := map[string]struct {
+		path  string
+		token string
+		body  []byte
+		want  int
+	}{
+		"no token":            {path: JobsPath, body: valid, want: http.StatusUnauthorized},
+		"wrong token":         {path: JobsPath, token: "wrong", body: valid, want: http.StatusUnauthorized},
+		"unknown type":        {path: JobsPath, token: testToken, body: unknown, want: http.StatusBadRequest},
+		"invalid json":        {path: JobsPath, token: testToken, body: []byte("{"), want: http.StatusBadRequest},
+		"registered":          {path: JobsPath, token: testToken, body: valid, want: http.StatusAccepted},
+		"large envelope":      {path: JobsPath, token: testToken, body: large, want: http.StatusRequestEntityTooLarge},
+		"large result":        {path: ResultsPath, token: testToken, body: large, want: http.StatusRequestEntityTooLarge},
+		"unauthorized result": {path: ResultsPath, body: []byte("{}"), want: http.StatusUnauthorized},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := postJSON(t, srv, tt.path, tt.token, tt.body); got != tt.want {
+				t.Errorf("got status %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if n := d.Pending(); n != 1 {
+		t.Errorf("got %d pending jobs, want 1", n)
+	}
+}
+
+// TestDispatcherAgentRoundTrip checks that a job queued on the dispatcher is pulled by an agent, run on its pool, and
+// its result reported back to the dispatcher and acknowledged.
+func TestDispatcherAgentRoundTrip(t *testing.T) {
+	jobtypes.RegisterHTTPCheck(nil)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	d, srv := newTestDispatcher(t)
+	env := httpCheckEnvelope(t, target.URL)
+	body, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got := postJSON(t, srv, JobsPath, testToken, body); got != http.StatusAccepted {
+		t.Fatalf("enqueue: got status %d, want %d", got, http.StatusAccepted)
+	}
+
+	pool := worker.NewPool(1, false, 1, hclog.NewNullLogger())
+	pool.Run()
+	a := NewAgent("test-agent", srv.URL, testToken, pool, hclog.NewNullLogger()).
+		WithPollInterval(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	select {
+	case res := <-d.Results():
+		if res.JobID != env.JobID || res.AgentID != "test-agent" || res.Type != jobtypes.HTTPCheck {
+			t.Errorf("got result %+v for job %s", res, env.JobID)
+		}
+		if res.Error != "" {
+			t.Errorf("job failed: %s", res.Error)
+		}
+		var check jobtypes.HTTPCheckResult
+		if err := json.Unmarshal(res.Value, &check); err != nil || check.Status != http.StatusNoContent {
+			t.Errorf("got value %s, want status %d", res.Value, http.StatusNoContent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result reported")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run: got %v, want context.Canceled", err)
+	}
+	if n := d.Pending(); n != 0 {
+		t.Errorf("got %d pending jobs, want 0", n)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if n := len(d.leases); n != 0 {
+		t.Errorf("got %d leases left, want 0", n)
+	}
+}
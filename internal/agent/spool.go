@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/goptics/sqliteq"
+)
+
+// SpoolQueueName is the name of the persistent queue holding results an agent could not report.
+const SpoolQueueName = "agent-results"
+
+// ErrSpoolFailed indicates that a result could not be written to the spool.
+var ErrSpoolFailed = errors.New("failed to spool result")
+
+// ResultSpool persists the results an agent could not report to the dispatcher in a sqlite queue, so they are
+// reported on its next run instead of being lost.
+type ResultSpool struct {
+	db    sqliteq.Queues
+	queue *sqliteq.Queue
+}
+
+// OpenResultSpool opens, or creates, the result spool at dbPath. Results a previous run was reporting when it stopped
+// are spooled again.
+func OpenResultSpool(dbPath string) (*ResultSpool, error) {
+	db := sqliteq.New(dbPath)
+	queue, err := db.NewQueue(SpoolQueueName, sqliteq.WithRemoveOnComplete(true))
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	queue.RequeueNoAckRows()
+	return &ResultSpool{db: db, queue: queue}, nil
+}
+
+// Len returns the number of spooled results.
+func (s *ResultSpool) Len() int {
+	return s.queue.Len()
+}
+
+// Close closes the spool. Spooled results remain for the next run.
+func (s *ResultSpool) Close() error {
+	return errors.Join(s.queue.Close(), s.db.Close())
+}
+
+// put spools an encoded result.
+func (s *ResultSpool) put(body []byte) error {
+	if !s.queue.Enqueue(body) {
+		return ErrSpoolFailed
+	}
+	return nil
+}
+
+// next returns the oldest spooled result and the ack ID that removes it once reported, or false when the spool is
+// empty.
+func (s *ResultSpool) next() ([]byte, string, bool) {
+	item, ok, ackID := s.queue.DequeueWithAckId()
+	if !ok {
+		return nil, "", false
+	}
+	body, _ := item.([]byte)
+	return body, ackID, true
+}
+
+// ack removes a reported result from the spool.
+func (s *ResultSpool) ack(ackID string) {
+	s.queue.Acknowledge(ackID)
+}
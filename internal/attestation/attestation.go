@@ -0,0 +1,278 @@
+// Package attestation asks a remote attestation service whether a plugin binary may be used, for organizations that
+// centralize trust decisions outside individual hosts. The host sends the plugin's name, version, binary digest, and
+// signer, after its own checksum, signature, and provenance checks have passed, and the service answers with a
+// verdict. Verdicts are cached, and when the service cannot be reached a fallback policy decides: deny the plugin,
+// allow it, or use the last verdict cached for the binary.
+package attestation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// FallbackDeny rejects plugins while the attestation service cannot be reached.
+// FallbackAllow accepts plugins while the attestation service cannot be reached.
+// FallbackCache uses the last verdict cached for a binary, however old, and rejects binaries without one.
+const (
+	FallbackDeny  = "deny"
+	FallbackAllow = "allow"
+	FallbackCache = "cache"
+)
+
+// Fallbacks lists the valid fallback policies.
+var Fallbacks = []string{FallbackDeny, FallbackAllow, FallbackCache}
+
+// DefaultTimeout bounds each request to the attestation service.
+// DefaultCacheTTL is how long a verdict is used before the service is asked again.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = 10 * time.Minute
+)
+
+// ErrNotAttested indicates that the attestation service does not trust a plugin binary.
+// ErrUnavailable indicates that the attestation service could not be reached and the fallback policy rejects the
+// plugin.
+// ErrUnexpectedStatus indicates that the attestation service responded with an unexpected HTTP status.
+var (
+	ErrNotAttested      = errors.New("plugin is not attested")
+	ErrUnavailable      = errors.New("attestation service unavailable")
+	ErrUnexpectedStatus = errors.New("unexpected response status from attestation service")
+)
+
+// Subject is the plugin binary a host asks the attestation service about, sent as the JSON body of the request.
+type Subject struct {
+	Plugin  string `json:"plugin"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`           // hex digest of the plugin binary
+	Signer  string `json:"signer,omitempty"` // trusted key that signed the binary, if it is signed
+}
+
+// Verdict is the attestation service's answer about a Subject.
+type Verdict struct {
+	Trusted bool   `json:"trusted"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// cachedVerdict is a verdict with the time the service gave it.
+type cachedVerdict struct {
+	Verdict
+	At time.Time `json:"at"`
+}
+
+// Client asks an attestation service at a URL for verdicts about plugin binaries, POSTing each Subject as JSON and
+// expecting a Verdict with status 200. Verdicts are cached for the cache TTL, in memory and, with a cache file, on
+// disk, so the cache fallback also works for the binaries seen before a restart.
+type Client struct {
+	url          string
+	token        string
+	client       *http.Client
+	cacheTTL     time.Duration
+	fallback     string
+	cacheFile    string
+	mu           sync.Mutex
+	cache        map[string]cachedVerdict // verdicts by plugin name and binary digest
+	clientLogger hclog.Logger
+}
+
+// NewClient creates a Client asking the attestation service at url, presenting token as a bearer token when it is
+// not empty. It rejects plugins while the service cannot be reached until another fallback is set.
+func NewClient(url, token string, clientLogger hclog.Logger) *Client {
+	if clientLogger == nil {
+		clientLogger = hclog.Default()
+	}
+	return &Client{
+		url:          url,
+		token:        token,
+		client:       &http.Client{Timeout: DefaultTimeout},
+		cacheTTL:     DefaultCacheTTL,
+		fallback:     FallbackDeny,
+		cache:        make(map[string]cachedVerdict),
+		clientLogger: clientLogger,
+	}
+}
+
+// WithTimeout bounds each request to the service and returns the updated Client. Non-positive values keep the
+// default.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	if timeout > 0 {
+		c.client.Timeout = timeout
+	}
+	return c
+}
+
+// WithCacheTTL sets how long a verdict is used before the service is asked again, zero asking every time, and
+// returns the updated Client.
+func (c *Client) WithCacheTTL(ttl time.Duration) *Client {
+	c.cacheTTL = max(ttl, 0)
+	return c
+}
+
+// WithFallback sets the policy applied while the service cannot be reached, one of Fallbacks, and returns the
+// updated Client. Unknown policies keep the current one.
+func (c *Client) WithFallback(fallback string) *Client {
+	switch fallback {
+	case FallbackDeny, FallbackAllow, FallbackCache:
+		c.fallback = fallback
+	}
+	return c
+}
+
+// WithCacheFile keeps the verdict cache in the JSON file at path, loading the verdicts already in it, and returns
+// the updated Client. A missing file is created with the first verdict.
+func (c *Client) WithCacheFile(path string) (*Client, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read attestation cache: %w", err)
+	default:
+		if err := json.Unmarshal(data, &c.cache); err != nil {
+			return nil, fmt.Errorf("parse attestation cache %s: %w", path, err)
+		}
+	}
+	c.cacheFile = path
+	return c, nil
+}
+
+// AttestFile asks about the plugin binary at path, see Attest.
+func (c *Client) AttestFile(ctx context.Context, plugin, version, path, signer string) error {
+	digest, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	return c.Attest(ctx, Subject{Plugin: plugin, Version: version, SHA256: digest, Signer: signer})
+}
+
+// Attest returns nil when the service trusts the subject, ErrNotAttested with the service's reason when it does not,
+// and, when the service cannot be reached, what the fallback policy decides: ErrUnavailable when it rejects the
+// plugin. A cached verdict younger than the cache TTL is used without asking the service.
+func (c *Client) Attest(ctx context.Context, s Subject) error {
+	key := s.Plugin + "@" + s.SHA256
+	cached, ok := c.cached(key)
+	if ok && time.Since(cached.At) < c.cacheTTL {
+		return verdictErr(s, cached.Verdict)
+	}
+	verdict, err := c.ask(ctx, s)
+	if err == nil {
+		c.store(key, verdict)
+		return verdictErr(s, verdict)
+	}
+	switch {
+	case c.fallback == FallbackAllow:
+		c.clientLogger.Warn("Attestation service unavailable, allowing plugin", logger.KeyPluginName, s.Plugin,
+			"sha256", s.SHA256, logger.KeyError, err)
+		return nil
+	case c.fallback == FallbackCache && ok:
+		c.clientLogger.Warn("Attestation service unavailable, using cached verdict", logger.KeyPluginName, s.Plugin,
+			"sha256", s.SHA256, "trusted", cached.Trusted, "cached_at", cached.At, logger.KeyError, err)
+		return verdictErr(s, cached.Verdict)
+	}
+	return fmt.Errorf("%w: %s: %w", ErrUnavailable, s.Plugin, err)
+}
+
+// ask sends the subject to the service and returns its verdict.
+func (c *Client) ask(ctx context.Context, s Subject) (Verdict, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return Verdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return Verdict{}, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+	var verdict Verdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return Verdict{}, fmt.Errorf("decode attestation verdict: %w", err)
+	}
+	return verdict, nil
+}
+
+// cached returns the verdict cached under key, however old.
+func (c *Client) cached(key string) (cachedVerdict, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.cache[key]
+	return v, ok
+}
+
+// store caches the verdict under key and writes the cache file, if any. A cache file that cannot be written is
+// logged, leaving the verdict cached in memory.
+func (c *Client) store(key string, verdict Verdict) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[key] = cachedVerdict{Verdict: verdict, At: time.Now()}
+	if c.cacheFile == "" {
+		return
+	}
+	if err := writeCache(c.cacheFile, c.cache); err != nil {
+		c.clientLogger.Warn("Failed to write attestation cache", "file", c.cacheFile, logger.KeyError, err)
+	}
+}
+
+// writeCache replaces the cache file with cache, through a temporary file so a crash never leaves it truncated.
+func writeCache(path string, cache map[string]cachedVerdict) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// verdictErr returns nil for a trusted verdict and ErrNotAttested with its reason otherwise.
+func verdictErr(s Subject, v Verdict) error {
+	if v.Trusted {
+		return nil
+	}
+	if v.Reason == "" {
+		return fmt.Errorf("%w: %s %s", ErrNotAttested, s.Plugin, s.Version)
+	}
+	return fmt.Errorf("%w: %s %s: %s", ErrNotAttested, s.Plugin, s.Version, v.Reason)
+}
+
+// fileSHA256 returns the hex SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
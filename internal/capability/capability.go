@@ -5,6 +5,7 @@ type Capabilities struct {
 	Filesystem []FileSystemCapability `yaml:"filesystem,omitempty"`
 	Network    *NetworkCapability     `yaml:"network,omitempty"`
 	Process    *ProcessCapability     `yaml:"process,omitempty"`
+	Jobs       *JobsCapability        `yaml:"jobs,omitempty"`
 }
 
 // FileSystemCapability defines permissions for a specific path.
@@ -48,3 +49,23 @@ type ExecRule struct {
 	Command string   `yaml:"command"`
 	Args    []string `yaml:"args,omitempty"`
 }
+
+// JobsCapability limits the work a job source plugin may submit to the host's worker pool.
+// An empty Types list allows no job types, and a zero MaxPerMinute leaves the volume unlimited.
+type JobsCapability struct {
+	Types        []string `yaml:"types"`
+	MaxPerMinute int      `yaml:"max_per_minute,omitempty"`
+}
+
+// Allows reports whether jobs of the named type may be submitted.
+func (j *JobsCapability) Allows(jobType string) bool {
+	if j == nil {
+		return false
+	}
+	for _, t := range j.Types {
+		if t == jobType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PermRead allows reading a file's contents and metadata.
+// PermWrite allows modifying an existing file.
+// PermList allows listing a directory's entries.
+// PermCreate allows creating files and directories.
+// PermDelete allows removing files and directories.
+const (
+	PermRead   = "read"
+	PermWrite  = "write"
+	PermList   = "list"
+	PermCreate = "create"
+	PermDelete = "delete"
+)
+
+// FilePermissions lists the filesystem permissions a manifest may request.
+var FilePermissions = []string{PermRead, PermWrite, PermList, PermCreate, PermDelete}
+
+var (
+	// ErrAccessDenied indicates that no filesystem grant allows the requested operation on the path.
+	ErrAccessDenied = errors.New("filesystem access denied")
+	// ErrUnknownPermission indicates that a filesystem grant requests a permission that is not in FilePermissions.
+	ErrUnknownPermission = errors.New("unknown filesystem permission")
+	// ErrRelativePath indicates that a filesystem grant or request uses a relative path.
+	ErrRelativePath = errors.New("filesystem path is not absolute")
+)
+
+// fileGrant is a FileSystemCapability with its path resolved.
+type fileGrant struct {
+	path        string
+	permissions []string
+	recursive   bool
+}
+
+// FilesystemGuard enforces a plugin's declared filesystem capabilities. A path is allowed when a grant names it
+// exactly, or when it lies directly inside a granted directory, or anywhere below one if the grant is recursive.
+// Paths are resolved through symlinks before matching, so a link cannot be used to escape a grant.
+// Anything not granted is denied.
+type FilesystemGuard struct {
+	grants []fileGrant
+}
+
+// NewFilesystemGuard builds a FilesystemGuard from a plugin's declared filesystem capabilities. It returns an error
+// if a grant uses a relative path or an unknown permission.
+func NewFilesystemGuard(caps []FileSystemCapability) (*FilesystemGuard, error) {
+	g := &FilesystemGuard{grants: make([]fileGrant, 0, len(caps))}
+	var errs []error
+	for _, c := range caps {
+		if !filepath.IsAbs(c.Path) {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrRelativePath, c.Path))
+			continue
+		}
+		for _, perm := range c.Permissions {
+			if !slices.Contains(FilePermissions, perm) {
+				errs = append(errs, fmt.Errorf("%w: %q for %q", ErrUnknownPermission, perm, c.Path))
+			}
+		}
+		g.grants = append(g.grants, fileGrant{
+			path:        resolvePath(c.Path),
+			permissions: c.Permissions,
+			recursive:   c.Recursive,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Check returns nil if a grant allows perm on path, or an error wrapping ErrAccessDenied otherwise.
+func (g *FilesystemGuard) Check(path string, perm string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%w: %w: %q", ErrAccessDenied, ErrRelativePath, path)
+	}
+	resolved := resolvePath(path)
+	for _, grant := range g.grants {
+		if grant.covers(resolved) && slices.Contains(grant.permissions, perm) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s %q", ErrAccessDenied, perm, path)
+}
+
+// covers reports whether the grant applies to the resolved path.
+func (fg fileGrant) covers(path string) bool {
+	if path == fg.path {
+		return true
+	}
+	rel, err := filepath.Rel(fg.path, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return fg.recursive || !strings.Contains(rel, string(filepath.Separator))
+}
+
+// resolvePath cleans path and resolves symlinks in its longest existing prefix, so paths that do not exist yet,
+// such as a file about to be created, are still resolved through their parent directories.
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolvePath(parent), filepath.Base(path))
+}
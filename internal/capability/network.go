@@ -0,0 +1,97 @@
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// ProtocolTCP and ProtocolUDP are the network protocols a manifest may declare.
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+)
+
+// NetworkProtocols lists the network protocols a manifest may declare.
+var NetworkProtocols = []string{ProtocolTCP, ProtocolUDP}
+
+// WildcardHosts lists egress host values that allow any destination.
+var WildcardHosts = []string{"*", "0.0.0.0", "::"}
+
+var (
+	// ErrEgressDenied indicates that no egress rule allows a connection to the destination.
+	ErrEgressDenied = errors.New("network egress denied")
+	// ErrUnknownProtocol indicates that a network rule or request uses a protocol not in NetworkProtocols.
+	ErrUnknownProtocol = errors.New("unknown network protocol")
+)
+
+// NetworkGuard enforces a plugin's declared egress rules. A destination is allowed when a rule for its protocol
+// lists its port and a matching host: the exact hostname or IP, an IP inside a listed CIDR, a subdomain of a
+// "*.example.com" entry, or anything for a wildcard host. Hostnames are not resolved, so a plugin granted a hostname
+// must dial it by name. Anything not granted is denied.
+type NetworkGuard struct {
+	egress []EgressRule
+}
+
+// NewNetworkGuard builds a NetworkGuard from a plugin's declared network capability, which may be nil to deny all
+// egress. It returns an error if a rule uses an unknown protocol.
+func NewNetworkGuard(c *NetworkCapability) (*NetworkGuard, error) {
+	g := &NetworkGuard{}
+	if c == nil {
+		return g, nil
+	}
+	var errs []error
+	for _, rule := range c.Egress {
+		if !slices.Contains(NetworkProtocols, strings.ToLower(rule.Protocol)) {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownProtocol, rule.Protocol))
+			continue
+		}
+		g.egress = append(g.egress, rule)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// CheckEgress returns nil if an egress rule allows connecting to host:port over protocol, or an error wrapping
+// ErrEgressDenied otherwise.
+func (g *NetworkGuard) CheckEgress(protocol string, host string, port int) error {
+	protocol = strings.ToLower(protocol)
+	if !slices.Contains(NetworkProtocols, protocol) {
+		return fmt.Errorf("%w: %w: %q", ErrEgressDenied, ErrUnknownProtocol, protocol)
+	}
+	for _, rule := range g.egress {
+		if strings.ToLower(rule.Protocol) != protocol || !slices.Contains(rule.Ports, port) {
+			continue
+		}
+		for _, allowed := range rule.Hosts {
+			if hostMatches(allowed, host) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s %s", ErrEgressDenied, protocol, net.JoinHostPort(host, fmt.Sprint(port)))
+}
+
+// hostMatches reports whether host is covered by the allowed host entry.
+func hostMatches(allowed, host string) bool {
+	if slices.Contains(WildcardHosts, allowed) {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	allowed = strings.ToLower(strings.TrimSuffix(allowed, "."))
+	if ip := net.ParseIP(host); ip != nil {
+		if _, cidr, err := net.ParseCIDR(allowed); err == nil {
+			return cidr.Contains(ip)
+		}
+		allowedIP := net.ParseIP(allowed)
+		return allowedIP != nil && allowedIP.Equal(ip)
+	}
+	if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == allowed
+}
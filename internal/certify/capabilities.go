@@ -0,0 +1,93 @@
+package certify
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+)
+
+// knownPermissions lists the filesystem permissions a manifest may request.
+var knownPermissions = capability.FilePermissions
+
+// writePermissions lists the filesystem permissions that modify the host.
+var writePermissions = []string{capability.PermWrite, capability.PermCreate, capability.PermDelete}
+
+// knownProtocols lists the network protocols a manifest may declare.
+var knownProtocols = capability.NetworkProtocols
+
+// wildcardHosts lists egress host values that grant unrestricted outbound access.
+var wildcardHosts = capability.WildcardHosts
+
+// capabilityBoundaries checks the plugin's declared capabilities, failing on malformed declarations and warning on
+// declarations broad enough to defeat the capability boundary. It reports whether the declarations are valid.
+func capabilityBoundaries(report *Report, m *registry.Manifest) bool {
+	var failures, warnings []string
+
+	for _, fsCap := range m.Capabilities.Filesystem {
+		if !filepath.IsAbs(fsCap.Path) {
+			failures = append(failures, fmt.Sprintf("filesystem path %q is not absolute", fsCap.Path))
+		}
+		for _, perm := range fsCap.Permissions {
+			if !slices.Contains(knownPermissions, perm) {
+				failures = append(failures, fmt.Sprintf("filesystem path %q requests unknown permission %q",
+					fsCap.Path, perm))
+			}
+			if filepath.Clean(fsCap.Path) == "/" && slices.Contains(writePermissions, perm) {
+				warnings = append(warnings, fmt.Sprintf("filesystem root requests %q", perm))
+			}
+		}
+	}
+
+	if network := m.Capabilities.Network; network != nil {
+		for _, rule := range network.Egress {
+			if !slices.Contains(knownProtocols, strings.ToLower(rule.Protocol)) {
+				failures = append(failures, fmt.Sprintf("egress protocol %q is not supported", rule.Protocol))
+			}
+			for _, host := range rule.Hosts {
+				if slices.Contains(wildcardHosts, host) {
+					warnings = append(warnings, fmt.Sprintf("egress host %q allows any destination", host))
+				}
+			}
+			failures = append(failures, invalidPorts("egress", rule.Ports)...)
+		}
+		for _, rule := range network.Ingress {
+			if !slices.Contains(knownProtocols, strings.ToLower(rule.Protocol)) {
+				failures = append(failures, fmt.Sprintf("ingress protocol %q is not supported", rule.Protocol))
+			}
+			failures = append(failures, invalidPorts("ingress", rule.Ports)...)
+		}
+	}
+
+	if process := m.Capabilities.Process; process != nil {
+		for _, rule := range process.Exec {
+			if !filepath.IsAbs(rule.Command) {
+				failures = append(failures, fmt.Sprintf("exec command %q is not absolute", rule.Command))
+			}
+		}
+	}
+
+	switch {
+	case len(failures) > 0:
+		report.add("capability boundaries", StatusFail, strings.Join(append(failures, warnings...), "; "))
+	case len(warnings) > 0:
+		report.add("capability boundaries", StatusWarn, strings.Join(warnings, "; "))
+	default:
+		report.add("capability boundaries", StatusPass, "")
+	}
+	return len(failures) == 0
+}
+
+// invalidPorts returns a finding for every port outside the valid TCP/UDP range.
+func invalidPorts(direction string, ports []int) []string {
+	var findings []string
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			findings = append(findings, fmt.Sprintf("%s port %d is out of range", direction, port))
+		}
+	}
+	return findings
+}
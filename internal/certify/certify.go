@@ -0,0 +1,425 @@
+// Package certify runs the plugin conformance and certification suite, producing a pass/fail report plugin authors
+// can attach to their releases.
+package certify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/checksum"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/animal"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/bmj2728/PlugsConc/shared/pkg/metricsink"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// DefaultStartTimeout is the time allowed for a candidate plugin to complete its handshake.
+// DefaultStreamTimeout is the time a job source is given to stream its first job request.
+const (
+	DefaultStartTimeout  = 10 * time.Second
+	DefaultStreamTimeout = 5 * time.Second
+)
+
+// launchChecks are the checks that launch the plugin, skipped when its binary cannot be trusted.
+var launchChecks = []string{"sandbox", "handshake", "ping", "dispense", "interface conformance"}
+
+// conformanceJobType is the only job type a job source is allowed to request during its conformance check.
+const conformanceJobType = "certify"
+
+// errStopStream ends a job source's stream once its first job request has been received.
+var errStopStream = errors.New("conformance check complete")
+
+var (
+	// ErrNoConformance indicates that no conformance check is registered for the plugin's type.
+	ErrNoConformance = errors.New("no conformance check registered for plugin type")
+	// ErrWrongInterface indicates that the dispensed plugin does not implement the interface for its type.
+	ErrWrongInterface = errors.New("dispensed plugin does not implement the expected interface")
+	// ErrEmptyResponse indicates that a conformance call returned an empty response.
+	ErrEmptyResponse = errors.New("plugin returned an empty response")
+	// ErrNonConformant indicates that a conformance call returned a response that violates the interface contract.
+	ErrNonConformant = errors.New("plugin response violates the interface contract")
+)
+
+// Status is the outcome of a single certification check.
+type Status string
+
+// StatusPass indicates the check succeeded.
+// StatusWarn indicates the check succeeded with findings the author should review.
+// StatusFail indicates the check failed and the plugin cannot be certified.
+// StatusSkip indicates the check was not run because an earlier check failed.
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Check records the outcome of one step of the certification suite.
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the result of certifying a plugin directory.
+type Report struct {
+	Dir        string        `json:"dir"`
+	PluginName string        `json:"plugin_name"`
+	Version    string        `json:"version"`
+	Type       string        `json:"type"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Checks     []Check       `json:"checks"`
+}
+
+// Passed reports whether no check in the report failed.
+func (r *Report) Passed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the report as human-readable text.
+func (r *Report) String() string {
+	var b strings.Builder
+	result := "PASS"
+	if !r.Passed() {
+		result = "FAIL"
+	}
+	_, _ = fmt.Fprintf(&b, "certification %s: %s %s (%s) in %s\n", result, r.PluginName, r.Version, r.Type,
+		r.Duration.Round(time.Millisecond))
+	for _, c := range r.Checks {
+		_, _ = fmt.Fprintf(&b, "  [%-4s] %s", c.Status, c.Name)
+		if c.Detail != "" {
+			_, _ = fmt.Fprintf(&b, ": %s", c.Detail)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// JSON returns the report encoded as indented JSON for attaching to a release.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// add appends a check to the report.
+func (r *Report) add(name string, status Status, detail string) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: detail})
+}
+
+// skip appends the named checks to the report as skipped for reason.
+func (r *Report) skip(reason string, names ...string) {
+	for _, name := range names {
+		r.add(name, StatusSkip, reason)
+	}
+}
+
+// Conformance verifies that a dispensed plugin implements and correctly serves the interface for its type.
+type Conformance func(raw any) error
+
+var (
+	conformanceMu sync.RWMutex
+	// conformanceChecks maps plugin type names to the conformance check for that type.
+	conformanceChecks = map[string]Conformance{
+		"animal":       animalConformance,
+		"animal-grpc":  animalConformance,
+		"logsink":      logSinkConformance,
+		"metricsink":   metricSinkConformance,
+		"authprovider": authProviderConformance,
+		"jobsource":    jobSourceConformance,
+	}
+)
+
+// RegisterConformance registers the conformance check for a plugin type, replacing any existing check.
+func RegisterConformance(pluginType string, check Conformance) {
+	conformanceMu.Lock()
+	defer conformanceMu.Unlock()
+	conformanceChecks[pluginType] = check
+}
+
+// conformanceFor returns the conformance check registered for the plugin type.
+func conformanceFor(pluginType string) (Conformance, bool) {
+	conformanceMu.RLock()
+	defer conformanceMu.RUnlock()
+	c, ok := conformanceChecks[pluginType]
+	return c, ok
+}
+
+// animalConformance verifies the animal.Animal contract.
+func animalConformance(raw any) error {
+	a, ok := raw.(animal.Animal)
+	if !ok {
+		return ErrWrongInterface
+	}
+	for _, loud := range []bool{false, true} {
+		if a.Speak(loud) == "" {
+			return fmt.Errorf("%w: Speak(%t)", ErrEmptyResponse, loud)
+		}
+	}
+	return nil
+}
+
+// logSinkConformance verifies the logsink.LogSink contract by shipping a single record.
+func logSinkConformance(raw any) error {
+	s, ok := raw.(logsink.LogSink)
+	if !ok {
+		return ErrWrongInterface
+	}
+	return s.Write([]logsink.Record{{
+		Time:    time.Now(),
+		Level:   hclog.Info.String(),
+		Logger:  "certify",
+		Message: "Conformance check",
+	}})
+}
+
+// metricSinkConformance verifies the metricsink.MetricSink contract by exporting an empty snapshot.
+func metricSinkConformance(raw any) error {
+	s, ok := raw.(metricsink.MetricSink)
+	if !ok {
+		return ErrWrongInterface
+	}
+	return s.Export(metricsink.Snapshot{Time: time.Now(), Host: "certify"})
+}
+
+// authProviderConformance verifies the authprovider.AuthProvider contract by checking that a request without a token
+// is denied.
+func authProviderConformance(raw any) error {
+	a, ok := raw.(authprovider.AuthProvider)
+	if !ok {
+		return ErrWrongInterface
+	}
+	res, err := a.Authenticate(authprovider.Request{Method: "GET", Path: "/debug/state"})
+	if err != nil {
+		return err
+	}
+	if res.Allowed {
+		return fmt.Errorf("%w: request without a token was allowed", ErrNonConformant)
+	}
+	return nil
+}
+
+// jobSourceConformance verifies the jobsource.JobSource contract by streaming until the first job request or the
+// stream timeout, checking that the source only requests the job types it was given and stops when the host does.
+func jobSourceConformance(raw any) error {
+	s, ok := raw.(jobsource.JobSource)
+	if !ok {
+		return ErrWrongInterface
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultStreamTimeout)
+	defer cancel()
+	var requests []jobsource.Request
+	err := s.Stream(ctx, []string{conformanceJobType}, func(req jobsource.Request) error {
+		requests = append(requests, req)
+		return errStopStream
+	})
+	if err != nil && !errors.Is(err, errStopStream) && ctx.Err() == nil {
+		return err
+	}
+	for _, req := range requests {
+		if req.Type != conformanceJobType {
+			return fmt.Errorf("%w: requested job type %q", ErrNonConformant, req.Type)
+		}
+	}
+	return nil
+}
+
+// Certify runs the certification suite against the plugin in dir: manifest and launch detail validation,
+// checksum verification, a sandboxed launch and handshake, interface conformance for the plugin's type,
+// capability boundary checks on the declared capabilities, and probes of the host services guarding them. A plugin
+// whose binary fails checksum verification is never launched; the launch checks are skipped instead.
+func Certify(dir string, certLogger hclog.Logger) *Report {
+	if certLogger == nil {
+		certLogger = hclog.Default()
+	}
+	report := &Report{Dir: dir, StartedAt: time.Now()}
+	defer func() { report.Duration = time.Since(report.StartedAt) }()
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		report.add("manifest", StatusFail, err.Error())
+		return report
+	}
+	report.Dir = absDir
+
+	m, entrypoint, _, err := registry.LoadPluginManifest(absDir)
+	if err != nil {
+		report.add("manifest", StatusFail, err.Error())
+		return report
+	}
+	report.PluginName = m.PluginData.Name
+	report.Version = m.PluginData.Version
+	report.Type = m.PluginData.Type
+	if errs := registry.ValidateManifest(m); len(errs) > 0 {
+		report.add("manifest", StatusFail, errors.Join(errs...).Error())
+		return report
+	}
+	report.add("manifest", StatusPass, "")
+
+	ld := m.ToLaunchDetails()
+	if ld == nil || len(ld.AllowedProtocols) == 0 {
+		report.add("launch details", StatusFail, "invalid handshake or plugin format")
+		return report
+	}
+	report.add("launch details", StatusPass, "")
+
+	secConf := verifyChecksum(report, absDir)
+	verifyProvenance(report, absDir, m, entrypoint)
+
+	pluginType := registry.AvailablePluginTypes.GetByString(m.PluginData.Type)
+	if pluginType == nil {
+		report.add("plugin type", StatusFail, fmt.Sprintf("unknown plugin type %q", m.PluginData.Type))
+		return report
+	}
+	report.add("plugin type", StatusPass, "")
+
+	if secConf == nil {
+		report.skip("checksum failed", launchChecks...)
+	} else {
+		raw, cleanup := launch(report, certLogger.Named(m.PluginData.Name), ld, entrypoint, pluginType, secConf)
+		defer cleanup()
+		if raw != nil {
+			conformance(report, m.PluginData.Type, raw)
+		}
+	}
+
+	if capabilityBoundaries(report, m) {
+		boundaryProbes(report, m, certLogger.Named("probe"))
+	} else {
+		report.skip("capability declarations are invalid", "capability probes")
+	}
+	return report
+}
+
+// verifyChecksum checks the plugin's checksum file against its binary, returning a SecureConfig when valid.
+func verifyChecksum(report *Report, dir string) *plugin.SecureConfig {
+	cs, err := checksum.NewFile(dir)
+	if err == nil {
+		err = cs.Parse()
+	}
+	if err != nil {
+		report.add("checksum", StatusFail, err.Error())
+		return nil
+	}
+	if !cs.Compare() {
+		report.add("checksum", StatusFail, "binary does not match "+cs.CSFileName())
+		return nil
+	}
+	secConf, err := cs.SecConf()
+	if err != nil {
+		report.add("checksum", StatusFail, err.Error())
+		return nil
+	}
+	report.add("checksum", StatusPass, "")
+	return secConf
+}
+
+// verifyProvenance checks the plugin's provenance file, if it has one, against its manifest and binary.
+func verifyProvenance(report *Report, dir string, m *registry.Manifest, entrypoint string) {
+	prov, err := registry.LoadProvenance(dir)
+	if err == nil && prov != nil {
+		err = errors.Join(prov.Validate(m, entrypoint)...)
+	}
+	switch {
+	case err != nil:
+		report.add("provenance", StatusFail, err.Error())
+	case prov == nil:
+		report.add("provenance", StatusWarn, "no "+registry.ProvenanceFileName)
+	default:
+		report.add("provenance", StatusPass, fmt.Sprintf("built by %s from %s@%s", prov.Builder, prov.SourceRepo,
+			prov.Commit))
+	}
+}
+
+// launch starts the plugin in a sandbox without the host environment, working in a throwaway directory,
+// then completes the handshake, pings the connection, and dispenses the plugin.
+// The returned cleanup function kills the plugin and removes the sandbox.
+func launch(report *Report,
+	launchLogger hclog.Logger,
+	ld *registry.PluginLaunchDetails,
+	entrypoint string,
+	pluginType plugin.Plugin,
+	secConf *plugin.SecureConfig) (any, func()) {
+	sandbox, err := os.MkdirTemp("", "plugsconc-certify-")
+	if err != nil {
+		report.add("sandbox", StatusFail, err.Error())
+		return nil, func() {}
+	}
+	report.add("sandbox", StatusPass, sandbox)
+
+	cmd := exec.Command(entrypoint)
+	cmd.Dir = sandbox
+	cmd.Env = []string{"HOME=" + sandbox, "TMPDIR=" + sandbox}
+
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  *ld.HandshakeConfig,
+		Plugins:          map[string]plugin.Plugin{ld.PluginName: pluginType},
+		Cmd:              cmd,
+		AllowedProtocols: ld.AllowedProtocols,
+		AutoMTLS:         ld.AutoMTLS,
+		SecureConfig:     secConf,
+		Logger:           launchLogger,
+		StartTimeout:     DefaultStartTimeout,
+		SkipHostEnv:      true,
+		UnixSocketConfig: &plugin.UnixSocketConfig{TempDir: sandbox},
+	})
+	cleanup := func() {
+		client.Kill()
+		if err := os.RemoveAll(sandbox); err != nil {
+			launchLogger.Error("Failed to remove certification sandbox", logger.KeyError, err)
+		}
+	}
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		report.add("handshake", StatusFail, err.Error())
+		return nil, cleanup
+	}
+	report.add("handshake", StatusPass, fmt.Sprintf("protocol %s, version %d",
+		client.Protocol(), client.NegotiatedVersion()))
+
+	if err := rpcClient.Ping(); err != nil {
+		report.add("ping", StatusFail, err.Error())
+		return nil, cleanup
+	}
+	report.add("ping", StatusPass, "")
+
+	raw, err := rpcClient.Dispense(ld.PluginName)
+	if err != nil {
+		report.add("dispense", StatusFail, err.Error())
+		return nil, cleanup
+	}
+	report.add("dispense", StatusPass, "")
+	return raw, cleanup
+}
+
+// conformance runs the registered conformance check for the plugin type against the dispensed plugin.
+func conformance(report *Report, pluginType string, raw any) {
+	check, ok := conformanceFor(pluginType)
+	if !ok {
+		report.add("interface conformance", StatusFail, fmt.Sprintf("%s: %q", ErrNoConformance, pluginType))
+		return
+	}
+	if err := check(raw); err != nil {
+		report.add("interface conformance", StatusFail, err.Error())
+		return
+	}
+	report.add("interface conformance", StatusPass, "")
+}
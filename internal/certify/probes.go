@@ -0,0 +1,160 @@
+package certify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/ngfs"
+	"github.com/bmj2728/PlugsConc/shared/pkg/ngnet"
+	filesystemv1 "github.com/bmj2728/PlugsConc/shared/protogen/filesystem/v1"
+	networkv1 "github.com/bmj2728/PlugsConc/shared/protogen/network/v1"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultProbeTimeout bounds all the capability probes of one plugin.
+const DefaultProbeTimeout = 5 * time.Second
+
+// probe is an operation the plugin's capabilities do not allow, which the host services must deny.
+type probe struct {
+	desc string
+	run  func(ctx context.Context) error
+}
+
+// boundaryProbes serves the host filesystem and network services the plugin would be given, guarded by its declared
+// capabilities, on a loopback gRPC server, and calls them with operations those capabilities do not allow: listing
+// and stating a directory outside every grant, listing or stating a granted path without that permission, and
+// dialing an undeclared destination. The check fails if any of them is not denied with codes.PermissionDenied and
+// warns when the grants are broad enough that a probe has nothing left to deny.
+func boundaryProbes(report *Report, m *registry.Manifest, probeLogger hclog.Logger) {
+	caps := m.Capabilities
+	fsGuard, fsErr := capability.NewFilesystemGuard(caps.Filesystem)
+	netGuard, netErr := capability.NewNetworkGuard(caps.Network)
+	fsService, fsSvcErr := ngfs.NewGuardedNGFS(caps.Filesystem)
+	netService, netSvcErr := ngnet.NewNGNet(m.PluginData.Name, caps.Network, probeLogger.Named("ngnet"))
+	if err := errors.Join(fsErr, netErr, fsSvcErr, netSvcErr); err != nil {
+		report.add("capability probes", StatusSkip, err.Error())
+		return
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		report.add("capability probes", StatusFail, err.Error())
+		return
+	}
+	server := grpc.NewServer()
+	filesystemv1.RegisterFileSystemServer(server, fsService.WithLogger(probeLogger.Named("ngfs")))
+	networkv1.RegisterNetworkServer(server, netService)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		report.add("capability probes", StatusFail, err.Error())
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	fsClient := filesystemv1.NewFileSystemClient(conn)
+	dialer := ngnet.NewDialer(networkv1.NewNetworkClient(conn))
+
+	// a directory of its own and a port nobody declared are outside the capabilities of any sane manifest
+	outside, err := os.MkdirTemp("", "plugsconc-certify-probe-")
+	if err != nil {
+		report.add("capability probes", StatusFail, err.Error())
+		return
+	}
+	defer func() {
+		if err := os.RemoveAll(outside); err != nil {
+			probeLogger.Error("Failed to remove probe directory", logger.KeyError, err)
+		}
+	}()
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		report.add("capability probes", StatusFail, err.Error())
+		return
+	}
+	defer func() { _ = target.Close() }()
+	targetPort := target.Addr().(*net.TCPAddr).Port
+
+	var probes []probe
+	var warnings []string
+	readDir := func(path string) probe {
+		return probe{desc: "list " + path, run: func(ctx context.Context) error {
+			_, err := fsClient.ReadDir(ctx, &filesystemv1.ReadDirRequest{Path: path})
+			return err
+		}}
+	}
+	stat := func(path string) probe {
+		return probe{desc: "read " + path, run: func(ctx context.Context) error {
+			_, err := fsClient.Stat(ctx, &filesystemv1.StatRequest{Path: path})
+			return err
+		}}
+	}
+	if fsGuard.Check(outside, capability.PermList) != nil {
+		probes = append(probes, readDir(outside))
+	} else {
+		warnings = append(warnings, "filesystem grants allow listing "+outside)
+	}
+	if fsGuard.Check(filepath.Join(outside, "probe"), capability.PermRead) != nil {
+		probes = append(probes, stat(filepath.Join(outside, "probe")))
+	} else {
+		warnings = append(warnings, "filesystem grants allow reading "+outside)
+	}
+	for _, grant := range caps.Filesystem {
+		if !slices.Contains(grant.Permissions, capability.PermList) &&
+			fsGuard.Check(grant.Path, capability.PermList) != nil {
+			probes = append(probes, readDir(grant.Path))
+		}
+		if !slices.Contains(grant.Permissions, capability.PermRead) &&
+			fsGuard.Check(grant.Path, capability.PermRead) != nil {
+			probes = append(probes, stat(grant.Path))
+		}
+	}
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(targetPort))
+	if netGuard.CheckEgress("tcp", "127.0.0.1", targetPort) != nil {
+		probes = append(probes, probe{desc: "dial tcp " + address, run: func(ctx context.Context) error {
+			c, err := dialer.Dial(ctx, "tcp", address)
+			if err == nil {
+				_ = c.Close()
+			}
+			return err
+		}})
+	} else {
+		warnings = append(warnings, "egress rules allow "+address)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultProbeTimeout)
+	defer cancel()
+	var failures []string
+	for _, p := range probes {
+		err := p.run(ctx)
+		switch {
+		case err == nil:
+			failures = append(failures, p.desc+" was allowed")
+		case status.Code(err) != codes.PermissionDenied:
+			failures = append(failures, fmt.Sprintf("%s failed instead of being denied: %v", p.desc, err))
+		}
+	}
+
+	switch {
+	case len(failures) > 0:
+		report.add("capability probes", StatusFail, strings.Join(append(failures, warnings...), "; "))
+	case len(warnings) > 0:
+		report.add("capability probes", StatusWarn, strings.Join(warnings, "; "))
+	default:
+		report.add("capability probes", StatusPass, fmt.Sprintf("%d disallowed operations denied", len(probes)))
+	}
+}
@@ -0,0 +1,281 @@
+package checksum
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Algorithm names a hash function a checksum file can be written with. It is both the extension of the checksum
+// file and the prefix that may precede the hash inside it.
+type Algorithm string
+
+// SHA256 is the SHA-256 hash function.
+// SHA512 is the SHA-512 hash function.
+// BLAKE2b is the 512-bit BLAKE2b hash function.
+const (
+	SHA256  Algorithm = "sha256"
+	SHA512  Algorithm = "sha512"
+	BLAKE2b Algorithm = "blake2b"
+)
+
+// CSFileBase is the name of a checksum file without its extension.
+// CSFileExt is the extension of the default, SHA-256, checksum file.
+const (
+	CSFileBase = "plugin"
+	CSFileExt  = string(SHA256)
+)
+
+// Algorithms lists the supported algorithms, strongest first; when a plugin directory holds more than one checksum
+// file, the first one found in this order is used.
+var Algorithms = []Algorithm{BLAKE2b, SHA512, SHA256}
+
+// ErrUnsupportedAlgorithm indicates that a checksum file names a hash function that is not supported.
+// ErrChecksumMismatch indicates that a plugin binary does not match its checksum file.
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported checksum algorithm")
+	ErrChecksumMismatch     = errors.New("plugin binary does not match checksum")
+)
+
+// ParseAlgorithm returns the Algorithm named by s, ignoring case.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	a := Algorithm(strings.ToLower(s))
+	switch a {
+	case SHA256, SHA512, BLAKE2b:
+		return a, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, s)
+}
+
+// FileName returns the name of the checksum file written with the algorithm, e.g. "plugin.sha512".
+func (a Algorithm) FileName() string {
+	return CSFileBase + "." + string(a)
+}
+
+// New returns a new hash.Hash computing the algorithm.
+func (a Algorithm) New() hash.Hash {
+	switch a {
+	case SHA512:
+		return sha512.New()
+	case BLAKE2b:
+		// New512 only fails for keys longer than 64 bytes
+		h, _ := blake2b.New512(nil)
+		return h
+	default:
+		return sha256.New()
+	}
+}
+
+// File represents a checksum file in a plugin directory, written with any of the supported algorithms. The algorithm
+// is detected from the file's extension, and may be overridden by an "<algorithm>:" prefix on the hash.
+type File struct {
+	path      string
+	algorithm Algorithm
+	csFile    string
+	hexHash   string
+	fileName  string
+}
+
+// NewFile creates a new File for the checksum file in the given directory path.
+// Returns an error if the path is empty or invalid.
+func NewFile(dir string) (*File, error) {
+	if dir == "" {
+		return nil, ErrInvalidChecksumPath
+	}
+	aPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, ErrInvalidChecksumPath
+	}
+	return &File{path: aPath}, nil
+}
+
+// Path returns the directory holding the checksum file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Algorithm returns the algorithm the checksum was written with, empty until the file is parsed.
+func (f *File) Algorithm() Algorithm {
+	return f.algorithm
+}
+
+// CSFileName returns the name of the checksum file that was parsed, empty until the file is parsed.
+func (f *File) CSFileName() string {
+	return f.csFile
+}
+
+// Hash returns the hexadecimal hash read from the checksum file.
+func (f *File) Hash() string {
+	return f.hexHash
+}
+
+// FileName returns the name of the file the checksum is for.
+func (f *File) FileName() string {
+	return f.fileName
+}
+
+// Parse finds the checksum file in the directory, reads and validates it, and records its algorithm, hash, and file
+// name. Returns an error if no checksum file can be read or its contents are invalid.
+func (f *File) Parse() error {
+	r, err := os.OpenRoot(f.path)
+	if err != nil {
+		err = errors.Join(ErrInvalidChecksumPath, err)
+		hclog.Default().Error("Failed to open checksum file", logger.KeyError, err)
+		return err
+	}
+	defer func(r *os.Root) {
+		if err := r.Close(); err != nil {
+			hclog.Default().Error("Failed to close checksum file", logger.KeyError, err)
+		}
+	}(r)
+
+	var fileBytes []byte
+	for _, a := range Algorithms {
+		fileBytes, err = fs.ReadFile(r.FS(), a.FileName())
+		if err == nil {
+			f.algorithm, f.csFile = a, a.FileName()
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		err := errors.Join(ErrInvalidChecksum, err)
+		hclog.Default().Error("Failed to read checksum file", logger.KeyError, err)
+		return err
+	}
+	return f.parse(fileBytes)
+}
+
+// parse validates the contents of the checksum file, written with f's algorithm, and records its hash and file name.
+func (f *File) parse(fileBytes []byte) error {
+	rawFields := strings.Fields(string(fileBytes))
+	if len(rawFields) != 2 {
+		err := fmt.Errorf("%w: %s must hold a hash and a file name", ErrInvalidChecksum, f.csFile)
+		hclog.Default().Error("Failed to parse checksum file", logger.KeyError, err)
+		return err
+	}
+	hexHash := rawFields[0]
+	if prefix, rest, ok := strings.Cut(hexHash, ":"); ok {
+		a, err := ParseAlgorithm(prefix)
+		if err != nil {
+			hclog.Default().Error("Failed to parse checksum file", logger.KeyError, err)
+			return errors.Join(ErrInvalidChecksum, err)
+		}
+		f.algorithm, hexHash = a, rest
+	}
+	if decoded, err := hex.DecodeString(hexHash); err != nil || len(decoded) != f.algorithm.New().Size() {
+		err := fmt.Errorf("%w: %s does not hold a %s hash", ErrInvalidChecksum, f.csFile, f.algorithm)
+		hclog.Default().Error("Failed to parse checksum file", logger.KeyError, err)
+		return err
+	}
+
+	f.hexHash = strings.ToLower(hexHash)
+	f.fileName = rawFields[1]
+	return nil
+}
+
+// SecConf generates a SecureConfig with the checksum and the hash implementation of its algorithm if the checksum is
+// valid, otherwise returns an error.
+func (f *File) SecConf() (*plugin.SecureConfig, error) {
+	if f.hexHash == "" {
+		return nil, ErrInvalidChecksum
+	}
+	checksumBytes, err := hex.DecodeString(f.hexHash)
+	if err != nil {
+		err := errors.Join(ErrInvalidChecksum, err)
+		hclog.Default().Error("Failed to parse checksum file", logger.KeyError, err)
+		return nil, err
+	}
+	return &plugin.SecureConfig{
+		Checksum: checksumBytes,
+		Hash:     f.algorithm.New(),
+	}, nil
+}
+
+// Compare reports whether the file the checksum is for matches the checksum.
+func (f *File) Compare() bool {
+	r, err := os.OpenRoot(f.path)
+	if err != nil {
+		err = errors.Join(ErrInvalidChecksumPath, err)
+		hclog.Default().Error("Failed to open checksum file", logger.KeyError, err)
+		return false
+	}
+	defer func(r *os.Root) {
+		if err := r.Close(); err != nil {
+			hclog.Default().Error("Failed to close checksum file", logger.KeyError, err)
+		}
+	}(r)
+
+	file, err := r.Open(f.FileName())
+	if err != nil {
+		err := errors.Join(ErrInvalidChecksum, err)
+		hclog.Default().Error("Failed to read checksummed file", logger.KeyError, err)
+		return false
+	}
+	defer func() { _ = file.Close() }()
+	sum, err := f.sum(file)
+	if err != nil {
+		hclog.Default().Error("Failed to read checksummed file", logger.KeyError, err)
+		return false
+	}
+	return f.hexHash == sum
+}
+
+// sum returns the hexadecimal hash of r's contents computed with f's algorithm.
+func (f *File) sum(r io.Reader) (string, error) {
+	h := f.algorithm.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyBinary checks the plugin binary at binaryPath against the checksum file at csFile, whose algorithm is taken
+// from its extension unless the hash carries an "<algorithm>:" prefix. It returns ErrChecksumMismatch if the checksum
+// names a different file or the binary's hash differs, and ErrInvalidChecksum if the checksum file cannot be read.
+func VerifyBinary(csFile, binaryPath string) error {
+	algorithm, err := ParseAlgorithm(strings.TrimPrefix(filepath.Ext(csFile), "."))
+	if err != nil {
+		return errors.Join(ErrInvalidChecksum, err)
+	}
+	fileBytes, err := os.ReadFile(csFile)
+	if err != nil {
+		return errors.Join(ErrInvalidChecksum, err)
+	}
+	f := &File{path: filepath.Dir(csFile), algorithm: algorithm, csFile: filepath.Base(csFile)}
+	if err := f.parse(fileBytes); err != nil {
+		return err
+	}
+	if f.fileName != filepath.Base(binaryPath) {
+		return fmt.Errorf("%w: %s is for %q, not %q", ErrChecksumMismatch, f.csFile, f.fileName,
+			filepath.Base(binaryPath))
+	}
+	binary, err := os.Open(binaryPath)
+	if err != nil {
+		return errors.Join(ErrInvalidChecksum, err)
+	}
+	defer func() { _ = binary.Close() }()
+	sum, err := f.sum(binary)
+	if err != nil {
+		return errors.Join(ErrInvalidChecksum, err)
+	}
+	if sum != f.hexHash {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, binaryPath)
+	}
+	return nil
+}
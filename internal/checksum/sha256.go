@@ -28,6 +28,8 @@ var (
 )
 
 // SHA256File represents a file containing a SHA-256 checksum and an associated file name for validation purposes.
+//
+// Deprecated: use File, which also reads SHA-512 and BLAKE2b checksum files.
 type SHA256File struct {
 	path     string
 	hexHash  string
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables that override config fields.
+const EnvPrefix = "PLUGSCONC"
+
+// ErrInvalidEnv indicates that an environment variable override could not be parsed into its config field.
+var ErrInvalidEnv = errors.New("invalid environment override")
+
+// EnvError reports an environment variable whose value does not parse as the type of the field it overrides.
+type EnvError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+// Error returns the variable, its value, and the parse failure.
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("%s: %s=%q: %v", ErrInvalidEnv, e.Name, e.Value, e.Err)
+}
+
+// Unwrap returns ErrInvalidEnv so callers can match with errors.Is.
+func (e *EnvError) Unwrap() error {
+	return ErrInvalidEnv
+}
+
+// LoadWithEnv builds the config from, in increasing order of precedence, DefaultConfig, the YAML file at path, and
+// environment variable overrides, then validates the result. A missing file is not an error, so a host can be
+// configured from the environment alone.
+//
+// Every field can be overridden by a variable named EnvPrefix followed by the upper-cased YAML keys of its path,
+// joined by underscores, e.g. PLUGSCONC_LOGGING_LEVEL or PLUGSCONC_LOGGING_ALIGN_MODULE_WIDTH. Lists of values are
+// comma separated, maps are comma separated key=value pairs, and the fields of list entries already defined by the
+// file are addressed by index, e.g. PLUGSCONC_LOGGING_FILES_0_LEVEL.
+func LoadWithEnv(path string) (*Config, error) {
+	conf, err := decodeFile(path)
+	if conf == nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		conf, err = DefaultConfig(), nil
+	}
+	if err := errors.Join(err, conf.ApplyEnv(), conf.Validate()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// ApplyEnv overrides config fields with the environment variables named after them, returning an EnvError for each
+// value that does not parse.
+func (c *Config) ApplyEnv() error {
+	return applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix, os.LookupEnv)
+}
+
+// EnvNames returns the names of the environment variables that override the config's fields, in field order.
+func (c *Config) EnvNames() []string {
+	var names []string
+	_ = applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix, func(name string) (string, bool) {
+		names = append(names, name)
+		return "", false
+	})
+	return names
+}
+
+// applyEnv walks the fields of the struct v, overriding each leaf field whose variable lookup finds a value.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(key)
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			errs = append(errs, applyEnv(fv, name, lookup))
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < fv.Len(); j++ {
+				errs = append(errs, applyEnv(fv.Index(j), name+"_"+strconv.Itoa(j), lookup))
+			}
+		default:
+			value, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setFromEnv(fv, value); err != nil {
+				errs = append(errs, &EnvError{Name: name, Value: value, Err: err})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setFromEnv parses value into the field v according to its kind.
+func setFromEnv(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		items := splitList(value)
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", v.Type())
+		}
+		m := make(map[string]string)
+		for _, pair := range splitList(value) {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a key=value pair", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma-separated value, trimming spaces and dropping empty items.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,473 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/mq"
+	"github.com/bmj2728/PlugsConc/internal/semver"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/hashicorp/go-hclog"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrReadConfig indicates that the config file could not be read.
+// ErrParseConfig indicates that the config file is not valid YAML or a value has the wrong type.
+// ErrUnknownKey indicates that the config file sets a key the Config structs do not define.
+// ErrInvalidConfig indicates that a config value failed validation.
+var (
+	ErrReadConfig    = errors.New("failed to read config file")
+	ErrParseConfig   = errors.New("failed to parse config file")
+	ErrUnknownKey    = errors.New("unknown config key")
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// unknownField matches the yaml.v3 error reported for a key with no matching struct field.
+var unknownField = regexp.MustCompile(`^line (\d+): field (\S+) not found in type (\S+)$`)
+
+// UnknownKeyError reports a key in the config file that does not match any Config field, typically a typo.
+type UnknownKeyError struct {
+	Line int
+	Key  string
+	Type string
+}
+
+// Error returns the location and name of the unknown key.
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("%s: line %d: %q is not a field of %s", ErrUnknownKey, e.Line, e.Key, e.Type)
+}
+
+// Unwrap returns ErrUnknownKey so callers can match with errors.Is.
+func (e *UnknownKeyError) Unwrap() error {
+	return ErrUnknownKey
+}
+
+// ValidationError reports a config value that failed validation.
+type ValidationError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+// Error returns the field, its value, and why it is invalid.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s=%v: %s", ErrInvalidConfig, e.Field, e.Value, e.Reason)
+}
+
+// Unwrap returns ErrInvalidConfig so callers can match with errors.Is.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Load reads the YAML config file at path on top of DefaultConfig, so fields missing from the file keep their
+// default values, then validates the result. Unknown keys are reported as UnknownKeyError and invalid values as
+// ValidationError; every problem found is returned, joined.
+func Load(path string) (*Config, error) {
+	conf, err := decodeFile(path)
+	if conf == nil {
+		return nil, err
+	}
+	if err := errors.Join(err, conf.Validate()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// decodeFile reads the YAML config file at path on top of DefaultConfig. It returns a nil Config when the file
+// cannot be read or parsed at all; otherwise the Config is returned along with any unknown keys and mistyped values.
+func decodeFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(ErrReadConfig, err)
+	}
+	conf := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, errors.Join(ErrParseConfig, err)
+		}
+		// the remaining fields are still decoded, so they are validated too
+		return conf, decodeError(typeErr)
+	}
+	return conf, nil
+}
+
+// decodeError converts the problems in a yaml.v3 TypeError into UnknownKeyError values, wrapping any other problem,
+// such as a value of the wrong type, in ErrParseConfig.
+func decodeError(typeErr *yaml.TypeError) error {
+	errs := make([]error, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		m := unknownField.FindStringSubmatch(msg)
+		if m == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrParseConfig, msg))
+			continue
+		}
+		line, _ := strconv.Atoi(m[1])
+		errs = append(errs, &UnknownKeyError{Line: line, Key: m[2], Type: m[3]})
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks levels, rates, durations, and the directories config paths point into, returning every problem
+// found, joined.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field string, value any, reason string) {
+		errs = append(errs, &ValidationError{Field: field, Value: value, Reason: reason})
+	}
+	level := func(field, value string) {
+		if hclog.LevelFromString(value) == hclog.NoLevel {
+			invalid(field, value, "not a log level")
+		}
+	}
+	nonNegative := func(field string, value int) {
+		if value < 0 {
+			invalid(field, value, "must not be negative")
+		}
+	}
+	rate := func(field string, value float64) {
+		if value < 0 || value > 1 {
+			invalid(field, value, "must be between 0 and 1")
+		}
+	}
+	directory := func(field, path string) {
+		if path == "" {
+			invalid(field, path, "must not be empty")
+			return
+		}
+		dir := filepath.Dir(path)
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			invalid(field, path, dir+" is not a directory")
+		}
+	}
+
+	level("logging.level", c.Logging.Level)
+	if !slices.Contains(LogFormats, c.Logging.Format) {
+		invalid("logging.format", c.Logging.Format, "must be one of "+strings.Join(LogFormats, ", "))
+	}
+	if !slices.Contains(LogColors, c.Logging.Color) {
+		invalid("logging.color", c.Logging.Color, "must be one of "+strings.Join(LogColors, ", "))
+	}
+	for i, name := range c.Logging.StackTraces {
+		level(fmt.Sprintf("logging.stack_traces[%d]", i), name)
+	}
+	nonNegative("logging.error_summary_interval_ms", c.Logging.ErrorSummaryInterval)
+	nonNegative("logging.stack_depth", c.Logging.StackDepth)
+	nonNegative("logging.align.module_width", c.Logging.Align.ModuleWidth)
+	async := false
+	for i, f := range c.Logging.Files {
+		field := fmt.Sprintf("logging.files[%d]", i)
+		if f.Name == "" {
+			invalid(field+".name", f.Name, "must not be empty")
+		}
+		level(field+".level", f.Level)
+		directory(field+".filename", f.Filename)
+		nonNegative(field+".max_size", f.MaxSize)
+		nonNegative(field+".max_backups", f.MaxBackups)
+		nonNegative(field+".max_age", f.MaxAge)
+		async = async || f.Async
+	}
+	if !slices.Contains(mq.Backends, c.Logging.MQ.Backend) {
+		invalid("logging.mq.backend", c.Logging.MQ.Backend, "must be one of "+strings.Join(mq.Backends, ", "))
+	}
+	if async {
+		switch c.Logging.MQ.Backend {
+		case mq.BackendSQLite:
+			directory("logging.mq.file", c.Logging.MQ.File)
+		case mq.BackendRedis:
+			if c.Logging.MQ.Redis.Addr == "" {
+				invalid("logging.mq.redis.addr", c.Logging.MQ.Redis.Addr, "must not be empty")
+			}
+		}
+	}
+	nonNegative("logging.mq.redis.db", c.Logging.MQ.Redis.DB)
+	nonNegative("logging.mq.capacity", c.Logging.MQ.Capacity)
+	nonNegative("logging.async.workers", c.Logging.Async.Workers)
+	nonNegative("logging.async.batch_size", c.Logging.Async.BatchSize)
+	nonNegative("logging.async.flush_interval_ms", c.Logging.Async.FlushInterval)
+	nonNegative("logging.async.drain_timeout_ms", c.Logging.Async.DrainTimeout)
+	for i, s := range c.Logging.Sinks {
+		field := fmt.Sprintf("logging.sinks[%d]", i)
+		if s.Plugin == "" {
+			invalid(field+".plugin", s.Plugin, "must not be empty")
+		}
+		level(field+".level", s.Level)
+		nonNegative(field+".batch_size", s.BatchSize)
+		nonNegative(field+".flush_interval_ms", s.FlushInterval)
+	}
+	if pf := c.Logging.PluginFiles; pf.Enabled {
+		level("logging.plugin_files.level", pf.Level)
+		directory("logging.plugin_files.dir", pf.Dir)
+		nonNegative("logging.plugin_files.max_size", pf.MaxSize)
+		nonNegative("logging.plugin_files.max_backups", pf.MaxBackups)
+		nonNegative("logging.plugin_files.max_age", pf.MaxAge)
+	}
+
+	rate("chaos.delay_rate", c.Chaos.DelayRate)
+	rate("chaos.fail_rate", c.Chaos.FailRate)
+	rate("chaos.panic_rate", c.Chaos.PanicRate)
+	rate("chaos.kill_rate", c.Chaos.KillRate)
+	nonNegative("chaos.max_delay_ms", c.Chaos.MaxDelay)
+
+	if c.HA.Enabled {
+		directory("ha.lock_file", c.HA.LockFile)
+	}
+	nonNegative("ha.retry_interval_ms", c.HA.RetryInterval)
+
+	if !slices.Contains(storage.Backends, c.Storage.Backend) {
+		invalid("storage.backend", c.Storage.Backend, "must be one of "+strings.Join(storage.Backends, ", "))
+	}
+	if c.Storage.Backend != storage.BackendMemory && c.Storage.DataDir == "" {
+		invalid("storage.data_dir", c.Storage.DataDir, "must not be empty")
+	}
+	nonNegative("history.max_age", c.History.MaxAge)
+	nonNegative("history.max_rows", c.History.MaxRows)
+	nonNegative("history.prune_interval_ms", c.History.PruneInterval)
+
+	if c.SLA.Enabled {
+		if c.SLA.SampleInterval <= 0 {
+			invalid("sla.sample_interval_ms", c.SLA.SampleInterval, "must be positive")
+		}
+		if c.SLA.RetentionDays <= 0 {
+			invalid("sla.retention_days", c.SLA.RetentionDays, "must be positive")
+		}
+		for _, days := range c.SLA.Windows {
+			if days <= 0 || days > c.SLA.RetentionDays {
+				invalid("sla.windows", days, "must be between 1 and sla.retention_days")
+			}
+		}
+	}
+	nonNegative("sla.report_interval_ms", c.SLA.ReportInterval)
+
+	if c.Watchdog.Enabled {
+		if c.Watchdog.Threshold <= 0 {
+			invalid("watchdog.threshold_ms", c.Watchdog.Threshold, "must be positive")
+		}
+		if c.Watchdog.Interval <= 0 {
+			invalid("watchdog.interval_ms", c.Watchdog.Interval, "must be positive")
+		}
+	}
+
+	if c.Adaptive.Enabled {
+		if c.Adaptive.MinWorkers < 1 {
+			invalid("adaptive_concurrency.min_workers", c.Adaptive.MinWorkers, "must be positive")
+		}
+		if c.Adaptive.MaxWorkers < c.Adaptive.MinWorkers {
+			invalid("adaptive_concurrency.max_workers", c.Adaptive.MaxWorkers, "must not be less than min_workers")
+		}
+		if c.Adaptive.TargetLatency <= 0 {
+			invalid("adaptive_concurrency.target_latency_ms", c.Adaptive.TargetLatency, "must be positive")
+		}
+		rate("adaptive_concurrency.max_error_rate", c.Adaptive.MaxErrorRate)
+		if c.Adaptive.Interval <= 0 {
+			invalid("adaptive_concurrency.interval_ms", c.Adaptive.Interval, "must be positive")
+		}
+		if c.Adaptive.DecreaseFactor <= 0 || c.Adaptive.DecreaseFactor >= 1 {
+			invalid("adaptive_concurrency.decrease_factor", c.Adaptive.DecreaseFactor, "must be between 0 and 1")
+		}
+	}
+
+	nonNegative("results.max_bytes", c.Results.MaxBytes)
+	if !slices.Contains(ResultPolicies, c.Results.Policy) {
+		invalid("results.policy", c.Results.Policy, "must be one of "+strings.Join(ResultPolicies, ", "))
+	}
+	if c.Results.Policy == "spill" {
+		directory("results.spill_dir", c.Results.SpillDir)
+	}
+
+	if c.Memory.Enabled {
+		nonNegative("memory.soft_limit_mb", c.Memory.SoftLimit)
+		if c.Memory.Throttle && c.Memory.SoftLimit == 0 {
+			invalid("memory.soft_limit_mb", c.Memory.SoftLimit, "must be positive to throttle")
+		}
+		if c.Memory.ThrottleWorkers < 1 {
+			invalid("memory.throttle_workers", c.Memory.ThrottleWorkers, "must be positive")
+		}
+		if c.Memory.Interval <= 0 {
+			invalid("memory.interval_ms", c.Memory.Interval, "must be positive")
+		}
+	}
+
+	for name, queue := range c.Queues {
+		if !slices.Contains(QueueBackends, queue.Backend) {
+			invalid("queues."+name+".backend", queue.Backend, "must be one of "+strings.Join(QueueBackends, ", "))
+		}
+		if queue.Backend == QueuePersistent {
+			directory("queues."+name+".path", queue.Path)
+		}
+		for class, limit := range queue.Classes {
+			field := "queues." + name + ".classes." + class
+			if class == "" {
+				invalid(field, class, "class name must not be empty")
+			}
+			nonNegative(field+".max_concurrent", limit.MaxConcurrent)
+			if limit.Rate < 0 {
+				invalid(field+".rate", limit.Rate, "must not be negative")
+			}
+			nonNegative(field+".burst", limit.Burst)
+		}
+	}
+
+	if c.Plugins.Dir == "" {
+		invalid("plugins.dir", c.Plugins.Dir, "must not be empty")
+	}
+	nonNegative("plugins.reload_debounce_ms", c.Plugins.ReloadDebounce)
+	if c.Plugins.HotReload && c.Plugins.ReloadWorkers < 1 {
+		invalid("plugins.reload_workers", c.Plugins.ReloadWorkers, "must be positive")
+	}
+	if c.Plugins.ReloadRate < 0 {
+		invalid("plugins.reload_rate", c.Plugins.ReloadRate, "must not be negative")
+	}
+	if c.Plugins.TrustStore != "" {
+		if info, err := os.Stat(c.Plugins.TrustStore); err != nil || !info.IsDir() {
+			invalid("plugins.trust_store", c.Plugins.TrustStore, "must be a directory")
+		}
+	}
+	if !slices.Contains(InteractionModes, c.Plugins.Interactions.Mode) {
+		invalid("plugins.interactions.mode", c.Plugins.Interactions.Mode,
+			"must be one of "+strings.Join(InteractionModes, ", "))
+	}
+	if c.Plugins.Interactions.Mode != InteractionsOff && c.Plugins.Interactions.Dir == "" {
+		invalid("plugins.interactions.dir", c.Plugins.Interactions.Dir, "must not be empty")
+	}
+	if att := c.Plugins.Attestation; att.Enabled {
+		if u, err := url.Parse(att.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("plugins.attestation.url", att.URL, "must be an http or https URL")
+		}
+		nonNegative("plugins.attestation.timeout_ms", att.Timeout)
+		nonNegative("plugins.attestation.cache_ttl_ms", att.CacheTTL)
+		if att.CacheFile != "" {
+			directory("plugins.attestation.cache_file", att.CacheFile)
+		}
+		if !slices.Contains(AttestationFallbacks, att.Fallback) {
+			invalid("plugins.attestation.fallback", att.Fallback,
+				"must be one of "+strings.Join(AttestationFallbacks, ", "))
+		}
+	}
+	if c.Plugins.AutoRestart {
+		restart := c.Plugins.Restart
+		if restart.InitialBackoff <= 0 {
+			invalid("plugins.restart.initial_backoff_ms", restart.InitialBackoff, "must be positive")
+		}
+		if restart.MaxBackoff < restart.InitialBackoff {
+			invalid("plugins.restart.max_backoff_ms", restart.MaxBackoff, "must not be less than initial_backoff_ms")
+		}
+		if restart.MaxRestarts <= 0 {
+			invalid("plugins.restart.max_restarts", restart.MaxRestarts, "must be positive")
+		}
+		if restart.ResetAfter <= 0 {
+			invalid("plugins.restart.reset_after_ms", restart.ResetAfter, "must be positive")
+		}
+	}
+	if discovery := c.Plugins.Discovery; discovery.Degraded {
+		if discovery.InitialBackoff <= 0 {
+			invalid("plugins.discovery.initial_backoff_ms", discovery.InitialBackoff, "must be positive")
+		}
+		if discovery.MaxBackoff < discovery.InitialBackoff {
+			invalid("plugins.discovery.max_backoff_ms", discovery.MaxBackoff, "must not be less than initial_backoff_ms")
+		}
+	}
+	for _, pattern := range c.Plugins.Layout.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			invalid("plugins.layout.allow", pattern, "must be a valid file pattern")
+		}
+	}
+	if c.Plugins.Converge.Enabled && c.Plugins.Converge.Interval <= 0 {
+		invalid("plugins.converge.interval_ms", c.Plugins.Converge.Interval, "must be positive")
+	}
+	for name, version := range c.Plugins.Converge.Pins {
+		if _, err := semver.VersionFromString(version); err != nil {
+			invalid("plugins.converge.pins."+name, version, "must be a semantic version major.minor.patch")
+		}
+	}
+	for group, members := range c.Plugins.Groups {
+		if group == "" {
+			invalid("plugins.groups", group, "group names must not be empty")
+		}
+		if len(members) == 0 {
+			invalid("plugins.groups."+group, members, "must list at least one plugin")
+		}
+		if slices.Contains(members, "") {
+			invalid("plugins.groups."+group, members, "plugin names must not be empty")
+		}
+	}
+
+	if c.Janitor.Enabled {
+		if c.Janitor.MaxAge <= 0 {
+			invalid("janitor.max_age", c.Janitor.MaxAge, "must be positive")
+		}
+		if c.Janitor.Interval <= 0 {
+			invalid("janitor.interval_ms", c.Janitor.Interval, "must be positive")
+		}
+	}
+
+	if c.Admin.Enabled {
+		if _, _, err := net.SplitHostPort(c.Admin.Address); err != nil {
+			invalid("admin.address", c.Admin.Address, "must be host:port")
+		}
+	}
+	if c.REST.Enabled {
+		if _, _, err := net.SplitHostPort(c.REST.Address); err != nil {
+			invalid("rest.address", c.REST.Address, "must be host:port")
+		}
+	}
+	if c.Incident.Enabled {
+		directory("incident.dir", c.Incident.Dir)
+		if c.Incident.CPUProfile <= 0 {
+			invalid("incident.cpu_profile_ms", c.Incident.CPUProfile, "must be positive")
+		}
+		if c.Incident.Cooldown <= 0 {
+			invalid("incident.cooldown_ms", c.Incident.Cooldown, "must be positive")
+		}
+		if c.Incident.RecentLogs <= 0 {
+			invalid("incident.recent_logs", c.Incident.RecentLogs, "must be positive")
+		}
+	}
+	if c.Tracing.Enabled {
+		if !slices.Contains(TraceExporters, c.Tracing.Exporter) {
+			invalid("tracing.exporter", c.Tracing.Exporter, "must be one of "+strings.Join(TraceExporters, ", "))
+		}
+		if c.Tracing.Exporter == TraceExporterOTLP {
+			if _, _, err := net.SplitHostPort(c.Tracing.Endpoint); err != nil {
+				invalid("tracing.endpoint", c.Tracing.Endpoint, "must be host:port")
+			}
+		}
+		rate("tracing.sample_ratio", c.Tracing.SampleRatio)
+	}
+	if c.Metrics.Enabled && c.Metrics.ExportInterval <= 0 {
+		invalid("metrics.export_interval_ms", c.Metrics.ExportInterval, "must be positive")
+	}
+	if c.Agents.Enabled {
+		if _, _, err := net.SplitHostPort(c.Agents.Address); err != nil {
+			invalid("agents.address", c.Agents.Address, "must be host:port")
+		}
+		directory("agents.queue", c.Agents.Queue)
+		if c.Agents.Lease <= 0 {
+			invalid("agents.lease_ms", c.Agents.Lease, "must be positive")
+		}
+		if c.Agents.RequeueInterval <= 0 {
+			invalid("agents.requeue_interval_ms", c.Agents.RequeueInterval, "must be positive")
+		}
+		if c.Agents.MaxRequestBytes <= 0 {
+			invalid("agents.max_request_bytes", c.Agents.MaxRequestBytes, "must be positive")
+		}
+	}
+	if c.Agents.Spool != "" {
+		directory("agents.spool", c.Agents.Spool)
+	}
+	nonNegative("schedules.jitter_ms", c.Schedules.Jitter)
+	return errors.Join(errs...)
+}
@@ -1 +1,720 @@
 package config
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/bmj2728/PlugsConc/internal/mq"
+	"github.com/bmj2728/PlugsConc/internal/semver"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+)
+
+// LogFormats lists the console formats: human-readable lines or hclog's JSON records.
+// LogColors lists when human-readable console output is colored: only on a terminal, always, or never.
+var (
+	LogFormats = []string{"human", "json"}
+	LogColors  = []string{"auto", "always", "never"}
+)
+
+// Config is the root configuration for the host application, mirroring the layout of config.yaml.
+type Config struct {
+	General   General   `json:"general" yaml:"general"`
+	Logging   Logging   `json:"logging" yaml:"logging"`
+	Chaos     Chaos     `json:"chaos" yaml:"chaos"`
+	HA        HA        `json:"ha" yaml:"ha"`
+	Storage   Storage   `json:"storage" yaml:"storage"`
+	History   History   `json:"history" yaml:"history"`
+	SLA       SLA       `json:"sla" yaml:"sla"`
+	Watchdog  Watchdog  `json:"watchdog" yaml:"watchdog"`
+	Adaptive  Adaptive  `json:"adaptive_concurrency" yaml:"adaptive_concurrency"`
+	Results   Results   `json:"results" yaml:"results"`
+	Memory    Memory    `json:"memory" yaml:"memory"`
+	Queues    Queues    `json:"queues" yaml:"queues"`
+	Plugins   Plugins   `json:"plugins" yaml:"plugins"`
+	Janitor   Janitor   `json:"janitor" yaml:"janitor"`
+	Admin     Admin     `json:"admin" yaml:"admin"`
+	REST      REST      `json:"rest" yaml:"rest"`
+	Debug     Debug     `json:"debug" yaml:"debug"`
+	Incident  Incident  `json:"incident" yaml:"incident"`
+	Tracing   Tracing   `json:"tracing" yaml:"tracing"`
+	Metrics   Metrics   `json:"metrics" yaml:"metrics"`
+	Agents    Agents    `json:"agents" yaml:"agents"`
+	Schedules Schedules `json:"schedules" yaml:"schedules"`
+}
+
+// General holds the application identity settings.
+type General struct {
+	Name    string         `json:"name" yaml:"name"`
+	Mode    string         `json:"mode" yaml:"mode"`
+	Version semver.Version `json:"version" yaml:"version"`
+}
+
+// Logging holds the logging settings, including the declaratively defined file sinks.
+// Format selects human-readable or JSON console output, and Color when human-readable output is colored: auto only
+// colors it on a terminal. Colors overrides individual elements of the console Theme. ErrorSummaryInterval is how
+// often, in milliseconds, a summary of the most frequent errors is logged, 0 disabling it. StackTraces lists the
+// levels whose records carry a stack trace, of StackDepth frames, of the host code that emitted them.
+type Logging struct {
+	Level                string            `json:"level" yaml:"level"`
+	Format               string            `json:"format" yaml:"format"` // human or json
+	Color                string            `json:"color" yaml:"color"`   // auto, always, or never
+	Theme                string            `json:"theme" yaml:"theme"`   // none, dark, light, or solarized
+	Colors               map[string]string `json:"colors,omitempty" yaml:"colors,omitempty"`
+	Align                Align             `json:"align" yaml:"align"`
+	ErrorSummaryInterval int               `json:"error_summary_interval_ms" yaml:"error_summary_interval_ms"`
+	StackTraces          []string          `json:"stack_traces,omitempty" yaml:"stack_traces,omitempty"`
+	StackDepth           int               `json:"stack_depth" yaml:"stack_depth"`
+	Files                []LogFile         `json:"files,omitempty" yaml:"files,omitempty"`
+	CollectPluginOutput  bool              `json:"collect_plugin_output" yaml:"collect_plugin_output"`
+	PluginFiles          PluginLogFiles    `json:"plugin_files" yaml:"plugin_files"`
+	Async                AsyncLogging      `json:"async" yaml:"async"`
+	MQ                   LogMQ             `json:"mq" yaml:"mq"`
+	Sinks                []LogSinkPlugin   `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+}
+
+// Align configures the column-aligned console format: the logger name column is padded to ModuleWidth and the
+// PriorityKeys attributes are rendered first, in order, ahead of the remaining attributes in sorted order.
+type Align struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	ModuleWidth  int      `json:"module_width" yaml:"module_width"`
+	PriorityKeys []string `json:"priority_keys,omitempty" yaml:"priority_keys,omitempty"`
+}
+
+// LogFile defines a rotating file sink with its own minimum level filter and lumberjack rotation settings.
+// A sink receives every record at or above Level, e.g. an errors.log at warn and a debug.log at trace.
+type LogFile struct {
+	Name            string `json:"name" yaml:"name"`
+	Filename        string `json:"filename" yaml:"filename"`
+	Level           string `json:"level" yaml:"level"`
+	MaxSize         int    `json:"max_size" yaml:"max_size"`       // megabytes
+	MaxBackups      int    `json:"max_backups" yaml:"max_backups"` // number of backups
+	MaxAge          int    `json:"max_age" yaml:"max_age"`         // days
+	Compress        bool   `json:"compress" yaml:"compress"`
+	IncludeLocation bool   `json:"include_location" yaml:"include_location"`
+	JSON            bool   `json:"json" yaml:"json"`
+	Async           bool   `json:"async" yaml:"async"` // written through the persistent log queue, see AsyncLogging
+}
+
+// PluginLogFiles configures a rotating log file per plugin, Dir/<plugin>.log, receiving the records at or above
+// Level logged through the plugin's logger and its subloggers: the plugin's own output when it is collected, and
+// what the host logs about the plugin's process.
+type PluginLogFiles struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Dir        string `json:"dir" yaml:"dir"`
+	Level      string `json:"level" yaml:"level"`
+	MaxSize    int    `json:"max_size" yaml:"max_size"`       // megabytes
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"` // number of backups
+	MaxAge     int    `json:"max_age" yaml:"max_age"`         // days
+	Compress   bool   `json:"compress" yaml:"compress"`
+	JSON       bool   `json:"json" yaml:"json"`
+}
+
+// AsyncLogging configures how the files with Async set are written through the log queue, so a slow disk never
+// holds up the logger: records are batched into the queue, see LogMQ, and Workers workers write them to the files.
+// Records still queued at shutdown after DrainTimeout are written on the next start, unless the queue is in memory.
+type AsyncLogging struct {
+	Workers       int `json:"workers" yaml:"workers"`
+	BatchSize     int `json:"batch_size" yaml:"batch_size"`
+	FlushInterval int `json:"flush_interval_ms" yaml:"flush_interval_ms"` // milliseconds
+	DrainTimeout  int `json:"drain_timeout_ms" yaml:"drain_timeout_ms"`   // milliseconds
+}
+
+// LogMQ selects the backend of the log queue, one of mq.Backends: a SQLite database at File, the Redis server at
+// Redis.Addr, for deployments without local disk, or an in-memory ring buffer of Capacity records that drops the
+// oldest when full and persists nothing.
+type LogMQ struct {
+	Backend  string     `json:"backend" yaml:"backend"`
+	File     string     `json:"file" yaml:"file"`
+	Redis    LogMQRedis `json:"redis" yaml:"redis"`
+	Capacity int        `json:"capacity" yaml:"capacity"`
+}
+
+// LogMQRedis locates the Redis server and database of the log queue. Key prefixes the queue's keys and must be unique
+// to each host, since opening the queue requeues the records in flight under its key.
+type LogMQRedis struct {
+	Addr     string `json:"addr" yaml:"addr"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	DB       int    `json:"db" yaml:"db"`
+	Key      string `json:"key" yaml:"key"`
+}
+
+// LogSinkPlugin ships the records at or above Level to the logsink plugin named Plugin, in batches of up to
+// BatchSize records and at least every FlushInterval.
+type LogSinkPlugin struct {
+	Plugin        string `json:"plugin" yaml:"plugin"`
+	Level         string `json:"level" yaml:"level"`
+	BatchSize     int    `json:"batch_size" yaml:"batch_size"`
+	FlushInterval int    `json:"flush_interval_ms" yaml:"flush_interval_ms"` // milliseconds
+}
+
+// ChaosEnvVar is the environment variable that enables chaos mode regardless of the config file setting.
+const ChaosEnvVar = "PLUGSCONC_CHAOS"
+
+// Chaos configures the opt-in fault injection mode used to exercise retry, restart, and supervision behavior in
+// staging. Rates are probabilities in the range [0, 1] applied independently to each job execution.
+type Chaos struct {
+	Enabled   bool    `json:"enabled" yaml:"enabled"`
+	DelayRate float64 `json:"delay_rate" yaml:"delay_rate"`
+	MaxDelay  int     `json:"max_delay_ms" yaml:"max_delay_ms"` // milliseconds
+	FailRate  float64 `json:"fail_rate" yaml:"fail_rate"`
+	PanicRate float64 `json:"panic_rate" yaml:"panic_rate"`
+	KillRate  float64 `json:"kill_rate" yaml:"kill_rate"`
+}
+
+// IsEnabled reports whether chaos mode is enabled by the config or by the PLUGSCONC_CHAOS environment variable.
+func (c Chaos) IsEnabled() bool {
+	if c.Enabled {
+		return true
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(ChaosEnvVar))
+	return err == nil && enabled
+}
+
+// HA configures leader election between host instances that share a plugins directory and queue.
+// Only the leader launches plugins and processes the watcher; the standby waits on LockFile and takes over
+// when the leader exits or crashes.
+type HA struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	LockFile      string `json:"lock_file" yaml:"lock_file"`
+	RetryInterval int    `json:"retry_interval_ms" yaml:"retry_interval_ms"` // milliseconds
+}
+
+// Storage selects the backend that holds the host's persisted data and the directory its database lives in.
+// Backend is one of storage.Backends.
+type Storage struct {
+	Backend string `json:"backend" yaml:"backend"`
+	DataDir string `json:"data_dir" yaml:"data_dir"`
+}
+
+// History configures the retention policy of the persistent job history, kept in the storage backend. Records beyond
+// MaxAge or MaxRows are pruned every PruneInterval, 0 disabling pruning.
+type History struct {
+	MaxAge        int `json:"max_age" yaml:"max_age"`                     // days, 0 keeps records indefinitely
+	MaxRows       int `json:"max_rows" yaml:"max_rows"`                   // 0 keeps any number of records
+	PruneInterval int `json:"prune_interval_ms" yaml:"prune_interval_ms"` // milliseconds
+}
+
+// SLA configures the availability reporting for SLA dashboards: each plugin's state is sampled every SampleInterval
+// and the day's totals persisted in the storage backend for RetentionDays. Windows lists the windows, in days,
+// summarized by the REST API's /sla endpoint and logged every ReportInterval, 0 disabling the log.
+type SLA struct {
+	Enabled        bool  `json:"enabled" yaml:"enabled"`
+	SampleInterval int   `json:"sample_interval_ms" yaml:"sample_interval_ms"` // milliseconds
+	ReportInterval int   `json:"report_interval_ms" yaml:"report_interval_ms"` // milliseconds
+	Windows        []int `json:"windows" yaml:"windows"`                       // days
+	RetentionDays  int   `json:"retention_days" yaml:"retention_days"`
+}
+
+// Watchdog configures warnings for jobs that run longer than Threshold, checked every Interval.
+type Watchdog struct {
+	Enabled   bool `json:"enabled" yaml:"enabled"`
+	Threshold int  `json:"threshold_ms" yaml:"threshold_ms"` // milliseconds
+	Interval  int  `json:"interval_ms" yaml:"interval_ms"`   // milliseconds
+}
+
+// Adaptive configures additive-increase/multiplicative-decrease control of the worker pool's concurrency: the pool
+// grows by one worker each Interval while jobs are queued, and shrinks by DecreaseFactor when the smoothed job latency
+// exceeds TargetLatency or the smoothed error rate exceeds MaxErrorRate, always staying between MinWorkers and
+// MaxWorkers.
+type Adaptive struct {
+	Enabled        bool    `json:"enabled" yaml:"enabled"`
+	MinWorkers     int     `json:"min_workers" yaml:"min_workers"`
+	MaxWorkers     int     `json:"max_workers" yaml:"max_workers"`
+	TargetLatency  int     `json:"target_latency_ms" yaml:"target_latency_ms"` // milliseconds
+	MaxErrorRate   float64 `json:"max_error_rate" yaml:"max_error_rate"`
+	Interval       int     `json:"interval_ms" yaml:"interval_ms"` // milliseconds
+	DecreaseFactor float64 `json:"decrease_factor" yaml:"decrease_factor"`
+}
+
+// ResultPolicies lists the valid values of Results.Policy, matching the worker package's overflow policies.
+var ResultPolicies = []string{"truncate", "spill", "error"}
+
+// Results bounds the size of job results: a result larger than MaxBytes is truncated, spilled to a file in SpillDir
+// that the result then references, or dropped with an error, according to Policy. A MaxBytes of zero disables the
+// limit.
+type Results struct {
+	MaxBytes int    `json:"max_bytes" yaml:"max_bytes"`
+	Policy   string `json:"policy" yaml:"policy"`
+	SpillDir string `json:"spill_dir" yaml:"spill_dir"`
+}
+
+// Memory configures sampling of the host's memory use and GC pressure every Interval. When memory use rises above
+// SoftLimit a warning is logged and, with Throttle enabled, only ThrottleWorkers jobs run at once until it falls back
+// below the limit. A SoftLimit of zero only samples.
+type Memory struct {
+	Enabled         bool `json:"enabled" yaml:"enabled"`
+	SoftLimit       int  `json:"soft_limit_mb" yaml:"soft_limit_mb"` // mebibytes
+	Throttle        bool `json:"throttle" yaml:"throttle"`
+	ThrottleWorkers int  `json:"throttle_workers" yaml:"throttle_workers"`
+	Interval        int  `json:"interval_ms" yaml:"interval_ms"` // milliseconds
+}
+
+// QueueMemory keeps a worker pool's queued jobs in memory only.
+// QueuePersistent persists a worker pool's submitted jobs in a sqlite queue so they survive a restart.
+const (
+	QueueMemory     = "memory"
+	QueuePersistent = "persistent"
+)
+
+// QueueBackends lists the valid values of Queue.Backend.
+var QueueBackends = []string{QueueMemory, QueuePersistent}
+
+// Queues selects the queue backend of each worker pool, keyed by pool name, host or agent. Pools that are not listed
+// queue jobs in memory.
+type Queues map[string]Queue
+
+// Queue configures the queue of a worker pool. Backend is memory, or persistent to keep submitted jobs in the sqlite
+// database at Path until they finish, run in submission order, so they survive a restart. Only serializable jobs can
+// be submitted to a persistent pool. Classes limits the jobs of each job class, keyed by class name, e.g. io.
+type Queue struct {
+	Backend string              `json:"backend" yaml:"backend"`
+	Path    string              `json:"path" yaml:"path"`
+	Classes map[string]JobClass `json:"classes" yaml:"classes"`
+}
+
+// JobClass limits the jobs of a class to MaxConcurrent running at once and Rate started per second, letting up to
+// Burst start at once after an idle period; 0 leaves a bound unlimited. Jobs held back by their class do not occupy
+// a worker, so a flood of slow jobs of one class cannot starve the others.
+type JobClass struct {
+	MaxConcurrent int     `json:"max_concurrent" yaml:"max_concurrent"`
+	Rate          float64 `json:"rate" yaml:"rate"` // jobs per second
+	Burst         int     `json:"burst" yaml:"burst"`
+}
+
+// Pool returns the queue configured for the named pool, or a memory queue when the pool is not listed.
+func (q Queues) Pool(name string) Queue {
+	if queue, ok := q[name]; ok {
+		return queue
+	}
+	return Queue{Backend: QueueMemory}
+}
+
+// Plugins configures plugin discovery and lifecycle management. Plugins are loaded from Dir, and those named in
+// Autostart, or every loaded plugin when it is empty, are launched at startup. With HotReload enabled, a plugin
+// whose binary, manifest, or checksum changes is reloaded once its files have been quiet for ReloadDebounce, by a
+// reconcile job on a pool of ReloadWorkers workers, submitted at most ReloadRate times a second (0 for no limit). Each
+// plugin gets private temp and cache directories under RuntimeDir, injected through TMPDIR and the XDG base directory
+// variables; an empty RuntimeDir leaves plugins with the host's environment. Plugins that crash or fail their health
+// checks are restarted only with AutoRestart enabled, following Restart. Converge keeps the autostart plugins running
+// at their pinned versions. Interactions records the calls made to gRPC plugins, or replays them without launching
+// the plugins. Plugin binaries with a detached signature are verified
+// against the public keys in TrustStore, and plugins are rejected if badly signed, or unsigned when their manifest
+// or RequireSignatures asks for a signature; an empty TrustStore rejects every plugin that must be signed. With
+// Attestation enabled, a remote service also has to attest each binary before it is marked available and launched.
+type Plugins struct {
+	Dir               string       `json:"dir" yaml:"dir"`
+	Autostart         []string     `json:"autostart" yaml:"autostart"`
+	HotReload         bool         `json:"hot_reload" yaml:"hot_reload"`
+	ReloadDebounce    int          `json:"reload_debounce_ms" yaml:"reload_debounce_ms"` // milliseconds
+	ReloadWorkers     int          `json:"reload_workers" yaml:"reload_workers"`
+	ReloadRate        float64      `json:"reload_rate" yaml:"reload_rate"` // reconcile jobs per second
+	RuntimeDir        string       `json:"runtime_dir" yaml:"runtime_dir"`
+	AutoRestart       bool         `json:"auto_restart" yaml:"auto_restart"`
+	Restart           Restart      `json:"restart" yaml:"restart"`
+	Discovery         Discovery    `json:"discovery" yaml:"discovery"`
+	Layout            Layout       `json:"layout" yaml:"layout"`
+	Groups            Groups       `json:"groups" yaml:"groups"`
+	Converge          Converge     `json:"converge" yaml:"converge"`
+	Interactions      Interactions `json:"interactions" yaml:"interactions"`
+	TrustStore        string       `json:"trust_store" yaml:"trust_store"`
+	RequireSignatures bool         `json:"require_signatures" yaml:"require_signatures"`
+	Attestation       Attestation  `json:"attestation" yaml:"attestation"`
+}
+
+// AttestationDeny rejects plugins while the attestation service cannot be reached.
+// AttestationAllow accepts plugins while the attestation service cannot be reached.
+// AttestationCache uses the last verdict cached for a binary, and rejects binaries without one.
+const (
+	AttestationDeny  = "deny"
+	AttestationAllow = "allow"
+	AttestationCache = "cache"
+)
+
+// AttestationFallbacks lists the valid values of Attestation.Fallback.
+var AttestationFallbacks = []string{AttestationDeny, AttestationAllow, AttestationCache}
+
+// Attestation configures the remote attestation service that centralizes trust decisions about plugin binaries. The
+// host POSTs each plugin's name, version, binary digest, and signer to URL, presenting Token as a bearer token, and
+// caches the verdicts for CacheTTL, also in CacheFile when it is set. Fallback decides while the service cannot be
+// reached. Set Token from PLUGSCONC_PLUGINS_ATTESTATION_TOKEN rather than the file.
+type Attestation struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	URL       string `json:"url" yaml:"url"`
+	Token     string `json:"token" yaml:"token"`
+	Timeout   int    `json:"timeout_ms" yaml:"timeout_ms"`     // milliseconds
+	CacheTTL  int    `json:"cache_ttl_ms" yaml:"cache_ttl_ms"` // milliseconds
+	CacheFile string `json:"cache_file" yaml:"cache_file"`
+	Fallback  string `json:"fallback" yaml:"fallback"` // deny, allow, or cache
+}
+
+// InteractionsOff disables the recording and replay of plugin calls.
+// InteractionsRecord records plugin calls while the plugins run normally.
+// InteractionsReplay answers plugin calls from their recordings instead of launching the plugins.
+const (
+	InteractionsOff    = "off"
+	InteractionsRecord = "record"
+	InteractionsReplay = "replay"
+)
+
+// InteractionModes lists the valid values of Interactions.Mode.
+var InteractionModes = []string{InteractionsOff, InteractionsRecord, InteractionsReplay}
+
+// Interactions configures the recording and replay of plugin calls. Mode is off, record, to write each plugin's calls
+// and responses to a file in Dir, or replay, to answer calls from those files without launching the plugins.
+type Interactions struct {
+	Mode string `json:"mode" yaml:"mode"`
+	Dir  string `json:"dir" yaml:"dir"`
+}
+
+// Restart configures the automatic restart of crashed plugins. The first restart waits InitialBackoff, and each
+// further restart in a row waits twice as long, up to MaxBackoff. A plugin still crashing after MaxRestarts restarts
+// in a row is marked failed and quarantined; one that stays healthy for ResetAfter starts over.
+type Restart struct {
+	InitialBackoff int `json:"initial_backoff_ms" yaml:"initial_backoff_ms"` // milliseconds
+	MaxBackoff     int `json:"max_backoff_ms" yaml:"max_backoff_ms"`         // milliseconds
+	MaxRestarts    int `json:"max_restarts" yaml:"max_restarts"`
+	ResetAfter     int `json:"reset_after_ms" yaml:"reset_after_ms"` // milliseconds
+}
+
+// Groups maps the name of each plugin group, such as "ingest" or "reporting", to the plugins it starts and stops
+// together, for hosts that switch workloads between modes.
+type Groups map[string][]string
+
+// Discovery configures how the host treats a plugins directory it cannot read at startup, such as a network mount
+// that is not ready yet. With Degraded set, the host starts with no plugins, reports itself degraded, and retries
+// discovery after InitialBackoff, doubling the wait up to MaxBackoff, until the directory can be read and its plugins
+// are loaded and started. Otherwise the host fails to start.
+type Discovery struct {
+	Degraded       bool `json:"degraded" yaml:"degraded"`
+	InitialBackoff int  `json:"initial_backoff_ms" yaml:"initial_backoff_ms"` // milliseconds
+	MaxBackoff     int  `json:"max_backoff_ms" yaml:"max_backoff_ms"`         // milliseconds
+}
+
+// Layout configures the check of each plugin directory's layout during discovery: a manifest, the binary it names,
+// and a checksum file are required, and the plugin's config file, signature, and provenance are allowed. Allow lists
+// further file patterns, such as "*.go", a directory may hold. Other files are logged as unexpected, and with Strict
+// set they reject the plugin.
+type Layout struct {
+	Strict bool     `json:"strict" yaml:"strict"`
+	Allow  []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+}
+
+// Converge configures the periodic comparison of the autostart plugins with their desired state: every Interval,
+// autostart plugins that are not running are started, and those whose files changed since launch are reloaded. Pins
+// maps plugin names to the only version each may run; a plugin whose manifest declares another version is held
+// stopped.
+type Converge struct {
+	Enabled  bool              `json:"enabled" yaml:"enabled"`
+	Interval int               `json:"interval_ms" yaml:"interval_ms"` // milliseconds
+	Pins     map[string]string `json:"pins" yaml:"pins"`
+}
+
+// Janitor configures the periodic removal of stale plugin artifacts under plugins.runtime_dir: the runtime directories
+// of uninstalled plugins and temp files older than MaxAge, swept every Interval.
+type Janitor struct {
+	Enabled  bool `json:"enabled" yaml:"enabled"`
+	MaxAge   int  `json:"max_age" yaml:"max_age"`         // days
+	Interval int  `json:"interval_ms" yaml:"interval_ms"` // milliseconds
+}
+
+// Admin configures the admin gRPC API through which operators list, inspect, start, stop, and reload plugins and read
+// pool metrics on a running host. When AuthPlugin names an authprovider plugin every call is authenticated by it;
+// otherwise, when Token is set, every call must present it as a bearer token; set it from PLUGSCONC_ADMIN_TOKEN rather
+// than the file.
+type Admin struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Address    string `json:"address" yaml:"address"` // host:port to listen on
+	Token      string `json:"token" yaml:"token"`
+	AuthPlugin string `json:"auth_plugin" yaml:"auth_plugin"`
+}
+
+// REST configures the embedded HTTP server that serves the host's plugins, pool metrics, and health as JSON for
+// monitoring systems that cannot speak gRPC. When AuthPlugin names an authprovider plugin every request is
+// authenticated by it; otherwise, when Token is set, every request must present it as a bearer token; set it from
+// PLUGSCONC_REST_TOKEN rather than the file.
+type REST struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Address    string `json:"address" yaml:"address"` // host:port to listen on
+	Token      string `json:"token" yaml:"token"`
+	AuthPlugin string `json:"auth_plugin" yaml:"auth_plugin"`
+}
+
+// Debug configures the debug endpoints (pprof profiles, goroutine and state dumps, log levels, the API catalog, the
+// bill of materials, the janitor, and incident captures), served under /debug/ by the REST server with its
+// credentials. They are only reachable when both the REST endpoints and Enabled are set.
+type Debug struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+// Incident configures incident captures: tar.gz archives in Dir bundling a CPU profile of CPUProfile, a goroutine
+// dump, the pool and catalog state, and the last RecentLogs log records. Besides manual captures, a capture is
+// triggered by watchdog long-job events when OnWatchdog is set and by flap detection when OnFlap is set, at most once
+// per Cooldown.
+type Incident struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Dir        string `json:"dir" yaml:"dir"`
+	CPUProfile int    `json:"cpu_profile_ms" yaml:"cpu_profile_ms"` // milliseconds
+	Cooldown   int    `json:"cooldown_ms" yaml:"cooldown_ms"`       // milliseconds
+	RecentLogs int    `json:"recent_logs" yaml:"recent_logs"`
+	OnWatchdog bool   `json:"on_watchdog" yaml:"on_watchdog"`
+	OnFlap     bool   `json:"on_flap" yaml:"on_flap"`
+}
+
+// TraceExporterOTLP exports spans over OTLP gRPC to an OpenTelemetry collector.
+// TraceExporterStdout writes spans to stdout as JSON, for local debugging.
+const (
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+)
+
+// TraceExporters lists the supported trace exporters.
+var TraceExporters = []string{TraceExporterOTLP, TraceExporterStdout}
+
+// Tracing configures OpenTelemetry tracing of job submission and execution and of the plugin calls jobs make. Spans
+// are sent by Exporter, to the collector at Endpoint for otlp, and SampleRatio of the traces started by the host are
+// recorded.
+type Tracing struct {
+	Enabled     bool    `json:"enabled" yaml:"enabled"`
+	Exporter    string  `json:"exporter" yaml:"exporter"` // otlp or stdout
+	Endpoint    string  `json:"endpoint" yaml:"endpoint"` // host:port of the OTLP gRPC collector
+	Insecure    bool    `json:"insecure" yaml:"insecure"` // connect to the collector without TLS
+	SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// Metrics configures the export of pool, plugin, and runtime metrics to every metricsink plugin in the catalog, one
+// snapshot every ExportInterval.
+type Metrics struct {
+	Enabled        bool `json:"enabled" yaml:"enabled"`
+	ExportInterval int  `json:"export_interval_ms" yaml:"export_interval_ms"` // milliseconds
+}
+
+// Agents configures remote worker agents. With Enabled set the host runs an agent.Dispatcher on Address, holding the
+// jobs for agents in the sqlite queue at Queue; a pulled job is handed out again when its result is not reported
+// within Lease, checked every RequeueInterval, and requests larger than MaxRequestBytes are refused. An agent spools
+// the results it could not report to the sqlite queue at Spool and reports them on its next run. The host and its
+// agents share the bearer token in PLUGSCONC_AGENT_TOKEN.
+type Agents struct {
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+	Address         string `json:"address" yaml:"address"` // host:port to listen on
+	Queue           string `json:"queue" yaml:"queue"`
+	Lease           int    `json:"lease_ms" yaml:"lease_ms"`                       // milliseconds
+	RequeueInterval int    `json:"requeue_interval_ms" yaml:"requeue_interval_ms"` // milliseconds
+	Spool           string `json:"spool" yaml:"spool"`
+	MaxRequestBytes int    `json:"max_request_bytes" yaml:"max_request_bytes"`
+}
+
+// Schedules configures the jobs the host submits to its pool on a schedule, each a cron expression, a descriptor
+// such as @hourly, or "@every <duration>". VerifyChecksums re-verifies the checksum and launch details of every loaded
+// plugin, empty disabling it. Each run is delayed by a random duration up to Jitter.
+type Schedules struct {
+	VerifyChecksums string `json:"verify_checksums" yaml:"verify_checksums"`
+	Jitter          int    `json:"jitter_ms" yaml:"jitter_ms"` // milliseconds
+}
+
+// DefaultConfig returns a Config populated with the application's default values.
+func DefaultConfig() *Config {
+	return &Config{
+		General: General{
+			Name: "PlugsConc",
+			Mode: "dev",
+		},
+		Logging: Logging{
+			Level:                "info",
+			Format:               "human",
+			Color:                "auto",
+			Theme:                "dark",
+			ErrorSummaryInterval: 300000,
+			StackTraces:          []string{"error"},
+			StackDepth:           8,
+			Align: Align{
+				Enabled:      false,
+				ModuleWidth:  24,
+				PriorityKeys: []string{"job_id", "plugin", "worker_id", "error"},
+			},
+			Files:               []LogFile{},
+			CollectPluginOutput: true,
+			PluginFiles: PluginLogFiles{
+				Enabled:    false,
+				Dir:        "./logs/plugins",
+				Level:      "trace",
+				MaxSize:    2,
+				MaxBackups: 5,
+				MaxAge:     7,
+				Compress:   true,
+				JSON:       true,
+			},
+			Async: AsyncLogging{
+				Workers:       10,
+				BatchSize:     100,
+				FlushInterval: 1000,
+				DrainTimeout:  5000,
+			},
+			MQ: LogMQ{
+				Backend: mq.BackendSQLite,
+				File:    "./logs/logs.db",
+				Redis: LogMQRedis{
+					Addr: "localhost:6379",
+					Key:  "plugsconc",
+				},
+				Capacity: 10000,
+			},
+			Sinks: []LogSinkPlugin{},
+		},
+		Chaos: Chaos{
+			Enabled:   false,
+			DelayRate: 0.1,
+			MaxDelay:  2000,
+			FailRate:  0.05,
+			PanicRate: 0.01,
+			KillRate:  0,
+		},
+		HA: HA{
+			Enabled:       false,
+			LockFile:      "./plugins/.leader.lock",
+			RetryInterval: 1000,
+		},
+		Storage: Storage{
+			Backend: storage.BackendSQLite,
+			DataDir: "./data",
+		},
+		History: History{
+			MaxAge:        30,
+			MaxRows:       100000,
+			PruneInterval: 3600000,
+		},
+		SLA: SLA{
+			Enabled:        false,
+			SampleInterval: 10000,
+			ReportInterval: 3600000,
+			Windows:        []int{1, 7, 30},
+			RetentionDays:  90,
+		},
+		Watchdog: Watchdog{
+			Enabled:   true,
+			Threshold: 60000,
+			Interval:  5000,
+		},
+		Adaptive: Adaptive{
+			Enabled:        false,
+			MinWorkers:     1,
+			MaxWorkers:     32,
+			TargetLatency:  1000,
+			MaxErrorRate:   0.1,
+			Interval:       5000,
+			DecreaseFactor: 0.5,
+		},
+		Results: Results{
+			MaxBytes: 16 * 1024 * 1024,
+			Policy:   "spill",
+			SpillDir: "./data/results",
+		},
+		Memory: Memory{
+			Enabled:         true,
+			SoftLimit:       0,
+			Throttle:        false,
+			ThrottleWorkers: 1,
+			Interval:        5000,
+		},
+		Queues: Queues{
+			"host": {
+				Backend: QueueMemory,
+				Path:    "./data/queues/host.db",
+			},
+			"agent": {
+				Backend: QueueMemory,
+				Path:    "./data/queues/agent.db",
+			},
+		},
+		Plugins: Plugins{
+			Dir:            "./plugins",
+			Autostart:      []string{},
+			HotReload:      false,
+			ReloadDebounce: 500,
+			ReloadWorkers:  4,
+			ReloadRate:     10,
+			RuntimeDir:     "./data/runtime",
+			AutoRestart:    true,
+			Restart: Restart{
+				InitialBackoff: 1000,
+				MaxBackoff:     60000,
+				MaxRestarts:    5,
+				ResetAfter:     300000,
+			},
+			Discovery: Discovery{
+				Degraded:       false,
+				InitialBackoff: 1000,
+				MaxBackoff:     60000,
+			},
+			Groups: Groups{},
+			Converge: Converge{
+				Enabled:  true,
+				Interval: 30000,
+				Pins:     map[string]string{},
+			},
+			Interactions: Interactions{
+				Mode: InteractionsOff,
+				Dir:  "./data/interactions",
+			},
+			TrustStore:        "",
+			RequireSignatures: false,
+			Attestation: Attestation{
+				Enabled:   false,
+				Timeout:   5000,
+				CacheTTL:  600000,
+				CacheFile: "./data/attestations.json",
+				Fallback:  AttestationCache,
+			},
+		},
+		Janitor: Janitor{
+			Enabled:  true,
+			MaxAge:   7,
+			Interval: 3600000,
+		},
+		Admin: Admin{
+			Enabled: false,
+			Address: "127.0.0.1:7070",
+			Token:   "",
+		},
+		REST: REST{
+			Enabled: false,
+			Address: "127.0.0.1:7071",
+			Token:   "",
+		},
+		Debug: Debug{
+			Enabled: false,
+		},
+		Incident: Incident{
+			Enabled:    false,
+			Dir:        "./data/incidents",
+			CPUProfile: 10000,
+			Cooldown:   300000,
+			RecentLogs: 1000,
+			OnWatchdog: true,
+			OnFlap:     true,
+		},
+		Tracing: Tracing{
+			Enabled:     false,
+			Exporter:    TraceExporterOTLP,
+			Endpoint:    "127.0.0.1:4317",
+			Insecure:    true,
+			SampleRatio: 1,
+		},
+		Metrics: Metrics{
+			Enabled:        false,
+			ExportInterval: 15000,
+		},
+		Agents: Agents{
+			Enabled:         false,
+			Address:         "127.0.0.1:7072",
+			Queue:           "./data/queues/dispatcher.db",
+			Lease:           300000,
+			RequeueInterval: 30000,
+			Spool:           "./data/queues/agent-results.db",
+			MaxRequestBytes: 32 * 1024 * 1024,
+		},
+		Schedules: Schedules{
+			VerifyChecksums: "@hourly",
+			Jitter:          60000,
+		},
+	}
+}
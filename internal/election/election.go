@@ -0,0 +1,144 @@
+// Package election provides leader election for HA host pairs that share a plugins directory and queue.
+// Leadership is an exclusive advisory lock on a shared lock file: only the leader launches plugins and processes the
+// watcher, and because the lock is released when the leader's process exits, the standby takes over on failure.
+package election
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultRetryInterval is how often a standby retries the lock while another instance leads.
+const DefaultRetryInterval = time.Second
+
+var (
+	// ErrNotLeader indicates that the instance does not hold leadership.
+	ErrNotLeader = errors.New("not the leader")
+	// ErrOpenLockFile indicates that the shared lock file could not be opened.
+	ErrOpenLockFile = errors.New("failed to open lock file")
+)
+
+// Elector campaigns for leadership of the lock file at path on behalf of the instance identified by id.
+type Elector struct {
+	electLogger   hclog.Logger
+	id            string
+	path          string
+	retryInterval time.Duration
+	mu            sync.RWMutex
+	file          *os.File
+}
+
+// NewElector creates an Elector for the instance id using the shared lock file at path. A retryInterval of zero or
+// less uses DefaultRetryInterval.
+func NewElector(path string, id string, retryInterval time.Duration, electLogger hclog.Logger) *Elector {
+	if electLogger == nil {
+		electLogger = hclog.Default()
+	}
+	if retryInterval <= 0 {
+		retryInterval = DefaultRetryInterval
+	}
+	return &Elector{
+		electLogger:   electLogger.With(logger.KeyNodeID, id),
+		id:            id,
+		path:          path,
+		retryInterval: retryInterval,
+	}
+}
+
+// ID returns the identifier of this instance.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.file != nil
+}
+
+// TryAcquire makes a single attempt to become leader, reporting whether this instance now holds leadership.
+func (e *Elector) TryAcquire() (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil {
+		return true, nil
+	}
+	// the file is not truncated on open so a standby never erases the leader's identity
+	f, err := os.OpenFile(e.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, errors.Join(ErrOpenLockFile, err)
+	}
+	locked, err := tryLock(f)
+	if err != nil || !locked {
+		return false, errors.Join(err, f.Close())
+	}
+	// record the leader's identity for standbys and operators
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteAt([]byte(e.id+"\n"), 0)
+		if err != nil {
+			e.electLogger.Warn("Failed to record leader identity", logger.KeyError, err)
+		}
+	}
+	e.file = f
+	e.electLogger.Info("Acquired leadership", "lock_file", e.path)
+	return true, nil
+}
+
+// Campaign blocks until this instance becomes leader or ctx is canceled.
+func (e *Elector) Campaign(ctx context.Context) error {
+	logged := false
+	for {
+		ok, err := e.TryAcquire()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if !logged {
+			leader, _ := e.Leader()
+			e.electLogger.Info("Standing by for leadership", logger.KeyLeaderID, leader)
+			logged = true
+		}
+		t := time.NewTimer(e.retryInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Leader returns the identifier recorded by the current leader, or an empty string if none is recorded.
+func (e *Elector) Leader() (string, error) {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Resign gives up leadership so the standby can take over.
+func (e *Elector) Resign() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return ErrNotLeader
+	}
+	err := errors.Join(e.file.Truncate(0), unlock(e.file), e.file.Close())
+	e.file = nil
+	e.electLogger.Info("Resigned leadership")
+	return err
+}
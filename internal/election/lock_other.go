@@ -0,0 +1,18 @@
+//go:build !unix
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock reports ErrUnsupported on platforms without advisory file locks.
+func tryLock(_ *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// unlock reports ErrUnsupported on platforms without advisory file locks.
+func unlock(_ *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,344 @@
+// Package history persists completed job results to a storage backend so they can be queried after they have been read from
+// the pool's results channel.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+// Outcome is the final state of a recorded job.
+type Outcome string
+
+// OutcomeSucceeded indicates the job returned without error.
+// OutcomeFailed indicates the job returned an error.
+// OutcomeCanceled indicates the job's context was canceled or its deadline expired.
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeCanceled  Outcome = "canceled"
+)
+
+// DefaultQueryLimit is the maximum number of records returned by a query that does not set a limit.
+const DefaultQueryLimit = 100
+
+var (
+	// ErrOpenStore indicates that the history buckets could not be opened in the storage backend.
+	ErrOpenStore = errors.New("failed to open job history store")
+	// ErrRecord indicates that a job result could not be written to the store.
+	ErrRecord = errors.New("failed to record job result")
+)
+
+// recordsBucket holds records keyed by recordKey; idsBucket maps job IDs to their record keys.
+const (
+	recordsBucket = "job_history"
+	idsBucket     = "job_history_ids"
+)
+
+// Record is a persisted job result.
+type Record struct {
+	JobID       string        `json:"job_id"`
+	Type        string        `json:"job_type,omitempty"`
+	Plugin      string        `json:"plugin,omitempty"`
+	WorkerID    int           `json:"worker_id"`
+	Outcome     Outcome       `json:"outcome"`
+	Error       string        `json:"error,omitempty"`
+	Attempts    int           `json:"attempts"`
+	SubmittedAt time.Time     `json:"submitted_at"`
+	StartedAt   time.Time     `json:"started_at"`
+	FinishedAt  time.Time     `json:"finished_at"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// NewRecord converts a job result into a Record.
+func NewRecord(res *worker.JobResult) *Record {
+	r := &Record{
+		JobID:    res.JobID,
+		Type:     res.Type,
+		Plugin:   res.Plugin,
+		WorkerID: res.WorkerID,
+		Outcome:  OutcomeSucceeded,
+	}
+	if res.Metrics != nil {
+		r.Attempts = res.Metrics.Attempts + 1
+		r.SubmittedAt = res.Metrics.SubmittedAt
+		r.StartedAt = res.Metrics.StartedAt
+		r.FinishedAt = res.Metrics.FinishedAt
+		r.Duration = res.Metrics.Duration
+	}
+	if r.FinishedAt.IsZero() {
+		r.FinishedAt = timestamp.Now()
+	}
+	if res.Err != nil {
+		r.Outcome = OutcomeFailed
+		if errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded) {
+			r.Outcome = OutcomeCanceled
+		}
+		r.Error = res.Err.Error()
+	}
+	return r
+}
+
+// Filter selects records in a query. Zero values match everything.
+type Filter struct {
+	Outcome Outcome   // only records with this outcome
+	Since   time.Time // only records finished at or after this time
+	Type    string    // only records of this job type
+	Plugin  string    // only records for this plugin
+	Limit   int       // maximum records returned, DefaultQueryLimit when zero
+}
+
+// Retention bounds the size of the store. Zero values disable the corresponding bound.
+type Retention struct {
+	MaxAge  time.Duration // records finished longer ago than MaxAge are removed
+	MaxRows int           // only the most recent MaxRows records are kept
+}
+
+// Store is a job history store kept in a storage.Backend. Records are keyed by finish time so queries and
+// retention walk them in order, with a second bucket mapping job IDs to their record keys.
+type Store struct {
+	historyLogger hclog.Logger
+	records       storage.Bucket
+	ids           storage.Bucket
+	retention     Retention
+}
+
+// New opens the job history buckets in backend. The backend is owned by the caller, who closes it.
+func New(backend storage.Backend, retention Retention, historyLogger hclog.Logger) (*Store, error) {
+	if historyLogger == nil {
+		historyLogger = hclog.Default()
+	}
+	records, err := backend.Bucket(recordsBucket)
+	if err != nil {
+		return nil, errors.Join(ErrOpenStore, err)
+	}
+	ids, err := backend.Bucket(idsBucket)
+	if err != nil {
+		return nil, errors.Join(ErrOpenStore, err)
+	}
+	return &Store{historyLogger: historyLogger, records: records, ids: ids, retention: retention}, nil
+}
+
+// Record persists a completed job result, replacing any earlier record for the same job.
+func (s *Store) Record(res *worker.JobResult) error {
+	return s.put(NewRecord(res))
+}
+
+// put stores a record, replacing any earlier record for the same job.
+func (s *Store) put(r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Join(ErrRecord, err)
+	}
+	if old, err := s.ids.Get([]byte(r.JobID)); err == nil {
+		if err := s.records.Delete(old); err != nil {
+			return errors.Join(ErrRecord, err)
+		}
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return errors.Join(ErrRecord, err)
+	}
+	key := recordKey(r.FinishedAt, r.JobID)
+	if err := s.records.Put(key, data); err != nil {
+		return errors.Join(ErrRecord, err)
+	}
+	if err := s.ids.Put([]byte(r.JobID), key); err != nil {
+		return errors.Join(ErrRecord, err)
+	}
+	return nil
+}
+
+// Tee records every result read from in and forwards it on the returned channel, which is closed when in is closed.
+// Use it in place of the pool's Results channel so results are persisted without changing how they are consumed.
+func (s *Store) Tee(in <-chan *worker.JobResult) <-chan *worker.JobResult {
+	out := make(chan *worker.JobResult, cap(in))
+	go func() {
+		defer close(out)
+		for res := range in {
+			if err := s.Record(res); err != nil {
+				s.historyLogger.Error("Failed to record job result", logger.KeyJobID, res.JobID, logger.KeyError, err)
+			}
+			out <- res
+		}
+	}()
+	return out
+}
+
+// Query returns the records matching the filter, most recently finished first.
+func (s *Store) Query(f Filter) ([]*Record, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	var records []*Record
+	err := s.records.Scan(nil, true, func(_, value []byte) error {
+		var r Record
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		if !f.Since.IsZero() && r.FinishedAt.Before(f.Since) {
+			return storage.ErrStopScan
+		}
+		if (f.Outcome != "" && r.Outcome != f.Outcome) ||
+			(f.Type != "" && r.Type != f.Type) ||
+			(f.Plugin != "" && r.Plugin != f.Plugin) {
+			return nil
+		}
+		records = append(records, &r)
+		if len(records) == limit {
+			return storage.ErrStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// OutcomeCounts counts the records of each Outcome.
+type OutcomeCounts struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Canceled  int `json:"canceled"`
+}
+
+// Total returns the number of records counted.
+func (c OutcomeCounts) Total() int {
+	return c.Succeeded + c.Failed + c.Canceled
+}
+
+// Add returns the sum of c and o.
+func (c OutcomeCounts) Add(o OutcomeCounts) OutcomeCounts {
+	return OutcomeCounts{
+		Succeeded: c.Succeeded + o.Succeeded,
+		Failed:    c.Failed + o.Failed,
+		Canceled:  c.Canceled + o.Canceled,
+	}
+}
+
+// Outcomes counts the outcomes of the records finished at or after since and before until, by plugin. Jobs that did
+// not call a plugin are counted under the empty name.
+func (s *Store) Outcomes(since, until time.Time) (map[string]OutcomeCounts, error) {
+	counts := make(map[string]OutcomeCounts)
+	err := s.records.Scan(nil, true, func(_, value []byte) error {
+		var r Record
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		if r.FinishedAt.Before(since) {
+			return storage.ErrStopScan
+		}
+		if !r.FinishedAt.Before(until) {
+			return nil
+		}
+		c := counts[r.Plugin]
+		switch r.Outcome {
+		case OutcomeSucceeded:
+			c.Succeeded++
+		case OutcomeFailed:
+			c.Failed++
+		case OutcomeCanceled:
+			c.Canceled++
+		}
+		counts[r.Plugin] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Prune applies the retention policy, returning the number of records removed.
+func (s *Store) Prune() (int64, error) {
+	var removed int64
+	if s.retention.MaxAge > 0 {
+		cutoff := recordKey(time.Now().Add(-s.retention.MaxAge), "")
+		err := s.records.Scan(nil, false, func(key, value []byte) error {
+			if bytes.Compare(key, cutoff) >= 0 {
+				return storage.ErrStopScan
+			}
+			if err := s.remove(key, value); err != nil {
+				return err
+			}
+			removed++
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+	if s.retention.MaxRows > 0 {
+		n, err := s.records.Count()
+		if err != nil {
+			return removed, err
+		}
+		excess := n - s.retention.MaxRows
+		if excess <= 0 {
+			return removed, nil
+		}
+		err = s.records.Scan(nil, false, func(key, value []byte) error {
+			if excess == 0 {
+				return storage.ErrStopScan
+			}
+			if err := s.remove(key, value); err != nil {
+				return err
+			}
+			excess--
+			removed++
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// remove deletes a record and its job ID index entry.
+func (s *Store) remove(key, value []byte) error {
+	var r Record
+	if err := json.Unmarshal(value, &r); err == nil {
+		if err := s.ids.Delete([]byte(r.JobID)); err != nil {
+			return err
+		}
+	}
+	return s.records.Delete(key)
+}
+
+// RunRetention prunes the store every interval until ctx is canceled.
+func (s *Store) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.Prune()
+			if err != nil {
+				s.historyLogger.Error("Failed to prune job history", logger.KeyError, err)
+				continue
+			}
+			if removed > 0 {
+				s.historyLogger.Debug("Pruned job history", "removed", removed)
+			}
+		}
+	}
+}
+
+// recordKey orders records by finish time, breaking ties by job ID.
+func recordKey(finishedAt time.Time, jobID string) []byte {
+	key := binary.BigEndian.AppendUint64(nil, uint64(finishedAt.UnixNano()))
+	return append(key, jobID...)
+}
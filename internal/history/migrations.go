@@ -0,0 +1,99 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+)
+
+// Component is the name the job history's schema is versioned under.
+const Component = "history"
+
+// LegacyFileName is the sqlite database the job history was kept in before it moved to the storage backend.
+const LegacyFileName = "history.db"
+
+// Migrations returns the job history's schema migrations. dataDir is searched for a legacy history database.
+func Migrations(dataDir string) []storage.Migration {
+	return []storage.Migration{
+		{
+			Component:   Component,
+			Version:     1,
+			Description: "import records from the legacy " + LegacyFileName,
+			Up: func(b storage.Backend) error {
+				return importLegacy(b, filepath.Join(dataDir, LegacyFileName))
+			},
+			// the legacy database is left in place, so reverting only discards the imported copy
+			Down: clearBuckets,
+		},
+	}
+}
+
+// importLegacy copies every record in the legacy sqlite job history at path into the backend. A missing file is
+// not an error.
+func importLegacy(b storage.Backend, path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	store, err := New(b, Retention{}, nil)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	rows, err := db.Query(`SELECT job_id, job_type, plugin, worker_id, outcome, error, attempts,
+		submitted_at, started_at, finished_at, duration FROM job_history`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var r Record
+		var outcome string
+		var submitted, started, finished, duration int64
+		if err := rows.Scan(&r.JobID, &r.Type, &r.Plugin, &r.WorkerID, &outcome, &r.Error, &r.Attempts,
+			&submitted, &started, &finished, &duration); err != nil {
+			return err
+		}
+		r.Outcome = Outcome(outcome)
+		r.SubmittedAt = fromUnixNano(submitted)
+		r.StartedAt = fromUnixNano(started)
+		r.FinishedAt = fromUnixNano(finished)
+		r.Duration = time.Duration(duration)
+		if err := store.put(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// clearBuckets removes every job history record from the backend.
+func clearBuckets(b storage.Backend) error {
+	for _, name := range []string{recordsBucket, idsBucket} {
+		bucket, err := b.Bucket(name)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Scan(nil, false, func(key, _ []byte) error {
+			return bucket.Delete(key)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fromUnixNano converts the Unix nanoseconds stored by the legacy database, where zero meant the zero time.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
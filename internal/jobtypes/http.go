@@ -0,0 +1,91 @@
+package jobtypes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+)
+
+// HTTPCheck is the job type probing an HTTP endpoint, e.g. from a remote worker agent in another network.
+const HTTPCheck = "http_check"
+
+// maxDrain bounds how much of a response body an HTTPCheck job reads, so the connection can be reused.
+const maxDrain = 64 << 10
+
+var (
+	// ErrInvalidCheck indicates that an HTTPCheckRequest has no absolute http or https URL.
+	ErrInvalidCheck = errors.New("invalid http check")
+	// ErrUnexpectedStatus indicates that a checked endpoint responded with a status other than the expected one.
+	ErrUnexpectedStatus = errors.New("unexpected http status")
+)
+
+// HTTPCheckRequest is the payload of an HTTPCheck job. Method defaults to GET. The check succeeds when the endpoint
+// responds with ExpectStatus, or any 2xx status when ExpectStatus is zero, within Timeout milliseconds, if set.
+type HTTPCheckRequest struct {
+	URL          string `json:"url"`
+	Method       string `json:"method,omitempty"`
+	ExpectStatus int    `json:"expect_status,omitempty"`
+	Timeout      int    `json:"timeout_ms,omitempty"` // milliseconds
+}
+
+// HTTPCheckResult is the value of a finished HTTPCheck job.
+type HTTPCheckResult struct {
+	Status  int   `json:"status"`
+	Latency int64 `json:"latency_ms"` // milliseconds
+}
+
+// RegisterHTTPCheck registers the HTTPCheck job type, sending its requests with client, or http.DefaultClient when
+// client is nil.
+func RegisterHTTPCheck(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	worker.AvailableJobTypes.Register(HTTPCheck, worker.JSONCodec[HTTPCheckRequest]{},
+		func(payload any) worker.WorkUnit {
+			req, _ := payload.(HTTPCheckRequest)
+			return func(ctx context.Context) (any, error) {
+				return req.check(ctx, client)
+			}
+		})
+}
+
+// check sends the request with client and compares the response status with the expected one.
+func (c HTTPCheckRequest) check(ctx context.Context, client *http.Client) (*HTTPCheckResult, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url %q", ErrInvalidCheck, c.URL)
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidCheck, err)
+	}
+	start := timestamp.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	_ = resp.Body.Close()
+	res := &HTTPCheckResult{Status: resp.StatusCode, Latency: timestamp.Now().Sub(start).Milliseconds()}
+	if ok := resp.StatusCode == c.ExpectStatus ||
+		(c.ExpectStatus == 0 && resp.StatusCode >= 200 && resp.StatusCode < 300); !ok {
+		return res, fmt.Errorf("%w: %s %s: %s", ErrUnexpectedStatus, method, c.URL, resp.Status)
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package jobtypes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHTTPCheck checks that an HTTPCheck request succeeds on the expected status and fails on any other status or an
+// invalid URL.
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead && r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	tests := map[string]struct {
+		req    HTTPCheckRequest
+		status int
+		err    error
+	}{
+		"2xx":             {req: HTTPCheckRequest{URL: srv.URL}, status: http.StatusOK},
+		"expected status": {req: HTTPCheckRequest{URL: srv.URL + "/missing", ExpectStatus: 404}, status: 404},
+		"unexpected":      {req: HTTPCheckRequest{URL: srv.URL + "/missing"}, status: 404, err: ErrUnexpectedStatus},
+		"method":          {req: HTTPCheckRequest{URL: srv.URL + "/missing", Method: http.MethodHead}, status: 200},
+		"relative url":    {req: HTTPCheckRequest{URL: "/health"}, err: ErrInvalidCheck},
+		"scheme":          {req: HTTPCheckRequest{URL: "file:///etc/passwd"}, err: ErrInvalidCheck},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := tt.req.check(context.Background(), srv.Client())
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.status != 0 && (res == nil || res.Status != tt.status) {
+				t.Errorf("got result %+v, want status %d", res, tt.status)
+			}
+		})
+	}
+}
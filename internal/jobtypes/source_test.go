@@ -0,0 +1,85 @@
+package jobtypes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+	"github.com/hashicorp/go-hclog"
+)
+
+// fakeSource is a jobsource.JobSource sending a fixed list of requests.
+type fakeSource struct {
+	requests []jobsource.Request
+}
+
+// Stream sends every request, then waits for ctx to be canceled as a plugin with no more work would.
+func (s *fakeSource) Stream(ctx context.Context, _ []string, send func(jobsource.Request) error) error {
+	for _, req := range s.requests {
+		if err := send(req); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestSourceFeederHTTPCheck checks that http_check jobs requested by a job source are run on the pool with the
+// registered handler and tagged with the source's name, while requests outside its jobs capability or with an invalid
+// payload are rejected.
+func TestSourceFeederHTTPCheck(t *testing.T) {
+	RegisterHTTPCheck(nil)
+	RegisterVerifyChecksums(&fakeVerifier{}, func() []string { return nil }, hclog.NewNullLogger())
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	payload, err := json.Marshal(HTTPCheckRequest{URL: target.URL})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	source := &fakeSource{requests: []jobsource.Request{
+		{Type: HTTPCheck, Encoding: worker.EncodingJSON, Payload: payload},
+		{Type: VerifyChecksums, Encoding: worker.EncodingJSON, Payload: []byte(`{}`)},
+		{Type: HTTPCheck, Encoding: worker.EncodingJSON, Payload: []byte(`not json`)},
+		{Type: HTTPCheck, Encoding: worker.EncodingProto, Payload: payload},
+	}}
+
+	pool := worker.NewPool(1, false, 4, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	limits := &capability.JobsCapability{Types: []string{HTTPCheck}}
+	feeder := worker.NewSourceFeeder("checker", source, pool, limits, hclog.NewNullLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- feeder.Run(ctx) }()
+
+	select {
+	case res := <-pool.Results():
+		if res.Err != nil {
+			t.Fatalf("job failed: %v", res.Err)
+		}
+		if res.Type != HTTPCheck || res.Plugin != "checker" {
+			t.Errorf("got job type %q from plugin %q, want %q from checker", res.Type, res.Plugin, HTTPCheck)
+		}
+		if check, ok := res.Value.(*HTTPCheckResult); !ok || check.Status != http.StatusOK {
+			t.Errorf("got value %#v, want status %d", res.Value, http.StatusOK)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result for the requested job")
+	}
+	cancel()
+	<-done
+	if got := feeder.Accepted(); got != 1 {
+		t.Errorf("got %d accepted requests, want 1", got)
+	}
+	if got := feeder.Rejected(); got != 3 {
+		t.Errorf("got %d rejected requests, want 3", got)
+	}
+}
@@ -0,0 +1,60 @@
+// Package jobtypes holds the serializable job types the host registers in worker.AvailableJobTypes, so they can be
+// persisted in durable queues, queued for remote worker agents, requested by job source plugins, and scheduled.
+package jobtypes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+// VerifyChecksums is the job type re-verifying the checksums of installed plugins.
+const VerifyChecksums = "verify_checksums"
+
+// PluginVerifier checks an installed plugin, e.g. a registry.PluginManager re-verifying its binary's checksum.
+type PluginVerifier interface {
+	Verify(name string) error
+}
+
+// VerifyChecksumsRequest is the payload of a VerifyChecksums job. An empty Plugins verifies every installed plugin.
+type VerifyChecksumsRequest struct {
+	Plugins []string `json:"plugins,omitempty"`
+}
+
+// RegisterVerifyChecksums registers the VerifyChecksums job type, verifying plugins with verifier. plugins lists the
+// installed plugins verified by a request naming none. The job returns the number of plugins verified and fails with
+// the errors of those that were not.
+func RegisterVerifyChecksums(verifier PluginVerifier, plugins func() []string, jobLogger hclog.Logger) {
+	if jobLogger == nil {
+		jobLogger = hclog.Default()
+	}
+	worker.AvailableJobTypes.Register(VerifyChecksums, worker.JSONCodec[VerifyChecksumsRequest]{},
+		func(payload any) worker.WorkUnit {
+			req, _ := payload.(VerifyChecksumsRequest)
+			return func(ctx context.Context) (any, error) {
+				names := req.Plugins
+				if len(names) == 0 {
+					names = plugins()
+				}
+				var errs []error
+				verified := 0
+				for _, name := range names {
+					if err := ctx.Err(); err != nil {
+						return verified, err
+					}
+					if err := verifier.Verify(name); err != nil {
+						jobLogger.Warn("Plugin failed checksum re-verification", logger.KeyPluginName, name,
+							logger.KeyError, err)
+						errs = append(errs, fmt.Errorf("%s: %w", name, err))
+						continue
+					}
+					verified++
+				}
+				return verified, errors.Join(errs...)
+			}
+		})
+}
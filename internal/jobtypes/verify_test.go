@@ -0,0 +1,71 @@
+package jobtypes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+// fakeVerifier fails the plugins in bad and records the plugins it was asked to verify.
+type fakeVerifier struct {
+	bad      map[string]bool
+	verified []string
+}
+
+// Verify records name and fails it when it is in bad.
+func (v *fakeVerifier) Verify(name string) error {
+	v.verified = append(v.verified, name)
+	if v.bad[name] {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
+
+// TestVerifyChecksums checks that a VerifyChecksums job, rebuilt from its envelope, verifies the requested plugins,
+// or every installed plugin when none is named, and fails with the plugins that did not verify.
+func TestVerifyChecksums(t *testing.T) {
+	installed := []string{"a", "b", "c"}
+	tests := map[string]struct {
+		req      VerifyChecksumsRequest
+		bad      map[string]bool
+		want     []string
+		verified int
+		wantErr  bool
+	}{
+		"all installed": {want: installed, verified: 3},
+		"named":         {req: VerifyChecksumsRequest{Plugins: []string{"b"}}, want: []string{"b"}, verified: 1},
+		"failure":       {bad: map[string]bool{"b": true}, want: installed, verified: 2, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifier := &fakeVerifier{bad: tt.bad}
+			RegisterVerifyChecksums(verifier, func() []string { return installed }, hclog.NewNullLogger())
+			job, err := worker.NewSerializableJob(context.Background(), VerifyChecksums, tt.req)
+			if err != nil {
+				t.Fatalf("NewSerializableJob: %v", err)
+			}
+			env, err := job.Envelope()
+			if err != nil {
+				t.Fatalf("Envelope: %v", err)
+			}
+			job, err = worker.JobFromEnvelope(context.Background(), env)
+			if err != nil {
+				t.Fatalf("JobFromEnvelope: %v", err)
+			}
+			val, err := job.Execute(job.Ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if val != tt.verified {
+				t.Errorf("got %v verified, want %d", val, tt.verified)
+			}
+			if !slices.Equal(verifier.verified, tt.want) {
+				t.Errorf("verified %v, want %v", verifier.verified, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package logger
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/goptics/varmq"
 	"github.com/hashicorp/go-hclog"
@@ -15,38 +18,165 @@ var (
 	ErrNoQueue = errors.New("queue not present")
 	// ErrEmptyMessage indicates that the message is empty.
 	ErrEmptyMessage = errors.New("empty message")
+	// ErrWriterClosed indicates a write to an AsyncWriter that was closed.
+	ErrWriterClosed = errors.New("async writer closed")
+	// ErrDrainTimeout indicates that the queue's worker had not worked through the queued records when Close gave up
+	// waiting; the records stay in the persistent queue and are logged once it is worked again.
+	ErrDrainTimeout = errors.New("timed out draining the log queue")
 )
 
-// AsyncWriter represents a writer that queues messages asynchronously using a persistent queue.
+// DefaultAsyncDrainTimeout is how long Close waits for the queue's worker to log the queued records.
+const DefaultAsyncDrainTimeout = 5 * time.Second
+
+// AsyncWriter represents a writer that queues messages asynchronously using a persistent queue. By default every
+// Write is enqueued at once; with WithBatching, records are buffered and enqueued together, as one queue item of
+// newline-separated records, when the batch is full, on every flush interval, and on Flush and Close.
 type AsyncWriter struct {
-	queue varmq.PersistentQueue[[]byte]
+	queue        varmq.PersistentQueue[[]byte]
+	mu           sync.Mutex
+	batch        []byte // buffered records, each ending in a newline
+	count        int    // records in batch
+	batchSize    int
+	drainTimeout time.Duration
+	closed       bool
+	done         chan struct{} // stops the flush loop, nil without one
 }
 
 // NewAsyncWriter creates and returns a new AsyncWriter initialized with the provided persistent queue.
 func NewAsyncWriter(queue varmq.PersistentQueue[[]byte]) *AsyncWriter {
 	return &AsyncWriter{
-		queue: queue,
+		queue:        queue,
+		batchSize:    1,
+		drainTimeout: DefaultAsyncDrainTimeout,
+	}
+}
+
+// WithBatching buffers up to size records before enqueuing them together and, with a positive interval, enqueues a
+// partial batch at least that often, then returns the updated AsyncWriter. A size of 1 or less enqueues every
+// record at once. It must be called before the first Write, and Close must be called to stop the flush loop.
+func (a *AsyncWriter) WithBatching(size int, interval time.Duration) *AsyncWriter {
+	a.batchSize = max(size, 1)
+	if a.batchSize > 1 && interval > 0 && a.done == nil {
+		a.done = make(chan struct{})
+		go a.flushLoop(interval)
 	}
+	return a
+}
+
+// WithDrainTimeout sets how long Close waits for the queue's worker to log the queued records, zero or less not
+// waiting, and returns the updated AsyncWriter.
+func (a *AsyncWriter) WithDrainTimeout(timeout time.Duration) *AsyncWriter {
+	a.drainTimeout = timeout
+	return a
 }
 
-// Write attempts to enqueue the given byte slice into the queue. Returns the number of bytes written or an error.
-func (a AsyncWriter) Write(p []byte) (n int, err error) {
+// Write enqueues the given byte slice, or adds it to the current batch when batching. Returns the number of bytes
+// written or an error.
+func (a *AsyncWriter) Write(p []byte) (n int, err error) {
 	if len(p) == 0 {
 		return 0, ErrEmptyMessage
 	}
-	ok := a.queue.Add(p) // try to enqueue the message, returns true if successful, false if not
-	if !ok {
-		return 0, ErrFailedToWrite
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return 0, ErrWriterClosed
+	}
+	if a.batchSize <= 1 {
+		if !a.queue.Add(p) { // try to enqueue the message, returns true if successful, false if not
+			return 0, ErrFailedToWrite
+		}
+		return len(p), nil
+	}
+	// p may be reused by the caller once Write returns, so it is copied into the batch
+	a.batch = append(a.batch, p...)
+	if p[len(p)-1] != '\n' {
+		a.batch = append(a.batch, '\n')
+	}
+	a.count++
+	if a.count >= a.batchSize {
+		if err := a.flush(); err != nil {
+			return 0, err
+		}
 	}
 	return len(p), nil
 }
 
-// Close safely closes the underlying queue of the AsyncWriter instance if it exists, returning an error if not present.
-func (a AsyncWriter) Close() error {
+// Flush enqueues the buffered records, if any.
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.flush()
+}
+
+// flush enqueues the batch as one queue item. The batch is dropped when the queue refuses it, so a failing queue
+// does not grow it without bound. The caller must hold mu.
+func (a *AsyncWriter) flush() error {
+	if a.count == 0 {
+		return nil
+	}
+	batch := a.batch
+	a.batch, a.count = nil, 0
+	if !a.queue.Add(batch) {
+		return ErrFailedToWrite
+	}
+	return nil
+}
+
+// flushLoop flushes the batch every interval until Close.
+func (a *AsyncWriter) flushLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			if err := a.Flush(); err != nil {
+				hclog.Default().Warn("Failed to flush async log records", KeyError, err)
+			}
+		}
+	}
+}
+
+// Close stops accepting records, enqueues the buffered ones, waits up to the drain timeout for the queue's worker to
+// log every queued record, and closes the underlying queue, returning an error if it is not present. Records the
+// worker has not logged in time stay in the persistent queue and ErrDrainTimeout is returned with the close error.
+func (a *AsyncWriter) Close() error {
 	if a.queue == nil {
 		return ErrNoQueue
 	}
-	return a.queue.Close()
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	if a.done != nil {
+		close(a.done)
+	}
+	err := a.flush()
+	a.mu.Unlock()
+	return errors.Join(err, a.drain(), a.queue.Close())
+}
+
+// drain waits up to the drain timeout for the queue's worker to finish the queued records.
+func (a *AsyncWriter) drain() error {
+	if a.drainTimeout <= 0 || a.queue.Worker() == nil {
+		return nil
+	}
+	drained := make(chan struct{})
+	go func() {
+		a.queue.Worker().WaitUntilFinished()
+		close(drained)
+	}()
+	timer := time.NewTimer(a.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("%w: %d still queued after %s", ErrDrainTimeout, a.queue.NumPending(), a.drainTimeout)
+	}
 }
 
 // AsyncSink creates and returns a SinkAdapter for asynchronous logging using a persistent message queue.
@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/internal/mq"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrNotLogSink indicates that a plugin configured in config.Logging.Sinks is not a logsink plugin.
+// ErrNoLogQueue indicates that dead letters were requested from a Topology without async files, and so without a
+// log queue.
+var (
+	ErrNotLogSink = errors.New("plugin is not a log sink")
+	ErrNoLogQueue = errors.New("async log queue is not configured")
+)
+
+// Topology is the host's logging pipeline as Build assembles it from the Logging config:
+//
+//	Logger (console) ─┬─ sync file sinks
+//	                  ├─ plugin files sink ── Dir/<plugin>.log
+//	                  ├─ async sink ── AsyncWriter ── mq.Backend ── async logger ─┬─ async file sinks
+//	                  │                                                           └─ ...
+//	                  └─ logsink plugin sinks, attached with AttachPluginSinks
+//
+// Every sink filters by its own level, registered in Levels so it can be changed at runtime.
+type Topology struct {
+	// Logger is the intercept logger writing to the console, with every configured sink registered on it.
+	Logger hclog.InterceptLogger
+	// Levels holds the console logger and every configured sink, see LevelController.
+	Levels *LevelController
+	// PluginFiles is the sink giving each plugin a file of its own, nil unless enabled; pass it to the plugin host.
+	PluginFiles *PluginLogFiles
+	// Async is the writer shipping records to the persistent log queue, nil unless a file is async; the plugin host
+	// can write collected plugin output to it.
+	Async *AsyncWriter
+
+	conf        config.Logging
+	console     io.Writer
+	queue       mq.Backend            // nil unless a file is async
+	asyncLogger hclog.InterceptLogger // logs the queued records to the async files
+	mu          sync.Mutex
+	closers     []func() error // run in reverse order by Shutdown
+}
+
+// Build assembles the logging pipeline conf.Logging describes: the console logger with its theme and format, the
+// file sinks, written synchronously or, with async set, through the persistent log queue, and the plugin files sink.
+// The logsink plugins in conf.Logging.Sinks are attached later with AttachPluginSinks, once the plugins can be
+// dispensed. Shutdown flushes and closes everything Build opened.
+func Build(conf *config.Config) (*Topology, error) {
+	return build(conf, os.Stdout)
+}
+
+// build assembles the pipeline as Build does, writing the console to stdout.
+func build(conf *config.Config, stdout io.Writer) (*Topology, error) {
+	lc := conf.Logging
+	theme, themeErr := ThemeByName(lc.Theme, lc.Colors)
+	if themeErr != nil {
+		theme = AvailableThemes[ThemeNone]
+	}
+	console := NewHumanWriter(stdout, theme).
+		WithFormat(lc.Format).
+		WithColor(ParseColorMode(lc.Color))
+	if lc.Align.Enabled {
+		console.WithAlignment(lc.Align.ModuleWidth).WithPriorityKeys(lc.Align.PriorityKeys...)
+	}
+	t := &Topology{
+		Logger:  HumanMultiLogger(conf.General.Name, hclog.LevelFromString(lc.Level), console, true),
+		Levels:  NewLevelController(),
+		conf:    lc,
+		console: console,
+	}
+	if themeErr != nil {
+		t.Logger.Warn("Invalid logging theme, colors disabled", KeyError, themeErr)
+	}
+	t.Levels.Register(LevelConsole, t.Logger)
+
+	var async []config.LogFile
+	for _, f := range lc.Files {
+		if f.Async {
+			async = append(async, f)
+			continue
+		}
+		sink, rotator := levelFileSink(f)
+		t.register(LevelFilePrefix+f.Name, sink)
+		t.closers = append(t.closers, rotator.Close)
+	}
+	if len(async) > 0 {
+		if err := t.buildAsync(async); err != nil {
+			return nil, errors.Join(err, t.Shutdown())
+		}
+	}
+
+	if lc.PluginFiles.Enabled {
+		t.PluginFiles = NewPluginLogFiles(lc.PluginFiles)
+		t.register(LevelPluginFiles, t.PluginFiles)
+		t.closers = append(t.closers, t.PluginFiles.Close)
+	}
+	return t, nil
+}
+
+// buildAsync routes the files through the persistent log queue: an async sink on Logger writes the records to an
+// AsyncWriter, the queue's workers log them through an async intercept logger, and that fans them out to the files.
+func (t *Topology) buildAsync(files []config.LogFile) error {
+	// the async logger only fans records out to its sinks, which keep their own levels
+	asyncLogger := AsyncInterceptLogger(t.Logger.Name(), hclog.Off, io.Discard, hclog.ColorOff, false, true)
+	levelers := make([]Leveler, 0, len(files))
+	for _, f := range files {
+		sink, rotator := levelFileSink(f)
+		asyncLogger.RegisterSink(sink)
+		if l, ok := sink.(Leveler); ok {
+			t.Levels.Register(LevelFilePrefix+f.Name, l)
+			levelers = append(levelers, l)
+		}
+		// the rotators are closed after the queue has drained, Shutdown running closers in reverse
+		t.closers = append(t.closers, rotator.Close)
+	}
+	backend, err := openLogMQ(t.conf.MQ)
+	if err != nil {
+		return err
+	}
+	t.queue, t.asyncLogger = backend, asyncLogger
+	ac := t.conf.Async
+	queue := OpenLogQueue(backend, ac.Workers, asyncLogger)
+	t.Async = NewAsyncWriter(queue).
+		WithBatching(ac.BatchSize, time.Duration(ac.FlushInterval)*time.Millisecond).
+		WithDrainTimeout(time.Duration(ac.DrainTimeout) * time.Millisecond)
+	t.closers = append(t.closers, t.Async.Close)
+	forward := &asyncSink{
+		SinkAdapter: hclog.NewSinkAdapter(NewOptions("async", hclog.Trace, t.Async, hclog.ColorOff, false, true)),
+		files:       levelers,
+	}
+	t.Logger.RegisterSink(forward)
+	t.closers = append(t.closers, func() error {
+		t.Logger.DeregisterSink(forward)
+		return nil
+	})
+	return nil
+}
+
+// asyncSink forwards a record to the log queue when at least one of the async files keeps it, so a file's level
+// changed at runtime takes effect without touching the forwarding sink.
+type asyncSink struct {
+	hclog.SinkAdapter
+	files []Leveler
+}
+
+// Accept forwards the record when any of the async files' levels admits it. It implements hclog.SinkAdapter.
+func (s *asyncSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level == hclog.Off {
+		return
+	}
+	if slices.ContainsFunc(s.files, func(f Leveler) bool { return level >= f.GetLevel() }) {
+		s.SinkAdapter.Accept(name, level, msg, args...)
+	}
+}
+
+// AttachPluginSinks dispenses each logsink plugin in config.Logging.Sinks, registers a PluginProxySink shipping
+// records to it on Logger, and runs the sinks until ctx is canceled or the returned stop func is called. stop ships
+// the records still buffered, so it must be called before the plugins are shut down. Plugins that cannot be
+// dispensed are skipped and reported in the error.
+func (t *Topology) AttachPluginSinks(ctx context.Context,
+	dispense func(name string) (any, error)) (stop func(), err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var proxies []*PluginProxySink
+	var errs []error
+	// shipping failures go to the console only, so they are not forwarded back into a failing sink
+	sinkLogger := hclog.New(NewOptions(t.Logger.Name()+".sinks", hclog.Info, t.console, hclog.ColorOff, false, true))
+	for _, sc := range t.conf.Sinks {
+		raw, err := dispense(sc.Plugin)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sc.Plugin, err))
+			continue
+		}
+		s, ok := raw.(logsink.LogSink)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotLogSink, sc.Plugin))
+			continue
+		}
+		level := hclog.LevelFromString(sc.Level)
+		if level == hclog.NoLevel {
+			level = hclog.Info
+		}
+		proxy := NewPluginProxySink(s, level, sinkLogger.With(KeyPluginName, sc.Plugin)).
+			WithBatching(sc.BatchSize, time.Duration(sc.FlushInterval)*time.Millisecond)
+		t.register(LevelSinkPrefix+sc.Plugin, proxy)
+		proxies = append(proxies, proxy)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proxy.Run(ctx)
+		}()
+	}
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			for _, proxy := range proxies {
+				t.Logger.DeregisterSink(proxy)
+			}
+			cancel()
+			wg.Wait()
+		})
+	}
+	return stop, errors.Join(errs...)
+}
+
+// DeadLetters returns the records the log queue's worker could not log, oldest first.
+func (t *Topology) DeadLetters() ([]mq.DeadLetter, error) {
+	if t.queue == nil {
+		return nil, ErrNoLogQueue
+	}
+	return t.queue.DeadLetters().List()
+}
+
+// ReplayDeadLetters logs the dead letters with ids, every one when ids is empty, to the async files again, see
+// mq.ReplayDeadLetters.
+func (t *Topology) ReplayDeadLetters(ids ...string) (replayed, failed int, err error) {
+	if t.queue == nil {
+		return 0, 0, ErrNoLogQueue
+	}
+	return mq.ReplayDeadLetters(t.queue.DeadLetters(), ids, func(data []byte) error {
+		return logQueued(t.asyncLogger, data)
+	})
+}
+
+// register registers sink on Logger and, when its level can be changed, in Levels under name.
+func (t *Topology) register(name string, sink hclog.SinkAdapter) {
+	t.Logger.RegisterSink(sink)
+	if l, ok := sink.(Leveler); ok {
+		t.Levels.Register(name, l)
+	}
+}
+
+// Shutdown drains the log queue and closes every file Build opened, returning the errors joined. The sync and plugin
+// file sinks stay registered, so records logged afterwards reopen their files. It is safe to call more than once.
+func (t *Topology) Shutdown() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs []error
+	for _, closer := range slices.Backward(t.closers) {
+		errs = append(errs, closer())
+	}
+	t.closers = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,276 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/bmj2728/utils/pkg/strutil"
+	"github.com/hashicorp/go-hclog"
+)
+
+var (
+	// ErrSinkMissedRecords indicates that a sink did not receive every self-test record expected at its level.
+	ErrSinkMissedRecords = errors.New("sink did not receive all expected records")
+	// ErrSinkNotPersisted indicates that a file sink received records that were not found on disk.
+	ErrSinkNotPersisted = errors.New("sink records were not persisted")
+	// ErrSinkNotAttached indicates that a logsink plugin configured in config.Logging.Sinks could not be attached.
+	ErrSinkNotAttached = errors.New("sink plugin was not attached")
+	// ErrNoDispenser indicates that a self-test had no way to dispense the configured logsink plugins.
+	ErrNoDispenser = errors.New("no plugin dispenser")
+)
+
+// selfTestPlugin is the plugin whose file in config.Logging.PluginFiles.Dir receives the self-test records.
+const selfTestPlugin = "logcheck"
+
+// checkLevels are the levels emitted during a logging self-test, in ascending severity.
+var checkLevels = []hclog.Level{hclog.Trace, hclog.Debug, hclog.Info, hclog.Warn, hclog.Error}
+
+// CheckResult reports the outcome of the logging self-test for a single sink.
+type CheckResult struct {
+	Sink     string
+	Level    hclog.Level
+	Expected int
+	Received int
+	Err      error
+}
+
+// OK reports whether the sink received, and where applicable persisted, every expected record.
+func (c CheckResult) OK() bool {
+	return c.Err == nil
+}
+
+// String returns a single-line, human-readable summary of the result.
+func (c CheckResult) String() string {
+	status := "ok"
+	if c.Err != nil {
+		status = "FAIL: " + c.Err.Error()
+	}
+	return fmt.Sprintf("%-24s level=%-5s expected=%d received=%d %s",
+		c.Sink, c.Level.String(), c.Expected, c.Received, status)
+}
+
+// markerWriter wraps an io.Writer and counts the writes containing a self-test marker.
+type markerWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	marker []byte
+	count  int
+}
+
+// Write forwards p to the wrapped writer and counts it if it carries the marker.
+func (m *markerWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bytes.Contains(p, m.marker) {
+		m.count++
+	}
+	return m.out.Write(p)
+}
+
+// Count returns the number of marked writes seen so far.
+func (m *markerWriter) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count
+}
+
+// markerSink is an hclog.SinkAdapter counting the records at or above its level that carry a self-test marker, e.g.
+// the records the log queue's worker logs to the async files.
+type markerSink struct {
+	level  hclog.Level
+	marker string
+	count  atomic.Int64
+}
+
+// Accept counts the record if it is at or above the sink's level and carries the marker.
+func (m *markerSink) Accept(_ string, level hclog.Level, _ string, args ...interface{}) {
+	if level >= m.level && slices.ContainsFunc(args, func(arg any) bool { return arg == m.marker }) {
+		m.count.Add(1)
+	}
+}
+
+// markerLogSink wraps a logsink plugin and counts the marked records it shipped without an error.
+type markerLogSink struct {
+	logsink.LogSink
+	marker string
+	mu     sync.Mutex
+	count  int
+	err    error // the last error a batch of marked records was shipped with
+}
+
+// Write ships the records to the plugin and counts the marked ones once they are shipped.
+func (m *markerLogSink) Write(records []logsink.Record) error {
+	err := m.LogSink.Write(records)
+	marked := 0
+	for _, rec := range records {
+		if rec.Attrs["marker"] == m.marker {
+			marked++
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case err != nil && marked > 0:
+		m.err = err
+	case err == nil:
+		m.count += marked
+	}
+	return err
+}
+
+// result returns the marked records shipped so far and the last error shipping them.
+func (m *markerLogSink) result() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count, m.err
+}
+
+// SelfTest builds the logging pipeline the Logging config describes with Build, writing the console to out, which
+// defaults to os.Stdout when nil, and attaches the logsink plugins dispense returns, e.g. a plugin host's Dispense.
+// It logs a uniquely marked record at every level and, once the plugin sinks have shipped their records and the log
+// queue has drained, verifies that every sink registered in the topology's Levels received the records at or above
+// its level: the console, the file sinks, sync and async, and the plugin files by the records persisted to disk, and
+// the logsink plugins by the records they accepted. The log queue is checked for the records it must have passed on
+// to the async files, and a configured logsink plugin that could not be attached fails with the reason.
+func SelfTest(conf *config.Config, out io.Writer, dispense func(name string) (any, error)) []CheckResult {
+	if conf == nil {
+		conf = config.DefaultConfig()
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	if dispense == nil {
+		dispense = func(string) (any, error) { return nil, ErrNoDispenser }
+	}
+	marker := "logcheck-" + strutil.GenerateUUIDV7()
+	console := &markerWriter{out: out, marker: []byte(marker)}
+	t, err := build(conf, console)
+	if err != nil {
+		return []CheckResult{{Sink: "topology", Err: err}}
+	}
+
+	shipped := make(map[string]*markerLogSink)
+	unattached := make(map[string]error)
+	stop, _ := t.AttachPluginSinks(context.Background(), func(name string) (any, error) {
+		raw, err := dispense(name)
+		if err != nil {
+			unattached[name] = err
+			return nil, err
+		}
+		s, ok := raw.(logsink.LogSink)
+		if !ok {
+			unattached[name] = ErrNotLogSink
+			return raw, nil
+		}
+		shipped[name] = &markerLogSink{LogSink: s, marker: marker}
+		return shipped[name], nil
+	})
+	levels := t.Levels.Levels()
+	var queue *markerSink
+	if t.asyncLogger != nil {
+		// the async sink forwards the records any of the async files keeps
+		queue = &markerSink{level: hclog.Off, marker: marker}
+		for _, f := range conf.Logging.Files {
+			if f.Async {
+				queue.level = min(queue.level, hclog.LevelFromString(levels[LevelFilePrefix+f.Name]))
+			}
+		}
+		t.asyncLogger.RegisterSink(queue)
+	}
+
+	// records logged through a registered plugin logger also reach the plugin files
+	l := t.PluginFiles.Register(selfTestPlugin, t.Logger.Named("logcheck"))
+	for _, level := range checkLevels {
+		l.Log(level, "logging self-test record", "marker", marker)
+	}
+	stop()
+	shutdownErr := t.Shutdown()
+
+	files := make(map[string]config.LogFile, len(conf.Logging.Files))
+	for _, f := range conf.Logging.Files {
+		files[LevelFilePrefix+f.Name] = f
+	}
+	results := make([]CheckResult, 0, len(levels)+len(unattached)+1)
+	for _, name := range slices.Sorted(maps.Keys(levels)) {
+		res := expect(name, hclog.LevelFromString(levels[name]))
+		switch {
+		case name == LevelConsole:
+			res.Received = console.Count()
+		case name == LevelPluginFiles:
+			res.Received, res.Err = persisted(filepath.Join(conf.Logging.PluginFiles.Dir, selfTestPlugin+".log"),
+				marker, res.Expected)
+		case strings.HasPrefix(name, LevelFilePrefix):
+			f := files[name]
+			res.Received, res.Err = persisted(NewRotator(f.Filename, 0, 0, 0, false).Filename, marker, res.Expected)
+		case strings.HasPrefix(name, LevelSinkPrefix):
+			if s, ok := shipped[strings.TrimPrefix(name, LevelSinkPrefix)]; ok {
+				res.Received, res.Err = s.result()
+			}
+		}
+		if res.Err == nil && res.Received != res.Expected {
+			res.Err = ErrSinkMissedRecords
+		}
+		results = append(results, res)
+	}
+	for _, sc := range conf.Logging.Sinks {
+		err, ok := unattached[sc.Plugin]
+		if !ok {
+			continue
+		}
+		level := hclog.LevelFromString(sc.Level)
+		if level == hclog.NoLevel {
+			level = hclog.Info
+		}
+		res := expect(LevelSinkPrefix+sc.Plugin, level)
+		res.Err = fmt.Errorf("%w: %w", ErrSinkNotAttached, err)
+		results = append(results, res)
+	}
+	if queue != nil {
+		res := expect("queue", queue.level)
+		res.Received = int(queue.count.Load())
+		if res.Received != res.Expected {
+			res.Err = errors.Join(ErrSinkMissedRecords, shutdownErr)
+		}
+		results = append(results, res)
+	} else if shutdownErr != nil {
+		results = append(results, CheckResult{Sink: "topology", Err: shutdownErr})
+	}
+	return results
+}
+
+// expect returns the result of the named sink expecting the self-test records at or above level.
+func expect(name string, level hclog.Level) CheckResult {
+	res := CheckResult{Sink: name, Level: level}
+	for _, l := range checkLevels {
+		if l >= level {
+			res.Expected++
+		}
+	}
+	return res
+}
+
+// persisted returns the number of records carrying the marker in filename, and ErrSinkNotPersisted unless it is
+// expected.
+func persisted(filename, marker string, expected int) (int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, errors.Join(ErrSinkNotPersisted, err)
+	}
+	found := bytes.Count(data, []byte(marker))
+	if found != expected {
+		return found, fmt.Errorf("%w: found %d of %d records in %s", ErrSinkNotPersisted, found, expected, filename)
+	}
+	return found, nil
+}
@@ -45,10 +45,18 @@ const (
 	KeyWorkerID = "worker_id"
 	// KeyBatchErrors represents the logging key for storing or referencing batch error information.
 	KeyBatchErrors = "batch_errors"
+	// KeyBatchID represents the logging key for the identifier shared by all jobs submitted in one batch.
+	KeyBatchID = "batch_id"
 	// KeyJobMetrics represents the identifier key for job-related metrics in the system.
 	KeyJobMetrics = "job_metrics"
 	// KeyJobValue represents the value associated with a specific job in the job processing system.
 	KeyJobValue = "job_value"
+	// KeyJobType represents the key used to classify a job by the kind of work it performs.
+	KeyJobType = "job_type"
+	// KeyJobPlugin represents the key used to associate a job with the plugin it interacts with.
+	KeyJobPlugin = "plugin"
+	// KeyJobClass represents the key used to associate a job with the class its pool limits it by.
+	KeyJobClass = "job_class"
 	// KeyJobError represents the key used to record or identify errors associated
 	// with a specific job during processing.
 	KeyJobError = "job_error"
@@ -97,4 +105,33 @@ const (
 	KeyGroupSecurity = "security"
 	// KeyPluginAutoMTLS represents the configuration key for enabling or disabling automatic mTLS in plugins.
 	KeyPluginAutoMTLS = "auto_mtls"
+	// KeyStabilityScore represents the computed stability score of a plugin over the flap detection window.
+	KeyStabilityScore = "stability_score"
+	// KeyCrashCount represents the number of crashes recorded for a plugin within the flap detection window.
+	KeyCrashCount = "crash_count"
+	// KeyRestartCount represents the number of restarts recorded for a plugin within the flap detection window.
+	KeyRestartCount = "restart_count"
+	// KeyFlapWindow represents the sliding window used to evaluate plugin flapping.
+	KeyFlapWindow = "flap_window"
+	// KeyAgentID represents the identifier of a remote worker agent.
+	KeyAgentID = "agent_id"
+	// KeyAckID represents the acknowledgment identifier of a job pulled from a persistent queue.
+	KeyAckID = "ack_id"
+	// KeyNodeID represents the identifier of a host instance taking part in leader election.
+	KeyNodeID = "node_id"
+	// KeyLeaderID represents the identifier of the host instance currently holding leadership.
+	KeyLeaderID = "leader_id"
+	// KeyRunningFor represents how long a job has been running.
+	KeyRunningFor = "running_for"
+	// KeyThreshold represents a configured duration threshold.
+	KeyThreshold = "threshold"
+	// KeyStack represents a captured stack trace attached to a log record.
+	KeyStack = "stack"
+	// KeyPID represents the process ID of a plugin process.
+	KeyPID = "pid"
+	// KeyStream represents the output stream, stdout or stderr, a plugin process wrote a collected line to.
+	KeyStream = "stream"
+	// KeyRequestID represents the correlation ID of the external request, job submission, or reconcile a record
+	// belongs to.
+	KeyRequestID = "request_id"
 )
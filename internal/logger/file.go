@@ -1,8 +1,11 @@
 package logger
 
 import (
+	"github.com/bmj2728/PlugsConc/internal/config"
 	"github.com/hashicorp/go-hclog"
 	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
 )
 
 const DefaultLogFilename = "./logs/app.log"
@@ -48,6 +51,8 @@ func FileLogger(name string,
 	return hclog.New(&hclog.LoggerOptions{
 		Name:            name,
 		Level:           level,
+		TimeFn:          timestamp.Now,
+		TimeFormat:      timestamp.Format,
 		Output:          rotator,
 		Color:           color,
 		IncludeLocation: includeLocation,
@@ -66,9 +71,42 @@ func FileSink(name string,
 	return hclog.NewSinkAdapter(&hclog.LoggerOptions{
 		Name:            name,
 		Level:           level,
+		TimeFn:          timestamp.Now,
+		TimeFormat:      timestamp.Format,
 		Output:          rotator,
 		Color:           color,
 		IncludeLocation: includeLocation,
 		JSONFormat:      isJSON,
 	})
 }
+
+// LevelFileSinks creates a rotating file sink for each configured LogFile. Each sink filters records by its own
+// level, so splitting output by severity is a matter of declaring one file per level in the Logging config.
+func LevelFileSinks(files []config.LogFile) []hclog.SinkAdapter {
+	sinks := make([]hclog.SinkAdapter, 0, len(files))
+	for _, f := range files {
+		sink, _ := levelFileSink(f)
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// levelFileSink creates the rotating file sink for f and returns it with its rotator, so the file can be closed.
+func levelFileSink(f config.LogFile) (hclog.SinkAdapter, *lumberjack.Logger) {
+	level := hclog.LevelFromString(f.Level)
+	if level == hclog.NoLevel {
+		level = hclog.Info
+	}
+	rotator := NewRotator(f.Filename, f.MaxSize, f.MaxBackups, f.MaxAge, f.Compress)
+	return FileSink(f.Name, level, rotator, hclog.ColorOff, f.IncludeLocation, f.JSON), rotator
+}
+
+// RegisterFileSinks creates the configured level-filtered file sinks and registers each of them on the given
+// intercept logger, returning the registered sinks so they can be deregistered later.
+func RegisterFileSinks(intercept hclog.InterceptLogger, files []config.LogFile) []hclog.SinkAdapter {
+	sinks := LevelFileSinks(files)
+	for _, s := range sinks {
+		intercept.RegisterSink(s)
+	}
+	return sinks
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultTopErrors is the number of fingerprints included in each periodic error summary.
+const DefaultTopErrors = 10
+
+// hclogPackage is the import path prefix of hclog, whose frames are skipped when locating the caller of a record.
+const hclogPackage = "github.com/hashicorp/go-hclog"
+
+// digitRuns matches numbers embedded in messages, which are normalized out of the message template so records that
+// differ only by a count, port, or ID share a fingerprint.
+var digitRuns = regexp.MustCompile(`\d+`)
+
+// ErrorStats counts the occurrences of one error fingerprint.
+type ErrorStats struct {
+	Fingerprint string    `json:"fingerprint"`
+	Logger      string    `json:"logger"`
+	Level       string    `json:"level"`
+	Template    string    `json:"template"`
+	Frame       string    `json:"frame"`
+	Count       int       `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// ErrorFingerprinter is an hclog sink that fingerprints error records by their message template and the top stack
+// frame that emitted them, keeping an occurrence counter for each fingerprint. Register it on an intercept logger
+// with RegisterSink; Top reports the most frequent errors without an external log system.
+type ErrorFingerprinter struct {
+	mu    sync.RWMutex
+	level hclog.Level
+	stats map[string]*ErrorStats
+}
+
+// NewErrorFingerprinter creates an ErrorFingerprinter that counts records at or above level, typically hclog.Error
+// or hclog.Warn.
+func NewErrorFingerprinter(level hclog.Level) *ErrorFingerprinter {
+	return &ErrorFingerprinter{
+		level: level,
+		stats: make(map[string]*ErrorStats),
+	}
+}
+
+// Accept records an occurrence of the record's fingerprint. It implements hclog.SinkAdapter.
+func (f *ErrorFingerprinter) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < f.level || level == hclog.Off {
+		return
+	}
+	template := digitRuns.ReplaceAllString(msg, "N")
+	frame := callerFrame()
+	sum := sha1.Sum([]byte(name + "\x00" + template + "\x00" + frame))
+	fp := hex.EncodeToString(sum[:6])
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.stats[fp]
+	if !ok {
+		s = &ErrorStats{
+			Fingerprint: fp,
+			Logger:      name,
+			Level:       level.String(),
+			Template:    template,
+			Frame:       frame,
+			FirstSeen:   now,
+		}
+		f.stats[fp] = s
+	}
+	s.Count++
+	s.LastSeen = now
+	if errText := errorArg(args); errText != "" {
+		s.LastError = errText
+	}
+}
+
+// Top returns up to n fingerprints, most frequent first.
+func (f *ErrorFingerprinter) Top(n int) []ErrorStats {
+	f.mu.RLock()
+	all := make([]ErrorStats, 0, len(f.stats))
+	for _, s := range f.stats {
+		all = append(all, *s)
+	}
+	f.mu.RUnlock()
+	slices.SortFunc(all, func(a, b ErrorStats) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+	if n > 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+// Reset clears every counter.
+func (f *ErrorFingerprinter) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats = make(map[string]*ErrorStats)
+}
+
+// RunSummaries emits a summary record of the top n fingerprints to summaryLogger every interval until ctx is
+// canceled. Summaries are logged at info so they are never fingerprinted themselves.
+func (f *ErrorFingerprinter) RunSummaries(ctx context.Context, interval time.Duration, n int,
+	summaryLogger hclog.Logger) {
+	if summaryLogger == nil {
+		summaryLogger = hclog.Default()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			top := f.Top(n)
+			if len(top) == 0 {
+				continue
+			}
+			lines := make([]string, 0, len(top))
+			for _, s := range top {
+				lines = append(lines, fmt.Sprintf("%s x%d %s (%s)", s.Fingerprint, s.Count, s.Template, s.Frame))
+			}
+			summaryLogger.Info("Top errors", "fingerprints", len(top), "errors", strings.Join(lines, "; "))
+		}
+	}
+}
+
+// callerFrame returns "function file:line" for the first frame outside the runtime, hclog, and this package's
+// fingerprinting, i.e. the host code that emitted the record.
+func callerFrame() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, hclogPackage) &&
+			!strings.HasPrefix(frame.Function, "runtime.") &&
+			!strings.Contains(frame.Function, "internal/logger.(*ErrorFingerprinter)") {
+			return fmt.Sprintf("%s %s:%d", frame.Function, trimPath(frame.File), frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// trimPath shortens a file path to its last two elements.
+func trimPath(file string) string {
+	parts := strings.Split(file, "/")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// errorArg returns the text of the record's error attribute, if any, under KeyError or the plain "error" key.
+func errorArg(args []interface{}) string {
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok && (key == KeyError || key == "error") {
+			return fmt.Sprint(args[i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,257 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mattn/go-isatty"
+)
+
+// hclog JSON fields rendered in the line header rather than as attributes.
+const (
+	jsonFieldLevel     = "@level"
+	jsonFieldMessage   = "@message"
+	jsonFieldModule    = "@module"
+	jsonFieldTimestamp = "@timestamp"
+	jsonFieldCaller    = "@caller"
+)
+
+// FormatHuman renders console records as colored, human-readable lines.
+// FormatJSON writes console records as the JSON objects hclog emits, one per line, for log shippers and jq.
+const (
+	FormatHuman = "human"
+	FormatJSON  = "json"
+)
+
+// Formats lists the supported console formats.
+var Formats = []string{FormatHuman, FormatJSON}
+
+// ColorAuto colors human-readable output only when it is written to a terminal.
+// ColorAlways colors human-readable output wherever it is written.
+// ColorNever never colors human-readable output.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ParseColorMode returns the hclog.ColorOption named by one of ColorAuto, ColorAlways, or ColorNever, defaulting to
+// hclog.AutoColor.
+func ParseColorMode(name string) hclog.ColorOption {
+	switch strings.ToLower(name) {
+	case ColorAlways:
+		return hclog.ForceColor
+	case ColorNever:
+		return hclog.ColorOff
+	default:
+		return hclog.AutoColor
+	}
+}
+
+// IsTerminal reports whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// HumanWriter renders the JSON records written by an hclog logger as colored, human-readable lines.
+// Rendering from JSON rather than hclog's own text format lets the colors come from a Theme, with 256-color and
+// truecolor support, instead of hclog's fixed 16-color palette. Lines that are not JSON are passed through.
+type HumanWriter struct {
+	mu           sync.Mutex
+	out          io.Writer
+	theme        *Theme
+	moduleWidth  int      // pad the module column to this width when aligning, 0 disables alignment
+	priorityKeys []string // attributes rendered first, in this order
+	format       string   // FormatHuman or FormatJSON
+	buf          []byte
+}
+
+// NewHumanWriter creates a HumanWriter that writes to out using theme. A nil theme disables colors.
+func NewHumanWriter(out io.Writer, theme *Theme) *HumanWriter {
+	if theme == nil {
+		theme = AvailableThemes[ThemeNone]
+	}
+	return &HumanWriter{out: out, theme: theme, format: FormatHuman}
+}
+
+// WithFormat selects how records are written, FormatHuman or FormatJSON, and returns the updated HumanWriter. With
+// FormatJSON the records hclog emits are written unchanged, so level filtering still applies but no colors are added.
+// Unknown formats select FormatHuman.
+func (h *HumanWriter) WithFormat(format string) *HumanWriter {
+	h.format = FormatHuman
+	if format == FormatJSON {
+		h.format = FormatJSON
+	}
+	return h
+}
+
+// WithColor sets when the theme's colors are applied, and returns the updated HumanWriter: hclog.AutoColor colors
+// the output only when it is written to a terminal, hclog.ColorOff never colors it, and hclog.ForceColor, the default,
+// always does.
+func (h *HumanWriter) WithColor(color hclog.ColorOption) *HumanWriter {
+	if color == hclog.ColorOff || (color == hclog.AutoColor && !IsTerminal(h.out)) {
+		h.theme = AvailableThemes[ThemeNone]
+	}
+	return h
+}
+
+// WithAlignment pads the module column to width so messages line up across loggers, and returns the updated
+// HumanWriter. The level column is always padded. A width of zero disables alignment.
+func (h *HumanWriter) WithAlignment(width int) *HumanWriter {
+	h.moduleWidth = max(width, 0)
+	return h
+}
+
+// WithPriorityKeys renders the given attributes first, in order, ahead of the remaining attributes in sorted
+// order, and returns the updated HumanWriter. Keeping e.g. job_id and plugin in a fixed position makes high-volume
+// output easier to scan.
+func (h *HumanWriter) WithPriorityKeys(keys ...string) *HumanWriter {
+	h.priorityKeys = keys
+	return h
+}
+
+// Write renders every complete line in p, buffering any trailing partial line until the next Write.
+func (h *HumanWriter) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf = append(h.buf, p...)
+	for {
+		i := bytes.IndexByte(h.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := h.buf[:i]
+		h.buf = h.buf[i+1:]
+		rendered := string(line) + "\n"
+		if h.format != FormatJSON {
+			rendered = h.render(line)
+		}
+		if _, err := io.WriteString(h.out, rendered); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// render converts one JSON record into a human-readable line ending in a newline.
+func (h *HumanWriter) render(line []byte) string {
+	var rec map[string]any
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return string(line) + "\n"
+	}
+	level := hclog.LevelFromString(fmt.Sprint(rec[jsonFieldLevel]))
+	var b strings.Builder
+	if ts, ok := rec[jsonFieldTimestamp].(string); ok {
+		b.WriteString(h.theme.Timestamp.Wrap(ts))
+		b.WriteByte(' ')
+	}
+	label := levelLabel(level)
+	b.WriteString(h.theme.Level(level).Wrap(label))
+	b.WriteString(strings.Repeat(" ", max(1, 8-len(label))))
+	if caller, ok := rec[jsonFieldCaller].(string); ok {
+		b.WriteString(caller)
+		b.WriteString(": ")
+	}
+	module, _ := rec[jsonFieldModule].(string)
+	switch {
+	case h.moduleWidth > 0:
+		// the colon stays attached to the name so the padding lines up the messages
+		b.WriteString(h.theme.Module.Wrap(module + ":"))
+		b.WriteString(strings.Repeat(" ", max(1, h.moduleWidth+1-len(module))))
+	case module != "":
+		b.WriteString(h.theme.Module.Wrap(module))
+		b.WriteString(": ")
+	}
+	b.WriteString(fmt.Sprint(rec[jsonFieldMessage]))
+
+	keys := h.orderKeys(rec)
+	if len(keys) > 0 {
+		b.WriteByte(':')
+	}
+	for _, k := range keys {
+		b.WriteByte(' ')
+		b.WriteString(h.theme.Key.Wrap(k))
+		b.WriteByte('=')
+		b.WriteString(formatValue(rec[k]))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// orderKeys returns the record's attribute keys with the priority keys first, in their configured order, followed
+// by the rest in sorted order.
+func (h *HumanWriter) orderKeys(rec map[string]any) []string {
+	keys := make([]string, 0, len(rec))
+	for _, k := range h.priorityKeys {
+		if _, ok := rec[k]; ok && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	priority := len(keys)
+	for k := range rec {
+		if !strings.HasPrefix(k, "@") && !slices.Contains(keys[:priority], k) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys[priority:])
+	return keys
+}
+
+// levelLabel returns hclog's bracketed label for the level, e.g. "[INFO]".
+func levelLabel(level hclog.Level) string {
+	return "[" + strings.ToUpper(level.String()) + "]"
+}
+
+// formatValue renders an attribute value, quoting strings that contain spaces or quotes.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		if strings.Contains(val, "\n") {
+			// multi-line values, e.g. stack traces, are indented beneath the record as hclog does
+			return "\n  | " + strings.ReplaceAll(strings.TrimRight(val, "\n"), "\n", "\n  | ")
+		}
+		if strings.ContainsAny(val, " \t\"=") {
+			return fmt.Sprintf("%q", val)
+		}
+		return val
+	case map[string]any, []any:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	case nil:
+		return "<nil>"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ThemedMultiLogger creates an intercept logger like MultiLogger whose console output is rendered by a HumanWriter
+// using theme. Sinks registered on the returned logger keep their own formats.
+func ThemedMultiLogger(name string, level hclog.Level, theme *Theme, includeLocation bool) hclog.InterceptLogger {
+	return HumanMultiLogger(name, level, NewHumanWriter(os.Stdout, theme), includeLocation)
+}
+
+// HumanMultiLogger creates an intercept logger like MultiLogger whose console output is rendered by the given
+// HumanWriter, e.g. one configured with WithAlignment and WithPriorityKeys.
+func HumanMultiLogger(name string, level hclog.Level, out *HumanWriter, includeLocation bool) hclog.InterceptLogger {
+	return hclog.NewInterceptLogger(&hclog.LoggerOptions{
+		Name:            name,
+		Level:           level,
+		TimeFn:          timestamp.Now,
+		TimeFormat:      timestamp.Format,
+		Output:          out,
+		IncludeLocation: includeLocation,
+		SyncParentLevel: true,
+		JSONFormat:      true})
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/hashicorp/go-hclog"
+)
+
+// LevelConsole names the host's console logger in a LevelController, LevelPluginFiles the PluginLogFiles sink,
+// LevelFilePrefix, followed by the configured name, each of the file sinks in config.Logging.Files, and
+// LevelSinkPrefix, followed by the plugin name, each of the logsink plugins in config.Logging.Sinks.
+const (
+	LevelConsole     = "console"
+	LevelPluginFiles = "plugin_files"
+	LevelFilePrefix  = "files."
+	LevelSinkPrefix  = "sinks."
+)
+
+// Leveler is a logger or sink whose level can be changed at runtime. hclog loggers implement it, as do the sinks
+// created by FileSink, PluginLogFiles, and PluginProxySink.
+type Leveler interface {
+	SetLevel(level hclog.Level)
+	GetLevel() hclog.Level
+}
+
+// LevelController changes the levels of the running host's console logger and log sinks without a restart, e.g. on
+// SIGHUP from a re-read config with Apply or from the admin API with SetLevel. The console logger filters what it
+// writes by its own level while every sink filters what it receives by its own, so each is registered separately.
+// The per-logger levels of plugin client loggers are kept by a LevelRegistry instead.
+type LevelController struct {
+	mu      sync.RWMutex
+	targets map[string]Leveler
+}
+
+// NewLevelController creates an empty LevelController.
+func NewLevelController() *LevelController {
+	return &LevelController{targets: make(map[string]Leveler)}
+}
+
+// Register tracks target under name, replacing any target previously registered under it.
+func (c *LevelController) Register(name string, target Leveler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.targets[name] = target
+}
+
+// RegisterFileSinks registers the sinks created for files by the package's RegisterFileSinks, in the same order,
+// under LevelFilePrefix and their configured names.
+func (c *LevelController) RegisterFileSinks(files []config.LogFile, sinks []hclog.SinkAdapter) {
+	for i, s := range sinks {
+		if l, ok := s.(Leveler); ok && i < len(files) {
+			c.Register(LevelFilePrefix+files[i].Name, l)
+		}
+	}
+}
+
+// SetLevel changes the level of the target registered under name.
+func (c *LevelController) SetLevel(name string, level hclog.Level) error {
+	if level == hclog.NoLevel {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, level.String())
+	}
+	c.mu.RLock()
+	target, ok := c.targets[name]
+	c.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q (registered: %s)", ErrUnknownLogger, name, strings.Join(c.Names(), ", "))
+	}
+	target.SetLevel(level)
+	return nil
+}
+
+// Apply sets the level of every registered target to the one conf gives it, e.g. after the config file was re-read,
+// and returns the names of the targets whose level changed. Targets conf does not mention keep their level, and
+// file sinks conf adds are reported as errors, since sinks are only created at startup.
+func (c *LevelController) Apply(conf config.Logging) ([]string, error) {
+	levels := map[string]string{LevelConsole: conf.Level}
+	if conf.PluginFiles.Enabled {
+		levels[LevelPluginFiles] = conf.PluginFiles.Level
+	}
+	for _, f := range conf.Files {
+		levels[LevelFilePrefix+f.Name] = f.Level
+	}
+	for _, s := range conf.Sinks {
+		levels[LevelSinkPrefix+s.Plugin] = s.Level
+	}
+	var changed []string
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(levels)) {
+		level, err := ParseLevel(levels[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		c.mu.RLock()
+		target, ok := c.targets[name]
+		c.mu.RUnlock()
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%w: %q, restart to add it", ErrUnknownLogger, name))
+		case ok && target.GetLevel() != level:
+			target.SetLevel(level)
+			changed = append(changed, name)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// Names returns the names of the registered targets, sorted.
+func (c *LevelController) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.targets))
+}
+
+// Levels returns the current level of every registered target, keyed by name.
+func (c *LevelController) Levels() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	levels := make(map[string]string, len(c.targets))
+	for name, target := range c.targets {
+		levels[name] = target.GetLevel().String()
+	}
+	return levels
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrUnknownLogger indicates that no logger is registered under the requested name.
+// ErrInvalidLevel indicates that a level name could not be parsed.
+var (
+	ErrUnknownLogger = errors.New("unknown logger")
+	ErrInvalidLevel  = errors.New("invalid log level")
+)
+
+// LevelRegistry tracks named loggers, e.g. the client logger of each plugin, so their levels can be changed at
+// runtime without affecting other loggers. Loggers must descend from a logger created with SyncParentLevel, as
+// MultiLogger and HumanMultiLogger are, so that a level set on one logger applies to it and to its subloggers,
+// including the one go-plugin forwards plugin output through, while its siblings keep their own level.
+type LevelRegistry struct {
+	mu      sync.RWMutex
+	loggers map[string]hclog.Logger
+}
+
+// NewLevelRegistry creates an empty LevelRegistry.
+func NewLevelRegistry() *LevelRegistry {
+	return &LevelRegistry{loggers: make(map[string]hclog.Logger)}
+}
+
+// Register tracks l under name, replacing any logger previously registered under it, and returns l so it can be
+// passed straight to e.g. plugin.ClientConfig.
+func (r *LevelRegistry) Register(name string, l hclog.Logger) hclog.Logger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loggers[name] = l
+	return l
+}
+
+// Unregister stops tracking the logger registered under name.
+func (r *LevelRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.loggers, name)
+}
+
+// SetLevel changes the level of the logger registered under name. The level stays in effect until it is set again
+// on this logger or on one of its parents.
+func (r *LevelRegistry) SetLevel(name string, level hclog.Level) error {
+	if level == hclog.NoLevel {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, level.String())
+	}
+	r.mu.RLock()
+	l, ok := r.loggers[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q (registered: %s)", ErrUnknownLogger, name, strings.Join(r.Names(), ", "))
+	}
+	l.SetLevel(level)
+	return nil
+}
+
+// Level returns the current level of the logger registered under name.
+func (r *LevelRegistry) Level(name string) (hclog.Level, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.loggers[name]
+	if !ok {
+		return hclog.NoLevel, fmt.Errorf("%w: %q", ErrUnknownLogger, name)
+	}
+	return l.GetLevel(), nil
+}
+
+// Levels returns the current level of every registered logger, keyed by name.
+func (r *LevelRegistry) Levels() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	levels := make(map[string]string, len(r.loggers))
+	for name, l := range r.loggers {
+		levels[name] = l.GetLevel().String()
+	}
+	return levels
+}
+
+// Names returns the names of the registered loggers in sorted order.
+func (r *LevelRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.loggers))
+}
+
+// ParseLevel parses a level name such as "trace" or "WARN", rejecting names hclog does not recognize.
+func ParseLevel(s string) (hclog.Level, error) {
+	level := hclog.LevelFromString(s)
+	if level == hclog.NoLevel {
+		return hclog.NoLevel, fmt.Errorf("%w: %q", ErrInvalidLevel, s)
+	}
+	return level, nil
+}
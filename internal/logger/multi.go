@@ -3,6 +3,7 @@ package logger
 import (
 	"os"
 
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
 	"github.com/hashicorp/go-hclog"
 )
 
@@ -10,6 +11,7 @@ import (
 // location inclusion, and JSON option. The primary logger must write to stdout.
 // Additional locations can be added to the logger by calling RegisterSink on the returned logger.
 // A sink is created by passing hclog.LoggerOptions to NewSinkAdapter - no changes are needed to the options.
+// A level set on a named sublogger applies to it and its own subloggers only, so it can be tracked in a LevelRegistry.
 func MultiLogger(name string,
 	level hclog.Level,
 	color hclog.ColorOption,
@@ -18,9 +20,12 @@ func MultiLogger(name string,
 	return hclog.NewInterceptLogger(&hclog.LoggerOptions{
 		Name:            name,
 		Level:           level,
+		TimeFn:          timestamp.Now,
+		TimeFormat:      timestamp.Format,
 		Output:          os.Stdout,
 		Color:           color,
 		IncludeLocation: includeLocation,
+		SyncParentLevel: true,
 		JSONFormat:      isJSON})
 }
 
@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
 	"github.com/goptics/varmq"
 	"github.com/hashicorp/go-hclog"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -19,6 +20,8 @@ func NewOptions(name string,
 	return &hclog.LoggerOptions{
 		Name:            name,
 		Level:           level,
+		TimeFn:          timestamp.Now,
+		TimeFormat:      timestamp.Format,
 		Output:          output,
 		Color:           color,
 		IncludeLocation: includeLocation,
@@ -82,3 +85,12 @@ func AsyncOptions(name string,
 	output := NewAsyncWriter(queue)
 	return NewOptions(name, level, output, color, includeLocation, isJson)
 }
+
+// PluginOptions configures and returns a pointer to hclog.LoggerOptions writing JSON records to a logsink plugin
+// through sink, by way of a PluginWriter.
+func PluginOptions(name string,
+	level hclog.Level,
+	sink *PluginProxySink,
+	includeLocation bool) *hclog.LoggerOptions {
+	return NewOptions(name, level, NewPluginWriter(sink), hclog.ColorOff, includeLocation, true)
+}
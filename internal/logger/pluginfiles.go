@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/hashicorp/go-hclog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// PluginLogFiles is an hclog.SinkAdapter giving every plugin a rotating log file of its own, Dir/<plugin>.log. It
+// is registered as a sink on the host's intercept logger and keeps the records of the loggers registered for a
+// plugin with Register, and of their named subloggers, e.g. the one go-plugin logs the plugin's process through.
+// A plugin's file is created with its first record.
+type PluginLogFiles struct {
+	conf    config.PluginLogFiles
+	level   atomic.Int32 // hclog.Level of the records kept
+	mu      sync.RWMutex
+	plugins map[string]string // plugin name by logger name
+	files   map[string]*pluginFile
+}
+
+// pluginFile is the rotating file sink of one plugin.
+type pluginFile struct {
+	sink    hclog.SinkAdapter
+	rotator *lumberjack.Logger
+}
+
+// NewPluginLogFiles creates a PluginLogFiles writing to conf.Dir with conf's level and rotation settings.
+func NewPluginLogFiles(conf config.PluginLogFiles) *PluginLogFiles {
+	level := hclog.LevelFromString(conf.Level)
+	if level == hclog.NoLevel {
+		level = hclog.Trace
+	}
+	f := &PluginLogFiles{
+		conf:    conf,
+		plugins: make(map[string]string),
+		files:   make(map[string]*pluginFile),
+	}
+	f.SetLevel(level)
+	return f
+}
+
+// SetLevel changes the level of the records written to every plugin's file.
+func (f *PluginLogFiles) SetLevel(level hclog.Level) {
+	f.level.Store(int32(level))
+}
+
+// GetLevel returns the level of the records written to every plugin's file.
+func (f *PluginLogFiles) GetLevel() hclog.Level {
+	return hclog.Level(f.level.Load())
+}
+
+// Register routes the records of l and its subloggers to the named plugin's file and returns l, so it can be
+// chained like LevelRegistry.Register. It does nothing on a nil PluginLogFiles.
+func (f *PluginLogFiles) Register(plugin string, l hclog.Logger) hclog.Logger {
+	if f == nil {
+		return l
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.plugins[l.Name()] = plugin
+	return l
+}
+
+// Accept writes the record to the file of the plugin whose logger, or one of its subloggers, it was logged through,
+// and drops the records of every other logger.
+func (f *PluginLogFiles) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < f.GetLevel() {
+		return
+	}
+	plugin, ok := f.plugin(name)
+	if !ok {
+		return
+	}
+	f.file(plugin).sink.Accept(name, level, msg, args...)
+}
+
+// Close closes every plugin's file. A record logged afterwards opens its file again.
+func (f *PluginLogFiles) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var errs []error
+	for _, pf := range f.files {
+		errs = append(errs, pf.rotator.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// plugin returns the plugin whose registered logger is name or the nearest of its parents.
+func (f *PluginLogFiles) plugin(name string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for {
+		if plugin, ok := f.plugins[name]; ok {
+			return plugin, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return "", false
+		}
+		name = name[:i]
+	}
+}
+
+// file returns the plugin's file sink, creating it on first use.
+func (f *PluginLogFiles) file(plugin string) *pluginFile {
+	f.mu.RLock()
+	pf, ok := f.files[plugin]
+	f.mu.RUnlock()
+	if ok {
+		return pf
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pf, ok := f.files[plugin]; ok {
+		return pf
+	}
+	// plugin names are directory names, so the base name only guards against a name escaping Dir
+	rotator := NewRotator(filepath.Join(f.conf.Dir, filepath.Base(plugin)+".log"), f.conf.MaxSize, f.conf.MaxBackups,
+		f.conf.MaxAge, f.conf.Compress)
+	pf = &pluginFile{
+		// records are filtered by Accept, so the level can change without touching each file's sink
+		sink:    FileSink(plugin, hclog.Trace, rotator, hclog.ColorOff, false, f.conf.JSON),
+		rotator: rotator,
+	}
+	f.files[plugin] = pf
+	return pf
+}
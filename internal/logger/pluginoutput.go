@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// StreamStdout and StreamStderr name the plugin process stream a collected line was read from.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// PluginOutput is an io.Writer collecting one output stream of a plugin process. Each complete line is parsed with
+// LogEntry.UnmarshalJSON and re-emitted into the host logger at the level the plugin logged it, tagged with the
+// plugin name, the process ID, and the stream. Lines that are not hclog JSON are re-emitted as the message of an info
+// record when read from stdout and of a warn record when read from stderr, where plain output is usually a panic.
+// With an async writer set, the tagged records are written to it as JSON lines instead, e.g. to an AsyncWriter whose
+// queue is worked by LogQueue, so a chatty plugin does not block on the host's sinks.
+type PluginOutput struct {
+	mu      sync.Mutex
+	logger  hclog.Logger
+	async   io.Writer
+	plugin  string
+	stream  string
+	pid     func() int
+	partial []byte // the start of a line whose end has not been written yet
+}
+
+// NewPluginOutput creates a PluginOutput re-emitting the stream of the named plugin into l. pid is called for every
+// line and returns the plugin's process ID, or 0 while it is not known; it may be nil.
+func NewPluginOutput(l hclog.Logger, plugin, stream string, pid func() int) *PluginOutput {
+	if l == nil {
+		l = hclog.Default()
+	}
+	return &PluginOutput{logger: l, plugin: plugin, stream: stream, pid: pid}
+}
+
+// WithAsync writes the collected records to w as JSON lines instead of re-emitting them into the logger, and returns
+// the updated PluginOutput.
+func (o *PluginOutput) WithAsync(w io.Writer) *PluginOutput {
+	o.async = w
+	return o
+}
+
+// Write re-emits every complete line of p, keeping an incomplete last line until the rest of it is written. It
+// always consumes all of p.
+func (o *PluginOutput) Write(p []byte) (n int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data := append(o.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		o.emit(data[:i])
+		data = data[i+1:]
+	}
+	o.partial = append(o.partial[:0], data...)
+	return len(p), nil
+}
+
+// emit re-emits one line, skipping blank ones.
+func (o *PluginOutput) emit(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	entry, level := o.entry(line)
+	args := make([]any, 0, 2*len(entry.Fields)+12)
+	for k, v := range entry.Fields {
+		args = append(args, k, v)
+	}
+	if entry.Module != "" {
+		args = append(args, "module", entry.Module)
+	}
+	if entry.Caller != "" {
+		args = append(args, "caller", entry.Caller)
+	}
+	if entry.Timestamp != "" {
+		args = append(args, "orig_timestamp", timestamp.Normalize(entry.Timestamp))
+	}
+	args = append(args, KeyPluginName, o.plugin, KeyStream, o.stream)
+	if o.pid != nil {
+		if pid := o.pid(); pid > 0 {
+			args = append(args, KeyPID, pid)
+		}
+	}
+	if o.async == nil {
+		o.logger.Log(level, entry.Message, args...)
+		return
+	}
+	record := map[string]any{
+		"@level":     level.String(),
+		"@message":   entry.Message,
+		"@module":    o.logger.Name(),
+		"@timestamp": timestamp.String(timestamp.Now()),
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		record[args[i].(string)] = args[i+1]
+	}
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = o.async.Write(append(data, '\n'))
+	}
+	if err != nil {
+		o.logger.Warn("Failed to queue plugin output", KeyPluginName, o.plugin, KeyStream, o.stream, KeyError, err)
+		o.logger.Log(level, entry.Message, args...)
+	}
+}
+
+// entry parses line as an hclog JSON record, falling back to a record whose message is the whole line, and returns
+// it with the level it is re-emitted at.
+func (o *PluginOutput) entry(line []byte) (LogEntry, hclog.Level) {
+	var entry LogEntry
+	if line[0] == '{' && entry.UnmarshalJSON(line) == nil {
+		level := hclog.LevelFromString(entry.Level)
+		if level == hclog.NoLevel || level == hclog.Off {
+			level = hclog.Info
+		}
+		return entry, level
+	}
+	entry = LogEntry{Message: string(line)}
+	if o.stream == StreamStderr {
+		return entry, hclog.Warn
+	}
+	return entry, hclog.Info
+}
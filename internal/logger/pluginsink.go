@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultSinkBatchSize is the largest batch of records a PluginProxySink ships in one call.
+// DefaultSinkFlushInterval is how often a PluginProxySink ships a partial batch.
+// DefaultSinkBuffer is the number of records a PluginProxySink buffers before dropping new ones.
+const (
+	DefaultSinkBatchSize     = 100
+	DefaultSinkFlushInterval = time.Second
+	DefaultSinkBuffer        = 4096
+)
+
+// PluginProxySink is an hclog sink that forwards the host's log records to a logsink plugin in batches, so shipping
+// to Loki, Elasticsearch, or S3 is a plugin concern rather than host code. Accept never blocks the logger: records
+// are buffered and shipped by Run, and records arriving while the buffer is full are dropped and counted.
+type PluginProxySink struct {
+	sink          logsink.LogSink
+	level         atomic.Int32 // hclog.Level of the records shipped
+	records       chan logsink.Record
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Uint64
+	sinkLogger    hclog.Logger
+}
+
+// NewPluginProxySink creates a PluginProxySink that ships records at or above level to sink. Shipping failures are
+// reported to sinkLogger, which must not itself forward into this sink.
+func NewPluginProxySink(sink logsink.LogSink, level hclog.Level, sinkLogger hclog.Logger) *PluginProxySink {
+	if sinkLogger == nil {
+		sinkLogger = hclog.Default()
+	}
+	p := &PluginProxySink{
+		sink:          sink,
+		records:       make(chan logsink.Record, DefaultSinkBuffer),
+		batchSize:     DefaultSinkBatchSize,
+		flushInterval: DefaultSinkFlushInterval,
+		sinkLogger:    sinkLogger,
+	}
+	p.SetLevel(level)
+	return p
+}
+
+// SetLevel changes the level of the records shipped to the plugin.
+func (p *PluginProxySink) SetLevel(level hclog.Level) {
+	p.level.Store(int32(level))
+}
+
+// GetLevel returns the level of the records shipped to the plugin.
+func (p *PluginProxySink) GetLevel() hclog.Level {
+	return hclog.Level(p.level.Load())
+}
+
+// WithBatching sets the largest batch shipped in one call and how often a partial batch is shipped, and returns the
+// updated PluginProxySink. Non-positive values keep the defaults. It must be called before Run.
+func (p *PluginProxySink) WithBatching(size int, interval time.Duration) *PluginProxySink {
+	if size > 0 {
+		p.batchSize = size
+	}
+	if interval > 0 {
+		p.flushInterval = interval
+	}
+	return p
+}
+
+// Accept buffers the record for shipping. It implements hclog.SinkAdapter.
+func (p *PluginProxySink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < p.GetLevel() || level == hclog.Off {
+		return
+	}
+	p.enqueue(newRecord(name, level, msg, args))
+}
+
+// enqueue buffers rec for shipping, dropping and counting it when the buffer is full.
+func (p *PluginProxySink) enqueue(rec logsink.Record) {
+	select {
+	case p.records <- rec:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// newRecord converts an hclog record to a logsink.Record, rendering its attributes as strings in Attrs and keeping
+// their JSON types in Fields.
+func newRecord(name string, level hclog.Level, msg string, args []interface{}) logsink.Record {
+	rec := logsink.Record{
+		Time:    timestamp.Now(),
+		Level:   level.String(),
+		Logger:  name,
+		Message: msg,
+		Attrs:   make(map[string]string, (len(args)+1)/2),
+		Fields:  make(map[string]any, (len(args)+1)/2),
+	}
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			rec.Attrs["EXTRA_VALUE_AT_END"] = fmt.Sprint(args[i])
+			rec.Fields["EXTRA_VALUE_AT_END"] = fieldValue(args[i])
+			break
+		}
+		key := fmt.Sprint(args[i])
+		rec.Attrs[key] = fmt.Sprint(args[i+1])
+		rec.Fields[key] = fieldValue(args[i+1])
+	}
+	return rec
+}
+
+// fieldValue converts an attribute value to the value shipped in a record's Fields: scalars are kept, times,
+// durations, errors, and Stringers are rendered as strings, and other values take the shape of their JSON encoding.
+func fieldValue(v any) any {
+	switch v := v.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case time.Time:
+		return timestamp.String(v)
+	case time.Duration:
+		return v.String()
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case hclog.Format:
+		if len(v) == 0 {
+			return ""
+		}
+		return fmt.Sprintf(fmt.Sprint(v[0]), v[1:]...)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var shaped any
+	if err := json.Unmarshal(data, &shaped); err != nil {
+		return fmt.Sprint(v)
+	}
+	return shaped
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (p *PluginProxySink) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// Run ships buffered records in batches until ctx is canceled, then ships whatever is still buffered.
+func (p *PluginProxySink) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+	batch := make([]logsink.Record, 0, p.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case rec := <-p.records:
+					batch = append(batch, rec)
+					if len(batch) >= p.batchSize {
+						batch = p.flush(batch)
+					}
+				default:
+					p.flush(batch)
+					return
+				}
+			}
+		case rec := <-p.records:
+			batch = append(batch, rec)
+			if len(batch) >= p.batchSize {
+				batch = p.flush(batch)
+			}
+		case <-ticker.C:
+			batch = p.flush(batch)
+		}
+	}
+}
+
+// flush ships the batch, if any, and returns it emptied for reuse.
+func (p *PluginProxySink) flush(batch []logsink.Record) []logsink.Record {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := p.sink.Write(batch); err != nil {
+		p.sinkLogger.Warn("Failed to ship log records to sink plugin", KeyError, err, "records", len(batch))
+	}
+	return batch[:0]
+}
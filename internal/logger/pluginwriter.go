@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/hashicorp/go-hclog"
+)
+
+// PluginWriter is an io.Writer that decodes the JSON lines written by an hclog logger and ships them to a logsink
+// plugin through a PluginProxySink. It lets a logger whose output, rather than its records, should reach the plugin,
+// such as one created with PluginOptions, ship through the same batching and buffering as the sink's Accept.
+type PluginWriter struct {
+	sink *PluginProxySink
+}
+
+// NewPluginWriter creates a PluginWriter shipping through sink, whose Run must be running for records to be shipped.
+func NewPluginWriter(sink *PluginProxySink) *PluginWriter {
+	return &PluginWriter{sink: sink}
+}
+
+// Write decodes each line of p as an hclog JSON record and buffers it for shipping, skipping records below the sink's
+// level. Lines that are not JSON are shipped as the message of an info record. Records are dropped rather than
+// blocking the logger when the sink's buffer is full, so Write only fails for an empty p.
+func (w *PluginWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, ErrEmptyMessage
+	}
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		rec, level := entryRecord(line)
+		if level < w.sink.GetLevel() || level == hclog.Off {
+			continue
+		}
+		w.sink.enqueue(rec)
+	}
+	return len(p), nil
+}
+
+// entryRecord converts one line of hclog JSON output to a logsink.Record and returns it with its level.
+func entryRecord(line []byte) (logsink.Record, hclog.Level) {
+	var entry LogEntry
+	if err := entry.UnmarshalJSON(line); err != nil {
+		return logsink.Record{Time: timestamp.Now(), Level: hclog.Info.String(), Message: string(line)}, hclog.Info
+	}
+	level := hclog.LevelFromString(entry.Level)
+	if level == hclog.NoLevel {
+		level = hclog.Info
+	}
+	rec := logsink.Record{
+		Level:   level.String(),
+		Logger:  entry.Module,
+		Message: entry.Message,
+		Attrs:   make(map[string]string, len(entry.Fields)+1),
+		Fields:  make(map[string]any, len(entry.Fields)+1),
+	}
+	if t, err := timestamp.Parse(entry.Timestamp); err == nil {
+		rec.Time = t
+	} else {
+		rec.Time = timestamp.Now()
+	}
+	if entry.Caller != "" {
+		entry.Fields["caller"] = entry.Caller
+	}
+	for k, v := range entry.Fields {
+		rec.Fields[k] = v
+		rec.Attrs[k] = attrString(v)
+	}
+	return rec, level
+}
+
+// attrString renders a decoded JSON value as the string shipped in a record's Attrs: strings as they are, other
+// values as their JSON encoding.
+func attrString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
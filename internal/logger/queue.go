@@ -1,12 +1,14 @@
 package logger
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
-	"path/filepath"
 	"strings"
 
-	"github.com/goptics/sqliteq"
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/internal/mq"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
 	"github.com/goptics/varmq"
 	"github.com/hashicorp/go-hclog"
 )
@@ -50,63 +52,101 @@ func (l *LogEntry) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// LogQueue handles the initialization of a persistent log queue, processes jobs, and logs messages based on
-// their severity level.
-func LogQueue(qLogger hclog.Logger) varmq.PersistentQueue[[]byte] {
+// DefaultLogQueueWorkers is the number of workers logging queued records.
+const DefaultLogQueueWorkers = 10
 
-	dir := "/home/brian/GolandProjects/PlugsConc/logs"
-
-	aDir, err := filepath.Abs(dir)
+// LogQueue opens the log queue conf.Logging.MQ configures, creating the SQLite database's directory if missing, and
+// binds conf.Logging.Async.Workers workers logging each queued record through qLogger at the level it was recorded
+// at. It returns an error wrapping mq.ErrOpenBackend or mq.ErrUnknownBackend when the queue cannot be opened.
+func LogQueue(conf *config.Config, qLogger hclog.Logger) (varmq.PersistentQueue[[]byte], error) {
+	backend, err := openLogMQ(conf.Logging.MQ)
 	if err != nil {
-		hclog.Default().Error("Failed to get absolute path for logs directory", KeyError, err.Error())
-		return nil
+		return nil, err
 	}
+	return OpenLogQueue(backend, conf.Logging.Async.Workers, qLogger), nil
+}
 
-	sdb := sqliteq.New(filepath.Join(aDir, "logs.db"))
+// openLogMQ opens the queue backend mc configures.
+func openLogMQ(mc config.LogMQ) (mq.Backend, error) {
+	return mq.Open(mc.Backend, mq.Options{
+		File:          mc.File,
+		RedisAddr:     mc.Redis.Addr,
+		RedisPassword: mc.Redis.Password,
+		RedisDB:       mc.Redis.DB,
+		RedisKey:      mc.Redis.Key,
+		Capacity:      mc.Capacity,
+	})
+}
 
-	persistentQueue, err := sdb.NewQueue("log-queue", sqliteq.WithRemoveOnComplete(true))
-	if err != nil {
-		hclog.Default().Error("Failed to create queue", KeyError, err.Error())
+// OpenLogQueue binds workers workers to the queue backend, e.g. one opened with mq.Open, logging each queued record
+// through qLogger at the level it was recorded at. Records that cannot be decoded are kept in the backend's dead
+// letters, see mq.ReplayDeadLetters.
+func OpenLogQueue(backend mq.Backend, workers int, qLogger hclog.Logger) varmq.PersistentQueue[[]byte] {
+	if workers <= 0 {
+		workers = DefaultLogQueueWorkers
 	}
-
 	loggerWorker := varmq.NewWorker(
 		func(j varmq.Job[[]byte]) {
-			var logEntry LogEntry
-			err := logEntry.UnmarshalJSON(j.Data())
-			if err != nil {
-				hclog.Default().Error("Failed to unmarshal log message", KeyError, errors.Join(ErrLogMsgDecoder, err))
+			// a job holds one record, or a batch of newline-separated records from a batching AsyncWriter
+			for _, line := range bytes.Split(j.Data(), []byte("\n")) {
+				if len(bytes.TrimSpace(line)) > 0 {
+					if err := logQueued(qLogger, line); err != nil {
+						deadLetter(backend, line, err)
+					}
+				}
 			}
-			// from here we'll extract the data then use the passed in interceptor to log the message
-			lev := hclog.LevelFromString(logEntry.Level)
-			msg := logEntry.Message
-			var args []any
+		}, workers,
+	)
 
-			args = append(args, "caller", logEntry.Caller)
-			args = append(args, "module", logEntry.Module)
-			args = append(args, "orig_timestamp", logEntry.Timestamp)
+	// Bind the loggerWorker to the persistent queue
+	return loggerWorker.WithPersistentQueue(backend)
+}
 
-			for k, v := range logEntry.Fields {
-				args = append(args, k, v)
-			}
+// deadLetter keeps the record the worker could not log in the backend's dead letters.
+func deadLetter(backend mq.Backend, data []byte, reason error) {
+	d := mq.NewDeadLetter(bytes.Clone(data), reason.Error())
+	if err := backend.DeadLetters().Put(d); err != nil {
+		hclog.Default().Error("Failed to dead-letter log message, dropping it", KeyError, errors.Join(reason, err))
+		return
+	}
+	hclog.Default().Warn("Dead-lettered log message", "dead_letter_id", d.ID, KeyError, reason)
+}
 
-			switch lev {
-			case hclog.Trace:
-				qLogger.Trace(msg, args...)
-			case hclog.Debug:
-				qLogger.Debug(msg, args...)
-			case hclog.Warn:
-				qLogger.Warn(msg, args...)
-			case hclog.Error:
-				qLogger.Error(msg, args...)
-			case hclog.Info:
-				qLogger.Info(msg, args...)
-			default:
-				qLogger.Info(msg, args...)
+// logQueued logs one queued record through qLogger at the level it was recorded at, or returns an error wrapping
+// ErrLogMsgDecoder when it cannot be decoded.
+func logQueued(qLogger hclog.Logger, data []byte) error {
+	var logEntry LogEntry
+	err := logEntry.UnmarshalJSON(data)
+	if err != nil {
+		return errors.Join(ErrLogMsgDecoder, err)
+	}
+	// from here we'll extract the data then use the passed in interceptor to log the message
+	lev := hclog.LevelFromString(logEntry.Level)
+	msg := logEntry.Message
+	var args []any
 
-			}
-		}, 10,
-	)
+	args = append(args, "caller", logEntry.Caller)
+	args = append(args, "module", logEntry.Module)
+	args = append(args, "orig_timestamp", timestamp.Normalize(logEntry.Timestamp))
 
-	// Bind the loggerWorker to the persistent queue
-	return loggerWorker.WithPersistentQueue(persistentQueue)
+	for k, v := range logEntry.Fields {
+		args = append(args, k, v)
+	}
+
+	switch lev {
+	case hclog.Trace:
+		qLogger.Trace(msg, args...)
+	case hclog.Debug:
+		qLogger.Debug(msg, args...)
+	case hclog.Warn:
+		qLogger.Warn(msg, args...)
+	case hclog.Error:
+		qLogger.Error(msg, args...)
+	case hclog.Info:
+		qLogger.Info(msg, args...)
+	default:
+		qLogger.Info(msg, args...)
+
+	}
+	return nil
 }
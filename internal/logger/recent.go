@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultRecentLogs is the number of records a RecentLogs sink keeps when no capacity is configured.
+const DefaultRecentLogs = 1000
+
+// RecentLogs is an hclog sink that keeps the most recent records in a fixed-size ring buffer, so incident captures can
+// include the logs leading up to a failure without reading them back from files. Register it on an intercept logger
+// with RegisterSink.
+type RecentLogs struct {
+	mu      sync.Mutex
+	level   hclog.Level
+	records []logsink.Record
+	next    int  // index the next record is written to
+	full    bool // whether the buffer has wrapped
+}
+
+// NewRecentLogs creates a RecentLogs sink keeping the last capacity records at or above level. A capacity of zero or
+// less uses DefaultRecentLogs.
+func NewRecentLogs(capacity int, level hclog.Level) *RecentLogs {
+	if capacity <= 0 {
+		capacity = DefaultRecentLogs
+	}
+	return &RecentLogs{
+		level:   level,
+		records: make([]logsink.Record, capacity),
+	}
+}
+
+// Accept stores the record, evicting the oldest one once the buffer is full. It implements hclog.SinkAdapter.
+func (r *RecentLogs) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < r.level || level == hclog.Off {
+		return
+	}
+	rec := newRecord(name, level, msg, args)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Records returns the buffered records, oldest first.
+func (r *RecentLogs) Records() []logsink.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]logsink.Record(nil), r.records[:r.next]...)
+	}
+	return append(append(make([]logsink.Record, 0, len(r.records)), r.records[r.next:]...), r.records[:r.next]...)
+}
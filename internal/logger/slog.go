@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// LevelTrace is the slog level records at hclog.Trace are forwarded at, below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// KeyLogger is the attribute holding the hclog logger name of records forwarded to slog.
+const KeyLogger = "logger"
+
+// slogSink is an hclog.SinkAdapter that forwards records into an slog.Handler.
+type slogSink struct {
+	handler slog.Handler
+}
+
+// SlogSink returns an hclog sink that writes every record it accepts into handler, so output from go-plugin clients,
+// the registry, and the rest of the hclog pipeline reaches slog-based handlers in one consistent format. Register it
+// on an intercept logger with RegisterSink. The hclog logger name is carried in the KeyLogger attribute.
+func SlogSink(handler slog.Handler) hclog.SinkAdapter {
+	return &slogSink{handler: handler}
+}
+
+// Accept converts the hclog record to an slog.Record and hands it to the handler.
+func (s *slogSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	ctx := context.Background()
+	slogLevel := SlogLevel(level)
+	if level == hclog.Off || !s.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	r := slog.NewRecord(timestamp.Now(), slogLevel, msg, 0)
+	if name != "" {
+		r.AddAttrs(slog.String(KeyLogger, name))
+	}
+	r.Add(args...)
+	_ = s.handler.Handle(ctx, r)
+}
+
+// SlogLevel maps an hclog level to the equivalent slog level.
+func SlogLevel(level hclog.Level) slog.Level {
+	switch level {
+	case hclog.Trace:
+		return LevelTrace
+	case hclog.Debug:
+		return slog.LevelDebug
+	case hclog.Warn:
+		return slog.LevelWarn
+	case hclog.Error:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
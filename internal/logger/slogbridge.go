@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// hclogHandler is an slog.Handler that writes records through an hclog.Logger.
+type hclogHandler struct {
+	logger hclog.Logger
+	group  string // prefix of attribute keys, each open group followed by a dot
+}
+
+// HclogHandler returns an slog.Handler that writes every record through l, so code and libraries logging with slog
+// share the configured hclog pipeline: its console format, sinks, and levels. Levels are mapped with HclogLevel and
+// groups are flattened into dotted keys. A top-level KeyLogger attribute, as added by SlogSink and SlogLogger, names
+// the sublogger the record is written to, so records that cross the bridge twice keep their logger name.
+func HclogHandler(l hclog.Logger) slog.Handler {
+	if l == nil {
+		l = hclog.Default()
+	}
+	return &hclogHandler{logger: l}
+}
+
+// Enabled reports whether the hclog logger writes records at level.
+func (h *hclogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return HclogLevel(level) >= h.logger.GetLevel()
+}
+
+// Handle writes r through the hclog logger.
+func (h *hclogHandler) Handle(_ context.Context, r slog.Record) error {
+	l := h.logger
+	args := make([]any, 0, 2*r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group == "" && a.Key == KeyLogger {
+			l = l.Named(a.Value.String())
+			return true
+		}
+		args = appendAttr(args, h.group, a)
+		return true
+	})
+	l.Log(HclogLevel(r.Level), r.Message, args...)
+	return nil
+}
+
+// WithAttrs returns a handler whose records carry attrs.
+func (h *hclogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	args := make([]any, 0, 2*len(attrs))
+	for _, a := range attrs {
+		args = appendAttr(args, h.group, a)
+	}
+	return &hclogHandler{logger: h.logger.With(args...), group: h.group}
+}
+
+// WithGroup returns a handler that prefixes the keys of the attributes added afterwards with name.
+func (h *hclogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &hclogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr appends a as hclog key/value pairs to args, prefixing its key with group and flattening groups.
+func appendAttr(args []any, group string, a slog.Attr) []any {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return args
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// an inline group has no key of its own, so its attributes join the enclosing group
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			args = appendAttr(args, prefix, ga)
+		}
+		return args
+	}
+	return append(args, group+a.Key, a.Value.Any())
+}
+
+// slogLogger is an hclog.Logger that writes records into an slog.Handler.
+type slogLogger struct {
+	handler slog.Handler
+	name    string
+	args    []any
+	level   *atomic.Int32 // shared with the loggers derived by With and Named, as hclog's own loggers do
+}
+
+// SlogLogger returns an hclog.Logger that writes every record into handler, so the registry, go-plugin clients, and
+// other hclog code can log through a pipeline configured with slog. Records at or above level are passed to the
+// handler, which may filter them further; levels are mapped with SlogLevel. The logger name is carried in the
+// KeyLogger attribute and implied arguments become attributes of every record.
+func SlogLogger(handler slog.Handler, level hclog.Level) hclog.Logger {
+	l := &slogLogger{handler: handler, level: new(atomic.Int32)}
+	l.SetLevel(level)
+	return l
+}
+
+// Log writes a record at level.
+func (l *slogLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	l.log(level, msg, args)
+}
+
+// Trace writes a record at hclog.Trace.
+func (l *slogLogger) Trace(msg string, args ...interface{}) {
+	l.log(hclog.Trace, msg, args)
+}
+
+// Debug writes a record at hclog.Debug.
+func (l *slogLogger) Debug(msg string, args ...interface{}) {
+	l.log(hclog.Debug, msg, args)
+}
+
+// Info writes a record at hclog.Info.
+func (l *slogLogger) Info(msg string, args ...interface{}) {
+	l.log(hclog.Info, msg, args)
+}
+
+// Warn writes a record at hclog.Warn.
+func (l *slogLogger) Warn(msg string, args ...interface{}) {
+	l.log(hclog.Warn, msg, args)
+}
+
+// Error writes a record at hclog.Error.
+func (l *slogLogger) Error(msg string, args ...interface{}) {
+	l.log(hclog.Error, msg, args)
+}
+
+// log writes a record at level to the handler when both the logger and the handler are enabled for it. It must be
+// called directly by the exported logging methods, so the record's source is their caller.
+func (l *slogLogger) log(level hclog.Level, msg string, args []interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	ctx := context.Background()
+	slogLevel := SlogLevel(level)
+	if !l.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	var pcs [1]uintptr
+	// skip runtime.Callers, log, and the exported method
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(timestamp.Now(), slogLevel, msg, pcs[0])
+	if l.name != "" {
+		r.AddAttrs(slog.String(KeyLogger, l.name))
+	}
+	r.Add(l.args...)
+	r.Add(args...)
+	_ = l.handler.Handle(ctx, r)
+}
+
+// enabled reports whether the logger's level lets records at level through.
+func (l *slogLogger) enabled(level hclog.Level) bool {
+	current := hclog.Level(l.level.Load())
+	return current != hclog.Off && level != hclog.Off && level >= current
+}
+
+// IsTrace reports whether records at hclog.Trace are written.
+func (l *slogLogger) IsTrace() bool { return l.enabled(hclog.Trace) }
+
+// IsDebug reports whether records at hclog.Debug are written.
+func (l *slogLogger) IsDebug() bool { return l.enabled(hclog.Debug) }
+
+// IsInfo reports whether records at hclog.Info are written.
+func (l *slogLogger) IsInfo() bool { return l.enabled(hclog.Info) }
+
+// IsWarn reports whether records at hclog.Warn are written.
+func (l *slogLogger) IsWarn() bool { return l.enabled(hclog.Warn) }
+
+// IsError reports whether records at hclog.Error are written.
+func (l *slogLogger) IsError() bool { return l.enabled(hclog.Error) }
+
+// ImpliedArgs returns the arguments added to every record by With.
+func (l *slogLogger) ImpliedArgs() []interface{} {
+	return l.args
+}
+
+// With returns a logger that adds args to every record.
+func (l *slogLogger) With(args ...interface{}) hclog.Logger {
+	derived := *l
+	derived.args = append(append(make([]any, 0, len(l.args)+len(args)), l.args...), args...)
+	return &derived
+}
+
+// Name returns the logger's name.
+func (l *slogLogger) Name() string {
+	return l.name
+}
+
+// Named returns a sublogger whose name is appended to the logger's, separated by a dot as hclog does.
+func (l *slogLogger) Named(name string) hclog.Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return l.ResetNamed(name)
+}
+
+// ResetNamed returns a sublogger named name, replacing the logger's name.
+func (l *slogLogger) ResetNamed(name string) hclog.Logger {
+	derived := *l
+	derived.name = name
+	return &derived
+}
+
+// SetLevel changes the level of the logger and of the loggers derived from it.
+func (l *slogLogger) SetLevel(level hclog.Level) {
+	if level == hclog.NoLevel {
+		level = hclog.DefaultLevel
+	}
+	l.level.Store(int32(level))
+}
+
+// GetLevel returns the logger's level.
+func (l *slogLogger) GetLevel() hclog.Level {
+	return hclog.Level(l.level.Load())
+}
+
+// StandardLogger returns a standard library logger whose output is written to the handler as records at
+// opts.ForceLevel, or hclog.Info when it is unset. Levels are not inferred from the output.
+func (l *slogLogger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	level := hclog.Info
+	if opts != nil && opts.ForceLevel != hclog.NoLevel {
+		level = opts.ForceLevel
+	}
+	handler := l.handler
+	if l.name != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String(KeyLogger, l.name)})
+	}
+	return slog.NewLogLogger(slog.New(handler).With(l.args...).Handler(), SlogLevel(level))
+}
+
+// StandardWriter returns the writer of StandardLogger, writing each line as a record.
+func (l *slogLogger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	return l.StandardLogger(opts).Writer()
+}
+
+// HclogLevel maps an slog level to the hclog level it falls in; levels below slog.LevelDebug, such as LevelTrace,
+// map to hclog.Trace.
+func HclogLevel(level slog.Level) hclog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return hclog.Trace
+	case level < slog.LevelInfo:
+		return hclog.Debug
+	case level < slog.LevelWarn:
+		return hclog.Info
+	case level < slog.LevelError:
+		return hclog.Warn
+	default:
+		return hclog.Error
+	}
+}
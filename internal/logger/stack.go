@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultStackDepth is the number of frames captured when no depth is configured.
+const DefaultStackDepth = 8
+
+// stackLogger wraps an hclog.Logger, attaching a trimmed stack trace to records at the configured levels.
+type stackLogger struct {
+	hclog.Logger
+	levels []hclog.Level
+	depth  int
+}
+
+// WithStackTraces wraps l so records at any of levels carry a KeyStack attribute holding the stack of the host code
+// that emitted them, skipping logging frames and trimmed to depth frames. Loggers derived with Named, With, or
+// ResetNamed keep capturing stacks. A depth of zero or less uses DefaultStackDepth.
+func WithStackTraces(l hclog.Logger, depth int, levels ...hclog.Level) hclog.Logger {
+	if len(levels) == 0 {
+		return l
+	}
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	return &stackLogger{Logger: l, levels: levels, depth: depth}
+}
+
+// ParseLevels converts level names, e.g. from config, to hclog levels, ignoring names hclog does not recognize.
+func ParseLevels(names []string) []hclog.Level {
+	levels := make([]hclog.Level, 0, len(names))
+	for _, name := range names {
+		if level := hclog.LevelFromString(name); level != hclog.NoLevel {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// withStack appends the stack attribute to args when level is configured for capture.
+func (s *stackLogger) withStack(level hclog.Level, args []interface{}) []interface{} {
+	if !slices.Contains(s.levels, level) {
+		return args
+	}
+	return append(args, KeyStack, captureStack(s.depth))
+}
+
+// Log emits a record at level, attaching a stack trace when configured.
+func (s *stackLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	s.Logger.Log(level, msg, s.withStack(level, args)...)
+}
+
+// Trace emits a trace record, attaching a stack trace when configured.
+func (s *stackLogger) Trace(msg string, args ...interface{}) {
+	s.Logger.Trace(msg, s.withStack(hclog.Trace, args)...)
+}
+
+// Debug emits a debug record, attaching a stack trace when configured.
+func (s *stackLogger) Debug(msg string, args ...interface{}) {
+	s.Logger.Debug(msg, s.withStack(hclog.Debug, args)...)
+}
+
+// Info emits an info record, attaching a stack trace when configured.
+func (s *stackLogger) Info(msg string, args ...interface{}) {
+	s.Logger.Info(msg, s.withStack(hclog.Info, args)...)
+}
+
+// Warn emits a warn record, attaching a stack trace when configured.
+func (s *stackLogger) Warn(msg string, args ...interface{}) {
+	s.Logger.Warn(msg, s.withStack(hclog.Warn, args)...)
+}
+
+// Error emits an error record, attaching a stack trace when configured.
+func (s *stackLogger) Error(msg string, args ...interface{}) {
+	s.Logger.Error(msg, s.withStack(hclog.Error, args)...)
+}
+
+// With returns a derived logger that keeps capturing stack traces.
+func (s *stackLogger) With(args ...interface{}) hclog.Logger {
+	return &stackLogger{Logger: s.Logger.With(args...), levels: s.levels, depth: s.depth}
+}
+
+// Named returns a derived logger that keeps capturing stack traces.
+func (s *stackLogger) Named(name string) hclog.Logger {
+	return &stackLogger{Logger: s.Logger.Named(name), levels: s.levels, depth: s.depth}
+}
+
+// ResetNamed returns a derived logger that keeps capturing stack traces.
+func (s *stackLogger) ResetNamed(name string) hclog.Logger {
+	return &stackLogger{Logger: s.Logger.ResetNamed(name), levels: s.levels, depth: s.depth}
+}
+
+// captureStack formats up to depth frames of the calling host code, one "function file:line" per line, skipping the
+// runtime, hclog, and this wrapper.
+func captureStack(depth int) string {
+	pcs := make([]uintptr, depth+16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	lines := make([]string, 0, depth)
+	for len(lines) < depth {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, hclogPackage) &&
+			!strings.HasPrefix(frame.Function, "runtime.") &&
+			!strings.Contains(frame.Function, "internal/logger.(*stackLogger)") {
+			lines = append(lines, fmt.Sprintf("%s %s:%d", frame.Function, trimPath(frame.File), frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrInvalidColor indicates that a color value could not be parsed.
+// ErrUnknownTheme indicates that no theme is registered under the requested name.
+// ErrUnknownThemeElement indicates that a color override names an element themes do not have.
+var (
+	ErrInvalidColor        = errors.New("invalid color")
+	ErrUnknownTheme        = errors.New("unknown theme")
+	ErrUnknownThemeElement = errors.New("unknown theme element")
+)
+
+// Color is a terminal foreground color: one of the 16 ANSI colors, a 256-color palette index, or a truecolor RGB
+// value. The zero Color leaves text uncolored.
+type Color struct {
+	sgr string // SGR parameters, e.g. "31", "38;5;208", or "38;2;255;136;0"
+}
+
+// namedColors maps color names to their 16-color ANSI SGR codes.
+var namedColors = map[string]int{
+	"black": 30, "red": 31, "green": 32, "yellow": 33, "blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+	"bright-black": 90, "bright-red": 91, "bright-green": 92, "bright-yellow": 93,
+	"bright-blue": 94, "bright-magenta": 95, "bright-cyan": 96, "bright-white": 97,
+}
+
+// ANSI returns the 16-color ANSI color with the given SGR code, 30-37 or 90-97.
+func ANSI(code int) Color {
+	return Color{sgr: strconv.Itoa(code)}
+}
+
+// Color256 returns the color at index n of the 256-color palette.
+func Color256(n uint8) Color {
+	return Color{sgr: fmt.Sprintf("38;5;%d", n)}
+}
+
+// RGB returns the truecolor value with the given red, green, and blue components.
+func RGB(r, g, b uint8) Color {
+	return Color{sgr: fmt.Sprintf("38;2;%d;%d;%d", r, g, b)}
+}
+
+// ParseColor parses a color name (e.g. "red", "bright-cyan"), a 256-color palette index (e.g. "208"), or a
+// truecolor hex value (e.g. "#ff8800"). An empty string or "none" returns the zero Color.
+func ParseColor(s string) (Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "" || s == "none":
+		return Color{}, nil
+	case strings.HasPrefix(s, "#"):
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil || len(s) != 7 {
+			return Color{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+		}
+		return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+	}
+	if code, ok := namedColors[s]; ok {
+		return ANSI(code), nil
+	}
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Color256(uint8(n)), nil
+	}
+	return Color{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+}
+
+// Wrap returns s surrounded by the escape sequences that render it in the color.
+func (c Color) Wrap(s string) string {
+	if c.sgr == "" {
+		return s
+	}
+	return "\x1b[" + c.sgr + "m" + s + "\x1b[0m"
+}
+
+// Theme assigns colors to the elements of a human-readable log line.
+type Theme struct {
+	Name      string
+	Timestamp Color
+	Trace     Color
+	Debug     Color
+	Info      Color
+	Warn      Color
+	Error     Color
+	Module    Color
+	Key       Color
+}
+
+// Level returns the theme's color for the log level.
+func (t *Theme) Level(level hclog.Level) Color {
+	switch level {
+	case hclog.Trace:
+		return t.Trace
+	case hclog.Debug:
+		return t.Debug
+	case hclog.Info:
+		return t.Info
+	case hclog.Warn:
+		return t.Warn
+	case hclog.Error:
+		return t.Error
+	default:
+		return Color{}
+	}
+}
+
+// WithOverrides returns a copy of the theme with the named elements recolored. Element names are the lowercase
+// Theme field names, e.g. "warn" or "timestamp", and values are parsed with ParseColor.
+func (t *Theme) WithOverrides(overrides map[string]string) (*Theme, error) {
+	out := *t
+	elements := map[string]*Color{
+		"timestamp": &out.Timestamp,
+		"trace":     &out.Trace,
+		"debug":     &out.Debug,
+		"info":      &out.Info,
+		"warn":      &out.Warn,
+		"error":     &out.Error,
+		"module":    &out.Module,
+		"key":       &out.Key,
+	}
+	for name, value := range overrides {
+		dst, ok := elements[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownThemeElement, name)
+		}
+		c, err := ParseColor(value)
+		if err != nil {
+			return nil, err
+		}
+		*dst = c
+	}
+	return &out, nil
+}
+
+// ThemeNone disables colors.
+// ThemeDark suits terminals with a dark background.
+// ThemeLight suits terminals with a light background.
+// ThemeSolarized uses the Solarized accent colors in truecolor.
+const (
+	ThemeNone      = "none"
+	ThemeDark      = "dark"
+	ThemeLight     = "light"
+	ThemeSolarized = "solarized"
+)
+
+// AvailableThemes is the registry of named themes selectable from the logging config.
+var AvailableThemes = map[string]*Theme{
+	ThemeNone: {Name: ThemeNone},
+	ThemeDark: {
+		Name:      ThemeDark,
+		Timestamp: Color256(244),
+		Trace:     Color256(245),
+		Debug:     Color256(75),
+		Info:      Color256(114),
+		Warn:      Color256(221),
+		Error:     Color256(203),
+		Module:    Color256(176),
+		Key:       Color256(110),
+	},
+	ThemeLight: {
+		Name:      ThemeLight,
+		Timestamp: Color256(242),
+		Trace:     Color256(240),
+		Debug:     Color256(25),
+		Info:      Color256(28),
+		Warn:      Color256(130),
+		Error:     Color256(160),
+		Module:    Color256(90),
+		Key:       Color256(24),
+	},
+	ThemeSolarized: {
+		Name:      ThemeSolarized,
+		Timestamp: RGB(0x58, 0x6e, 0x75),
+		Trace:     RGB(0x65, 0x7b, 0x83),
+		Debug:     RGB(0x26, 0x8b, 0xd2),
+		Info:      RGB(0x85, 0x99, 0x00),
+		Warn:      RGB(0xb5, 0x89, 0x00),
+		Error:     RGB(0xdc, 0x32, 0x2f),
+		Module:    RGB(0x6c, 0x71, 0xc4),
+		Key:       RGB(0x2a, 0xa1, 0x98),
+	},
+}
+
+// ThemeByName returns the registered theme with the given name, applying any color overrides.
+// An empty name selects ThemeDark.
+func ThemeByName(name string, overrides map[string]string) (*Theme, error) {
+	if name == "" {
+		name = ThemeDark
+	}
+	theme, ok := AvailableThemes[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownTheme, name,
+			strings.Join(slices.Sorted(maps.Keys(AvailableThemes)), ", "))
+	}
+	return theme.WithOverrides(overrides)
+}
@@ -0,0 +1,506 @@
+package management
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/mq"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/sla"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	adminv1 "github.com/bmj2728/PlugsConc/shared/protogen/admin/v1"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AdminTokenMetadata is the gRPC metadata key under which admin clients present their bearer token, as
+// "Bearer <token>".
+const AdminTokenMetadata = "authorization"
+
+// ErrNoPool indicates that pool metrics were requested from an admin server without a worker pool.
+// ErrNoLevels indicates that log levels were requested from an admin server without a level controller.
+// ErrNoDeadLetters indicates that dead letters were requested from an admin server without a log queue.
+// ErrNoSLA indicates that SLA reports were requested without an SLA reporter.
+// ErrNoFlap indicates that stability reports were requested without a flap detector.
+var (
+	ErrNoPool        = errors.New("worker pool is not configured")
+	ErrNoLevels      = errors.New("log level control is not configured")
+	ErrNoDeadLetters = errors.New("log dead letters are not configured")
+	ErrNoSLA         = errors.New("SLA reporting is not configured")
+	ErrNoFlap        = errors.New("flap detection is not configured")
+)
+
+// LogDeadLetters lists and replays the records the async log queue's worker could not log. logger.Topology
+// implements it.
+type LogDeadLetters interface {
+	DeadLetters() ([]mq.DeadLetter, error)
+	ReplayDeadLetters(ids ...string) (replayed, failed int, err error)
+}
+
+// AdminOptions configures the admin gRPC service and the REST endpoints. When Auth is set every call is authenticated
+// by the authprovider plugin; otherwise, when Token is set, every call must present it as a bearer token, in the
+// authorization metadata or header. Pool is optional; without it pool metrics are unavailable. Degraded, also
+// optional, returns why the host as a whole is degraded, such as its plugins directory being unavailable, or nil.
+// Levels, also optional, lets operators change the levels of the console logger and log sinks at runtime, and
+// DeadLetters lets them inspect and replay the records the async log queue could not log. SLA, also optional, serves
+// the plugins' availability reports over REST. HostVersion is reported by the REST endpoints' API catalog, and
+// Janitor, also optional, reports and runs the plugin artifact janitor's sweeps over REST. Flap, also optional,
+// serves the flap detector's stability reports over REST.
+type AdminOptions struct {
+	Token       string
+	Auth        authprovider.AuthProvider
+	Manager     *registry.PluginManager
+	Catalog     *registry.PluginCatalog
+	Pool        *worker.Pool
+	Degraded    func() error
+	Levels      *logger.LevelController
+	DeadLetters LogDeadLetters
+	SLA         *sla.Reporter
+	Janitor     *registry.Janitor
+	Flap        *registry.FlapDetector
+	HostVersion string
+	Logger      hclog.Logger
+}
+
+// AdminServer implements the admin.v1 Admin gRPC service, letting operators list the installed plugins, inspect,
+// start, stop, and reload them, and read the worker pool's metrics on a running host.
+type AdminServer struct {
+	adminv1.UnimplementedAdminServer
+	opts        AdminOptions
+	adminLogger hclog.Logger
+}
+
+// NewAdminServer returns an AdminServer managing the plugins of opts.Manager.
+func NewAdminServer(opts AdminOptions) *AdminServer {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	return &AdminServer{opts: opts, adminLogger: opts.Logger}
+}
+
+// NewGRPCServer returns a gRPC server with the admin service registered behind its authentication interceptor. Every
+// call is given a request ID, returned in the plugsconc-request-id response header and added to the records logged
+// and the plugin calls made for it; a valid ID the client sends in the same metadata is kept.
+func (a *AdminServer) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(append(opts, grpc.ChainUnaryInterceptor(requestIDInterceptor(a.adminLogger),
+		a.UnaryInterceptor()))...)
+	a.Register(s)
+	return s
+}
+
+// Register registers the admin service on s. Calls are only authenticated when s was built with UnaryInterceptor.
+func (a *AdminServer) Register(s grpc.ServiceRegistrar) {
+	adminv1.RegisterAdminServer(s, a)
+}
+
+// Serve serves the admin service on lis until ctx is canceled, then stops gracefully.
+func (a *AdminServer) Serve(ctx context.Context, lis net.Listener) error {
+	s := a.NewGRPCServer()
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	a.adminLogger.Info("Serving admin API", "address", lis.Addr().String())
+	return s.Serve(lis)
+}
+
+// UnaryInterceptor returns an interceptor rejecting admin calls that the authprovider plugin denies or that do not
+// present the configured token, with Unauthenticated.
+func (a *AdminServer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.authenticate(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticate checks the bearer token in the call's metadata against the authprovider plugin or the configured
+// token, returning a gRPC status error when the call is denied.
+func (a *AdminServer) authenticate(ctx context.Context, method string) error {
+	if a.opts.Auth == nil && a.opts.Token == "" {
+		return nil
+	}
+	authLogger := requestLogger(ctx, a.adminLogger)
+	var auth, remote string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(AdminTokenMetadata); len(values) > 0 {
+			auth = values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr.String()
+	}
+	token := strings.TrimPrefix(auth, bearerPrefix)
+	if a.opts.Auth != nil {
+		res, err := a.opts.Auth.Authenticate(authprovider.Request{
+			Token:      token,
+			Method:     method,
+			Path:       method,
+			RemoteAddr: remote,
+		})
+		if err != nil {
+			authLogger.Error("Auth provider failed", "method", method, logger.KeyError, err)
+			return status.Error(codes.Unavailable, "auth provider failed")
+		}
+		if !res.Allowed {
+			authLogger.Warn("Rejected unauthorized admin call", "method", method, "remote", remote,
+				"reason", res.Reason)
+			return status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		authLogger.Debug("Authenticated admin call", "method", method, "subject", res.Subject)
+		return nil
+	}
+	if !strings.HasPrefix(auth, bearerPrefix) || subtle.ConstantTimeCompare([]byte(token), []byte(a.opts.Token)) != 1 {
+		authLogger.Warn("Rejected unauthorized admin call", "method", method, "remote", remote)
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return nil
+}
+
+// ListPlugins returns the installed plugins matching every filter of the request, sorted by name.
+func (a *AdminServer) ListPlugins(_ context.Context, req *adminv1.ListPluginsRequest) (*adminv1.ListPluginsResponse,
+	error) {
+	if a.opts.Catalog == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plugin catalog is not configured")
+	}
+	res := &adminv1.ListPluginsResponse{}
+	for _, info := range a.opts.Catalog.Search(req.GetQuery()) {
+		if !matchFilter(info.Type, req.GetType()) || !matchFilter(info.Language, req.GetLanguage()) ||
+			!matchFilter(info.StateName, req.GetState()) {
+			continue
+		}
+		res.Plugins = append(res.Plugins, &adminv1.PluginInfo{
+			Name:        info.Name,
+			Type:        info.Type,
+			Format:      info.Format,
+			Language:    info.Language,
+			Version:     info.Version,
+			Description: info.Description,
+			Dir:         info.Dir,
+			State:       info.StateName,
+		})
+	}
+	return res, nil
+}
+
+// matchFilter reports whether value matches filter, ignoring case; an empty filter matches every value.
+func matchFilter(value, filter string) bool {
+	return filter == "" || strings.EqualFold(value, filter)
+}
+
+// GetPluginStatus returns the lifecycle status of the named plugin.
+func (a *AdminServer) GetPluginStatus(_ context.Context, req *adminv1.GetPluginStatusRequest) (
+	*adminv1.GetPluginStatusResponse, error) {
+	st, err := a.status(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.GetPluginStatusResponse{Status: st}, nil
+}
+
+// StartPlugin starts the named plugin and returns its status.
+func (a *AdminServer) StartPlugin(ctx context.Context, req *adminv1.StartPluginRequest) (*adminv1.StartPluginResponse,
+	error) {
+	st, err := a.control(ctx, "start", req.GetName(), a.opts.Manager.Start)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.StartPluginResponse{Status: st}, nil
+}
+
+// StopPlugin stops the named plugin and returns its status.
+func (a *AdminServer) StopPlugin(ctx context.Context, req *adminv1.StopPluginRequest) (*adminv1.StopPluginResponse,
+	error) {
+	st, err := a.control(ctx, "stop", req.GetName(), a.opts.Manager.Stop)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.StopPluginResponse{Status: st}, nil
+}
+
+// ReloadPlugin re-reads the named plugin's manifest, restarts it, and returns its status.
+func (a *AdminServer) ReloadPlugin(ctx context.Context, req *adminv1.ReloadPluginRequest) (
+	*adminv1.ReloadPluginResponse, error) {
+	st, err := a.control(ctx, "reload", req.GetName(), a.opts.Manager.Reload)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.ReloadPluginResponse{Status: st}, nil
+}
+
+// UnquarantinePlugin releases the named plugin from quarantine, leaving it stopped, and returns its status.
+func (a *AdminServer) UnquarantinePlugin(ctx context.Context, req *adminv1.UnquarantinePluginRequest) (
+	*adminv1.UnquarantinePluginResponse, error) {
+	st, err := a.control(ctx, "unquarantine", req.GetName(), a.opts.Manager.Unquarantine)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.UnquarantinePluginResponse{Status: st}, nil
+}
+
+// ApprovePlugin clears the flap detector's demotion of the named plugin after review, leaving it stopped, and returns
+// its status.
+func (a *AdminServer) ApprovePlugin(ctx context.Context, req *adminv1.ApprovePluginRequest) (
+	*adminv1.ApprovePluginResponse, error) {
+	st, err := a.control(ctx, "approve", req.GetName(), a.opts.Manager.Approve)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.ApprovePluginResponse{Status: st}, nil
+}
+
+// ListGroups returns the aggregated status of every plugin group, sorted by name.
+func (a *AdminServer) ListGroups(context.Context, *adminv1.ListGroupsRequest) (*adminv1.ListGroupsResponse, error) {
+	if a.opts.Manager == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plugin manager is not configured")
+	}
+	res := &adminv1.ListGroupsResponse{}
+	for _, group := range a.opts.Manager.GroupStatuses() {
+		res.Groups = append(res.Groups, groupStatus(group))
+	}
+	return res, nil
+}
+
+// GetGroupStatus returns the aggregated status of the named plugin group.
+func (a *AdminServer) GetGroupStatus(ctx context.Context, req *adminv1.GetGroupStatusRequest) (
+	*adminv1.GetGroupStatusResponse, error) {
+	group, err := a.groupControl(ctx, "status", req.GetName(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.GetGroupStatusResponse{Group: group}, nil
+}
+
+// StartGroup starts the plugins of the named group and returns its status.
+func (a *AdminServer) StartGroup(ctx context.Context, req *adminv1.StartGroupRequest) (*adminv1.StartGroupResponse,
+	error) {
+	group, err := a.groupControl(ctx, "start", req.GetName(), a.opts.Manager.StartGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.StartGroupResponse{Group: group}, nil
+}
+
+// StopGroup stops the plugins of the named group and returns its status.
+func (a *AdminServer) StopGroup(ctx context.Context, req *adminv1.StopGroupRequest) (*adminv1.StopGroupResponse,
+	error) {
+	group, err := a.groupControl(ctx, "stop", req.GetName(), a.opts.Manager.StopGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.StopGroupResponse{Group: group}, nil
+}
+
+// groupControl runs a lifecycle action, if any, on the named plugin group, logging it, and returns the group's
+// resulting status.
+func (a *AdminServer) groupControl(ctx context.Context, action, name string, run func(name string) error) (
+	*adminv1.GroupStatus, error) {
+	if a.opts.Manager == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plugin manager is not configured")
+	}
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "group name is required")
+	}
+	controlLogger := requestLogger(ctx, a.adminLogger)
+	if run != nil {
+		if err := run(name); err != nil {
+			controlLogger.Warn("Admin group "+action+" failed", "group", name, logger.KeyError, err)
+			return nil, statusError(err)
+		}
+		controlLogger.Info("Admin group "+action+" succeeded", "group", name)
+	}
+	group, err := a.opts.Manager.GroupStatus(name)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return groupStatus(group), nil
+}
+
+// groupStatus converts a PluginManager group status to its admin.v1 form.
+func groupStatus(group registry.GroupStatus) *adminv1.GroupStatus {
+	res := &adminv1.GroupStatus{
+		Name:    group.Name,
+		State:   group.State,
+		Running: int32(group.Running),
+		Failed:  int32(group.Failed),
+		Plugins: make([]*adminv1.PluginStatus, 0, len(group.Plugins)),
+	}
+	for _, st := range group.Plugins {
+		res.Plugins = append(res.Plugins, pluginStatus(st))
+	}
+	return res
+}
+
+// GetPoolMetrics returns the worker pool's current metrics.
+func (a *AdminServer) GetPoolMetrics(context.Context, *adminv1.GetPoolMetricsRequest) (
+	*adminv1.GetPoolMetricsResponse, error) {
+	pool := a.opts.Pool
+	if pool == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrNoPool.Error())
+	}
+	snap := pool.Snapshot()
+	return &adminv1.GetPoolMetricsResponse{Pool: &adminv1.PoolMetrics{
+		Workers:           int32(snap.Workers),
+		QueuedJobs:        int32(snap.QueuedJobs),
+		RunningJobs:       int32(len(pool.Running())),
+		JobsSubmitted:     int64(snap.Submissions),
+		FailedSubmissions: int64(snap.FailedSubmissions),
+		SuccessfulJobs:    int64(snap.SuccessfulJobs),
+		FailedJobs:        int64(snap.FailedJobs),
+		TimedOutJobs:      int64(snap.TimedOutJobs),
+	}}, nil
+}
+
+// GetLogLevels returns the current level of the console logger and of every log sink.
+func (a *AdminServer) GetLogLevels(context.Context, *adminv1.GetLogLevelsRequest) (*adminv1.GetLogLevelsResponse,
+	error) {
+	if a.opts.Levels == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrNoLevels.Error())
+	}
+	return &adminv1.GetLogLevelsResponse{Levels: a.opts.Levels.Levels()}, nil
+}
+
+// SetLogLevel changes the level of the console logger or of a log sink, named as in GetLogLevels, and returns the
+// levels after the change.
+func (a *AdminServer) SetLogLevel(ctx context.Context, req *adminv1.SetLogLevelRequest) (
+	*adminv1.SetLogLevelResponse, error) {
+	if a.opts.Levels == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrNoLevels.Error())
+	}
+	level, err := logger.ParseLevel(req.GetLevel())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := a.opts.Levels.SetLevel(req.GetTarget(), level); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	requestLogger(ctx, a.adminLogger).Info("Changed log level", logger.KeyLogger, req.GetTarget(),
+		"level", level.String())
+	return &adminv1.SetLogLevelResponse{Levels: a.opts.Levels.Levels()}, nil
+}
+
+// ListDeadLetters returns the records the async log queue's worker could not log, oldest first.
+func (a *AdminServer) ListDeadLetters(context.Context, *adminv1.ListDeadLettersRequest) (
+	*adminv1.ListDeadLettersResponse, error) {
+	if a.opts.DeadLetters == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrNoDeadLetters.Error())
+	}
+	letters, err := a.opts.DeadLetters.DeadLetters()
+	if err != nil {
+		return nil, deadLetterStatus(err)
+	}
+	resp := &adminv1.ListDeadLettersResponse{DeadLetters: make([]*adminv1.DeadLetter, 0, len(letters))}
+	for _, d := range letters {
+		resp.DeadLetters = append(resp.DeadLetters, &adminv1.DeadLetter{
+			Id:         d.ID,
+			Data:       d.Data,
+			Reason:     d.Reason,
+			AtUnixNano: d.At.UnixNano(),
+			Attempts:   int32(d.Attempts),
+		})
+	}
+	return resp, nil
+}
+
+// ReplayDeadLetters logs the dead letters with the requested IDs, every one when none are given, to the async log
+// files again. The ones that fail again are kept and counted as failed.
+func (a *AdminServer) ReplayDeadLetters(ctx context.Context, req *adminv1.ReplayDeadLettersRequest) (
+	*adminv1.ReplayDeadLettersResponse, error) {
+	if a.opts.DeadLetters == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrNoDeadLetters.Error())
+	}
+	replayed, failed, err := a.opts.DeadLetters.ReplayDeadLetters(req.GetIds()...)
+	if err != nil && replayed == 0 && failed == 0 {
+		return nil, deadLetterStatus(err)
+	}
+	reqLogger := requestLogger(ctx, a.adminLogger)
+	if err != nil {
+		reqLogger.Warn("Dead letter replay incomplete", logger.KeyError, err)
+	}
+	reqLogger.Info("Replayed log dead letters", "replayed", replayed, "failed", failed)
+	return &adminv1.ReplayDeadLettersResponse{Replayed: int32(replayed), Failed: int32(failed)}, nil
+}
+
+// deadLetterStatus maps a dead letter error to a gRPC status.
+func deadLetterStatus(err error) error {
+	switch {
+	case errors.Is(err, logger.ErrNoLogQueue):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, mq.ErrDeadLetterNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// control runs a lifecycle action on the named plugin, logging it, and returns the plugin's resulting status.
+func (a *AdminServer) control(ctx context.Context, action, name string, run func(name string) error) (
+	*adminv1.PluginStatus, error) {
+	if a.opts.Manager == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plugin manager is not configured")
+	}
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "plugin name is required")
+	}
+	controlLogger := requestLogger(ctx, a.adminLogger)
+	if err := run(name); err != nil {
+		controlLogger.Warn("Admin "+action+" failed", logger.KeyPluginName, name, logger.KeyError, err)
+		return nil, statusError(err)
+	}
+	controlLogger.Info("Admin "+action+" succeeded", logger.KeyPluginName, name)
+	return a.status(name)
+}
+
+// status returns the admin.v1 status of the named plugin.
+func (a *AdminServer) status(name string) (*adminv1.PluginStatus, error) {
+	if a.opts.Manager == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plugin manager is not configured")
+	}
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "plugin name is required")
+	}
+	st, err := a.opts.Manager.Status(name)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return pluginStatus(st), nil
+}
+
+// pluginStatus converts a PluginManager status to its admin.v1 form.
+func pluginStatus(st registry.PluginStatus) *adminv1.PluginStatus {
+	res := &adminv1.PluginStatus{
+		Name:       st.Name,
+		State:      st.StateName,
+		Protocol:   st.Protocol,
+		Restarts:   int32(st.Restarts),
+		ApiVersion: st.APIVersion,
+		LastError:  st.LastError,
+	}
+	if !st.StartedAt.IsZero() {
+		res.StartedAtUnixNano = st.StartedAt.UnixNano()
+	}
+	return res
+}
+
+// statusError maps a PluginManager error to the gRPC status returned to admin clients.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, registry.ErrPluginNotFound), errors.Is(err, registry.ErrGroupNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, registry.ErrPluginRunning), errors.Is(err, registry.ErrPluginNotRunning),
+		errors.Is(err, registry.ErrPluginDisabled), errors.Is(err, registry.ErrPluginQuarantined),
+		errors.Is(err, registry.ErrPinnedVersion):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,262 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/apiversion"
+	adminv1 "github.com/bmj2728/PlugsConc/shared/protogen/admin/v1"
+	animalv1 "github.com/bmj2728/PlugsConc/shared/protogen/animal/v1"
+	authproviderv1 "github.com/bmj2728/PlugsConc/shared/protogen/authprovider/v1"
+	filelisterv1 "github.com/bmj2728/PlugsConc/shared/protogen/filelister/v1"
+	filesystemv1 "github.com/bmj2728/PlugsConc/shared/protogen/filesystem/v1"
+	jobsourcev1 "github.com/bmj2728/PlugsConc/shared/protogen/jobsource/v1"
+	lifecyclev1 "github.com/bmj2728/PlugsConc/shared/protogen/lifecycle/v1"
+	logsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/logsink/v1"
+	metricsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/metricsink/v1"
+	networkv1 "github.com/bmj2728/PlugsConc/shared/protogen/network/v1"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoFiles are the descriptors of every protocol the host speaks with plugins, plugin contracts and host services
+// alike.
+var protoFiles = []protoreflect.FileDescriptor{
+	adminv1.File_admin_v1_admin_proto,
+	animalv1.File_animal_v1_animal_proto,
+	authproviderv1.File_authprovider_v1_authprovider_proto,
+	filelisterv1.File_filelister_v1_filelister_proto,
+	filesystemv1.File_filesystem_v1_fs_proto,
+	jobsourcev1.File_jobsource_v1_jobsource_proto,
+	lifecyclev1.File_lifecycle_v1_lifecycle_proto,
+	logsinkv1.File_logsink_v1_logsink_proto,
+	metricsinkv1.File_metricsink_v1_metricsink_proto,
+	networkv1.File_network_v1_network_proto,
+}
+
+// APICatalog is a machine-readable description of what this host build supports: the gRPC services and messages
+// of its plugin contracts and host services, the plugin types manifests may declare, and the capability schema.
+type APICatalog struct {
+	HostVersion  string          `json:"host_version"`
+	APIVersion   string          `json:"api_version"` // shared plugin API version, see shared/pkg/apiversion
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Services     []ServiceDoc    `json:"services"`
+	Messages     []MessageDoc    `json:"messages"`
+	Enums        []EnumDoc       `json:"enums,omitempty"`
+	PluginTypes  []PluginTypeDoc `json:"plugin_types"`
+	Capabilities []SchemaField   `json:"capabilities"`
+}
+
+// ServiceDoc describes a gRPC service and its methods.
+type ServiceDoc struct {
+	Name    string      `json:"name"`
+	File    string      `json:"file"`
+	Methods []MethodDoc `json:"methods"`
+}
+
+// MethodDoc describes a gRPC method by its request and response message names.
+type MethodDoc struct {
+	Name            string `json:"name"`
+	Input           string `json:"input"`
+	Output          string `json:"output"`
+	ClientStreaming bool   `json:"client_streaming,omitempty"`
+	ServerStreaming bool   `json:"server_streaming,omitempty"`
+}
+
+// MessageDoc describes a protobuf message and its fields.
+type MessageDoc struct {
+	Name   string     `json:"name"`
+	Fields []FieldDoc `json:"fields"`
+}
+
+// FieldDoc describes a message field. Type is the scalar kind, or the full name of a message or enum.
+type FieldDoc struct {
+	Name     string `json:"name"`
+	Number   int    `json:"number"`
+	Type     string `json:"type"`
+	Repeated bool   `json:"repeated,omitempty"`
+	Map      bool   `json:"map,omitempty"`
+}
+
+// EnumDoc describes a protobuf enum and its value names.
+type EnumDoc struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// PluginTypeDoc describes a plugin type a manifest may declare and the protocols it can be served over.
+type PluginTypeDoc struct {
+	Name      string   `json:"name"`
+	Protocols []string `json:"protocols"`
+}
+
+// SchemaField describes a manifest field by its YAML key and type. Values lists the accepted values of enumerated
+// string fields, and Fields the nested fields of sections and lists of objects.
+type SchemaField struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Values []string      `json:"values,omitempty"`
+	Fields []SchemaField `json:"fields,omitempty"`
+}
+
+// capabilityValues are the accepted values of enumerated capability fields, keyed by their dotted YAML path.
+var capabilityValues = map[string][]string{
+	"filesystem.permissions":   capability.FilePermissions,
+	"network.egress.protocol":  capability.NetworkProtocols,
+	"network.ingress.protocol": capability.NetworkProtocols,
+}
+
+// BuildAPICatalog generates the APICatalog of this host build, reporting hostVersion as its version.
+func BuildAPICatalog(hostVersion string) APICatalog {
+	catalog := APICatalog{
+		HostVersion:  hostVersion,
+		APIVersion:   apiversion.Version,
+		GeneratedAt:  time.Now(),
+		Capabilities: schemaFields(reflect.TypeOf(capability.Capabilities{}), ""),
+	}
+	for _, file := range protoFiles {
+		services := file.Services()
+		for i := range services.Len() {
+			catalog.Services = append(catalog.Services, serviceDoc(file, services.Get(i)))
+		}
+		addMessages(&catalog, file.Messages())
+		addEnums(&catalog, file.Enums())
+	}
+	for _, name := range registry.AvailablePluginTypesLookup.Names() {
+		doc := PluginTypeDoc{Name: name, Protocols: []string{}}
+		p := registry.AvailablePluginTypes.GetByString(name)
+		if _, ok := p.(plugin.GRPCPlugin); ok {
+			doc.Protocols = append(doc.Protocols, string(plugin.ProtocolGRPC))
+		} else {
+			doc.Protocols = append(doc.Protocols, string(plugin.ProtocolNetRPC))
+		}
+		catalog.PluginTypes = append(catalog.PluginTypes, doc)
+	}
+	return catalog
+}
+
+// serviceDoc describes service, declared in file.
+func serviceDoc(file protoreflect.FileDescriptor, service protoreflect.ServiceDescriptor) ServiceDoc {
+	doc := ServiceDoc{Name: string(service.FullName()), File: file.Path()}
+	methods := service.Methods()
+	for i := range methods.Len() {
+		m := methods.Get(i)
+		doc.Methods = append(doc.Methods, MethodDoc{
+			Name:            string(m.Name()),
+			Input:           string(m.Input().FullName()),
+			Output:          string(m.Output().FullName()),
+			ClientStreaming: m.IsStreamingClient(),
+			ServerStreaming: m.IsStreamingServer(),
+		})
+	}
+	return doc
+}
+
+// addMessages adds messages and their nested messages and enums to catalog. Synthetic map entry messages are
+// described by their map field instead.
+func addMessages(catalog *APICatalog, messages protoreflect.MessageDescriptors) {
+	for i := range messages.Len() {
+		msg := messages.Get(i)
+		if msg.IsMapEntry() {
+			continue
+		}
+		doc := MessageDoc{Name: string(msg.FullName()), Fields: []FieldDoc{}}
+		fields := msg.Fields()
+		for j := range fields.Len() {
+			doc.Fields = append(doc.Fields, fieldDoc(fields.Get(j)))
+		}
+		catalog.Messages = append(catalog.Messages, doc)
+		addMessages(catalog, msg.Messages())
+		addEnums(catalog, msg.Enums())
+	}
+}
+
+// addEnums adds enums to catalog.
+func addEnums(catalog *APICatalog, enums protoreflect.EnumDescriptors) {
+	for i := range enums.Len() {
+		enum := enums.Get(i)
+		doc := EnumDoc{Name: string(enum.FullName())}
+		values := enum.Values()
+		for j := range values.Len() {
+			doc.Values = append(doc.Values, string(values.Get(j).Name()))
+		}
+		catalog.Enums = append(catalog.Enums, doc)
+	}
+}
+
+// fieldDoc describes field; a map field is typed by its value.
+func fieldDoc(field protoreflect.FieldDescriptor) FieldDoc {
+	doc := FieldDoc{
+		Name:     string(field.Name()),
+		Number:   int(field.Number()),
+		Repeated: field.IsList(),
+		Map:      field.IsMap(),
+	}
+	if field.IsMap() {
+		field = field.MapValue()
+	}
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		doc.Type = string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		doc.Type = string(field.Enum().FullName())
+	default:
+		doc.Type = field.Kind().String()
+	}
+	return doc
+}
+
+// schemaFields describes the YAML fields of struct type t, whose dotted YAML path is path.
+func schemaFields(t reflect.Type, path string) []SchemaField {
+	var fields []SchemaField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		field := SchemaField{Name: name, Type: ft.Kind().String(), Values: capabilityValues[fieldPath]}
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+			field.Type = "list of " + ft.Kind().String()
+		}
+		if ft.Kind() == reflect.Struct {
+			field.Type = strings.Replace(field.Type, "struct", "object", 1)
+			field.Fields = schemaFields(ft, fieldPath)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// apiCatalog writes the APICatalog of this host build as JSON.
+func apiCatalog(opts DebugOptions) http.HandlerFunc {
+	return writeAPICatalog(opts.HostVersion, opts.Logger)
+}
+
+// writeAPICatalog returns a handler writing the APICatalog of the host version as JSON.
+func writeAPICatalog(hostVersion string, catalogLogger hclog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(BuildAPICatalog(hostVersion)); err != nil {
+			requestLogger(r.Context(), catalogLogger).Error("Failed to write API catalog", logger.KeyError, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+)
+
+// ErrNotAuthProvider indicates that the plugin named as the management auth provider does not implement
+// authprovider.AuthProvider.
+var ErrNotAuthProvider = errors.New("plugin is not an auth provider")
+
+// PluginAuth is an authprovider.AuthProvider that authenticates through the named authprovider plugin, dispensed for
+// every request so a plugin restarted since the last one is picked up. A request is rejected when the plugin cannot be
+// dispensed, so a missing auth provider never opens the management API.
+type PluginAuth struct {
+	name     string
+	dispense func(name string) (any, error)
+}
+
+// NewPluginAuth returns a PluginAuth authenticating through the plugin name, dispensed with dispense, e.g. a host's
+// Dispense.
+func NewPluginAuth(name string, dispense func(name string) (any, error)) *PluginAuth {
+	return &PluginAuth{name: name, dispense: dispense}
+}
+
+// Authenticate dispenses the plugin and passes req to it.
+func (a *PluginAuth) Authenticate(req authprovider.Request) (authprovider.Result, error) {
+	raw, err := a.dispense(a.name)
+	if err != nil {
+		return authprovider.Result{}, fmt.Errorf("%s: %w", a.name, err)
+	}
+	provider, ok := raw.(authprovider.AuthProvider)
+	if !ok {
+		return authprovider.Result{}, fmt.Errorf("%w: %s", ErrNotAuthProvider, a.name)
+	}
+	return provider.Authenticate(req)
+}
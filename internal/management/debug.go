@@ -0,0 +1,361 @@
+// Package management provides the host's management endpoints used by operators and tooling to inspect and
+// control a running host.
+package management
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/sbom"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DebugPrefix is the path prefix under which all debug endpoints are served.
+	DebugPrefix = "/debug/"
+	// bearerPrefix is the expected prefix of the Authorization header value.
+	bearerPrefix = "Bearer "
+)
+
+// ErrDebugDisabled indicates that the debug endpoints were requested but are disabled by configuration.
+// ErrDebugNoCredentials indicates that the debug endpoints were requested but neither a token nor an auth provider is
+// configured, so they refuse every request.
+// ErrNoJanitor indicates that the janitor endpoints were requested but no janitor is configured.
+var (
+	ErrDebugDisabled      = errors.New("debug endpoints are disabled")
+	ErrDebugNoCredentials = errors.New("debug endpoints require a token or an auth provider")
+	ErrNoJanitor          = errors.New("plugin artifact janitor is not configured")
+)
+
+// DebugOptions configures the debug endpoints. The endpoints are only served when Enabled is true. When Auth is set
+// every request is authenticated by the authprovider plugin; otherwise every request must present Token as a bearer
+// token, and without either the endpoints refuse every request.
+type DebugOptions struct {
+	Enabled     bool
+	Token       string
+	Auth        authprovider.AuthProvider
+	Catalog     *registry.PluginCatalog
+	Pool        *worker.Pool
+	Errors      *logger.ErrorFingerprinter
+	Levels      *logger.LevelRegistry
+	Janitor     *registry.Janitor
+	Memory      *worker.MemoryMonitor
+	Incidents   *IncidentCapturer
+	HostVersion string // reported by the API catalog
+	Logger      hclog.Logger
+}
+
+// RuntimeState is a serializable summary of the Go runtime used by the state dump endpoint.
+type RuntimeState struct {
+	GoVersion  string `json:"go_version"`
+	Goroutines int    `json:"goroutines"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	HeapAlloc  uint64 `json:"heap_alloc_bytes"`
+	HeapInuse  uint64 `json:"heap_inuse_bytes"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// readRuntimeState returns the current RuntimeState of the process.
+func readRuntimeState() RuntimeState {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return RuntimeState{
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		HeapAlloc:  ms.HeapAlloc,
+		HeapInuse:  ms.HeapInuse,
+		NumGC:      ms.NumGC,
+	}
+}
+
+// StateDump is the payload returned by the state dump endpoint, combining catalog, pool, and runtime state.
+type StateDump struct {
+	Timestamp time.Time                 `json:"timestamp"`
+	Runtime   RuntimeState              `json:"runtime"`
+	Catalog   *registry.CatalogSnapshot `json:"catalog,omitempty"`
+	Pool      *worker.PoolSnapshot      `json:"pool,omitempty"`
+	Memory    *worker.MemoryStats       `json:"memory,omitempty"`
+	Pending   []worker.JobSnapshot      `json:"pending_jobs,omitempty"`
+	Running   []worker.JobSnapshot      `json:"running_jobs,omitempty"`
+	TopErrors []logger.ErrorStats       `json:"top_errors,omitempty"`
+}
+
+// LogLevelRequest is the body accepted by the log level endpoint.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// DebugHandler returns an http.Handler serving pprof profiles under /debug/pprof/, a full goroutine dump at
+// /debug/goroutines, a catalog, pool, and memory state dump at /debug/state, and the levels of the registered loggers
+// at /debug/loglevels, where PUT /debug/loglevels/{name} changes one logger's level at runtime, and the APICatalog of
+// the services, plugin types, and capabilities this host supports at /debug/api. GET /debug/sbom serves a bill of
+// materials of the host and its installed plugins, as CycloneDX or, with ?format=spdx, SPDX JSON. GET /debug/janitor
+// reports the plugin artifact janitor's totals and POST /debug/janitor runs a sweep. POST /debug/incident captures an
+// incident archive, with the optional reason query parameter, and returns its IncidentReport. Responses carry the
+// request's ID in the X-Request-ID header. It is intended to be mounted on the management API at DebugPrefix.
+func DebugHandler(opts DebugOptions) http.Handler {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(DebugPrefix+"pprof/", pprof.Index)
+	mux.HandleFunc(DebugPrefix+"pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc(DebugPrefix+"pprof/profile", pprof.Profile)
+	mux.HandleFunc(DebugPrefix+"pprof/symbol", pprof.Symbol)
+	mux.HandleFunc(DebugPrefix+"pprof/trace", pprof.Trace)
+	mux.HandleFunc(DebugPrefix+"goroutines", goroutineDump(opts.Logger))
+	mux.HandleFunc(DebugPrefix+"state", stateDump(opts))
+	mux.HandleFunc("GET "+DebugPrefix+"loglevels", logLevels(opts))
+	mux.HandleFunc("PUT "+DebugPrefix+"loglevels/{name}", setLogLevel(opts))
+	mux.HandleFunc("GET "+DebugPrefix+"api", apiCatalog(opts))
+	mux.HandleFunc("GET "+DebugPrefix+"sbom", billOfMaterials(opts))
+	mux.HandleFunc("GET "+DebugPrefix+"janitor", janitorStats(opts.Janitor, opts.Logger))
+	mux.HandleFunc("POST "+DebugPrefix+"janitor", janitorSweep(opts.Janitor, opts.Logger))
+	mux.HandleFunc("POST "+DebugPrefix+"incident", captureIncident(opts))
+	return requireRequestID(opts.Logger, guard(opts, mux))
+}
+
+// guard rejects requests when the debug endpoints are disabled, have no credentials configured, or the request is not
+// authenticated.
+func guard(opts DebugOptions, next http.Handler) http.Handler {
+	authorized := requireAuth("debug", opts.Token, opts.Auth, opts.Logger, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.Enabled {
+			http.Error(w, ErrDebugDisabled.Error(), http.StatusNotFound)
+			return
+		}
+		// pprof, log levels, and incident captures are never served unauthenticated
+		if opts.Token == "" && opts.Auth == nil {
+			http.Error(w, ErrDebugNoCredentials.Error(), http.StatusForbidden)
+			return
+		}
+		authorized.ServeHTTP(w, r)
+	})
+}
+
+// requireAuth rejects requests to the kind of endpoint that the authprovider plugin denies or, without one, that do
+// not present token as a bearer token. An empty token without a provider lets every request through.
+func requireAuth(kind, token string, auth authprovider.AuthProvider, authLogger hclog.Logger,
+	next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil {
+			if !authenticate(kind, auth, authLogger, w, r) {
+				return
+			}
+		} else if token != "" {
+			header := r.Header.Get("Authorization")
+			presented := strings.TrimPrefix(header, bearerPrefix)
+			if !strings.HasPrefix(header, bearerPrefix) ||
+				subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				requestLogger(r.Context(), authLogger).Warn("Rejected unauthorized "+kind+" request",
+					"path", r.URL.Path, "remote", r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate consults the authprovider plugin for the request, writing an error response and returning false when
+// the request is denied or no decision could be made.
+func authenticate(kind string, auth authprovider.AuthProvider, authLogger hclog.Logger, w http.ResponseWriter,
+	r *http.Request) bool {
+	authLogger = requestLogger(r.Context(), authLogger)
+	res, err := auth.Authenticate(authprovider.Request{
+		Token:      strings.TrimPrefix(r.Header.Get("Authorization"), bearerPrefix),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+	})
+	if err != nil {
+		authLogger.Error("Auth provider failed", "path", r.URL.Path, logger.KeyError, err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return false
+	}
+	if !res.Allowed {
+		authLogger.Warn("Rejected unauthorized "+kind+" request", "path", r.URL.Path, "remote", r.RemoteAddr,
+			"reason", res.Reason)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return false
+	}
+	authLogger.Debug("Authenticated "+kind+" request", "path", r.URL.Path, "subject", res.Subject)
+	return true
+}
+
+// goroutineDump writes the stack traces of all goroutines in plain text.
+func goroutineDump(dumpLogger hclog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := rpprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+			dumpLogger.Error("Failed to write goroutine dump", logger.KeyError, err)
+		}
+	}
+}
+
+// captureState returns a StateDump of the given sources, any of which may be nil.
+func captureState(catalog *registry.PluginCatalog, pool *worker.Pool, memory *worker.MemoryMonitor,
+	errs *logger.ErrorFingerprinter) StateDump {
+	dump := StateDump{
+		Timestamp: time.Now(),
+		Runtime:   readRuntimeState(),
+	}
+	if catalog != nil {
+		snap := catalog.Snapshot()
+		dump.Catalog = &snap
+	}
+	if pool != nil {
+		snap := pool.Snapshot()
+		dump.Pool = &snap
+		dump.Pending = pool.Pending()
+		dump.Running = pool.Running()
+	}
+	if memory != nil {
+		stats := memory.Stats()
+		dump.Memory = &stats
+	}
+	if errs != nil {
+		dump.TopErrors = errs.Top(logger.DefaultTopErrors)
+	}
+	return dump
+}
+
+// stateDump writes a StateDump of the configured catalog and pool as JSON.
+func stateDump(opts DebugOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dump := captureState(opts.Catalog, opts.Pool, opts.Memory, opts.Errors)
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(dump); err != nil {
+			requestLogger(r.Context(), opts.Logger).Error("Failed to write state dump", logger.KeyError, err)
+		}
+	}
+}
+
+// logLevels writes the current level of every registered logger as JSON.
+func logLevels(opts DebugOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		levels := map[string]string{}
+		if opts.Levels != nil {
+			levels = opts.Levels.Levels()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(levels); err != nil {
+			requestLogger(r.Context(), opts.Logger).Error("Failed to write log levels", logger.KeyError, err)
+		}
+	}
+}
+
+// setLogLevel changes the level of the logger named in the path to the level in the LogLevelRequest body.
+func setLogLevel(opts DebugOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Levels == nil {
+			http.Error(w, logger.ErrUnknownLogger.Error(), http.StatusNotFound)
+			return
+		}
+		var req LogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level, err := logger.ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := r.PathValue("name")
+		if err := opts.Levels.SetLevel(name, level); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		requestLogger(r.Context(), opts.Logger).Info("Changed log level", logger.KeyLogger, name, "level", level.String())
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// janitorStats writes the JanitorStats of the plugin artifact janitor as JSON.
+func janitorStats(janitor *registry.Janitor, janitorLogger hclog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if janitor == nil {
+			http.Error(w, ErrNoJanitor.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(janitor.Stats()); err != nil {
+			requestLogger(r.Context(), janitorLogger).Error("Failed to write janitor stats", logger.KeyError, err)
+		}
+	}
+}
+
+// janitorSweep runs a sweep of the plugin artifact janitor and writes its JanitorReport as JSON.
+func janitorSweep(janitor *registry.Janitor, janitorLogger hclog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if janitor == nil {
+			http.Error(w, ErrNoJanitor.Error(), http.StatusNotFound)
+			return
+		}
+		report := janitor.Sweep()
+		requestLogger(r.Context(), janitorLogger).Info("Ran janitor sweep", "bytes_reclaimed", report.BytesReclaimed)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			requestLogger(r.Context(), janitorLogger).Error("Failed to write janitor report", logger.KeyError, err)
+		}
+	}
+}
+
+// billOfMaterials writes an SBOM of the host binary and the catalog's installed plugins in the format named by the
+// format query parameter, CycloneDX by default.
+func billOfMaterials(opts DebugOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = sbom.FormatCycloneDX
+		}
+		data, err := sbom.Build(opts.HostVersion, opts.Catalog).Encode(format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(data); err != nil {
+			requestLogger(r.Context(), opts.Logger).Error("Failed to write SBOM", logger.KeyError, err)
+		}
+	}
+}
+
+// captureIncident captures an incident archive and writes its IncidentReport as JSON. The capture is cut short when
+// the client disconnects during the CPU profile.
+func captureIncident(opts DebugOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Incidents == nil {
+			http.Error(w, ErrNoIncidents.Error(), http.StatusNotFound)
+			return
+		}
+		report, err := opts.Incidents.Capture(r.Context(), IncidentManual, r.URL.Query().Get("reason"), nil)
+		switch {
+		case errors.Is(err, ErrIncidentInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			requestLogger(r.Context(), opts.Logger).Error("Failed to write incident report", logger.KeyError, err)
+		}
+	}
+}
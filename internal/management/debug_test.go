@@ -0,0 +1,64 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	"github.com/hashicorp/go-hclog"
+)
+
+// stubAuth is an authprovider.AuthProvider allowing the requests that present its token.
+type stubAuth struct {
+	token string
+	err   error
+}
+
+// Authenticate allows the request when it presents the stub's token.
+func (a stubAuth) Authenticate(req authprovider.Request) (authprovider.Result, error) {
+	if a.err != nil {
+		return authprovider.Result{}, a.err
+	}
+	return authprovider.Result{Allowed: req.Token == a.token, Subject: "tester"}, nil
+}
+
+// TestDebugHandlerAuth checks that the debug endpoints are only served to authenticated requests, and never when no
+// credentials are configured.
+func TestDebugHandlerAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   DebugOptions
+		header string
+		want   int
+	}{
+		{name: "disabled", opts: DebugOptions{Token: "secret"}, header: "Bearer secret", want: http.StatusNotFound},
+		{name: "no credentials", opts: DebugOptions{Enabled: true}, want: http.StatusForbidden},
+		{name: "missing token", opts: DebugOptions{Enabled: true, Token: "secret"}, want: http.StatusUnauthorized},
+		{name: "wrong token", opts: DebugOptions{Enabled: true, Token: "secret"}, header: "Bearer guess",
+			want: http.StatusUnauthorized},
+		{name: "token", opts: DebugOptions{Enabled: true, Token: "secret"}, header: "Bearer secret",
+			want: http.StatusOK},
+		{name: "provider denies", opts: DebugOptions{Enabled: true, Auth: stubAuth{token: "secret"}},
+			header: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "provider allows", opts: DebugOptions{Enabled: true, Auth: stubAuth{token: "secret"}},
+			header: "Bearer secret", want: http.StatusOK},
+		{name: "provider fails", opts: DebugOptions{Enabled: true, Auth: stubAuth{err: errors.New("down")}},
+			header: "Bearer secret", want: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Logger = hclog.NewNullLogger()
+			req := httptest.NewRequest(http.MethodGet, DebugPrefix+"api", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			DebugHandler(tt.opts).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
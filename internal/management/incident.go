@@ -0,0 +1,256 @@
+package management
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	rpprof "runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/utils/pkg/strutil"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultIncidentCPUProfile is how long an incident capture profiles the CPU when no duration is configured.
+// DefaultIncidentCooldown is the minimum time between two automatically triggered captures when none is configured.
+const (
+	DefaultIncidentCPUProfile = 10 * time.Second
+	DefaultIncidentCooldown   = 5 * time.Minute
+)
+
+// IncidentManual marks a capture requested by an operator.
+// IncidentWatchdog marks a capture triggered by a job running longer than the watchdog's threshold.
+// IncidentFlap marks a capture triggered by a plugin being demoted by the flap detector.
+const (
+	IncidentManual   = "manual"
+	IncidentWatchdog = "watchdog"
+	IncidentFlap     = "flap"
+)
+
+// ErrIncidentInProgress indicates that a capture was requested while another one is still running.
+// ErrNoIncidents indicates that the incident endpoint was requested but no IncidentCapturer is configured.
+var (
+	ErrIncidentInProgress = errors.New("incident capture already in progress")
+	ErrNoIncidents        = errors.New("incident capture is not configured")
+)
+
+// IncidentOptions configures an IncidentCapturer. Archives are written to Dir, and each holds a CPU profile of
+// CPUProfile, a goroutine dump, a StateDump of Catalog, Pool, Memory, and Errors, and the records buffered in Logs.
+// Any of the sources may be nil, leaving its part out of the archive. Automatically triggered captures are
+// skipped within Cooldown of the previous one.
+type IncidentOptions struct {
+	Dir        string
+	CPUProfile time.Duration
+	Cooldown   time.Duration
+	Catalog    *registry.PluginCatalog
+	Pool       *worker.Pool
+	Memory     *worker.MemoryMonitor
+	Errors     *logger.ErrorFingerprinter
+	Logs       *logger.RecentLogs
+	Logger     hclog.Logger
+}
+
+// IncidentReport describes one capture. It is written to the archive as incident.json and returned to the caller.
+// Errors lists the parts of the archive that could not be captured.
+type IncidentReport struct {
+	ID        string        `json:"id"`
+	Trigger   string        `json:"trigger"`
+	Reason    string        `json:"reason"`
+	Detail    any           `json:"detail,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Archive   string        `json:"archive"`
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+// IncidentCapturer bundles a CPU profile, a goroutine dump, the pool and catalog state, and the recent logs of the
+// host into one tar.gz archive for offline analysis. Captures are requested manually with Capture or automatically
+// from watchdog and flap-detection events; only one capture runs at a time.
+type IncidentCapturer struct {
+	opts           IncidentOptions
+	incidentLogger hclog.Logger
+	capturing      atomic.Bool
+	mu             sync.Mutex
+	lastAuto       time.Time // start of the last automatically triggered capture
+}
+
+// NewIncidentCapturer creates an IncidentCapturer writing archives to opts.Dir. Non-positive durations use
+// DefaultIncidentCPUProfile and DefaultIncidentCooldown.
+func NewIncidentCapturer(opts IncidentOptions) *IncidentCapturer {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	if opts.CPUProfile <= 0 {
+		opts.CPUProfile = DefaultIncidentCPUProfile
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultIncidentCooldown
+	}
+	return &IncidentCapturer{opts: opts, incidentLogger: opts.Logger}
+}
+
+// Capture profiles the CPU for the configured duration, or until ctx is canceled, then writes the incident archive
+// and returns its IncidentReport. It returns ErrIncidentInProgress when another capture is running; failing to
+// capture one part of the archive is recorded in the report rather than returned.
+func (c *IncidentCapturer) Capture(ctx context.Context, trigger, reason string, detail any) (*IncidentReport, error) {
+	if !c.capturing.CompareAndSwap(false, true) {
+		return nil, ErrIncidentInProgress
+	}
+	defer c.capturing.Store(false)
+
+	report := &IncidentReport{
+		ID:        strutil.GenerateUUIDV7(),
+		Trigger:   trigger,
+		Reason:    reason,
+		Detail:    detail,
+		StartedAt: time.Now().UTC(),
+	}
+	c.incidentLogger.Warn("Capturing incident", "incident", report.ID, "trigger", trigger, "reason", reason)
+	files := make(map[string][]byte)
+	failed := func(part string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", part, err))
+	}
+
+	var cpu bytes.Buffer
+	if err := rpprof.StartCPUProfile(&cpu); err != nil {
+		failed("cpu.pprof", err)
+	} else {
+		select {
+		case <-time.After(c.opts.CPUProfile):
+		case <-ctx.Done():
+		}
+		rpprof.StopCPUProfile()
+		files["cpu.pprof"] = cpu.Bytes()
+	}
+
+	var goroutines bytes.Buffer
+	if err := rpprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		failed("goroutines.txt", err)
+	} else {
+		files["goroutines.txt"] = goroutines.Bytes()
+	}
+
+	state, err := json.MarshalIndent(captureState(c.opts.Catalog, c.opts.Pool, c.opts.Memory, c.opts.Errors), "", "  ")
+	if err != nil {
+		failed("state.json", err)
+	} else {
+		files["state.json"] = state
+	}
+
+	if c.opts.Logs != nil {
+		var logs bytes.Buffer
+		enc := json.NewEncoder(&logs)
+		for _, rec := range c.opts.Logs.Records() {
+			if err := enc.Encode(rec); err != nil {
+				failed("logs.jsonl", err)
+				break
+			}
+		}
+		files["logs.jsonl"] = logs.Bytes()
+	}
+
+	// the random tail of the ID keeps captures started within the same second apart
+	name := fmt.Sprintf("incident-%s-%s-%s.tar.gz", report.StartedAt.Format("20060102T150405Z"), trigger,
+		report.ID[len(report.ID)-8:])
+	report.Archive = filepath.Join(c.opts.Dir, name)
+	report.Duration = time.Since(report.StartedAt)
+	if err := writeIncidentArchive(report, files); err != nil {
+		c.incidentLogger.Error("Failed to write incident archive", "incident", report.ID, logger.KeyError, err)
+		return nil, err
+	}
+	c.incidentLogger.Warn("Captured incident", "incident", report.ID, "archive", report.Archive,
+		"duration", report.Duration.String())
+	return report, nil
+}
+
+// writeIncidentArchive writes incident.json and files to a tar.gz archive at report.Archive.
+func writeIncidentArchive(report *IncidentReport, files map[string][]byte) (err error) {
+	meta, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(report.Archive), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(report.Archive)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, f.Close()) }()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{"incident.json", "cpu.pprof", "goroutines.txt", "state.json", "logs.jsonl"} {
+		data, ok := files[name]
+		if name == "incident.json" {
+			data, ok = meta, true
+		}
+		if !ok {
+			continue
+		}
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: report.StartedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Trigger starts an automatic capture in the background unless one ran within the cooldown or is still running, and
+// reports whether a capture was started.
+func (c *IncidentCapturer) Trigger(trigger, reason string, detail any) bool {
+	c.mu.Lock()
+	if time.Since(c.lastAuto) < c.opts.Cooldown || c.capturing.Load() {
+		c.mu.Unlock()
+		c.incidentLogger.Debug("Skipped incident capture", "trigger", trigger, "reason", reason)
+		return false
+	}
+	c.lastAuto = time.Now()
+	c.mu.Unlock()
+	go func() {
+		if _, err := c.Capture(context.Background(), trigger, reason, detail); err != nil &&
+			!errors.Is(err, ErrIncidentInProgress) {
+			c.incidentLogger.Error("Incident capture failed", "trigger", trigger, logger.KeyError, err)
+		}
+	}()
+	return true
+}
+
+// WatchLongJobs triggers a capture for the long-running job events of a Watchdog until events is closed or ctx is
+// canceled.
+func (c *IncidentCapturer) WatchLongJobs(ctx context.Context, events <-chan worker.LongJobEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.Trigger(IncidentWatchdog, fmt.Sprintf("job %s running longer than %s", event.Job.JobID, event.Threshold),
+				event)
+		}
+	}
+}
+
+// OnFlap triggers a capture for a plugin demoted by the flap detector. It is meant to be passed to
+// FlapDetector.OnDisable.
+func (c *IncidentCapturer) OnFlap(report registry.FlapReport) {
+	c.Trigger(IncidentFlap, fmt.Sprintf("plugin %s is flapping", report.PluginName), report)
+}
@@ -0,0 +1,77 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+	"github.com/hashicorp/go-hclog"
+)
+
+// jobSourceType is the manifest type of jobsource plugins.
+const jobSourceType = "jobsource"
+
+// ErrNotJobSource indicates that a plugin of the jobsource type does not implement jobsource.JobSource.
+var ErrNotJobSource = errors.New("plugin is not a job source")
+
+// JobSourceOptions configures AttachJobSources. Dispense starts and returns a plugin, e.g. a host's Dispense; Pool is
+// the pool the sources' jobs are submitted to.
+type JobSourceOptions struct {
+	Catalog  *registry.PluginCatalog
+	Dispense func(name string) (any, error)
+	Pool     *worker.Pool
+	Logger   hclog.Logger
+}
+
+// AttachJobSources dispenses every jobsource plugin in the catalog and runs a worker.SourceFeeder for each, limited
+// by the jobs capability of the plugin's manifest, until ctx is canceled or stop is called, which waits for the
+// feeders to return. Plugins that cannot be dispensed, are not job sources, or declare no jobs capability are skipped
+// and reported in the returned error; the others feed the pool regardless.
+func AttachJobSources(ctx context.Context, opts JobSourceOptions) (stop func(), err error) {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var errs []error
+	for _, info := range opts.Catalog.ListByType(jobSourceType) {
+		caps, _ := opts.Catalog.Capabilities(info.Name)
+		if caps.Jobs == nil || len(caps.Jobs.Types) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", worker.ErrNoJobsCapability, info.Name))
+			continue
+		}
+		raw, err := opts.Dispense(info.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", info.Name, err))
+			continue
+		}
+		source, ok := raw.(jobsource.JobSource)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotJobSource, info.Name))
+			continue
+		}
+		feederLogger := opts.Logger.With(logger.KeyPluginName, info.Name)
+		feeder := worker.NewSourceFeeder(info.Name, source, opts.Pool, caps.Jobs, feederLogger)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := feeder.Run(ctx)
+			if err != nil && ctx.Err() == nil && !errors.Is(err, worker.ErrPoolClosed) {
+				feederLogger.Warn("Job source stopped with an error", logger.KeyError, err)
+			}
+		}()
+	}
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+	return stop, errors.Join(errs...)
+}
@@ -0,0 +1,212 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/shared/pkg/metricsink"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultExportInterval is how often a MetricsExporter sends a snapshot when no interval is configured.
+const DefaultExportInterval = 15 * time.Second
+
+// metricSinkType is the manifest type of metricsink plugins.
+const metricSinkType = "metricsink"
+
+// ErrNotMetricSink indicates that a plugin of the metricsink type does not implement metricsink.MetricSink.
+var ErrNotMetricSink = errors.New("plugin is not a metric sink")
+
+// MetricsExporter periodically collects pool, plugin, and runtime metrics and sends them to a metricsink plugin,
+// so exporters for a monitoring stack are written as plugins rather than host code.
+type MetricsExporter struct {
+	exporterLogger hclog.Logger
+	sink           metricsink.MetricSink
+	interval       time.Duration
+	host           string
+	pool           *worker.Pool
+	plugins        *registry.PluginManager
+	memory         *worker.MemoryMonitor
+}
+
+// NewMetricsExporter creates a MetricsExporter that sends a snapshot to sink every interval. A non-positive
+// interval falls back to DefaultExportInterval.
+func NewMetricsExporter(sink metricsink.MetricSink, interval time.Duration,
+	exporterLogger hclog.Logger) *MetricsExporter {
+	if exporterLogger == nil {
+		exporterLogger = hclog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultExportInterval
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &MetricsExporter{
+		exporterLogger: exporterLogger,
+		sink:           sink,
+		interval:       interval,
+		host:           host,
+	}
+}
+
+// WithPool includes the pool's metrics in each snapshot and returns the updated MetricsExporter.
+func (e *MetricsExporter) WithPool(pool *worker.Pool) *MetricsExporter {
+	e.pool = pool
+	return e
+}
+
+// WithPluginManager includes the lifecycle status of the managed plugins in each snapshot and returns the updated
+// MetricsExporter.
+func (e *MetricsExporter) WithPluginManager(plugins *registry.PluginManager) *MetricsExporter {
+	e.plugins = plugins
+	return e
+}
+
+// WithMemoryMonitor reports the monitor's latest sample, including the GC pause ratio over its interval and whether
+// the pool is throttled, as the runtime metrics of each snapshot, and returns the updated MetricsExporter. Without a
+// monitor the runtime is sampled when a snapshot is collected.
+func (e *MetricsExporter) WithMemoryMonitor(memory *worker.MemoryMonitor) *MetricsExporter {
+	e.memory = memory
+	return e
+}
+
+// Collect returns a snapshot of the current metrics.
+func (e *MetricsExporter) Collect() metricsink.Snapshot {
+	var mem worker.MemoryStats
+	if e.memory != nil {
+		mem = e.memory.Stats()
+	} else {
+		mem = worker.ReadMemoryStats()
+	}
+	snap := metricsink.Snapshot{
+		Time: timestamp.Now(),
+		Host: e.host,
+		Runtime: metricsink.HostMetrics{
+			Goroutines:    mem.Goroutines,
+			HeapAlloc:     mem.HeapAlloc,
+			HeapInuse:     mem.HeapInuse,
+			HeapSys:       mem.HeapSys,
+			Sys:           mem.Sys,
+			Used:          mem.Used,
+			NextGC:        mem.NextGC,
+			NumGC:         mem.NumGC,
+			GCPauseTotal:  mem.GCPauseTotal,
+			LastGCPause:   mem.LastGCPause,
+			GCPauseRatio:  mem.GCPauseRatio,
+			GCCPUFraction: mem.GCCPUFraction,
+			SoftLimit:     mem.SoftLimit,
+			Throttled:     mem.Throttled,
+		},
+	}
+	if e.pool != nil {
+		ps := e.pool.Snapshot()
+		snap.Pool = metricsink.PoolMetrics{
+			Workers:           ps.Workers,
+			QueuedJobs:        ps.QueuedJobs,
+			RunningJobs:       len(e.pool.Running()),
+			JobsSubmitted:     ps.Submissions,
+			FailedSubmissions: ps.FailedSubmissions,
+			SuccessfulJobs:    ps.SuccessfulJobs,
+			FailedJobs:        ps.FailedJobs,
+			TimedOutJobs:      ps.TimedOutJobs,
+		}
+	}
+	if e.plugins != nil {
+		for _, status := range e.plugins.Statuses() {
+			pm := metricsink.PluginMetrics{
+				Name:     status.Name,
+				State:    status.StateName,
+				Restarts: status.Restarts,
+			}
+			if metrics, err := e.plugins.Metrics(status.Name); err == nil {
+				pm.Crashes = metrics.Crashes
+				pm.Quarantined = metrics.Quarantined
+			}
+			snap.Plugins = append(snap.Plugins, pm)
+		}
+	}
+	return snap
+}
+
+// Run sends a snapshot every interval until ctx is canceled. Failed exports are logged and retried with the next
+// snapshot.
+func (e *MetricsExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.sink.Export(e.Collect()); err != nil {
+				e.exporterLogger.Warn("Failed to export metrics to sink plugin", logger.KeyError, err)
+			}
+		}
+	}
+}
+
+// MetricSinkOptions configures AttachMetricSinks. Dispense starts and returns a plugin, e.g. a host's Dispense.
+// Pool, Manager, and Memory are optional and included in every snapshot like WithPool, WithPluginManager, and
+// WithMemoryMonitor.
+type MetricSinkOptions struct {
+	Catalog  *registry.PluginCatalog
+	Dispense func(name string) (any, error)
+	Interval time.Duration
+	Pool     *worker.Pool
+	Manager  *registry.PluginManager
+	Memory   *worker.MemoryMonitor
+	Logger   hclog.Logger
+}
+
+// AttachMetricSinks dispenses every metricsink plugin in the catalog and runs a MetricsExporter for each until ctx is
+// canceled or stop is called, which waits for the exporters to return. Plugins that cannot be dispensed, or are not
+// metric sinks, are skipped and reported in the returned error; the others are exported to regardless.
+func AttachMetricSinks(ctx context.Context, opts MetricSinkOptions) (stop func(), err error) {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var errs []error
+	for _, info := range opts.Catalog.ListByType(metricSinkType) {
+		raw, err := opts.Dispense(info.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", info.Name, err))
+			continue
+		}
+		sink, ok := raw.(metricsink.MetricSink)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotMetricSink, info.Name))
+			continue
+		}
+		exporter := NewMetricsExporter(sink, opts.Interval, opts.Logger.With(logger.KeyPluginName, info.Name)).
+			WithPool(opts.Pool).
+			WithPluginManager(opts.Manager).
+			WithMemoryMonitor(opts.Memory)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exporter.Run(ctx)
+		}()
+		opts.Logger.Info("Exporting metrics to sink plugin", logger.KeyPluginName, info.Name,
+			"interval", exporter.interval.String())
+	}
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+	return stop, errors.Join(errs...)
+}
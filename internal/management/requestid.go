@@ -0,0 +1,57 @@
+package management
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/callctx"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// withRequestID returns ctx carrying id as its request ID and, for requestLogger, a logger adding it to every record.
+func withRequestID(ctx context.Context, id string, l hclog.Logger) context.Context {
+	return hclog.WithContext(callctx.WithRequestID(ctx, id), l.With(logger.KeyRequestID, id))
+}
+
+// requestLogger returns the logger stored in ctx by withRequestID, or fallback outside a request.
+func requestLogger(ctx context.Context, fallback hclog.Logger) hclog.Logger {
+	if _, ok := callctx.RequestIDFromCtx(ctx); !ok {
+		return fallback
+	}
+	return hclog.FromContext(ctx)
+}
+
+// requestIDInterceptor returns an interceptor that gives every admin call a request ID, the one the client sent in
+// the callctx.MetadataRequestID metadata when it is valid and a new one otherwise. The ID is returned to the client
+// in the response header metadata, forwarded to the plugin calls made for the request, and added to the records
+// logged for it.
+func requestIDInterceptor(l hclog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := ""
+		if values := metadata.ValueFromIncomingContext(ctx, callctx.MetadataRequestID); len(values) > 0 &&
+			callctx.ValidRequestID(values[0]) {
+			id = values[0]
+		} else {
+			id = callctx.NewRequestID()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(callctx.MetadataRequestID, id))
+		return handler(withRequestID(ctx, id, l), req)
+	}
+}
+
+// requireRequestID gives every HTTP request a request ID, the one the client sent in the callctx.HeaderRequestID
+// header when it is valid and a new one otherwise, returns it in the same response header, and stores it in the
+// request's context as requestIDInterceptor does for admin calls.
+func requireRequestID(l hclog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(callctx.HeaderRequestID)
+		if !callctx.ValidRequestID(id) {
+			id = callctx.NewRequestID()
+		}
+		w.Header().Set(callctx.HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(withRequestID(r.Context(), id, l)))
+	})
+}
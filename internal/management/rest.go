@@ -0,0 +1,383 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/hashicorp/go-hclog"
+)
+
+// HealthOK reports that every managed plugin is in a healthy or inactive state.
+// HealthDegraded reports that at least one managed plugin is in an error state, or that the host itself is degraded.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+// maxCallBody bounds the request body of a generic plugin call.
+const maxCallBody = 1 << 20
+
+// PluginDetail is the body returned by GET /plugins/{name}: the plugin's catalog entry and, once it has loaded as a
+// launchable plugin, its lifecycle status.
+type PluginDetail struct {
+	Info   registry.PluginInfo    `json:"info"`
+	Status *registry.PluginStatus `json:"status,omitempty"`
+}
+
+// PoolMetrics is the body returned by GET /pool/metrics: the pool snapshot and the number of jobs running.
+type PoolMetrics struct {
+	worker.PoolSnapshot
+	RunningJobs int `json:"running_jobs"`
+}
+
+// HealthReport is the body returned by GET /healthz. Status is HealthDegraded, served with 503 Service Unavailable,
+// when any plugin in Failed is in an error state or the host is degraded for the Reason given, and HealthOK otherwise.
+type HealthReport struct {
+	Status  string   `json:"status"`
+	Reason  string   `json:"reason,omitempty"`
+	Plugins int      `json:"plugins"`
+	Running int      `json:"running"`
+	Failed  []string `json:"failed"`
+}
+
+// RESTHandler returns an http.Handler serving the host's plugins and pool as JSON for monitoring systems that do not
+// speak gRPC: GET /plugins lists the installed plugins, filtered by the type, language, state, and q query parameters,
+// GET /plugins/{name} returns one plugin's PluginDetail, GET /plugins/{name}/services the services a running gRPC
+// plugin describes, POST /plugins/{name}/call/{method} calls one of their unary methods with the protojson request body
+// and returns the protojson response, POST /plugins/{name}/unquarantine releases a quarantined plugin, POST
+// /plugins/{name}/approve clears a flapping plugin's demotion after review, GET /plugins/{name}/flap its FlapReport
+// with its stability score, GET /flap the FlapReport of every plugin that crashed or restarted recently, least stable
+// first, GET /groups the status of every plugin group, GET /groups/{name} one group's status, GET /pool/metrics the
+// PoolMetrics of opts.Pool, GET /sla the sla.Report of opts.SLA, GET /sla/daily its daily rollups, filtered by the
+// plugin, since, and until (YYYY-MM-DD) query parameters, GET /api the APICatalog of this host build, GET /janitor the
+// plugin artifact janitor's totals, POST /janitor a sweep's JanitorReport, and GET /healthz a HealthReport. Requests
+// other than /healthz, which probes must reach without credentials, are authenticated like admin API calls, with the
+// bearer token in the Authorization header. Every response carries the request's ID in the X-Request-ID header, which
+// the client may set to correlate the request with its own.
+func RESTHandler(opts AdminOptions) http.Handler {
+	if opts.Logger == nil {
+		opts.Logger = hclog.Default()
+	}
+	api := http.NewServeMux()
+	api.HandleFunc("GET /plugins", listPlugins(opts))
+	api.HandleFunc("GET /plugins/{name}", pluginDetail(opts))
+	api.HandleFunc("GET /plugins/{name}/services", pluginServices(opts))
+	api.HandleFunc("POST /plugins/{name}/call/{method...}", pluginCall(opts))
+	api.HandleFunc("POST /plugins/{name}/unquarantine", unquarantinePlugin(opts))
+	api.HandleFunc("POST /plugins/{name}/approve", approvePlugin(opts))
+	api.HandleFunc("GET /plugins/{name}/flap", flapReport(opts))
+	api.HandleFunc("GET /flap", flapReports(opts))
+	api.HandleFunc("GET /groups", listGroups(opts))
+	api.HandleFunc("GET /groups/{name}", groupDetail(opts))
+	api.HandleFunc("GET /pool/metrics", poolMetrics(opts))
+	api.HandleFunc("GET /sla", slaReport(opts))
+	api.HandleFunc("GET /sla/daily", slaDaily(opts))
+	api.HandleFunc("GET /api", writeAPICatalog(opts.HostVersion, opts.Logger))
+	api.HandleFunc("GET /janitor", janitorStats(opts.Janitor, opts.Logger))
+	api.HandleFunc("POST /janitor", janitorSweep(opts.Janitor, opts.Logger))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", healthz(opts))
+	mux.Handle("/", requireAuth("management", opts.Token, opts.Auth, opts.Logger, api))
+	return requireRequestID(opts.Logger, mux)
+}
+
+// writeJSON writes v as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, restLogger hclog.Logger, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		restLogger.Error("Failed to write response", logger.KeyError, err)
+	}
+}
+
+// listPlugins writes the installed plugins matching every filter in the query string as JSON.
+func listPlugins(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		infos := make([]registry.PluginInfo, 0)
+		if opts.Catalog != nil {
+			query := r.URL.Query()
+			for _, info := range opts.Catalog.Search(query.Get("q")) {
+				if matchFilter(info.Type, query.Get("type")) && matchFilter(info.Language, query.Get("language")) &&
+					matchFilter(info.StateName, query.Get("state")) {
+					infos = append(infos, info)
+				}
+			}
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, infos)
+	}
+}
+
+// pluginDetail writes the PluginDetail of the plugin named in the path as JSON.
+func pluginDetail(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		var detail PluginDetail
+		found := false
+		if opts.Catalog != nil {
+			for _, info := range opts.Catalog.List() {
+				if info.Name == name {
+					detail.Info, found = info, true
+					break
+				}
+			}
+		}
+		if opts.Manager != nil {
+			status, err := opts.Manager.Status(name)
+			switch {
+			case err == nil:
+				detail.Status, found = &status, true
+			case !errors.Is(err, registry.ErrPluginNotFound):
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if !found {
+			http.Error(w, registry.ErrPluginNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, detail)
+	}
+}
+
+// pluginServices writes the lifecycle.Description of the running plugin named in the path as JSON.
+func pluginServices(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Manager == nil {
+			http.Error(w, registry.ErrPluginNotRunning.Error(), http.StatusNotFound)
+			return
+		}
+		desc, err := opts.Manager.Describe(r.Context(), r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), callStatus(err))
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, desc)
+	}
+}
+
+// unquarantinePlugin releases the plugin named in the path from quarantine, leaving it stopped, and writes its
+// registry.PluginStatus as JSON.
+func unquarantinePlugin(opts AdminOptions) http.HandlerFunc {
+	return controlPlugin(opts, "unquarantine", opts.Manager.Unquarantine)
+}
+
+// approvePlugin clears the flap detector's demotion of the plugin named in the path after review, leaving it
+// stopped, and writes its registry.PluginStatus as JSON.
+func approvePlugin(opts AdminOptions) http.HandlerFunc {
+	return controlPlugin(opts, "approve", opts.Manager.Approve)
+}
+
+// controlPlugin runs the action on the plugin named in the path and writes its registry.PluginStatus as JSON.
+func controlPlugin(opts AdminOptions, action string, run func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Manager == nil {
+			http.Error(w, registry.ErrPluginNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		name := r.PathValue("name")
+		restLogger := requestLogger(r.Context(), opts.Logger)
+		if err := run(name); err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, registry.ErrPluginNotFound) {
+				code = http.StatusNotFound
+			}
+			restLogger.Warn("REST "+action+" failed", logger.KeyPluginName, name, logger.KeyError, err)
+			http.Error(w, err.Error(), code)
+			return
+		}
+		restLogger.Info("REST "+action+" succeeded", logger.KeyPluginName, name)
+		status, err := opts.Manager.Status(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, restLogger, http.StatusOK, status)
+	}
+}
+
+// flapReport writes the registry.FlapReport, with the stability score, of the plugin named in the path as JSON.
+func flapReport(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Flap == nil {
+			http.Error(w, ErrNoFlap.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, opts.Flap.Report(r.PathValue("name")))
+	}
+}
+
+// flapReports writes the registry.FlapReport of every plugin with crashes or restarts in the window as JSON, least
+// stable first.
+func flapReports(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Flap == nil {
+			http.Error(w, ErrNoFlap.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, opts.Flap.Reports())
+	}
+}
+
+// pluginCall calls the method named in the path, as Service/Method or a bare method name, of the running plugin
+// named in the path with the request body, and writes the response.
+func pluginCall(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Manager == nil {
+			http.Error(w, registry.ErrPluginNotRunning.Error(), http.StatusNotFound)
+			return
+		}
+		name, method := r.PathValue("name"), r.PathValue("method")
+		args, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallBody))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		callLogger := requestLogger(r.Context(), opts.Logger)
+		res, err := opts.Manager.Invoke(r.Context(), name, method, args)
+		if err != nil {
+			callLogger.Warn("Plugin call failed", logger.KeyPluginName, name, "method", method, logger.KeyError, err)
+			http.Error(w, err.Error(), callStatus(err))
+			return
+		}
+		callLogger.Info("Plugin called", logger.KeyPluginName, name, "method", method)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(append(res, '\n')); err != nil {
+			callLogger.Error("Failed to write response", logger.KeyError, err)
+		}
+	}
+}
+
+// callStatus returns the HTTP status code a failed plugin description or call is reported with.
+func callStatus(err error) int {
+	switch {
+	case errors.Is(err, registry.ErrPluginNotFound), errors.Is(err, registry.ErrPluginNotRunning),
+		errors.Is(err, registry.ErrMethodNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, registry.ErrInvalidArguments):
+		return http.StatusBadRequest
+	case errors.Is(err, registry.ErrDynamicUnsupported), errors.Is(err, registry.ErrStreamingMethod):
+		return http.StatusNotImplemented
+	case errors.Is(err, registry.ErrIncompatibleAPI):
+		return http.StatusConflict
+	default:
+		// the plugin failed the call
+		return http.StatusBadGateway
+	}
+}
+
+// listGroups writes the status of every plugin group as JSON.
+func listGroups(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groups := make([]registry.GroupStatus, 0)
+		if opts.Manager != nil {
+			groups = opts.Manager.GroupStatuses()
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, groups)
+	}
+}
+
+// groupDetail writes the status of the plugin group named in the path as JSON.
+func groupDetail(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Manager == nil {
+			http.Error(w, registry.ErrGroupNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		group, err := opts.Manager.GroupStatus(r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, group)
+	}
+}
+
+// poolMetrics writes the PoolMetrics of the worker pool as JSON.
+func poolMetrics(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.Pool == nil {
+			http.Error(w, ErrNoPool.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, PoolMetrics{
+			PoolSnapshot: opts.Pool.Snapshot(),
+			RunningJobs:  len(opts.Pool.Running()),
+		})
+	}
+}
+
+// slaReport writes the sla.Report of every configured window as JSON.
+func slaReport(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.SLA == nil {
+			http.Error(w, ErrNoSLA.Error(), http.StatusNotFound)
+			return
+		}
+		report, err := opts.SLA.Report(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, report)
+	}
+}
+
+// slaDaily writes the daily rollups matching the plugin, since, and until query parameters as JSON.
+func slaDaily(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.SLA == nil {
+			http.Error(w, ErrNoSLA.Error(), http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		since, until := q.Get("since"), q.Get("until")
+		for _, day := range []string{since, until} {
+			if _, err := time.Parse(time.DateOnly, day); day != "" && err != nil {
+				http.Error(w, "since and until must be dates in YYYY-MM-DD form", http.StatusBadRequest)
+				return
+			}
+		}
+		rollups, err := opts.SLA.Rollups(since, until, q.Get("plugin"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), http.StatusOK, rollups)
+	}
+}
+
+// healthz writes the HealthReport of the managed plugins as JSON, with 503 when any is in an error state or the host is
+// degraded.
+func healthz(opts AdminOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := HealthReport{Status: HealthOK, Failed: make([]string, 0)}
+		if opts.Manager != nil {
+			for _, status := range opts.Manager.Statuses() {
+				report.Plugins++
+				switch {
+				case status.State == registry.PluginRunning:
+					report.Running++
+				case status.State >= registry.PluginMissingManifest:
+					report.Failed = append(report.Failed, status.Name)
+				}
+			}
+		}
+		if opts.Degraded != nil {
+			if err := opts.Degraded(); err != nil {
+				report.Reason = err.Error()
+			}
+		}
+		code := http.StatusOK
+		if len(report.Failed) > 0 || report.Reason != "" {
+			report.Status = HealthDegraded
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, requestLogger(r.Context(), opts.Logger), code, report)
+	}
+}
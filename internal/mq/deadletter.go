@@ -0,0 +1,106 @@
+package mq
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/utils/pkg/strutil"
+)
+
+// ErrDeadLetterNotFound indicates that no dead letter is kept under the requested ID.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is an item the log queue's worker could not process, e.g. a record that is not valid JSON, kept with
+// the reason until an operator replays it.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Data     []byte    `json:"data"`
+	Reason   string    `json:"reason"`
+	At       time.Time `json:"at"` // when it was last dead-lettered
+	Attempts int       `json:"attempts"`
+}
+
+// NewDeadLetter creates a DeadLetter for data with a new ID, failed once for reason.
+func NewDeadLetter(data []byte, reason string) DeadLetter {
+	return DeadLetter{ID: strutil.GenerateUUIDV7(), Data: data, Reason: reason, At: time.Now(), Attempts: 1}
+}
+
+// DeadLetters keeps the dead letters of a queue in its backend: a table next to the queue's in SQLite, a hash in
+// Redis, or memory.
+type DeadLetters interface {
+	// Put stores d under d.ID, replacing any dead letter already stored under it.
+	Put(d DeadLetter) error
+	// List returns the dead letters, oldest first.
+	List() ([]DeadLetter, error)
+	// Remove forgets the dead letter stored under id; removing a missing one is not an error.
+	Remove(id string) error
+}
+
+// ReplayDeadLetters passes each dead letter with one of ids, every dead letter when ids is empty, to process again.
+// The ones processed are removed and the others kept with the new reason and attempt. It returns how many were
+// replayed and how many failed again, with the errors of the store and of the ids not found.
+func ReplayDeadLetters(dl DeadLetters, ids []string, process func(data []byte) error) (replayed, failed int,
+	err error) {
+	letters, err := dl.List()
+	if err != nil {
+		return 0, 0, err
+	}
+	var errs []error
+	for _, id := range ids {
+		if !slices.ContainsFunc(letters, func(d DeadLetter) bool { return d.ID == id }) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id))
+		}
+	}
+	for _, d := range letters {
+		if len(ids) > 0 && !slices.Contains(ids, d.ID) {
+			continue
+		}
+		if perr := process(d.Data); perr != nil {
+			failed++
+			d.Reason, d.At = perr.Error(), time.Now()
+			d.Attempts++
+			errs = append(errs, dl.Put(d))
+			continue
+		}
+		replayed++
+		errs = append(errs, dl.Remove(d.ID))
+	}
+	return replayed, failed, errors.Join(errs...)
+}
+
+// memoryDeadLetters keeps dead letters in memory, up to a capacity beyond which the oldest are dropped.
+type memoryDeadLetters struct {
+	mu       sync.Mutex
+	letters  []DeadLetter // oldest first
+	capacity int
+}
+
+// Put stores d, dropping the oldest dead letter when at capacity.
+func (m *memoryDeadLetters) Put(d DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = slices.DeleteFunc(m.letters, func(l DeadLetter) bool { return l.ID == d.ID })
+	if len(m.letters) >= m.capacity {
+		m.letters = m.letters[1:]
+	}
+	m.letters = append(m.letters, d)
+	return nil
+}
+
+// List returns the dead letters, oldest first.
+func (m *memoryDeadLetters) List() ([]DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.letters), nil
+}
+
+// Remove forgets the dead letter stored under id.
+func (m *memoryDeadLetters) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = slices.DeleteFunc(m.letters, func(l DeadLetter) bool { return l.ID == id })
+	return nil
+}
@@ -0,0 +1,158 @@
+package mq
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMemoryCapacity is the number of items the memory backend holds when no capacity is given.
+const DefaultMemoryCapacity = 10000
+
+// Memory is a Backend keeping the queue in a ring buffer of a fixed capacity. When it is full, an enqueued item
+// replaces the oldest one, which is counted as dropped, so a stalled worker costs the oldest records rather than
+// memory or blocked writers. Nothing survives a restart, dead letters included.
+type Memory struct {
+	mu       sync.Mutex
+	items    [][]byte
+	head     int               // index of the oldest item
+	size     int               // number of items queued
+	inflight map[string][]byte // dequeued items awaiting acknowledgement, by ack ID
+	nextAck  uint64
+	dropped  atomic.Uint64
+	closed   bool
+	dead     *memoryDeadLetters
+}
+
+// NewMemory creates an empty Memory holding up to capacity items, DefaultMemoryCapacity when it is not positive.
+func NewMemory(capacity int) *Memory {
+	if capacity <= 0 {
+		capacity = DefaultMemoryCapacity
+	}
+	return &Memory{
+		items:    make([][]byte, capacity),
+		inflight: make(map[string][]byte),
+		dead:     &memoryDeadLetters{capacity: capacity},
+	}
+}
+
+// Name returns BackendMemory.
+func (m *Memory) Name() string {
+	return BackendMemory
+}
+
+// DeadLetters returns the dead letters kept in memory, up to the buffer's capacity.
+func (m *Memory) DeadLetters() DeadLetters {
+	return m.dead
+}
+
+// Enqueue adds item, which must be a []byte, dropping the oldest item when the buffer is full.
+func (m *Memory) Enqueue(item any) bool {
+	data, ok := item.([]byte)
+	if !ok {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return false
+	}
+	if m.size == len(m.items) {
+		m.head = (m.head + 1) % len(m.items)
+		m.size--
+		m.dropped.Add(1)
+	}
+	m.items[(m.head+m.size)%len(m.items)] = data
+	m.size++
+	return true
+}
+
+// Dequeue removes and returns the oldest item.
+func (m *Memory) Dequeue() (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.pop()
+	if !ok {
+		return nil, false
+	}
+	return data, true
+}
+
+// DequeueWithAckId removes and returns the oldest item with the ID Acknowledge takes once it has been worked.
+func (m *Memory) DequeueWithAckId() (any, bool, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.pop()
+	if !ok {
+		return nil, false, ""
+	}
+	m.nextAck++
+	ackID := strconv.FormatUint(m.nextAck, 10)
+	m.inflight[ackID] = data
+	return data, true, ackID
+}
+
+// Acknowledge forgets the dequeued item with ackID.
+func (m *Memory) Acknowledge(ackID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.inflight[ackID]; !ok {
+		return false
+	}
+	delete(m.inflight, ackID)
+	return true
+}
+
+// pop removes and returns the oldest item. The caller must hold mu.
+func (m *Memory) pop() ([]byte, bool) {
+	if m.closed || m.size == 0 {
+		return nil, false
+	}
+	data := m.items[m.head]
+	m.items[m.head] = nil
+	m.head = (m.head + 1) % len(m.items)
+	m.size--
+	return data, true
+}
+
+// Len returns the number of items queued.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.size
+}
+
+// Values returns the queued items, oldest first.
+func (m *Memory) Values() []any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	values := make([]any, 0, m.size)
+	for i := range m.size {
+		values = append(values, m.items[(m.head+i)%len(m.items)])
+	}
+	return values
+}
+
+// Purge removes every queued item.
+func (m *Memory) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.items)
+	m.head, m.size = 0, 0
+}
+
+// Dropped returns the number of items dropped because the buffer was full.
+func (m *Memory) Dropped() uint64 {
+	return m.dropped.Load()
+}
+
+// Close discards the queued items and refuses new ones.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	clear(m.items)
+	m.head, m.size = 0, 0
+	clear(m.inflight)
+	return nil
+}
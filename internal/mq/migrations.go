@@ -0,0 +1,56 @@
+package mq
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/bmj2728/PlugsConc/internal/storage"
+)
+
+// Component is the name the log queue's schema is versioned under.
+const Component = "logqueue"
+
+// Migrations returns the log queue's schema migrations for the named backend opened with opts, as Open would open
+// it. Only the SQLite backend keeps a schema of its own; the queue's table is owned by sqliteq, so the migrations
+// version the tables beside it. The Redis and memory backends have nothing to migrate.
+func Migrations(backend string, opts Options) []storage.Migration {
+	file := opts.File
+	if file == "" {
+		file = DefaultSQLiteFile
+	}
+	return []storage.Migration{
+		{
+			Component:   Component,
+			Version:     1,
+			Description: "add the log_dead_letters table to the SQLite log queue",
+			Up: func(storage.Backend) error {
+				if backend != BackendSQLite {
+					return nil
+				}
+				return createDeadLetters(file)
+			},
+			// binaries without dead letters never read the table, so reverting leaves it and its records in place
+			Down: func(storage.Backend) error { return nil },
+		},
+	}
+}
+
+// createDeadLetters creates the log_dead_letters table in the SQLite log queue at path. A missing file is not an
+// error; OpenSQLite creates the table with the database.
+func createDeadLetters(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(deadLetterSchema)
+	return errors.Join(err, db.Close())
+}
@@ -0,0 +1,56 @@
+package mq
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestMigrationsCreateDeadLetters checks that the log queue migration adds the dead letter table to an existing
+// SQLite queue, and leaves a missing queue file and other backends alone.
+func TestMigrationsCreateDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "logs.db")
+	db, err := sql.Open("sqlite3", existing)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec("CREATE TABLE queue (id TEXT)"); err != nil {
+		t.Fatalf("create queue table: %v", err)
+	}
+
+	tests := map[string]struct {
+		backend string
+		file    string
+	}{
+		"existing sqlite": {backend: BackendSQLite, file: existing},
+		"missing sqlite":  {backend: BackendSQLite, file: filepath.Join(dir, "missing.db")},
+		"memory":          {backend: BackendMemory},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := storage.NewMigrator(storage.NewMemory(), hclog.NewNullLogger()).
+				Register(Migrations(tt.backend, Options{File: tt.file})...)
+			if _, err := m.Migrate(false); err != nil {
+				t.Fatalf("Migrate: %v", err)
+			}
+			if v, err := m.Version(Component); err != nil || v != 1 {
+				t.Errorf("got version %d, %v, want 1", v, err)
+			}
+		})
+	}
+	var table string
+	err = db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'log_dead_letters'").Scan(&table)
+	if err != nil {
+		t.Errorf("log_dead_letters table not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing queue file: got %v, want it left uncreated", err)
+	}
+}
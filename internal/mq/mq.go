@@ -0,0 +1,71 @@
+// Package mq provides the queue backends the persistent log queue can be stored in. A Backend is a varmq persistent
+// queue, so the log queue's worker is bound to it the same way whichever backend is chosen: SQLite for a local
+// database file, Redis for deployments without local disk, or an in-memory ring buffer that keeps only the newest
+// records and persists nothing.
+package mq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goptics/varmq"
+)
+
+// BackendSQLite stores the queue in a SQLite database file.
+// BackendRedis stores the queue in a Redis list.
+// BackendMemory keeps the queue in a bounded in-memory ring buffer; nothing is persisted.
+const (
+	BackendSQLite = "sqlite"
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// Backends lists the supported backend names.
+var Backends = []string{BackendSQLite, BackendRedis, BackendMemory}
+
+// QueueName is the name of the log queue: its SQLite table and, with a prefix, its Redis keys.
+const QueueName = "log-queue"
+
+var (
+	// ErrUnknownBackend indicates that the requested backend is not one of Backends.
+	ErrUnknownBackend = errors.New("unknown queue backend")
+	// ErrOpenBackend indicates that the backend's database or server could not be opened.
+	ErrOpenBackend = errors.New("failed to open queue backend")
+)
+
+// Backend is a persistent queue the log queue's worker can be bound to. Items are acknowledged once worked, and
+// backends that persist the queue requeue the items dequeued but never acknowledged when they are opened again.
+type Backend interface {
+	varmq.IPersistentQueue
+	// Name returns the backend's name, one of Backends.
+	Name() string
+	// DeadLetters returns where the items the worker could not process are kept, see ReplayDeadLetters.
+	DeadLetters() DeadLetters
+}
+
+// Options configures the backend Open opens; each backend uses only its own fields.
+type Options struct {
+	// File is the SQLite database file.
+	File string
+	// RedisAddr is the host:port of the Redis server, RedisPassword and RedisDB select the database, and RedisKey
+	// prefixes the keys of the queue's lists.
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisKey      string
+	// Capacity is the number of items the memory backend holds before dropping the oldest.
+	Capacity int
+}
+
+// Open opens the named backend with opts.
+func Open(backend string, opts Options) (Backend, error) {
+	switch backend {
+	case BackendSQLite:
+		return OpenSQLite(opts.File)
+	case BackendRedis:
+		return OpenRedis(opts.RedisAddr, opts.RedisPassword, opts.RedisDB, opts.RedisKey)
+	case BackendMemory:
+		return NewMemory(opts.Capacity), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, backend)
+}
@@ -0,0 +1,225 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKey prefixes the keys of the queue's lists when no key is given.
+// RedisTimeout bounds each command sent to the Redis server.
+const (
+	DefaultRedisKey = "plugsconc"
+	RedisTimeout    = 5 * time.Second
+)
+
+// Redis is a Backend keeping the queue in a Redis list, <key>:log-queue, moving each dequeued item to a second list,
+// <key>:log-queue:processing, until it is acknowledged, and its dead letters in the hash <key>:log-queue:dead. Items
+// left in the processing list by a host that stopped before acknowledging them are requeued when the queue is opened,
+// so each host needs a key of its own. Hosts without local disk can still log asynchronously.
+type Redis struct {
+	client     *redis.Client
+	pending    string // list of queued items
+	processing string // list of dequeued items awaiting acknowledgement
+	mu         sync.Mutex
+	inflight   map[string]string // dequeued items by ack ID
+	nextAck    uint64
+	dead       *redisDeadLetters
+}
+
+// OpenRedis connects to the Redis server at addr, selects db, and opens the queue under key, requeuing the items a
+// previous host dequeued but never acknowledged.
+func OpenRedis(addr, password string, db int, key string) (*Redis, error) {
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	r := &Redis{
+		client: redis.NewClient(&redis.Options{
+			Addr:         addr,
+			Password:     password,
+			DB:           db,
+			ReadTimeout:  RedisTimeout,
+			WriteTimeout: RedisTimeout,
+		}),
+		pending:    key + ":" + QueueName,
+		processing: key + ":" + QueueName + ":processing",
+		inflight:   make(map[string]string),
+	}
+	r.dead = &redisDeadLetters{client: r.client, key: key + ":" + QueueName + ":dead"}
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		_ = r.client.Close()
+		return nil, fmt.Errorf("%w: redis %s: %w", ErrOpenBackend, addr, err)
+	}
+	if err := r.requeue(ctx); err != nil {
+		_ = r.client.Close()
+		return nil, fmt.Errorf("%w: requeue unacknowledged items: %w", ErrOpenBackend, err)
+	}
+	return r, nil
+}
+
+// requeue moves the items in the processing list back to the front of the queue.
+func (r *Redis) requeue(ctx context.Context) error {
+	for {
+		err := r.client.LMove(ctx, r.processing, r.pending, "RIGHT", "LEFT").Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Name returns BackendRedis.
+func (r *Redis) Name() string {
+	return BackendRedis
+}
+
+// DeadLetters returns the dead letters kept in the <key>:log-queue:dead hash.
+func (r *Redis) DeadLetters() DeadLetters {
+	return r.dead
+}
+
+// Enqueue appends item, which must be a []byte, to the queue.
+func (r *Redis) Enqueue(item any) bool {
+	data, ok := item.([]byte)
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	return r.client.RPush(ctx, r.pending, data).Err() == nil
+}
+
+// Dequeue removes and returns the oldest item.
+func (r *Redis) Dequeue() (any, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	data, err := r.client.LPop(ctx, r.pending).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// DequeueWithAckId moves the oldest item to the processing list and returns it with the ID Acknowledge takes once
+// it has been worked.
+func (r *Redis) DequeueWithAckId() (any, bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	data, err := r.client.LMove(ctx, r.pending, r.processing, "LEFT", "RIGHT").Result()
+	if err != nil {
+		return nil, false, ""
+	}
+	r.mu.Lock()
+	r.nextAck++
+	ackID := strconv.FormatUint(r.nextAck, 10)
+	r.inflight[ackID] = data
+	r.mu.Unlock()
+	return []byte(data), true, ackID
+}
+
+// Acknowledge removes the dequeued item with ackID from the processing list.
+func (r *Redis) Acknowledge(ackID string) bool {
+	r.mu.Lock()
+	data, ok := r.inflight[ackID]
+	delete(r.inflight, ackID)
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	return r.client.LRem(ctx, r.processing, 1, data).Err() == nil
+}
+
+// Len returns the number of items queued, 0 when the server cannot be reached.
+func (r *Redis) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	n, err := r.client.LLen(ctx, r.pending).Result()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// Values returns the queued items, oldest first.
+func (r *Redis) Values() []any {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	items, err := r.client.LRange(ctx, r.pending, 0, -1).Result()
+	if err != nil {
+		return nil
+	}
+	values := make([]any, 0, len(items))
+	for _, item := range items {
+		values = append(values, []byte(item))
+	}
+	return values
+}
+
+// Purge removes every queued item.
+func (r *Redis) Purge() {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	_ = r.client.Del(ctx, r.pending).Err()
+}
+
+// Close closes the connection to the server. Queued and unacknowledged items stay in Redis.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// redisDeadLetters keeps dead letters in a Redis hash, as JSON by ID.
+type redisDeadLetters struct {
+	client *redis.Client
+	key    string
+}
+
+// Put stores d under d.ID.
+func (r *redisDeadLetters) Put(d DeadLetter) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	return r.client.HSet(ctx, r.key, d.ID, data).Err()
+}
+
+// List returns the dead letters, oldest first.
+func (r *redisDeadLetters) List() ([]DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	all, err := r.client.HGetAll(ctx, r.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]DeadLetter, 0, len(all))
+	for id, data := range all {
+		var d DeadLetter
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			return nil, fmt.Errorf("dead letter %s: %w", id, err)
+		}
+		letters = append(letters, d)
+	}
+	slices.SortFunc(letters, func(a, b DeadLetter) int { return a.At.Compare(b.At) })
+	return letters, nil
+}
+
+// Remove deletes the dead letter stored under id.
+func (r *redisDeadLetters) Remove(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+	return r.client.HDel(ctx, r.key, id).Err()
+}
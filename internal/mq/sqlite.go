@@ -0,0 +1,131 @@
+package mq
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goptics/sqliteq"
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+)
+
+// DefaultSQLiteFile is the database file the SQLite backend uses when none is given.
+const DefaultSQLiteFile = "./logs/logs.db"
+
+const deadLetterSchema = `
+CREATE TABLE IF NOT EXISTS log_dead_letters (
+	id       TEXT PRIMARY KEY,
+	data     BLOB NOT NULL,
+	reason   TEXT NOT NULL,
+	at       INTEGER NOT NULL,
+	attempts INTEGER NOT NULL
+);
+`
+
+// SQLite is a Backend storing the queue in a table of a SQLite database file, removing items once acknowledged, and
+// its dead letters in the log_dead_letters table of the same file.
+type SQLite struct {
+	*sqliteq.Queue
+	db   sqliteq.Queues
+	dead *sqliteDeadLetters
+}
+
+// OpenSQLite opens the queue in the SQLite database at path, creating the file and its directory if needed.
+func OpenSQLite(path string) (_ *SQLite, err error) {
+	if path == "" {
+		path = DefaultSQLiteFile
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	// sqliteq panics when the database cannot be opened
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrOpenBackend, path, r)
+		}
+	}()
+	db := sqliteq.New(path)
+	queue, err := db.NewQueue(QueueName, sqliteq.WithRemoveOnComplete(true))
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	dead, err := openSQLiteDeadLetters(path)
+	if err != nil {
+		return nil, errors.Join(ErrOpenBackend, err, db.Close())
+	}
+	return &SQLite{Queue: queue, db: db, dead: dead}, nil
+}
+
+// Name returns BackendSQLite.
+func (s *SQLite) Name() string {
+	return BackendSQLite
+}
+
+// DeadLetters returns the dead letters kept in the log_dead_letters table.
+func (s *SQLite) DeadLetters() DeadLetters {
+	return s.dead
+}
+
+// Close closes the queue and its database.
+func (s *SQLite) Close() error {
+	return errors.Join(s.Queue.Close(), s.db.Close(), s.dead.db.Close())
+}
+
+// sqliteDeadLetters keeps dead letters in the log_dead_letters table, through a connection of its own since sqliteq
+// does not share its own.
+type sqliteDeadLetters struct {
+	db *sql.DB
+}
+
+// openSQLiteDeadLetters opens the database at path and creates the log_dead_letters table if needed.
+func openSQLiteDeadLetters(path string) (*sqliteDeadLetters, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(deadLetterSchema); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return &sqliteDeadLetters{db: db}, nil
+}
+
+// Put stores d under d.ID.
+func (s *sqliteDeadLetters) Put(d DeadLetter) error {
+	_, err := s.db.Exec("INSERT OR REPLACE INTO log_dead_letters (id, data, reason, at, attempts) VALUES (?, ?, ?, ?, ?)",
+		d.ID, d.Data, d.Reason, d.At.UnixNano(), d.Attempts)
+	return err
+}
+
+// List returns the dead letters, oldest first.
+func (s *sqliteDeadLetters) List() ([]DeadLetter, error) {
+	rows, err := s.db.Query("SELECT id, data, reason, at, attempts FROM log_dead_letters ORDER BY at")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var letters []DeadLetter
+	for rows.Next() {
+		var d DeadLetter
+		var at int64
+		if err := rows.Scan(&d.ID, &d.Data, &d.Reason, &at, &d.Attempts); err != nil {
+			return nil, err
+		}
+		d.At = time.Unix(0, at)
+		letters = append(letters, d)
+	}
+	return letters, rows.Err()
+}
+
+// Remove deletes the dead letter stored under id.
+func (s *sqliteDeadLetters) Remove(id string) error {
+	_, err := s.db.Exec("DELETE FROM log_dead_letters WHERE id = ?", id)
+	return err
+}
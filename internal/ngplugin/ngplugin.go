@@ -27,7 +27,10 @@ type PluginFiles struct {
 
 func NewPluginFiles(dir string, bin string) PluginFiles {
 
-	mf := filepath.Join(dir, "manifest.yaml")
+	mf := filepath.Join(dir, registry.ManifestFileName)
+	if name, err := registry.FindManifest(dir); err == nil {
+		mf = filepath.Join(dir, name)
+	}
 	bf := filepath.Join(dir, bin)
 	sha256 := strings.Join([]string{bf, checksum.CSFileExt}, ".")
 	cf := filepath.Join(dir, sha256)
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/apiversion"
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	"github.com/hashicorp/go-plugin"
+)
+
+// DefaultAPICheckTimeout bounds the Info call that checks a plugin's shared API version before it is first dispensed.
+const DefaultAPICheckTimeout = 5 * time.Second
+
+// ErrIncompatibleAPI indicates that a plugin was built against a shared API version this host cannot serve, and must
+// be rebuilt against the host's version.
+var ErrIncompatibleAPI = apiversion.ErrIncompatible
+
+// apiCheck is the outcome of checking a launched plugin's shared API version, kept until the plugin is started again.
+type apiCheck struct {
+	checked bool
+	version string // version the plugin reported, empty when it predates versioning
+	err     error
+}
+
+// checkAPI checks, on the first dispense of each launch, that the named gRPC plugin was built against a shared API
+// version compatible with the host's, asking it through the lifecycle Info RPC. A plugin built before the shared API
+// was versioned is dispensed with a warning; net/rpc plugins have no Info RPC and are not checked.
+func (pm *PluginManager) checkAPI(name string, rpcClient plugin.ClientProtocol) error {
+	grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+	if !ok {
+		return nil
+	}
+	var check apiCheck
+	pm.mu.RLock()
+	if mp, ok := pm.plugins[name]; ok {
+		check = mp.api
+	}
+	pm.mu.RUnlock()
+	if check.checked {
+		return check.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultAPICheckTimeout)
+	info, err := lifecycle.QueryInfo(ctx, grpcClient.Conn)
+	cancel()
+	switch {
+	case errors.Is(err, lifecycle.ErrNoInfo) || (err == nil && info.APIVersion == ""):
+		pm.managerLogger.Warn("Plugin does not report its shared API version; rebuild it against v"+
+			apiversion.Version+" if its calls fail", logger.KeyPluginName, name)
+		check = apiCheck{checked: true}
+	case err != nil:
+		// the plugin could not answer, so check again on the next dispense
+		return fmt.Errorf("check shared API version of %q: %w", name, err)
+	default:
+		check = apiCheck{checked: true, version: info.APIVersion}
+		if err := apiversion.Check(apiversion.Version, info.APIVersion); err != nil {
+			check.err = fmt.Errorf("plugin %q: %w", name, err)
+			pm.managerLogger.Error("Plugin built against an incompatible shared API", logger.KeyPluginName, name,
+				"plugin_api_version", info.APIVersion, "host_api_version", apiversion.Version)
+		}
+	}
+	pm.mu.Lock()
+	pm.entry(name).api = check
+	pm.mu.Unlock()
+	return check.err
+}
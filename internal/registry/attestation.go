@@ -0,0 +1,20 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/bmj2728/PlugsConc/internal/attestation"
+)
+
+// attest asks the attestation service, when one is consulted, whether the named plugin's binary at entrypoint, signed
+// by signer if it is signed, may be used. It returns PluginNotAttested if the plugin is rejected, otherwise
+// PluginAvailable.
+func attest(attester *attestation.Client, name, version, entrypoint, signer string) (PluginState, error) {
+	if attester == nil {
+		return PluginAvailable, nil
+	}
+	if err := attester.AttestFile(context.Background(), name, version, entrypoint, signer); err != nil {
+		return PluginNotAttested, err
+	}
+	return PluginAvailable, nil
+}
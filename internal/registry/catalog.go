@@ -2,7 +2,9 @@ package registry
 
 import (
 	"context"
+	"maps"
 	"os/exec"
+	"sort"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
@@ -16,8 +18,11 @@ type PluginCatalog struct {
 	manifests     *Manifests
 	pluginMap     map[string]plugin.Plugin // this is passed to each client config
 	launchDetails []*PluginLaunchDetails   // these are passed to the plugin launcher
+	desired       map[string]DesiredState  // what each managed plugin should be doing, see Converger
 	fw            *fsnotify.Watcher
 	watch         func(ctx context.Context, fw *fsnotify.Watcher)
+	watching      watchRun                              // the watch function started by StartWatching
+	states        func(name string) (PluginState, bool) // live plugin states for queries, set by the PluginManager
 }
 
 // NewPluginCatalog creates and initializes a new PluginCatalog instance with the given manifests.
@@ -27,6 +32,7 @@ func NewPluginCatalog(manifests *Manifests) *PluginCatalog {
 		mu:            sync.RWMutex{},
 		pluginMap:     make(map[string]plugin.Plugin),
 		launchDetails: make([]*PluginLaunchDetails, 0),
+		desired:       make(map[string]DesiredState),
 	}
 }
 
@@ -58,7 +64,21 @@ func (c *PluginCatalog) AddLaunchDetails(details *PluginLaunchDetails) {
 	c.launchDetails = append(c.launchDetails, details)
 }
 
-// WithFileWatcher sets the file watcher for the PluginCatalog and returns the updated instance.
+// AddManifests adds the entries of manifests, such as those of a discovery retried after the catalog was created, to
+// the catalog's manifests in a thread-safe manner.
+func (c *PluginCatalog) AddManifests(manifests *Manifests) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.manifests == nil {
+		c.manifests = NewManifests()
+	}
+	for dir, entry := range manifests.GetManifests() {
+		c.manifests.Add(dir, entry)
+	}
+}
+
+// WithFileWatcher sets the file watcher for the PluginCatalog and the function that consumes its events, run by
+// StartWatching, and returns the updated instance.
 func (c *PluginCatalog) WithFileWatcher(fw *fsnotify.Watcher,
 	watch func(ctx context.Context, fw *fsnotify.Watcher)) *PluginCatalog {
 	c.mu.Lock()
@@ -83,25 +103,94 @@ func (c *PluginCatalog) GetWatchlist() []string {
 	return c.fw.WatchList()
 }
 
-// SetWatchFunc sets a function to handle filesystem watch events, synchronizing access with a mutex.
+// SetWatchFunc sets a function to handle filesystem watch events, synchronizing access with a mutex. It takes effect
+// the next time StartWatching is called.
 func (c *PluginCatalog) SetWatchFunc(watch func(ctx context.Context, fw *fsnotify.Watcher)) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.watch = watch
 }
 
+// CatalogSnapshot is a point-in-time, serializable view of the catalog's registered plugins, launch details,
+// desired states, watched paths, and watch status.
+type CatalogSnapshot struct {
+	Plugins       []string                `json:"plugins" yaml:"plugins"`
+	LaunchDetails []LaunchDetailsSnapshot `json:"launch_details" yaml:"launch_details"`
+	Desired       map[string]DesiredState `json:"desired,omitempty" yaml:"desired,omitempty"`
+	Watchlist     []string                `json:"watchlist" yaml:"watchlist"`
+	Watch         WatchStatus             `json:"watch" yaml:"watch"`
+}
+
+// LaunchDetailsSnapshot is a serializable summary of a PluginLaunchDetails entry.
+type LaunchDetailsSnapshot struct {
+	PluginName       string            `json:"plugin_name" yaml:"plugin_name"`
+	Entrypoint       string            `json:"entrypoint" yaml:"entrypoint"`
+	AllowedProtocols []plugin.Protocol `json:"allowed_protocols" yaml:"allowed_protocols"`
+	AutoMTLS         bool              `json:"auto_mtls" yaml:"auto_mtls"`
+	InProcess        bool              `json:"in_process,omitempty" yaml:"in_process,omitempty"`
+	Version          string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Provenance       *Provenance       `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+}
+
+// Snapshot returns a CatalogSnapshot of the catalog's current contents in a thread-safe manner.
+func (c *PluginCatalog) Snapshot() CatalogSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	snap := CatalogSnapshot{
+		Plugins:       make([]string, 0, len(c.pluginMap)),
+		LaunchDetails: make([]LaunchDetailsSnapshot, 0, len(c.launchDetails)),
+		Watchlist:     make([]string, 0),
+	}
+	for name := range c.pluginMap {
+		snap.Plugins = append(snap.Plugins, name)
+	}
+	sort.Strings(snap.Plugins)
+	for _, ld := range c.launchDetails {
+		entry := LaunchDetailsSnapshot{
+			PluginName:       ld.PluginName,
+			AllowedProtocols: ld.AllowedProtocols,
+			AutoMTLS:         ld.AutoMTLS,
+			InProcess:        ld.InProcess,
+			Version:          ld.Version,
+			Provenance:       ld.Provenance,
+		}
+		if ld.Cmd != nil {
+			entry.Entrypoint = ld.Cmd.Path
+		}
+		snap.LaunchDetails = append(snap.LaunchDetails, entry)
+	}
+	if len(c.desired) > 0 {
+		snap.Desired = maps.Clone(c.desired)
+	}
+	if c.fw != nil {
+		snap.Watchlist = append(snap.Watchlist, c.fw.WatchList()...)
+	}
+	snap.Watch = c.watchStatus()
+	return snap
+}
+
 // PluginLaunchDetails represents the details required to launch a plugin including its configuration
 // and execution command.
 // PluginName is the identifier for the plugin.
+// Version is the plugin version declared in its manifest.
 // HandshakeConfig specifies the handshake configuration needed for the plugin communication.
 // Cmd holds the execution command for running the plugin.
 // AllowedProtocols lists the communication protocols supported by the plugin.
+// InProcess marks a plugin served from inside the host process, which has no Cmd.
+// SignatureRequired refuses to launch the plugin unless its binary carries a valid signature.
+// Provenance records how the plugin binary was built, when the plugin ships a provenance file.
 type PluginLaunchDetails struct {
-	PluginName       string                  `json:"plugin_name" yaml:"plugin_name"`
-	HandshakeConfig  *plugin.HandshakeConfig `json:"handshake_config" yaml:"handshake_config"`
-	Cmd              *exec.Cmd               `json:"Cmd" yaml:"Cmd"`
-	AllowedProtocols []plugin.Protocol       `json:"allowed_protocols" yaml:"allowed_protocols"`
-	AutoMTLS         bool                    `json:"auto_mtls" yaml:"auto_mtls"`
+	PluginName        string                  `json:"plugin_name" yaml:"plugin_name"`
+	Version           string                  `json:"version" yaml:"version"`
+	HandshakeConfig   *plugin.HandshakeConfig `json:"handshake_config" yaml:"handshake_config"`
+	Cmd               *exec.Cmd               `json:"Cmd" yaml:"Cmd"`
+	AllowedProtocols  []plugin.Protocol       `json:"allowed_protocols" yaml:"allowed_protocols"`
+	AutoMTLS          bool                    `json:"auto_mtls" yaml:"auto_mtls"`
+	HealthCheck       *HealthCheck            `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+	Warmup            *Warmup                 `json:"warmup,omitempty" yaml:"warmup,omitempty"`
+	InProcess         bool                    `json:"in_process,omitempty" yaml:"in_process,omitempty"`
+	SignatureRequired bool                    `json:"signature_required,omitempty" yaml:"signature_required,omitempty"`
+	Provenance        *Provenance             `json:"provenance,omitempty" yaml:"provenance,omitempty"`
 }
 
 // NewPluginLaunchDetails initializes a new PluginLaunchDetails instance with the specified parameters.
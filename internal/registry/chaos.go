@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+)
+
+// KillRandom kills the process of a randomly chosen running plugin behind the manager's back, as a crash would, and
+// returns the plugin's name. The next health check finds the process gone, records the crash, and restarts the
+// plugin under the restart policy. It is the worker.PluginKiller chaos mode uses to exercise supervision.
+func (pm *PluginManager) KillRandom() (string, error) {
+	pm.mu.RLock()
+	running := make([]string, 0, len(pm.plugins))
+	for name, mp := range pm.plugins {
+		if mp.client != nil && !mp.client.Exited() {
+			running = append(running, name)
+		}
+	}
+	pm.mu.RUnlock()
+	if len(running) == 0 {
+		return "", fmt.Errorf("%w: no plugin to kill", ErrPluginNotRunning)
+	}
+	name := running[rand.IntN(len(running))]
+	client, err := pm.client(name)
+	if err != nil {
+		return "", err
+	}
+	// kill the process itself when it has one, so the client sees it die instead of being shut down gracefully
+	if rc := client.ReattachConfig(); rc != nil && rc.Pid > 0 {
+		if proc, err := os.FindProcess(rc.Pid); err == nil && proc.Kill() == nil {
+			pm.managerLogger.Warn("Killed plugin process", logger.KeyPluginName, name, "pid", rc.Pid)
+			return name, nil
+		}
+	}
+	client.Kill()
+	pm.managerLogger.Warn("Killed plugin connection", logger.KeyPluginName, name)
+	return name, nil
+}
@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// compatibilityBucket holds one Compatibility per plugin, plugin version, and host version, keyed by compatibilityKey.
+const compatibilityBucket = "plugin_compatibility"
+
+// compatibilityKeySep separates the parts of a compatibility key; it cannot appear in names or versions.
+const compatibilityKeySep = "\x00"
+
+var (
+	// ErrOpenCompatibility indicates that the compatibility bucket could not be opened in the storage backend.
+	ErrOpenCompatibility = errors.New("failed to open plugin compatibility matrix")
+	// ErrRecordCompatibility indicates that a successful run could not be recorded in the compatibility matrix.
+	ErrRecordCompatibility = errors.New("failed to record plugin compatibility")
+)
+
+// CompatibilityComponent is the name the compatibility matrix's schema is versioned under.
+const CompatibilityComponent = "compat"
+
+// CompatibilityMigrations returns the compatibility matrix's schema migrations.
+func CompatibilityMigrations() []storage.Migration {
+	return []storage.Migration{
+		{
+			Component:   CompatibilityComponent,
+			Version:     1,
+			Description: "drop undecodable compatibility records and record their times in UTC",
+			Up:          normalizeCompatibility,
+			// the records keep their format, so binaries before the migration read them unchanged
+			Down: func(storage.Backend) error { return nil },
+		},
+	}
+}
+
+// Compatibility records that a plugin version has run successfully against a host version.
+type Compatibility struct {
+	Plugin        string    `json:"plugin"`
+	PluginVersion string    `json:"plugin_version"`
+	HostVersion   string    `json:"host_version"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	Runs          int       `json:"runs"`
+}
+
+// CompatibilityMatrix persists which plugin versions have successfully started against which host versions, so
+// that launching a combination that has never run before can be flagged during rollouts.
+type CompatibilityMatrix struct {
+	bucket       storage.Bucket
+	hostVersion  string
+	compatLogger hclog.Logger
+}
+
+// NewCompatibilityMatrix opens the compatibility matrix in backend, recording runs against hostVersion. The backend
+// is owned by the caller, who closes it.
+func NewCompatibilityMatrix(backend storage.Backend, hostVersion string,
+	compatLogger hclog.Logger) (*CompatibilityMatrix, error) {
+	if compatLogger == nil {
+		compatLogger = hclog.Default()
+	}
+	bucket, err := backend.Bucket(compatibilityBucket)
+	if err != nil {
+		return nil, errors.Join(ErrOpenCompatibility, err)
+	}
+	return &CompatibilityMatrix{bucket: bucket, hostVersion: hostVersion, compatLogger: compatLogger}, nil
+}
+
+// HostVersion returns the host version runs are recorded against.
+func (m *CompatibilityMatrix) HostVersion() string {
+	return m.hostVersion
+}
+
+// Seen reports whether version of the named plugin has run successfully against this host version before.
+func (m *CompatibilityMatrix) Seen(plugin, version string) (bool, error) {
+	_, err := m.bucket.Get(compatibilityKey(plugin, version, m.hostVersion))
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Record notes a successful run of version of the named plugin against this host version.
+func (m *CompatibilityMatrix) Record(plugin, version string) error {
+	key := compatibilityKey(plugin, version, m.hostVersion)
+	now := timestamp.Now()
+	c := Compatibility{Plugin: plugin, PluginVersion: version, HostVersion: m.hostVersion, FirstSeen: now}
+	data, err := m.bucket.Get(key)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &c); err != nil {
+			return errors.Join(ErrRecordCompatibility, err)
+		}
+	case !errors.Is(err, storage.ErrNotFound):
+		return errors.Join(ErrRecordCompatibility, err)
+	}
+	c.LastSeen = now
+	c.Runs++
+	data, err = json.Marshal(c)
+	if err != nil {
+		return errors.Join(ErrRecordCompatibility, err)
+	}
+	if err := m.bucket.Put(key, data); err != nil {
+		return errors.Join(ErrRecordCompatibility, err)
+	}
+	return nil
+}
+
+// Entries returns the recorded combinations for the named plugin, or for every plugin when plugin is empty, ordered
+// by plugin, plugin version, and host version.
+func (m *CompatibilityMatrix) Entries(plugin string) ([]Compatibility, error) {
+	var prefix []byte
+	if plugin != "" {
+		prefix = []byte(plugin + compatibilityKeySep)
+	}
+	entries := make([]Compatibility, 0)
+	err := m.bucket.Scan(prefix, false, func(_, value []byte) error {
+		var c Compatibility
+		if err := json.Unmarshal(value, &c); err != nil {
+			return err
+		}
+		entries = append(entries, c)
+		return nil
+	})
+	return entries, err
+}
+
+// check warns when version of the named plugin has never run against this host version.
+func (m *CompatibilityMatrix) check(plugin, version string) {
+	seen, err := m.Seen(plugin, version)
+	if err != nil {
+		m.compatLogger.Warn("Failed to check plugin compatibility", logger.KeyPluginName, plugin,
+			"plugin_version", version, logger.KeyError, err)
+		return
+	}
+	if !seen {
+		m.compatLogger.Warn("Launching untested plugin and host version combination", logger.KeyPluginName, plugin,
+			"plugin_version", version, "host_version", m.hostVersion)
+	}
+}
+
+// compatibilityKey returns the key of the combination of plugin, plugin version, and host version.
+func compatibilityKey(plugin, version, hostVersion string) []byte {
+	return []byte(plugin + compatibilityKeySep + version + compatibilityKeySep + hostVersion)
+}
+
+// normalizeCompatibility rewrites every record in the compatibility bucket with its times in UTC, deleting the
+// records that cannot be decoded, which would otherwise fail every listing.
+func normalizeCompatibility(b storage.Backend) error {
+	bucket, err := b.Bucket(compatibilityBucket)
+	if err != nil {
+		return err
+	}
+	return bucket.Scan(nil, false, func(key, value []byte) error {
+		var c Compatibility
+		if err := json.Unmarshal(value, &c); err != nil {
+			return bucket.Delete(key)
+		}
+		c.FirstSeen, c.LastSeen = c.FirstSeen.UTC(), c.LastSeen.UTC()
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestCompatibilityMigrations checks that the compatibility migration drops undecodable records and moves the times
+// of the others to UTC, keeping them readable by the matrix.
+func TestCompatibilityMigrations(t *testing.T) {
+	backend := storage.NewMemory()
+	bucket, err := backend.Bucket(compatibilityBucket)
+	if err != nil {
+		t.Fatalf("Bucket: %v", err)
+	}
+	seen := time.Date(2026, 10, 14, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	good, err := json.Marshal(Compatibility{Plugin: "cat", PluginVersion: "1.0.0", HostVersion: "2.0.0",
+		FirstSeen: seen, LastSeen: seen, Runs: 3})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	goodKey := compatibilityKey("cat", "1.0.0", "2.0.0")
+	badKey := compatibilityKey("dog", "1.0.0", "2.0.0")
+	if err := bucket.Put(goodKey, good); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := bucket.Put(badKey, []byte("not json")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	m := storage.NewMigrator(backend, hclog.NewNullLogger()).Register(CompatibilityMigrations()...)
+	if _, err := m.Migrate(false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if _, err := bucket.Get(badKey); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("undecodable record: got %v, want ErrNotFound", err)
+	}
+	data, err := bucket.Get(goodKey)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	var c Compatibility
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if c.FirstSeen.Location() != time.UTC || !c.FirstSeen.Equal(seen) || c.Runs != 3 {
+		t.Errorf("got record %+v, want first seen %s in UTC", c, seen.UTC())
+	}
+	matrix, err := NewCompatibilityMatrix(backend, "2.0.0", hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewCompatibilityMatrix: %v", err)
+	}
+	if ok, err := matrix.Seen("cat", "1.0.0"); !ok || err != nil {
+		t.Errorf("Seen: got %t, %v, want true", ok, err)
+	}
+	if _, err := m.Rollback(CompatibilityComponent, 0, false); err != nil {
+		t.Errorf("Rollback: %v", err)
+	}
+}
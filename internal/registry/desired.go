@@ -0,0 +1,370 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/callctx"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultConvergeInterval is how often a Converger compares plugins with their desired state when no interval is
+// configured.
+const DefaultConvergeInterval = 30 * time.Second
+
+// ConvergeActionStart records that an enabled plugin that was not running was started.
+// ConvergeActionStop records that a disabled plugin, or one running a version other than its pinned version, was
+// stopped.
+// ConvergeActionReload records that a running plugin whose files changed since it was launched was reloaded, or a
+// rejected plugin whose files changed was loaded again.
+// ConvergeActionHold records that a plugin could not be converged without an operator, e.g. because it is
+// quarantined, awaiting approval, or its pinned version is not installed.
+const (
+	ConvergeActionStart  = "start"
+	ConvergeActionStop   = "stop"
+	ConvergeActionReload = "reload"
+	ConvergeActionHold   = "hold"
+)
+
+// ErrPinnedVersion indicates that a plugin cannot be started because its manifest declares a version other than the
+// one pinned in its desired state.
+var ErrPinnedVersion = errors.New("plugin version does not match its pinned version")
+
+// DesiredState is the state an operator wants a plugin in, which a Converger moves the plugin towards. An enabled
+// plugin should be running and a disabled one stopped. A non-empty Version pins the plugin to that version: it is
+// only started, or reloaded, while its manifest declares it.
+type DesiredState struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// SetDesiredState sets the desired state of the named plugin.
+func (c *PluginCatalog) SetDesiredState(name string, desired DesiredState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.desired[name] = desired
+}
+
+// RemoveDesiredState forgets the desired state of the named plugin, leaving it to be managed by hand.
+func (c *PluginCatalog) RemoveDesiredState(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.desired, name)
+}
+
+// GetDesiredState returns the desired state of the named plugin, and whether it has one.
+func (c *PluginCatalog) GetDesiredState(name string) (DesiredState, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	desired, ok := c.desired[name]
+	return desired, ok
+}
+
+// GetDesiredStates returns a copy of the desired states of every plugin that has one, keyed by plugin name.
+func (c *PluginCatalog) GetDesiredStates() map[string]DesiredState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.desired)
+}
+
+// checkPin returns ErrPinnedVersion if the named plugin is pinned to a version other than version.
+func (c *PluginCatalog) checkPin(name, version string) error {
+	desired, ok := c.GetDesiredState(name)
+	if !ok || desired.Version == "" || sameVersion(desired.Version, version) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is version %q, pinned to %q", ErrPinnedVersion, name, version, desired.Version)
+}
+
+// fingerprint identifies the files a plugin was launched from, so a Converger can tell when they change without
+// relying on file watcher events.
+type fingerprint struct {
+	version  string    // manifest version
+	manifest string    // manifest hash
+	size     int64     // binary size
+	modTime  time.Time // binary modification time
+}
+
+// same reports whether two fingerprints describe the same files.
+func (f fingerprint) same(other fingerprint) bool {
+	return f.version == other.version && f.manifest == other.manifest && f.size == other.size &&
+		f.modTime.Equal(other.modTime)
+}
+
+// readFingerprint reads the fingerprint of the plugin files on disk behind the launch details.
+func readFingerprint(ld *PluginLaunchDetails) (fingerprint, error) {
+	path := ld.Entrypoint().Path
+	m, _, hash, err := LoadPluginManifest(filepath.Dir(path))
+	if err != nil {
+		return fingerprint{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fingerprint{}, err
+	}
+	return fingerprint{version: m.PluginData.Version, manifest: hash, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// rejectedState reports whether a plugin in state failed verification of its files, so that starting it again
+// without changing them would fail the same way.
+func rejectedState(state PluginState) bool {
+	switch state {
+	case PluginMissingManifest, PluginMissingChecksum, PluginMissingBinary, PluginInvalidManifest,
+		PluginInvalidLaunchDetails, PluginInvalidChecksum, PluginInvalidBinary, PluginBadChecksum, PluginUnsigned,
+		PluginBadSignature, PluginBadProvenance, PluginNotAttested, PluginUnexpectedFile:
+		return true
+	}
+	return false
+}
+
+// observedPlugin is what a Converger sees of a plugin's actual state.
+type observedPlugin struct {
+	state            PluginState
+	files            fingerprint // files the running process was launched from, zero when unknown
+	restartScheduled bool
+	quarantined      bool
+}
+
+// observe returns the actual state of the named plugin.
+func (pm *PluginManager) observe(name string) observedPlugin {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok {
+		return observedPlugin{state: PluginAvailable}
+	}
+	return observedPlugin{
+		state:            mp.state,
+		files:            mp.files,
+		restartScheduled: !mp.nextRestart.IsZero(),
+		quarantined:      mp.quarantined,
+	}
+}
+
+// ConvergeEvent describes an action a Converger took, or could not take, to bring a plugin to its desired state.
+type ConvergeEvent struct {
+	Plugin string    `json:"plugin"`
+	Action string    `json:"action"`
+	Reason string    `json:"reason"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// ConvergeStats counts the passes a Converger has made and the actions it took.
+type ConvergeStats struct {
+	Passes   int       `json:"passes"`
+	Started  int       `json:"started"`
+	Stopped  int       `json:"stopped"`
+	Reloaded int       `json:"reloaded"`
+	Held     int       `json:"held"`
+	Failed   int       `json:"failed"`
+	LastPass time.Time `json:"last_pass,omitempty"`
+}
+
+// Converger periodically compares the desired state of every plugin in a PluginManager's catalog with its actual
+// state and converges them: enabled plugins that are not running are started, disabled plugins that are running are
+// stopped, and running plugins whose manifest or binary changed since launch are reloaded. It complements the file
+// watcher and the health checker, catching changes and failures they missed. It does not override operator
+// decisions: quarantined plugins, plugins disabled pending review, and crashed plugins awaiting a scheduled restart
+// are left alone, as are plugins that failed verification until their files change. Plugins without a desired state
+// are never touched.
+type Converger struct {
+	manager        *PluginManager
+	interval       time.Duration
+	convergeLogger hclog.Logger
+	mu             sync.Mutex
+	failed         map[string]fingerprint // files of rejected plugins that failed to load again
+	stats          ConvergeStats
+}
+
+// NewConverger creates a Converger for the plugins of manager that converges them every interval. An interval of
+// zero or less uses DefaultConvergeInterval.
+func NewConverger(manager *PluginManager, interval time.Duration, convergeLogger hclog.Logger) *Converger {
+	if convergeLogger == nil {
+		convergeLogger = hclog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultConvergeInterval
+	}
+	return &Converger{
+		manager:        manager,
+		interval:       interval,
+		convergeLogger: convergeLogger,
+		failed:         make(map[string]fingerprint),
+	}
+}
+
+// Run converges the plugins every interval until ctx is canceled.
+func (c *Converger) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.ConvergeAll()
+		}
+	}
+}
+
+// Stats returns the counts of the passes made and actions taken so far.
+func (c *Converger) Stats() ConvergeStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// ConvergeAll makes one pass over the plugins with a desired state, in name order, and returns an event for every
+// plugin that was acted on or held.
+func (c *Converger) ConvergeAll() []ConvergeEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	desired := c.manager.catalog.GetDesiredStates()
+	var events []ConvergeEvent
+	for _, name := range slices.Sorted(maps.Keys(desired)) {
+		event, ok := c.converge(name, desired[name])
+		if !ok {
+			continue
+		}
+		c.record(event)
+		events = append(events, event)
+	}
+	c.stats.Passes++
+	c.stats.LastPass = time.Now()
+	return events
+}
+
+// converge brings the named plugin towards want, returning the event describing what was done and false when the
+// plugin already matches it. The caller must hold mu.
+func (c *Converger) converge(name string, want DesiredState) (ConvergeEvent, bool) {
+	pm := c.manager
+	actual := pm.observe(name)
+	running := actual.state == PluginRunning
+	switch {
+	case actual.state == PluginLaunching || actual.state == PluginWarmingUp:
+		return ConvergeEvent{}, false
+	case !want.Enabled:
+		if !running {
+			return ConvergeEvent{}, false
+		}
+		return acted(name, ConvergeActionStop, "plugin is disabled", pm.Stop(name)), true
+	}
+
+	ld, err := pm.launchDetails(name)
+	switch {
+	case err != nil:
+		return hold(name, "plugin is not in the catalog"), true
+	case actual.quarantined:
+		return hold(name, "plugin is quarantined"), true
+	case actual.state == PluginDisabledPendingReview || (pm.flap != nil && pm.flap.IsDisabled(name)):
+		return hold(name, "plugin is disabled pending review"), true
+	}
+
+	if running {
+		return c.converged(name, want, ld, actual)
+	}
+	if want.Version != "" && !sameVersion(want.Version, ld.Version) {
+		return hold(name, fmt.Sprintf("version %q is installed, pinned to %q", ld.Version, want.Version)), true
+	}
+	if actual.state == PluginStoppedUnexpectedly && actual.restartScheduled {
+		// the health checker restarts it once its backoff has passed
+		return ConvergeEvent{}, false
+	}
+	if rejectedState(actual.state) && !ld.InProcess {
+		files, err := readFingerprint(ld)
+		if err != nil {
+			return hold(name, "plugin files cannot be read: "+err.Error()), true
+		}
+		if failed, ok := c.failed[name]; ok && failed.same(files) {
+			return ConvergeEvent{}, false
+		}
+		c.failed[name] = files
+		event := acted(name, ConvergeActionReload, "plugin failed verification", pm.Reload(name))
+		if event.Error == "" {
+			delete(c.failed, name)
+		}
+		return event, true
+	}
+	return acted(name, ConvergeActionStart, "plugin is enabled but "+actual.state.String(), pm.Start(name)), true
+}
+
+// converged checks a running plugin against its pinned version and the files it was launched from, stopping a
+// plugin running another version than its pin and reloading one whose files changed.
+func (c *Converger) converged(name string, want DesiredState, ld *PluginLaunchDetails,
+	actual observedPlugin) (ConvergeEvent, bool) {
+	if ld.InProcess || actual.files == (fingerprint{}) {
+		return ConvergeEvent{}, false
+	}
+	if want.Version != "" && !sameVersion(want.Version, actual.files.version) {
+		reason := fmt.Sprintf("plugin runs version %q, pinned to %q", actual.files.version, want.Version)
+		return acted(name, ConvergeActionStop, reason, c.manager.Stop(name)), true
+	}
+	files, err := readFingerprint(ld)
+	switch {
+	case err != nil:
+		return hold(name, "plugin files cannot be read: "+err.Error()), true
+	case files.same(actual.files):
+		return ConvergeEvent{}, false
+	case want.Version != "" && !sameVersion(want.Version, files.version):
+		return hold(name, fmt.Sprintf("plugin files changed to version %q, pinned to %q", files.version,
+			want.Version)), true
+	}
+	return acted(name, ConvergeActionReload, "plugin files changed since launch", c.reload(name)), true
+}
+
+// reload reloads the named plugin, through the manager's reconcile pool when it has one so a reload the file
+// watcher already queued is not repeated.
+func (c *Converger) reload(name string) error {
+	if r := c.manager.reconciler; r != nil {
+		r.schedule(name, callctx.NewRequestID())
+		return nil
+	}
+	return c.manager.Reload(name)
+}
+
+// acted returns the event for an action taken on the named plugin and its outcome.
+func acted(name, action, reason string, err error) ConvergeEvent {
+	event := ConvergeEvent{Plugin: name, Action: action, Reason: reason, At: time.Now()}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// hold returns the event for a plugin that cannot be converged without an operator.
+func hold(name, reason string) ConvergeEvent {
+	return ConvergeEvent{Plugin: name, Action: ConvergeActionHold, Reason: reason, At: time.Now()}
+}
+
+// record logs the event and counts it. The caller must hold mu.
+func (c *Converger) record(event ConvergeEvent) {
+	switch {
+	case event.Error != "":
+		c.stats.Failed++
+		c.convergeLogger.Error("Failed to converge plugin", logger.KeyPluginName, event.Plugin,
+			"action", event.Action, "reason", event.Reason, logger.KeyError, event.Error)
+		return
+	case event.Action == ConvergeActionHold:
+		c.stats.Held++
+		c.convergeLogger.Debug("Plugin held from its desired state", logger.KeyPluginName, event.Plugin,
+			"reason", event.Reason)
+		return
+	case event.Action == ConvergeActionStart:
+		c.stats.Started++
+	case event.Action == ConvergeActionStop:
+		c.stats.Stopped++
+	case event.Action == ConvergeActionReload:
+		c.stats.Reloaded++
+	}
+	c.convergeLogger.Info("Converged plugin", logger.KeyPluginName, event.Plugin, "action", event.Action,
+		"reason", event.Reason)
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/apiversion"
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	"github.com/hashicorp/go-plugin"
+)
+
+// DefaultDryRunInfoTimeout bounds the Info call of a dry-run launch.
+const DefaultDryRunInfoTimeout = 10 * time.Second
+
+// ErrDryRunFailed indicates that a plugin could not be launched, or did not answer, during a dry run.
+var ErrDryRunFailed = errors.New("plugin dry run failed")
+
+// DryRunReport is the outcome of a dry-run launch of a plugin. Info is nil when the plugin does not implement the
+// lifecycle Info hook or speaks net/rpc.
+type DryRunReport struct {
+	Name     string          `json:"name"`
+	Version  string          `json:"version,omitempty"`
+	Protocol string          `json:"protocol,omitempty"`
+	Info     *lifecycle.Info `json:"info,omitempty"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// DryRunLaunch launches the named plugin, completes its handshake and protocol negotiation, queries its Info hook
+// and checks the shared API version it reports, and shuts it down again. The plugin is never dispensed, its managed state is untouched, and it may run alongside a
+// managed instance, so it can be used to validate a deployment before exposing the plugin to traffic.
+func (pm *PluginManager) DryRunLaunch(name string) (*DryRunReport, error) {
+	start := time.Now()
+	ld, pluginType, secConf, err := pm.verify(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *plugin.Client
+	if ld.InProcess {
+		client, err = pm.newInProcessClient(name, ld, pluginType)
+	} else {
+		client, err = pm.newClient(name, ld, pluginType, secConf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer client.Kill()
+	rpcClient, err := client.Client()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDryRunFailed, err)
+	}
+	if err := rpcClient.Ping(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDryRunFailed, err)
+	}
+
+	report := &DryRunReport{Name: name, Version: ld.Version, Protocol: string(client.Protocol())}
+	if grpcClient, ok := rpcClient.(*plugin.GRPCClient); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultDryRunInfoTimeout)
+		info, err := lifecycle.QueryInfo(ctx, grpcClient.Conn)
+		cancel()
+		switch {
+		case err == nil:
+			report.Info = &info
+		case !errors.Is(err, lifecycle.ErrNoInfo):
+			return nil, fmt.Errorf("%w: %w", ErrDryRunFailed, err)
+		}
+		if err == nil && info.APIVersion != "" {
+			if err := apiversion.Check(apiversion.Version, info.APIVersion); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrDryRunFailed, err)
+			}
+		}
+	}
+	report.Duration = time.Since(start)
+	pm.managerLogger.Debug("Plugin dry run succeeded", logger.KeyPluginName, name, "protocol", report.Protocol,
+		"duration", report.Duration.String())
+	return report, nil
+}
+
+// Verify checks, without launching it, that the named plugin has launch details and a registered plugin type and
+// that its binary matches its checksum file and passes signature verification. In-process plugins have no binary to
+// check.
+func (pm *PluginManager) Verify(name string) error {
+	_, _, _, err := pm.verify(name)
+	return err
+}
+
+// verify checks the named plugin as Verify does, returning what is needed to launch it.
+func (pm *PluginManager) verify(name string) (*PluginLaunchDetails, plugin.Plugin, *plugin.SecureConfig, error) {
+	ld, err := pm.launchDetails(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pluginType := pm.catalog.GetPlugin(name)
+	if pluginType == nil {
+		return nil, nil, nil, fmt.Errorf("%w: %q has no registered plugin type", ErrPluginNotFound, name)
+	}
+	if ld.InProcess {
+		return ld, pluginType, nil, nil
+	}
+	secConf, _, err := secureConfig(ld)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	_, signer, err := pm.signatures.verify(ld.Entrypoint().Path, ld.SignatureRequired)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := attest(pm.attester, name, ld.Version, ld.Entrypoint().Path, signer); err != nil {
+		return nil, nil, nil, err
+	}
+	return ld, pluginType, secConf, nil
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ErrDynamicUnsupported indicates that a plugin cannot be described or called dynamically: it is a net/rpc plugin,
+// a replayed one, or a gRPC plugin built before the Describe RPC was added to the shared API.
+// ErrStreamingMethod indicates that a dynamic call names a streaming method, which Invoke cannot call.
+var (
+	ErrDynamicUnsupported = errors.New("plugin does not support dynamic calls")
+	ErrStreamingMethod    = errors.New("streaming plugin methods cannot be called dynamically")
+)
+
+// Describe asks the named running gRPC plugin, through the lifecycle Describe RPC, for the services and methods it
+// serves, including those of plugin types registered at runtime that the host has no static type for.
+func (pm *PluginManager) Describe(ctx context.Context, name string) (lifecycle.Description, error) {
+	conn, err := pm.dynamicConn(name)
+	if err != nil {
+		return lifecycle.Description{}, err
+	}
+	desc, err := lifecycle.Describe(ctx, conn)
+	if errors.Is(err, lifecycle.ErrNoDescription) {
+		return lifecycle.Description{}, fmt.Errorf("%w: %q: %w", ErrDynamicUnsupported, name, err)
+	}
+	return desc, err
+}
+
+// Invoke calls a unary method of the named running gRPC plugin without a static type for it, building the request
+// and response from the descriptors the plugin returns through Describe. method is Service/Method with the service's
+// full name, e.g. animal.v1.Animal/Speak, or a bare method name when only one of the plugin's services has it. args
+// is the request as protojson, empty for an empty request, and the response is returned as protojson.
+func (pm *PluginManager) Invoke(ctx context.Context, name, method string, args json.RawMessage) (json.RawMessage,
+	error) {
+	desc, err := pm.Describe(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	svc, m, ok := desc.Method(method)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no method %q, available: %s", ErrMethodNotFound, name, method,
+			strings.Join(dynamicMethods(desc), ", "))
+	}
+	if m.ClientStreaming || m.ServerStreaming {
+		return nil, fmt.Errorf("%w: %s/%s", ErrStreamingMethod, svc.Name, m.Name)
+	}
+	in, err := dynamicMessage(desc, m.InputType)
+	if err != nil {
+		return nil, err
+	}
+	out, err := dynamicMessage(desc, m.OutputType)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := strings.TrimSpace(string(args)); trimmed != "" {
+		if err := protojson.Unmarshal([]byte(trimmed), in); err != nil {
+			return nil, fmt.Errorf("%w: %s/%s: %w", ErrInvalidArguments, svc.Name, m.Name, err)
+		}
+	}
+	conn, err := pm.dynamicConn(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Invoke(ctx, "/"+svc.Name+"/"+m.Name, in, out); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(out)
+}
+
+// dynamicConn returns the gRPC connection of the named running plugin, failing with ErrDynamicUnsupported for
+// net/rpc and replayed plugins. The plugin is checked to have been built against a compatible shared API version,
+// as Dispense does.
+func (pm *PluginManager) dynamicConn(name string) (*grpc.ClientConn, error) {
+	if _, ok := pm.replayed(name); ok {
+		return nil, fmt.Errorf("%w: %q is replayed", ErrDynamicUnsupported, name)
+	}
+	client, err := pm.client(name)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := client.Client()
+	if err != nil {
+		return nil, err
+	}
+	grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a gRPC plugin", ErrDynamicUnsupported, name)
+	}
+	if err := pm.checkAPI(name, rpcClient); err != nil {
+		return nil, err
+	}
+	return grpcClient.Conn, nil
+}
+
+// dynamicMessage returns an empty message of the named type, resolved from the descriptors in desc.
+func dynamicMessage(desc lifecycle.Description, typeName string) (*dynamicpb.Message, error) {
+	if typeName == "" || desc.Files == nil {
+		return nil, fmt.Errorf("%w: message types are not described", ErrDynamicUnsupported)
+	}
+	d, err := desc.Files.FindDescriptorByName(protoreflect.FullName(typeName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrDynamicUnsupported, typeName, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a message", ErrDynamicUnsupported, typeName)
+	}
+	return dynamicpb.NewMessage(md), nil
+}
+
+// dynamicMethods returns the methods in desc as Service/Method names.
+func dynamicMethods(desc lifecycle.Description) []string {
+	var names []string
+	for _, svc := range desc.Services {
+		for _, m := range svc.Methods {
+			names = append(names, svc.Name+"/"+m.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-plugin"
+)
+
+// TransportNetRPC marks a call made to a net/rpc plugin.
+// TransportGRPC marks a call made to a gRPC plugin, including calls answered from a replayed recording.
+const (
+	TransportNetRPC = string(plugin.ProtocolNetRPC)
+	TransportGRPC   = string(plugin.ProtocolGRPC)
+)
+
+// callLatencyWeight is the weight of the newest call in a plugin's moving average call duration.
+const callLatencyWeight = 0.2
+
+// ErrWrongInterface indicates that a dispensed plugin does not implement the interface a typed call expects.
+var ErrWrongInterface = errors.New("plugin does not implement the expected interface")
+
+// CallOptions configures a call made through Call. Retries is the number of times a failed call is repeated, and
+// should only be set for idempotent methods; RetryDelay is the wait between attempts.
+type CallOptions struct {
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// CallMetadata describes how a plugin call was served: the plugin version and transport that answered it, when it
+// started, how long it took across every attempt, and how many times it was retried.
+type CallMetadata struct {
+	Plugin    string        `json:"plugin"`
+	Version   string        `json:"version,omitempty"`
+	Transport string        `json:"transport,omitempty"` // TransportNetRPC or TransportGRPC
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Retries   int           `json:"retries"`
+}
+
+// Result is the envelope a typed plugin call returns: the value the plugin returned and the call's metadata.
+type Result[T any] struct {
+	Value T            `json:"value"`
+	Meta  CallMetadata `json:"meta"`
+}
+
+// CallStats summarizes the calls made to a plugin through Call, so host code can route to the instance that has
+// served calls fastest. AvgDuration is a moving average weighted towards recent calls.
+type CallStats struct {
+	Plugin       string        `json:"plugin"`
+	Calls        int           `json:"calls"`
+	Failures     int           `json:"failures"`
+	LastDuration time.Duration `json:"last_duration"`
+	AvgDuration  time.Duration `json:"avg_duration"`
+	LastCall     time.Time     `json:"last_call"`
+}
+
+// callStats records the CallStats of each plugin.
+type callStats struct {
+	mu    sync.RWMutex
+	stats map[string]*CallStats
+}
+
+// record adds a finished call to the named plugin's stats.
+func (c *callStats) record(meta CallMetadata, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = make(map[string]*CallStats)
+	}
+	s, ok := c.stats[meta.Plugin]
+	if !ok {
+		s = &CallStats{Plugin: meta.Plugin, AvgDuration: meta.Duration}
+		c.stats[meta.Plugin] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Failures++
+	}
+	s.LastDuration = meta.Duration
+	weighted := callLatencyWeight*float64(meta.Duration) + (1-callLatencyWeight)*float64(s.AvgDuration)
+	s.AvgDuration = time.Duration(weighted)
+	s.LastCall = meta.StartedAt
+}
+
+// get returns the named plugin's stats, reporting false when no call has been recorded.
+func (c *callStats) get(name string) (CallStats, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.stats[name]
+	if !ok {
+		return CallStats{}, false
+	}
+	return *s, true
+}
+
+// Call dispenses the named plugin as I, calls fn with it, and returns fn's value in a Result envelope carrying the
+// call's metadata, repeating failed attempts as opts allows. Typed shims generated by shimgen wrap each method of a
+// shared plugin interface in Call; host code opts in by calling a plugin through its shim instead of the interface
+// returned by Dispense. Every call is recorded in the plugin's CallStats.
+func Call[I, T any](pm *PluginManager, name string, opts CallOptions, fn func(impl I) (T, error)) (Result[T], error) {
+	res := Result[T]{Meta: CallMetadata{Plugin: name, StartedAt: time.Now()}}
+	if ld, err := pm.launchDetails(name); err == nil {
+		res.Meta.Version = ld.Version
+	}
+	var err error
+	for attempt := 0; ; attempt++ {
+		res.Meta.Retries = attempt
+		res.Value, err = callOnce(pm, name, &res.Meta, fn)
+		if err == nil || attempt >= opts.Retries {
+			break
+		}
+		pm.managerLogger.Debug("Retrying plugin call", logger.KeyPluginName, name, logger.KeyRetryCount, attempt+1,
+			logger.KeyError, err)
+		time.Sleep(opts.RetryDelay)
+	}
+	res.Meta.Duration = time.Since(res.Meta.StartedAt)
+	pm.calls.record(res.Meta, err)
+	return res, err
+}
+
+// callOnce dispenses the named plugin as I and calls fn with it, recording the transport in meta.
+func callOnce[I, T any](pm *PluginManager, name string, meta *CallMetadata, fn func(impl I) (T, error)) (T, error) {
+	var zero T
+	raw, err := pm.Dispense(name)
+	if err != nil {
+		return zero, err
+	}
+	impl, ok := raw.(I)
+	if !ok {
+		return zero, fmt.Errorf("%w: %q is %T", ErrWrongInterface, name, raw)
+	}
+	if status, err := pm.Status(name); err == nil {
+		meta.Transport = status.Protocol
+	}
+	return fn(impl)
+}
+
+// CallStats returns the stats of the calls made to the named plugin through Call, reporting false when none has
+// been made.
+func (pm *PluginManager) CallStats(name string) (CallStats, bool) {
+	return pm.calls.get(name)
+}
+
+// Fastest returns the plugin among names with the lowest average call duration. Plugins that have not been called
+// yet are preferred, so every candidate is observed before the averages are compared; it reports false when names is
+// empty.
+func (pm *PluginManager) Fastest(names ...string) (string, bool) {
+	best, found := "", false
+	var bestAvg time.Duration
+	for _, name := range names {
+		s, ok := pm.calls.get(name)
+		if !ok {
+			return name, true
+		}
+		if !found || s.AvgDuration < bestAvg {
+			best, bestAvg, found = name, s.AvgDuration, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrMethodNotFound indicates that a dispensed plugin has no exported method of the requested name.
+// ErrInvalidArguments indicates that the arguments of a call cannot be decoded into the method's parameters.
+var (
+	ErrMethodNotFound   = errors.New("plugin method not found")
+	ErrInvalidArguments = errors.New("invalid plugin method arguments")
+)
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// Exec dispenses the named running plugin and calls one of the methods of the interface it serves, for ad-hoc
+// testing without a host program. args is a JSON array holding one element per parameter; a method with a single
+// parameter that is not a slice also accepts the bare value, and a leading context.Context parameter is passed ctx
+// rather than decoded. Exec returns the method's results, without a trailing error result, which is returned as the
+// error instead.
+func (pm *PluginManager) Exec(ctx context.Context, name, method string, args json.RawMessage) ([]any, error) {
+	impl, err := pm.Dispense(name)
+	if err != nil {
+		return nil, err
+	}
+	fn := reflect.ValueOf(impl).MethodByName(method)
+	if !fn.IsValid() {
+		return nil, fmt.Errorf("%w: %q has no method %q, available: %s", ErrMethodNotFound, name, method,
+			strings.Join(Methods(impl), ", "))
+	}
+
+	in, err := execArgs(ctx, fn.Type(), args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s.%s: %w", ErrInvalidArguments, name, method, err)
+	}
+	out := fn.Call(in)
+	if n := len(out); n > 0 && fn.Type().Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	results := make([]any, 0, len(out))
+	for _, v := range out {
+		results = append(results, v.Interface())
+	}
+	return results, nil
+}
+
+// Methods returns the names of the exported methods of a dispensed plugin, ordered by name.
+func Methods(impl any) []string {
+	t := reflect.TypeOf(impl)
+	if t == nil {
+		return nil
+	}
+	names := make([]string, 0, t.NumMethod())
+	for i := range t.NumMethod() {
+		names = append(names, t.Method(i).Name)
+	}
+	return names
+}
+
+// execArgs decodes args into the parameters of a method of type fnType, passing ctx for a leading context parameter.
+func execArgs(ctx context.Context, fnType reflect.Type, args json.RawMessage) ([]reflect.Value, error) {
+	if fnType.IsVariadic() {
+		return nil, errors.New("variadic methods are not supported")
+	}
+	in := make([]reflect.Value, 0, fnType.NumIn())
+	params := make([]reflect.Type, 0, fnType.NumIn())
+	for i := range fnType.NumIn() {
+		if i == 0 && fnType.In(i) == contextType {
+			in = append(in, reflect.ValueOf(ctx))
+			continue
+		}
+		params = append(params, fnType.In(i))
+	}
+
+	var raw []json.RawMessage
+	trimmed := strings.TrimSpace(string(args))
+	switch {
+	case trimmed == "":
+	case len(params) == 1 && params[0].Kind() != reflect.Slice && params[0].Kind() != reflect.Array &&
+		!strings.HasPrefix(trimmed, "["):
+		raw = []json.RawMessage{json.RawMessage(trimmed)}
+	default:
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("arguments must be a JSON array: %w", err)
+		}
+	}
+	if len(raw) != len(params) {
+		return nil, fmt.Errorf("got %d arguments, want %d", len(raw), len(params))
+	}
+	for i, p := range params {
+		v := reflect.New(p)
+		if err := json.Unmarshal(raw[i], v.Interface()); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		in = append(in, v.Elem())
+	}
+	return in, nil
+}
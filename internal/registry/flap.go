@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultFlapWindow is the default sliding window used to evaluate plugin crashes and restarts.
+	DefaultFlapWindow = 10 * time.Minute
+	// DefaultFlapThreshold is the default number of crash/restart events within the window that marks a
+	// plugin as flapping.
+	DefaultFlapThreshold = 5
+)
+
+// FlapEvent represents the kind of lifecycle event tracked by the FlapDetector.
+type FlapEvent int
+
+// FlapCrash represents a plugin process exiting unexpectedly.
+// FlapRestart represents a plugin being restarted by the host.
+const (
+	FlapCrash FlapEvent = iota
+	FlapRestart
+)
+
+// flapRecord is a single timestamped crash or restart event.
+type flapRecord struct {
+	event FlapEvent
+	at    time.Time
+}
+
+// FlapReport is a point-in-time summary of a plugin's stability over the flap detection window.
+type FlapReport struct {
+	PluginName string        `json:"plugin_name" yaml:"plugin_name"`
+	Crashes    int           `json:"crashes" yaml:"crashes"`
+	Restarts   int           `json:"restarts" yaml:"restarts"`
+	Score      float64       `json:"stability_score" yaml:"stability_score"`
+	Window     time.Duration `json:"window" yaml:"window"`
+	Disabled   bool          `json:"disabled" yaml:"disabled"`
+}
+
+// FlapDetector tracks per-plugin crash and restart frequency over a sliding window, computes a stability score,
+// and demotes chronically flapping plugins to PluginDisabledPendingReview.
+type FlapDetector struct {
+	mu        sync.RWMutex
+	flapLog   hclog.Logger
+	window    time.Duration
+	threshold int
+	events    map[string][]flapRecord
+	disabled  map[string]time.Time // plugin name -> time it was demoted
+	onDisable func(FlapReport)     // called when a plugin is demoted, nil when not set
+}
+
+// NewFlapDetector creates a FlapDetector using the given window and threshold.
+// Non-positive values fall back to DefaultFlapWindow and DefaultFlapThreshold.
+func NewFlapDetector(window time.Duration, threshold int, flapLog hclog.Logger) *FlapDetector {
+	if window <= 0 {
+		window = DefaultFlapWindow
+	}
+	if threshold < 1 {
+		threshold = DefaultFlapThreshold
+	}
+	if flapLog == nil {
+		flapLog = hclog.Default()
+	}
+	return &FlapDetector{
+		mu:        sync.RWMutex{},
+		flapLog:   flapLog,
+		window:    window,
+		threshold: threshold,
+		events:    make(map[string][]flapRecord),
+		disabled:  make(map[string]time.Time),
+	}
+}
+
+// OnDisable sets fn to be called, on its own goroutine, with the FlapReport of each plugin as it is demoted to
+// PluginDisabledPendingReview, and returns the updated FlapDetector.
+func (f *FlapDetector) OnDisable(fn func(FlapReport)) *FlapDetector {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onDisable = fn
+	return f
+}
+
+// RecordCrash records an unexpected exit for the named plugin and returns the resulting state.
+func (f *FlapDetector) RecordCrash(name string) PluginState {
+	return f.record(name, FlapCrash)
+}
+
+// RecordRestart records a restart of the named plugin and returns the resulting state.
+func (f *FlapDetector) RecordRestart(name string) PluginState {
+	return f.record(name, FlapRestart)
+}
+
+// record appends an event for the plugin, prunes events outside the window, and demotes the plugin once the
+// threshold is reached. It returns PluginDisabledPendingReview for demoted plugins and PluginStateUnknown otherwise,
+// leaving the caller's view of the plugin state unchanged.
+func (f *FlapDetector) record(name string, event FlapEvent) PluginState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	f.events[name] = append(f.prune(name, now), flapRecord{event: event, at: now})
+	if _, ok := f.disabled[name]; ok {
+		return PluginDisabledPendingReview
+	}
+	if len(f.events[name]) >= f.threshold {
+		f.disabled[name] = now
+		crashes, restarts := f.count(name)
+		f.flapLog.Warn("Plugin is flapping, disabled pending review",
+			logger.KeyPluginName, name,
+			logger.KeyCrashCount, crashes,
+			logger.KeyRestartCount, restarts,
+			logger.KeyFlapWindow, f.window.String(),
+			logger.KeyStabilityScore, f.score(name))
+		if f.onDisable != nil {
+			go f.onDisable(f.report(name))
+		}
+		return PluginDisabledPendingReview
+	}
+	return PluginStateUnknown
+}
+
+// prune drops events older than the window for the given plugin. The caller must hold the lock.
+func (f *FlapDetector) prune(name string, now time.Time) []flapRecord {
+	cutoff := now.Add(-f.window)
+	kept := f.events[name][:0]
+	for _, r := range f.events[name] {
+		if r.at.After(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// count returns the number of crashes and restarts recorded for the plugin. The caller must hold the lock.
+func (f *FlapDetector) count(name string) (crashes int, restarts int) {
+	cutoff := time.Now().Add(-f.window)
+	for _, r := range f.events[name] {
+		if !r.at.After(cutoff) {
+			continue
+		}
+		switch r.event {
+		case FlapCrash:
+			crashes++
+		case FlapRestart:
+			restarts++
+		}
+	}
+	return crashes, restarts
+}
+
+// score computes the stability score for the plugin, from 1.0 (no events) to 0.0 (at or above the threshold).
+// The caller must hold the lock.
+func (f *FlapDetector) score(name string) float64 {
+	crashes, restarts := f.count(name)
+	s := 1.0 - float64(crashes+restarts)/float64(f.threshold)
+	if s < 0 {
+		return 0
+	}
+	return s
+}
+
+// StabilityScore returns the current stability score of the named plugin.
+func (f *FlapDetector) StabilityScore(name string) float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.score(name)
+}
+
+// IsDisabled reports whether the named plugin has been demoted to PluginDisabledPendingReview.
+func (f *FlapDetector) IsDisabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, ok := f.disabled[name]
+	return ok
+}
+
+// Approve clears the disabled state and event history of the named plugin after an operator review.
+func (f *FlapDetector) Approve(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.disabled, name)
+	delete(f.events, name)
+	f.flapLog.Info("Plugin approved after review", logger.KeyPluginName, name)
+}
+
+// Report returns a FlapReport for the named plugin.
+func (f *FlapDetector) Report(name string) FlapReport {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.report(name)
+}
+
+// report builds a FlapReport for the plugin. The caller must hold the lock.
+func (f *FlapDetector) report(name string) FlapReport {
+	crashes, restarts := f.count(name)
+	_, disabled := f.disabled[name]
+	return FlapReport{
+		PluginName: name,
+		Crashes:    crashes,
+		Restarts:   restarts,
+		Score:      f.score(name),
+		Window:     f.window,
+		Disabled:   disabled,
+	}
+}
+
+// Reports returns a FlapReport for every tracked plugin, sorted by ascending stability score so the least stable
+// plugins are listed first.
+func (f *FlapDetector) Reports() []FlapReport {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	reports := make([]FlapReport, 0, len(f.events))
+	for name := range f.events {
+		reports = append(reports, f.report(name))
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Score == reports[j].Score {
+			return reports[i].PluginName < reports[j].PluginName
+		}
+		return reports[i].Score < reports[j].Score
+	})
+	return reports
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/hashicorp/go-plugin"
@@ -67,6 +68,18 @@ func (pfl *PluginFormatLookup) GetPluginFormat(format string) PluginFormat {
 	return pfl.formats[format]
 }
 
+// Names returns the registered plugin format names in sorted order.
+func (pfl *PluginFormatLookup) Names() []string {
+	pfl.mu.RLock()
+	defer pfl.mu.RUnlock()
+	names := make([]string, 0, len(pfl.formats))
+	for name := range pfl.formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IsValidFormat checks if the provided format string exists as a key in the PluginFormatLookup map.
 // Returns true if valid.
 func (pfl *PluginFormatLookup) IsValidFormat(format string) bool {
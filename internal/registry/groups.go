@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+)
+
+// GroupRunning reports that every plugin of a group is running.
+// GroupPartial reports that some, but not all, plugins of a group are running and none has failed.
+// GroupStopped reports that no plugin of a group is running and none has failed.
+// GroupFailed reports that at least one plugin of a group is in an error state or not installed.
+const (
+	GroupRunning = "running"
+	GroupPartial = "partial"
+	GroupStopped = "stopped"
+	GroupFailed  = "failed"
+)
+
+// ErrGroupNotFound indicates that a plugin group is not defined.
+var ErrGroupNotFound = errors.New("plugin group not found")
+
+// GroupStatus aggregates the status of the plugins of a group. Members that are not installed are listed with their
+// error in LastError and counted as failed.
+type GroupStatus struct {
+	Name    string         `json:"name" yaml:"name"`
+	State   string         `json:"state" yaml:"state"` // GroupRunning, GroupPartial, GroupStopped, or GroupFailed
+	Running int            `json:"running" yaml:"running"`
+	Failed  int            `json:"failed" yaml:"failed"`
+	Plugins []PluginStatus `json:"plugins" yaml:"plugins"`
+}
+
+// WithGroups defines the named groups of plugins that StartGroup and StopGroup start and stop together, and returns
+// the updated PluginManager. It must be called before the groups are used.
+func (pm *PluginManager) WithGroups(groups map[string][]string) *PluginManager {
+	pm.groups = maps.Clone(groups)
+	return pm
+}
+
+// Groups returns the names of the defined plugin groups, sorted.
+func (pm *PluginManager) Groups() []string {
+	return slices.Sorted(maps.Keys(pm.groups))
+}
+
+// groupMembers returns the plugins of the named group, or ErrGroupNotFound.
+func (pm *PluginManager) groupMembers(name string) ([]string, error) {
+	members, ok := pm.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrGroupNotFound, name)
+	}
+	return members, nil
+}
+
+// StartGroup enables the plugins of the named group in the catalog's desired states, keeping their pinned versions,
+// and starts those that are not running. Plugins that fail to start are reported together in the returned error,
+// and the rest keep running.
+func (pm *PluginManager) StartGroup(name string) error {
+	members, err := pm.groupMembers(name)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, member := range members {
+		desired, _ := pm.catalog.GetDesiredState(member)
+		desired.Enabled = true
+		pm.catalog.SetDesiredState(member, desired)
+		if status, err := pm.Status(member); err == nil && status.State == PluginRunning {
+			continue
+		}
+		if err := pm.Start(member); err != nil {
+			errs = append(errs, fmt.Errorf("start %s: %w", member, err))
+		}
+	}
+	return pm.logGroup("Started", name, errs)
+}
+
+// StopGroup disables the plugins of the named group in the catalog's desired states, so a converger does not start
+// them again, and stops those that are running. A plugin that is also in another group is stopped too. Plugins that
+// fail to stop are reported together in the returned error.
+func (pm *PluginManager) StopGroup(name string) error {
+	members, err := pm.groupMembers(name)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, member := range members {
+		desired, _ := pm.catalog.GetDesiredState(member)
+		desired.Enabled = false
+		pm.catalog.SetDesiredState(member, desired)
+		if err := pm.Stop(member); err != nil && !errors.Is(err, ErrPluginNotRunning) {
+			errs = append(errs, fmt.Errorf("stop %s: %w", member, err))
+		}
+	}
+	return pm.logGroup("Stopped", name, errs)
+}
+
+// logGroup logs the outcome of starting or stopping the named group and returns its errors joined.
+func (pm *PluginManager) logGroup(verb, name string, errs []error) error {
+	err := errors.Join(errs...)
+	if err != nil {
+		pm.managerLogger.Warn(verb+" plugin group with errors", "group", name, logger.KeyError, err)
+		return err
+	}
+	pm.managerLogger.Info(verb+" plugin group", "group", name)
+	return nil
+}
+
+// GroupStatus returns the aggregated status of the named group.
+func (pm *PluginManager) GroupStatus(name string) (GroupStatus, error) {
+	members, err := pm.groupMembers(name)
+	if err != nil {
+		return GroupStatus{}, err
+	}
+	group := GroupStatus{Name: name, Plugins: make([]PluginStatus, 0, len(members))}
+	for _, member := range members {
+		status, err := pm.Status(member)
+		if err != nil {
+			status = PluginStatus{Name: member, State: PluginStateUnknown, StateName: PluginStateUnknown.String(),
+				LastError: err.Error()}
+		}
+		switch {
+		case err != nil:
+			group.Failed++
+		case status.State == PluginRunning:
+			group.Running++
+		case status.State >= PluginMissingManifest:
+			group.Failed++
+		}
+		group.Plugins = append(group.Plugins, status)
+	}
+	switch {
+	case group.Failed > 0:
+		group.State = GroupFailed
+	case group.Running == len(members):
+		group.State = GroupRunning
+	case group.Running > 0:
+		group.State = GroupPartial
+	default:
+		group.State = GroupStopped
+	}
+	return group, nil
+}
+
+// GroupStatuses returns the aggregated status of every defined group, sorted by name.
+func (pm *PluginManager) GroupStatuses() []GroupStatus {
+	statuses := make([]GroupStatus, 0, len(pm.groups))
+	for _, name := range pm.Groups() {
+		if status, err := pm.GroupStatus(name); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthCheckPing pings the plugin connection, the default for every plugin kind.
+// HealthCheckGRPC calls the standard gRPC health service of a gRPC plugin.
+// HealthCheckExec runs a command, relative to the plugin directory, that exits zero when the plugin is healthy.
+const (
+	HealthCheckPing = "ping"
+	HealthCheckGRPC = "grpc"
+	HealthCheckExec = "exec"
+)
+
+// DefaultHealthCheckTimeout bounds a single health check.
+// DefaultFailureThreshold is the number of consecutive failed checks after which a plugin is restarted.
+// DefaultGRPCHealthService is the service go-plugin registers with the gRPC health service.
+const (
+	DefaultHealthCheckTimeout = 2 * time.Second
+	DefaultFailureThreshold   = 3
+	DefaultGRPCHealthService  = "plugin"
+)
+
+// ErrUnhealthy indicates that a plugin failed its health check.
+// ErrInvalidHealthCheck indicates that a manifest's health check cannot be run against the plugin.
+var (
+	ErrUnhealthy          = errors.New("plugin health check failed")
+	ErrInvalidHealthCheck = errors.New("invalid health check")
+)
+
+// HealthCheck is the manifest-declared liveness check the supervisor runs against a plugin. Zero values fall back
+// to a ping every supervise interval, timing out after DefaultHealthCheckTimeout and restarting the plugin after
+// DefaultFailureThreshold consecutive failures.
+type HealthCheck struct {
+	Type             string   `json:"type" yaml:"type"`                                     // ping, grpc, or exec
+	Interval         int      `json:"interval_ms" yaml:"interval_ms"`                       // milliseconds
+	Timeout          int      `json:"timeout_ms" yaml:"timeout_ms"`                         // milliseconds
+	FailureThreshold int      `json:"failure_threshold" yaml:"failure_threshold"`           // consecutive failures
+	GRPCService      string   `json:"grpc_service,omitempty" yaml:"grpc_service,omitempty"` // grpc checks only
+	Command          []string `json:"command,omitempty" yaml:"command,omitempty"`           // exec checks only
+}
+
+// Validate checks that the health check's type is known and has what it needs to run.
+func (hc *HealthCheck) Validate() error {
+	if hc == nil {
+		return nil
+	}
+	switch hc.kind() {
+	case HealthCheckPing, HealthCheckGRPC:
+	case HealthCheckExec:
+		if len(hc.Command) == 0 {
+			return fmt.Errorf("%w: exec check without a command", ErrInvalidHealthCheck)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidHealthCheck, hc.Type)
+	}
+	if hc.Interval < 0 || hc.Timeout < 0 || hc.FailureThreshold < 0 {
+		return fmt.Errorf("%w: interval, timeout, and failure threshold must not be negative", ErrInvalidHealthCheck)
+	}
+	return nil
+}
+
+// kind returns the check type, HealthCheckPing when unset.
+func (hc *HealthCheck) kind() string {
+	if hc == nil || hc.Type == "" {
+		return HealthCheckPing
+	}
+	return hc.Type
+}
+
+// interval returns how often the check runs, or zero to run it every supervise interval.
+func (hc *HealthCheck) interval() time.Duration {
+	if hc == nil {
+		return 0
+	}
+	return time.Duration(hc.Interval) * time.Millisecond
+}
+
+// timeout returns the time allowed for one check.
+func (hc *HealthCheck) timeout() time.Duration {
+	if hc == nil || hc.Timeout <= 0 {
+		return DefaultHealthCheckTimeout
+	}
+	return time.Duration(hc.Timeout) * time.Millisecond
+}
+
+// threshold returns the number of consecutive failures that trigger a restart.
+func (hc *HealthCheck) threshold() int {
+	if hc == nil || hc.FailureThreshold <= 0 {
+		return DefaultFailureThreshold
+	}
+	return hc.FailureThreshold
+}
+
+// check runs the health check against a running plugin whose binary lives in dir.
+func (hc *HealthCheck) check(client *plugin.Client, dir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hc.timeout())
+	defer cancel()
+	var err error
+	switch hc.kind() {
+	case HealthCheckPing:
+		err = ping(ctx, client)
+	case HealthCheckGRPC:
+		err = grpcHealth(ctx, client, hc.GRPCService)
+	case HealthCheckExec:
+		err = execHealth(ctx, hc.Command, dir)
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidHealthCheck, hc.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrUnhealthy, hc.kind(), err)
+	}
+	return nil
+}
+
+// ping pings the plugin connection, giving up when ctx ends.
+func ping(ctx context.Context, client *plugin.Client) error {
+	rpcClient, err := client.Client()
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- rpcClient.Ping() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// grpcHealth asks the plugin's gRPC health service whether service is serving.
+func grpcHealth(ctx context.Context, client *plugin.Client, service string) error {
+	rpcClient, err := client.Client()
+	if err != nil {
+		return err
+	}
+	grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+	if !ok {
+		return fmt.Errorf("%w: grpc check on a %s plugin", ErrInvalidHealthCheck, client.Protocol())
+	}
+	if service == "" {
+		service = DefaultGRPCHealthService
+	}
+	resp, err := healthpb.NewHealthClient(grpcClient.Conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service %q is %s", service, resp.GetStatus())
+	}
+	return nil
+}
+
+// execHealth runs command in dir, resolving a relative command against dir.
+func execHealth(ctx context.Context, command []string, dir string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("%w: exec check without a command", ErrInvalidHealthCheck)
+	}
+	path := command[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	cmd := exec.CommandContext(ctx, path, command[1:]...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultHealthEventBuffer is the capacity of the health checker's events channel.
+const DefaultHealthEventBuffer = 100
+
+// HealthActionNone records that a failed check was only reported.
+// HealthActionRestartScheduled records that a crashed plugin will be restarted once its backoff has passed.
+// HealthActionRestart records that a crashed plugin was restarted.
+// HealthActionRestartFailed records that restarting a crashed plugin failed, e.g. because it is flapping.
+// HealthActionQuarantine records that a crash-looping plugin used up its restarts and was quarantined.
+const (
+	HealthActionNone             = "none"
+	HealthActionRestartScheduled = "restart_scheduled"
+	HealthActionRestart          = "restart"
+	HealthActionRestartFailed    = "restart_failed"
+	HealthActionQuarantine       = "quarantine"
+)
+
+// HealthEvent describes a failed health check, or the first passing check after failures. Failures is the number of
+// consecutive failed checks, and State the plugin's state once the checker acted on the result.
+type HealthEvent struct {
+	Plugin    string      `json:"plugin"`
+	Healthy   bool        `json:"healthy"`
+	Failures  int         `json:"failures"`
+	State     PluginState `json:"state"`
+	StateName string      `json:"state_name"`
+	Action    string      `json:"action"`
+	Error     string      `json:"error,omitempty"`
+	CheckedAt time.Time   `json:"checked_at"`
+}
+
+// HealthChecker runs the liveness probes of a PluginManager's running plugins: each plugin's manifest-declared
+// health check, or a ping of its go-plugin client, once it is due. A plugin whose process has died, or that fails
+// its check too many times in a row, is marked PluginStoppedUnexpectedly and, when restarts are enabled, restarted
+// following the manager's RestartPolicy. Failures, recoveries, and restarts are logged and emitted as HealthEvents.
+type HealthChecker struct {
+	manager      *PluginManager
+	interval     time.Duration
+	restart      bool
+	events       chan HealthEvent
+	failing      map[string]struct{} // plugins whose last check failed
+	healthLogger hclog.Logger
+}
+
+// NewHealthChecker creates a HealthChecker for the running plugins of manager that checks them every interval and
+// restarts crashed plugins. An interval of zero or less uses DefaultSuperviseInterval.
+func NewHealthChecker(manager *PluginManager, interval time.Duration, healthLogger hclog.Logger) *HealthChecker {
+	if healthLogger == nil {
+		healthLogger = hclog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultSuperviseInterval
+	}
+	return &HealthChecker{
+		manager:      manager,
+		interval:     interval,
+		restart:      true,
+		events:       make(chan HealthEvent, DefaultHealthEventBuffer),
+		failing:      make(map[string]struct{}),
+		healthLogger: healthLogger,
+	}
+}
+
+// WithRestart sets whether crashed plugins are restarted, and returns the updated HealthChecker. Without restarts,
+// crashed plugins stay PluginStoppedUnexpectedly until an operator acts on the events.
+func (hc *HealthChecker) WithRestart(restart bool) *HealthChecker {
+	hc.restart = restart
+	return hc
+}
+
+// Events returns a channel of health events. Events are dropped when the channel is full.
+func (hc *HealthChecker) Events() <-chan HealthEvent {
+	return hc.events
+}
+
+// Run checks the running plugins and restarts the crashed plugins that are due every interval until ctx is canceled,
+// then closes the events channel.
+func (hc *HealthChecker) Run(ctx context.Context) {
+	defer close(hc.events)
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			hc.CheckAll()
+			hc.RestartDue()
+		}
+	}
+}
+
+// CheckAll checks every running plugin whose check is due once.
+func (hc *HealthChecker) CheckAll() {
+	for _, name := range hc.manager.names() {
+		if hc.manager.healthDue(name) {
+			hc.check(name)
+		}
+	}
+}
+
+// RestartDue restarts every crashed plugin whose backoff has passed.
+func (hc *HealthChecker) RestartDue() {
+	for _, name := range hc.manager.restartsDue() {
+		action := HealthActionRestart
+		err := hc.manager.restartCrashed(name)
+		switch {
+		case err == nil:
+			delete(hc.failing, name)
+			hc.healthLogger.Info("Restarted crashed plugin", logger.KeyPluginName, name)
+		case hc.manager.quarantined(name):
+			action = HealthActionQuarantine
+		default:
+			action = HealthActionRestartFailed
+			hc.healthLogger.Error("Failed to restart plugin", logger.KeyPluginName, name, logger.KeyError, err)
+		}
+		hc.emit(name, err, hc.manager.failures(name), action)
+	}
+}
+
+// check runs the health check of the named plugin and acts on its result.
+func (hc *HealthChecker) check(name string) {
+	err := hc.manager.Health(name)
+	failures := hc.manager.failures(name)
+	_, wasFailing := hc.failing[name]
+	if err == nil {
+		if wasFailing {
+			delete(hc.failing, name)
+			hc.healthLogger.Info("Plugin healthy again", logger.KeyPluginName, name)
+			hc.emit(name, nil, failures, HealthActionNone)
+		}
+		return
+	}
+	hc.failing[name] = struct{}{}
+
+	action := HealthActionNone
+	if hc.manager.state(name) == PluginStoppedUnexpectedly && hc.restart {
+		action = HealthActionRestartScheduled
+		if hc.manager.scheduleRestart(name) {
+			action = HealthActionQuarantine
+		}
+	}
+	hc.emit(name, err, failures, action)
+}
+
+// emit sends a HealthEvent for the named plugin, dropping it when the channel is full.
+func (hc *HealthChecker) emit(name string, err error, failures int, action string) {
+	event := HealthEvent{
+		Plugin:    name,
+		Healthy:   err == nil,
+		Failures:  failures,
+		Action:    action,
+		CheckedAt: time.Now(),
+	}
+	event.State = hc.manager.state(name)
+	event.StateName = event.State.String()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	select {
+	case hc.events <- event:
+	default:
+		hc.healthLogger.Debug("Health events channel full, event dropped", logger.KeyPluginName, name)
+	}
+}
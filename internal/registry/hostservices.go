@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/ngfs"
+	"github.com/bmj2728/PlugsConc/shared/pkg/ngnet"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// hostServicesPlugin wraps a gRPC plugin type so that, the first time the host dispenses the plugin, the host services
+// it may call back into are served to it over the go-plugin broker, guarded by the capabilities of its manifest.
+type hostServicesPlugin struct {
+	plugin.Plugin
+	grpcPlugin plugin.GRPCPlugin
+	serve      func(broker *plugin.GRPCBroker)
+	once       sync.Once
+}
+
+// GRPCServer registers the wrapped plugin's server.
+func (p *hostServicesPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	return p.grpcPlugin.GRPCServer(broker, s)
+}
+
+// GRPCClient starts serving the host services over broker, once per plugin process, and returns the wrapped plugin's
+// client.
+func (p *hostServicesPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker,
+	conn *grpc.ClientConn) (interface{}, error) {
+	p.once.Do(func() { p.serve(broker) })
+	return p.grpcPlugin.GRPCClient(ctx, broker, conn)
+}
+
+// withHostServices returns pluginType wrapped to serve the named plugin its host services, or pluginType itself when
+// the plugin does not speak gRPC.
+func (pm *PluginManager) withHostServices(name string, pluginType plugin.Plugin) plugin.Plugin {
+	grpcPlugin, ok := pluginType.(plugin.GRPCPlugin)
+	if !ok {
+		return pluginType
+	}
+	caps, _ := pm.catalog.Capabilities(name)
+	return &hostServicesPlugin{
+		Plugin:     pluginType,
+		grpcPlugin: grpcPlugin,
+		serve:      func(broker *plugin.GRPCBroker) { pm.serveHostServices(name, caps, broker) },
+	}
+}
+
+// serveHostServices serves the named plugin over broker a filesystem service granting only the paths and
+// permissions of its filesystem capabilities, under ngfs.BrokerID, and a network service dialing only the
+// destinations of its egress rules, under ngnet.BrokerID. A plugin declaring neither is denied every request.
+func (pm *PluginManager) serveHostServices(name string, caps capability.Capabilities, broker *plugin.GRPCBroker) {
+	servicesLogger := pm.managerLogger.With(logger.KeyPluginName, name)
+	fs, err := ngfs.NewGuardedNGFS(caps.Filesystem)
+	if err != nil {
+		servicesLogger.Error("Failed to build the plugin's filesystem service", logger.KeyError, err)
+	} else {
+		ngfs.ServeBroker(broker, fs.WithLogger(servicesLogger.Named("ngfs")))
+	}
+	network, err := ngnet.NewNGNet(name, caps.Network, pm.managerLogger.Named("ngnet"))
+	if err != nil {
+		servicesLogger.Error("Failed to build the plugin's network service", logger.KeyError, err)
+	} else {
+		ngnet.ServeBroker(broker, network)
+	}
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-plugin/runner"
+)
+
+// DefaultInProcessStartTimeout bounds how long an in-process plugin may take to start serving.
+const DefaultInProcessStartTimeout = 5 * time.Second
+
+// inProcessVersion is the Version of in-process plugins, which have no manifest.
+const inProcessVersion = "in-process"
+
+// ErrInProcessStart indicates that an in-process plugin did not start serving.
+var ErrInProcessStart = errors.New("in-process plugin failed to start")
+
+// AddInProcess adds a plugin served from inside the host process rather than launched from a binary, such as an
+// in-memory fake. pluginType must carry its implementation, e.g. &animal.AnimalGRPCPlugin{Impl: impl}. In-process
+// plugins go through the same handshake, transport, and lifecycle as launched plugins, but have no manifest,
+// checksum, or runtime directories, and cannot run exec health checks.
+func (c *PluginCatalog) AddInProcess(name string, pluginType plugin.Plugin) {
+	protocol := plugin.ProtocolNetRPC
+	if _, ok := pluginType.(plugin.GRPCPlugin); ok {
+		protocol = plugin.ProtocolGRPC
+	}
+	c.AddPlugin(name, pluginType)
+	c.SetLaunchDetails(&PluginLaunchDetails{
+		PluginName: name,
+		Version:    inProcessVersion,
+		HandshakeConfig: &plugin.HandshakeConfig{
+			ProtocolVersion:  1,
+			MagicCookieKey:   "PLUGSCONC_IN_PROCESS",
+			MagicCookieValue: name,
+		},
+		AllowedProtocols: []plugin.Protocol{protocol},
+		InProcess:        true,
+	})
+}
+
+// newInProcessClient serves the named in-process plugin on a goroutine and returns a client attached to it.
+func (pm *PluginManager) newInProcessClient(name string, ld *PluginLaunchDetails,
+	pluginType plugin.Plugin) (*plugin.Client, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reattach := make(chan *plugin.ReattachConfig, 1)
+	closed := make(chan struct{})
+	serve := &plugin.ServeConfig{
+		HandshakeConfig: *ld.Handshake(),
+		Plugins:         plugin.PluginSet{name: pluginType},
+		Logger:          pm.clientLogger(name),
+		Test:            &plugin.ServeTestConfig{Context: ctx, ReattachConfigCh: reattach, CloseCh: closed},
+	}
+	if _, ok := pluginType.(plugin.GRPCPlugin); ok {
+		serve.GRPCServer = plugin.DefaultGRPCServer
+	}
+	go plugin.Serve(serve)
+
+	var config *plugin.ReattachConfig
+	select {
+	case config = <-reattach:
+	case <-closed:
+		cancel()
+		return nil, fmt.Errorf("%w: %q stopped serving", ErrInProcessStart, name)
+	case <-time.After(DefaultInProcessStartTimeout):
+		cancel()
+		return nil, fmt.Errorf("%w: %q did not serve within %s", ErrInProcessStart, name,
+			DefaultInProcessStartTimeout)
+	}
+	// attach as if to a process, rather than in go-plugin's test mode, so Kill stops the server and Exited reports it
+	r := &inProcessRunner{id: "in-process:" + name, cancel: cancel, closed: closed}
+	config.Test = false
+	config.ReattachFunc = func() (runner.AttachedRunner, error) { return r, nil }
+	return plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  *ld.Handshake(),
+		Plugins:          map[string]plugin.Plugin{name: pluginType},
+		Reattach:         config,
+		AllowedProtocols: ld.PluginAllowedProtocols(),
+		Logger:           pm.clientLogger(name),
+		GRPCDialOptions:  pm.grpcDialOptions(name),
+	}), nil
+}
+
+// inProcessRunner stands in for the process of an in-process plugin: it exits when the plugin stops serving.
+type inProcessRunner struct {
+	id     string
+	cancel context.CancelFunc
+	closed <-chan struct{}
+}
+
+// Wait blocks until the plugin stops serving.
+func (r *inProcessRunner) Wait(_ context.Context) error {
+	<-r.closed
+	return nil
+}
+
+// Kill stops the plugin serving and waits for it to finish.
+func (r *inProcessRunner) Kill(_ context.Context) error {
+	r.cancel()
+	<-r.closed
+	return nil
+}
+
+// ID identifies the plugin, since it has no process ID of its own.
+func (r *inProcessRunner) ID() string {
+	return r.id
+}
+
+// PluginToHost returns the plugin's address unchanged, since the plugin shares the host's network.
+func (r *inProcessRunner) PluginToHost(pluginNet, pluginAddr string) (string, string, error) {
+	return pluginNet, pluginAddr, nil
+}
+
+// HostToPlugin returns the host's address unchanged, since the plugin shares the host's network.
+func (r *inProcessRunner) HostToPlugin(hostNet, hostAddr string) (string, string, error) {
+	return hostNet, hostAddr, nil
+}
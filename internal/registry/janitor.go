@@ -0,0 +1,200 @@
+package registry
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultJanitorRetention is how old an orphaned runtime directory or a temp file must be before the janitor removes
+// it when no retention is configured.
+const DefaultJanitorRetention = 7 * 24 * time.Hour
+
+// DefaultJanitorInterval is how often Run sweeps when no interval is configured.
+const DefaultJanitorInterval = time.Hour
+
+// JanitorReport describes what a single sweep removed. Errors lists the artifacts that could not be removed; a
+// failure does not stop the sweep.
+type JanitorReport struct {
+	StartedAt      time.Time     `json:"started_at"`
+	Duration       time.Duration `json:"duration"`
+	OrphanedDirs   int           `json:"orphaned_dirs"`
+	TempFiles      int           `json:"temp_files"`
+	BytesReclaimed int64         `json:"bytes_reclaimed"`
+	Errors         []string      `json:"errors,omitempty"`
+}
+
+// JanitorStats accumulates the reports of every sweep since the janitor was created.
+type JanitorStats struct {
+	Sweeps         int           `json:"sweeps"`
+	OrphanedDirs   int           `json:"orphaned_dirs"`
+	TempFiles      int           `json:"temp_files"`
+	BytesReclaimed int64         `json:"bytes_reclaimed"`
+	LastSweep      JanitorReport `json:"last_sweep"`
+}
+
+// Janitor garbage-collects stale plugin artifacts in a RuntimeDirs: the runtime directories of plugins that are no
+// longer in the catalog, and files left in plugins' temp directories, once they are older than the retention period.
+// Sockets are never removed, since a running plugin may still be listening on them.
+type Janitor struct {
+	mu            sync.Mutex
+	runtime       *RuntimeDirs
+	catalog       *PluginCatalog
+	retention     time.Duration
+	janitorLogger hclog.Logger
+	stats         JanitorStats
+}
+
+// NewJanitor creates a Janitor for the runtime directories of the plugins in catalog. A retention of zero or less
+// uses DefaultJanitorRetention.
+func NewJanitor(runtime *RuntimeDirs, catalog *PluginCatalog, retention time.Duration,
+	janitorLogger hclog.Logger) *Janitor {
+	if janitorLogger == nil {
+		janitorLogger = hclog.Default()
+	}
+	if retention <= 0 {
+		retention = DefaultJanitorRetention
+	}
+	return &Janitor{
+		runtime:       runtime,
+		catalog:       catalog,
+		retention:     retention,
+		janitorLogger: janitorLogger,
+	}
+}
+
+// Run sweeps every interval until ctx is canceled. An interval of zero or less uses DefaultJanitorInterval.
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultJanitorInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.Sweep()
+		}
+	}
+}
+
+// Sweep removes the stale artifacts once and returns what it removed. Concurrent sweeps run one after the other.
+func (j *Janitor) Sweep() JanitorReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	report := JanitorReport{StartedAt: time.Now()}
+	cutoff := report.StartedAt.Add(-j.retention)
+
+	installed := make([]string, 0)
+	for _, ld := range j.catalog.GetLaunchDetails() {
+		installed = append(installed, ld.Name())
+	}
+	entries, err := os.ReadDir(j.runtime.root)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if slices.Contains(installed, name) {
+			j.sweepTemp(filepath.Join(j.runtime.Dir(name), "tmp"), cutoff, &report)
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		size := dirSize(j.runtime.Dir(name))
+		if err := j.runtime.Remove(name); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+		report.OrphanedDirs++
+		report.BytesReclaimed += size
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	j.stats.Sweeps++
+	j.stats.OrphanedDirs += report.OrphanedDirs
+	j.stats.TempFiles += report.TempFiles
+	j.stats.BytesReclaimed += report.BytesReclaimed
+	j.stats.LastSweep = report
+	if report.OrphanedDirs > 0 || report.TempFiles > 0 || len(report.Errors) > 0 {
+		j.janitorLogger.Info("Removed stale plugin artifacts", "orphaned_dirs", report.OrphanedDirs,
+			"temp_files", report.TempFiles, "bytes_reclaimed", report.BytesReclaimed, "errors", len(report.Errors))
+	}
+	return report
+}
+
+// Stats returns the totals of every sweep so far.
+func (j *Janitor) Stats() JanitorStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+// sweepTemp removes the regular files under dir last modified before cutoff, then the directories older than cutoff
+// that are left empty.
+func (j *Janitor) sweepTemp(dir string, cutoff time.Time, report *JanitorReport) {
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// judged by their age before the sweep, since removing their files updates it
+			if info, err := d.Info(); err == nil && path != dir && info.ModTime().Before(cutoff) {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			return nil
+		}
+		report.TempFiles++
+		report.BytesReclaimed += info.Size()
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		j.janitorLogger.Warn("Failed to sweep plugin temp directory", "dir", dir, logger.KeyError, err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+	// deepest first, so parents emptied by removing their children are removed too; os.Remove keeps non-empty ones
+	for _, d := range slices.Backward(dirs) {
+		_ = os.Remove(d)
+	}
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -1,6 +1,9 @@
 package registry
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 // PluginLanguage represents a language in which plugins can be implemented. It is represented as an integer type.
 type PluginLanguage int
@@ -108,6 +111,18 @@ func (l *PluginLanguageLookup) GetLanguage(language string) PluginLanguage {
 	return l.languages[language]
 }
 
+// Names returns the registered plugin language names in sorted order.
+func (l *PluginLanguageLookup) Names() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	names := make([]string, 0, len(l.languages))
+	for name := range l.languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IsValidLanguage checks if the given language is present in the available plugin language lookup map.
 func IsValidLanguage(lang string) bool {
 	AvailablePluginLanguageLookup.mu.RLock()
@@ -0,0 +1,183 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/checksum"
+	"github.com/bmj2728/PlugsConc/internal/signature"
+)
+
+// ErrInvalidLayout indicates that a plugin directory does not match the layout it is checked against.
+var ErrInvalidLayout = errors.New("invalid plugin directory layout")
+
+// LayoutManifest is replaced by the name of the plugin's manifest file, one of ManifestFileNames.
+// LayoutEntrypoint is replaced by the manifest's entrypoint.
+// LayoutName is replaced by the manifest's plugin name.
+// LayoutChecksum is replaced by the name of a checksum file written with any of checksum.Algorithms.
+const (
+	LayoutManifest   = "{manifest}"
+	LayoutEntrypoint = "{entrypoint}"
+	LayoutName       = "{name}"
+	LayoutChecksum   = "{checksum}"
+)
+
+// LayoutFile is a file a plugin directory may hold. Pattern is matched with path.Match against the file's
+// slash-separated path relative to the directory, once its placeholders are replaced. A Required file must exist, and
+// Missing is the state reported when it does not.
+type LayoutFile struct {
+	Pattern  string
+	Required bool
+	Missing  PluginState
+}
+
+// Layout is the template plugin directories are checked against during discovery, so that an incomplete
+// installation is reported before a launch is attempted. A file matching none of Files is unexpected, which only
+// rejects the plugin when Strict is set.
+type Layout struct {
+	Files  []LayoutFile
+	Strict bool
+}
+
+// DefaultLayout expects a manifest, the binary it names as its entrypoint, and a checksum file, and allows the
+// plugin's config file, signature, and provenance.
+var DefaultLayout = Layout{Files: []LayoutFile{
+	{Pattern: LayoutManifest, Required: true, Missing: PluginMissingManifest},
+	{Pattern: LayoutEntrypoint, Required: true, Missing: PluginMissingBinary},
+	{Pattern: LayoutChecksum, Required: true, Missing: PluginMissingChecksum},
+	{Pattern: LayoutName + ConfigFileSuffix},
+	{Pattern: signature.SigFileName},
+	{Pattern: ProvenanceFileName},
+}}
+
+// WithAllowed returns a copy of the layout that also allows the optional files matching patterns, e.g. "*.go" for
+// sources kept next to the binary.
+func (l Layout) WithAllowed(patterns ...string) Layout {
+	l.Files = slices.Clone(l.Files)
+	for _, p := range patterns {
+		l.Files = append(l.Files, LayoutFile{Pattern: p})
+	}
+	return l
+}
+
+// LayoutError reports a file of a plugin directory that does not match its layout, with the state the plugin is
+// left in because of it.
+type LayoutError struct {
+	File   string
+	State  PluginState
+	Reason string
+}
+
+// Error returns the file and what is wrong with it.
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidLayout, e.File, e.Reason)
+}
+
+// Unwrap returns ErrInvalidLayout so callers can match with errors.Is.
+func (e *LayoutError) Unwrap() error {
+	return ErrInvalidLayout
+}
+
+// ValidateLayout checks the plugin directory dir, whose manifest file is manifestName, against layout for the plugin
+// m describes. Every problem found is returned as a *LayoutError: a required file that is missing, with the file's
+// Missing state, an entrypoint that is not an executable file, with PluginInvalidBinary, and each unexpected file,
+// with PluginUnexpectedFile. A directory matching the layout returns nil.
+func ValidateLayout(dir, manifestName string, m *Manifest, layout Layout) []error {
+	var errs []error
+	problem := func(file string, state PluginState, reason string) {
+		errs = append(errs, &LayoutError{File: file, State: state, Reason: reason})
+	}
+	patterns := make([][]string, len(layout.Files))
+	for i, f := range layout.Files {
+		patterns[i] = expandLayoutPattern(f.Pattern, manifestName, m)
+	}
+
+	for i, f := range layout.Files {
+		if !f.Required {
+			continue
+		}
+		if !slices.ContainsFunc(patterns[i], func(p string) bool { return layoutFileExists(dir, p) }) {
+			file := f.Pattern
+			if len(patterns[i]) > 0 {
+				file = strings.Join(patterns[i], " or ")
+			}
+			problem(file, f.Missing, "is missing")
+		}
+	}
+	if m != nil {
+		entrypoint := filepath.Join(dir, m.PluginData.Entrypoint)
+		info, err := os.Stat(entrypoint)
+		if err == nil && (!info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0) {
+			problem(m.PluginData.Entrypoint, PluginInvalidBinary, "is not an executable file")
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// the launch reports a directory that cannot be read
+		problem(".", PluginStateUnknown, err.Error())
+		return errs
+	}
+	for _, entry := range entries {
+		expected := slices.ContainsFunc(patterns, func(alternatives []string) bool {
+			return slices.ContainsFunc(alternatives, func(p string) bool {
+				return layoutMatches(p, entry.Name(), entry.IsDir())
+			})
+		})
+		if !expected {
+			problem(entry.Name(), PluginUnexpectedFile, "is not part of the plugin layout")
+		}
+	}
+	return errs
+}
+
+// expandLayoutPattern returns the patterns pattern stands for once its placeholders are replaced. A placeholder the
+// manifest is needed for matches nothing without one.
+func expandLayoutPattern(pattern, manifestName string, m *Manifest) []string {
+	var name, entrypoint string
+	if m != nil {
+		name, entrypoint = m.PluginData.Name, filepath.ToSlash(filepath.Clean(m.PluginData.Entrypoint))
+	}
+	if (strings.Contains(pattern, LayoutName) && name == "") ||
+		(strings.Contains(pattern, LayoutEntrypoint) && entrypoint == "") {
+		return nil
+	}
+	pattern = strings.NewReplacer(LayoutManifest, manifestName, LayoutName, name, LayoutEntrypoint, entrypoint).
+		Replace(pattern)
+	if !strings.Contains(pattern, LayoutChecksum) {
+		return []string{pattern}
+	}
+	expanded := make([]string, 0, len(checksum.Algorithms))
+	for _, a := range checksum.Algorithms {
+		expanded = append(expanded, strings.ReplaceAll(pattern, LayoutChecksum, a.FileName()))
+	}
+	return expanded
+}
+
+// layoutFileExists reports whether a file in dir matches pattern.
+func layoutFileExists(dir, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(matches, func(match string) bool {
+		info, err := os.Stat(match)
+		return err == nil && !info.IsDir()
+	})
+}
+
+// layoutMatches reports whether the top-level entry name of a plugin directory matches pattern, a directory
+// matching when a file below it may.
+func layoutMatches(pattern, name string, isDir bool) bool {
+	first, _, nested := strings.Cut(pattern, "/")
+	if nested && !isDir {
+		return false
+	}
+	ok, err := path.Match(first, name)
+	return err == nil && ok
+}
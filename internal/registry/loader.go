@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/bmj2728/PlugsConc/internal/attestation"
+	"github.com/bmj2728/PlugsConc/internal/checksum"
 	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/signature"
 	"github.com/hashicorp/go-hclog"
 )
 
@@ -17,6 +20,10 @@ var (
 	ErrClosingFS         = errors.New("failed to close plugin files")
 	ErrReadingFile       = errors.New("failed to read file")
 	ErrYAMLUnmarshaling  = errors.New("failed to unmarshal YAML")
+	ErrJSONUnmarshaling  = errors.New("failed to unmarshal JSON")
+	ErrTOMLUnmarshaling  = errors.New("failed to unmarshal TOML")
+	// ErrEntrypoint indicates that the binary a manifest names as its entrypoint is missing or not executable.
+	ErrEntrypoint = errors.New("plugin entrypoint is missing or not executable")
 )
 
 const (
@@ -45,6 +52,9 @@ type PluginLoader struct {
 	loadLogger hclog.Logger
 	path       string // path to the plugins directory
 	manifests  *Manifests
+	signatures signatures
+	attester   *attestation.Client // remote attestation service consulted last, nil when none is configured
+	layout     Layout
 }
 
 // NewPluginLoader initializes a new PluginLoader for managing plugins in the specified directory path.
@@ -56,10 +66,34 @@ func NewPluginLoader(path string, loadLogger hclog.Logger) (*PluginLoader, error
 		loadLogger: loadLogger,
 		path:       path,
 		manifests:  NewManifests(),
+		layout:     DefaultLayout,
 	}
 	return loader, nil
 }
 
+// WithTrustStore verifies the detached signature of every plugin binary against trustStore when it is loaded,
+// rejecting plugins that are badly signed, or unsigned when their manifest sets security.signature_required or
+// requireAll is set, and returns the updated PluginLoader. A nil trustStore rejects every plugin required to be signed.
+func (pl *PluginLoader) WithTrustStore(trustStore *signature.TrustStore, requireAll bool) *PluginLoader {
+	pl.signatures = signatures{trustStore: trustStore, requireAll: requireAll}
+	return pl
+}
+
+// WithAttestation asks attester whether every plugin binary that passed checksum, signature, and provenance
+// verification may be used, rejecting those it does not attest with PluginNotAttested, and returns the updated
+// PluginLoader.
+func (pl *PluginLoader) WithAttestation(attester *attestation.Client) *PluginLoader {
+	pl.attester = attester
+	return pl
+}
+
+// WithLayout checks every plugin directory against layout, instead of DefaultLayout, before its binary is verified,
+// and returns the updated PluginLoader.
+func (pl *PluginLoader) WithLayout(layout Layout) *PluginLoader {
+	pl.layout = layout
+	return pl
+}
+
 // Load discovers, parses, and loads plugin manifests from the specified directory, returning manifests and load errors.
 func (pl *PluginLoader) Load() (*Manifests, LoaderErrors) {
 	// Initialize a LoaderErrors map to store errors that occurred during plugin loading
@@ -116,17 +150,46 @@ func (pl *PluginLoader) Load() (*Manifests, LoaderErrors) {
 				// if there is an error getting the absolute path, try to use the relative path instead
 				absPluginRoot = filepath.Join(pl.path, path)
 			}
-			manifest, entrypoint, hash, err := LoadManifest(absPluginRoot, ManifestFileName)
+			manifest, entrypoint, hash, err := LoadPluginManifest(absPluginRoot)
+			// a missing or non-executable binary is reported by the layout check below
+			if errors.Is(err, ErrEntrypoint) {
+				err = nil
+			}
 			if err != nil {
 				pl.loadLogger.Error("Failed to load manifest", logger.KeyError, err)
 				// if there is an error loading the manifest, Add it to the LoaderErrors map
 				lErrs.add(absPluginRoot, err)
-				// Add the manifest to the manifests map (nil/"") to indicate that the manifest is invalid/missing
-				// this allows observability for improperly "installed" plugins
-				pl.manifests.Add(absPluginRoot, NewManifestEntry(manifest, entrypoint, hash))
 			}
-			// Add the manifest to the manifest entry map
-			pl.manifests.Add(absPluginRoot, NewManifestEntry(manifest, entrypoint, hash))
+			// an invalid/missing manifest is still added (nil/"") to allow observability for improperly "installed"
+			// plugins; a parsed one is validated, and a valid one has its binary verified against its checksum
+			entry := NewManifestEntry(manifest, entrypoint, hash)
+			if err != nil {
+				entry.state, entry.err = PluginInvalidManifest, err
+				if errors.Is(err, fs.ErrNotExist) {
+					entry.state = PluginMissingManifest
+				}
+			} else {
+				if errs := ValidateManifest(manifest); len(errs) > 0 {
+					entry.state, entry.err = PluginInvalidManifest, errors.Join(errs...)
+					pl.loadLogger.Error("Invalid manifest", "dir", absPluginRoot, logger.KeyError, entry.err)
+				} else if entry.state, entry.err = pl.checkLayout(absPluginRoot, manifest); !entry.Rejected() {
+					entry.state, entry.err = pl.verify(absPluginRoot, entrypoint)
+				}
+				var signer string
+				if !entry.Rejected() {
+					signer = pl.verifySignature(entry, manifest.Security.SignatureRequired)
+				}
+				if !entry.Rejected() {
+					pl.verifyProvenance(entry, absPluginRoot)
+				}
+				if !entry.Rejected() {
+					pl.verifyAttestation(entry, signer)
+				}
+				if entry.Rejected() {
+					lErrs.add(absPluginRoot, entry.err)
+				}
+			}
+			pl.manifests.Add(absPluginRoot, entry)
 		}
 		return nil
 	})
@@ -140,6 +203,109 @@ func (pl *PluginLoader) Load() (*Manifests, LoaderErrors) {
 	return pl.manifests, lErrs
 }
 
+// checkLayout checks the plugin directory dir against the loader's layout. It returns the state of the first problem
+// that rejects the plugin with every such problem joined, warning about unexpected files unless the layout is strict.
+func (pl *PluginLoader) checkLayout(dir string, m *Manifest) (PluginState, error) {
+	manifestName, err := FindManifest(dir)
+	if err != nil {
+		return PluginInvalidManifest, err
+	}
+	state := PluginDirectoryValidated
+	var rejected []error
+	for _, err := range ValidateLayout(dir, manifestName, m, pl.layout) {
+		var le *LayoutError
+		if errors.As(err, &le) && le.State == PluginUnexpectedFile && !pl.layout.Strict {
+			pl.loadLogger.Warn("Unexpected file in plugin directory", "dir", dir, "file", le.File)
+			continue
+		}
+		if len(rejected) == 0 && le != nil {
+			state = le.State
+		}
+		rejected = append(rejected, err)
+	}
+	if len(rejected) > 0 {
+		err := errors.Join(rejected...)
+		pl.loadLogger.Error("Plugin directory does not match its layout", "dir", dir, "state", state.String(),
+			logger.KeyError, err)
+		return state, err
+	}
+	return state, nil
+}
+
+// verify checks the plugin binary at entrypoint against the checksum file in dir, returning PluginAvailable if they
+// match and PluginBadChecksum if they do not. A missing or unreadable checksum file leaves the plugin unverified, with
+// PluginStateUnknown, for the launch to report.
+func (pl *PluginLoader) verify(dir, entrypoint string) (PluginState, error) {
+	cs, err := checksum.NewFile(dir)
+	if err == nil {
+		err = cs.Parse()
+	}
+	if err != nil {
+		pl.loadLogger.Warn("Plugin binary not verified", "dir", dir, logger.KeyError, err)
+		return PluginStateUnknown, err
+	}
+	err = checksum.VerifyBinary(filepath.Join(dir, cs.CSFileName()), entrypoint)
+	switch {
+	case errors.Is(err, checksum.ErrChecksumMismatch):
+		pl.loadLogger.Error("Plugin binary does not match its checksum", "dir", dir, logger.KeyError, err)
+		return PluginBadChecksum, err
+	case err != nil:
+		pl.loadLogger.Warn("Plugin binary not verified", "dir", dir, logger.KeyError, err)
+		return PluginStateUnknown, err
+	}
+	return PluginAvailable, nil
+}
+
+// verifySignature checks the signature of the entry's binary, leaving the entry in PluginUnsigned or
+// PluginBadSignature if it is rejected, and returns the trusted key that signed it, if any.
+func (pl *PluginLoader) verifySignature(entry *ManifestEntry, required bool) string {
+	state, signer, err := pl.signatures.verify(entry.entrypoint, required)
+	if err != nil {
+		pl.loadLogger.Error("Plugin signature verification failed", "entrypoint", entry.entrypoint,
+			"state", state.String(), logger.KeyError, err)
+		entry.state, entry.err = state, err
+		return ""
+	}
+	if signer != "" {
+		pl.loadLogger.Debug("Plugin signature verified", "entrypoint", entry.entrypoint, "signer", signer)
+	}
+	return signer
+}
+
+// verifyAttestation asks the attestation service about the entry's binary, leaving the entry in PluginNotAttested
+// if it is rejected.
+func (pl *PluginLoader) verifyAttestation(entry *ManifestEntry, signer string) {
+	if pl.attester == nil {
+		return
+	}
+	data := entry.entry.PluginData
+	if state, err := attest(pl.attester, data.Name, data.Version, entry.entrypoint, signer); err != nil {
+		pl.loadLogger.Error("Plugin attestation failed", "entrypoint", entry.entrypoint, logger.KeyError, err)
+		entry.state, entry.err = state, err
+		return
+	}
+	pl.loadLogger.Debug("Plugin attested", "entrypoint", entry.entrypoint)
+}
+
+// verifyProvenance loads the provenance file in dir, if any, and checks it against the entry's manifest and binary,
+// leaving the entry in PluginBadProvenance if it is unreadable or inconsistent.
+func (pl *PluginLoader) verifyProvenance(entry *ManifestEntry, dir string) {
+	prov, err := LoadProvenance(dir)
+	if err == nil && prov != nil {
+		err = errors.Join(prov.Validate(entry.entry, entry.entrypoint)...)
+	}
+	if err != nil {
+		pl.loadLogger.Error("Plugin provenance verification failed", "dir", dir, logger.KeyError, err)
+		entry.state, entry.err = PluginBadProvenance, err
+		return
+	}
+	entry.provenance = prov
+	if prov != nil {
+		pl.loadLogger.Debug("Plugin provenance verified", "dir", dir, "builder", prov.Builder,
+			"source_repo", prov.SourceRepo, "commit", prov.Commit)
+	}
+}
+
 // GetManifests returns a reference to the loaded plugin manifests managed by the PluginLoader.
 func (pl *PluginLoader) GetManifests() *Manifests {
 	return pl.manifests
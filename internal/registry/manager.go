@@ -0,0 +1,647 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/attestation"
+	"github.com/bmj2728/PlugsConc/internal/checksum"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/replay"
+	"github.com/bmj2728/PlugsConc/internal/signature"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// ErrPluginNotFound indicates that the catalog has no launch details for the requested plugin.
+// ErrPluginRunning indicates that a plugin cannot be started because it is already running.
+// ErrPluginNotRunning indicates that a plugin cannot be used or stopped because it is not running.
+// ErrPluginDisabled indicates that a plugin cannot be started because it is disabled pending review.
+// ErrChecksumMismatch indicates that a plugin binary does not match its checksum file.
+// ErrInvalidLaunchDetails indicates that launch details could not be built from a plugin's manifest.
+var (
+	ErrPluginNotFound       = errors.New("plugin not found")
+	ErrPluginRunning        = errors.New("plugin already running")
+	ErrPluginNotRunning     = errors.New("plugin not running")
+	ErrPluginDisabled       = errors.New("plugin disabled pending review")
+	ErrChecksumMismatch     = checksum.ErrChecksumMismatch
+	ErrInvalidLaunchDetails = errors.New("invalid launch details")
+)
+
+// DefaultSuperviseInterval is how often Supervise checks the health of running plugins.
+const DefaultSuperviseInterval = 5 * time.Second
+
+// PluginStatus is a point-in-time, serializable view of a managed plugin.
+type PluginStatus struct {
+	Name       string      `json:"name" yaml:"name"`
+	State      PluginState `json:"state" yaml:"state"`
+	StateName  string      `json:"state_name" yaml:"state_name"`
+	Protocol   string      `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	StartedAt  time.Time   `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+	Restarts   int         `json:"restarts" yaml:"restarts"`
+	APIVersion string      `json:"api_version,omitempty" yaml:"api_version,omitempty"` // reported on first dispense
+	LastError  string      `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+}
+
+// managedPlugin is the lifecycle state of a single plugin. Fields are guarded by the manager's lock.
+type managedPlugin struct {
+	client    *plugin.Client
+	state     PluginState
+	startedAt time.Time
+	restarts  int
+	lastErr   error
+	failures  int       // consecutive failed health checks
+	nextCheck time.Time // when Supervise next runs the health check
+
+	crashes     int
+	lastCrash   time.Time
+	crashLoop   int       // restarts in a row since the plugin last stayed up for the restart policy's ResetAfter
+	nextRestart time.Time // when the crashed plugin is restarted, zero when no restart is scheduled
+	quarantined bool      // crash loop exhausted the restart policy; Start refuses the plugin until Unquarantine
+
+	files fingerprint // plugin files the running process was launched from, zero when unknown
+	api   apiCheck    // shared API version check, run on the first dispense after each launch
+
+	replay     any              // client interface answered from a recording, nil unless replayed
+	replayConn *grpc.ClientConn // never-dialed connection behind replay
+}
+
+// PluginManager owns the lifecycle of the plugins in a PluginCatalog: it launches them from their
+// PluginLaunchDetails after verifying their checksum, checks their health, shuts them down, and restarts them,
+// recording every transition as a PluginState.
+type PluginManager struct {
+	mu             sync.RWMutex
+	managerLogger  hclog.Logger
+	catalog        *PluginCatalog
+	plugins        map[string]*managedPlugin
+	flap           *FlapDetector                  // optional crash and restart tracking, nil when not configured
+	compat         *CompatibilityMatrix           // optional tested plugin and host versions, nil when not configured
+	runtime        *RuntimeDirs                   // optional per-plugin temp and cache dirs, nil when not configured
+	restartPolicy  RestartPolicy                  // backoff and quarantine of crashed plugins
+	recorder       *replay.Recorder               // optional recording of gRPC plugin calls, nil when not configured
+	replayer       *replay.Replayer               // optional replay of recorded calls instead of launching plugins
+	signatures     signatures                     // signature verification of plugin binaries before each launch
+	attester       *attestation.Client            // optional remote attestation of plugin binaries before each launch
+	clientLogger   func(name string) hclog.Logger // builds the go-plugin client logger for a plugin
+	dialOptions    []grpc.DialOption
+	reloadDebounce time.Duration       // how long WatchAndReload waits for files to settle, DefaultReloadDebounce when 0
+	reconciler     *reconciler         // runs reloads as jobs on a worker pool, nil to reload on the watcher goroutine
+	calls          callStats           // durations and failures of the calls made through Call
+	groups         map[string][]string // plugins started and stopped together, by group name
+	output         *outputCollection   // optional collection of plugin stdout and stderr, nil when not configured
+}
+
+// NewPluginManager creates a PluginManager for the plugins in catalog, whose queries then report the live state of
+// the plugins it starts.
+func NewPluginManager(catalog *PluginCatalog, managerLogger hclog.Logger) *PluginManager {
+	if managerLogger == nil {
+		managerLogger = hclog.Default()
+	}
+	pm := &PluginManager{
+		managerLogger: managerLogger,
+		catalog:       catalog,
+		plugins:       make(map[string]*managedPlugin),
+		clientLogger:  managerLogger.Named,
+	}
+	catalog.trackStates(pm.liveState)
+	return pm
+}
+
+// WithFlapDetector records crashes and restarts in flap and refuses to start plugins it has disabled, and returns
+// the updated PluginManager.
+func (pm *PluginManager) WithFlapDetector(flap *FlapDetector) *PluginManager {
+	pm.flap = flap
+	return pm
+}
+
+// WithTrustStore verifies the detached signature of a plugin's binary against trustStore before every launch,
+// refusing plugins that are badly signed, or unsigned when their manifest sets security.signature_required or
+// requireAll is set, and returns the updated PluginManager. It should match the PluginLoader's trust store, so a
+// binary replaced after loading is held to the same rules.
+func (pm *PluginManager) WithTrustStore(trustStore *signature.TrustStore, requireAll bool) *PluginManager {
+	pm.signatures = signatures{trustStore: trustStore, requireAll: requireAll}
+	return pm
+}
+
+// WithAttestation asks attester whether a plugin's binary may be used before every launch, after its checksum and
+// signature are verified, refusing plugins it does not attest with PluginNotAttested, and returns the updated
+// PluginManager. It should match the PluginLoader's attestation client.
+func (pm *PluginManager) WithAttestation(attester *attestation.Client) *PluginManager {
+	pm.attester = attester
+	return pm
+}
+
+// WithCompatibilityMatrix records every successful start in compat and warns before launching a plugin version that
+// has never run against this host version, and returns the updated PluginManager.
+func (pm *PluginManager) WithCompatibilityMatrix(compat *CompatibilityMatrix) *PluginManager {
+	pm.compat = compat
+	return pm
+}
+
+// WithRuntimeDirs launches every plugin with TMPDIR and the XDG base directories pointing at its own directories in
+// runtime, and returns the updated PluginManager.
+func (pm *PluginManager) WithRuntimeDirs(runtime *RuntimeDirs) *PluginManager {
+	pm.runtime = runtime
+	return pm
+}
+
+// WithClientLogger sets the function that builds the go-plugin client logger for each plugin, e.g. one that
+// registers it in a logger.LevelRegistry, and returns the updated PluginManager.
+func (pm *PluginManager) WithClientLogger(clientLogger func(name string) hclog.Logger) *PluginManager {
+	pm.clientLogger = clientLogger
+	return pm
+}
+
+// WithOutputCollection collects the stdout and stderr of every plugin process the manager launches, re-emitting each
+// line through the plugin's client logger tagged with the plugin name, process ID, and stream, as a
+// logger.PluginOutput does, and returns the updated PluginManager. go-plugin no longer logs the plugin's stderr
+// itself, so lines are not logged twice. With async non-nil, the tagged records are written to it as JSON lines
+// instead, e.g. to a logger.AsyncWriter.
+func (pm *PluginManager) WithOutputCollection(async io.Writer) *PluginManager {
+	pm.output = &outputCollection{async: async}
+	return pm
+}
+
+// WithGRPCDialOptions adds dial options used for the connection to gRPC plugins and returns the updated
+// PluginManager.
+func (pm *PluginManager) WithGRPCDialOptions(opts ...grpc.DialOption) *PluginManager {
+	pm.dialOptions = append(pm.dialOptions, opts...)
+	return pm
+}
+
+// launchDetails returns the catalog's launch details for the named plugin.
+func (pm *PluginManager) launchDetails(name string) (*PluginLaunchDetails, error) {
+	for _, ld := range pm.catalog.GetLaunchDetails() {
+		if ld.Name() == name {
+			return ld, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrPluginNotFound, name)
+}
+
+// entry returns the lifecycle state of the named plugin, creating it when missing. The caller must hold the lock.
+func (pm *PluginManager) entry(name string) *managedPlugin {
+	mp, ok := pm.plugins[name]
+	if !ok {
+		mp = &managedPlugin{state: PluginAvailable}
+		pm.plugins[name] = mp
+	}
+	return mp
+}
+
+// Start verifies the named plugin's checksum, launches it, completes the handshake, and runs its warm-up hook before
+// marking it running. A plugin pinned to another version than its manifest declares is refused with
+// ErrPinnedVersion.
+func (pm *PluginManager) Start(name string) error {
+	ld, err := pm.launchDetails(name)
+	if err != nil {
+		return err
+	}
+	if err := pm.catalog.checkPin(name, ld.Version); err != nil {
+		return err
+	}
+	pluginType := pm.catalog.GetPlugin(name)
+	if pluginType == nil {
+		return fmt.Errorf("%w: %q has no registered plugin type", ErrPluginNotFound, name)
+	}
+	if pm.replayer != nil {
+		return pm.startReplay(name, pluginType)
+	}
+	if pm.flap != nil && pm.flap.IsDisabled(name) {
+		pm.setState(name, PluginDisabledPendingReview, nil)
+		return fmt.Errorf("%w: %q", ErrPluginDisabled, name)
+	}
+
+	pm.mu.Lock()
+	mp := pm.entry(name)
+	if mp.quarantined {
+		pm.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrPluginQuarantined, name)
+	}
+	if mp.state == PluginLaunching || mp.state == PluginWarmingUp || mp.state == PluginRunning {
+		pm.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrPluginRunning, name)
+	}
+	mp.state = PluginLaunching
+	pm.mu.Unlock()
+
+	var files fingerprint
+	var client *plugin.Client
+	if ld.InProcess {
+		client, err = pm.newInProcessClient(name, ld, pluginType)
+		if err != nil {
+			pm.setState(name, PluginFailedToLaunch, err)
+			pm.managerLogger.Error("Failed to launch plugin", logger.KeyPluginName, name, logger.KeyError, err)
+			return err
+		}
+	} else {
+		if pm.compat != nil {
+			pm.compat.check(name, ld.Version)
+		}
+		// read before verifying, so files replaced during the launch count as changed
+		if fp, err := readFingerprint(ld); err == nil {
+			files = fp
+			files.version = ld.Version
+		}
+		secConf, state, err := secureConfig(ld)
+		if err != nil {
+			pm.setState(name, state, err)
+			pm.managerLogger.Error("Plugin checksum verification failed", logger.KeyPluginName, name,
+				logger.KeyError, err)
+			return err
+		}
+		state, signer, err := pm.signatures.verify(ld.Entrypoint().Path, ld.SignatureRequired)
+		if err != nil {
+			pm.setState(name, state, err)
+			pm.managerLogger.Error("Plugin signature verification failed", logger.KeyPluginName, name,
+				logger.KeyError, err)
+			return err
+		}
+		if state, err := attest(pm.attester, name, ld.Version, ld.Entrypoint().Path, signer); err != nil {
+			pm.setState(name, state, err)
+			pm.managerLogger.Error("Plugin attestation failed", logger.KeyPluginName, name, logger.KeyError, err)
+			return err
+		}
+		client, err = pm.newClient(name, ld, pluginType, secConf)
+		if err != nil {
+			pm.setState(name, PluginFailedToLaunch, err)
+			pm.managerLogger.Error("Failed to prepare plugin runtime directories", logger.KeyPluginName, name,
+				logger.KeyError, err)
+			return err
+		}
+	}
+	if _, err := client.Client(); err != nil {
+		client.Kill()
+		pm.setState(name, PluginFailedToLaunch, err)
+		pm.managerLogger.Error("Failed to launch plugin", logger.KeyPluginName, name, logger.KeyError, err)
+		return err
+	}
+
+	pm.setState(name, PluginWarmingUp, nil)
+	warmupStart := time.Now()
+	if err := ld.Warmup.warmup(client, name); err != nil {
+		client.Kill()
+		pm.setState(name, PluginFailedToWarmUp, err)
+		pm.managerLogger.Error("Plugin warm-up failed", logger.KeyPluginName, name, logger.KeyError, err)
+		return err
+	}
+	pm.managerLogger.Debug("Plugin warmed up", logger.KeyPluginName, name, "duration", time.Since(warmupStart).String())
+
+	pm.mu.Lock()
+	mp.client = client
+	mp.state = PluginRunning
+	mp.startedAt = time.Now()
+	mp.lastErr = nil
+	mp.failures = 0
+	mp.nextCheck = time.Time{}
+	mp.nextRestart = time.Time{}
+	mp.files = files
+	mp.api = apiCheck{}
+	pm.mu.Unlock()
+	pm.managerLogger.Info("Plugin started", logger.KeyPluginName, name, "protocol", client.Protocol())
+	if pm.compat != nil && !ld.InProcess {
+		if err := pm.compat.Record(name, ld.Version); err != nil {
+			pm.managerLogger.Warn("Failed to record plugin compatibility", logger.KeyPluginName, name, logger.KeyError, err)
+		}
+	}
+	return nil
+}
+
+// newClient builds the go-plugin client that launches the named plugin from its launch details, preparing its
+// runtime directories when the manager has them. A gRPC plugin is served its host services over the broker once
+// dispensed.
+func (pm *PluginManager) newClient(name string, ld *PluginLaunchDetails, pluginType plugin.Plugin,
+	secConf *plugin.SecureConfig) (*plugin.Client, error) {
+	cmd := freshCmd(ld.Entrypoint())
+	skipHostEnv := false
+	if pm.runtime != nil {
+		env, err := pm.runtime.Prepare(name)
+		if err != nil {
+			return nil, err
+		}
+		// go-plugin appends the host environment after cmd.Env, where it would override the runtime directories, so
+		// the host environment is added here instead, ahead of them
+		cmd.Env = append(append(os.Environ(), cmd.Env...), env...)
+		skipHostEnv = true
+	}
+	config := &plugin.ClientConfig{
+		HandshakeConfig:  *ld.Handshake(),
+		Plugins:          map[string]plugin.Plugin{name: pm.withHostServices(name, pluginType)},
+		Cmd:              cmd,
+		AllowedProtocols: ld.PluginAllowedProtocols(),
+		AutoMTLS:         ld.AutoMTLS,
+		SecureConfig:     secConf,
+		Logger:           pm.clientLogger(name),
+		GRPCDialOptions:  pm.grpcDialOptions(name),
+		SkipHostEnv:      skipHostEnv,
+	}
+	pm.output.attach(name, config)
+	return plugin.NewClient(config), nil
+}
+
+// Stop shuts down the named plugin, gracefully if possible.
+func (pm *PluginManager) Stop(name string) error {
+	if pm.stopReplay(name) {
+		return nil
+	}
+	pm.mu.Lock()
+	mp, ok := pm.plugins[name]
+	if !ok || mp.client == nil {
+		pm.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrPluginNotRunning, name)
+	}
+	client := mp.client
+	mp.client = nil
+	pm.mu.Unlock()
+
+	client.Kill()
+	if !client.Exited() {
+		err := fmt.Errorf("%w: %q", ErrPluginRunning, name)
+		pm.setState(name, PluginFailedToStop, err)
+		return err
+	}
+	pm.setState(name, PluginStopped, nil)
+	pm.managerLogger.Info("Plugin stopped", logger.KeyPluginName, name)
+	return nil
+}
+
+// Restart stops the named plugin if it is running and starts it again, recording the restart with the flap
+// detector.
+func (pm *PluginManager) Restart(name string) error {
+	if err := pm.Stop(name); err != nil && !errors.Is(err, ErrPluginNotRunning) {
+		return err
+	}
+	pm.mu.Lock()
+	pm.entry(name).restarts++
+	pm.mu.Unlock()
+	if pm.flap != nil && pm.flap.RecordRestart(name) == PluginDisabledPendingReview {
+		pm.setState(name, PluginDisabledPendingReview, nil)
+		return fmt.Errorf("%w: %q", ErrPluginDisabled, name)
+	}
+	return pm.Start(name)
+}
+
+// StopAll shuts down every running plugin.
+func (pm *PluginManager) StopAll() {
+	for _, name := range pm.names() {
+		if err := pm.Stop(name); err != nil && !errors.Is(err, ErrPluginNotRunning) {
+			pm.managerLogger.Error("Failed to stop plugin", logger.KeyPluginName, name, logger.KeyError, err)
+		}
+	}
+}
+
+// Dispense returns the interface implementation served by the named running plugin. A gRPC plugin is first checked
+// to have been built against a compatible shared API version, failing with ErrIncompatibleAPI otherwise.
+func (pm *PluginManager) Dispense(name string) (any, error) {
+	if impl, ok := pm.replayed(name); ok {
+		return impl, nil
+	}
+	client, err := pm.client(name)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := client.Client()
+	if err != nil {
+		return nil, err
+	}
+	if err := pm.checkAPI(name, rpcClient); err != nil {
+		return nil, err
+	}
+	return rpcClient.Dispense(name)
+}
+
+// Health runs the named plugin's manifest-declared health check, pinging it when none is declared. A plugin whose
+// process has exited, or that fails its check the declared number of consecutive times, is killed, marked
+// PluginStoppedUnexpectedly, and the crash is recorded with the flap detector.
+func (pm *PluginManager) Health(name string) error {
+	if _, ok := pm.replayed(name); ok {
+		return nil
+	}
+	client, err := pm.client(name)
+	if err != nil {
+		return err
+	}
+	if client.Exited() {
+		err := fmt.Errorf("%w: %q exited", ErrPluginNotRunning, name)
+		pm.crashed(name, err)
+		return err
+	}
+	ld, err := pm.launchDetails(name)
+	if err != nil {
+		return err
+	}
+	hc := ld.HealthCheck
+	var dir string
+	if ld.Entrypoint() != nil {
+		dir = filepath.Dir(ld.Entrypoint().Path)
+	}
+	err = hc.check(client, dir)
+
+	pm.mu.Lock()
+	mp := pm.entry(name)
+	if err == nil {
+		mp.failures = 0
+		if mp.crashLoop > 0 && time.Since(mp.startedAt) >= pm.restartPolicy.resetAfter() {
+			mp.crashLoop = 0
+		}
+		pm.mu.Unlock()
+		return nil
+	}
+	mp.failures++
+	failures := mp.failures
+	mp.lastErr = err
+	pm.mu.Unlock()
+	if failures < hc.threshold() {
+		pm.managerLogger.Warn("Plugin health check failed", logger.KeyPluginName, name, "failures", failures,
+			logger.KeyError, err)
+		return err
+	}
+	client.Kill()
+	pm.crashed(name, err)
+	return err
+}
+
+// Status returns the lifecycle status of the named plugin.
+func (pm *PluginManager) Status(name string) (PluginStatus, error) {
+	if _, err := pm.launchDetails(name); err != nil {
+		return PluginStatus{}, err
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok {
+		return PluginStatus{Name: name, State: PluginAvailable, StateName: PluginAvailable.String()}, nil
+	}
+	status := PluginStatus{
+		Name:      name,
+		State:     mp.state,
+		StateName: mp.state.String(),
+		StartedAt: mp.startedAt,
+		Restarts:  mp.restarts,
+	}
+	status.APIVersion = mp.api.version
+	if mp.client != nil {
+		status.Protocol = string(mp.client.Protocol())
+	}
+	if mp.replayConn != nil {
+		status.Protocol = string(plugin.ProtocolGRPC)
+	}
+	if mp.lastErr != nil {
+		status.LastError = mp.lastErr.Error()
+	}
+	return status, nil
+}
+
+// Statuses returns the status of every plugin in the catalog, sorted by name.
+func (pm *PluginManager) Statuses() []PluginStatus {
+	statuses := make([]PluginStatus, 0)
+	for _, ld := range pm.catalog.GetLaunchDetails() {
+		if status, err := pm.Status(ld.Name()); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Supervise checks the health of every running plugin each interval until ctx is canceled, restarting plugins
+// whose process exited or that failed their health check too many times in a row with the backoff of the manager's
+// RestartPolicy, unless the flap detector has disabled them or they were quarantined. A plugin declaring a longer
+// health check interval is checked only once it is due; shorter intervals are rounded up to the supervise interval.
+// Running plugins are stopped on return.
+func (pm *PluginManager) Supervise(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSuperviseInterval
+	}
+	defer pm.StopAll()
+	NewHealthChecker(pm, interval, pm.managerLogger).Run(ctx)
+}
+
+// healthDue reports whether the named plugin is running and its health check is due, scheduling the next check.
+func (pm *PluginManager) healthDue(name string) bool {
+	var interval time.Duration
+	if ld, err := pm.launchDetails(name); err == nil {
+		interval = ld.HealthCheck.interval()
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	mp := pm.plugins[name]
+	now := time.Now()
+	if mp.state != PluginRunning || now.Before(mp.nextCheck) {
+		return false
+	}
+	mp.nextCheck = now.Add(interval)
+	return true
+}
+
+// client returns the go-plugin client of the named running plugin.
+func (pm *PluginManager) client(name string) (*plugin.Client, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok || mp.client == nil {
+		return nil, fmt.Errorf("%w: %q", ErrPluginNotRunning, name)
+	}
+	return mp.client, nil
+}
+
+// crashed marks the named plugin as stopped unexpectedly, counts the crash, and records it with the flap detector.
+func (pm *PluginManager) crashed(name string, err error) {
+	pm.mu.Lock()
+	mp := pm.entry(name)
+	mp.client = nil
+	mp.crashes++
+	mp.lastCrash = time.Now()
+	pm.mu.Unlock()
+	state := PluginStoppedUnexpectedly
+	if pm.flap != nil && pm.flap.RecordCrash(name) == PluginDisabledPendingReview {
+		state = PluginDisabledPendingReview
+	}
+	pm.setState(name, state, err)
+	pm.managerLogger.Warn("Plugin stopped unexpectedly", logger.KeyPluginName, name, logger.KeyError, err)
+}
+
+// setState records a state transition for the named plugin along with the error that caused it, if any.
+func (pm *PluginManager) setState(name string, state PluginState, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	mp := pm.entry(name)
+	mp.state = state
+	if err != nil {
+		mp.lastErr = err
+	}
+}
+
+// state returns the state of the named plugin, PluginAvailable when it has never been started.
+func (pm *PluginManager) state(name string) PluginState {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if mp, ok := pm.plugins[name]; ok {
+		return mp.state
+	}
+	return PluginAvailable
+}
+
+// failures returns the number of consecutive failed health checks of the named plugin.
+func (pm *PluginManager) failures(name string) int {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if mp, ok := pm.plugins[name]; ok {
+		return mp.failures
+	}
+	return 0
+}
+
+// names returns the names of the plugins the manager has started at least once.
+func (pm *PluginManager) names() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	names := make([]string, 0, len(pm.plugins))
+	for name := range pm.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// secureConfig verifies the plugin binary against the checksum file next to it, returning the SecureConfig for the
+// launch or the PluginState describing the failure.
+func secureConfig(ld *PluginLaunchDetails) (*plugin.SecureConfig, PluginState, error) {
+	cmd := ld.Entrypoint()
+	if cmd == nil {
+		return nil, PluginInvalidLaunchDetails, fmt.Errorf("%w: missing entrypoint", ErrPluginNotFound)
+	}
+	cs, err := checksum.NewFile(filepath.Dir(cmd.Path))
+	if err != nil {
+		return nil, PluginMissingChecksum, err
+	}
+	if err := cs.Parse(); err != nil {
+		return nil, PluginInvalidChecksum, err
+	}
+	if !cs.Compare() {
+		return nil, PluginBadChecksum, ErrChecksumMismatch
+	}
+	secConf, err := cs.SecConf()
+	if err != nil {
+		return nil, PluginInvalidChecksum, err
+	}
+	return secConf, PluginLaunching, nil
+}
+
+// freshCmd copies cmd so the plugin can be launched again; an exec.Cmd can only be started once.
+func freshCmd(cmd *exec.Cmd) *exec.Cmd {
+	fresh := exec.Command(cmd.Path)
+	fresh.Args = cmd.Args
+	fresh.Env = cmd.Env
+	fresh.Dir = cmd.Dir
+	return fresh
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/bmj2728/PlugsConc/internal/logger"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
-	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -30,6 +29,8 @@ type Manifest struct {
 	Handshake    Handshake               `json:"handshake" yaml:"handshake"`
 	Security     Security                `json:"security" yaml:"security"`
 	Capabilities capability.Capabilities `json:"capabilities" yaml:"capabilities"`
+	HealthCheck  *HealthCheck            `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+	Warmup       *Warmup                 `json:"warmup,omitempty" yaml:"warmup,omitempty"`
 }
 
 type PluginData struct {
@@ -55,13 +56,34 @@ type Handshake struct {
 }
 
 // Security represents configuration related to security features, including automatic mutual TLS (Transport Layer Security).
+// SignatureRequired rejects the plugin unless its binary has a detached signature, plugin.sig, made by a key in the
+// host's trust store.
 type Security struct {
-	AutoMTLS bool `json:"auto_mtls" yaml:"auto_mtls"`
+	AutoMTLS          bool `json:"auto_mtls" yaml:"auto_mtls"`
+	SignatureRequired bool `json:"signature_required" yaml:"signature_required"`
+}
+
+// LoadPluginManifest finds the manifest in the plugin directory root, in any of the supported formats, and loads it
+// as LoadManifest does.
+func LoadPluginManifest(root string) (m *Manifest, entrypoint string, hash string, err error) {
+	path, err := FindManifest(root)
+	if err != nil {
+		hclog.Default().Error("Failed to find manifest", logger.KeyError, err)
+		return nil, "", "", err
+	}
+	return LoadManifest(root, path)
 }
 
 // LoadManifest reads and parses a manifest file at the specified path, returning the parsed Manifest,
-// its hash, and any error.
+// its hash, and any error. The file is decoded as YAML, JSON, or TOML according to its extension. When the entrypoint
+// it names is missing or not executable, the manifest is returned along with an error wrapping ErrEntrypoint.
 func LoadManifest(root, path string) (m *Manifest, entrypoint string, hash string, err error) {
+	format, err := ManifestFormatOf(path)
+	if err != nil {
+		hclog.Default().Error("Failed to load manifest", logger.KeyError, err)
+		return nil, "", "", err
+	}
+
 	r, err := os.OpenRoot(root)
 	if err != nil {
 		err := errors.Join(ErrLoadingFS, err)
@@ -87,9 +109,9 @@ func LoadManifest(root, path string) (m *Manifest, entrypoint string, hash strin
 
 	hash = getMD5Hash(f)
 
-	if err := yaml.Unmarshal(f, &m); err != nil {
-		err := errors.Join(ErrYAMLUnmarshaling, err)
-		hclog.Default().Error("Failed to unmarshall manifest", logger.KeyError, err)
+	m, err = decodeManifest(f, format)
+	if err != nil {
+		hclog.Default().Error("Failed to unmarshall manifest", "format", format, logger.KeyError, err)
 		return nil, "", "", err
 	}
 
@@ -97,7 +119,8 @@ func LoadManifest(root, path string) (m *Manifest, entrypoint string, hash strin
 	_, err = exec.LookPath(entrypoint)
 	if err != nil {
 		hclog.Default().Error("Failed to look up entrypoint", logger.KeyError, err)
-		return nil, "", "", err
+		// the parsed manifest is returned with the error so the directory's layout can still be checked
+		return m, entrypoint, hash, errors.Join(ErrEntrypoint, err)
 	}
 
 	return m, entrypoint, hash, nil
@@ -112,6 +135,7 @@ func getMD5Hash(data []byte) string {
 func (m *Manifest) ToLaunchDetails() *PluginLaunchDetails {
 	var ld PluginLaunchDetails
 	ld.PluginName = m.PluginData.Name
+	ld.Version = m.PluginData.Version
 	hc, err := m.Handshake.ToConfig()
 	if err != nil {
 		hclog.Default().Error("Failed to load plugin launch details", logger.KeyError, err)
@@ -125,6 +149,13 @@ func (m *Manifest) ToLaunchDetails() *PluginLaunchDetails {
 		ld.AllowedProtocols = pf
 	}
 	ld.AutoMTLS = m.Security.AutoMTLS
+	ld.SignatureRequired = m.Security.SignatureRequired
+	if err := m.HealthCheck.Validate(); err != nil {
+		hclog.Default().Error("Failed to load plugin launch details", logger.KeyError, err)
+		return nil
+	}
+	ld.HealthCheck = m.HealthCheck
+	ld.Warmup = m.Warmup
 	return &ld
 }
 
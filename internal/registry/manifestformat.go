@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFormat is the encoding of a manifest file, detected from its extension.
+type ManifestFormat string
+
+// ManifestYAML, ManifestJSON, and ManifestTOML are the supported manifest encodings. Every format has the same schema,
+// with the keys of manifest.example.yaml.
+const (
+	ManifestYAML ManifestFormat = "yaml"
+	ManifestJSON ManifestFormat = "json"
+	ManifestTOML ManifestFormat = "toml"
+)
+
+// ManifestFileNames lists the manifest file names a plugin directory may hold, one per supported format.
+var ManifestFileNames = []string{ManifestFileName, "manifest.json", "manifest.toml"}
+
+var (
+	// ErrAmbiguousManifest indicates that a plugin directory holds manifests in more than one format.
+	ErrAmbiguousManifest = errors.New("plugin directory has more than one manifest")
+	// ErrUnsupportedManifestFormat indicates that a manifest file's extension is not a supported format.
+	ErrUnsupportedManifestFormat = errors.New("unsupported manifest format")
+	// ErrEmptyManifest indicates that a manifest file holds no document.
+	ErrEmptyManifest = errors.New("manifest is empty")
+)
+
+// ManifestFormatOf returns the format of the manifest file at path from its extension: .yaml or .yml, .json, or
+// .toml.
+func ManifestFormatOf(path string) (ManifestFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ManifestYAML, nil
+	case ".json":
+		return ManifestJSON, nil
+	case ".toml":
+		return ManifestTOML, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedManifestFormat, filepath.Base(path))
+}
+
+// FindManifest returns the name of the manifest file in dir, whichever of ManifestFileNames exists. It returns
+// ErrAmbiguousManifest if more than one does, and ManifestFileName if none does, so that reading it reports the
+// manifest missing.
+func FindManifest(dir string) (string, error) {
+	var found []string
+	for _, name := range ManifestFileNames {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return ManifestFileName, nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%w: %s in %s", ErrAmbiguousManifest, strings.Join(found, ", "), dir)
+}
+
+// decodeManifest decodes a manifest in the given format. JSON and TOML documents are decoded into generic values and
+// re-encoded as YAML, so every format is decoded through the same yaml tags and accepts exactly the same keys.
+func decodeManifest(data []byte, format ManifestFormat) (*Manifest, error) {
+	switch format {
+	case ManifestJSON:
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, errors.Join(ErrJSONUnmarshaling, err)
+		}
+		return reencodeManifest(doc, ErrJSONUnmarshaling)
+	case ManifestTOML:
+		var doc map[string]any
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, errors.Join(ErrTOMLUnmarshaling, err)
+		}
+		return reencodeManifest(doc, ErrTOMLUnmarshaling)
+	case ManifestYAML:
+		var m *Manifest
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, errors.Join(ErrYAMLUnmarshaling, err)
+		}
+		if m == nil {
+			return nil, errors.Join(ErrYAMLUnmarshaling, ErrEmptyManifest)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedManifestFormat, format)
+}
+
+// reencodeManifest decodes a generic document through the manifest's yaml tags, reporting failures as errKind.
+func reencodeManifest(doc any, errKind error) (*Manifest, error) {
+	if doc == nil {
+		return nil, errors.Join(errKind, ErrEmptyManifest)
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, errors.Join(errKind, err)
+	}
+	var m *Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, errors.Join(errKind, err)
+	}
+	if m == nil {
+		return nil, errors.Join(errKind, ErrEmptyManifest)
+	}
+	return m, nil
+}
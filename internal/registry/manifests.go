@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"os/exec"
 	"sync"
 )
 
@@ -9,6 +10,9 @@ type ManifestEntry struct {
 	entry      *Manifest
 	entrypoint string
 	hash       string
+	provenance *Provenance
+	state      PluginState
+	err        error
 }
 
 // NewManifestEntry creates a new ManifestEntry instance, associating a manifest with its corresponding hash.
@@ -30,10 +34,57 @@ func (m *ManifestEntry) Hash() string {
 	return m.hash
 }
 
+// Entrypoint returns the absolute path of the plugin binary named by the manifest.
 func (m *ManifestEntry) Entrypoint() string {
 	return m.entrypoint
 }
 
+// Provenance returns the build provenance shipped with the plugin, or nil if it has none.
+func (m *ManifestEntry) Provenance() *Provenance {
+	return m.provenance
+}
+
+// State returns the state the loader left the plugin in: PluginAvailable once its directory matched its layout and
+// its binary matched its checksum and passed signature, provenance, and attestation verification,
+// PluginInvalidManifest, PluginMissingBinary, PluginInvalidBinary, PluginMissingChecksum, PluginUnexpectedFile,
+// PluginBadChecksum, PluginUnsigned, PluginBadSignature, PluginBadProvenance, or PluginNotAttested if it failed, or
+// PluginStateUnknown if the binary could not be verified at load time.
+func (m *ManifestEntry) State() PluginState {
+	return m.state
+}
+
+// Err returns the error behind a failed State, or nil.
+func (m *ManifestEntry) Err() error {
+	return m.err
+}
+
+// Rejected reports whether the loader refused the plugin because its directory layout, manifest, binary,
+// provenance, or attestation failed verification.
+func (m *ManifestEntry) Rejected() bool {
+	switch m.state {
+	case PluginInvalidManifest, PluginMissingBinary, PluginInvalidBinary, PluginMissingChecksum, PluginUnexpectedFile,
+		PluginBadChecksum, PluginUnsigned, PluginBadSignature, PluginBadProvenance, PluginNotAttested:
+		return true
+	}
+	return false
+}
+
+// ToLaunchDetails builds the launch details of the plugin, launching the entrypoint resolved inside the plugin
+// directory rather than looking it up on PATH. It returns nil if the manifest is missing or failed validation, the
+// directory does not match its layout, or the binary failed checksum, signature, or provenance verification.
+func (m *ManifestEntry) ToLaunchDetails() *PluginLaunchDetails {
+	if m.entry == nil || m.Rejected() {
+		return nil
+	}
+	ld := m.entry.ToLaunchDetails()
+	if ld == nil {
+		return nil
+	}
+	ld.Cmd = exec.Command(m.entrypoint)
+	ld.Provenance = m.provenance
+	return ld
+}
+
 // Manifests is a thread-safe structure for managing a collection of ManifestEntry objects with synchronized access.
 type Manifests struct {
 	mu      sync.RWMutex
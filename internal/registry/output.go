@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"io"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// outputCollection re-emits the stdout and stderr of launched plugin processes into the host logger, see
+// PluginManager.WithOutputCollection.
+type outputCollection struct {
+	async io.Writer // JSON lines destination of the collected records, nil to log them directly
+}
+
+// attach routes the stdout and stderr of the plugin launched by config through logger.PluginOutput writers logging
+// to the plugin's client logger, and mutes the logger go-plugin relogs the plugin's stderr with. It does nothing on
+// a nil outputCollection.
+func (oc *outputCollection) attach(name string, config *plugin.ClientConfig) {
+	if oc == nil || config.Cmd == nil {
+		return
+	}
+	pid := processID(config.Cmd)
+	output := func(stream string) io.Writer {
+		return logger.NewPluginOutput(config.Logger, name, stream, pid).WithAsync(oc.async)
+	}
+	// the plugin's logs are written to its stderr pipe, while its os.Stdout and os.Stderr are forwarded over gRPC
+	// once it is served, so each stream has its own writer to keep lines from interleaving
+	config.Stderr = output(logger.StreamStderr)
+	config.SyncStdout = output(logger.StreamStdout)
+	config.SyncStderr = output(logger.StreamStderr)
+	config.Logger = &collectedLogger{Logger: config.Logger, stderr: filepath.Base(config.Cmd.Path)}
+}
+
+// processID returns a function reporting the process ID of cmd, or 0 before it is started. go-plugin starts cmd
+// before it starts copying the process's output, so the writers calling it never race with the start.
+func processID(cmd *exec.Cmd) func() int {
+	return func() int {
+		if cmd.Process == nil {
+			return 0
+		}
+		return cmd.Process.Pid
+	}
+}
+
+// mutedLogger discards every record; its level is hclog.Off so go-plugin skips parsing the lines it would log.
+var mutedLogger = hclog.New(&hclog.LoggerOptions{Level: hclog.Off, Output: io.Discard})
+
+// collectedLogger is the client logger of a plugin whose output is collected. go-plugin relogs the plugin's stderr
+// through the sublogger named after the plugin binary, which it mutes, since the lines already reach the host logger
+// through the collecting writers.
+type collectedLogger struct {
+	hclog.Logger
+	stderr string // base name of the plugin binary
+}
+
+// Named returns mutedLogger for the plugin binary's name and a named sublogger otherwise.
+func (l *collectedLogger) Named(name string) hclog.Logger {
+	if name == l.stderr {
+		return mutedLogger
+	}
+	return l.Logger.Named(name)
+}
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// ProvenanceFileName is the name of the optional provenance file in a plugin directory.
+const ProvenanceFileName = "provenance.yaml"
+
+// provenanceClockSkew is how far in the future a build time may lie before it is reported as inconsistent, allowing
+// for clock differences between the builder and the host.
+const provenanceClockSkew = 5 * time.Minute
+
+// commitPattern matches an abbreviated or full SHA-1 or SHA-256 commit hash.
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// ErrBadProvenance indicates that a plugin's provenance file is unreadable or inconsistent with its manifest or
+// binary.
+var ErrBadProvenance = errors.New("plugin provenance is not valid")
+
+// Provenance records how a plugin binary was built, after SLSA build provenance: the builder that produced it, the
+// source repository and commit it was built from, when it was built, and the plugin version it was built as. Digest,
+// the binary's SHA-256, ties the record to the binary it describes. Being written as YAML, the file may also be JSON.
+type Provenance struct {
+	Builder    string    `json:"builder" yaml:"builder"`
+	SourceRepo string    `json:"source_repo" yaml:"source_repo"`
+	Commit     string    `json:"commit" yaml:"commit"`
+	BuildTime  time.Time `json:"build_time" yaml:"build_time"`
+	Version    string    `json:"version" yaml:"version"`
+	Digest     string    `json:"binary_sha256,omitempty" yaml:"binary_sha256,omitempty"`
+}
+
+// LoadProvenance reads the provenance file in dir, returning nil and no error if the plugin has none.
+func LoadProvenance(dir string) (*Provenance, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProvenanceFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Join(ErrBadProvenance, ErrReadingFile, err)
+	}
+	var p Provenance
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, errors.Join(ErrBadProvenance, ErrYAMLUnmarshaling, err)
+	}
+	return &p, nil
+}
+
+// Validate checks that the provenance is complete and consistent with the plugin it describes: the builder, source
+// repository, and build time are set, the commit is a hash, the build time is not in the future, the version is the
+// one the manifest declares, and, when recorded, the digest matches the binary at entrypoint. Every inconsistency is
+// returned, each wrapping ErrBadProvenance.
+func (p *Provenance) Validate(m *Manifest, entrypoint string) []error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrBadProvenance}, args...)...))
+	}
+	if strings.TrimSpace(p.Builder) == "" {
+		invalid("builder is required")
+	}
+	if strings.TrimSpace(p.SourceRepo) == "" {
+		invalid("source_repo is required")
+	}
+	if !commitPattern.MatchString(p.Commit) {
+		invalid("commit %q is not a commit hash", p.Commit)
+	}
+	switch {
+	case p.BuildTime.IsZero():
+		invalid("build_time is required")
+	case p.BuildTime.After(time.Now().Add(provenanceClockSkew)):
+		invalid("build_time %s is in the future", p.BuildTime.Format(time.RFC3339))
+	}
+	if m != nil && !sameVersion(p.Version, m.PluginData.Version) {
+		invalid("version %q does not match manifest version %q", p.Version, m.PluginData.Version)
+	}
+	if p.Digest != "" {
+		digest, err := fileSHA256(entrypoint)
+		switch {
+		case err != nil:
+			invalid("cannot digest binary: %v", err)
+		case !strings.EqualFold(p.Digest, digest):
+			invalid("binary_sha256 does not match %s", filepath.Base(entrypoint))
+		}
+	}
+	return errs
+}
+
+// sameVersion reports whether two version strings name the same semantic version, comparing them as written when
+// either does not parse.
+func sameVersion(a, b string) bool {
+	va, errA := semver.VersionFromString(a)
+	vb, errB := semver.VersionFromString(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Major == vb.Major && va.Minor == vb.Minor && va.Patch == vb.Patch && va.Codename == vb.Codename
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+)
+
+// PluginInfo is a serializable summary of an installed plugin, as returned by the catalog's queries. A plugin
+// directory whose manifest could not be loaded is listed under its directory name with its loader state.
+type PluginInfo struct {
+	Name        string      `json:"name" yaml:"name"`
+	Type        string      `json:"type,omitempty" yaml:"type,omitempty"`
+	Format      string      `json:"format,omitempty" yaml:"format,omitempty"`
+	Language    string      `json:"language,omitempty" yaml:"language,omitempty"`
+	Version     string      `json:"version,omitempty" yaml:"version,omitempty"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Maintainer  string      `json:"maintainer,omitempty" yaml:"maintainer,omitempty"`
+	URL         string      `json:"url,omitempty" yaml:"url,omitempty"`
+	Dir         string      `json:"dir" yaml:"dir"`
+	Entrypoint  string      `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
+	State       PluginState `json:"state" yaml:"state"`
+	StateName   string      `json:"state_name" yaml:"state_name"`
+}
+
+// info returns the PluginInfo of the entry loaded from dir.
+func (m *ManifestEntry) info(dir string) PluginInfo {
+	info := PluginInfo{Name: filepath.Base(dir), Dir: dir, Entrypoint: m.entrypoint, State: m.state}
+	if manifest := m.entry; manifest != nil {
+		data := manifest.PluginData
+		info.Name = data.Name
+		info.Type = data.Type
+		info.Format = data.Format
+		info.Language = data.Language
+		info.Version = data.Version
+		info.Description = manifest.About.Description
+		info.Maintainer = manifest.About.Maintainer
+		info.URL = manifest.About.URL
+	}
+	info.StateName = info.State.String()
+	return info
+}
+
+// List returns every installed plugin sorted by name, then directory.
+func (m *Manifests) List() []PluginInfo {
+	return m.list(func(PluginInfo) bool { return true })
+}
+
+// ListByType returns the installed plugins of the given type, e.g. "animal", compared case-insensitively, sorted
+// by name.
+func (m *Manifests) ListByType(pluginType string) []PluginInfo {
+	return m.list(func(info PluginInfo) bool { return strings.EqualFold(info.Type, pluginType) })
+}
+
+// ListByLanguage returns the installed plugins written in the given language, e.g. "go", compared
+// case-insensitively, sorted by name.
+func (m *Manifests) ListByLanguage(language string) []PluginInfo {
+	return m.list(func(info PluginInfo) bool { return strings.EqualFold(info.Language, language) })
+}
+
+// ListByState returns the installed plugins the loader left in the given state, sorted by name.
+func (m *Manifests) ListByState(state PluginState) []PluginInfo {
+	return m.list(func(info PluginInfo) bool { return info.State == state })
+}
+
+// Search returns the installed plugins whose name or description contains every word of query, ignoring case,
+// sorted by name. An empty query matches every plugin.
+func (m *Manifests) Search(query string) []PluginInfo {
+	return m.list(matches(query))
+}
+
+// list returns the installed plugins accepted by keep, sorted by name, then directory.
+func (m *Manifests) list(keep func(PluginInfo) bool) []PluginInfo {
+	m.mu.RLock()
+	infos := make([]PluginInfo, 0, len(m.entries))
+	for dir, entry := range m.entries {
+		infos = append(infos, entry.info(dir))
+	}
+	m.mu.RUnlock()
+	return sortInfos(slices.DeleteFunc(infos, func(info PluginInfo) bool { return !keep(info) }))
+}
+
+// matches returns a filter accepting the plugins whose name or description contains every word of query, ignoring
+// case.
+func matches(query string) func(PluginInfo) bool {
+	words := strings.Fields(strings.ToLower(query))
+	return func(info PluginInfo) bool {
+		text := strings.ToLower(info.Name + " " + info.Description)
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sortInfos sorts infos by name, then directory, so query results are stable, and returns them.
+func sortInfos(infos []PluginInfo) []PluginInfo {
+	slices.SortFunc(infos, func(a, b PluginInfo) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.Dir, b.Dir))
+	})
+	return infos
+}
+
+// List returns every installed plugin sorted by name, with the live state of the plugins the PluginManager has
+// started and the loader state of the others.
+func (c *PluginCatalog) List() []PluginInfo {
+	return c.list(func(PluginInfo) bool { return true })
+}
+
+// ListByType returns the installed plugins of the given type, e.g. "animal", compared case-insensitively, sorted
+// by name.
+func (c *PluginCatalog) ListByType(pluginType string) []PluginInfo {
+	return c.list(func(info PluginInfo) bool { return strings.EqualFold(info.Type, pluginType) })
+}
+
+// ListByLanguage returns the installed plugins written in the given language, e.g. "go", compared
+// case-insensitively, sorted by name.
+func (c *PluginCatalog) ListByLanguage(language string) []PluginInfo {
+	return c.list(func(info PluginInfo) bool { return strings.EqualFold(info.Language, language) })
+}
+
+// ListByState returns the installed plugins in the given state, e.g. PluginRunning, sorted by name. A plugin the
+// PluginManager has started is matched on its live state, any other on the state the loader left it in.
+func (c *PluginCatalog) ListByState(state PluginState) []PluginInfo {
+	return c.list(func(info PluginInfo) bool { return info.State == state })
+}
+
+// Search returns the installed plugins whose name or description contains every word of query, ignoring case,
+// sorted by name. An empty query matches every plugin.
+func (c *PluginCatalog) Search(query string) []PluginInfo {
+	return c.list(matches(query))
+}
+
+// Capabilities returns the capabilities the named plugin's manifest requests and true, or false when no installed
+// plugin has that name.
+func (c *PluginCatalog) Capabilities(name string) (capability.Capabilities, bool) {
+	c.mu.RLock()
+	manifests := c.manifests
+	c.mu.RUnlock()
+	if manifests == nil {
+		return capability.Capabilities{}, false
+	}
+	manifests.mu.RLock()
+	defer manifests.mu.RUnlock()
+	for _, entry := range manifests.entries {
+		if manifest := entry.Manifest(); manifest != nil && manifest.PluginData.Name == name {
+			return manifest.Capabilities, true
+		}
+	}
+	return capability.Capabilities{}, false
+}
+
+// list returns the installed plugins, with their live states, accepted by keep, sorted by name.
+func (c *PluginCatalog) list(keep func(PluginInfo) bool) []PluginInfo {
+	c.mu.RLock()
+	manifests, states := c.manifests, c.states
+	c.mu.RUnlock()
+	if manifests == nil {
+		return []PluginInfo{}
+	}
+	infos := manifests.List()
+	if states != nil {
+		for i := range infos {
+			if state, ok := states(infos[i].Name); ok {
+				infos[i].State = state
+				infos[i].StateName = state.String()
+			}
+		}
+	}
+	return slices.DeleteFunc(infos, func(info PluginInfo) bool { return !keep(info) })
+}
+
+// trackStates makes the catalog's queries report the live states returned by states for the plugins it knows.
+func (c *PluginCatalog) trackStates(states func(name string) (PluginState, bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states = states
+}
+
+// liveState returns the state of the named plugin and true once the manager has started it.
+func (pm *PluginManager) liveState(name string) (PluginState, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok {
+		return 0, false
+	}
+	return mp.state, true
+}
@@ -0,0 +1,210 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+)
+
+// ReconcileJobType is the type of the jobs that reconcile a plugin with its files after they change.
+const ReconcileJobType = "reconcile_plugin"
+
+// ReconcileStats counts the reconcile jobs a PluginManager has scheduled since it started watching.
+type ReconcileStats struct {
+	Scheduled    int `json:"scheduled"`    // file changes that asked for a reconcile
+	Deduplicated int `json:"deduplicated"` // requests absorbed by a job already queued for the plugin
+	Submitted    int `json:"submitted"`    // jobs submitted to the pool
+	Failed       int `json:"failed"`       // jobs that could not be submitted or whose reload failed
+}
+
+// reconciler turns plugin file changes into reconcile jobs on a worker pool. A plugin has at most one job waiting at
+// a time, since a reconcile reads whatever is on disk when it runs; a change arriving while its job runs queues one
+// more. Jobs are submitted in the order their plugins changed, no faster than the configured rate, from a dispatcher
+// goroutine, so a mass update neither blocks the watcher nor floods the pool.
+type reconciler struct {
+	pool     *worker.Pool
+	interval time.Duration // minimum time between submissions, no limit when zero
+	notify   chan struct{} // wakes the dispatcher when a plugin is queued
+	mu       sync.Mutex
+	queue    []string          // plugins waiting to be submitted, oldest first
+	waiting  map[string]bool   // plugins queued or submitted but not yet started
+	running  map[string]bool   // plugins whose reconcile job is running
+	again    map[string]bool   // plugins changed while their job ran
+	ids      map[string]string // request IDs of the changes behind the plugins' next jobs
+	stats    ReconcileStats
+}
+
+// newReconciler creates a reconciler submitting to pool at most rate jobs per second, without limit when rate is
+// zero or less.
+func newReconciler(pool *worker.Pool, rate float64) *reconciler {
+	r := &reconciler{
+		pool:    pool,
+		notify:  make(chan struct{}, 1),
+		waiting: make(map[string]bool),
+		running: make(map[string]bool),
+		again:   make(map[string]bool),
+		ids:     make(map[string]string),
+	}
+	if rate > 0 {
+		r.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return r
+}
+
+// WithReconcilePool makes WatchAndReload reconcile changed plugins as jobs on pool instead of reloading them one at a
+// time on the watcher goroutine, submitting at most rate jobs per second, or without limit when rate is zero or less,
+// and returns the updated PluginManager. The pool must be running while WatchAndReload is, and its results consumed.
+func (pm *PluginManager) WithReconcilePool(pool *worker.Pool, rate float64) *PluginManager {
+	pm.reconciler = newReconciler(pool, rate)
+	return pm
+}
+
+// ReconcileStats returns the counts of the reconcile jobs scheduled so far, all zero without a reconcile pool.
+func (pm *PluginManager) ReconcileStats() ReconcileStats {
+	if pm.reconciler == nil {
+		return ReconcileStats{}
+	}
+	pm.reconciler.mu.Lock()
+	defer pm.reconciler.mu.Unlock()
+	return pm.reconciler.stats
+}
+
+// reconcile brings the named plugin in line with its files: a plugin that is running, or was running when it failed,
+// is reloaded, and one that was never started or was stopped is left alone. It is safe to run repeatedly.
+func (pm *PluginManager) reconcile(name string) error {
+	if !pm.reloadable(name) {
+		return nil
+	}
+	return pm.Reload(name)
+}
+
+// schedule queues a reconcile of the named plugin for the change with request ID id unless one is already waiting,
+// whose job then keeps the ID of the change that queued it, and never blocks.
+func (r *reconciler) schedule(name, id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Scheduled++
+	switch {
+	case r.waiting[name]:
+		r.stats.Deduplicated++
+		return false
+	case r.running[name]:
+		if r.again[name] {
+			r.stats.Deduplicated++
+			return false
+		}
+		r.again[name] = true
+		r.ids[name] = id
+		return true
+	}
+	r.ids[name] = id
+	r.enqueue(name)
+	return true
+}
+
+// enqueue adds the plugin to the queue and wakes the dispatcher. The caller must hold mu.
+func (r *reconciler) enqueue(name string) {
+	r.waiting[name] = true
+	r.queue = append(r.queue, name)
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next removes and returns the oldest queued plugin with the request ID of the change that queued it.
+func (r *reconciler) next() (name, id string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return "", "", false
+	}
+	name = r.queue[0]
+	r.queue = r.queue[1:]
+	id = r.ids[name]
+	delete(r.ids, name)
+	return name, id, true
+}
+
+// started marks the plugin's job as running, so later changes queue another reconcile rather than being absorbed.
+func (r *reconciler) started(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiting, name)
+	r.running[name] = true
+}
+
+// finished records the outcome of the plugin's job, queueing it again if it changed while the job ran.
+func (r *reconciler) finished(name string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiting, name)
+	delete(r.running, name)
+	if failed {
+		r.stats.Failed++
+	}
+	if r.again[name] {
+		delete(r.again, name)
+		r.enqueue(name)
+	}
+}
+
+// dispatch submits a reconcile job for each queued plugin, pacing submissions to the configured rate, until ctx is
+// canceled.
+func (pm *PluginManager) dispatch(ctx context.Context) {
+	r := pm.reconciler
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.notify:
+		}
+		for {
+			name, id, ok := r.next()
+			if !ok {
+				break
+			}
+			if wait := time.Until(last.Add(r.interval)); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			last = time.Now()
+			pm.submitReconcile(ctx, name, id)
+		}
+	}
+}
+
+// submitReconcile submits the reconcile job of the named plugin, for the change with request ID id, to the pool.
+func (pm *PluginManager) submitReconcile(ctx context.Context, name, id string) {
+	r := pm.reconciler
+	job := worker.NewJob(ctx, func(context.Context) (any, error) {
+		r.started(name)
+		return nil, pm.reconcile(name)
+	}).WithType(ReconcileJobType).WithPlugin(name).WithRequestID(id).WithCallback(func(result *worker.JobResult) {
+		if result.Err != nil {
+			pm.managerLogger.Error("Failed to reload plugin", logger.KeyPluginName, name,
+				logger.KeyRequestID, result.RequestID, logger.KeyError, result.Err)
+		}
+		r.finished(name, result.Err != nil)
+	})
+	if err := r.pool.Submit(job); err != nil {
+		pm.managerLogger.Error("Failed to submit plugin reconcile", logger.KeyPluginName, name,
+			logger.KeyRequestID, id, logger.KeyError, err)
+		r.finished(name, true)
+		return
+	}
+	r.mu.Lock()
+	r.stats.Submitted++
+	r.mu.Unlock()
+	pm.managerLogger.Debug("Plugin reconcile submitted", logger.KeyPluginName, name, logger.KeyJobID, job.ID,
+		logger.KeyRequestID, job.RequestID)
+}
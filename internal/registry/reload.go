@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/callctx"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultReloadDebounce is how long WatchAndReload waits after the last change to a plugin's files before reloading
+// it, so a rebuild that writes the binary and checksum in several steps triggers a single reload.
+const DefaultReloadDebounce = 500 * time.Millisecond
+
+// ErrNoFileWatcher indicates that hot reload was requested for a catalog without a file watcher.
+var ErrNoFileWatcher = errors.New("catalog has no file watcher")
+
+// SetLaunchDetails replaces the launch details of the plugin with the same name, adding them if none exist.
+func (c *PluginCatalog) SetLaunchDetails(details *PluginLaunchDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ld := range c.launchDetails {
+		if ld.Name() == details.Name() {
+			c.launchDetails[i] = details
+			return
+		}
+	}
+	c.launchDetails = append(c.launchDetails, details)
+}
+
+// watcher returns the catalog's file watcher.
+func (c *PluginCatalog) watcher() *fsnotify.Watcher {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fw
+}
+
+// WithReloadDebounce sets how long WatchAndReload waits for a plugin's files to settle before reloading it, and
+// returns the updated PluginManager.
+func (pm *PluginManager) WithReloadDebounce(debounce time.Duration) *PluginManager {
+	pm.reloadDebounce = debounce
+	return pm
+}
+
+// WatchAndReload consumes the catalog's file watcher events until ctx is canceled or the watcher is closed. When a
+// plugin's binary, manifest, or checksum changes, the plugin is reloaded once its files have been quiet for the
+// debounce period: the manifest is re-read, the checksum re-verified, and the running client killed and relaunched.
+// Plugins that were never started, or were stopped by an operator, are left alone. With a reconcile pool, see
+// WithReconcilePool, the reload runs as a reconcile job on the pool instead of on the watcher goroutine.
+func (pm *PluginManager) WatchAndReload(ctx context.Context) error {
+	fw := pm.catalog.watcher()
+	if fw == nil {
+		return ErrNoFileWatcher
+	}
+	debounce := pm.reloadDebounce
+	if debounce <= 0 {
+		debounce = DefaultReloadDebounce
+	}
+	if pm.reconciler != nil {
+		go pm.dispatch(ctx)
+	}
+	timers := make(map[string]*time.Timer)
+	due := make(chan string)
+	defer func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			name := pm.pluginForPath(event.Name)
+			if name == "" {
+				continue
+			}
+			pm.managerLogger.Debug("Plugin file changed", logger.KeyPluginName, name,
+				"file", event.Name, "op", event.Op.String())
+			if t, ok := timers[name]; ok {
+				t.Reset(debounce)
+				continue
+			}
+			timers[name] = time.AfterFunc(debounce, func() {
+				select {
+				case due <- name:
+				case <-ctx.Done():
+				}
+			})
+		case name := <-due:
+			delete(timers, name)
+			if !pm.reloadable(name) {
+				continue
+			}
+			id := callctx.NewRequestID()
+			if pm.reconciler != nil {
+				if !pm.reconciler.schedule(name, id) {
+					pm.managerLogger.Debug("Plugin reconcile already queued", logger.KeyPluginName, name,
+						logger.KeyRequestID, id)
+				}
+				continue
+			}
+			pm.managerLogger.Debug("Reloading changed plugin", logger.KeyPluginName, name, logger.KeyRequestID, id)
+			if err := pm.Reload(name); err != nil {
+				pm.managerLogger.Error("Failed to reload plugin", logger.KeyPluginName, name, logger.KeyRequestID, id,
+					logger.KeyError, err)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			pm.managerLogger.Error("File watcher error", logger.KeyError, err)
+		}
+	}
+}
+
+// Reload re-reads and validates the named plugin's manifest and provenance, then stops the plugin if it is running and
+// starts it again, which re-verifies its checksum. An in-process plugin, which has no manifest, is only started
+// again. A new version other than the plugin's pinned version is refused with ErrPinnedVersion, leaving the plugin
+// as it was. Unlike Restart, a reload is not recorded with the flap detector.
+func (pm *PluginManager) Reload(name string) error {
+	ld, err := pm.launchDetails(name)
+	if err != nil {
+		return err
+	}
+	if ld.InProcess {
+		return pm.restartInPlace(name)
+	}
+	dir := filepath.Dir(ld.Entrypoint().Path)
+	m, entrypoint, _, err := LoadPluginManifest(dir)
+	if err != nil {
+		pm.setState(name, PluginInvalidManifest, err)
+		return err
+	}
+	if errs := ValidateManifest(m); len(errs) > 0 {
+		err := errors.Join(errs...)
+		pm.setState(name, PluginInvalidManifest, err)
+		return err
+	}
+	if m.PluginData.Name != name {
+		err := fmt.Errorf("%w: manifest in %s now names %q", ErrPluginNotFound, dir, m.PluginData.Name)
+		pm.setState(name, PluginInvalidManifest, err)
+		return err
+	}
+	fresh := m.ToLaunchDetails()
+	if fresh == nil {
+		err := fmt.Errorf("%w: %q", ErrInvalidLaunchDetails, name)
+		pm.setState(name, PluginInvalidLaunchDetails, err)
+		return err
+	}
+	fresh.Cmd = exec.Command(entrypoint)
+	prov, err := LoadProvenance(dir)
+	if err == nil && prov != nil {
+		err = errors.Join(prov.Validate(m, entrypoint)...)
+	}
+	if err != nil {
+		pm.setState(name, PluginBadProvenance, err)
+		return err
+	}
+	fresh.Provenance = prov
+	if err := pm.catalog.checkPin(name, fresh.Version); err != nil {
+		// keep the running version rather than stop the plugin for one it may not run
+		return err
+	}
+	pm.catalog.SetLaunchDetails(fresh)
+	return pm.restartInPlace(name)
+}
+
+// restartInPlace stops the named plugin if it is running and starts it again without recording a restart.
+func (pm *PluginManager) restartInPlace(name string) error {
+	if err := pm.Stop(name); err != nil && !errors.Is(err, ErrPluginNotRunning) {
+		return err
+	}
+	pm.managerLogger.Info("Reloading plugin", logger.KeyPluginName, name)
+	return pm.Start(name)
+}
+
+// pluginForPath returns the name of the plugin whose directory contains path, or the empty string.
+func (pm *PluginManager) pluginForPath(path string) string {
+	dir := filepath.Dir(path)
+	for _, ld := range pm.catalog.GetLaunchDetails() {
+		if ld.Entrypoint() != nil && filepath.Dir(ld.Entrypoint().Path) == dir {
+			return ld.Name()
+		}
+	}
+	return ""
+}
+
+// reloadable reports whether the named plugin was started and has not since been stopped or disabled.
+func (pm *PluginManager) reloadable(name string) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok {
+		return false
+	}
+	return mp.state != PluginAvailable && mp.state != PluginStopped && mp.state != PluginDisabledPendingReview
+}
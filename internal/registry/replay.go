@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/replay"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ErrReplayUnsupported indicates that a plugin cannot be replayed because it does not speak gRPC.
+var ErrReplayUnsupported = errors.New("plugin cannot be replayed")
+
+// WithRecorder records the gRPC calls made to every plugin launched from now on, and returns the updated
+// PluginManager.
+func (pm *PluginManager) WithRecorder(recorder *replay.Recorder) *PluginManager {
+	pm.recorder = recorder
+	return pm
+}
+
+// WithReplayer serves plugins from their recordings instead of launching them, and returns the updated
+// PluginManager. Replayed plugins are always healthy, and starting a plugin without a recording fails.
+func (pm *PluginManager) WithReplayer(replayer *replay.Replayer) *PluginManager {
+	pm.replayer = replayer
+	return pm
+}
+
+// grpcDialOptions returns the gRPC dial options for the connection to the named plugin.
+func (pm *PluginManager) grpcDialOptions(name string) []grpc.DialOption {
+	if pm.recorder == nil {
+		return pm.dialOptions
+	}
+	opts := make([]grpc.DialOption, 0, len(pm.dialOptions)+1)
+	opts = append(opts, pm.dialOptions...)
+	// chained last, so the recorded calls carry what the other interceptors sent
+	return append(opts, grpc.WithChainUnaryInterceptor(pm.recorder.UnaryClientInterceptor(name)))
+}
+
+// startReplay marks the named plugin running on a connection answered from its recording, without launching it.
+func (pm *PluginManager) startReplay(name string, pluginType plugin.Plugin) error {
+	grpcPlugin, ok := pluginType.(plugin.GRPCPlugin)
+	if !ok {
+		return fmt.Errorf("%w: %q does not speak gRPC", ErrReplayUnsupported, name)
+	}
+	pm.mu.Lock()
+	mp := pm.entry(name)
+	if mp.state == PluginRunning {
+		pm.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrPluginRunning, name)
+	}
+	pm.mu.Unlock()
+
+	if err := pm.replayer.Load(name); err != nil {
+		pm.setState(name, PluginFailedToLaunch, err)
+		return err
+	}
+	// the connection is never dialed, since the replay interceptor answers every call without invoking it
+	conn, err := grpc.NewClient("passthrough:///"+name, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(pm.replayer.UnaryClientInterceptor(name)))
+	if err != nil {
+		pm.setState(name, PluginFailedToLaunch, err)
+		return err
+	}
+	impl, err := grpcPlugin.GRPCClient(context.Background(), nil, conn)
+	if err != nil {
+		_ = conn.Close()
+		pm.setState(name, PluginFailedToLaunch, err)
+		return err
+	}
+
+	pm.mu.Lock()
+	mp.replay = impl
+	mp.replayConn = conn
+	mp.state = PluginRunning
+	mp.startedAt = time.Now()
+	mp.lastErr = nil
+	pm.mu.Unlock()
+	pm.managerLogger.Info("Plugin replaying recorded calls", logger.KeyPluginName, name)
+	return nil
+}
+
+// stopReplay closes the replay connection of the named plugin, reporting whether it was being replayed.
+func (pm *PluginManager) stopReplay(name string) bool {
+	pm.mu.Lock()
+	mp, ok := pm.plugins[name]
+	if !ok || mp.replayConn == nil {
+		pm.mu.Unlock()
+		return false
+	}
+	conn := mp.replayConn
+	mp.replay, mp.replayConn = nil, nil
+	mp.state = PluginStopped
+	pm.mu.Unlock()
+	_ = conn.Close()
+	pm.managerLogger.Info("Plugin stopped", logger.KeyPluginName, name)
+	return true
+}
+
+// replayed returns the client interface of the named plugin when it is being replayed.
+func (pm *PluginManager) replayed(name string) (any, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok || mp.replay == nil {
+		return nil, false
+	}
+	return mp.replay, true
+}
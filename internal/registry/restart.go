@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+)
+
+// DefaultInitialRestartBackoff is how long the manager waits before restarting a plugin after its first crash.
+// DefaultMaxRestartBackoff caps the doubling wait between restarts of a crash-looping plugin.
+// DefaultMaxRestarts is how many restarts in a row a crash-looping plugin gets before it is quarantined.
+// DefaultRestartResetAfter is how long a restarted plugin must stay healthy for its crash loop to be forgotten.
+const (
+	DefaultInitialRestartBackoff = time.Second
+	DefaultMaxRestartBackoff     = time.Minute
+	DefaultMaxRestarts           = 5
+	DefaultRestartResetAfter     = 5 * time.Minute
+)
+
+// ErrPluginQuarantined indicates that a plugin cannot be started because it kept crashing after restarts and was
+// quarantined; Unquarantine releases it.
+var ErrPluginQuarantined = errors.New("plugin quarantined after repeated crashes")
+
+// RestartPolicy controls how crashed plugins are restarted: after InitialBackoff, doubling up to MaxBackoff for each
+// further restart in a row, until MaxRestarts restarts have not kept the plugin up, when it is marked
+// PluginFailedToLaunch and quarantined. A plugin that stays healthy for ResetAfter leaves its crash loop. Zero values
+// use the package defaults.
+type RestartPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxRestarts    int
+	ResetAfter     time.Duration
+}
+
+// backoff returns how long to wait before the restart that follows attempt earlier restarts in a row.
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	wait, limit := p.InitialBackoff, p.MaxBackoff
+	if wait <= 0 {
+		wait = DefaultInitialRestartBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultMaxRestartBackoff
+	}
+	for range attempt {
+		if wait >= limit {
+			break
+		}
+		wait *= 2
+	}
+	return min(wait, limit)
+}
+
+// maxRestarts returns the number of restarts in a row after which a plugin is quarantined.
+func (p RestartPolicy) maxRestarts() int {
+	if p.MaxRestarts <= 0 {
+		return DefaultMaxRestarts
+	}
+	return p.MaxRestarts
+}
+
+// resetAfter returns how long a plugin must stay healthy for its crash loop to be forgotten.
+func (p RestartPolicy) resetAfter() time.Duration {
+	if p.ResetAfter <= 0 {
+		return DefaultRestartResetAfter
+	}
+	return p.ResetAfter
+}
+
+// PluginMetrics counts the crashes and restarts of a managed plugin. CrashLoop is the number of restarts in a row
+// that have not yet kept the plugin up, and NextRestart when the next one is due, zero when none is scheduled.
+type PluginMetrics struct {
+	Name        string    `json:"name" yaml:"name"`
+	Crashes     int       `json:"crashes" yaml:"crashes"`
+	Restarts    int       `json:"restarts" yaml:"restarts"`
+	CrashLoop   int       `json:"crash_loop" yaml:"crash_loop"`
+	LastCrash   time.Time `json:"last_crash,omitempty" yaml:"last_crash,omitempty"`
+	NextRestart time.Time `json:"next_restart,omitempty" yaml:"next_restart,omitempty"`
+	Quarantined bool      `json:"quarantined" yaml:"quarantined"`
+}
+
+// WithRestartPolicy sets how crashed plugins are restarted and returns the updated PluginManager.
+func (pm *PluginManager) WithRestartPolicy(policy RestartPolicy) *PluginManager {
+	pm.restartPolicy = policy
+	return pm
+}
+
+// Metrics returns the crash and restart counters of the named plugin.
+func (pm *PluginManager) Metrics(name string) (PluginMetrics, error) {
+	if _, err := pm.launchDetails(name); err != nil {
+		return PluginMetrics{}, err
+	}
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	if !ok {
+		return PluginMetrics{Name: name}, nil
+	}
+	return mp.metrics(name), nil
+}
+
+// AllMetrics returns the crash and restart counters of every plugin the manager has started, ordered by name.
+func (pm *PluginManager) AllMetrics() []PluginMetrics {
+	names := pm.names()
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	metrics := make([]PluginMetrics, 0, len(names))
+	for _, name := range names {
+		metrics = append(metrics, pm.plugins[name].metrics(name))
+	}
+	return metrics
+}
+
+// Unquarantine releases the named quarantined plugin and forgets its crash loop, leaving it stopped so that it can
+// be started again. Releasing a plugin that is not quarantined does nothing.
+func (pm *PluginManager) Unquarantine(name string) error {
+	if _, err := pm.launchDetails(name); err != nil {
+		return err
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	mp, ok := pm.plugins[name]
+	if !ok || !mp.quarantined {
+		return nil
+	}
+	mp.quarantined = false
+	mp.crashLoop = 0
+	mp.nextRestart = time.Time{}
+	mp.state = PluginStopped
+	pm.managerLogger.Info("Plugin released from quarantine", logger.KeyPluginName, name)
+	return nil
+}
+
+// Approve clears the flap detector's demotion and event history of the named plugin after an operator review,
+// leaving it stopped so that it can be started again. Approving a plugin that is not disabled only clears its history.
+func (pm *PluginManager) Approve(name string) error {
+	if _, err := pm.launchDetails(name); err != nil {
+		return err
+	}
+	if pm.flap == nil {
+		return nil
+	}
+	pm.flap.Approve(name)
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if mp, ok := pm.plugins[name]; ok && mp.state == PluginDisabledPendingReview {
+		mp.state = PluginStopped
+	}
+	return nil
+}
+
+// scheduleRestart schedules the restart of the named crashed plugin after the policy's backoff, or quarantines it
+// once its crash loop has used up the policy's restarts, and reports whether it was quarantined.
+func (pm *PluginManager) scheduleRestart(name string) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	mp := pm.entry(name)
+	if mp.crashLoop >= pm.restartPolicy.maxRestarts() {
+		mp.quarantined = true
+		mp.nextRestart = time.Time{}
+		mp.state = PluginFailedToLaunch
+		pm.managerLogger.Error("Plugin keeps crashing, quarantined", logger.KeyPluginName, name,
+			logger.KeyRestartCount, mp.crashLoop, logger.KeyError, mp.lastErr)
+		return true
+	}
+	wait := pm.restartPolicy.backoff(mp.crashLoop)
+	mp.nextRestart = time.Now().Add(wait)
+	pm.managerLogger.Info("Plugin restart scheduled", logger.KeyPluginName, name, "backoff", wait.String(),
+		logger.KeyRestartCount, mp.crashLoop)
+	return false
+}
+
+// restartsDue returns the names of the crashed plugins whose scheduled restart is due, ordered by name.
+func (pm *PluginManager) restartsDue() []string {
+	names := pm.names()
+	now := time.Now()
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	due := make([]string, 0)
+	for _, name := range names {
+		mp := pm.plugins[name]
+		if !mp.quarantined && !mp.nextRestart.IsZero() && !now.Before(mp.nextRestart) {
+			due = append(due, name)
+		}
+	}
+	return due
+}
+
+// restartCrashed restarts the named crashed plugin as part of its crash loop. A restart that fails to launch the
+// plugin schedules the next attempt, or quarantines the plugin, just like a crash.
+func (pm *PluginManager) restartCrashed(name string) error {
+	pm.mu.Lock()
+	mp := pm.entry(name)
+	mp.crashLoop++
+	mp.nextRestart = time.Time{}
+	pm.mu.Unlock()
+
+	err := pm.Restart(name)
+	if err != nil && !errors.Is(err, ErrPluginDisabled) {
+		pm.scheduleRestart(name)
+	}
+	return err
+}
+
+// quarantined reports whether the named plugin is quarantined.
+func (pm *PluginManager) quarantined(name string) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	mp, ok := pm.plugins[name]
+	return ok && mp.quarantined
+}
+
+// metrics returns the PluginMetrics of the plugin. The caller must hold the lock.
+func (mp *managedPlugin) metrics(name string) PluginMetrics {
+	return PluginMetrics{
+		Name:        name,
+		Crashes:     mp.crashes,
+		Restarts:    mp.restarts,
+		CrashLoop:   mp.crashLoop,
+		LastCrash:   mp.lastCrash,
+		NextRestart: mp.nextRestart,
+		Quarantined: mp.quarantined,
+	}
+}
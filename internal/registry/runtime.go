@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrRuntimeDir indicates that a plugin's runtime directories could not be created or removed.
+var ErrRuntimeDir = errors.New("plugin runtime directory error")
+
+// runtimeDirPerm is the permission of runtime directories; only the host user may read another plugin's files.
+const runtimeDirPerm = 0o700
+
+// runtimeEnv maps each environment variable injected at launch to the subdirectory of the plugin's runtime directory
+// it points to.
+var runtimeEnv = []struct {
+	name   string
+	subdir string
+}{
+	{"TMPDIR", "tmp"},
+	{"XDG_CACHE_HOME", "cache"},
+	{"XDG_CONFIG_HOME", "config"},
+	{"XDG_DATA_HOME", "data"},
+	{"XDG_STATE_HOME", "state"},
+}
+
+// RuntimeDirs manages a private temp, cache, config, data, and state directory for each plugin under a root
+// directory, so plugins do not write over each other's files or the host's temp space.
+type RuntimeDirs struct {
+	root          string
+	runtimeLogger hclog.Logger
+}
+
+// NewRuntimeDirs creates root if it does not exist and returns the RuntimeDirs beneath it.
+func NewRuntimeDirs(root string, runtimeLogger hclog.Logger) (*RuntimeDirs, error) {
+	if runtimeLogger == nil {
+		runtimeLogger = hclog.Default()
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Join(ErrRuntimeDir, err)
+	}
+	if err := os.MkdirAll(abs, runtimeDirPerm); err != nil {
+		return nil, errors.Join(ErrRuntimeDir, err)
+	}
+	return &RuntimeDirs{root: abs, runtimeLogger: runtimeLogger}, nil
+}
+
+// Dir returns the runtime directory of the named plugin.
+func (r *RuntimeDirs) Dir(name string) string {
+	return filepath.Join(r.root, name)
+}
+
+// Prepare creates the runtime directories of the named plugin and returns the environment variables pointing the
+// plugin at them, to be appended to the host environment at launch.
+func (r *RuntimeDirs) Prepare(name string) ([]string, error) {
+	dir := r.Dir(name)
+	env := make([]string, 0, len(runtimeEnv))
+	for _, e := range runtimeEnv {
+		path := filepath.Join(dir, e.subdir)
+		if err := os.MkdirAll(path, runtimeDirPerm); err != nil {
+			return nil, errors.Join(ErrRuntimeDir, err)
+		}
+		env = append(env, e.name+"="+path)
+	}
+	return env, nil
+}
+
+// Remove deletes the runtime directories of the named plugin and everything in them.
+func (r *RuntimeDirs) Remove(name string) error {
+	if err := os.RemoveAll(r.Dir(name)); err != nil {
+		return errors.Join(ErrRuntimeDir, err)
+	}
+	r.runtimeLogger.Debug("Removed plugin runtime directory", logger.KeyPluginName, name)
+	return nil
+}
+
+// Prune removes the runtime directories of every plugin not named in installed, cleaning up after plugins that
+// have been uninstalled.
+func (r *RuntimeDirs) Prune(installed []string) error {
+	entries, err := os.ReadDir(r.root)
+	if err != nil {
+		return errors.Join(ErrRuntimeDir, err)
+	}
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() || slices.Contains(installed, entry.Name()) {
+			continue
+		}
+		r.runtimeLogger.Info("Removing runtime directory of uninstalled plugin", logger.KeyPluginName, entry.Name())
+		errs = append(errs, r.Remove(entry.Name()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,28 @@
+// Code generated by shimgen from animal.Animal; DO NOT EDIT.
+
+package shims
+
+import (
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/animal"
+)
+
+// AnimalShim calls a dispensed animal.Animal plugin through registry.Call, returning each result in a
+// registry.Result envelope with the call's metadata.
+type AnimalShim struct {
+	manager *registry.PluginManager
+	name    string
+	opts    registry.CallOptions
+}
+
+// NewAnimalShim returns the shim calling the named plugin of manager with opts.
+func NewAnimalShim(manager *registry.PluginManager, name string, opts registry.CallOptions) *AnimalShim {
+	return &AnimalShim{manager: manager, name: name, opts: opts}
+}
+
+// Speak calls Speak on the plugin.
+func (s *AnimalShim) Speak(isLoud bool) (registry.Result[string], error) {
+	return registry.Call(s.manager, s.name, s.opts, func(impl animal.Animal) (string, error) {
+		return impl.Speak(isLoud), nil
+	})
+}
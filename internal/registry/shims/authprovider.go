@@ -0,0 +1,28 @@
+// Code generated by shimgen from authprovider.AuthProvider; DO NOT EDIT.
+
+package shims
+
+import (
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+)
+
+// AuthProviderShim calls a dispensed authprovider.AuthProvider plugin through registry.Call, returning each result in a
+// registry.Result envelope with the call's metadata.
+type AuthProviderShim struct {
+	manager *registry.PluginManager
+	name    string
+	opts    registry.CallOptions
+}
+
+// NewAuthProviderShim returns the shim calling the named plugin of manager with opts.
+func NewAuthProviderShim(manager *registry.PluginManager, name string, opts registry.CallOptions) *AuthProviderShim {
+	return &AuthProviderShim{manager: manager, name: name, opts: opts}
+}
+
+// Authenticate calls Authenticate on the plugin.
+func (s *AuthProviderShim) Authenticate(req authprovider.Request) (registry.Result[authprovider.Result], error) {
+	return registry.Call(s.manager, s.name, s.opts, func(impl authprovider.AuthProvider) (authprovider.Result, error) {
+		return impl.Authenticate(req)
+	})
+}
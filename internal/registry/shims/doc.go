@@ -0,0 +1,10 @@
+// Package shims holds typed shims for the shared plugin interfaces, generated by shimgen. Calling a plugin through
+// its shim instead of the interface returned by Dispense returns every result in a registry.Result envelope with the
+// call's duration, plugin version, transport, and retry count, so host code can route calls on observed metadata.
+package shims
+
+//go:generate go run ../../shimgen -dir ../../../shared/pkg/animal -type Animal -o animal.go
+//go:generate go run ../../shimgen -dir ../../../shared/pkg/authprovider -type AuthProvider -o authprovider.go
+//go:generate go run ../../shimgen -dir ../../../shared/pkg/jobsource -type JobSource -o jobsource.go
+//go:generate go run ../../shimgen -dir ../../../shared/pkg/logsink -type LogSink -o logsink.go
+//go:generate go run ../../shimgen -dir ../../../shared/pkg/metricsink -type MetricSink -o metricsink.go
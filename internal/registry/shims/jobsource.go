@@ -0,0 +1,31 @@
+// Code generated by shimgen from jobsource.JobSource; DO NOT EDIT.
+
+package shims
+
+import (
+	"context"
+
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+)
+
+// JobSourceShim calls a dispensed jobsource.JobSource plugin through registry.Call, returning each result in a
+// registry.Result envelope with the call's metadata.
+type JobSourceShim struct {
+	manager *registry.PluginManager
+	name    string
+	opts    registry.CallOptions
+}
+
+// NewJobSourceShim returns the shim calling the named plugin of manager with opts.
+func NewJobSourceShim(manager *registry.PluginManager, name string, opts registry.CallOptions) *JobSourceShim {
+	return &JobSourceShim{manager: manager, name: name, opts: opts}
+}
+
+// Stream calls Stream on the plugin.
+func (s *JobSourceShim) Stream(ctx context.Context, jobTypes []string, send func(jobsource.Request) error) (
+	registry.Result[struct{}], error) {
+	return registry.Call(s.manager, s.name, s.opts, func(impl jobsource.JobSource) (struct{}, error) {
+		return struct{}{}, impl.Stream(ctx, jobTypes, send)
+	})
+}
@@ -0,0 +1,28 @@
+// Code generated by shimgen from logsink.LogSink; DO NOT EDIT.
+
+package shims
+
+import (
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+)
+
+// LogSinkShim calls a dispensed logsink.LogSink plugin through registry.Call, returning each result in a
+// registry.Result envelope with the call's metadata.
+type LogSinkShim struct {
+	manager *registry.PluginManager
+	name    string
+	opts    registry.CallOptions
+}
+
+// NewLogSinkShim returns the shim calling the named plugin of manager with opts.
+func NewLogSinkShim(manager *registry.PluginManager, name string, opts registry.CallOptions) *LogSinkShim {
+	return &LogSinkShim{manager: manager, name: name, opts: opts}
+}
+
+// Write calls Write on the plugin.
+func (s *LogSinkShim) Write(records []logsink.Record) (registry.Result[struct{}], error) {
+	return registry.Call(s.manager, s.name, s.opts, func(impl logsink.LogSink) (struct{}, error) {
+		return struct{}{}, impl.Write(records)
+	})
+}
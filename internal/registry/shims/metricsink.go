@@ -0,0 +1,28 @@
+// Code generated by shimgen from metricsink.MetricSink; DO NOT EDIT.
+
+package shims
+
+import (
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/metricsink"
+)
+
+// MetricSinkShim calls a dispensed metricsink.MetricSink plugin through registry.Call, returning each result in a
+// registry.Result envelope with the call's metadata.
+type MetricSinkShim struct {
+	manager *registry.PluginManager
+	name    string
+	opts    registry.CallOptions
+}
+
+// NewMetricSinkShim returns the shim calling the named plugin of manager with opts.
+func NewMetricSinkShim(manager *registry.PluginManager, name string, opts registry.CallOptions) *MetricSinkShim {
+	return &MetricSinkShim{manager: manager, name: name, opts: opts}
+}
+
+// Export calls Export on the plugin.
+func (s *MetricSinkShim) Export(snapshot metricsink.Snapshot) (registry.Result[struct{}], error) {
+	return registry.Call(s.manager, s.name, s.opts, func(impl metricsink.MetricSink) (struct{}, error) {
+		return struct{}{}, impl.Export(snapshot)
+	})
+}
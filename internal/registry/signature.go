@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bmj2728/PlugsConc/internal/signature"
+)
+
+// signatures verifies plugin binaries against the detached signature next to them. The zero value verifies nothing.
+type signatures struct {
+	trustStore *signature.TrustStore // keys signatures are checked against, nil when none is configured
+	requireAll bool                  // require every plugin to be signed, not only those whose manifest asks
+}
+
+// verify checks the signature of the plugin binary at entrypoint against the trust store. A plugin must be signed
+// when required is set or the host requires every plugin to be; a plugin that is signed anyway has its signature
+// checked whenever there is a trust store. It returns PluginUnsigned or PluginBadSignature if the plugin is rejected,
+// otherwise PluginAvailable and the trusted key that signed it, if any.
+func (s signatures) verify(entrypoint string, required bool) (PluginState, string, error) {
+	required = required || s.requireAll
+	sigPath := filepath.Join(filepath.Dir(entrypoint), signature.SigFileName)
+	if _, err := os.Stat(sigPath); errors.Is(err, os.ErrNotExist) {
+		if required {
+			return PluginUnsigned, "", fmt.Errorf("%w: no %s", signature.ErrUnsigned, sigPath)
+		}
+		return PluginAvailable, "", nil
+	}
+	if s.trustStore == nil {
+		if required {
+			return PluginBadSignature, "", fmt.Errorf("%w: no trust store configured", signature.ErrNoTrustedKeys)
+		}
+		return PluginAvailable, "", nil
+	}
+	signer, err := s.trustStore.Verify(entrypoint, sigPath)
+	if err != nil {
+		return PluginBadSignature, "", err
+	}
+	return PluginAvailable, signer, nil
+}
@@ -1,5 +1,7 @@
 package registry
 
+import "fmt"
+
 // PluginState represents various states a plugin can be in during its lifecycle or validation process.
 // Values 100+ are reserved for error codes
 type PluginState int
@@ -28,6 +30,8 @@ const (
 	PluginRunning
 	// PluginStopped indicates the state when a plugin has been stopped after running.
 	PluginStopped
+	// PluginWarmingUp indicates that the plugin has completed the handshake and is running its warm-up hook.
+	PluginWarmingUp
 )
 const (
 	// PluginMissingManifest is used when a plugin is missing a manifest file
@@ -54,4 +58,63 @@ const (
 	PluginFailedToStop = PluginState(109)
 	// PluginStoppedUnexpectedly indicates that the plugin ceased running unexpectedly due to an unforeseen issue.
 	PluginStoppedUnexpectedly = PluginState(110)
+	// PluginDisabledPendingReview indicates the plugin crashed or restarted too frequently within the flap detection
+	// window and has been disabled until an operator reviews it.
+	PluginDisabledPendingReview = PluginState(111)
+	// PluginFailedToWarmUp indicates that the plugin's warm-up hook failed or did not finish within its timeout.
+	PluginFailedToWarmUp = PluginState(112)
+	// PluginUnsigned indicates that a plugin required to be signed has no signature file.
+	PluginUnsigned = PluginState(113)
+	// PluginBadSignature indicates that a plugin's signature was not made by a trusted key over its binary.
+	PluginBadSignature = PluginState(114)
+	// PluginBadProvenance indicates that a plugin's provenance file is unreadable or inconsistent with its manifest or
+	// binary.
+	PluginBadProvenance = PluginState(115)
+	// PluginNotAttested indicates that the remote attestation service does not trust a plugin's binary, or could not
+	// be reached and its fallback policy rejects the plugin.
+	PluginNotAttested = PluginState(116)
+	// PluginUnexpectedFile indicates that a plugin directory holds a file its layout does not allow, and the layout
+	// is strict.
+	PluginUnexpectedFile = PluginState(117)
 )
+
+// pluginStateNames maps each PluginState to its name.
+var pluginStateNames = map[PluginState]string{
+	PluginStateUnknown:          "unknown",
+	PluginDirectoryDiscovered:   "directory_discovered",
+	PluginDirectoryScanned:      "directory_scanned",
+	PluginDirectoryValidated:    "directory_validated",
+	PluginDataLoaded:            "data_loaded",
+	PluginManifestValidated:     "manifest_validated",
+	PluginAvailable:             "available",
+	PluginLaunching:             "launching",
+	PluginRunning:               "running",
+	PluginStopped:               "stopped",
+	PluginWarmingUp:             "warming_up",
+	PluginMissingManifest:       "missing_manifest",
+	PluginMissingChecksum:       "missing_checksum",
+	PluginMissingBinary:         "missing_binary",
+	PluginInvalidManifest:       "invalid_manifest",
+	PluginInvalidLaunchDetails:  "invalid_launch_details",
+	PluginInvalidChecksum:       "invalid_checksum",
+	PluginInvalidBinary:         "invalid_binary",
+	PluginBadChecksum:           "bad_checksum",
+	PluginFailedToLaunch:        "failed_to_launch",
+	PluginFailedToStop:          "failed_to_stop",
+	PluginStoppedUnexpectedly:   "stopped_unexpectedly",
+	PluginDisabledPendingReview: "disabled_pending_review",
+	PluginFailedToWarmUp:        "failed_to_warm_up",
+	PluginUnsigned:              "unsigned",
+	PluginBadSignature:          "bad_signature",
+	PluginBadProvenance:         "bad_provenance",
+	PluginNotAttested:           "not_attested",
+	PluginUnexpectedFile:        "unexpected_file",
+}
+
+// String returns the name of the state.
+func (s PluginState) String() string {
+	if name, ok := pluginStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("state(%d)", int(s))
+}
@@ -1,9 +1,14 @@
 package registry
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/bmj2728/PlugsConc/shared/pkg/animal"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/bmj2728/PlugsConc/shared/pkg/metricsink"
 	"github.com/hashicorp/go-plugin"
 )
 
@@ -12,17 +17,31 @@ type PluginType int
 
 // AnimalPlugin represents a standard animal-related plugin type.
 // AnimalGRPCPlugin represents an animal-related plugin type using gRPC.
+// LogSinkGRPCPlugin represents a plugin that receives batched log records from the host and ships them to an
+// external log system.
+// MetricSinkGRPCPlugin represents a plugin that periodically receives the host's metrics snapshots and exports them
+// to a monitoring stack.
+// AuthProviderGRPCPlugin represents a plugin the host consults to authenticate management API requests.
+// JobSourceGRPCPlugin represents a plugin that streams job requests into the host's worker pool.
 const (
 	AnimalPlugin PluginType = iota
 	AnimalGRPCPlugin
+	LogSinkGRPCPlugin
+	MetricSinkGRPCPlugin
+	AuthProviderGRPCPlugin
+	JobSourceGRPCPlugin
 )
 
 // AvailablePluginTypes is a global instance of PluginTypes containing mappings of PluginType to their respective
 // implementations.
 var AvailablePluginTypes = PluginTypes{
 	types: map[PluginType]plugin.Plugin{
-		AnimalPlugin:     &animal.AnimalPlugin{},
-		AnimalGRPCPlugin: &animal.AnimalGRPCPlugin{},
+		AnimalPlugin:           &animal.AnimalPlugin{},
+		AnimalGRPCPlugin:       &animal.AnimalGRPCPlugin{},
+		LogSinkGRPCPlugin:      &logsink.LogSinkGRPCPlugin{},
+		MetricSinkGRPCPlugin:   &metricsink.MetricSinkGRPCPlugin{},
+		AuthProviderGRPCPlugin: &authprovider.AuthProviderGRPCPlugin{},
+		JobSourceGRPCPlugin:    &jobsource.JobSourceGRPCPlugin{},
 	},
 	mu: sync.RWMutex{},
 }
@@ -30,8 +49,12 @@ var AvailablePluginTypes = PluginTypes{
 // AvailablePluginTypesLookup is a mapping of plugin type names to their corresponding PluginType values.
 var AvailablePluginTypesLookup = PluginTypesLookup{
 	types: map[string]PluginType{
-		"animal":      AnimalPlugin,
-		"animal-grpc": AnimalGRPCPlugin,
+		"animal":       AnimalPlugin,
+		"animal-grpc":  AnimalGRPCPlugin,
+		"logsink":      LogSinkGRPCPlugin,
+		"metricsink":   MetricSinkGRPCPlugin,
+		"authprovider": AuthProviderGRPCPlugin,
+		"jobsource":    JobSourceGRPCPlugin,
 	},
 	mu: sync.RWMutex{},
 }
@@ -76,6 +99,18 @@ func (ptl *PluginTypesLookup) GetPluginType(pluginType string) PluginType {
 	return ptl.types[pluginType]
 }
 
+// Names returns the registered plugin type names in sorted order.
+func (ptl *PluginTypesLookup) Names() []string {
+	ptl.mu.RLock()
+	defer ptl.mu.RUnlock()
+	names := make([]string, 0, len(ptl.types))
+	for name := range ptl.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IsValidPluginType checks if the given plugin type string exists in the PluginTypesLookup's types map.
 func (ptl *PluginTypesLookup) IsValidPluginType(pluginType string) bool {
 	ptl.mu.RLock()
@@ -1 +1,184 @@
 package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/semver"
+)
+
+// ErrInvalidManifest indicates that a manifest field failed validation.
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// pluginNamePattern matches the plugin names a manifest may declare; names become directory and file names.
+var pluginNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ManifestError reports a manifest field that failed validation.
+type ManifestError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+// Error returns the field, its value, and why it is invalid.
+func (e *ManifestError) Error() string {
+	return fmt.Sprintf("%s: %s=%v: %s", ErrInvalidManifest, e.Field, e.Value, e.Reason)
+}
+
+// Unwrap returns ErrInvalidManifest so callers can match with errors.Is.
+func (e *ManifestError) Unwrap() error {
+	return ErrInvalidManifest
+}
+
+// ValidateManifest checks a manifest's required fields, its version against internal/semver, its type, format, and
+// language against the registered values, the completeness of its handshake, its health check and warm-up, and the
+// sanity of the capabilities it requests. Every problem found is returned as a *ManifestError, so a plugin author
+// sees them all at once; a valid manifest returns nil.
+func ValidateManifest(m *Manifest) []error {
+	if m == nil {
+		return []error{&ManifestError{Field: "manifest", Value: nil, Reason: "is empty"}}
+	}
+	var errs []error
+	invalid := func(field string, value any, reason string) {
+		errs = append(errs, &ManifestError{Field: field, Value: value, Reason: reason})
+	}
+	required := func(field, value string) bool {
+		if strings.TrimSpace(value) == "" {
+			invalid(field, value, "is required")
+			return false
+		}
+		return true
+	}
+	oneOf := func(field, value string, valid bool, names []string) {
+		if required(field, value) && !valid {
+			invalid(field, value, "must be one of "+strings.Join(names, ", "))
+		}
+	}
+
+	data := m.PluginData
+	if required("plugin.name", data.Name) && !pluginNamePattern.MatchString(data.Name) {
+		invalid("plugin.name", data.Name, "may only contain letters, digits, '.', '_', and '-'")
+	}
+	oneOf("plugin.type", data.Type, AvailablePluginTypesLookup.IsValidPluginType(data.Type),
+		AvailablePluginTypesLookup.Names())
+	oneOf("plugin.format", data.Format, AvailablePluginFormatLookup.IsValidFormat(data.Format),
+		AvailablePluginFormatLookup.Names())
+	oneOf("plugin.language", data.Language, IsValidLanguage(data.Language), AvailablePluginLanguageLookup.Names())
+	if required("plugin.entrypoint", data.Entrypoint) && !filepath.IsLocal(data.Entrypoint) {
+		invalid("plugin.entrypoint", data.Entrypoint, "must be a path inside the plugin directory")
+	}
+	if required("plugin.version", data.Version) {
+		if _, err := semver.VersionFromString(data.Version); err != nil {
+			invalid("plugin.version", data.Version, "must be a semantic version major.minor.patch")
+		}
+	}
+
+	if m.About.URL != "" {
+		if u, err := url.Parse(m.About.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			invalid("about.url", m.About.URL, "must be an http or https URL")
+		}
+	}
+
+	if m.Handshake.ProtocolVersion == 0 {
+		invalid("handshake.protocol_version", m.Handshake.ProtocolVersion, "must be positive")
+	}
+	required("handshake.magic_cookie_key", m.Handshake.MagicCookieKey)
+	required("handshake.magic_cookie_value", m.Handshake.MagicCookieValue)
+
+	if err := m.HealthCheck.Validate(); err != nil {
+		invalid("health_check", m.HealthCheck.Type, err.Error())
+	}
+	if m.Warmup != nil && m.Warmup.Timeout < 0 {
+		invalid("warmup.timeout_ms", m.Warmup.Timeout, "must not be negative")
+	}
+
+	errs = append(errs, validateCapabilities(m.Capabilities)...)
+	return errs
+}
+
+// validateCapabilities checks that the capabilities a manifest requests are well formed: absolute paths with known
+// permissions, known protocols with valid ports and non-empty hosts, absolute commands, and bounded job submission.
+func validateCapabilities(caps capability.Capabilities) []error {
+	var errs []error
+	invalid := func(field string, value any, reason string) {
+		errs = append(errs, &ManifestError{Field: field, Value: value, Reason: reason})
+	}
+	ports := func(field string, ports []int) {
+		if len(ports) == 0 {
+			invalid(field, ports, "must list at least one port")
+		}
+		for _, port := range ports {
+			if port < 1 || port > 65535 {
+				invalid(field, port, "must be between 1 and 65535")
+			}
+		}
+	}
+	protocol := func(field, value string) {
+		if !slices.Contains(capability.NetworkProtocols, strings.ToLower(value)) {
+			invalid(field, value, "must be one of "+strings.Join(capability.NetworkProtocols, ", "))
+		}
+	}
+
+	for i, fs := range caps.Filesystem {
+		field := fmt.Sprintf("capabilities.filesystem[%d]", i)
+		if !filepath.IsAbs(fs.Path) {
+			invalid(field+".path", fs.Path, "must be an absolute path")
+		}
+		if len(fs.Permissions) == 0 {
+			invalid(field+".permissions", fs.Permissions, "must list at least one permission")
+		}
+		for _, perm := range fs.Permissions {
+			if !slices.Contains(capability.FilePermissions, perm) {
+				invalid(field+".permissions", perm, "must be one of "+strings.Join(capability.FilePermissions, ", "))
+			}
+		}
+	}
+
+	if network := caps.Network; network != nil {
+		for i, rule := range network.Egress {
+			field := fmt.Sprintf("capabilities.network.egress[%d]", i)
+			protocol(field+".protocol", rule.Protocol)
+			if len(rule.Hosts) == 0 {
+				invalid(field+".hosts", rule.Hosts, "must list at least one host")
+			}
+			for _, host := range rule.Hosts {
+				if strings.TrimSpace(host) == "" {
+					invalid(field+".hosts", host, "must not be empty")
+				}
+			}
+			ports(field+".ports", rule.Ports)
+		}
+		for i, rule := range network.Ingress {
+			field := fmt.Sprintf("capabilities.network.ingress[%d]", i)
+			protocol(field+".protocol", rule.Protocol)
+			ports(field+".ports", rule.Ports)
+		}
+	}
+
+	if process := caps.Process; process != nil {
+		for i, rule := range process.Exec {
+			if !filepath.IsAbs(rule.Command) {
+				invalid(fmt.Sprintf("capabilities.process.exec[%d].command", i), rule.Command,
+					"must be an absolute path")
+			}
+		}
+	}
+
+	if jobs := caps.Jobs; jobs != nil {
+		for i, jobType := range jobs.Types {
+			if strings.TrimSpace(jobType) == "" {
+				invalid(fmt.Sprintf("capabilities.jobs.types[%d]", i), jobType, "must not be empty")
+			}
+		}
+		if jobs.MaxPerMinute < 0 {
+			invalid("capabilities.jobs.max_per_minute", jobs.MaxPerMinute, "must not be negative")
+		}
+	}
+	return errs
+}
@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	"github.com/hashicorp/go-plugin"
+)
+
+// DefaultWarmupTimeout bounds a plugin's warm-up hook when its manifest does not set a timeout.
+const DefaultWarmupTimeout = 30 * time.Second
+
+// ErrWarmupFailed indicates that a plugin's warm-up hook failed or did not finish within its timeout.
+var ErrWarmupFailed = errors.New("plugin warm-up failed")
+
+// Warmup configures the warm-up hook the manager calls after a plugin's handshake and before it is marked running.
+// gRPC plugins whose implementation satisfies lifecycle.Warmer are warmed up; other plugins start immediately.
+type Warmup struct {
+	Timeout int `json:"timeout_ms" yaml:"timeout_ms"` // milliseconds
+}
+
+// timeout returns the time allowed for the warm-up hook.
+func (w *Warmup) timeout() time.Duration {
+	if w == nil || w.Timeout <= 0 {
+		return DefaultWarmupTimeout
+	}
+	return time.Duration(w.Timeout) * time.Millisecond
+}
+
+// warmup calls the warm-up hook of the named plugin. net/rpc plugins have no hook and are considered warm.
+func (w *Warmup) warmup(client *plugin.Client, name string) error {
+	rpcClient, err := client.Client()
+	if err != nil {
+		return err
+	}
+	grpcClient, ok := rpcClient.(*plugin.GRPCClient)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout())
+	defer cancel()
+	if err := lifecycle.Warmup(ctx, grpcClient.Conn, name); err != nil {
+		return fmt.Errorf("%w: %w", ErrWarmupFailed, err)
+	}
+	return nil
+}
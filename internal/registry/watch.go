@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoWatchFunc indicates that watching was started on a catalog without a watch function.
+// ErrAlreadyWatching indicates that watching was started on a catalog whose watch function is already running.
+var (
+	ErrNoWatchFunc     = errors.New("catalog has no watch function")
+	ErrAlreadyWatching = errors.New("catalog is already watching")
+)
+
+// WatchStatus is a point-in-time, serializable view of the catalog's file watching: whether the watch function is
+// running, when it last started and stopped, how many times it has been started, and the watched paths.
+type WatchStatus struct {
+	Watching  bool      `json:"watching" yaml:"watching"`
+	StartedAt time.Time `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+	StoppedAt time.Time `json:"stopped_at,omitempty" yaml:"stopped_at,omitempty"`
+	Starts    int       `json:"starts" yaml:"starts"`
+	Paths     []string  `json:"paths" yaml:"paths"`
+}
+
+// watchRun tracks the running watch function. Fields are guarded by the catalog's lock.
+type watchRun struct {
+	cancel    context.CancelFunc // stops the running watch function, nil when not watching
+	done      chan struct{}      // closed when the running watch function returns
+	startedAt time.Time
+	stoppedAt time.Time
+	starts    int
+}
+
+// StartWatching runs the catalog's watch function on its file watcher in a new goroutine until ctx is canceled,
+// StopWatching is called, or the function returns on its own, e.g. because the watcher was closed. It returns
+// ErrNoFileWatcher or ErrNoWatchFunc if either is missing, and ErrAlreadyWatching if the function is running.
+func (c *PluginCatalog) StartWatching(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.fw == nil:
+		return ErrNoFileWatcher
+	case c.watch == nil:
+		return ErrNoWatchFunc
+	case c.watching.cancel != nil:
+		return ErrAlreadyWatching
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	c.watching.cancel = cancel
+	c.watching.done = done
+	c.watching.startedAt = time.Now()
+	c.watching.starts++
+	watch, fw := c.watch, c.fw
+	go func() {
+		defer close(done)
+		defer cancel()
+		watch(ctx, fw)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.watching.cancel = nil
+		c.watching.stoppedAt = time.Now()
+	}()
+	return nil
+}
+
+// StopWatching stops the running watch function and waits for it to return. Stopping a catalog that is not watching
+// does nothing.
+func (c *PluginCatalog) StopWatching() {
+	c.mu.Lock()
+	cancel, done := c.watching.cancel, c.watching.done
+	c.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	// the watch function may use the catalog, so it is waited for without holding the lock
+	cancel()
+	<-done
+}
+
+// WatchStatus returns the status of the catalog's file watching.
+func (c *PluginCatalog) WatchStatus() WatchStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.watchStatus()
+}
+
+// watchStatus returns the status of the catalog's file watching. The caller must hold the lock.
+func (c *PluginCatalog) watchStatus() WatchStatus {
+	status := WatchStatus{
+		Watching:  c.watching.cancel != nil,
+		StartedAt: c.watching.startedAt,
+		StoppedAt: c.watching.stoppedAt,
+		Starts:    c.watching.starts,
+		Paths:     make([]string, 0),
+	}
+	if c.fw != nil {
+		status.Paths = append(status.Paths, c.fw.WatchList()...)
+	}
+	return status
+}
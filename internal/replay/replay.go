@@ -0,0 +1,262 @@
+// Package replay records the unary gRPC calls the host makes to plugins, with their responses, to one file per plugin,
+// and serves those recordings back without launching the plugins, for deterministic host integration tests and
+// offline development. net/rpc plugins and streaming calls are not recorded.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileExt is the extension of the recording files, one JSON Interaction per line.
+const fileExt = ".jsonl"
+
+var (
+	// ErrOpenRecording indicates that a plugin's recording could not be created or read.
+	ErrOpenRecording = errors.New("failed to open plugin recording")
+	// ErrNoRecording indicates that a plugin has no recording to replay.
+	ErrNoRecording = errors.New("no recording for plugin")
+)
+
+// Interaction is a recorded plugin call. Request and Response are the protojson encoding of the call's messages;
+// a failed call records its status code and message instead of a response.
+type Interaction struct {
+	Time     time.Time       `json:"time"`
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Code     codes.Code      `json:"code,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// recorded reports whether calls to method are recorded and replayed. The services go-plugin runs on every
+// connection, and the gRPC health service, belong to the plugin runtime rather than the plugin's interface.
+func recorded(method string) bool {
+	return !strings.HasPrefix(method, "/plugin.") && !strings.HasPrefix(method, "/grpc.health.")
+}
+
+// requestKey returns the key a call is matched by during replay. protojson output is deliberately unstable in its
+// whitespace, so the encoding is compacted first.
+func requestKey(method string, request json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, request); err != nil {
+		return method + " " + string(request)
+	}
+	return method + " " + buf.String()
+}
+
+// path returns the recording file of the named plugin in dir.
+func path(dir, plugin string) string {
+	return filepath.Join(dir, plugin+fileExt)
+}
+
+// Recorder appends the calls made to each plugin, and their responses, to the plugin's recording file in a directory.
+type Recorder struct {
+	mu             sync.Mutex
+	dir            string
+	files          map[string]*os.File
+	recorderLogger hclog.Logger
+}
+
+// NewRecorder creates dir if it does not exist and returns a Recorder writing to it. Existing recordings are replaced
+// the first time their plugin is called.
+func NewRecorder(dir string, recorderLogger hclog.Logger) (*Recorder, error) {
+	if recorderLogger == nil {
+		recorderLogger = hclog.Default()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Join(ErrOpenRecording, err)
+	}
+	return &Recorder{dir: dir, files: make(map[string]*os.File), recorderLogger: recorderLogger}, nil
+}
+
+// UnaryClientInterceptor records every unary call made to the named plugin.
+func (r *Recorder) UnaryClientInterceptor(plugin string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if recorded(method) {
+			r.record(plugin, method, req, reply, err)
+		}
+		return err
+	}
+}
+
+// Close closes the recording files.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for plugin, f := range r.files {
+		errs = append(errs, f.Close())
+		delete(r.files, plugin)
+	}
+	return errors.Join(errs...)
+}
+
+// record appends a call to the plugin's recording. Failing to record is logged rather than failing the call.
+func (r *Recorder) record(plugin, method string, req, reply any, callErr error) {
+	interaction := Interaction{Time: time.Now(), Method: method}
+	var err error
+	if interaction.Request, err = marshal(req); err != nil {
+		r.recorderLogger.Warn("Failed to record plugin call", logger.KeyPluginName, plugin, "method", method,
+			logger.KeyError, err)
+		return
+	}
+	if callErr != nil {
+		st := status.Convert(callErr)
+		interaction.Code, interaction.Error = st.Code(), st.Message()
+	} else if interaction.Response, err = marshal(reply); err != nil {
+		r.recorderLogger.Warn("Failed to record plugin call", logger.KeyPluginName, plugin, "method", method,
+			logger.KeyError, err)
+		return
+	}
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		r.recorderLogger.Warn("Failed to record plugin call", logger.KeyPluginName, plugin, "method", method,
+			logger.KeyError, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.files[plugin]
+	if !ok {
+		f, err = os.Create(path(r.dir, plugin))
+		if err != nil {
+			r.recorderLogger.Warn("Failed to create plugin recording", logger.KeyPluginName, plugin,
+				logger.KeyError, err)
+			return
+		}
+		r.files[plugin] = f
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		r.recorderLogger.Warn("Failed to record plugin call", logger.KeyPluginName, plugin, "method", method,
+			logger.KeyError, err)
+	}
+}
+
+// Replayer serves the calls recorded by a Recorder. Calls are matched by method and request; a call recorded more
+// than once is answered with its recorded responses in order, the last one repeating.
+type Replayer struct {
+	mu             sync.Mutex
+	dir            string
+	recordings     map[string]map[string][]Interaction // plugin -> request key -> interactions left to serve
+	replayerLogger hclog.Logger
+}
+
+// NewReplayer returns a Replayer serving the recordings in dir.
+func NewReplayer(dir string, replayerLogger hclog.Logger) *Replayer {
+	if replayerLogger == nil {
+		replayerLogger = hclog.Default()
+	}
+	return &Replayer{
+		dir:            dir,
+		recordings:     make(map[string]map[string][]Interaction),
+		replayerLogger: replayerLogger,
+	}
+}
+
+// Load reads the recording of the named plugin, replacing any loaded earlier so replay starts over.
+func (r *Replayer) Load(plugin string) error {
+	f, err := os.Open(path(r.dir, plugin))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %q", ErrNoRecording, plugin)
+	}
+	if err != nil {
+		return errors.Join(ErrOpenRecording, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	recording := make(map[string][]Interaction)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return errors.Join(ErrOpenRecording, fmt.Errorf("%s line %d: %w", f.Name(), line, err))
+		}
+		key := requestKey(interaction.Method, interaction.Request)
+		recording[key] = append(recording[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Join(ErrOpenRecording, err)
+	}
+	r.mu.Lock()
+	r.recordings[plugin] = recording
+	r.mu.Unlock()
+	r.replayerLogger.Debug("Loaded plugin recording", logger.KeyPluginName, plugin, "calls", len(recording))
+	return nil
+}
+
+// UnaryClientInterceptor answers every unary call made to the named plugin from its recording, without invoking
+// the plugin. A call that was not recorded fails with codes.NotFound.
+func (r *Replayer) UnaryClientInterceptor(plugin string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption) error {
+		if !recorded(method) {
+			return status.Errorf(codes.Unimplemented, "%s is not available during replay", method)
+		}
+		request, err := marshal(req)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		interaction, ok := r.next(plugin, requestKey(method, request))
+		if !ok {
+			r.replayerLogger.Warn("No recorded response for plugin call", logger.KeyPluginName, plugin,
+				"method", method, "request", string(request))
+			return status.Errorf(codes.NotFound, "no recorded response for %s %s", method, request)
+		}
+		if interaction.Code != codes.OK {
+			return status.Error(interaction.Code, interaction.Error)
+		}
+		msg, ok := reply.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "reply %T is not a protobuf message", reply)
+		}
+		if err := protojson.Unmarshal(interaction.Response, msg); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return nil
+	}
+}
+
+// next returns the recorded interaction answering the call with key, consuming it unless it is the last one.
+func (r *Replayer) next(plugin, key string) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.recordings[plugin][key]
+	if len(queue) == 0 {
+		return Interaction{}, false
+	}
+	if len(queue) > 1 {
+		r.recordings[plugin][key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// marshal returns the protojson encoding of a call's request or reply.
+func marshal(msg any) (json.RawMessage, error) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a protobuf message", msg)
+	}
+	return protojson.Marshal(m)
+}
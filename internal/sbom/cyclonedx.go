@@ -0,0 +1,137 @@
+package sbom
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bmj2728/utils/pkg/strutil"
+)
+
+// cdxBOM is a CycloneDX 1.5 JSON document, limited to the fields the host fills in.
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+// cdxMetadata describes when and by what the BOM was produced, and the component it describes.
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+// cdxTools lists the tools that produced the BOM.
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+// cdxComponent is a CycloneDX component.
+type cdxComponent struct {
+	Type               string           `json:"type"`
+	BOMRef             string           `json:"bom-ref,omitempty"`
+	Name               string           `json:"name"`
+	Version            string           `json:"version,omitempty"`
+	Supplier           *cdxOrganization `json:"supplier,omitempty"`
+	Hashes             []cdxHash        `json:"hashes,omitempty"`
+	PURL               string           `json:"purl,omitempty"`
+	ExternalReferences []cdxReference   `json:"externalReferences,omitempty"`
+	Properties         []cdxProperty    `json:"properties,omitempty"`
+}
+
+// cdxOrganization is a CycloneDX organizational entity.
+type cdxOrganization struct {
+	Name string   `json:"name,omitempty"`
+	URL  []string `json:"url,omitempty"`
+}
+
+// cdxHash is a CycloneDX hash of a component.
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// cdxReference is a CycloneDX external reference.
+type cdxReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// cdxProperty is a CycloneDX name-value property.
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cdxDependency records the components a component depends on.
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// CycloneDX returns the inventory as a CycloneDX 1.5 JSON document. The host binary is the BOM's subject, and the
+// plugins and Go modules are its components, each depended on by the host.
+func (inv *Inventory) CycloneDX() ([]byte, error) {
+	host := cdxComponentOf(inv.Host)
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + strutil.GenerateUUIDV7(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: inv.Timestamp.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: inv.Host.Name}}},
+			Component: host,
+		},
+		Components: make([]cdxComponent, 0, len(inv.Plugins)+len(inv.Libraries)),
+	}
+	hostDeps := cdxDependency{Ref: host.BOMRef, DependsOn: make([]string, 0)}
+	for _, c := range append(append([]Component{}, inv.Plugins...), inv.Libraries...) {
+		component := cdxComponentOf(c)
+		bom.Components = append(bom.Components, component)
+		hostDeps.DependsOn = append(hostDeps.DependsOn, component.BOMRef)
+	}
+	bom.Dependencies = []cdxDependency{hostDeps}
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+// cdxComponentOf converts a Component to a CycloneDX component referenced by its package URL.
+func cdxComponentOf(c Component) cdxComponent {
+	component := cdxComponent{
+		Type:    "application",
+		BOMRef:  c.Kind + ":" + c.PURL(),
+		Name:    c.Name,
+		Version: c.Version,
+		PURL:    c.PURL(),
+	}
+	if c.Kind == KindLibrary {
+		component.Type = "library"
+	}
+	if c.SHA256 != "" {
+		component.Hashes = []cdxHash{{Alg: "SHA-256", Content: c.SHA256}}
+	}
+	if c.Maintainer != "" || c.URL != "" {
+		component.Supplier = &cdxOrganization{Name: c.Maintainer}
+		if c.URL != "" {
+			component.Supplier.URL = []string{c.URL}
+			component.ExternalReferences = []cdxReference{{Type: "website", URL: c.URL}}
+		}
+	}
+	for _, p := range []cdxProperty{
+		{Name: "plugsconc:kind", Value: c.Kind},
+		{Name: "plugsconc:plugin_type", Value: c.PluginType},
+		{Name: "plugsconc:language", Value: c.Language},
+		{Name: "plugsconc:path", Value: c.Path},
+		{Name: "plugsconc:go_sum", Value: c.GoSum},
+		{Name: "plugsconc:error", Value: c.Error},
+	} {
+		if p.Value != "" {
+			component.Properties = append(component.Properties, p)
+		}
+	}
+	return component
+}
@@ -0,0 +1,160 @@
+// Package sbom builds a software bill of materials for the host binary and its installed plugins, and encodes it as a
+// CycloneDX or SPDX JSON document that security teams can feed into their scanning pipelines.
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/registry"
+)
+
+// FormatCycloneDX selects a CycloneDX 1.5 JSON document.
+// FormatSPDX selects an SPDX 2.3 JSON document.
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// Formats lists the supported SBOM formats.
+var Formats = []string{FormatCycloneDX, FormatSPDX}
+
+// ErrUnsupportedFormat indicates that an SBOM was requested in a format other than one of Formats.
+var ErrUnsupportedFormat = errors.New("unsupported SBOM format")
+
+// KindHost marks the component describing the host binary.
+// KindPlugin marks a component describing an installed plugin.
+// KindLibrary marks a Go module compiled into the host binary.
+const (
+	KindHost    = "host"
+	KindPlugin  = "plugin"
+	KindLibrary = "library"
+)
+
+// Component is one entry of the bill of materials: the host binary, an installed plugin, or a Go module compiled
+// into the host. SHA256 is the hex digest of the component's binary and is empty for libraries, which are identified
+// by their module version and go.sum hash instead.
+type Component struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	SHA256     string `json:"sha256,omitempty"`
+	GoSum      string `json:"go_sum,omitempty"` // h1: hash recorded in go.sum, libraries only
+	Maintainer string `json:"maintainer,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Path       string `json:"path,omitempty"`
+	PluginType string `json:"plugin_type,omitempty"`
+	Language   string `json:"language,omitempty"`
+	Error      string `json:"error,omitempty"` // why the binary could not be hashed
+}
+
+// PURL returns the component's package URL: pkg:golang for the host and its libraries, pkg:generic for plugins.
+func (c Component) PURL() string {
+	kind := "generic"
+	if c.Kind != KindPlugin {
+		kind = "golang"
+	}
+	if c.Version == "" {
+		return fmt.Sprintf("pkg:%s/%s", kind, c.Name)
+	}
+	return fmt.Sprintf("pkg:%s/%s@%s", kind, c.Name, url.PathEscape(c.Version))
+}
+
+// Inventory is the bill of materials of a host: the host binary, the Go modules compiled into it, and every
+// installed plugin, taken at Timestamp.
+type Inventory struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Host      Component   `json:"host"`
+	Libraries []Component `json:"libraries"`
+	Plugins   []Component `json:"plugins"`
+}
+
+// Build returns the Inventory of the running host binary, reported as version, and of every plugin installed in the
+// catalog, sorted by name. Binaries that cannot be read are listed without a hash and with the error.
+func Build(version string, catalog *registry.PluginCatalog) *Inventory {
+	inv := &Inventory{
+		Timestamp: time.Now().UTC(),
+		Host:      Component{Kind: KindHost, Name: "plugsconc", Version: version},
+		Libraries: make([]Component, 0),
+		Plugins:   make([]Component, 0),
+	}
+	if exe, err := os.Executable(); err == nil {
+		inv.Host.Name = filepath.Base(exe)
+		inv.Host.Path = exe
+		inv.Host.hash()
+	} else {
+		inv.Host.Error = err.Error()
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path != "" {
+			inv.Host.Name = info.Main.Path
+		}
+		inv.Host.Language = "go"
+		for _, dep := range info.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			inv.Libraries = append(inv.Libraries, Component{
+				Kind:    KindLibrary,
+				Name:    dep.Path,
+				Version: dep.Version,
+				GoSum:   dep.Sum,
+			})
+		}
+	}
+	if catalog != nil {
+		for _, info := range catalog.List() {
+			plugin := Component{
+				Kind:       KindPlugin,
+				Name:       info.Name,
+				Version:    info.Version,
+				Maintainer: info.Maintainer,
+				URL:        info.URL,
+				Path:       info.Entrypoint,
+				PluginType: info.Type,
+				Language:   info.Language,
+			}
+			if plugin.Path != "" {
+				plugin.hash()
+			}
+			inv.Plugins = append(inv.Plugins, plugin)
+		}
+	}
+	return inv
+}
+
+// hash records the SHA-256 digest of the component's binary, or the error reading it.
+func (c *Component) hash() {
+	f, err := os.Open(c.Path)
+	if err != nil {
+		c.Error = err.Error()
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		c.Error = err.Error()
+		return
+	}
+	c.SHA256 = hex.EncodeToString(h.Sum(nil))
+}
+
+// Encode returns the inventory as a JSON document in format, one of Formats.
+func (inv *Inventory) Encode(format string) ([]byte, error) {
+	switch format {
+	case FormatCycloneDX:
+		return inv.CycloneDX()
+	case FormatSPDX:
+		return inv.SPDX()
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
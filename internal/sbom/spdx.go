@@ -0,0 +1,129 @@
+package sbom
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/bmj2728/utils/pkg/strutil"
+)
+
+// spdxNoAssertion is the SPDX value for information that was not determined.
+const spdxNoAssertion = "NOASSERTION"
+
+// spdxIDUnsafe matches the characters not allowed in an SPDX identifier.
+var spdxIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// spdxDocument is an SPDX 2.3 JSON document, limited to the fields the host fills in.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+// spdxCreationInfo records when and by what the document was created.
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// spdxPackage is an SPDX package.
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	Supplier              string            `json:"supplier"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	Homepage              string            `json:"homepage,omitempty"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+// spdxChecksum is the checksum of an SPDX package.
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// spdxExternalRef is an SPDX external reference, here the package URL.
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// spdxRelationship relates two SPDX elements.
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX returns the inventory as an SPDX 2.3 JSON document. The document describes the host binary, which contains the
+// plugins and depends on the Go modules.
+func (inv *Inventory) SPDX() ([]byte, error) {
+	host := spdxPackageOf(inv.Host)
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              inv.Host.Name + " plugin inventory",
+		DocumentNamespace: "https://spdx.org/spdxdocs/plugsconc-" + strutil.GenerateUUIDV7(),
+		CreationInfo: spdxCreationInfo{
+			Created:  inv.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + inv.Host.Name + "-" + inv.Host.Version},
+		},
+		Packages: []spdxPackage{host},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: host.SPDXID},
+		},
+	}
+	for _, c := range append(append([]Component{}, inv.Plugins...), inv.Libraries...) {
+		pkg := spdxPackageOf(c)
+		doc.Packages = append(doc.Packages, pkg)
+		relation := "CONTAINS"
+		if c.Kind == KindLibrary {
+			relation = "DEPENDS_ON"
+		}
+		doc.Relationships = append(doc.Relationships,
+			spdxRelationship{SPDXElementID: host.SPDXID, RelationshipType: relation, RelatedSPDXElement: pkg.SPDXID})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// spdxPackageOf converts a Component to an SPDX package identified by its kind, name, and version.
+func spdxPackageOf(c Component) spdxPackage {
+	pkg := spdxPackage{
+		SPDXID:                "SPDXRef-" + spdxIDUnsafe.ReplaceAllString(c.Kind+"-"+c.Name+"-"+c.Version, "-"),
+		Name:                  c.Name,
+		VersionInfo:           c.Version,
+		Supplier:              spdxNoAssertion,
+		DownloadLocation:      spdxNoAssertion,
+		Homepage:              c.URL,
+		PrimaryPackagePurpose: "APPLICATION",
+		Comment:               c.Error,
+		ExternalRefs: []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  c.PURL(),
+		}},
+	}
+	if c.Kind == KindLibrary {
+		pkg.PrimaryPackagePurpose = "LIBRARY"
+	}
+	if c.Maintainer != "" {
+		pkg.Supplier = "Person: " + c.Maintainer
+	}
+	if c.SHA256 != "" {
+		pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: c.SHA256}}
+	}
+	return pkg
+}
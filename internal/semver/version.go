@@ -55,22 +55,20 @@ func VersionFromString(version string) (*Version, error) {
 
 	// Split version into major, minor, and patch
 	numbers := strings.Split(versionComponents[0], ".")
-
-	// Try to get major, minor, and patch from version string
-	major, err := strconv.Atoi(numbers[0])
-	if err != nil {
-		major = 0
-	}
-
-	minor, err := strconv.Atoi(numbers[1])
-	if err != nil {
-		minor = 0
+	if len(numbers) != 3 {
+		return nil, fmt.Errorf("%w: %q is not major.minor.patch", ErrUnabledToParseVersion, version)
 	}
 
-	patch, err := strconv.Atoi(numbers[2])
-	if err != nil {
-		patch = 0
+	// Get major, minor, and patch from version string, each a non-negative integer
+	var parsed [3]int
+	for i, number := range numbers {
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: %q is not a version number in %q", ErrUnabledToParseVersion, number, version)
+		}
+		parsed[i] = n
 	}
+	major, minor, patch := parsed[0], parsed[1], parsed[2]
 
 	// If major, minor, and patch are all 0, then we can't parse the version'
 	if major == 0 && minor == 0 && patch == 0 {
@@ -0,0 +1,312 @@
+// Command shimgen generates a typed shim for a shared plugin interface. Each method of the shim calls the method on
+// the plugin through registry.Call and returns its result in a registry.Result envelope carrying the call's duration,
+// plugin version, transport, and retry count.
+//
+// Usage, from the package the shim is generated into:
+//
+//	go run ../../shimgen -dir ../../../shared/pkg/animal -type Animal -o animal.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// modulePath is the import path prefix of the shared packages shims are generated for.
+const modulePath = "github.com/bmj2728/PlugsConc/"
+
+// registryImport is the import path of the package providing Call and Result.
+const registryImport = modulePath + "internal/registry"
+
+// maxLine is the longest line the generated code is written with before a signature is wrapped.
+const maxLine = 120
+
+func main() {
+	dir := flag.String("dir", "", "directory of the package declaring the interface")
+	typeName := flag.String("type", "", "name of the interface")
+	out := flag.String("o", "", "output file, stdout when empty")
+	pkgName := flag.String("package", "", "package of the generated file, the GOPACKAGE of go generate by default")
+	flag.Parse()
+	if *dir == "" || *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkgName == "" {
+		*pkgName = os.Getenv("GOPACKAGE")
+	}
+	if *pkgName == "" {
+		*pkgName = "shims"
+	}
+	src, err := generate(*dir, *typeName, *pkgName)
+	if err != nil {
+		log.Fatalf("shimgen: %v", err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		log.Fatalf("shimgen: %v", err)
+	}
+}
+
+// source is the parsed package declaring the interface.
+type source struct {
+	name    string            // package name
+	path    string            // import path
+	imports map[string]string // package name -> import path, from the files of the package
+	used    map[string]string // imports referenced by the generated code, package name -> import path
+}
+
+// generate returns the formatted source of the shim for the interface typeName declared in dir.
+func generate(dir, typeName, pkgName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	for name, pkg := range pkgs {
+		src := &source{name: name, imports: map[string]string{}, used: map[string]string{}}
+		var iface *ast.InterfaceType
+		for _, file := range pkg.Files {
+			for _, imp := range file.Imports {
+				p, _ := strconv.Unquote(imp.Path.Value)
+				alias := path.Base(p)
+				if imp.Name != nil {
+					alias = imp.Name.Name
+				}
+				src.imports[alias] = p
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == typeName {
+					iface, _ = ts.Type.(*ast.InterfaceType)
+				}
+				return iface == nil
+			})
+		}
+		if iface == nil {
+			continue
+		}
+		src.path, err = importPath(dir)
+		if err != nil {
+			return nil, err
+		}
+		src.used[src.name] = src.path
+		return src.shim(iface, typeName, pkgName)
+	}
+	return nil, fmt.Errorf("interface %s not found in %s", typeName, dir)
+}
+
+// importPath returns the import path of the package in dir, which must be inside this module.
+func importPath(dir string) (string, error) {
+	abs, err := absDir(dir)
+	if err != nil {
+		return "", err
+	}
+	i := strings.Index(abs, "/shared/")
+	if i < 0 {
+		return "", fmt.Errorf("%s is not a shared package", dir)
+	}
+	return modulePath + abs[i+1:], nil
+}
+
+// absDir returns dir as a cleaned absolute path with forward slashes.
+func absDir(dir string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if !path.IsAbs(dir) {
+		dir = path.Join(wd, dir)
+	}
+	return path.Clean(dir), nil
+}
+
+// shim renders the shim of iface.
+func (s *source) shim(iface *ast.InterfaceType, typeName, pkgName string) ([]byte, error) {
+	qualified := s.name + "." + typeName
+	shimName := typeName + "Shim"
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// %s calls a dispensed %s plugin through registry.Call, returning each result in a\n", shimName,
+		qualified)
+	fmt.Fprintf(&body, "// registry.Result envelope with the call's metadata.\n")
+	fmt.Fprintf(&body, "type %s struct {\n\tmanager *registry.PluginManager\n\tname string\n", shimName)
+	fmt.Fprintf(&body, "\topts registry.CallOptions\n}\n\n")
+	fmt.Fprintf(&body, "// New%s returns the shim calling the named plugin of manager with opts.\n", shimName)
+	fmt.Fprintf(&body, "func New%s(manager *registry.PluginManager, name string, opts registry.CallOptions) *%s {\n",
+		shimName, shimName)
+	fmt.Fprintf(&body, "\treturn &%s{manager: manager, name: name, opts: opts}\n}\n", shimName)
+
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s embeds an interface, which shimgen does not support", typeName)
+		}
+		method := field.Names[0].Name
+		params, args := s.params(fn)
+		value, call := s.results(fn, method, args)
+		fmt.Fprintf(&body, "\n// %s calls %s on the plugin.\n", method, method)
+		signature := fmt.Sprintf("func (s *%s) %s(%s) (registry.Result[%s], error) {", shimName, method, params, value)
+		if len(signature) > maxLine {
+			// wrap the results onto their own line, as hand-written code in this module does
+			signature = fmt.Sprintf("func (s *%s) %s(%s) (\n\tregistry.Result[%s], error) {", shimName, method, params,
+				value)
+		}
+		fmt.Fprintf(&body, "%s\n", signature)
+		fmt.Fprintf(&body, "\treturn registry.Call(s.manager, s.name, s.opts, func(impl %s) (%s, error) {\n",
+			qualified, value)
+		fmt.Fprintf(&body, "%s\t})\n}\n", call)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by shimgen from %s; DO NOT EDIT.\n\npackage %s\n\nimport (\n", qualified, pkgName)
+	s.used["registry"] = registryImport
+	names := make([]string, 0, len(s.used))
+	for name := range s.used {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iStd, jStd := !strings.Contains(s.used[names[i]], "."), !strings.Contains(s.used[names[j]], ".")
+		if iStd != jStd {
+			return iStd
+		}
+		return s.used[names[i]] < s.used[names[j]]
+	})
+	for i, name := range names {
+		// standard library imports come first, separated from the module's
+		if i > 0 && !strings.Contains(s.used[names[i-1]], ".") && strings.Contains(s.used[name], ".") {
+			out.WriteByte('\n')
+		}
+		if path.Base(s.used[name]) == name {
+			fmt.Fprintf(&out, "\t%q\n", s.used[name])
+		} else {
+			fmt.Fprintf(&out, "\t%s %q\n", name, s.used[name])
+		}
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// params returns the parameter list of fn, naming unnamed parameters, and the arguments passing them on.
+func (s *source) params(fn *ast.FuncType) (string, string) {
+	var params, args []string
+	for i, field := range fn.Params.List {
+		typ := s.expr(field.Type)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+		}
+		for _, name := range names {
+			params = append(params, name.Name+" "+typ)
+			if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+				args = append(args, name.Name+"...")
+			} else {
+				args = append(args, name.Name)
+			}
+		}
+	}
+	return strings.Join(params, ", "), strings.Join(args, ", ")
+}
+
+// results returns the type of the value a method's envelope carries and the body of the function passed to Call.
+// Methods returning only an error carry struct{}.
+func (s *source) results(fn *ast.FuncType, method, args string) (string, string) {
+	var types []string
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			for range max(1, len(field.Names)) {
+				types = append(types, s.expr(field.Type))
+			}
+		}
+	}
+	call := fmt.Sprintf("impl.%s(%s)", method, args)
+	switch {
+	case len(types) == 0:
+		return "struct{}", fmt.Sprintf("\t\t%s\n\t\treturn struct{}{}, nil\n", call)
+	case len(types) == 1 && types[0] == "error":
+		return "struct{}", fmt.Sprintf("\t\treturn struct{}{}, %s\n", call)
+	case len(types) == 1:
+		return types[0], fmt.Sprintf("\t\treturn %s, nil\n", call)
+	default:
+		return types[0], fmt.Sprintf("\t\treturn %s\n", call)
+	}
+}
+
+// expr renders a type expression of the interface, qualifying the types declared in its package.
+func (s *source) expr(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return s.name + "." + t.Name
+		}
+		return t.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			s.used[pkg.Name] = s.imports[pkg.Name]
+		}
+		return s.expr(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + s.expr(t.X)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + s.expr(t.Elt)
+		}
+		return "[" + s.literal(t.Len) + "]" + s.expr(t.Elt)
+	case *ast.MapType:
+		return "map[" + s.expr(t.Key) + "]" + s.expr(t.Value)
+	case *ast.Ellipsis:
+		return "..." + s.expr(t.Elt)
+	case *ast.ChanType:
+		return "chan " + s.expr(t.Value)
+	case *ast.InterfaceType:
+		return "any"
+	case *ast.FuncType:
+		params := make([]string, 0)
+		for _, field := range t.Params.List {
+			for range max(1, len(field.Names)) {
+				params = append(params, s.expr(field.Type))
+			}
+		}
+		results := make([]string, 0)
+		if t.Results != nil {
+			for _, field := range t.Results.List {
+				for range max(1, len(field.Names)) {
+					results = append(results, s.expr(field.Type))
+				}
+			}
+		}
+		sig := "func(" + strings.Join(params, ", ") + ")"
+		switch len(results) {
+		case 0:
+			return sig
+		case 1:
+			return sig + " " + results[0]
+		default:
+			return sig + " (" + strings.Join(results, ", ") + ")"
+		}
+	default:
+		return s.literal(e)
+	}
+}
+
+// literal renders an expression as written.
+func (s *source) literal(e ast.Expr) string {
+	var b bytes.Buffer
+	_ = format.Node(&b, token.NewFileSet(), e)
+	return b.String()
+}
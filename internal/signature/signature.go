@@ -0,0 +1,223 @@
+// Package signature verifies detached signatures of plugin binaries against a trust store of public keys. A plugin's
+// signature is the file plugin.sig next to its binary, and may be an OpenPGP signature made with gpg --detach-sign,
+// armored or binary, or a signature over the binary's SHA-256 digest (or, for Ed25519, the binary itself) made with
+// an ECDSA, Ed25519, or RSA key, raw or base64-encoded, such as cosign sign-blob --key produces.
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// SigFileName is the name of the detached signature file in a plugin directory.
+const SigFileName = "plugin.sig"
+
+// pgpKeyExts and pemKeyExts are the extensions of the trust store files holding OpenPGP keyrings, armored or binary,
+// and PEM-encoded PKIX public keys.
+var (
+	pgpKeyExts = []string{".asc", ".gpg"}
+	pemKeyExts = []string{".pem", ".pub"}
+)
+
+var (
+	// ErrLoadTrustStore indicates that the trust store directory or one of its keys could not be read.
+	ErrLoadTrustStore = errors.New("failed to load trust store")
+	// ErrNoTrustedKeys indicates that a signature cannot be verified because the trust store holds no keys.
+	ErrNoTrustedKeys = errors.New("no trusted keys")
+	// ErrUnsigned indicates that a plugin has no signature file.
+	ErrUnsigned = errors.New("plugin is not signed")
+	// ErrBadSignature indicates that a plugin's signature was not made by any trusted key over its binary.
+	ErrBadSignature = errors.New("plugin signature is not valid")
+)
+
+// key is a trusted PKIX public key and the file it was read from.
+type key struct {
+	name   string
+	public crypto.PublicKey
+}
+
+// TrustStore holds the public keys plugin signatures are verified against.
+type TrustStore struct {
+	keys        []key
+	keyring     openpgp.EntityList
+	storeLogger hclog.Logger
+}
+
+// LoadTrustStore reads every key in dir: OpenPGP keyrings from .asc and .gpg files, and ECDSA, Ed25519, and RSA
+// public keys from PEM-encoded .pem and .pub files. Other files are ignored.
+func LoadTrustStore(dir string, storeLogger hclog.Logger) (*TrustStore, error) {
+	if storeLogger == nil {
+		storeLogger = hclog.Default()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Join(ErrLoadTrustStore, err)
+	}
+	ts := &TrustStore{storeLogger: storeLogger}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !slices.Contains(pgpKeyExts, ext) && !slices.Contains(pemKeyExts, ext) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Join(ErrLoadTrustStore, err)
+		}
+		if slices.Contains(pgpKeyExts, ext) {
+			err = ts.addKeyring(data)
+		} else {
+			err = ts.addPEM(entry.Name(), data)
+		}
+		if err != nil {
+			return nil, errors.Join(ErrLoadTrustStore, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	storeLogger.Debug("Loaded trust store", "dir", dir, "keys", ts.Len())
+	return ts, nil
+}
+
+// addKeyring adds the entities of an armored or binary OpenPGP keyring.
+func (ts *TrustStore) addKeyring(data []byte) error {
+	read := openpgp.ReadKeyRing
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		read = openpgp.ReadArmoredKeyRing
+	}
+	keyring, err := read(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	ts.keyring = append(ts.keyring, keyring...)
+	return nil
+}
+
+// addPEM adds every public key in a PEM file.
+func (ts *TrustStore) addPEM(name string, data []byte) error {
+	found := false
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return err
+		}
+		switch public.(type) {
+		case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		default:
+			return fmt.Errorf("unsupported public key type %T", public)
+		}
+		ts.keys = append(ts.keys, key{name: name, public: public})
+		found = true
+	}
+	if !found {
+		return errors.New("no PEM public key found")
+	}
+	return nil
+}
+
+// Len returns the number of trusted keys.
+func (ts *TrustStore) Len() int {
+	return len(ts.keys) + len(ts.keyring)
+}
+
+// Verify checks the detached signature at sigPath against the binary at binaryPath, returning a description of the
+// trusted key that made it. It returns ErrUnsigned if there is no signature file, and ErrBadSignature if no trusted
+// key made the signature over the binary.
+func (ts *TrustStore) Verify(binaryPath, sigPath string) (string, error) {
+	sig, err := os.ReadFile(sigPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: no %s", ErrUnsigned, filepath.Base(sigPath))
+	}
+	if err != nil {
+		return "", errors.Join(ErrBadSignature, err)
+	}
+	if ts.Len() == 0 {
+		return "", ErrNoTrustedKeys
+	}
+	binary, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return "", errors.Join(ErrBadSignature, err)
+	}
+
+	if signer, ok := ts.verifyPGP(binary, sig); ok {
+		return signer, nil
+	}
+	if signer, ok := ts.verifyPKIX(binary, sig); ok {
+		return signer, nil
+	}
+	return "", fmt.Errorf("%w: %s was not made by a trusted key over %s", ErrBadSignature, filepath.Base(sigPath),
+		filepath.Base(binaryPath))
+}
+
+// verifyPGP checks an armored or binary OpenPGP signature.
+func (ts *TrustStore) verifyPGP(binary, sig []byte) (string, bool) {
+	if len(ts.keyring) == 0 {
+		return "", false
+	}
+	if block, err := armor.Decode(bytes.NewReader(sig)); err == nil {
+		if block.Type != openpgp.SignatureType {
+			return "", false
+		}
+		sig, err = io.ReadAll(block.Body)
+		if err != nil {
+			return "", false
+		}
+	}
+	signer, err := openpgp.CheckDetachedSignature(ts.keyring, bytes.NewReader(binary), bytes.NewReader(sig))
+	if err != nil {
+		ts.storeLogger.Trace("OpenPGP signature not verified", logger.KeyError, err)
+		return "", false
+	}
+	if names := slices.Sorted(maps.Keys(signer.Identities)); len(names) > 0 {
+		return "openpgp:" + names[0], true
+	}
+	return fmt.Sprintf("openpgp:%X", signer.PrimaryKey.Fingerprint), true
+}
+
+// verifyPKIX checks a raw or base64-encoded signature made with one of the trusted PKIX keys.
+func (ts *TrustStore) verifyPKIX(binary, sig []byte) (string, bool) {
+	if len(ts.keys) == 0 {
+		return "", false
+	}
+	candidates := [][]byte{sig}
+	if decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig))); err == nil {
+		candidates = append(candidates, decoded)
+	}
+	digest := sha256.Sum256(binary)
+	for _, k := range ts.keys {
+		for _, s := range candidates {
+			var ok bool
+			switch public := k.public.(type) {
+			case *ecdsa.PublicKey:
+				ok = ecdsa.VerifyASN1(public, digest[:], s)
+			case ed25519.PublicKey:
+				ok = ed25519.Verify(public, binary, s)
+			case *rsa.PublicKey:
+				ok = rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], s) == nil ||
+					rsa.VerifyPSS(public, crypto.SHA256, digest[:], s, nil) == nil
+			}
+			if ok {
+				return k.name, true
+			}
+		}
+	}
+	return "", false
+}
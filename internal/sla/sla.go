@@ -0,0 +1,392 @@
+// Package sla reports plugin availability for SLA dashboards. A Reporter samples the plugin manager's states and
+// restart counts, reads job outcomes from the job history, and persists the totals of each day and plugin as a
+// Rollup in a storage backend. Report sums the rollups over windows of days: the time each plugin spent running,
+// its restarts and crashes, and its job success rate.
+package sla
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/history"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultSampleInterval is how often Run samples the plugins when no interval is configured.
+// DefaultRetentionDays is how many days of rollups are kept when no retention is configured.
+const (
+	DefaultSampleInterval = 10 * time.Second
+	DefaultRetentionDays  = 90
+)
+
+// DefaultWindows are the windows, in days, reported when none are configured: today, the last week, and the last 30
+// days.
+var DefaultWindows = []int{1, 7, 30}
+
+// ErrOpenStore indicates that the rollup bucket could not be opened in the storage backend.
+var ErrOpenStore = errors.New("failed to open SLA rollup store")
+
+// rollupsBucket holds the rollups keyed by day and plugin name, so a scan walks them in day order.
+const rollupsBucket = "sla_daily"
+
+// Source is the plugin manager whose plugins are reported on. *registry.PluginManager implements it.
+type Source interface {
+	Statuses() []registry.PluginStatus
+	AllMetrics() []registry.PluginMetrics
+}
+
+// Rollup is one plugin's totals for one day. Observed is how long the plugin was sampled that day, and Running how
+// much of it the plugin was in the running state; time the host itself was down is not observed. Jobs counts the
+// outcomes of the jobs that called the plugin and finished that day.
+type Rollup struct {
+	Day      string                `json:"day"` // YYYY-MM-DD in the host's time zone
+	Plugin   string                `json:"plugin"`
+	Observed time.Duration         `json:"observed"`
+	Running  time.Duration         `json:"running"`
+	Restarts int                   `json:"restarts"`
+	Crashes  int                   `json:"crashes"`
+	Jobs     history.OutcomeCounts `json:"jobs"`
+}
+
+// Summary is one plugin's totals over a window. Availability is the share of the observed time the plugin was
+// running and SuccessRate the share of its jobs that succeeded, both in [0, 1] and 0 when there is nothing to divide.
+type Summary struct {
+	Plugin       string                `json:"plugin"`
+	Observed     time.Duration         `json:"observed"`
+	Running      time.Duration         `json:"running"`
+	Availability float64               `json:"availability"`
+	Restarts     int                   `json:"restarts"`
+	Crashes      int                   `json:"crashes"`
+	Jobs         history.OutcomeCounts `json:"jobs"`
+	SuccessRate  float64               `json:"success_rate"`
+}
+
+// Window is the Summary of every plugin over the last Days days, today included, starting on Since.
+type Window struct {
+	Days    int       `json:"days"`
+	Since   string    `json:"since"`
+	Plugins []Summary `json:"plugins"`
+}
+
+// Report is the body served for SLA dashboards: a Window for each configured window.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Windows     []Window  `json:"windows"`
+}
+
+// Reporter samples a Source and keeps daily rollups of its plugins in a storage backend. Samples attribute the time
+// since the previous sample to the state each plugin was in at the previous sample, and to the day the sample is
+// taken on.
+type Reporter struct {
+	source         Source
+	rollups        storage.Bucket
+	jobs           *history.Store
+	windows        []int
+	retention      int
+	reporterLogger hclog.Logger
+
+	mu          sync.Mutex
+	day         string             // day the rollups in today are for
+	today       map[string]*Rollup // by plugin
+	lastSample  time.Time
+	lastState   map[string]registry.PluginState
+	lastMetrics map[string]registry.PluginMetrics
+}
+
+// NewReporter opens the rollup bucket in backend for the plugins of source. The backend is owned by the caller, who
+// closes it.
+func NewReporter(source Source, backend storage.Backend, reporterLogger hclog.Logger) (*Reporter, error) {
+	if reporterLogger == nil {
+		reporterLogger = hclog.Default()
+	}
+	rollups, err := backend.Bucket(rollupsBucket)
+	if err != nil {
+		return nil, errors.Join(ErrOpenStore, err)
+	}
+	return &Reporter{
+		source:         source,
+		rollups:        rollups,
+		windows:        DefaultWindows,
+		retention:      DefaultRetentionDays,
+		reporterLogger: reporterLogger,
+		lastState:      make(map[string]registry.PluginState),
+		lastMetrics:    make(map[string]registry.PluginMetrics),
+	}, nil
+}
+
+// WithJobs counts the outcomes of the jobs recorded in store and returns the updated Reporter.
+func (r *Reporter) WithJobs(store *history.Store) *Reporter {
+	r.jobs = store
+	return r
+}
+
+// WithWindows sets the windows reported, in days, and returns the updated Reporter. Non-positive windows are
+// ignored, and without any DefaultWindows are kept.
+func (r *Reporter) WithWindows(days ...int) *Reporter {
+	windows := slices.DeleteFunc(slices.Clone(days), func(d int) bool { return d <= 0 })
+	if len(windows) > 0 {
+		r.windows = windows
+	}
+	return r
+}
+
+// WithRetention sets how many days of rollups are kept, zero or less keeping DefaultRetentionDays, and returns the
+// updated Reporter.
+func (r *Reporter) WithRetention(days int) *Reporter {
+	if days > 0 {
+		r.retention = days
+	}
+	return r
+}
+
+// Run samples the plugins every interval, DefaultSampleInterval when zero or less, and prunes rollups older than the
+// retention once a day, until ctx is canceled. With a positive reportInterval it also logs the summaries of the
+// shortest window that often.
+func (r *Reporter) Run(ctx context.Context, interval, reportInterval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var report <-chan time.Time
+	if reportInterval > 0 {
+		reportTicker := time.NewTicker(reportInterval)
+		defer reportTicker.Stop()
+		report = reportTicker.C
+	}
+	r.sampleAndLog(time.Now())
+	pruned := ""
+	for {
+		select {
+		case <-ctx.Done():
+			r.sampleAndLog(time.Now())
+			return
+		case now := <-ticker.C:
+			r.sampleAndLog(now)
+			if day := now.Format(time.DateOnly); day != pruned {
+				pruned = day
+				if removed, err := r.Prune(now); err != nil {
+					r.reporterLogger.Error("Failed to prune SLA rollups", logger.KeyError, err)
+				} else if removed > 0 {
+					r.reporterLogger.Debug("Pruned SLA rollups", "removed", removed)
+				}
+			}
+		case now := <-report:
+			r.logSummary(now)
+		}
+	}
+}
+
+// sampleAndLog samples the plugins, logging a failure.
+func (r *Reporter) sampleAndLog(now time.Time) {
+	if err := r.Sample(now); err != nil {
+		r.reporterLogger.Error("Failed to sample plugin availability", logger.KeyError, err)
+	}
+}
+
+// logSummary logs the availability and job success rate of every plugin over the shortest window.
+func (r *Reporter) logSummary(now time.Time) {
+	report, err := r.Report(now)
+	if err != nil {
+		r.reporterLogger.Error("Failed to build SLA report", logger.KeyError, err)
+		return
+	}
+	if len(report.Windows) == 0 || len(report.Windows[0].Plugins) == 0 {
+		return
+	}
+	w := report.Windows[0]
+	lines := make([]string, 0, len(w.Plugins))
+	for _, s := range w.Plugins {
+		lines = append(lines, fmt.Sprintf("%s %.2f%% up, %d restarts, %d/%d jobs ok", s.Plugin, s.Availability*100,
+			s.Restarts, s.Jobs.Succeeded, s.Jobs.Total()))
+	}
+	r.reporterLogger.Info("SLA summary", "window_days", w.Days, "since", w.Since, "plugins", strings.Join(lines, "; "))
+}
+
+// Sample adds the time since the previous sample, the restarts and crashes since then, and the day's job outcomes
+// to the rollups of the day now falls on, and persists them.
+func (r *Reporter) Sample(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if day := now.Format(time.DateOnly); day != r.day {
+		today, err := r.load(day)
+		if err != nil {
+			return err
+		}
+		r.day, r.today = day, today
+	}
+	var elapsed time.Duration
+	if !r.lastSample.IsZero() && now.After(r.lastSample) {
+		elapsed = now.Sub(r.lastSample)
+	}
+	r.lastSample = now
+	for _, status := range r.source.Statuses() {
+		ru := r.rollup(status.Name)
+		if prev, ok := r.lastState[status.Name]; ok {
+			ru.Observed += elapsed
+			if prev == registry.PluginRunning {
+				ru.Running += elapsed
+			}
+		}
+		r.lastState[status.Name] = status.State
+	}
+	for _, m := range r.source.AllMetrics() {
+		if prev, ok := r.lastMetrics[m.Name]; ok {
+			ru := r.rollup(m.Name)
+			ru.Restarts += max(m.Restarts-prev.Restarts, 0)
+			ru.Crashes += max(m.Crashes-prev.Crashes, 0)
+		}
+		r.lastMetrics[m.Name] = m
+	}
+	if r.jobs != nil {
+		start, _ := time.ParseInLocation(time.DateOnly, r.day, now.Location())
+		counts, err := r.jobs.Outcomes(start, start.AddDate(0, 0, 1))
+		if err != nil {
+			return fmt.Errorf("count job outcomes: %w", err)
+		}
+		for plugin, c := range counts {
+			if plugin != "" {
+				r.rollup(plugin).Jobs = c
+			}
+		}
+	}
+	var errs []error
+	for _, ru := range r.today {
+		errs = append(errs, r.put(ru))
+	}
+	return errors.Join(errs...)
+}
+
+// rollup returns the plugin's rollup for the day being sampled, creating it if needed. The caller must hold mu.
+func (r *Reporter) rollup(plugin string) *Rollup {
+	ru, ok := r.today[plugin]
+	if !ok {
+		ru = &Rollup{Day: r.day, Plugin: plugin}
+		r.today[plugin] = ru
+	}
+	return ru
+}
+
+// load returns the rollups persisted for day, so a restarted host keeps adding to them.
+func (r *Reporter) load(day string) (map[string]*Rollup, error) {
+	rollups, err := r.Rollups(day, day, "")
+	if err != nil {
+		return nil, err
+	}
+	today := make(map[string]*Rollup, len(rollups))
+	for _, ru := range rollups {
+		today[ru.Plugin] = &ru
+	}
+	return today, nil
+}
+
+// put persists ru.
+func (r *Reporter) put(ru *Rollup) error {
+	data, err := json.Marshal(ru)
+	if err != nil {
+		return err
+	}
+	return r.rollups.Put(rollupKey(ru.Day, ru.Plugin), data)
+}
+
+// Rollups returns the persisted rollups of the days from since to until, both YYYY-MM-DD and inclusive, for the
+// named plugin or, when plugin is empty, every plugin, in day order. Empty bounds are open.
+func (r *Reporter) Rollups(since, until, plugin string) ([]Rollup, error) {
+	rollups := make([]Rollup, 0)
+	err := r.rollups.Scan(nil, false, func(key, value []byte) error {
+		day, name, _ := strings.Cut(string(key), "/")
+		switch {
+		case since != "" && day < since:
+			return nil
+		case until != "" && day > until:
+			return storage.ErrStopScan
+		case plugin != "" && name != plugin:
+			return nil
+		}
+		var ru Rollup
+		if err := json.Unmarshal(value, &ru); err != nil {
+			return fmt.Errorf("rollup %s: %w", key, err)
+		}
+		rollups = append(rollups, ru)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// Report sums the rollups over each configured window ending on the day now falls on.
+func (r *Reporter) Report(now time.Time) (Report, error) {
+	report := Report{GeneratedAt: now, Windows: make([]Window, 0, len(r.windows))}
+	for _, days := range r.windows {
+		since := now.AddDate(0, 0, 1-days).Format(time.DateOnly)
+		rollups, err := r.Rollups(since, now.Format(time.DateOnly), "")
+		if err != nil {
+			return Report{}, err
+		}
+		report.Windows = append(report.Windows, Window{Days: days, Since: since, Plugins: summarize(rollups)})
+	}
+	return report, nil
+}
+
+// Prune removes the rollups of the days before the retention period ending on the day now falls on, returning the
+// number removed.
+func (r *Reporter) Prune(now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, 1-r.retention).Format(time.DateOnly)
+	removed := 0
+	err := r.rollups.Scan(nil, false, func(key, _ []byte) error {
+		if string(key) >= cutoff {
+			return storage.ErrStopScan
+		}
+		if err := r.rollups.Delete(key); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// summarize sums the rollups by plugin, sorted by plugin name.
+func summarize(rollups []Rollup) []Summary {
+	byPlugin := make(map[string]*Summary)
+	for _, ru := range rollups {
+		s, ok := byPlugin[ru.Plugin]
+		if !ok {
+			s = &Summary{Plugin: ru.Plugin}
+			byPlugin[ru.Plugin] = s
+		}
+		s.Observed += ru.Observed
+		s.Running += ru.Running
+		s.Restarts += ru.Restarts
+		s.Crashes += ru.Crashes
+		s.Jobs = s.Jobs.Add(ru.Jobs)
+	}
+	summaries := make([]Summary, 0, len(byPlugin))
+	for _, s := range byPlugin {
+		if s.Observed > 0 {
+			s.Availability = float64(s.Running) / float64(s.Observed)
+		}
+		if total := s.Jobs.Total(); total > 0 {
+			s.SuccessRate = float64(s.Jobs.Succeeded) / float64(total)
+		}
+		summaries = append(summaries, *s)
+	}
+	slices.SortFunc(summaries, func(a, b Summary) int { return strings.Compare(a.Plugin, b.Plugin) })
+	return summaries
+}
+
+// rollupKey orders rollups by day, then plugin name.
+func rollupKey(day, plugin string) []byte {
+	return []byte(day + "/" + plugin)
+}
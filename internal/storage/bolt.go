@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltOpenTimeout bounds how long OpenBolt waits for another process to release the database file lock.
+const boltOpenTimeout = time.Second
+
+// Bolt is a Backend that stores each bucket as a bbolt bucket in a single database file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens, or creates, the bbolt database at path.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Bucket returns the named bucket, creating it if it does not exist.
+func (b *Bolt) Bucket(name string) (Bucket, error) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(name))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &boltBucket{db: b.db, name: []byte(name)}, nil
+}
+
+// Name returns BackendBolt.
+func (b *Bolt) Name() string {
+	return BackendBolt
+}
+
+// Close closes the database file.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// boltBucket is a Bucket stored as a bbolt bucket.
+type boltBucket struct {
+	db   *bolt.DB
+	name []byte
+}
+
+func (b *boltBucket) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		// values are only valid for the life of the transaction
+		v := tx.Bucket(b.name).Get(key)
+		if v == nil {
+			return ErrNotFound
+		}
+		value = bytes.Clone(v)
+		return nil
+	})
+	return value, err
+}
+
+func (b *boltBucket) Put(key, value []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.name).Put(key, value)
+	})
+}
+
+func (b *boltBucket) Delete(key []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.name).Delete(key)
+	})
+}
+
+func (b *boltBucket) Scan(prefix []byte, reverse bool, fn func(key, value []byte) error) error {
+	var entries []entry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(b.name).Cursor()
+		collect := func(k, v []byte) {
+			entries = append(entries, entry{key: bytes.Clone(k), value: bytes.Clone(v)})
+		}
+		if !reverse {
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				collect(k, v)
+			}
+			return nil
+		}
+		var k, v []byte
+		if end := prefixEnd(prefix); end == nil {
+			k, v = c.Last()
+		} else if k, v = c.Seek(end); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, v = c.Prev() {
+			collect(k, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return runScan(entries, fn)
+}
+
+func (b *boltBucket) Count() (int, error) {
+	var n int
+	err := b.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(b.name).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+)
+
+// Memory is a Backend that keeps its buckets in memory, for tests and embedders that need no persistence.
+type Memory struct {
+	mu      sync.RWMutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemory creates an empty in-memory Backend.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket)}
+}
+
+// Bucket returns the named bucket, creating it if it does not exist.
+func (m *Memory) Bucket(name string) (Bucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[name]
+	if !ok {
+		b = &memoryBucket{data: make(map[string][]byte)}
+		m.buckets[name] = b
+	}
+	return b, nil
+}
+
+// Name returns BackendMemory.
+func (m *Memory) Name() string {
+	return BackendMemory
+}
+
+// Close discards every bucket.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets = make(map[string]*memoryBucket)
+	return nil
+}
+
+// memoryBucket is a Bucket backed by a map.
+type memoryBucket struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func (b *memoryBucket) Get(key []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	v, ok := b.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(v), nil
+}
+
+func (b *memoryBucket) Put(key, value []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (b *memoryBucket) Delete(key []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.data, string(key))
+	return nil
+}
+
+func (b *memoryBucket) Scan(prefix []byte, reverse bool, fn func(key, value []byte) error) error {
+	b.mu.RLock()
+	var entries []entry
+	for k, v := range b.data {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			entries = append(entries, entry{key: []byte(k), value: bytes.Clone(v)})
+		}
+	}
+	b.mu.RUnlock()
+	slices.SortFunc(entries, func(x, y entry) int {
+		if reverse {
+			return bytes.Compare(y.key, x.key)
+		}
+		return bytes.Compare(x.key, y.key)
+	})
+	return runScan(entries, fn)
+}
+
+func (b *memoryBucket) Count() (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.data), nil
+}
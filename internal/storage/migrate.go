@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// MetaBucket holds storage metadata, such as the schema version of each component.
+const MetaBucket = "_meta"
+
+// schemaVersionPrefix prefixes the MetaBucket key holding a component's schema version.
+const schemaVersionPrefix = "schema_version/"
+
+// DirectionUp applies a migration; DirectionDown reverts one.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+var (
+	// ErrInvalidMigration indicates that a registered migration is malformed or its versions are not contiguous.
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrMigrationFailed indicates that a migration step failed; steps already applied in the run are rolled back.
+	ErrMigrationFailed = errors.New("migration failed")
+	// ErrRollbackFailed indicates that reverting a migration step failed, leaving the component at the version
+	// reported in the error.
+	ErrRollbackFailed = errors.New("migration rollback failed")
+	// ErrIrreversible indicates that a rollback would revert a migration without a Down step.
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+	// ErrSchemaTooNew indicates that the stored schema was written by a newer host binary than this one.
+	ErrSchemaTooNew = errors.New("stored schema is newer than this binary supports")
+)
+
+// Migration moves one component's persisted data from Version-1 to Version. Down reverts it and may be nil when
+// the change cannot be undone.
+type Migration struct {
+	Component   string
+	Version     int
+	Description string
+	Up          func(Backend) error
+	Down        func(Backend) error
+}
+
+// Step is a migration the Migrator has applied, or would apply in a dry run.
+type Step struct {
+	Component   string `json:"component"`
+	Version     int    `json:"version"`
+	Direction   string `json:"direction"`
+	Description string `json:"description"`
+}
+
+// String describes the step.
+func (s Step) String() string {
+	return fmt.Sprintf("%s %s v%d: %s", s.Component, s.Direction, s.Version, s.Description)
+}
+
+// Migrator versions the persisted schema of each component in a Backend and brings it up to date with the
+// migrations registered by this binary.
+type Migrator struct {
+	backend       Backend
+	migrations    map[string][]Migration
+	migrateLogger hclog.Logger
+}
+
+// NewMigrator creates a Migrator for backend.
+func NewMigrator(backend Backend, migrateLogger hclog.Logger) *Migrator {
+	if migrateLogger == nil {
+		migrateLogger = hclog.Default()
+	}
+	return &Migrator{
+		backend:       backend,
+		migrations:    make(map[string][]Migration),
+		migrateLogger: migrateLogger,
+	}
+}
+
+// Register adds migrations and returns the updated Migrator. Each component's versions must run 1, 2, 3, ...
+// without gaps across all calls, and every migration needs an Up step; violations are reported by Plan and Migrate.
+func (m *Migrator) Register(migrations ...Migration) *Migrator {
+	for _, mig := range migrations {
+		m.migrations[mig.Component] = append(m.migrations[mig.Component], mig)
+	}
+	return m
+}
+
+// Version returns the stored schema version of component, zero if it has never been migrated.
+func (m *Migrator) Version(component string) (int, error) {
+	meta, err := m.backend.Bucket(MetaBucket)
+	if err != nil {
+		return 0, err
+	}
+	v, err := meta.Get([]byte(schemaVersionPrefix + component))
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(v))
+}
+
+// setVersion stores the schema version of component.
+func (m *Migrator) setVersion(component string, version int) error {
+	meta, err := m.backend.Bucket(MetaBucket)
+	if err != nil {
+		return err
+	}
+	return meta.Put([]byte(schemaVersionPrefix+component), []byte(strconv.Itoa(version)))
+}
+
+// Plan returns the steps Migrate would apply, without changing anything.
+func (m *Migrator) Plan() ([]Step, error) {
+	var steps []Step
+	for _, component := range m.components() {
+		pending, err := m.pending(component)
+		if err != nil {
+			return nil, err
+		}
+		for _, mig := range pending {
+			steps = append(steps, step(mig, DirectionUp))
+		}
+	}
+	return steps, nil
+}
+
+// Migrate applies every pending migration in component and version order. With dryRun set, the steps are returned
+// without being applied. If a step fails, the steps already applied in this run are reverted, newest first, and
+// the error wraps ErrMigrationFailed.
+func (m *Migrator) Migrate(dryRun bool) ([]Step, error) {
+	plan, err := m.Plan()
+	if err != nil || dryRun {
+		return plan, err
+	}
+	var applied []Migration
+	for _, component := range m.components() {
+		pending, err := m.pending(component)
+		if err != nil {
+			return nil, err
+		}
+		for _, mig := range pending {
+			m.migrateLogger.Info("Applying migration", "component", mig.Component, "version", mig.Version,
+				"description", mig.Description)
+			err := mig.Up(m.backend)
+			if err == nil {
+				err = m.setVersion(mig.Component, mig.Version)
+			}
+			if err != nil {
+				err = fmt.Errorf("%w: %s: %w", ErrMigrationFailed, step(mig, DirectionUp), err)
+				// the failed step may have partly applied, so it is reverted along with the rest of the run
+				return nil, errors.Join(err, m.revert(append(applied, mig)))
+			}
+			applied = append(applied, mig)
+		}
+	}
+	steps := make([]Step, len(applied))
+	for i, mig := range applied {
+		steps[i] = step(mig, DirectionUp)
+	}
+	return steps, nil
+}
+
+// Rollback reverts component to the target version by running Down steps, newest first. With dryRun set, the steps
+// are returned without being applied. A rollback through a migration without a Down step fails with
+// ErrIrreversible before anything is changed.
+func (m *Migrator) Rollback(component string, target int, dryRun bool) ([]Step, error) {
+	if err := m.validate(component); err != nil {
+		return nil, err
+	}
+	current, err := m.Version(component)
+	if err != nil {
+		return nil, err
+	}
+	if target < 0 || target > current {
+		return nil, fmt.Errorf("%w: cannot roll %s back from v%d to v%d", ErrInvalidMigration, component,
+			current, target)
+	}
+	var revert []Migration
+	var steps []Step
+	for _, mig := range slices.Backward(m.migrations[component]) {
+		if mig.Version <= target || mig.Version > current {
+			continue
+		}
+		if mig.Down == nil {
+			return nil, fmt.Errorf("%w: %s", ErrIrreversible, step(mig, DirectionDown))
+		}
+		revert = append(revert, mig)
+		steps = append(steps, step(mig, DirectionDown))
+	}
+	if dryRun {
+		return steps, nil
+	}
+	slices.Reverse(revert)
+	if err := m.revert(revert); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// revert runs the Down step of each migration, newest first, recording the version after each one.
+// It stops at the first migration without a Down step or whose Down step fails.
+func (m *Migrator) revert(migrations []Migration) error {
+	for _, mig := range slices.Backward(migrations) {
+		if mig.Down == nil {
+			return fmt.Errorf("%w: %w: %s", ErrRollbackFailed, ErrIrreversible, step(mig, DirectionDown))
+		}
+		m.migrateLogger.Warn("Reverting migration", "component", mig.Component, "version", mig.Version,
+			"description", mig.Description)
+		err := mig.Down(m.backend)
+		if err == nil {
+			err = m.setVersion(mig.Component, mig.Version-1)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrRollbackFailed, step(mig, DirectionDown), err)
+		}
+	}
+	return nil
+}
+
+// pending returns the migrations of component newer than its stored version.
+func (m *Migrator) pending(component string) ([]Migration, error) {
+	if err := m.validate(component); err != nil {
+		return nil, err
+	}
+	current, err := m.Version(component)
+	if err != nil {
+		return nil, err
+	}
+	migrations := m.migrations[component]
+	if current > len(migrations) {
+		return nil, fmt.Errorf("%w: %s is at v%d, this binary knows v%d", ErrSchemaTooNew, component, current,
+			len(migrations))
+	}
+	return migrations[current:], nil
+}
+
+// validate sorts the migrations of component and checks that their versions run 1, 2, 3, ... with an Up step each.
+func (m *Migrator) validate(component string) error {
+	migrations := m.migrations[component]
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return fmt.Errorf("%w: %s expected v%d, found v%d", ErrInvalidMigration, component, i+1, mig.Version)
+		}
+		if mig.Up == nil {
+			return fmt.Errorf("%w: %s v%d has no up step", ErrInvalidMigration, component, mig.Version)
+		}
+	}
+	return nil
+}
+
+// components returns the registered component names in sorted order.
+func (m *Migrator) components() []string {
+	names := make([]string, 0, len(m.migrations))
+	for name := range m.migrations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// step describes a migration applied in the given direction.
+func step(mig Migration, direction string) Step {
+	return Step{Component: mig.Component, Version: mig.Version, Direction: direction, Description: mig.Description}
+}
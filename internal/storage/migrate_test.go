@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// recorder records the migration steps run against a backend, failing the step named in fail.
+type recorder struct {
+	ran  []string
+	fail string
+}
+
+// migration returns a migration of component to version that records its Up and Down steps, without a Down step
+// when irreversible is set.
+func (r *recorder) migration(component string, version int, irreversible bool) Migration {
+	mig := Migration{Component: component, Version: version, Description: "test"}
+	name := step(mig, DirectionUp).String()
+	mig.Up = func(Backend) error {
+		r.ran = append(r.ran, name)
+		if name == r.fail {
+			return errors.New("boom")
+		}
+		return nil
+	}
+	if !irreversible {
+		down := step(mig, DirectionDown).String()
+		mig.Down = func(Backend) error {
+			r.ran = append(r.ran, down)
+			return nil
+		}
+	}
+	return mig
+}
+
+// versions returns the stored schema version of each component.
+func versions(t *testing.T, m *Migrator, components ...string) []int {
+	t.Helper()
+	var got []int
+	for _, c := range components {
+		v, err := m.Version(c)
+		if err != nil {
+			t.Fatalf("Version(%s): %v", c, err)
+		}
+		got = append(got, v)
+	}
+	return got
+}
+
+// TestMigrate checks that pending migrations are applied in component and version order, once, and that a dry run
+// changes nothing.
+func TestMigrate(t *testing.T) {
+	r := &recorder{}
+	m := NewMigrator(NewMemory(), hclog.NewNullLogger()).
+		Register(r.migration("b", 1, false), r.migration("a", 2, false)).
+		Register(r.migration("a", 1, false))
+	plan, err := m.Migrate(true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(plan) != 3 || len(r.ran) != 0 || !slices.Equal(versions(t, m, "a", "b"), []int{0, 0}) {
+		t.Fatalf("dry run planned %v and ran %v", plan, r.ran)
+	}
+	steps, err := m.Migrate(false)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	want := []string{"a up v1: test", "a up v2: test", "b up v1: test"}
+	if !slices.Equal(r.ran, want) || len(steps) != 3 {
+		t.Errorf("ran %v, want %v", r.ran, want)
+	}
+	if got := versions(t, m, "a", "b"); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("got versions %v, want [2 1]", got)
+	}
+	if steps, err := m.Migrate(false); err != nil || len(steps) != 0 {
+		t.Errorf("second Migrate applied %v, %v", steps, err)
+	}
+}
+
+// TestMigrateFailureRollsBack checks that a failed step reverts itself and the steps applied before it in the run.
+func TestMigrateFailureRollsBack(t *testing.T) {
+	r := &recorder{fail: "a up v2: test"}
+	m := NewMigrator(NewMemory(), hclog.NewNullLogger()).
+		Register(r.migration("a", 1, false), r.migration("a", 2, false))
+	if _, err := m.Migrate(false); !errors.Is(err, ErrMigrationFailed) {
+		t.Fatalf("got %v, want ErrMigrationFailed", err)
+	}
+	want := []string{"a up v1: test", "a up v2: test", "a down v2: test", "a down v1: test"}
+	if !slices.Equal(r.ran, want) {
+		t.Errorf("ran %v, want %v", r.ran, want)
+	}
+	if got := versions(t, m, "a"); got[0] != 0 {
+		t.Errorf("got version %d, want 0", got[0])
+	}
+}
+
+// TestRollback checks that a component is reverted to the target version, and that rollbacks through irreversible
+// migrations or to versions above the current one are refused before anything changes.
+func TestRollback(t *testing.T) {
+	r := &recorder{}
+	m := NewMigrator(NewMemory(), hclog.NewNullLogger()).
+		Register(r.migration("a", 1, true), r.migration("a", 2, false), r.migration("a", 3, false))
+	if _, err := m.Migrate(false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	r.ran = nil
+	if _, err := m.Rollback("a", 0, false); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("rollback through v1: got %v, want ErrIrreversible", err)
+	}
+	if _, err := m.Rollback("a", 4, false); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("rollback to v4: got %v, want ErrInvalidMigration", err)
+	}
+	if len(r.ran) != 0 {
+		t.Fatalf("refused rollbacks ran %v", r.ran)
+	}
+	steps, err := m.Rollback("a", 1, false)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	want := []string{"a down v3: test", "a down v2: test"}
+	if !slices.Equal(r.ran, want) || len(steps) != 2 {
+		t.Errorf("ran %v, want %v", r.ran, want)
+	}
+	if got := versions(t, m, "a"); got[0] != 1 {
+		t.Errorf("got version %d, want 1", got[0])
+	}
+}
+
+// TestMigrateInvalid checks that gaps in a component's versions and a stored schema newer than the binary are
+// reported.
+func TestMigrateInvalid(t *testing.T) {
+	r := &recorder{}
+	gap := NewMigrator(NewMemory(), hclog.NewNullLogger()).Register(r.migration("a", 2, false))
+	if _, err := gap.Plan(); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("gap: got %v, want ErrInvalidMigration", err)
+	}
+	backend := NewMemory()
+	newer := NewMigrator(backend, hclog.NewNullLogger()).Register(r.migration("a", 1, false), r.migration("a", 2, false))
+	if _, err := newer.Migrate(false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	older := NewMigrator(backend, hclog.NewNullLogger()).Register(r.migration("a", 1, false))
+	if _, err := older.Migrate(false); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("older binary: got %v, want ErrSchemaTooNew", err)
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS kv (
+	bucket TEXT NOT NULL,
+	key    BLOB NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+`
+
+// SQLite is a Backend that stores every bucket in one table of a sqlite database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens, or creates, the sqlite database at path.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, errors.Join(ErrOpenBackend, err, db.Close())
+	}
+	return &SQLite{db: db}, nil
+}
+
+// Bucket returns the named bucket. Buckets exist implicitly as rows sharing the bucket name.
+func (s *SQLite) Bucket(name string) (Bucket, error) {
+	return &sqliteBucket{db: s.db, name: name}, nil
+}
+
+// Name returns BackendSQLite.
+func (s *SQLite) Name() string {
+	return BackendSQLite
+}
+
+// Close closes the database.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// sqliteBucket is a Bucket stored as the rows of the kv table with its name.
+type sqliteBucket struct {
+	db   *sql.DB
+	name string
+}
+
+func (b *sqliteBucket) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := b.db.QueryRow("SELECT value FROM kv WHERE bucket = ? AND key = ?", b.name, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (b *sqliteBucket) Put(key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := b.db.Exec("INSERT OR REPLACE INTO kv (bucket, key, value) VALUES (?, ?, ?)", b.name, key, value)
+	return err
+}
+
+func (b *sqliteBucket) Delete(key []byte) error {
+	_, err := b.db.Exec("DELETE FROM kv WHERE bucket = ? AND key = ?", b.name, key)
+	return err
+}
+
+func (b *sqliteBucket) Scan(prefix []byte, reverse bool, fn func(key, value []byte) error) error {
+	query := "SELECT key, value FROM kv WHERE bucket = ?"
+	args := []any{b.name}
+	if len(prefix) > 0 {
+		query += " AND key >= ?"
+		args = append(args, prefix)
+	}
+	if end := prefixEnd(prefix); end != nil {
+		query += " AND key < ?"
+		args = append(args, end)
+	}
+	if reverse {
+		query += " ORDER BY key DESC"
+	} else {
+		query += " ORDER BY key ASC"
+	}
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.key, &e.value); err != nil {
+			return errors.Join(err, rows.Close())
+		}
+		entries = append(entries, e)
+	}
+	if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+		return err
+	}
+	return runScan(entries, fn)
+}
+
+func (b *sqliteBucket) Count() (int, error) {
+	var n int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM kv WHERE bucket = ?", b.name).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,116 @@
+// Package storage provides the key-value persistence used by host services. A Backend holds named buckets of
+// ordered keys, so embedders can choose sqlite, bbolt, or an in-memory store once and operators get a single data
+// directory to back up.
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BackendSQLite stores buckets in a sqlite database file in the data directory.
+// BackendBolt stores buckets in a bbolt database file in the data directory.
+// BackendMemory keeps buckets in memory; nothing is persisted.
+const (
+	BackendSQLite = "sqlite"
+	BackendBolt   = "bbolt"
+	BackendMemory = "memory"
+)
+
+// SQLiteFileName and BoltFileName are the database file names created in the data directory.
+const (
+	SQLiteFileName = "plugsconc.db"
+	BoltFileName   = "plugsconc.bolt"
+)
+
+// Backends lists the supported backend names.
+var Backends = []string{BackendSQLite, BackendBolt, BackendMemory}
+
+var (
+	// ErrNotFound indicates that a key does not exist in the bucket.
+	ErrNotFound = errors.New("key not found")
+	// ErrUnknownBackend indicates that the requested backend is not one of Backends.
+	ErrUnknownBackend = errors.New("unknown storage backend")
+	// ErrOpenBackend indicates that the backend's data directory or database could not be opened.
+	ErrOpenBackend = errors.New("failed to open storage backend")
+	// ErrStopScan may be returned by a Scan callback to stop the scan without error.
+	ErrStopScan = errors.New("stop scan")
+)
+
+// Backend is a set of named buckets sharing one database.
+type Backend interface {
+	// Bucket returns the named bucket, creating it if it does not exist.
+	Bucket(name string) (Bucket, error)
+	// Name returns the backend's name, one of Backends.
+	Name() string
+	// Close releases the backend's database.
+	Close() error
+}
+
+// Bucket is an ordered key-value collection. Keys are compared byte-wise.
+type Bucket interface {
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(key []byte) ([]byte, error)
+	// Put stores value under key, replacing any existing value.
+	Put(key, value []byte) error
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(key []byte) error
+	// Scan calls fn for every key with the prefix in ascending key order, or descending when reverse is set.
+	// The matching entries are read before fn is first called, so fn may modify the bucket. Returning ErrStopScan
+	// from fn stops the scan without error.
+	Scan(prefix []byte, reverse bool, fn func(key, value []byte) error) error
+	// Count returns the number of keys in the bucket.
+	Count() (int, error)
+}
+
+// Open opens the named backend, storing its database in dir. The directory is created if it does not exist;
+// it is not used by BackendMemory.
+func Open(backend string, dir string) (Backend, error) {
+	if backend == BackendMemory {
+		return NewMemory(), nil
+	}
+	if backend != BackendSQLite && backend != BackendBolt {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, backend)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Join(ErrOpenBackend, err)
+	}
+	if backend == BackendBolt {
+		return OpenBolt(filepath.Join(dir, BoltFileName))
+	}
+	return OpenSQLite(filepath.Join(dir, SQLiteFileName))
+}
+
+// entry is a key-value pair read during a scan.
+type entry struct {
+	key   []byte
+	value []byte
+}
+
+// runScan calls fn for each entry, treating ErrStopScan as a normal end of the scan.
+func runScan(entries []entry, fn func(key, value []byte) error) error {
+	for _, e := range entries {
+		if err := fn(e.key, e.value); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key greater than every key with the prefix, or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package timestamp keeps the times the host records comparable across machines: every recorded time is taken in
+// UTC and serialized as RFC3339Nano, and timestamps written by older hosts or by hclog in its default layouts are
+// parsed back into UTC.
+package timestamp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Format is the layout recorded timestamps are serialized with.
+const Format = time.RFC3339Nano
+
+// ErrUnparseable indicates that a timestamp matches none of the layouts Parse accepts.
+var ErrUnparseable = errors.New("unparseable timestamp")
+
+// layouts are the layouts Parse tries, in order: Format, then the JSON and plain layouts hclog uses by default.
+var layouts = []string{Format, hclog.TimeFormatJSON, hclog.TimeFormat}
+
+// Now returns the current time in UTC. It is the time source of every recorded timestamp, and can be passed as an
+// hclog TimeFn.
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// String serializes t in UTC with Format. The zero time serializes as an empty string.
+func String(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(Format)
+}
+
+// Parse parses a timestamp written with Format or one of hclog's default layouts and returns it in UTC. The offset
+// recorded in s is honored, so local times written by another machine convert to the same instant.
+func Parse(s string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrUnparseable, s)
+}
+
+// Normalize reformats s with Format in UTC, returning s unchanged when it cannot be parsed.
+func Normalize(s string) string {
+	t, err := Parse(s)
+	if err != nil {
+		return s
+	}
+	return String(t)
+}
@@ -0,0 +1,211 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultAdaptiveInterval is how often the adaptive controller adjusts concurrency when no interval is configured.
+// DefaultAdaptiveDecrease is the factor concurrency is multiplied by on back-off when none is configured.
+const (
+	DefaultAdaptiveInterval = 5 * time.Second
+	DefaultAdaptiveDecrease = 0.5
+)
+
+// adaptiveSmoothing is the weight of the latest interval in the smoothed latency and error rate, so a single slow
+// interval does not halve the pool on its own.
+const adaptiveSmoothing = 0.5
+
+// ErrInvalidAdaptiveConfig indicates that an AdaptiveConfig has inconsistent bounds or factors.
+var ErrInvalidAdaptiveConfig = errors.New("invalid adaptive concurrency config")
+
+// AdaptiveConfig bounds and tunes an AdaptiveConcurrency controller.
+type AdaptiveConfig struct {
+	MinWorkers     int
+	MaxWorkers     int
+	TargetLatency  time.Duration // smoothed job latency above which concurrency is cut
+	MaxErrorRate   float64       // smoothed share of failed jobs, 0 to 1, above which concurrency is cut
+	Interval       time.Duration // how often concurrency is adjusted, DefaultAdaptiveInterval when zero
+	DecreaseFactor float64       // multiplies the worker count on back-off, DefaultAdaptiveDecrease when zero
+}
+
+// validate fills in defaults and checks the config.
+func (c *AdaptiveConfig) validate() error {
+	if c.Interval <= 0 {
+		c.Interval = DefaultAdaptiveInterval
+	}
+	if c.DecreaseFactor == 0 {
+		c.DecreaseFactor = DefaultAdaptiveDecrease
+	}
+	switch {
+	case c.MinWorkers < 1:
+		return fmt.Errorf("%w: min workers %d is less than one", ErrInvalidAdaptiveConfig, c.MinWorkers)
+	case c.MaxWorkers < c.MinWorkers:
+		return fmt.Errorf("%w: max workers %d is less than min workers %d", ErrInvalidAdaptiveConfig,
+			c.MaxWorkers, c.MinWorkers)
+	case c.TargetLatency <= 0:
+		return fmt.Errorf("%w: target latency must be positive", ErrInvalidAdaptiveConfig)
+	case c.MaxErrorRate < 0 || c.MaxErrorRate > 1:
+		return fmt.Errorf("%w: max error rate %v is not between 0 and 1", ErrInvalidAdaptiveConfig, c.MaxErrorRate)
+	case c.DecreaseFactor <= 0 || c.DecreaseFactor >= 1:
+		return fmt.Errorf("%w: decrease factor %v is not between 0 and 1", ErrInvalidAdaptiveConfig,
+			c.DecreaseFactor)
+	}
+	return nil
+}
+
+// AdaptiveStats is a point-in-time view of an AdaptiveConcurrency controller.
+type AdaptiveStats struct {
+	Workers    int           `json:"workers"`
+	Latency    time.Duration `json:"smoothed_latency"`
+	ErrorRate  float64       `json:"smoothed_error_rate"`
+	Increases  int           `json:"increases"`
+	Decreases  int           `json:"decreases"`
+	AdjustedAt time.Time     `json:"adjusted_at"`
+}
+
+// AdaptiveConcurrency adjusts the number of workers in a pool with additive-increase/multiplicative-decrease: while
+// the smoothed job latency and error rate stay within their targets and jobs are queued, it adds a worker each
+// interval; when either exceeds its target, such as when a plugin slows down or starts failing, it multiplies the
+// worker count by the decrease factor. The worker count always stays between the configured bounds.
+type AdaptiveConcurrency struct {
+	adaptiveLogger hclog.Logger
+	pool           *Pool
+	conf           AdaptiveConfig
+	mu             sync.Mutex
+	jobs           int           // attempts finished this interval
+	failures       int           // attempts failed this interval
+	total          time.Duration // summed latency of the attempts finished this interval
+	stats          AdaptiveStats
+	sampled        bool // whether the smoothed values hold a sample
+}
+
+// NewAdaptiveConcurrency creates a controller for pool, returning ErrInvalidAdaptiveConfig if the config is
+// inconsistent. Its Middleware must be registered with the pool before Run, and Run started to adjust concurrency.
+func NewAdaptiveConcurrency(pool *Pool, conf AdaptiveConfig, adaptiveLogger hclog.Logger) (*AdaptiveConcurrency,
+	error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	if adaptiveLogger == nil {
+		adaptiveLogger = hclog.Default()
+	}
+	return &AdaptiveConcurrency{adaptiveLogger: adaptiveLogger, pool: pool, conf: conf}, nil
+}
+
+// Middleware measures the latency and outcome of every job attempt. Jobs canceled by their caller are not counted.
+func (ac *AdaptiveConcurrency) Middleware() Middleware {
+	return func(next WorkUnit) WorkUnit {
+		return func(ctx context.Context) (any, error) {
+			start := time.Now()
+			v, err := next(ctx)
+			if !errors.Is(err, context.Canceled) {
+				ac.observe(time.Since(start), err != nil)
+			}
+			return v, err
+		}
+	}
+}
+
+// observe records a finished job attempt.
+func (ac *AdaptiveConcurrency) observe(latency time.Duration, failed bool) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.jobs++
+	ac.total += latency
+	if failed {
+		ac.failures++
+	}
+}
+
+// Stats returns the controller's smoothed measurements and adjustments so far.
+func (ac *AdaptiveConcurrency) Stats() AdaptiveStats {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	stats := ac.stats
+	stats.Workers = ac.pool.Workers()
+	return stats
+}
+
+// Run first brings the pool within the configured bounds, then adjusts its concurrency every interval until ctx is
+// canceled or the pool is closed.
+func (ac *AdaptiveConcurrency) Run(ctx context.Context) {
+	workers := ac.pool.Workers()
+	if bounded := min(max(workers, ac.conf.MinWorkers), ac.conf.MaxWorkers); bounded != workers {
+		if err := ac.pool.Resize(bounded); err != nil {
+			return
+		}
+	}
+	ticker := time.NewTicker(ac.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if errors.Is(ac.adjust(), ErrPoolClosed) {
+				return
+			}
+		}
+	}
+}
+
+// adjust folds the interval's measurements into the smoothed values and resizes the pool accordingly. An interval
+// without jobs leaves the pool unchanged.
+func (ac *AdaptiveConcurrency) adjust() error {
+	ac.mu.Lock()
+	jobs, failures, total := ac.jobs, ac.failures, ac.total
+	ac.jobs, ac.failures, ac.total = 0, 0, 0
+	if jobs == 0 {
+		ac.mu.Unlock()
+		return nil
+	}
+	latency := total / time.Duration(jobs)
+	errorRate := float64(failures) / float64(jobs)
+	if ac.sampled {
+		latency = time.Duration(adaptiveSmoothing*float64(latency) + (1-adaptiveSmoothing)*float64(ac.stats.Latency))
+		errorRate = adaptiveSmoothing*errorRate + (1-adaptiveSmoothing)*ac.stats.ErrorRate
+	}
+	ac.stats.Latency, ac.stats.ErrorRate, ac.sampled = latency, errorRate, true
+	ac.mu.Unlock()
+
+	workers := ac.pool.Workers()
+	next := workers
+	degraded := latency > ac.conf.TargetLatency || errorRate > ac.conf.MaxErrorRate
+	switch {
+	case degraded:
+		next = max(int(math.Floor(float64(workers)*ac.conf.DecreaseFactor)), ac.conf.MinWorkers)
+	case ac.pool.queued() > 0:
+		next = min(workers+1, ac.conf.MaxWorkers)
+	}
+	if next == workers {
+		return nil
+	}
+	if err := ac.pool.Resize(next); err != nil {
+		return err
+	}
+
+	ac.mu.Lock()
+	if next > workers {
+		ac.stats.Increases++
+	} else {
+		ac.stats.Decreases++
+	}
+	ac.stats.AdjustedAt = timestamp.Now()
+	ac.mu.Unlock()
+	if degraded {
+		ac.adaptiveLogger.Warn("Reducing pool concurrency", "from", workers, "to", next,
+			"latency", latency.Round(time.Millisecond), "error_rate", errorRate)
+	} else {
+		ac.adaptiveLogger.Debug("Increasing pool concurrency", "from", workers, "to", next,
+			"latency", latency.Round(time.Millisecond), "error_rate", errorRate)
+	}
+	return nil
+}
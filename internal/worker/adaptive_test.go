@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestAdaptiveConfigValidate checks that inconsistent controller configs are refused and defaults are filled in.
+func TestAdaptiveConfigValidate(t *testing.T) {
+	valid := AdaptiveConfig{MinWorkers: 1, MaxWorkers: 4, TargetLatency: time.Second, MaxErrorRate: 0.1}
+	tests := map[string]func(c *AdaptiveConfig){
+		"no min workers":     func(c *AdaptiveConfig) { c.MinWorkers = 0 },
+		"max below min":      func(c *AdaptiveConfig) { c.MaxWorkers = 0 },
+		"no target latency":  func(c *AdaptiveConfig) { c.TargetLatency = 0 },
+		"error rate above 1": func(c *AdaptiveConfig) { c.MaxErrorRate = 1.5 },
+		"decrease factor 1":  func(c *AdaptiveConfig) { c.DecreaseFactor = 1 },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			conf := valid
+			mutate(&conf)
+			if _, err := NewAdaptiveConcurrency(nil, conf, hclog.NewNullLogger()); !errors.Is(err,
+				ErrInvalidAdaptiveConfig) {
+				t.Errorf("got %v, want ErrInvalidAdaptiveConfig", err)
+			}
+		})
+	}
+	conf := valid
+	if err := conf.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if conf.Interval != DefaultAdaptiveInterval || conf.DecreaseFactor != DefaultAdaptiveDecrease {
+		t.Errorf("got interval %s and decrease factor %v, want the defaults", conf.Interval, conf.DecreaseFactor)
+	}
+}
+
+// TestAdaptiveDecrease checks that the worker count is cut by the decrease factor, but not below the minimum, when
+// the smoothed latency or error rate exceeds its target, and left alone after an interval without jobs.
+func TestAdaptiveDecrease(t *testing.T) {
+	pool := NewPool(8, false, 1, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	ac, err := NewAdaptiveConcurrency(pool, AdaptiveConfig{
+		MinWorkers:    3,
+		MaxWorkers:    8,
+		TargetLatency: 100 * time.Millisecond,
+		MaxErrorRate:  0.5,
+	}, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewAdaptiveConcurrency: %v", err)
+	}
+	steps := []struct {
+		name    string
+		latency time.Duration
+		failed  bool
+		want    int
+	}{
+		{name: "slow", latency: time.Second, want: 4},
+		{name: "idle", want: 4},
+		{name: "failing", latency: time.Millisecond, failed: true, want: 3},
+		{name: "at minimum", latency: time.Second, failed: true, want: 3},
+	}
+	for _, step := range steps {
+		if step.name != "idle" {
+			ac.observe(step.latency, step.failed)
+		}
+		if err := ac.adjust(); err != nil {
+			t.Fatalf("%s: adjust: %v", step.name, err)
+		}
+		if got := pool.Workers(); got != step.want {
+			t.Errorf("%s: got %d workers, want %d", step.name, got, step.want)
+		}
+	}
+	if got := ac.Stats().Decreases; got != 2 {
+		t.Errorf("got %d decreases, want 2", got)
+	}
+}
+
+// TestAdaptiveIncrease checks that a worker is added while jobs are queued and latency and errors are within target,
+// up to the maximum.
+func TestAdaptiveIncrease(t *testing.T) {
+	pool := NewPool(1, false, 4, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	release := make(chan struct{})
+	defer close(release)
+	for range 4 {
+		if err := pool.Submit(NewJob(context.Background(), func(context.Context) (any, error) {
+			<-release
+			return nil, nil
+		})); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	ac, err := NewAdaptiveConcurrency(pool, AdaptiveConfig{
+		MinWorkers:    1,
+		MaxWorkers:    2,
+		TargetLatency: time.Second,
+		MaxErrorRate:  0.1,
+	}, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewAdaptiveConcurrency: %v", err)
+	}
+	for _, want := range []int{2, 2} {
+		ac.observe(time.Millisecond, false)
+		if err := ac.adjust(); err != nil {
+			t.Fatalf("adjust: %v", err)
+		}
+		if got := pool.Workers(); got != want {
+			t.Errorf("got %d workers, want %d", got, want)
+		}
+	}
+	if stats := ac.Stats(); stats.Increases != 1 || stats.Latency != time.Millisecond {
+		t.Errorf("got stats %+v, want one increase at 1ms latency", stats)
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BatchResult is the aggregated outcome of a Batch. Values and Errors are indexed in the order the jobs were given;
+// a job that could not be submitted is counted as failed with its submission error.
+type BatchResult struct {
+	BatchID    string        `json:"batch_id"`
+	Values     []any         `json:"values"`
+	Errors     []error       `json:"-"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Err returns the errors of every failed job joined into a single error, or nil if every job succeeded.
+func (r *BatchResult) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// Batch is a set of jobs submitted together whose results can be awaited collectively, unlike SubmitBatch, which only
+// reports whether each job was submitted. Jobs carry the batch ID in their context and JobResult, and still publish
+// their individual results on the pool's results channel.
+type Batch struct {
+	group  *JobGroup
+	once   sync.Once
+	result *BatchResult
+}
+
+// NewBatch submits jobs to pool as a batch, tying each job's context to ctx so canceling ctx cancels every job in the
+// batch, and returns the Batch tracking their completion.
+func NewBatch(ctx context.Context, pool *Pool, jobs []*Job) *Batch {
+	group := NewJobGroup(ctx, pool)
+	for _, job := range jobs {
+		// adding cannot fail before the group is submitted
+		_ = group.Add(job)
+	}
+	_ = group.Submit()
+	return &Batch{group: group}
+}
+
+// ID returns the batch's identifier, carried by every job in it.
+func (b *Batch) ID() string {
+	return b.group.ID()
+}
+
+// Wait blocks until every job in the batch has finished and returns the aggregated result. If the pool's workers
+// exit first, jobs that never ran are recorded as failed with ErrPoolTerminated. It may be called more than once.
+func (b *Batch) Wait() *BatchResult {
+	b.once.Do(func() {
+		gr := b.group.Wait()
+		res := &BatchResult{
+			BatchID:    gr.GroupID,
+			Values:     gr.Values,
+			Errors:     gr.Errors,
+			StartedAt:  gr.StartedAt,
+			FinishedAt: gr.FinishedAt,
+			Duration:   gr.Duration,
+		}
+		for _, err := range gr.Errors {
+			if err != nil {
+				res.Failed++
+			} else {
+				res.Succeeded++
+			}
+		}
+		b.result = res
+	})
+	return b.result
+}
+
+// Result returns a channel receiving the aggregated result once every job in the batch has finished, then closed,
+// for callers selecting on the batch alongside other events.
+func (b *Batch) Result() <-chan *BatchResult {
+	ch := make(chan *BatchResult, 1)
+	go func() {
+		ch <- b.Wait()
+		close(ch)
+	}()
+	return ch
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrChaosInjected indicates that a job failure was deliberately injected by chaos mode.
+var ErrChaosInjected = errors.New("chaos: injected job failure")
+
+// PluginKiller terminates a plugin connection chosen by the caller, returning the name of the killed plugin.
+// It is supplied by whatever owns the plugin clients so chaos mode can exercise restart behavior.
+type PluginKiller func() (string, error)
+
+// Chaos injects random delays, failures, panics, and plugin connection kills into job execution.
+// It is opt-in and intended for staging environments only.
+type Chaos struct {
+	mu          sync.Mutex
+	rng         *rand.Rand
+	conf        config.Chaos
+	killer      PluginKiller
+	chaosLogger hclog.Logger
+}
+
+// NewChaos creates a Chaos injector from the given configuration. It returns nil when chaos mode is not enabled by
+// the config or the PLUGSCONC_CHAOS environment variable, which disables fault injection in the pool.
+func NewChaos(conf config.Chaos, chaosLogger hclog.Logger) *Chaos {
+	if !conf.IsEnabled() {
+		return nil
+	}
+	if chaosLogger == nil {
+		chaosLogger = hclog.Default()
+	}
+	chaosLogger.Warn("Chaos mode enabled, jobs will be randomly delayed, failed, and panicked",
+		"delay_rate", conf.DelayRate,
+		"fail_rate", conf.FailRate,
+		"panic_rate", conf.PanicRate,
+		"kill_rate", conf.KillRate)
+	return &Chaos{
+		mu:          sync.Mutex{},
+		rng:         rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		conf:        conf,
+		chaosLogger: chaosLogger,
+	}
+}
+
+// WithPluginKiller sets the function used to kill a random plugin connection and returns the updated Chaos. It does
+// nothing on a nil Chaos, so it can be chained onto NewChaos whether or not chaos mode is enabled.
+func (c *Chaos) WithPluginKiller(killer PluginKiller) *Chaos {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.killer = killer
+	return c
+}
+
+// roll reports whether an event with the given probability should occur.
+func (c *Chaos) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < rate
+}
+
+// delay returns a random delay up to the configured maximum.
+func (c *Chaos) delay() time.Duration {
+	if c.conf.MaxDelay <= 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.rng.IntN(c.conf.MaxDelay)+1) * time.Millisecond
+}
+
+// Wrap returns a WorkUnit that applies the configured faults before delegating to next. Injected panics are
+// recovered by the worker's panic safety and surface as job errors.
+func (c *Chaos) Wrap(jobID string, next WorkUnit) WorkUnit {
+	if c == nil {
+		return next
+	}
+	return func(ctx context.Context) (any, error) {
+		faultLogger := c.chaosLogger.With(logger.KeyJobID, jobID)
+		if c.roll(c.conf.KillRate) {
+			c.mu.Lock()
+			killer := c.killer
+			c.mu.Unlock()
+			if killer != nil {
+				name, err := killer()
+				if err != nil {
+					faultLogger.Error("Chaos failed to kill plugin connection", logger.KeyError, err)
+				} else {
+					faultLogger.Warn("Chaos killed plugin connection", logger.KeyPluginName, name)
+				}
+			}
+		}
+		if c.roll(c.conf.DelayRate) {
+			d := c.delay()
+			faultLogger.Warn("Chaos delaying job", "delay", d.String())
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil, ctx.Err()
+			case <-t.C:
+			}
+		}
+		if c.roll(c.conf.PanicRate) {
+			faultLogger.Warn("Chaos panicking job")
+			panic(ErrChaosInjected)
+		}
+		if c.roll(c.conf.FailRate) {
+			faultLogger.Warn("Chaos failing job")
+			return nil, ErrChaosInjected
+		}
+		return next(ctx)
+	}
+}
@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// classPollInterval is how often a worker whose queues are closed checks whether jobs are still held back by their
+// class, so it does not exit while they wait to run.
+const classPollInterval = 10 * time.Millisecond
+
+// ClassLimit bounds how the jobs of a class run: at most MaxConcurrent at once and at most Rate started per second,
+// letting up to Burst start at once after an idle period. A bound of zero or less is unlimited.
+type ClassLimit struct {
+	MaxConcurrent int
+	Rate          float64
+	Burst         int
+}
+
+// unlimited reports whether the limit bounds nothing.
+func (l ClassLimit) unlimited() bool {
+	return l.MaxConcurrent <= 0 && l.Rate <= 0
+}
+
+// jobClasses admits the jobs of limited classes. A job whose class is at its limit is held back in the class's
+// backlog instead of occupying a worker, so a flood of slow jobs of one class cannot starve the others, and is handed
+// to the next free worker on ready once its class has room, in the order it was taken from the queues.
+type jobClasses struct {
+	mu    sync.Mutex
+	gates map[string]*classGate
+	held  int           // jobs in a backlog or being handed to a worker, guarded by mu
+	ready chan *Job     // admitted jobs that were held back
+	quit  chan struct{} // closed when the pool is terminated, abandoning held jobs
+}
+
+// classGate is the state of a limited class.
+type classGate struct {
+	limit    ClassLimit
+	interval time.Duration // between starts at Rate, zero when the rate is unlimited
+	running  int
+	due      time.Time // when the start after the last one would be due were there no burst
+	backlog  []*Job
+	timer    *time.Timer // schedules the backlog once the rate allows another start, nil when not armed
+}
+
+// newJobClasses returns an empty set of class limits for a pool terminated by closing quit.
+func newJobClasses(quit chan struct{}) *jobClasses {
+	return &jobClasses{
+		gates: make(map[string]*classGate),
+		ready: make(chan *Job),
+		quit:  quit,
+	}
+}
+
+// set limits class with limit, removing its limit when limit is unlimited.
+func (c *jobClasses) set(class string, limit ClassLimit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if limit.unlimited() {
+		delete(c.gates, class)
+		return
+	}
+	g := &classGate{limit: limit}
+	g.limit.Burst = max(limit.Burst, 1)
+	if limit.Rate > 0 {
+		g.interval = time.Duration(float64(time.Second) / limit.Rate)
+	}
+	c.gates[class] = g
+}
+
+// readyJobs returns the channel held jobs are handed to workers on, nil when no class is limited.
+func (c *jobClasses) readyJobs() <-chan *Job {
+	if c == nil {
+		return nil
+	}
+	return c.ready
+}
+
+// pending reports whether jobs are still held back, or being handed to a worker.
+func (c *jobClasses) pending() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.held > 0
+}
+
+// admit reports whether job may run now, taking a slot of its class. A job that may not is held back and handed to
+// a worker on ready once its class has room.
+func (c *jobClasses) admit(job *Job) bool {
+	if c == nil || job.Class == "" {
+		return true
+	}
+	if job.admitted {
+		job.admitted = false
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g, ok := c.gates[job.Class]
+	if !ok {
+		return true
+	}
+	now := time.Now()
+	// jobs already held back go first
+	if len(g.backlog) == 0 && g.free() && g.wait(now) <= 0 {
+		g.take(now)
+		return true
+	}
+	g.backlog = append(g.backlog, job)
+	c.held++
+	c.schedule(g)
+	return false
+}
+
+// release frees the slot job took of its class once it has run.
+func (c *jobClasses) release(job *Job) {
+	if c == nil || job.Class == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g, ok := c.gates[job.Class]
+	if !ok {
+		return
+	}
+	g.running = max(g.running-1, 0)
+	c.schedule(g)
+}
+
+// schedule admits the jobs at the head of g's backlog while the class has room, arming a timer when its rate delays
+// the next one. The caller must hold mu.
+func (c *jobClasses) schedule(g *classGate) {
+	for len(g.backlog) > 0 && g.free() {
+		now := time.Now()
+		if wait := g.wait(now); wait > 0 {
+			if g.timer == nil {
+				g.timer = time.AfterFunc(wait, func() {
+					c.mu.Lock()
+					defer c.mu.Unlock()
+					g.timer = nil
+					c.schedule(g)
+				})
+			}
+			return
+		}
+		job := g.backlog[0]
+		g.backlog[0] = nil
+		g.backlog = g.backlog[1:]
+		g.take(now)
+		job.admitted = true
+		go c.handOff(job)
+	}
+}
+
+// handOff hands an admitted job to the next free worker, abandoning it once the pool is terminated.
+func (c *jobClasses) handOff(job *Job) {
+	select {
+	case c.ready <- job:
+	case <-c.quit:
+	}
+	c.mu.Lock()
+	c.held--
+	c.mu.Unlock()
+}
+
+// free reports whether another job of the class may run alongside those running.
+func (g *classGate) free() bool {
+	return g.limit.MaxConcurrent <= 0 || g.running < g.limit.MaxConcurrent
+}
+
+// wait returns how long the class's rate delays the next start from now, zero or less when it may start.
+func (g *classGate) wait(now time.Time) time.Duration {
+	if g.interval == 0 {
+		return 0
+	}
+	due := g.due
+	if due.Before(now) {
+		due = now
+	}
+	return due.Add(-time.Duration(g.limit.Burst-1) * g.interval).Sub(now)
+}
+
+// take records that a job of the class starts at now.
+func (g *classGate) take(now time.Time) {
+	g.running++
+	if g.interval == 0 {
+		return
+	}
+	if g.due.Before(now) {
+		g.due = now
+	}
+	g.due = g.due.Add(g.interval)
+}
@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule indicates that a schedule spec is neither a cron expression nor a descriptor ParseSchedule
+// understands.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// cronSearchLimit bounds how far ahead Next looks for a matching time, so an expression that never matches, such as
+// February 30th, ends the schedule instead of looping forever.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Schedule decides when a scheduled job runs.
+type Schedule interface {
+	// Next returns the first time after t the job runs, or the zero time when it never runs again.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule running a job every interval, or never when interval is zero or less.
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+// intervalSchedule runs a job at a fixed interval.
+type intervalSchedule time.Duration
+
+// Next returns t plus the interval.
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	if s <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(s))
+}
+
+// cronDescriptors maps the predefined descriptors to the expressions they stand for.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronMonths and cronWeekdays are the names the month and day of week fields accept.
+var (
+	cronMonths   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// cronSchedule is a parsed cron expression, each field a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool // the field starts with *, so only the other day field restricts the day
+}
+
+// ParseSchedule parses spec as a standard five-field cron expression, "minute hour day-of-month month day-of-week",
+// with lists, ranges, steps, and month and weekday names, e.g. "*/15 9-17 * * mon-fri"; as one of the descriptors
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight, and @hourly; or as "@every <duration>", e.g. "@every 30s".
+// When both day fields are restricted, a day matching either runs the job, as in cron. Times are in the location of
+// the time Next is given.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%w: %q: interval must be a positive duration", ErrInvalidSchedule, spec)
+		}
+		return Every(interval), nil
+	}
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if expr, ok = cronDescriptors[strings.ToLower(spec)]; !ok {
+			return nil, fmt.Errorf("%w: %q: unknown descriptor", ErrInvalidSchedule, spec)
+		}
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q: want 5 fields, got %d", ErrInvalidSchedule, spec, len(fields))
+	}
+	// as in cron, a day field starting with * leaves the day to the other field
+	s := &cronSchedule{anyDom: strings.HasPrefix(fields[2], "*"), anyDow: strings.HasPrefix(fields[4], "*")}
+	var err error
+	parsers := []struct {
+		bits        *uint64
+		first, last int
+		names       []string
+	}{
+		{&s.minute, 0, 59, nil},
+		{&s.hour, 0, 23, nil},
+		{&s.dom, 1, 31, nil},
+		{&s.month, 1, 12, cronMonths},
+		{&s.dow, 0, 7, cronWeekdays},
+	}
+	for i, p := range parsers {
+		if *p.bits, err = parseCronField(fields[i], p.first, p.last, p.names); err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, spec, err)
+		}
+	}
+	// 7 is another name for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges, and steps between first and last into a bit set.
+// names, when given, name the values from first on.
+func parseCronField(field string, first, last int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+		}
+		lo, hi := first, last
+		if expr != "*" {
+			from, to, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = parseCronValue(from, first, last, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseCronValue(to, first, last, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// a single value with a step runs from the value to the end of the field
+				hi = last
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", expr)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number between first and last, or one of names.
+func parseCronValue(value string, first, last int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(value, name) {
+			return first + i, nil
+		}
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < first || v > last {
+		return 0, fmt.Errorf("value %q out of range %d-%d", value, first, last)
+	}
+	return v, nil
+}
+
+// Next returns the first minute after t matching the expression, or the zero time when none does within
+// cronSearchLimit.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day fields.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -50,6 +50,14 @@ const (
 	ctxKeySuccessfulJobs = ctxKey(logger.KeySuccessfulJobs)
 	// ctxKeyFailedJobs is a context key for tracking the number of failed jobs.
 	ctxKeyFailedJobs = ctxKey(logger.KeyFailedJobs)
+	// ctxKeyJobType is the context key for storing or retrieving the type of a job.
+	ctxKeyJobType = ctxKey(logger.KeyJobType)
+	// ctxKeyJobPlugin is the context key for storing or retrieving the plugin a job interacts with.
+	ctxKeyJobPlugin = ctxKey(logger.KeyJobPlugin)
+	// ctxKeyJobClass is the context key for storing or retrieving the class a job is limited by.
+	ctxKeyJobClass = ctxKey(logger.KeyJobClass)
+	// ctxKeyBatchID is the context key for storing or retrieving the identifier of the batch a job was submitted in.
+	ctxKeyBatchID = ctxKey(logger.KeyBatchID)
 	// ctxKeyWorkerID is the context key used to store and retrieve the worker ID from a context.
 	ctxKeyWorkerID = ctxKey("worker_id")
 )
@@ -75,6 +83,61 @@ func JobIDFromCtx(ctx context.Context) string {
 	return val
 }
 
+// WithBatchID returns a copy of the parent context with the specified batch ID added as a value.
+func WithBatchID(parent context.Context, id string) context.Context {
+	return context.WithValue(parent, ctxKeyBatchID, id)
+}
+
+// BatchIDFromCtx retrieves the identifier of the batch a job was submitted in, returning an empty string if it is
+// not present.
+func BatchIDFromCtx(ctx context.Context) string {
+	val, ok := ctx.Value(ctxKeyBatchID).(string)
+	if !ok {
+		hclog.Default().Warn(fmt.Sprintf("%s %q", ctxWarningPrefix, ctxKeyBatchID))
+		return ""
+	}
+	return val
+}
+
+// LookupJobID retrieves the job ID from the given context without logging when it is absent, e.g. for code that
+// runs both inside and outside of jobs.
+func LookupJobID(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ctxKeyJobID).(string)
+	return val, ok && val != ""
+}
+
+// JobTypeFromCtx retrieves the job type from the given context, returning an empty string if it is not present.
+func JobTypeFromCtx(ctx context.Context) string {
+	val, ok := ctx.Value(ctxKeyJobType).(string)
+	if !ok {
+		hclog.Default().Warn(fmt.Sprintf("%s %q", ctxWarningPrefix, ctxKeyJobType))
+		return ""
+	}
+	return val
+}
+
+// JobPluginFromCtx retrieves the name of the plugin a job interacts with from the given context, returning an empty
+// string if it is not present.
+func JobPluginFromCtx(ctx context.Context) string {
+	val, ok := ctx.Value(ctxKeyJobPlugin).(string)
+	if !ok {
+		hclog.Default().Warn(fmt.Sprintf("%s %q", ctxWarningPrefix, ctxKeyJobPlugin))
+		return ""
+	}
+	return val
+}
+
+// JobClassFromCtx retrieves the class a job is limited by from the given context, returning an empty string if it is
+// not present.
+func JobClassFromCtx(ctx context.Context) string {
+	val, ok := ctx.Value(ctxKeyJobClass).(string)
+	if !ok {
+		hclog.Default().Warn(fmt.Sprintf("%s %q", ctxWarningPrefix, ctxKeyJobClass))
+		return ""
+	}
+	return val
+}
+
 // MaxRetriesFromCtx retrieves the maximum retry count from the provided context.
 // Returns 0 if the value is not present or if an invalid value is encountered.
 func MaxRetriesFromCtx(ctx context.Context) int {
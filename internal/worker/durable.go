@@ -0,0 +1,214 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/goptics/sqliteq"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DurableQueueName is the name of the persistent queue holding a durable pool's submitted jobs.
+	DurableQueueName = "pool-jobs"
+	// durablePoll is how often an idle feeder checks the persistent queue, in case a wake-up was missed because the
+	// queue could not be read.
+	durablePoll = time.Second
+)
+
+var (
+	// ErrOpenDurableQueue indicates that the persistent job queue could not be opened.
+	ErrOpenDurableQueue = errors.New("failed to open durable job queue")
+	// ErrDurableEnqueue indicates that a job could not be written to the persistent job queue.
+	ErrDurableEnqueue = errors.New("failed to persist job")
+)
+
+// DurableQueue is a persistent, first-in first-out sqlite queue of serialized jobs backing a Pool's in-memory queues,
+// so jobs submitted to the pool survive a restart of the process. A job stays in the queue until it finishes; jobs
+// that were queued or running when the process stopped are run again by the next pool using the queue.
+type DurableQueue struct {
+	queueLogger hclog.Logger
+	db          sqliteq.Queues
+	queue       *sqliteq.Queue
+	mu          sync.Mutex
+	submitted   map[string]*Job // jobs submitted by this process, run as given rather than rebuilt from envelopes
+}
+
+// OpenDurableQueue opens, or creates, the persistent job queue in the sqlite database at path, creating its directory
+// if needed. Jobs left running by a previous process are returned to the queue.
+func OpenDurableQueue(path string, queueLogger hclog.Logger) (*DurableQueue, error) {
+	if queueLogger == nil {
+		queueLogger = hclog.Default()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.Join(fmt.Errorf("%w: %s", ErrOpenDurableQueue, path), err)
+	}
+	db := sqliteq.New(path)
+	queue, err := db.NewQueue(DurableQueueName, sqliteq.WithRemoveOnComplete(true))
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("%w: %s", ErrOpenDurableQueue, path), err, db.Close())
+	}
+	// jobs in processing were taken by a previous process that stopped before they finished
+	queue.RequeueNoAckRows()
+	if n := queue.Len(); n > 0 {
+		queueLogger.Info("Resuming persisted jobs", "path", path, "jobs", n)
+	}
+	return &DurableQueue{
+		queueLogger: queueLogger,
+		db:          db,
+		queue:       queue,
+		submitted:   make(map[string]*Job),
+	}, nil
+}
+
+// Len returns the number of persisted jobs not yet handed to a worker.
+func (q *DurableQueue) Len() int {
+	return q.queue.Len()
+}
+
+// Close closes the persistent queue. Jobs that have not finished remain in it for the next pool to run. It must be
+// called after the pool using the queue has been shut down.
+func (q *DurableQueue) Close() error {
+	return errors.Join(q.queue.Close(), q.db.Close())
+}
+
+// enqueue persists the job's envelope. Only jobs created with NewSerializableJob or JobFromEnvelope can be persisted.
+func (q *DurableQueue) enqueue(job *Job) error {
+	env, err := job.Envelope()
+	if err != nil {
+		return err
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.queue.Enqueue(data) {
+		return fmt.Errorf("%w: %s", ErrDurableEnqueue, job.ID)
+	}
+	q.submitted[job.ID] = job
+	return nil
+}
+
+// dequeue takes the oldest persisted job, reporting false when the queue is empty. The job is acknowledged, and so
+// removed from the queue, once it finishes. A job submitted by this process is returned as submitted, keeping its
+// context and callbacks; a job persisted by a previous process is rebuilt from its envelope.
+func (q *DurableQueue) dequeue() (*Job, bool) {
+	item, ok, ackID := q.queue.DequeueWithAckId()
+	if !ok {
+		return nil, false
+	}
+	data, _ := item.([]byte)
+	env, err := UnmarshalEnvelope(data)
+	if err != nil {
+		q.queueLogger.Error("Dropping unreadable persisted job", logger.KeyError, err)
+		q.queue.Acknowledge(ackID)
+		return nil, true
+	}
+	q.mu.Lock()
+	job, ok := q.submitted[env.JobID]
+	delete(q.submitted, env.JobID)
+	q.mu.Unlock()
+	if !ok {
+		if job, err = JobFromEnvelope(context.Background(), env); err != nil {
+			q.queueLogger.Error("Dropping persisted job that cannot be rebuilt", logger.KeyJobID, env.JobID,
+				logger.KeyError, err)
+			q.queue.Acknowledge(ackID)
+			return nil, true
+		}
+		job.Metrics.SubmittedAt = env.SubmittedAt
+	}
+	done := job.onComplete
+	job.onComplete = func(res *JobResult) {
+		if done != nil {
+			done(res)
+		}
+		if !q.queue.Acknowledge(ackID) {
+			q.queueLogger.Warn("Failed to acknowledge persisted job", logger.KeyJobID, res.JobID)
+		}
+	}
+	return job, true
+}
+
+// WithDurableQueue backs the pool's queues with the persistent queue q and returns the updated Pool. Submit then
+// persists each job before it is queued, and only serializable jobs, created with NewSerializableJob, can be
+// submitted. Workers take persisted jobs in submission order, oldest first, starting with any left by a previous
+// process. It must be called before Run; the pool does not close q.
+func (p *Pool) WithDurableQueue(q *DurableQueue) *Pool {
+	p.durable = q
+	p.wake = make(chan struct{}, 1)
+	p.feedStop = make(chan struct{})
+	return p
+}
+
+// submitDurable persists the job and wakes the feeder to queue it for a worker.
+func (p *Pool) submitDurable(job *Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed.Load() {
+		p.metrics.RecordFailedSubmission()
+		p.poolLogger.With(logger.KeyJobID, job.ID, logger.KeyRequestID, job.RequestID).
+			Warn("Job queue closed, job not submitted")
+		return ErrPoolClosed
+	}
+	if err := p.durable.enqueue(job); err != nil {
+		p.metrics.RecordFailedSubmission()
+		return err
+	}
+	p.metrics.RecordSubmission()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// feed moves persisted jobs, oldest first, into the in-memory queues as workers make room for them, until the pool is
+// closed. A job taken from the persistent queue but not yet queued when the pool closes stays unacknowledged and is
+// run by the next pool using the queue.
+func (p *Pool) feed() {
+	defer p.feeding.Done()
+	for {
+		job, ok := p.durable.dequeue()
+		if !ok {
+			select {
+			case <-p.wake:
+				continue
+			case <-time.After(durablePoll):
+				continue
+			case <-p.feedStop:
+				return
+			}
+		}
+		if job == nil {
+			continue
+		}
+		if !p.feedJob(job) {
+			return
+		}
+	}
+}
+
+// feedJob sends a persisted job to the in-memory queue for its priority, reporting false once the pool is closed.
+func (p *Pool) feedJob(job *Job) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed.Load() {
+		return false
+	}
+	p.tracker.queued(job)
+	select {
+	case p.queues[job.Priority.queue()] <- job:
+		return true
+	case <-p.quit:
+		p.tracker.dropped(job.ID)
+		return false
+	}
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestDurableQueueReplay checks that jobs persisted by a pool that stopped before running them are rebuilt from their
+// envelopes, with their priority, and run by the next pool opening the queue.
+func TestDurableQueueReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	q, err := OpenDurableQueue(path, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("OpenDurableQueue: %v", err)
+	}
+	// the first pool is never run, so its jobs stay persisted
+	first := NewPool(1, false, 2, hclog.NewNullLogger()).WithDurableQueue(q)
+	want := map[string]Priority{}
+	for text, priority := range map[string]Priority{"low": PriorityLow, "high": PriorityHigh} {
+		job, err := NewSerializableJob(context.Background(), testEchoJob, echoPayload{Text: text})
+		if err != nil {
+			t.Fatalf("NewSerializableJob: %v", err)
+		}
+		if err := first.Submit(job.WithPriority(priority)); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		want[job.ID] = priority
+	}
+	plain := NewJob(context.Background(), func(context.Context) (any, error) { return nil, nil })
+	if err := first.Submit(plain); !errors.Is(err, ErrNotSerializable) {
+		t.Errorf("Submit of a plain job: got %v, want ErrNotSerializable", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	q, err = OpenDurableQueue(path, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("reopen OpenDurableQueue: %v", err)
+	}
+	if got := q.Len(); got != len(want) {
+		t.Fatalf("got %d persisted jobs, want %d", got, len(want))
+	}
+	for range want {
+		job, ok := q.dequeue()
+		if !ok || job == nil {
+			t.Fatal("dequeue returned no job")
+		}
+		priority, found := want[job.ID]
+		if !found {
+			t.Errorf("replayed unknown job %s", job.ID)
+			continue
+		}
+		if job.Priority != priority {
+			t.Errorf("job %s: got priority %s, want %s", job.ID, job.Priority, priority)
+		}
+	}
+	// the dequeued jobs were never acknowledged, so they are run again by the next pool
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	q, err = OpenDurableQueue(path, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatalf("reopen OpenDurableQueue: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+	pool := NewPool(1, false, 2, hclog.NewNullLogger()).WithDurableQueue(q)
+	pool.Run()
+	got := map[string]any{}
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case res := <-pool.Results():
+			if res.Err != nil {
+				t.Errorf("job %s failed: %v", res.JobID, res.Err)
+			}
+			got[res.JobID] = res.Value
+		case <-timeout:
+			t.Fatalf("got %d results, want %d", len(got), len(want))
+		}
+	}
+	pool.Shutdown()
+	for id := range want {
+		if _, ok := got[id]; !ok {
+			t.Errorf("job %s was not run", id)
+		}
+	}
+	if n := q.Len(); n != 0 {
+		t.Errorf("got %d jobs left in the queue, want 0", n)
+	}
+}
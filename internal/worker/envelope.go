@@ -0,0 +1,244 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodingJSON identifies payloads serialized with encoding/json.
+// EncodingProto identifies payloads serialized with protocol buffers.
+const (
+	EncodingJSON  = "json"
+	EncodingProto = "proto"
+)
+
+var (
+	// ErrUnknownJobType indicates that no codec and handler are registered for the job type.
+	ErrUnknownJobType = errors.New("unknown job type")
+	// ErrNotSerializable indicates that a job was not created from a registered type and payload.
+	ErrNotSerializable = errors.New("job is not serializable")
+	// ErrEncodingMismatch indicates that an envelope's encoding does not match the registered codec.
+	ErrEncodingMismatch = errors.New("envelope encoding does not match registered codec")
+	// ErrInvalidPayload indicates that a payload does not have the type expected by its codec.
+	ErrInvalidPayload = errors.New("invalid payload for codec")
+)
+
+// Codec serializes and deserializes the payload of a job type.
+type Codec interface {
+	// Encoding returns the name of the wire encoding, e.g. EncodingJSON.
+	Encoding() string
+	// Marshal serializes a payload.
+	Marshal(payload any) ([]byte, error)
+	// Unmarshal deserializes a payload.
+	Unmarshal(data []byte) (any, error)
+}
+
+// JSONCodec is a Codec for payloads of type T encoded as JSON.
+type JSONCodec[T any] struct{}
+
+// Encoding returns EncodingJSON.
+func (JSONCodec[T]) Encoding() string {
+	return EncodingJSON
+}
+
+// Marshal encodes the payload as JSON.
+func (JSONCodec[T]) Marshal(payload any) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+// Unmarshal decodes JSON data into a value of type T.
+func (JSONCodec[T]) Unmarshal(data []byte) (any, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ProtoCodec is a Codec for protocol buffer payloads of type T. New must return an empty message to decode into.
+type ProtoCodec[T proto.Message] struct {
+	New func() T
+}
+
+// Encoding returns EncodingProto.
+func (ProtoCodec[T]) Encoding() string {
+	return EncodingProto
+}
+
+// Marshal encodes the payload, which must be a T, as a protocol buffer.
+func (ProtoCodec[T]) Marshal(payload any) ([]byte, error) {
+	msg, ok := payload.(T)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrInvalidPayload, payload)
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal decodes protocol buffer data into a new T.
+func (c ProtoCodec[T]) Unmarshal(data []byte) (any, error) {
+	msg := c.New()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// JobHandler builds the WorkUnit that executes a decoded payload.
+type JobHandler func(payload any) WorkUnit
+
+// jobType pairs the codec and handler registered for a job type name.
+type jobType struct {
+	codec   Codec
+	handler JobHandler
+}
+
+// JobTypes is a thread-safe registry of serializable job types, mapping type names to their codec and handler.
+type JobTypes struct {
+	mu    sync.RWMutex
+	types map[string]jobType
+}
+
+// AvailableJobTypes is the global registry of serializable job types used by NewSerializableJob and JobFromEnvelope.
+var AvailableJobTypes = JobTypes{
+	mu:    sync.RWMutex{},
+	types: make(map[string]jobType),
+}
+
+// Register associates a job type name with the codec for its payload and the handler that executes it.
+func (jt *JobTypes) Register(name string, codec Codec, handler JobHandler) {
+	jt.mu.Lock()
+	defer jt.mu.Unlock()
+	jt.types[name] = jobType{codec: codec, handler: handler}
+}
+
+// Get retrieves the codec and handler registered for the job type name.
+func (jt *JobTypes) Get(name string) (Codec, JobHandler, bool) {
+	jt.mu.RLock()
+	defer jt.mu.RUnlock()
+	t, ok := jt.types[name]
+	return t.codec, t.handler, ok
+}
+
+// Names returns the names of all registered job types.
+func (jt *JobTypes) Names() []string {
+	jt.mu.RLock()
+	defer jt.mu.RUnlock()
+	names := make([]string, 0, len(jt.types))
+	for name := range jt.types {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Envelope is the serializable form of a job: its registered type name, the encoded payload, and the settings
+// needed to rebuild it on another host or after being persisted.
+type Envelope struct {
+	JobID       string    `json:"job_id"`
+	Type        string    `json:"job_type"`
+	Plugin      string    `json:"plugin,omitempty"`
+	Class       string    `json:"class,omitempty"`
+	Encoding    string    `json:"encoding"`
+	Payload     []byte    `json:"payload"`
+	MaxRetries  int       `json:"max_retries"`
+	RetryDelay  int       `json:"retry_delay"`
+	Priority    Priority  `json:"priority,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+// Marshal encodes the envelope as JSON.
+func (e *Envelope) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UnmarshalEnvelope decodes an envelope previously produced by Envelope.Marshal.
+func UnmarshalEnvelope(data []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// NewSerializableJob creates a job of a registered type from its payload. Unlike NewJob, the resulting job can be
+// converted to an Envelope for persistence or remote execution.
+func NewSerializableJob(ctx context.Context, jobTypeName string, payload any) (*Job, error) {
+	_, handler, ok := AvailableJobTypes.Get(jobTypeName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownJobType, jobTypeName)
+	}
+	job := NewJob(ctx, handler(payload)).WithType(jobTypeName)
+	job.Payload = payload
+	return job, nil
+}
+
+// Envelope serializes the job's type, payload, priority, and retry settings. Only jobs created with
+// NewSerializableJob or JobFromEnvelope can be serialized.
+func (j *Job) Envelope() (*Envelope, error) {
+	if j.Type == "" || j.Payload == nil {
+		return nil, ErrNotSerializable
+	}
+	codec, _, ok := AvailableJobTypes.Get(j.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownJobType, j.Type)
+	}
+	data, err := codec.Marshal(j.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		JobID:       j.ID,
+		Type:        j.Type,
+		Plugin:      j.Plugin,
+		Class:       j.Class,
+		Encoding:    codec.Encoding(),
+		Payload:     data,
+		MaxRetries:  j.MaxRetries,
+		RetryDelay:  j.RetryDelay,
+		Priority:    j.Priority,
+		SubmittedAt: j.Metrics.SubmittedAt,
+		RequestID:   j.RequestID,
+	}, nil
+}
+
+// JobFromEnvelope rebuilds a job from an envelope using the registered codec and handler for its type.
+// The job keeps the envelope's ID so results can be correlated across hosts.
+func JobFromEnvelope(ctx context.Context, env *Envelope) (*Job, error) {
+	codec, handler, ok := AvailableJobTypes.Get(env.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownJobType, env.Type)
+	}
+	if codec.Encoding() != env.Encoding {
+		return nil, fmt.Errorf("%w: %s != %s", ErrEncodingMismatch, env.Encoding, codec.Encoding())
+	}
+	payload, err := codec.Unmarshal(env.Payload)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidPayload, err)
+	}
+	job := NewJob(ctx, handler(payload)).WithType(env.Type)
+	if env.JobID != "" {
+		job.ID = env.JobID
+		job.Ctx = WithJobID(job.Ctx, env.JobID)
+	}
+	if env.Plugin != "" {
+		job.WithPlugin(env.Plugin)
+	}
+	if env.Class != "" {
+		job.WithClass(env.Class)
+	}
+	if env.RequestID != "" {
+		job.WithRequestID(env.RequestID)
+	}
+	if env.MaxRetries > 0 || env.RetryDelay > 0 {
+		job.WithRetry(env.MaxRetries, env.RetryDelay)
+	}
+	job.Priority = env.Priority
+	job.Payload = payload
+	return job, nil
+}
@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testEchoJob and testProtoJob are job types registered for the tests, returning their payload's text.
+const (
+	testEchoJob  = "test_echo"
+	testProtoJob = "test_proto"
+)
+
+// echoPayload is the payload of testEchoJob.
+type echoPayload struct {
+	Text string `json:"text"`
+}
+
+func init() {
+	AvailableJobTypes.Register(testEchoJob, JSONCodec[echoPayload]{}, func(payload any) WorkUnit {
+		return func(context.Context) (any, error) {
+			return payload.(echoPayload).Text, nil
+		}
+	})
+	AvailableJobTypes.Register(testProtoJob, ProtoCodec[*wrapperspb.StringValue]{
+		New: func() *wrapperspb.StringValue { return &wrapperspb.StringValue{} },
+	}, func(payload any) WorkUnit {
+		return func(context.Context) (any, error) {
+			return payload.(*wrapperspb.StringValue).GetValue(), nil
+		}
+	})
+}
+
+// TestEnvelopeRoundTrip checks that a serializable job survives encoding to an envelope and back with its ID,
+// settings, and payload, for each codec.
+func TestEnvelopeRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		jobType  string
+		payload  any
+		encoding string
+	}{
+		"json":  {jobType: testEchoJob, payload: echoPayload{Text: "hello"}, encoding: EncodingJSON},
+		"proto": {jobType: testProtoJob, payload: wrapperspb.String("hello"), encoding: EncodingProto},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			job, err := NewSerializableJob(context.Background(), tt.jobType, tt.payload)
+			if err != nil {
+				t.Fatalf("NewSerializableJob: %v", err)
+			}
+			job.WithPlugin("p").WithClass("io").WithRequestID("req").WithRetry(2, 10).WithPriority(PriorityHigh)
+			env, err := job.Envelope()
+			if err != nil {
+				t.Fatalf("Envelope: %v", err)
+			}
+			if env.Encoding != tt.encoding {
+				t.Errorf("got encoding %q, want %q", env.Encoding, tt.encoding)
+			}
+			data, err := env.Marshal()
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			decoded, err := UnmarshalEnvelope(data)
+			if err != nil {
+				t.Fatalf("UnmarshalEnvelope: %v", err)
+			}
+			rebuilt, err := JobFromEnvelope(context.Background(), decoded)
+			if err != nil {
+				t.Fatalf("JobFromEnvelope: %v", err)
+			}
+			if rebuilt.ID != job.ID || rebuilt.Type != tt.jobType || rebuilt.Plugin != "p" || rebuilt.Class != "io" ||
+				rebuilt.RequestID != "req" || rebuilt.MaxRetries != 2 || rebuilt.RetryDelay != 10 ||
+				rebuilt.Priority != PriorityHigh {
+				t.Errorf("rebuilt job %+v does not match %+v", rebuilt, job)
+			}
+			if got := JobIDFromCtx(rebuilt.Ctx); got != job.ID {
+				t.Errorf("got context job ID %q, want %q", got, job.ID)
+			}
+			val, err := rebuilt.Execute(rebuilt.Ctx)
+			if err != nil || val != "hello" {
+				t.Errorf("got %v, %v, want hello", val, err)
+			}
+		})
+	}
+}
+
+// TestEnvelopeErrors checks that jobs and envelopes that cannot be converted are refused with the matching error.
+func TestEnvelopeErrors(t *testing.T) {
+	t.Run("not serializable", func(t *testing.T) {
+		job := NewJob(context.Background(), func(context.Context) (any, error) { return nil, nil })
+		if _, err := job.Envelope(); !errors.Is(err, ErrNotSerializable) {
+			t.Errorf("got %v, want ErrNotSerializable", err)
+		}
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		if _, err := NewSerializableJob(context.Background(), "missing", nil); !errors.Is(err, ErrUnknownJobType) {
+			t.Errorf("NewSerializableJob: got %v, want ErrUnknownJobType", err)
+		}
+		_, err := JobFromEnvelope(context.Background(), &Envelope{Type: "missing", Encoding: EncodingJSON})
+		if !errors.Is(err, ErrUnknownJobType) {
+			t.Errorf("JobFromEnvelope: got %v, want ErrUnknownJobType", err)
+		}
+	})
+	t.Run("encoding mismatch", func(t *testing.T) {
+		env := &Envelope{Type: testEchoJob, Encoding: EncodingProto, Payload: []byte(`{}`)}
+		if _, err := JobFromEnvelope(context.Background(), env); !errors.Is(err, ErrEncodingMismatch) {
+			t.Errorf("got %v, want ErrEncodingMismatch", err)
+		}
+	})
+	t.Run("invalid payload", func(t *testing.T) {
+		env := &Envelope{Type: testEchoJob, Encoding: EncodingJSON, Payload: []byte(`not json`)}
+		if _, err := JobFromEnvelope(context.Background(), env); !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("got %v, want ErrInvalidPayload", err)
+		}
+	})
+	t.Run("wrong proto payload", func(t *testing.T) {
+		codec := ProtoCodec[*wrapperspb.StringValue]{}
+		if _, err := codec.Marshal("text"); !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("got %v, want ErrInvalidPayload", err)
+		}
+	})
+}
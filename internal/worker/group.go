@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/utils/pkg/strutil"
+)
+
+// ErrGroupSubmitted indicates that jobs were added to a JobGroup after it was submitted.
+var ErrGroupSubmitted = errors.New("job group already submitted")
+
+// GroupResult is the aggregated result of a JobGroup. Values and Errors are indexed in the order jobs were added.
+type GroupResult struct {
+	GroupID    string        `json:"group_id"`
+	Values     []any         `json:"values"`
+	Errors     []error       `json:"-"`
+	FirstErr   error         `json:"-"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Err returns the errors of every failed job joined into a single error, or nil if every job succeeded.
+func (r *GroupResult) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// JobGroup fans a set of related jobs out to a pool and fans their results back in as a single GroupResult.
+// Jobs in the group carry the group ID as their batch ID, and still publish their individual results on the pool's
+// results channel.
+type JobGroup struct {
+	pool          *Pool
+	ctx           context.Context
+	cancel        context.CancelCauseFunc
+	cancelOnError bool
+	jobs          []*Job
+	mu            sync.Mutex
+	result        *GroupResult
+	reported      []bool
+	remaining     int
+	submitted     bool
+	finished      bool
+	done          chan struct{}
+}
+
+// NewJobGroup creates an empty JobGroup that submits to pool. Every job in the group is canceled when ctx ends.
+func NewJobGroup(ctx context.Context, pool *Pool) *JobGroup {
+	groupCtx, cancel := context.WithCancelCause(ctx)
+	return &JobGroup{
+		pool:   pool,
+		ctx:    groupCtx,
+		cancel: cancel,
+		result: &GroupResult{GroupID: strutil.GenerateUUIDV7()},
+		done:   make(chan struct{}),
+	}
+}
+
+// WithCancelOnError cancels the remaining jobs in the group as soon as one fails, using the failure as the
+// cancellation cause, and returns the updated JobGroup.
+func (g *JobGroup) WithCancelOnError() *JobGroup {
+	g.cancelOnError = true
+	return g
+}
+
+// ID returns the group's identifier.
+func (g *JobGroup) ID() string {
+	return g.result.GroupID
+}
+
+// Add adds a job to the group. Jobs must be added before Submit.
+func (g *JobGroup) Add(job *Job) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.submitted {
+		return ErrGroupSubmitted
+	}
+	g.jobs = append(g.jobs, job)
+	return nil
+}
+
+// Go creates a job from the WorkUnit, adds it to the group, and returns it so it can be configured before Submit.
+func (g *JobGroup) Go(execute WorkUnit) (*Job, error) {
+	job := NewJob(context.Background(), execute)
+	return job, g.Add(job)
+}
+
+// Submit submits every job in the group to the pool. Jobs that cannot be submitted are recorded as failed.
+func (g *JobGroup) Submit() error {
+	g.mu.Lock()
+	if g.submitted {
+		g.mu.Unlock()
+		return ErrGroupSubmitted
+	}
+	g.submitted = true
+	jobs := g.jobs
+	g.remaining = len(jobs)
+	g.result.Values = make([]any, len(jobs))
+	g.result.Errors = make([]error, len(jobs))
+	g.reported = make([]bool, len(jobs))
+	g.result.StartedAt = timestamp.Now()
+	if len(jobs) == 0 {
+		g.finish()
+	}
+	g.mu.Unlock()
+
+	for i, job := range jobs {
+		job.WithBatchID(g.result.GroupID).WithParent(g.ctx)
+		job.onComplete = func(res *JobResult) {
+			g.complete(i, res.Value, res.Err)
+		}
+		if err := g.pool.Submit(job); err != nil {
+			g.complete(i, nil, err)
+		}
+	}
+	return nil
+}
+
+// complete records the outcome of the job at index i, finishing the group when it is the last.
+func (g *JobGroup) complete(i int, value any, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.finished || g.reported[i] {
+		return
+	}
+	g.reported[i] = true
+	g.result.Values[i] = value
+	g.result.Errors[i] = err
+	if err != nil && g.result.FirstErr == nil {
+		g.result.FirstErr = err
+		if g.cancelOnError {
+			g.cancel(err)
+		}
+	}
+	g.remaining--
+	if g.remaining == 0 {
+		g.finish()
+	}
+}
+
+// finish finalizes the group result and releases waiters. The caller must hold mu.
+func (g *JobGroup) finish() {
+	g.finished = true
+	g.result.FinishedAt = timestamp.Now()
+	g.result.Duration = g.result.FinishedAt.Sub(g.result.StartedAt)
+	g.cancel(nil)
+	close(g.done)
+}
+
+// Wait blocks until every job in the group has finished and returns the aggregated result. If the pool's workers
+// exit first, e.g. because the pool was terminated, jobs that never ran are recorded as failed with
+// ErrPoolTerminated.
+func (g *JobGroup) Wait() *GroupResult {
+	select {
+	case <-g.done:
+	case <-g.pool.drained:
+		g.mu.Lock()
+		if !g.finished {
+			for i, reported := range g.reported {
+				if !reported {
+					g.result.Errors[i] = ErrPoolTerminated
+				}
+			}
+			if g.result.FirstErr == nil {
+				g.result.FirstErr = ErrPoolTerminated
+			}
+			g.finish()
+		}
+		g.mu.Unlock()
+	}
+	return g.result
+}
+
+// Run submits the group and waits for its aggregated result.
+func (g *JobGroup) Run() (*GroupResult, error) {
+	if err := g.Submit(); err != nil {
+		return nil, err
+	}
+	return g.Wait(), nil
+}
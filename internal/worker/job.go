@@ -2,8 +2,12 @@ package worker
 
 import (
 	"context"
+	"runtime/pprof"
 	"time"
 
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/bmj2728/PlugsConc/shared/pkg/callctx"
 	"github.com/bmj2728/utils/pkg/strutil"
 )
 
@@ -20,6 +24,16 @@ type Job struct {
 	CancelWithCause context.CancelCauseFunc // only available if the job was created with WithCancelCause
 	MaxRetries      int
 	RetryDelay      int
+	Type            string             // optional job classification, attached as a pprof label
+	Plugin          string             // optional plugin the job interacts with, attached as a pprof label
+	Class           string             // optional class the pool limits the job by, see Pool.WithClassLimit
+	Payload         any                // decoded payload of a serializable job, see NewSerializableJob
+	BatchID         string             // optional batch the job was submitted in, see Pool.SubmitBatch
+	RequestID       string             // correlation ID of the request that submitted the job, set by Pool.Submit
+	Priority        Priority           // order in which queued jobs are taken by workers, PriorityNormal by default
+	onComplete      func(*JobResult)   // optional hook called with the final result, see JobGroup
+	callbacks       []func(*JobResult) // called with the final result, see WithCallback
+	admitted        bool               // holds a slot of its class taken while it was held back, see jobClasses
 }
 
 // NewJob creates and initializes a new Job instance with a unique ID and the provided execution logic.
@@ -44,6 +58,87 @@ func (j *Job) WithRetry(maxRetries int, retryDelay int) *Job {
 	return j
 }
 
+// WithType sets the job's type and stores it in the job's context.
+func (j *Job) WithType(jobType string) *Job {
+	j.Type = jobType
+	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobType, jobType)
+	return j
+}
+
+// WithPlugin sets the name of the plugin the job interacts with and stores it in the job's context.
+func (j *Job) WithPlugin(name string) *Job {
+	j.Plugin = name
+	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobPlugin, name)
+	return j
+}
+
+// WithClass sets the class the job is limited by, e.g. "io" or "cpu", and stores it in the job's context. A pool with
+// a ClassLimit for the class holds the job back while the class is at its limit, see Pool.WithClassLimit.
+func (j *Job) WithClass(class string) *Job {
+	j.Class = class
+	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobClass, class)
+	return j
+}
+
+// WithPriority sets the priority with which the job is taken from the pool's queue and returns the updated Job.
+func (j *Job) WithPriority(priority Priority) *Job {
+	j.Priority = priority
+	return j
+}
+
+// WithCallback registers fn to be called with the job's final result, in addition to its delivery through the pool,
+// and returns the updated Job. Callbacks run on the worker goroutine in registration order and should not block.
+func (j *Job) WithCallback(fn func(*JobResult)) *Job {
+	j.callbacks = append(j.callbacks, fn)
+	return j
+}
+
+// WithBatchID sets the identifier of the batch the job belongs to and stores it in the job's context.
+func (j *Job) WithBatchID(id string) *Job {
+	j.BatchID = id
+	j.Ctx = WithBatchID(j.Ctx, id)
+	return j
+}
+
+// WithRequestID sets the correlation ID of the request the job is submitted for and stores it in the job's context,
+// from which plugin calls made by the job forward it.
+func (j *Job) WithRequestID(id string) *Job {
+	j.RequestID = id
+	j.Ctx = callctx.WithRequestID(j.Ctx, id)
+	return j
+}
+
+// ensureRequestID sets the job's request ID from its context, or to a new one when the job was not submitted for a
+// request, e.g. by a timer or a job source.
+func (j *Job) ensureRequestID() {
+	if j.RequestID != "" {
+		return
+	}
+	ctx, id := callctx.EnsureRequestID(j.Ctx)
+	j.RequestID, j.Ctx = id, ctx
+}
+
+// WithParent makes the job's context also end when parent ends, keeping the job's own values and cancellation.
+// The parent's cause is propagated to the job, and any cancel function already set on the job is still called
+// when the job finishes.
+func (j *Job) WithParent(parent context.Context) *Job {
+	updated, cancel := context.WithCancelCause(j.Ctx)
+	stop := context.AfterFunc(parent, func() { cancel(context.Cause(parent)) })
+	prevCancel, prevCancelWithCause := j.Cancel, j.CancelWithCause
+	j.Ctx = updated
+	j.Cancel = nil
+	j.CancelWithCause = func(cause error) {
+		stop()
+		cancel(cause)
+		if prevCancelWithCause != nil {
+			prevCancelWithCause(cause)
+		} else if prevCancel != nil {
+			prevCancel()
+		}
+	}
+	return j
+}
+
 // WithCancel creates a derived context with a cancel function for the current job and updates the job's context.
 func (j *Job) WithCancel() *Job {
 	updated, cancel := context.WithCancel(j.Ctx)
@@ -97,42 +192,67 @@ func (j *Job) WithDeadlineCause(deadline time.Time, cause error) *Job {
 
 // SetSubmittedAt updates the job's SubmittedAt field with the current time and stores it in the job's context.
 func (j *Job) SetSubmittedAt() {
-	j.Metrics.SubmittedAt = time.Now()
+	j.Metrics.SubmittedAt = timestamp.Now()
 	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobSubmittedAt, j.Metrics.SubmittedAt)
 }
 
 // SetStartedAt updates the Job's StartedAt timestamp and adds it to the Job's context as ctxKeyJobStartedAt.
 func (j *Job) SetStartedAt() {
-	j.Metrics.StartedAt = time.Now()
-	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobStartedAt, time.Now())
+	j.Metrics.StartedAt = timestamp.Now()
+	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobStartedAt, j.Metrics.StartedAt)
 }
 
 // SetFinishedAt sets the job's `FinishedAt` time to the current time, calculates the duration, and updates the context.
 func (j *Job) SetFinishedAt() {
-	j.Metrics.FinishedAt = time.Now()
-	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobFinishedAt, time.Now())
+	j.Metrics.FinishedAt = timestamp.Now()
+	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobFinishedAt, j.Metrics.FinishedAt)
 	j.Metrics.Duration = j.Metrics.FinishedAt.Sub(j.Metrics.StartedAt)
 	j.Ctx = context.WithValue(j.Ctx, ctxKeyJobDuration, j.Metrics.Duration)
 }
 
+// ProfileLabels returns the pprof labels attached to the worker goroutine while the job executes,
+// allowing CPU profiles to attribute time to specific jobs and plugins.
+func (j *Job) ProfileLabels() pprof.LabelSet {
+	labels := []string{logger.KeyJobID, j.ID}
+	if j.Type != "" {
+		labels = append(labels, logger.KeyJobType, j.Type)
+	}
+	if j.Plugin != "" {
+		labels = append(labels, logger.KeyJobPlugin, j.Plugin)
+	}
+	if j.Class != "" {
+		labels = append(labels, logger.KeyJobClass, j.Class)
+	}
+	return pprof.Labels(labels...)
+}
+
 // JobResult represents the outcome of an operation with its associated JobID, result value, and any error encountered.
 type JobResult struct {
-	JobID    string
-	WorkerID int
-	Ctx      context.Context
-	Metrics  *JobMetrics
-	Value    any
-	Err      error
+	JobID     string
+	WorkerID  int
+	BatchID   string
+	RequestID string
+	Type      string
+	Plugin    string
+	Ctx       context.Context
+	Metrics   *JobMetrics
+	Value     any
+	Err       error
+	Overflow  *ResultOverflow // set when Value exceeded the pool's ResultLimit and was truncated, spilled, or dropped
 }
 
 // NewJobResult creates a new JobResult instance, copying the job's metrics and associating it with a specific worker.
 func NewJobResult(job *Job, workerID int, value any, err error) *JobResult {
 	return &JobResult{
-		JobID:    job.ID,
-		WorkerID: workerID,
-		Ctx:      job.Ctx,
-		Metrics:  job.Metrics,
-		Value:    value,
-		Err:      err,
+		JobID:     job.ID,
+		WorkerID:  workerID,
+		BatchID:   job.BatchID,
+		RequestID: job.RequestID,
+		Type:      job.Type,
+		Plugin:    job.Plugin,
+		Ctx:       job.Ctx,
+		Metrics:   job.Metrics,
+		Value:     value,
+		Err:       err,
 	}
 }
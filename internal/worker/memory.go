@@ -0,0 +1,214 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultMemoryInterval is how often a MemoryMonitor samples the runtime when no interval is configured.
+// DefaultThrottleWorkers is how many jobs may run at once while the pool is throttled when none is configured.
+const (
+	DefaultMemoryInterval  = 5 * time.Second
+	DefaultThrottleWorkers = 1
+)
+
+// memoryRecovery is the share of the soft limit memory use must fall below before a MemoryMonitor reports recovery
+// and lifts throttling, so use hovering around the limit does not toggle it every interval.
+const memoryRecovery = 0.9
+
+// ErrInvalidMemoryConfig indicates that a MemoryConfig has a negative limit or throttles without a limit.
+var ErrInvalidMemoryConfig = errors.New("invalid memory monitor config")
+
+// MemoryConfig configures a MemoryMonitor.
+type MemoryConfig struct {
+	SoftLimit       uint64        // bytes of memory use above which warnings are logged, no limit when zero
+	Throttle        bool          // limit how many jobs run at once while memory use is above SoftLimit
+	ThrottleWorkers int           // jobs that may run at once while throttled, DefaultThrottleWorkers when zero
+	Interval        time.Duration // how often the runtime is sampled, DefaultMemoryInterval when zero
+}
+
+// validate fills in defaults and checks the config.
+func (c *MemoryConfig) validate() error {
+	if c.Interval <= 0 {
+		c.Interval = DefaultMemoryInterval
+	}
+	if c.ThrottleWorkers == 0 {
+		c.ThrottleWorkers = DefaultThrottleWorkers
+	}
+	switch {
+	case c.ThrottleWorkers < 0:
+		return fmt.Errorf("%w: throttle workers %d is negative", ErrInvalidMemoryConfig, c.ThrottleWorkers)
+	case c.Throttle && c.SoftLimit == 0:
+		return fmt.Errorf("%w: throttling needs a soft limit", ErrInvalidMemoryConfig)
+	}
+	return nil
+}
+
+// MemoryStats is a point-in-time view of the host's memory use and garbage collector, as sampled by a MemoryMonitor.
+// Used is the memory the Go runtime holds from the operating system and has not returned, the figure compared against
+// the soft limit. GCPauseRatio is the share of the last sampling interval the program spent paused for collection,
+// a short-term measure of GC pressure; GCCPUFraction is the share of CPU time used by the collector since start.
+type MemoryStats struct {
+	SampledAt     time.Time     `json:"sampled_at"`
+	Goroutines    int           `json:"goroutines"`
+	HeapAlloc     uint64        `json:"heap_alloc_bytes"`
+	HeapInuse     uint64        `json:"heap_inuse_bytes"`
+	HeapSys       uint64        `json:"heap_sys_bytes"`
+	HeapObjects   uint64        `json:"heap_objects"`
+	Sys           uint64        `json:"sys_bytes"`
+	Used          uint64        `json:"used_bytes"`
+	NextGC        uint64        `json:"next_gc_bytes"`
+	NumGC         uint32        `json:"num_gc"`
+	GCPauseTotal  time.Duration `json:"gc_pause_total"`
+	LastGCPause   time.Duration `json:"last_gc_pause"`
+	GCPauseRatio  float64       `json:"gc_pause_ratio"`
+	GCCPUFraction float64       `json:"gc_cpu_fraction"`
+	SoftLimit     uint64        `json:"soft_limit_bytes,omitempty"`
+	OverLimit     bool          `json:"over_soft_limit"`
+	Throttled     bool          `json:"throttled"`
+}
+
+// ReadMemoryStats samples the Go runtime's memory and garbage collector statistics. GCPauseRatio is left zero, as it
+// needs a previous sample.
+func ReadMemoryStats() MemoryStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return MemoryStats{
+		SampledAt:     timestamp.Now(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     ms.HeapAlloc,
+		HeapInuse:     ms.HeapInuse,
+		HeapSys:       ms.HeapSys,
+		HeapObjects:   ms.HeapObjects,
+		Sys:           ms.Sys,
+		Used:          ms.Sys - ms.HeapReleased,
+		NextGC:        ms.NextGC,
+		NumGC:         ms.NumGC,
+		GCPauseTotal:  time.Duration(ms.PauseTotalNs),
+		LastGCPause:   time.Duration(ms.PauseNs[(ms.NumGC+255)%256]),
+		GCCPUFraction: ms.GCCPUFraction,
+	}
+}
+
+// MemoryMonitor samples the host's memory use and GC pressure every interval. When use rises above the soft limit it
+// logs a warning and, if configured, throttles the pool so that only ThrottleWorkers jobs run at once until use falls
+// back below the limit, letting in-flight jobs finish and their memory be collected instead of starting more.
+type MemoryMonitor struct {
+	memoryLogger hclog.Logger
+	conf         MemoryConfig
+	throttled    atomic.Bool
+	slots        chan struct{} // jobs running while throttled
+	mu           sync.Mutex
+	stats        MemoryStats
+}
+
+// NewMemoryMonitor creates a MemoryMonitor, returning ErrInvalidMemoryConfig if the config is inconsistent. Its
+// Middleware must be registered with the pool before Run for throttling to take effect, and Run started to sample.
+func NewMemoryMonitor(conf MemoryConfig, memoryLogger hclog.Logger) (*MemoryMonitor, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	if memoryLogger == nil {
+		memoryLogger = hclog.Default()
+	}
+	m := &MemoryMonitor{
+		memoryLogger: memoryLogger,
+		conf:         conf,
+		slots:        make(chan struct{}, conf.ThrottleWorkers),
+	}
+	m.stats = ReadMemoryStats()
+	m.stats.SoftLimit = conf.SoftLimit
+	return m, nil
+}
+
+// Middleware holds job attempts back while the pool is throttled until one of the throttled slots is free or the
+// job's context is done. Outside throttling it only passes the attempt through.
+func (m *MemoryMonitor) Middleware() Middleware {
+	return func(next WorkUnit) WorkUnit {
+		return func(ctx context.Context) (any, error) {
+			if !m.throttled.Load() {
+				return next(ctx)
+			}
+			select {
+			case m.slots <- struct{}{}:
+				defer func() { <-m.slots }()
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Stats returns the latest sample.
+func (m *MemoryMonitor) Stats() MemoryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Throttled reports whether the pool is currently throttled.
+func (m *MemoryMonitor) Throttled() bool {
+	return m.throttled.Load()
+}
+
+// Run samples the runtime every interval until ctx is canceled. Throttling is lifted when it returns.
+func (m *MemoryMonitor) Run(ctx context.Context) {
+	defer m.throttled.Store(false)
+	ticker := time.NewTicker(m.conf.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+// sample reads the runtime, derives the GC pause ratio from the previous sample, and warns about or throttles on the
+// soft limit.
+func (m *MemoryMonitor) sample() {
+	stats := ReadMemoryStats()
+	stats.SoftLimit = m.conf.SoftLimit
+
+	m.mu.Lock()
+	prev := m.stats
+	if elapsed := stats.SampledAt.Sub(prev.SampledAt); elapsed > 0 {
+		stats.GCPauseRatio = float64(stats.GCPauseTotal-prev.GCPauseTotal) / float64(elapsed)
+	}
+	limit := m.conf.SoftLimit
+	switch {
+	case limit == 0:
+	case stats.Used > limit:
+		stats.OverLimit = true
+	case prev.OverLimit && float64(stats.Used) > memoryRecovery*float64(limit):
+		// stay over the limit until use has clearly recovered
+		stats.OverLimit = true
+	}
+	stats.Throttled = stats.OverLimit && m.conf.Throttle
+	m.stats = stats
+	m.mu.Unlock()
+	m.throttled.Store(stats.Throttled)
+
+	switch {
+	case stats.OverLimit && !prev.OverLimit:
+		m.memoryLogger.Warn("Host memory use above soft limit", "used", stats.Used, "soft_limit", limit,
+			"heap_alloc", stats.HeapAlloc, "goroutines", stats.Goroutines, "gc_pause_ratio", stats.GCPauseRatio,
+			"throttled", stats.Throttled)
+	case !stats.OverLimit && prev.OverLimit:
+		m.memoryLogger.Info("Host memory use recovered", "used", stats.Used, "soft_limit", limit)
+	case stats.OverLimit:
+		m.memoryLogger.Debug("Host memory use still above soft limit", "used", stats.Used, "soft_limit", limit,
+			"gc_pause_ratio", stats.GCPauseRatio)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
 )
 
 // ErrNoStart indicates that a required start time is missing.
@@ -13,7 +15,8 @@ var (
 	ErrNoComplete = errors.New("no complete time exists")
 )
 
-// PoolMetrics captures metrics about the lifecycle and performance of a thread pool during its runtime.
+// PoolMetrics captures metrics about the lifecycle and performance of a thread pool during its runtime. Its times are
+// recorded in UTC.
 type PoolMetrics struct {
 	mu                 sync.RWMutex  // mutex to allow threadsafe ops
 	startedAt          time.Time     // when Run() was called
@@ -24,6 +27,7 @@ type PoolMetrics struct {
 	submissionFailures int           // jobs that were unable to be submitted
 	succeeded          int           // jobs that completed successfully
 	failed             int           // jobs that did not complete successfully
+	timedOut           int           // failed jobs abandoned by their worker after their deadline passed
 }
 
 // NewPoolMetrics initializes a new instance of PoolMetrics with default values and a mutex for thread safety.
@@ -89,25 +93,33 @@ func (pm *PoolMetrics) FailedJobs() int {
 	return pm.failed
 }
 
+// TimedOutJobs returns the number of jobs whose deadline passed while they were queued, ran, or waited to be retried.
+// Timed-out jobs are also counted as failed.
+func (pm *PoolMetrics) TimedOutJobs() int {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.timedOut
+}
+
 // SetStarted records the current time as the start time for the pool. It ensures thread safety using a mutex lock.
 func (pm *PoolMetrics) SetStarted() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	pm.startedAt = time.Now()
+	pm.startedAt = timestamp.Now()
 }
 
 // SetStopped records the current time as the point when the pool was stopped, ensuring thread-safe access with a mutex.
 func (pm *PoolMetrics) SetStopped() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	pm.stoppedAt = time.Now()
+	pm.stoppedAt = timestamp.Now()
 }
 
 // SetCompleted records the time when the last job in the pool was completed.
 func (pm *PoolMetrics) SetCompleted() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	pm.completedAt = time.Now()
+	pm.completedAt = timestamp.Now()
 }
 
 // SetDuration calculates and sets the duration between the pool's start and completion times.
@@ -153,7 +165,15 @@ func (pm *PoolMetrics) RecordFailedJob() {
 	pm.failed++
 }
 
+// RecordTimedOutJob increments the count of jobs abandoned after their deadline passed in a thread-safe manner.
+func (pm *PoolMetrics) RecordTimedOutJob() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.timedOut++
+}
+
 // JobMetrics represents the timing and retry metrics of a job including submission, start, finish times, and attempts.
+// Times set by the pool are recorded in UTC.
 type JobMetrics struct {
 	SubmittedAt time.Time
 	StartedAt   time.Time
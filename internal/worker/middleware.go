@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrJobPanicked indicates that a job's WorkUnit panicked; the error carries the panic value and stack.
+var ErrJobPanicked = errors.New("job panicked")
+
+// Middleware wraps a WorkUnit with cross-cutting behavior such as tracing, metrics, auth context injection, or
+// result validation. It is applied to every job executed by a pool that registers it.
+type Middleware func(next WorkUnit) WorkUnit
+
+// Chain composes middlewares so the first is outermost, i.e. Chain(a, b)(unit) behaves like a(b(unit)).
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next WorkUnit) WorkUnit {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				next = middlewares[i](next)
+			}
+		}
+		return next
+	}
+}
+
+// Logging logs every job attempt through attemptLogger with its duration and retry count: failures at warn and
+// successes at debug.
+func Logging(attemptLogger hclog.Logger) Middleware {
+	return func(next WorkUnit) WorkUnit {
+		return func(ctx context.Context) (any, error) {
+			start := time.Now()
+			val, err := next(ctx)
+			l := attemptLogger.With(logger.KeyJobID, JobIDFromCtx(ctx), "retry", RetryCountFromCtx(ctx),
+				"duration", time.Since(start))
+			if err != nil {
+				l.Warn("Job attempt failed", logger.KeyError, err)
+			} else {
+				l.Debug("Job attempt succeeded")
+			}
+			return val, err
+		}
+	}
+}
+
+// Recover converts a panic in a job attempt into an error wrapping ErrJobPanicked, after calling onPanic, if set,
+// with the panic value, e.g. to count panics in a metric. Without it the worker still converts panics into errors,
+// but nothing else observes them.
+func Recover(onPanic func(ctx context.Context, recovered any)) Middleware {
+	return func(next WorkUnit) WorkUnit {
+		return func(ctx context.Context) (val any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if onPanic != nil {
+						onPanic(ctx, r)
+					}
+					val, err = nil, fmt.Errorf("%w: %v\nstack: %s", ErrJobPanicked, r, debug.Stack())
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// RateLimit starts at most rate job attempts per second across the pool, letting up to burst start at once after
+// an idle period, and passes attempts through unchanged when rate is zero or less. An attempt waiting for its turn
+// returns its context's error once the context ends.
+func RateLimit(rate float64, burst int) Middleware {
+	if rate <= 0 {
+		return func(next WorkUnit) WorkUnit { return next }
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	burst = max(burst, 1)
+	var mu sync.Mutex
+	var due time.Time // when the attempt after the last one reserved would start were there no burst
+	return func(next WorkUnit) WorkUnit {
+		return func(ctx context.Context) (any, error) {
+			mu.Lock()
+			now := time.Now()
+			if due.Before(now) {
+				due = now
+			}
+			start := due.Add(-time.Duration(burst-1) * interval)
+			due = due.Add(interval)
+			mu.Unlock()
+			if wait := time.Until(start); wait > 0 {
+				timer := time.NewTimer(wait)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
@@ -9,15 +10,26 @@ import (
 	"time"
 
 	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/utils/pkg/strutil"
 	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// ErrPoolClosed indicates that the worker pool has been closed and cannot accept any new jobs.
-var ErrPoolClosed = errors.New("worker pool is closed")
+var (
+	// ErrPoolClosed indicates that the worker pool has been closed and cannot accept any new jobs.
+	ErrPoolClosed = errors.New("worker pool is closed")
+	// ErrPoolTerminated is the cancellation cause of jobs that were running when the pool was terminated.
+	ErrPoolTerminated = errors.New("worker pool terminated")
+	// ErrTerminateTimeout indicates that workers did not exit before the termination timeout and were abandoned.
+	ErrTerminateTimeout = errors.New("timed out waiting for workers to exit")
+	// ErrInvalidPoolSize indicates that a pool was resized to fewer than one worker.
+	ErrInvalidPoolSize = errors.New("worker pool needs at least one worker")
+)
 
 // MetricResult represents the outcome of a metric evaluation with its success status.
 type MetricResult struct {
 	isSuccess bool
+	timedOut  bool // the job was abandoned by its worker after its deadline passed
 }
 
 // BatchErrors is a map that associates job IDs with their corresponding error objects if errors occur during execution.
@@ -29,6 +41,53 @@ func (b BatchErrors) Add(jobID string, err error) BatchErrors {
 	return b
 }
 
+// BatchSubmission is the submission status of a single job in a batch.
+type BatchSubmission struct {
+	JobID     string `json:"job_id"`
+	Submitted bool   `json:"submitted"`
+	Err       error  `json:"-"`
+}
+
+// BatchReport is the structured outcome of a SubmitBatch call.
+type BatchReport struct {
+	BatchID   string            `json:"batch_id"`
+	Submitted int               `json:"submitted"`
+	Failed    int               `json:"failed"`
+	Items     []BatchSubmission `json:"items"`
+	Errors    BatchErrors       `json:"-"`
+}
+
+// NewBatchReport creates an empty BatchReport with a new batch ID, sized for the given number of jobs.
+func NewBatchReport(size int) *BatchReport {
+	return &BatchReport{
+		BatchID: strutil.GenerateUUIDV7(),
+		Items:   make([]BatchSubmission, 0, size),
+		Errors:  make(BatchErrors),
+	}
+}
+
+// add records the submission status of a job.
+func (r *BatchReport) add(jobID string, err error) {
+	r.Items = append(r.Items, BatchSubmission{JobID: jobID, Submitted: err == nil, Err: err})
+	if err != nil {
+		r.Failed++
+		r.Errors.Add(jobID, err)
+		return
+	}
+	r.Submitted++
+}
+
+// Err returns the submission errors joined into a single error, or nil if every job was submitted.
+func (r *BatchReport) Err() error {
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.JobID, item.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // NewMetricResult creates and returns a new MetricResult with the given success status.
 func NewMetricResult(isSuccess bool) *MetricResult {
 	return &MetricResult{
@@ -39,14 +98,37 @@ func NewMetricResult(isSuccess bool) *MetricResult {
 // Pool represents a worker pool used to manage the execution of concurrent jobs.
 type Pool struct {
 	poolLogger     hclog.Logger
-	maxWorkers     int                // workers count
-	jobs           chan *Job          // for incoming jobs
-	results        chan *JobResult    // for completed jobs
-	wg             *sync.WaitGroup    // for workers
-	closed         atomic.Bool        // identify if closed
-	quit           chan struct{}      // for quit signals
-	metricsChannel chan *MetricResult // pool metrics chan
-	metrics        *PoolMetrics       // pool metrics
+	maxWorkers     int                       // workers count, guarded by sizeMu
+	running        bool                      // whether Run has started the workers, guarded by sizeMu
+	retire         map[int]chan struct{}     // closed to retire the worker with that ID, guarded by sizeMu
+	nextWorkerID   int                       // ID of the next worker started, guarded by sizeMu
+	middleware     Middleware                // chain built from middlewares by Run
+	sizeMu         sync.Mutex                // serializes Run and Resize
+	queues         [priorityLevels]chan *Job // incoming jobs by priority, most urgent first
+	results        chan *JobResult           // for completed jobs
+	wg             *sync.WaitGroup           // for workers
+	closed         atomic.Bool               // identify if closed
+	closing        chan struct{}             // closed once the pool is closed to new jobs, see Scheduler
+	quit           chan struct{}             // closed to signal workers to stop
+	quitOnce       sync.Once                 // closes quit exactly once
+	terminated     context.Context           // canceled with ErrPoolTerminated when the pool is terminated
+	terminate      context.CancelCauseFunc   // cancels terminated
+	drained        chan struct{}             // closed once every worker has exited after the pool is closed
+	metricsChannel chan *MetricResult        // pool metrics chan
+	metrics        *PoolMetrics              // pool metrics
+	chaos          *Chaos                    // optional fault injection
+	resultLimit    *ResultLimit              // optional bound on result size
+	middlewares    []Middleware              // wrap every job, outermost first
+	subscribers    []func(*JobResult)        // receive every result instead of the results channel, see OnResult
+	tracker        *jobTracker               // queued and running jobs
+	classes        *jobClasses               // limits of job classes, nil when no class is limited
+	tracer         trace.Tracer              // records submit and job spans, see WithTracerProvider
+	mu             sync.RWMutex              // guards sends on jobs against closing it
+
+	durable  *DurableQueue  // optional persistent queue jobs are submitted to, see WithDurableQueue
+	wake     chan struct{}  // signals the feeder that a job was persisted
+	feedStop chan struct{}  // closed to stop the feeder when the pool is closed
+	feeding  sync.WaitGroup // for the feeder
 }
 
 // NewPool initializes a new Pool with the specified number of workers and a buffer size for its channels.
@@ -66,91 +148,261 @@ func NewPool(maxWorkers int, limitToCPUs bool, buffer int, poolLogger hclog.Logg
 	if maxWorkers < 1 {
 		maxWorkers = 1
 	}
-	var jobs chan *Job
+	var queues [priorityLevels]chan *Job
 	var results chan *JobResult
 	var metricsConsumer chan *MetricResult
 	if buffer < 1 {
 		// create unbuffered channels
-		jobs = make(chan *Job)
+		for i := range queues {
+			queues[i] = make(chan *Job)
+		}
 		results = make(chan *JobResult)
 		metricsConsumer = make(chan *MetricResult)
 	} else {
-		// create buffered channels
-		jobs = make(chan *Job, buffer)
+		// create buffered channels, each priority level queuing up to buffer jobs
+		for i := range queues {
+			queues[i] = make(chan *Job, buffer)
+		}
 		results = make(chan *JobResult, buffer)
 		metricsConsumer = make(chan *MetricResult, buffer)
 	}
 	if poolLogger == nil {
 		poolLogger = hclog.Default()
 	}
+	terminated, terminate := context.WithCancelCause(context.Background())
 	return &Pool{
 		poolLogger:     poolLogger,
 		maxWorkers:     maxWorkers,
-		jobs:           jobs,
+		queues:         queues,
 		results:        results,
 		wg:             &sync.WaitGroup{},
 		quit:           make(chan struct{}),
+		terminated:     terminated,
+		terminate:      terminate,
+		drained:        make(chan struct{}),
+		closing:        make(chan struct{}),
 		metricsChannel: metricsConsumer,
 		metrics:        NewPoolMetrics(),
+		tracker:        newJobTracker(),
+		tracer:         defaultTracer(),
+		retire:         make(map[int]chan struct{}),
+		nextWorkerID:   1,
 	}
 }
 
+// WithChaos enables fault injection for every job executed by the pool and returns the updated Pool.
+// It must be called before Run; a nil Chaos leaves fault injection disabled.
+func (p *Pool) WithChaos(chaos *Chaos) *Pool {
+	p.chaos = chaos
+	return p
+}
+
+// WithResultLimit bounds the size of every job result, handling larger results with the limit's policy, and returns
+// the updated Pool. A limit of zero bytes or less leaves results unbounded. It must be called before Run.
+func (p *Pool) WithResultLimit(limit ResultLimit) *Pool {
+	p.resultLimit = nil
+	if limit.MaxBytes > 0 {
+		p.resultLimit = &limit
+	}
+	return p
+}
+
+// WithClassLimit limits the jobs of class, see Job.WithClass, to limit and returns the updated Pool. A job whose class
+// is at its limit is held back without occupying a worker, so a flood of slow jobs of one class, e.g. I/O, cannot
+// starve jobs of other classes, and runs once its class has room. Jobs without a limited class are not affected. An
+// unlimited ClassLimit removes the class's limit. It must be called before Run.
+func (p *Pool) WithClassLimit(class string, limit ClassLimit) *Pool {
+	if p.classes == nil {
+		p.classes = newJobClasses(p.quit)
+	}
+	p.classes.set(class, limit)
+	return p
+}
+
+// Use registers middleware that wraps every job executed by the pool and returns the updated Pool.
+// Middleware registered first is outermost. It must be called before Run.
+func (p *Pool) Use(middlewares ...Middleware) *Pool {
+	p.middlewares = append(p.middlewares, middlewares...)
+	return p
+}
+
+// OnResult subscribes fn to the result of every job run by the pool and returns the updated Pool. Each subscriber
+// receives every result, so consumers do not need their own goroutine draining Results; once any subscriber is
+// registered, results are delivered to the subscribers instead of the Results channel, which then only reports that
+// the pool has shut down. Subscribers run on the worker goroutine in registration order and should not block. It must
+// be called before Run.
+func (p *Pool) OnResult(fn func(*JobResult)) *Pool {
+	p.subscribers = append(p.subscribers, fn)
+	return p
+}
+
 // Run starts the worker pool and initializes the configured number of worker goroutines to process jobs concurrently.
 func (p *Pool) Run() {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
 	p.metrics.SetStarted()
 	go p.collectMetrics()
-	for i := 1; i <= p.maxWorkers; i++ {
-		nw := NewWorker(i, p.jobs, p.results, p.quit, p.metricsChannel, p.poolLogger.Named(fmt.Sprintf("worker-%d", i)))
-		p.wg.Add(1)
-		go func(w *Worker) {
-			defer p.wg.Done() // Signal completion when the goroutine exits
-			w.Start()
-		}(nw)
+	if len(p.middlewares) > 0 {
+		p.middleware = Chain(p.middlewares...)
 	}
+	p.running = true
+	for range p.maxWorkers {
+		p.startWorker()
+	}
+	if p.durable != nil {
+		p.feeding.Add(1)
+		go p.feed()
+	}
+}
+
+// startWorker starts a worker goroutine with the next worker ID. The caller must hold sizeMu.
+func (p *Pool) startWorker() {
+	id := p.nextWorkerID
+	p.nextWorkerID++
+	retire := make(chan struct{})
+	p.retire[id] = retire
+	nw := NewWorker(id, p.queues[PriorityNormal.queue()], p.results, p.quit, p.metricsChannel, p.poolLogger.Named(fmt.Sprintf("worker-%d", id))).
+		WithChaos(p.chaos).
+		WithResultLimit(p.resultLimit).
+		WithMiddleware(p.middleware).
+		withTracker(p.tracker).
+		withClasses(p.classes).
+		withTracer(p.tracer).
+		withTermination(p.terminated).
+		withRetire(retire).
+		withSubscribers(p.subscribers).
+		withQueues(p.queues[0], p.queues[1], p.queues[2])
+	p.wg.Add(1)
+	go func(w *Worker) {
+		defer p.wg.Done() // Signal completion when the goroutine exits
+		w.Start()
+	}(nw)
+}
+
+// Resize changes the number of workers to n while the pool is running. Growing starts new workers immediately;
+// shrinking retires the newest workers, each of which exits once it finishes its current job, so no job is
+// interrupted. Unlike NewPool, n is not capped at the number of CPUs. Resizing before Run sets the number of workers
+// Run starts. It returns ErrInvalidPoolSize when n is less than one and ErrPoolClosed once the pool is closed.
+func (p *Pool) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidPoolSize, n)
+	}
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+	// the read lock keeps closeJobs, and so the wait for workers to exit, from starting while workers are added
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	previous := p.maxWorkers
+	p.maxWorkers = n
+	if !p.running {
+		return nil
+	}
+	for range n - previous {
+		p.startWorker()
+	}
+	for range previous - n {
+		newest := p.nextWorkerID - 1
+		for newest > 0 && p.retire[newest] == nil {
+			newest--
+		}
+		close(p.retire[newest])
+		delete(p.retire, newest)
+	}
+	p.poolLogger.Info("Worker pool resized", "from", previous, "to", n)
+	return nil
 }
 
 // Submit schedules a Job for execution in the Pool; returns an error if the Pool is closed or the submission fails.
+// Workers take queued jobs in Job.Priority order, so a high priority job only waits for a free worker. A pool with a
+// DurableQueue persists the job first, and fails with ErrNotSerializable for a job that cannot be persisted.
 func (p *Pool) Submit(job *Job) (err error) {
 	job.SetSubmittedAt()
+	job.ensureRequestID()
+	// the submit span is carried in the job's context, parenting the span of its execution
+	var span trace.Span
+	job.Ctx, span = p.tracer.Start(job.Ctx, SpanSubmit, trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(job.spanAttributes()...))
+	defer func() { endSpan(span, err) }()
+	if p.durable != nil {
+		return p.submitDurable(job)
+	}
+	// the read lock is held for the whole send so closeJobs cannot close the queue underneath it
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	if p.closed.Load() {
+		p.metrics.RecordFailedSubmission()
+		p.poolLogger.With(logger.KeyJobID, job.ID, logger.KeyRequestID, job.RequestID).
+			Warn("Job queue closed, job not submitted")
+		return ErrPoolClosed
+	}
+	// track before sending so a worker never starts a job that is not yet recorded as queued
+	p.tracker.queued(job)
+	select {
+	case p.queues[job.Priority.queue()] <- job:
+	case <-p.quit:
+		// the pool was terminated while waiting for a worker
+		p.tracker.dropped(job.ID)
+		p.metrics.RecordFailedSubmission()
 		return ErrPoolClosed
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			err = ErrPoolClosed
-			p.metrics.RecordFailedSubmission()
-			p.poolLogger.With(logger.KeyJobID, job.ID).Warn("Job queue closed, job not submitted")
-		}
-	}()
-	p.jobs <- job
 	p.metrics.RecordSubmission()
 	return nil
 }
 
-// SubmitBatch processes a batch of jobs, submitting each to the pool and tracking the number of successes and failures.
-func (p *Pool) SubmitBatch(jobs []*Job) (int, int, BatchErrors) {
-	submitted := 0
-	failures := 0
-	var errorMap BatchErrors
+// SubmitBatch submits a batch of jobs under a shared batch ID, tying each job's context to parent so canceling
+// parent cancels every job in the batch. Jobs are not submitted once parent has ended. The returned BatchReport
+// records the submission status of every job; the batch ID is also carried in each job's context and JobResult
+// so results can be joined back to the report. Use NewBatch to await the batch's results collectively.
+func (p *Pool) SubmitBatch(parent context.Context, jobs []*Job) *BatchReport {
+	report := NewBatchReport(len(jobs))
+	batchLogger := p.poolLogger.With(logger.KeyBatchID, report.BatchID)
 	for _, job := range jobs {
-		err := p.Submit(job)
+		job.WithBatchID(report.BatchID).WithParent(parent)
+		err := context.Cause(parent)
+		if err == nil {
+			err = p.Submit(job)
+		}
+		report.add(job.ID, err)
 		if err != nil {
-			failures++
-			p.poolLogger.With(logger.KeyJobID, job.ID).Warn("Job failed", "error", err)
-			errorMap.Add(job.ID, err)
-		} else {
-			submitted++
+			batchLogger.With(logger.KeyJobID, job.ID).Warn("Job not submitted", logger.KeyError, err)
 		}
 	}
-	return submitted, failures, errorMap
+	if report.Failed > 0 {
+		batchLogger.Warn("Batch partially submitted", "submitted", report.Submitted, "failed", report.Failed)
+	}
+	return report
+}
+
+// closeJobs marks the pool closed and closes the jobs channel, reporting false if the pool was already closed.
+// It waits for in-flight submissions to finish sending, so Submit never sends on a closed channel, and for the
+// feeder of a durable pool to stop.
+func (p *Pool) closeJobs() bool {
+	p.mu.Lock()
+	if !p.closed.CompareAndSwap(false, true) {
+		p.mu.Unlock()
+		return false
+	}
+	close(p.closing)
+	for _, q := range p.queues {
+		close(q)
+	}
+	if p.feedStop != nil {
+		close(p.feedStop)
+	}
+	p.mu.Unlock()
+	p.feeding.Wait()
+	return true
 }
 
 // Shutdown gracefully stops the worker pool, ensuring all submitted jobs are completed and resources are released.
 func (p *Pool) Shutdown() {
-	if p.closed.CompareAndSwap(false, true) {
+	if p.closeJobs() {
 		p.metrics.SetStopped()
-		close(p.jobs)
 		p.wg.Wait()
+		close(p.drained)
 		p.metrics.SetCompleted()
 		err := p.metrics.SetDuration()
 		if err != nil {
@@ -161,12 +413,45 @@ func (p *Pool) Shutdown() {
 	}
 }
 
+// ShutdownContext gracefully stops the pool like Shutdown, but waits for queued and running jobs only until ctx ends.
+// The pool is then terminated: running jobs are canceled with ErrPoolTerminated, queued jobs are abandoned, and the
+// number of jobs that were queued or running at that point is returned along with the context's cause. Workers stuck
+// in a call that ignores its context are not waited for; the results channel is closed once they exit.
+func (p *Pool) ShutdownContext(ctx context.Context) (int, error) {
+	if !p.closeJobs() {
+		return 0, nil
+	}
+	p.metrics.SetStopped()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.finishTermination()
+		return 0, nil
+	case <-ctx.Done():
+	}
+	abandoned := len(p.Pending()) + len(p.Running())
+	p.poolLogger.Warn("Shutdown deadline reached, terminating pool", "abandoned_jobs", abandoned)
+	p.quitOnce.Do(func() {
+		close(p.quit)
+		p.terminate(ErrPoolTerminated)
+	})
+	go func() {
+		<-done
+		p.finishTermination()
+	}()
+	return abandoned, context.Cause(ctx)
+}
+
 // Stop gracefully shuts down the pool by marking it as closed, waiting for workers to finish, and finalizing metrics.
 func (p *Pool) Stop() {
-	if p.closed.CompareAndSwap(false, true) {
+	if p.closeJobs() {
 		p.metrics.SetStopped()
-		close(p.jobs)
 		p.wg.Wait()
+		close(p.drained)
 		p.metrics.SetCompleted()
 		err := p.metrics.SetDuration()
 		if err != nil {
@@ -175,24 +460,66 @@ func (p *Pool) Stop() {
 	}
 }
 
-// Terminate gracefully stops the pool execution by closing all channels and setting metrics,
-// canceling ongoing work immediately.
+// Terminate stops the pool immediately: workers stop taking jobs, running jobs are canceled with
+// ErrPoolTerminated, and queued jobs are abandoned. It waits for workers to exit before closing the results channel.
 func (p *Pool) Terminate() {
-	if p.closed.CompareAndSwap(false, true) {
-		p.metrics.SetStopped()
-		// Cancel any ongoing work by closing channels immediately
-		close(p.jobs)
-		p.metrics.SetCompleted()
-		err := p.metrics.SetDuration()
-		if err != nil {
-			p.poolLogger.Warn("unable to set pool duration")
-		}
-		close(p.results)
-		close(p.metricsChannel)
+	_ = p.TerminateWithTimeout(0)
+}
+
+// TerminateWithTimeout terminates the pool like Terminate but waits at most timeout for workers to exit; a timeout
+// of zero or less waits indefinitely. Workers still running at the timeout, e.g. stuck in a call that ignores its
+// context, are abandoned and ErrTerminateTimeout is returned. The results channel is then closed only once the
+// abandoned workers exit, so they can never send on a closed channel.
+func (p *Pool) TerminateWithTimeout(timeout time.Duration) error {
+	// signal workers first so a Shutdown in progress is hurried along as well
+	p.quitOnce.Do(func() {
+		close(p.quit)
+		p.terminate(ErrPoolTerminated)
+	})
+	if !p.closeJobs() {
+		return nil
+	}
+	p.metrics.SetStopped()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case <-done:
+		p.finishTermination()
+		return nil
+	case <-expired:
+		p.poolLogger.Warn("Workers did not exit before termination timeout, abandoning them", "timeout", timeout)
+		go func() {
+			<-done
+			p.finishTermination()
+		}()
+		return ErrTerminateTimeout
 	}
 }
 
-// Results returns a channel from which completed job results can be received.
+// finishTermination finalizes metrics and closes the results and metrics channels once every worker has exited.
+func (p *Pool) finishTermination() {
+	close(p.drained)
+	p.metrics.SetCompleted()
+	err := p.metrics.SetDuration()
+	if err != nil {
+		p.poolLogger.Warn("unable to set pool duration")
+	}
+	close(p.results)
+	close(p.metricsChannel)
+}
+
+// Results returns a channel from which completed job results can be received. Nothing is sent on it when result
+// subscribers are registered with OnResult.
 func (p *Pool) Results() <-chan *JobResult {
 	return p.results
 }
@@ -217,8 +544,10 @@ func (p *Pool) CompletedAt() time.Time {
 	return p.metrics.Completed()
 }
 
-// Workers returns the maximum number of workers configured for the pool.
+// Workers returns the number of workers configured for the pool, reflecting any Resize.
 func (p *Pool) Workers() int {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
 	return p.maxWorkers
 }
 
@@ -238,10 +567,60 @@ func (p *Pool) Metrics() *PoolMetrics {
 	mCopy.submissionFailures = p.metrics.submissionFailures
 	mCopy.succeeded = p.metrics.succeeded
 	mCopy.failed = p.metrics.failed
+	mCopy.timedOut = p.metrics.timedOut
 	//return copy
 	return mCopy
 }
 
+// PoolSnapshot is a point-in-time, serializable view of the pool's state and metrics.
+type PoolSnapshot struct {
+	Workers           int       `json:"worker_count"`
+	Closed            bool      `json:"pool_closed"`
+	QueuedJobs        int       `json:"queued_jobs"`
+	PendingResults    int       `json:"pending_results"`
+	StartedAt         time.Time `json:"pool_started_at"`
+	StoppedAt         time.Time `json:"pool_stopped_at"`
+	CompletedAt       time.Time `json:"pool_completed_at"`
+	Duration          float64   `json:"pool_duration_seconds"` // seconds
+	Submissions       int       `json:"jobs_submitted"`
+	FailedSubmissions int       `json:"failed_submissions"`
+	SuccessfulJobs    int       `json:"successful_jobs"`
+	FailedJobs        int       `json:"failed_jobs"`
+	TimedOutJobs      int       `json:"timed_out_jobs"`
+}
+
+// Snapshot returns a PoolSnapshot describing the pool's current state, suitable for debugging and reporting.
+func (p *Pool) Snapshot() PoolSnapshot {
+	m := p.Metrics()
+	return PoolSnapshot{
+		Workers:           p.Workers(),
+		Closed:            p.closed.Load(),
+		QueuedJobs:        p.queued(),
+		PendingResults:    len(p.results),
+		StartedAt:         m.startedAt,
+		StoppedAt:         m.stoppedAt,
+		CompletedAt:       m.completedAt,
+		Duration:          m.duration.Seconds(),
+		Submissions:       m.submissions,
+		FailedSubmissions: m.submissionFailures,
+		SuccessfulJobs:    m.succeeded,
+		FailedJobs:        m.failed,
+		TimedOutJobs:      m.timedOut,
+	}
+}
+
+// queued returns the number of jobs waiting in the pool's queues, including persisted jobs not yet queued.
+func (p *Pool) queued() int {
+	n := 0
+	if p.durable != nil {
+		n = p.durable.Len()
+	}
+	for _, q := range p.queues {
+		n += len(q)
+	}
+	return n
+}
+
 // collectMetrics processes metric results from the metricsChannel, updating success and failure counts
 // in a thread-safe manner.
 func (p *Pool) collectMetrics() {
@@ -252,6 +631,9 @@ func (p *Pool) collectMetrics() {
 		} else {
 			p.metrics.failed++
 		}
+		if mr.timedOut {
+			p.metrics.timedOut++
+		}
 		p.metrics.mu.Unlock()
 	}
 }
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// stressSubmitters and stressJobs are how many goroutines submit to the pool while it is closed, and how many jobs
+// each submits.
+const (
+	stressSubmitters = 16
+	stressJobs       = 200
+)
+
+// TestPoolSubmitDuringClose submits jobs from many goroutines while the pool is shut down or terminated. Run it with
+// -race: every submission must either be accepted or fail with ErrPoolClosed, and none may panic on a closed channel.
+func TestPoolSubmitDuringClose(t *testing.T) {
+	closers := map[string]func(*Pool){
+		"Shutdown":  (*Pool).Shutdown,
+		"Terminate": (*Pool).Terminate,
+		"ShutdownContext": func(p *Pool) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, _ = p.ShutdownContext(ctx)
+		},
+	}
+	for name, closePool := range closers {
+		t.Run(name, func(t *testing.T) {
+			pool := NewPool(4, false, 8, hclog.NewNullLogger())
+			pool.Run()
+			// drain the results so workers never block on a full channel
+			drained := make(chan struct{})
+			go func() {
+				defer close(drained)
+				for range pool.Results() {
+				}
+			}()
+
+			var accepted, rejected atomic.Int64
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for range stressSubmitters {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					for range stressJobs {
+						err := pool.Submit(NewJob(context.Background(), func(context.Context) (any, error) {
+							return nil, nil
+						}))
+						switch {
+						case err == nil:
+							accepted.Add(1)
+						case errors.Is(err, ErrPoolClosed):
+							rejected.Add(1)
+						default:
+							t.Errorf("Submit returned %v, want nil or ErrPoolClosed", err)
+						}
+					}
+				}()
+			}
+			close(start)
+			// close the pool once submissions are in full swing
+			for accepted.Load() < stressSubmitters*stressJobs/4 {
+				runtime.Gosched()
+			}
+			closePool(pool)
+			wg.Wait()
+			<-drained
+
+			if err := pool.Submit(NewJob(context.Background(), func(context.Context) (any, error) {
+				return nil, nil
+			})); !errors.Is(err, ErrPoolClosed) {
+				t.Errorf("Submit after %s returned %v, want ErrPoolClosed", name, err)
+			}
+			if total := accepted.Load() + rejected.Load(); total != stressSubmitters*stressJobs {
+				t.Errorf("got %d submissions, want %d", total, stressSubmitters*stressJobs)
+			}
+		})
+	}
+}
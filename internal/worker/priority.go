@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Priority orders queued jobs: workers always take a queued job of a higher priority before one of a lower
+// priority, and jobs of equal priority run in submission order. The zero value is PriorityNormal.
+type Priority int
+
+// PriorityLow is for bulk and background work that may wait behind everything else.
+// PriorityNormal is the default priority of a job.
+// PriorityHigh is for latency-sensitive work, such as plugin health checks, that should not wait behind bulk jobs.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// ErrUnknownPriority indicates that a priority name is not high, normal, or low.
+var ErrUnknownPriority = errors.New("unknown job priority")
+
+// priorityLevels is the number of priority levels, and so of pool queues.
+const priorityLevels = 3
+
+// priorityNames maps each Priority to its name.
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+}
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// ParsePriority returns the Priority named high, normal, or low; an empty name is PriorityNormal.
+func ParsePriority(name string) (Priority, error) {
+	if name == "" {
+		return PriorityNormal, nil
+	}
+	for p, n := range priorityNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("%w: %q", ErrUnknownPriority, name)
+}
+
+// queue returns the index of the pool queue holding jobs of priority p, zero being the most urgent. Priorities
+// outside the known range are clamped to the nearest level.
+func (p Priority) queue() int {
+	switch {
+	case p >= PriorityHigh:
+		return 0
+	case p <= PriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// OverflowPolicy is what a pool does with a job result larger than its ResultLimit.
+type OverflowPolicy string
+
+// OverflowTruncate cuts the result down to the limit.
+// OverflowSpill writes the result to a file and replaces it with a SpilledResult referencing the file.
+// OverflowError drops the result and fails the job with ErrResultTooLarge.
+const (
+	OverflowTruncate OverflowPolicy = "truncate"
+	OverflowSpill    OverflowPolicy = "spill"
+	OverflowError    OverflowPolicy = "error"
+)
+
+// spillFileExt is the extension of the files results are spilled to.
+const spillFileExt = ".result"
+
+var (
+	// ErrResultTooLarge indicates that a job's result exceeded the pool's ResultLimit and was dropped.
+	ErrResultTooLarge = errors.New("job result too large")
+	// ErrInvalidResultLimit indicates that a ResultLimit has an unknown policy or no directory to spill to.
+	ErrInvalidResultLimit = errors.New("invalid result limit")
+)
+
+// ResultLimit bounds the size of job results so a plugin returning a huge payload cannot exhaust host memory or
+// overwhelm result consumers. A result's size is its length for strings and byte slices, and the length of its JSON
+// encoding otherwise; results that cannot be encoded are passed through unchecked.
+type ResultLimit struct {
+	MaxBytes int            // largest result passed through unchanged, no limit when zero or less
+	Policy   OverflowPolicy // what to do with a larger result
+	SpillDir string         // where OverflowSpill writes results, created when first needed
+}
+
+// Validate reports an unknown policy, or OverflowSpill without a SpillDir.
+func (l ResultLimit) Validate() error {
+	switch l.Policy {
+	case OverflowTruncate, OverflowError:
+		return nil
+	case OverflowSpill:
+		if l.SpillDir == "" {
+			return fmt.Errorf("%w: %s needs a spill directory", ErrInvalidResultLimit, l.Policy)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown policy %q", ErrInvalidResultLimit, l.Policy)
+}
+
+// ResultOverflow describes what was done with a result that exceeded the pool's ResultLimit.
+type ResultOverflow struct {
+	Policy OverflowPolicy `json:"policy"`
+	Size   int            `json:"size"`           // size of the original result in bytes
+	Path   string         `json:"path,omitempty"` // file the result was spilled to
+}
+
+// SpilledResult replaces the value of a job result that was spilled to a file. The file holds the result as is for
+// strings and byte slices, and its JSON encoding otherwise.
+type SpilledResult struct {
+	Path string `json:"path"`
+	Size int    `json:"size"`
+}
+
+// apply enforces the limit on the value and error of the job with the given ID, returning the value and error to
+// report and, when the value was too large, what was done with it.
+func (l *ResultLimit) apply(jobID string, value any, err error) (any, *ResultOverflow, error) {
+	if l == nil || l.MaxBytes <= 0 || value == nil {
+		return value, nil, err
+	}
+	// measure strings before copying them
+	if s, ok := value.(string); ok && len(s) <= l.MaxBytes {
+		return value, nil, err
+	}
+	data, ok := resultBytes(value)
+	if !ok || len(data) <= l.MaxBytes {
+		return value, nil, err
+	}
+	overflow := &ResultOverflow{Policy: l.Policy, Size: len(data)}
+	tooLarge := fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrResultTooLarge, len(data), l.MaxBytes)
+	switch l.Policy {
+	case OverflowTruncate:
+		return truncate(value, data, l.MaxBytes), overflow, err
+	case OverflowSpill:
+		path, spillErr := l.spill(jobID, data)
+		if spillErr != nil {
+			overflow.Policy = OverflowError
+			return nil, overflow, errors.Join(err, tooLarge, spillErr)
+		}
+		overflow.Path = path
+		return &SpilledResult{Path: path, Size: len(data)}, overflow, err
+	default:
+		return nil, overflow, errors.Join(err, tooLarge)
+	}
+}
+
+// spill writes data to the job's file in SpillDir and returns its path.
+func (l *ResultLimit) spill(jobID string, data []byte) (string, error) {
+	if err := os.MkdirAll(l.SpillDir, 0o755); err != nil {
+		return "", err
+	}
+	// job IDs are usually UUIDs, but may come from a remote caller, so they must not escape SpillDir
+	path := filepath.Join(l.SpillDir, filepath.Base(filepath.Clean("/"+jobID))+spillFileExt)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// resultBytes returns the bytes a result's size is measured by, or false if it cannot be encoded.
+func resultBytes(value any) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	}
+	data, err := json.Marshal(value)
+	return data, err == nil
+}
+
+// truncate cuts a result down to limit bytes. Strings stay strings and are cut on a rune boundary; other values
+// become the truncated bytes of their encoding. The bytes are copied so the original result can be freed.
+func truncate(value any, data []byte, limit int) any {
+	if s, ok := value.(string); ok {
+		for limit > 0 && !utf8.RuneStart(s[limit]) {
+			limit--
+		}
+		return strings.Clone(s[:limit])
+	}
+	return bytes.Clone(data[:limit])
+}
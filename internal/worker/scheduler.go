@@ -0,0 +1,237 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrDuplicateSchedule indicates that a scheduled job is added under a name already in use.
+// ErrSchedulerStopped indicates that a job is added to a scheduler that has stopped.
+var (
+	ErrDuplicateSchedule = errors.New("scheduled job already exists")
+	ErrSchedulerStopped  = errors.New("scheduler is stopped")
+)
+
+// ScheduledJob is a job the Scheduler submits to its pool on a Schedule, e.g. a periodic plugin health check or
+// checksum re-verification. NewJob builds the job of each run with the context Run was given. Each run is delayed by
+// a random duration up to Jitter, so jobs sharing a schedule do not all start at once. Unless AllowOverlap is set, a
+// run is skipped while the job of the previous run is still queued or running.
+type ScheduledJob struct {
+	Name         string
+	Schedule     Schedule
+	NewJob       func(ctx context.Context) *Job
+	Jitter       time.Duration
+	AllowOverlap bool
+}
+
+// ScheduleStatus reports the runs of a scheduled job.
+type ScheduleStatus struct {
+	Name    string    `json:"name"`
+	Next    time.Time `json:"next,omitempty"`
+	LastRun time.Time `json:"last_run,omitempty"`
+	Runs    int       `json:"runs"`
+	Skipped int       `json:"skipped"`
+	LastErr string    `json:"last_error,omitempty"`
+}
+
+// Scheduler submits jobs to a pool on intervals or cron expressions. It stops submitting when the context given to
+// Run ends or the pool is closed, so shutting the pool down also stops its schedules.
+type Scheduler struct {
+	schedulerLogger hclog.Logger
+	pool            *Pool
+	mu              sync.Mutex
+	entries         map[string]*scheduleEntry // guarded by mu
+	ctx             context.Context           // the context Run was given, nil until Run is called, guarded by mu
+	stopped         bool                      // whether Run has returned, guarded by mu
+	wg              sync.WaitGroup            // for the entries' goroutines
+}
+
+// scheduleEntry is a scheduled job and the state of its runs.
+type scheduleEntry struct {
+	job      ScheduledJob
+	status   ScheduleStatus // guarded by the scheduler's mu
+	inFlight atomic.Bool    // whether the job of the last run is queued or running
+	remove   chan struct{}  // closed when the job is removed
+}
+
+// NewScheduler creates a Scheduler submitting jobs to pool.
+func NewScheduler(pool *Pool, schedulerLogger hclog.Logger) *Scheduler {
+	if schedulerLogger == nil {
+		schedulerLogger = hclog.Default()
+	}
+	return &Scheduler{
+		schedulerLogger: schedulerLogger,
+		pool:            pool,
+		entries:         make(map[string]*scheduleEntry),
+	}
+}
+
+// Add schedules job, starting it right away when the scheduler is running and otherwise once Run is called. It
+// returns ErrInvalidSchedule when the job has no name, schedule, or NewJob, ErrDuplicateSchedule when its name is in
+// use, and ErrSchedulerStopped once the scheduler has stopped.
+func (s *Scheduler) Add(job ScheduledJob) error {
+	if job.Name == "" || job.Schedule == nil || job.NewJob == nil {
+		return fmt.Errorf("%w: a scheduled job needs a name, a schedule, and NewJob", ErrInvalidSchedule)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateSchedule, job.Name)
+	}
+	e := &scheduleEntry{job: job, status: ScheduleStatus{Name: job.Name}, remove: make(chan struct{})}
+	s.entries[job.Name] = e
+	if s.ctx != nil {
+		s.start(e)
+	}
+	return nil
+}
+
+// Remove stops scheduling the named job, reporting false if it is not scheduled. A run already submitted is not
+// canceled.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return false
+	}
+	delete(s.entries, name)
+	close(e.remove)
+	return true
+}
+
+// Statuses returns the status of every scheduled job, sorted by name.
+func (s *Scheduler) Statuses() []ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]ScheduleStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status)
+	}
+	slices.SortFunc(statuses, func(a, b ScheduleStatus) int { return strings.Compare(a.Name, b.Name) })
+	return statuses
+}
+
+// Run starts the scheduled jobs and submits them until ctx is canceled or the pool is closed, then waits for the
+// jobs being submitted and returns. A scheduler runs once; jobs added after it has stopped are rejected.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	if s.ctx != nil || s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.ctx = ctx
+	for _, e := range s.entries {
+		s.start(e)
+	}
+	s.mu.Unlock()
+	s.schedulerLogger.Debug("Scheduler started")
+
+	select {
+	case <-ctx.Done():
+	case <-s.pool.closing:
+		s.schedulerLogger.Debug("Pool closed, stopping scheduler")
+	}
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.schedulerLogger.Debug("Scheduler stopped")
+}
+
+// start runs the entry's schedule in a goroutine. The caller must hold mu.
+func (s *Scheduler) start(e *scheduleEntry) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(s.ctx, e)
+	}()
+}
+
+// run submits the entry's job each time its schedule comes due until ctx ends, the entry is removed, the pool is
+// closed, or the schedule ends. Runs missed while a submission blocked are skipped.
+func (s *Scheduler) run(ctx context.Context, e *scheduleEntry) {
+	entryLogger := s.schedulerLogger.With("schedule", e.job.Name)
+	due := time.Now()
+	for {
+		if now := time.Now(); due.Before(now) {
+			due = now
+		}
+		due = e.job.Schedule.Next(due)
+		if due.IsZero() {
+			entryLogger.Debug("Schedule ended")
+			return
+		}
+		at := due
+		if e.job.Jitter > 0 {
+			at = at.Add(rand.N(e.job.Jitter))
+		}
+		s.mu.Lock()
+		e.status.Next = at
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-e.remove:
+			timer.Stop()
+			return
+		case <-s.pool.closing:
+			timer.Stop()
+			return
+		}
+		if errors.Is(s.submit(ctx, e, entryLogger), ErrPoolClosed) {
+			return
+		}
+	}
+}
+
+// submit builds and submits the job of a run of the entry, unless the job of its previous run has not finished yet,
+// and returns the submission error.
+func (s *Scheduler) submit(ctx context.Context, e *scheduleEntry, entryLogger hclog.Logger) error {
+	if !e.job.AllowOverlap && e.inFlight.Load() {
+		s.mu.Lock()
+		e.status.Skipped++
+		s.mu.Unlock()
+		entryLogger.Debug("Previous run still in flight, run skipped")
+		return nil
+	}
+	job := e.job.NewJob(ctx)
+	if job == nil {
+		return nil
+	}
+	e.inFlight.Store(true)
+	job.WithCallback(func(*JobResult) { e.inFlight.Store(false) })
+	err := s.pool.Submit(job)
+	if err != nil {
+		// the job was never queued, so its callback will not run
+		e.inFlight.Store(false)
+		entryLogger.Warn("Failed to submit scheduled job", logger.KeyJobID, job.ID, logger.KeyError, err)
+	}
+	s.mu.Lock()
+	e.status.LastRun = timestamp.Now()
+	e.status.Runs++
+	e.status.LastErr = ""
+	if err != nil {
+		e.status.LastErr = err.Error()
+	}
+	s.mu.Unlock()
+	return err
+}
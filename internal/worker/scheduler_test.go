@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestParseSchedule checks the next run of cron expressions, descriptors, and intervals, and that invalid specs are
+// refused.
+func TestParseSchedule(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2026, 10, 14, 10, 7, 30, 0, time.UTC)
+	tests := map[string]time.Time{
+		"*/15 * * * *":    time.Date(2026, 10, 14, 10, 15, 0, 0, time.UTC),
+		"@hourly":         time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC),
+		"@every 30s":      time.Date(2026, 10, 14, 10, 8, 0, 0, time.UTC),
+		"0 9 * * mon":     time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		"0 12 * * 7":      time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		"30 8 1 * *":      time.Date(2026, 11, 1, 8, 30, 0, 0, time.UTC),
+		"0 0 15,31 * fri": time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		"0 0 30 feb *":    {},
+	}
+	for spec, want := range tests {
+		t.Run(spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule: %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(want) {
+				t.Errorf("got next run %s, want %s", got, want)
+			}
+		})
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * foo *", "@often", "@every 0s"} {
+		t.Run("invalid "+spec, func(t *testing.T) {
+			if _, err := ParseSchedule(spec); !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("got %v, want ErrInvalidSchedule", err)
+			}
+		})
+	}
+}
+
+// TestSchedulerRuns checks that a scheduled job is submitted on its schedule until it is removed.
+func TestSchedulerRuns(t *testing.T) {
+	pool := NewPool(1, false, 1, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	var runs atomic.Int32
+	s := NewScheduler(pool, hclog.NewNullLogger())
+	if err := s.Add(ScheduledJob{
+		Name:     "tick",
+		Schedule: Every(5 * time.Millisecond),
+		NewJob: func(ctx context.Context) *Job {
+			return NewJob(ctx, func(context.Context) (any, error) {
+				runs.Add(1)
+				return nil, nil
+			})
+		},
+	}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := runs.Load(); got < 3 {
+		t.Fatalf("got %d runs, want at least 3", got)
+	}
+	statuses := s.Statuses()
+	if len(statuses) != 1 || statuses[0].Name != "tick" || statuses[0].Runs < 3 || statuses[0].LastErr != "" {
+		t.Errorf("got statuses %+v", statuses)
+	}
+	if !s.Remove("tick") || s.Remove("tick") {
+		t.Error("Remove did not report the job as scheduled exactly once")
+	}
+	cancel()
+	<-done
+	err := s.Add(ScheduledJob{Name: "late", Schedule: Every(time.Second), NewJob: noopJob})
+	if !errors.Is(err, ErrSchedulerStopped) {
+		t.Errorf("Add after Run: got %v, want ErrSchedulerStopped", err)
+	}
+}
+
+// TestSchedulerSkipsOverlap checks that a run is skipped while the job of the previous run is still running.
+func TestSchedulerSkipsOverlap(t *testing.T) {
+	pool := NewPool(2, false, 4, hclog.NewNullLogger())
+	pool.Run()
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		pool.Shutdown()
+	}()
+	s := NewScheduler(pool, hclog.NewNullLogger())
+	if err := s.Add(ScheduledJob{
+		Name:     "slow",
+		Schedule: Every(5 * time.Millisecond),
+		NewJob: func(ctx context.Context) *Job {
+			return NewJob(ctx, func(context.Context) (any, error) {
+				<-release
+				return nil, nil
+			})
+		},
+	}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if st := s.Statuses()[0]; st.Skipped >= 3 {
+			if st.Runs != 1 {
+				t.Errorf("got %d runs, want 1 while the first is running", st.Runs)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("no overlapping run was skipped")
+}
+
+// TestSchedulerAddErrors checks that jobs without a name, schedule, or NewJob, and duplicate names, are refused.
+func TestSchedulerAddErrors(t *testing.T) {
+	s := NewScheduler(NewPool(1, false, 1, hclog.NewNullLogger()), hclog.NewNullLogger())
+	if err := s.Add(ScheduledJob{Name: "job", Schedule: Every(time.Second), NewJob: noopJob}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	tests := map[string]struct {
+		job  ScheduledJob
+		want error
+	}{
+		"no name":     {job: ScheduledJob{Schedule: Every(time.Second), NewJob: noopJob}, want: ErrInvalidSchedule},
+		"no schedule": {job: ScheduledJob{Name: "other", NewJob: noopJob}, want: ErrInvalidSchedule},
+		"no NewJob":   {job: ScheduledJob{Name: "other", Schedule: Every(time.Second)}, want: ErrInvalidSchedule},
+		"duplicate": {
+			job:  ScheduledJob{Name: "job", Schedule: Every(time.Second), NewJob: noopJob},
+			want: ErrDuplicateSchedule,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Add(tt.job); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// noopJob builds a job that does nothing.
+func noopJob(ctx context.Context) *Job {
+	return NewJob(ctx, func(context.Context) (any, error) { return nil, nil })
+}
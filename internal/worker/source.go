@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/shared/pkg/jobsource"
+	"github.com/hashicorp/go-hclog"
+)
+
+var (
+	// ErrNoJobsCapability indicates that a job source plugin did not declare a jobs capability, so it may not
+	// submit any work.
+	ErrNoJobsCapability = errors.New("job source has no jobs capability")
+	// ErrJobTypeNotAllowed indicates that a job source requested a job type its jobs capability does not allow.
+	ErrJobTypeNotAllowed = errors.New("job type not allowed for job source")
+)
+
+// SourceFeeder streams job requests from a job source plugin into a Pool, enforcing the plugin's jobs capability:
+// requests for types it does not allow, or that are not registered, are rejected, and submissions are paced to
+// its per-minute limit. Pacing blocks the source's send call, so a busy host applies backpressure to the plugin.
+type SourceFeeder struct {
+	name         string
+	source       jobsource.JobSource
+	pool         *Pool
+	limits       *capability.JobsCapability
+	feederLogger hclog.Logger
+	accepted     atomic.Int64
+	rejected     atomic.Int64
+}
+
+// NewSourceFeeder creates a SourceFeeder that submits jobs requested by the named plugin to pool within limits.
+// Submitted jobs are tagged with the plugin's name.
+func NewSourceFeeder(name string,
+	source jobsource.JobSource,
+	pool *Pool,
+	limits *capability.JobsCapability,
+	feederLogger hclog.Logger) *SourceFeeder {
+	if feederLogger == nil {
+		feederLogger = hclog.Default()
+	}
+	return &SourceFeeder{
+		name:         name,
+		source:       source,
+		pool:         pool,
+		limits:       limits,
+		feederLogger: feederLogger.With(logger.KeyJobPlugin, name),
+	}
+}
+
+// Run streams job requests from the source until ctx is canceled, the source is exhausted, or the pool is closed.
+// Jobs are created with ctx, so canceling it also cancels the jobs the source submitted.
+func (f *SourceFeeder) Run(ctx context.Context) error {
+	if f.limits == nil || len(f.limits.Types) == 0 {
+		return ErrNoJobsCapability
+	}
+	var interval time.Duration
+	if f.limits.MaxPerMinute > 0 {
+		interval = time.Minute / time.Duration(f.limits.MaxPerMinute)
+	}
+	var next time.Time
+	f.feederLogger.Info("Job source started", "job_types", f.limits.Types)
+	err := f.source.Stream(ctx, f.limits.Types, func(req jobsource.Request) error {
+		if !f.limits.Allows(req.Type) {
+			f.reject(req, ErrJobTypeNotAllowed)
+			return nil
+		}
+		job, err := JobFromEnvelope(ctx, &Envelope{
+			Type:       req.Type,
+			Plugin:     f.name,
+			Encoding:   req.Encoding,
+			Payload:    req.Payload,
+			MaxRetries: req.MaxRetries,
+			RetryDelay: req.RetryDelay,
+		})
+		if err != nil {
+			f.reject(req, err)
+			return nil
+		}
+		if interval > 0 {
+			if err := waitUntil(ctx, next); err != nil {
+				return err
+			}
+			next = time.Now().Add(interval)
+		}
+		if err := f.pool.Submit(job); err != nil {
+			return err
+		}
+		f.accepted.Add(1)
+		return nil
+	})
+	f.feederLogger.Info("Job source stopped", "accepted", f.Accepted(), "rejected", f.Rejected())
+	return err
+}
+
+// Accepted returns the number of jobs submitted to the pool on the source's behalf.
+func (f *SourceFeeder) Accepted() int64 {
+	return f.accepted.Load()
+}
+
+// Rejected returns the number of job requests refused because of the source's capability or an invalid payload.
+func (f *SourceFeeder) Rejected() int64 {
+	return f.rejected.Load()
+}
+
+// reject records and logs a refused job request.
+func (f *SourceFeeder) reject(req jobsource.Request, err error) {
+	f.rejected.Add(1)
+	f.feederLogger.Warn("Job request rejected", logger.KeyJobType, req.Type, logger.KeyError, err)
+}
+
+// waitUntil blocks until t or until ctx is canceled, returning the context's error in the latter case.
+func waitUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the spans recorded by worker pools.
+const TracerName = "github.com/bmj2728/PlugsConc/internal/worker"
+
+// SpanSubmit is the name of the span recorded for each Pool.Submit call.
+// SpanJob is the name of the span recorded for each job a worker executes, a child of the job's submit span.
+const (
+	SpanSubmit = "pool.submit"
+	SpanJob    = "worker.job"
+)
+
+// Span attributes recorded on pool and job spans.
+const (
+	AttrJobID      = attribute.Key("job.id")
+	AttrJobType    = attribute.Key("job.type")
+	AttrJobPlugin  = attribute.Key("job.plugin")
+	AttrJobClass   = attribute.Key("job.class")
+	AttrBatchID    = attribute.Key("job.batch_id")
+	AttrPriority   = attribute.Key("job.priority")
+	AttrWorkerID   = attribute.Key("worker.id")
+	AttrRetries    = attribute.Key("job.retries")
+	AttrDurationMs = attribute.Key("job.duration_ms")
+)
+
+// WithTracerProvider records the pool's submit and job spans with tp instead of the global TracerProvider, and
+// returns the updated Pool. It must be called before Run.
+func (p *Pool) WithTracerProvider(tp trace.TracerProvider) *Pool {
+	p.tracer = tp.Tracer(TracerName)
+	return p
+}
+
+// defaultTracer returns the tracer of the global TracerProvider, which records nothing until one is installed with
+// otel.SetTracerProvider.
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// spanAttributes returns the attributes identifying the job on its spans.
+func (j *Job) spanAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{AttrJobID.String(j.ID), AttrPriority.String(j.Priority.String())}
+	if j.Type != "" {
+		attrs = append(attrs, AttrJobType.String(j.Type))
+	}
+	if j.Plugin != "" {
+		attrs = append(attrs, AttrJobPlugin.String(j.Plugin))
+	}
+	if j.Class != "" {
+		attrs = append(attrs, AttrJobClass.String(j.Class))
+	}
+	if j.BatchID != "" {
+		attrs = append(attrs, AttrBatchID.String(j.BatchID))
+	}
+	return attrs
+}
+
+// endSpan marks span as failed with err, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// JobSnapshot is a point-in-time view of a queued or running job.
+type JobSnapshot struct {
+	JobID       string        `json:"job_id"`
+	Type        string        `json:"job_type,omitempty"`
+	Plugin      string        `json:"plugin,omitempty"`
+	BatchID     string        `json:"batch_id,omitempty"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Priority    string        `json:"priority"`
+	WorkerID    int           `json:"worker_id,omitempty"` // zero while the job is queued
+	SubmittedAt time.Time     `json:"submitted_at"`
+	StartedAt   time.Time     `json:"started_at,omitempty"`
+	Age         time.Duration `json:"age"` // time queued for pending jobs, time running for running jobs
+}
+
+// jobTracker records which jobs are queued and which are running on a worker.
+type jobTracker struct {
+	mu      sync.RWMutex
+	pending map[string]JobSnapshot
+	running map[string]JobSnapshot
+}
+
+// newJobTracker creates an empty jobTracker.
+func newJobTracker() *jobTracker {
+	return &jobTracker{
+		pending: make(map[string]JobSnapshot),
+		running: make(map[string]JobSnapshot),
+	}
+}
+
+// queued records a job as waiting for a worker.
+func (t *jobTracker) queued(job *Job) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[job.ID] = JobSnapshot{
+		JobID:       job.ID,
+		Type:        job.Type,
+		Plugin:      job.Plugin,
+		BatchID:     job.BatchID,
+		RequestID:   job.RequestID,
+		Priority:    job.Priority.String(),
+		SubmittedAt: job.Metrics.SubmittedAt,
+	}
+}
+
+// dropped forgets a job that was recorded as queued but never reached the queue.
+func (t *jobTracker) dropped(jobID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, jobID)
+}
+
+// started moves a job from queued to running on the given worker.
+func (t *jobTracker) started(job *Job, workerID int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snap, ok := t.pending[job.ID]
+	if !ok {
+		snap = JobSnapshot{JobID: job.ID, Type: job.Type, Plugin: job.Plugin, BatchID: job.BatchID,
+			RequestID: job.RequestID, SubmittedAt: job.Metrics.SubmittedAt}
+	}
+	delete(t.pending, job.ID)
+	snap.WorkerID = workerID
+	snap.StartedAt = job.Metrics.StartedAt
+	t.running[job.ID] = snap
+}
+
+// finished forgets a job once it has finished executing.
+func (t *jobTracker) finished(jobID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.running, jobID)
+}
+
+// snapshot returns the running jobs, or the queued jobs when running is false, oldest first.
+// A queued job's age is measured from submission and a running job's age from when it started.
+func (t *jobTracker) snapshot(running bool) []JobSnapshot {
+	if t == nil {
+		return nil
+	}
+	m, since := t.pending, func(s JobSnapshot) time.Time { return s.SubmittedAt }
+	if running {
+		m, since = t.running, func(s JobSnapshot) time.Time { return s.StartedAt }
+	}
+	now := time.Now()
+	t.mu.RLock()
+	snaps := make([]JobSnapshot, 0, len(m))
+	for _, s := range m {
+		s.Age = now.Sub(since(s))
+		snaps = append(snaps, s)
+	}
+	t.mu.RUnlock()
+	slices.SortFunc(snaps, func(a, b JobSnapshot) int {
+		return since(a).Compare(since(b))
+	})
+	return snaps
+}
+
+// Pending returns a snapshot of the jobs submitted to the pool that are waiting for a worker, oldest first.
+func (p *Pool) Pending() []JobSnapshot {
+	return p.tracker.snapshot(false)
+}
+
+// Running returns a snapshot of the jobs currently executing on a worker, longest running first.
+func (p *Pool) Running() []JobSnapshot {
+	return p.tracker.snapshot(true)
+}
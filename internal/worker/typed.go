@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrResultType indicates that a job's result value is not of the type it was read as, e.g. because the pool's
+// ResultLimit replaced an oversized value with a truncated one or a reference to its spill file.
+var ErrResultType = errors.New("job result has unexpected type")
+
+// TypedWorkUnit is the work of a TypedJob, returning a value of type T.
+type TypedWorkUnit[T any] func(ctx context.Context) (T, error)
+
+// TypedJob is a Job whose work returns a T, so its results can be read without type assertions. It is submitted
+// like any other job, as its embedded Job, and the untyped Job API keeps working on it.
+type TypedJob[T any] struct {
+	*Job
+}
+
+// NewTypedJob creates a TypedJob running execute, with a new ID, like NewJob.
+func NewTypedJob[T any](ctx context.Context, execute TypedWorkUnit[T]) *TypedJob[T] {
+	return &TypedJob[T]{Job: NewJob(ctx, func(ctx context.Context) (any, error) {
+		return execute(ctx)
+	})}
+}
+
+// WithCallback registers fn to be called with the job's final result as a TypedResult and returns the updated
+// TypedJob. A value that is not a T is reported in the result's Err, wrapping ErrResultType. Callbacks run on the
+// worker goroutine in registration order and should not block.
+func (j *TypedJob[T]) WithCallback(fn func(*TypedResult[T])) *TypedJob[T] {
+	j.Job.WithCallback(func(res *JobResult) {
+		tr, err := ResultAs[T](res)
+		if err != nil {
+			tr.Err = errors.Join(tr.Err, err)
+		}
+		fn(tr)
+	})
+	return j
+}
+
+// TypedResult is a JobResult whose Value is a T. The embedded JobResult is a copy, so it can be changed without
+// affecting the result delivered to the pool's other consumers.
+type TypedResult[T any] struct {
+	JobResult
+	Value T
+}
+
+// ResultAs returns res with its value as a T, the zero T when the job returned no value, for results read from the
+// pool's results channel or a subscriber. A value that is not a T returns the result, with the zero T, and an error
+// wrapping ErrResultType.
+func ResultAs[T any](res *JobResult) (*TypedResult[T], error) {
+	tr := &TypedResult[T]{JobResult: *res}
+	if res.Value == nil {
+		return tr, nil
+	}
+	v, ok := res.Value.(T)
+	if !ok {
+		return tr, fmt.Errorf("%w: job %s returned %T, not %s", ErrResultType, res.JobID, res.Value,
+			reflect.TypeFor[T]())
+	}
+	tr.Value = v
+	return tr, nil
+}
+
+// BatchValues returns the values of a batch's jobs as Ts, in job order, with the zero T for jobs that failed or
+// returned no value. Values that are not Ts are left zero and reported in the error, wrapping ErrResultType.
+func BatchValues[T any](res *BatchResult) ([]T, error) {
+	values := make([]T, len(res.Values))
+	var errs []error
+	for i, value := range res.Values {
+		if value == nil {
+			continue
+		}
+		v, ok := value.(T)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: job %d returned %T, not %s", ErrResultType, i, value,
+				reflect.TypeFor[T]()))
+			continue
+		}
+		values[i] = v
+	}
+	return values, errors.Join(errs...)
+}
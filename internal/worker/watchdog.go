@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/timestamp"
+	"github.com/hashicorp/go-hclog"
+)
+
+// DefaultWatchdogInterval is how often the watchdog checks running jobs when no interval is configured.
+const DefaultWatchdogInterval = 5 * time.Second
+
+// DefaultWatchdogBuffer is the capacity of the watchdog's events channel.
+const DefaultWatchdogBuffer = 100
+
+// LongJobEvent is emitted once for each job that runs longer than the watchdog's threshold.
+type LongJobEvent struct {
+	Job        JobSnapshot   `json:"job"`
+	Threshold  time.Duration `json:"threshold"`
+	DetectedAt time.Time     `json:"detected_at"`
+}
+
+// Watchdog warns about jobs that have been running on a pool longer than a threshold, helping detect hung plugin
+// calls even when the job has no timeout.
+type Watchdog struct {
+	watchdogLogger hclog.Logger
+	pool           *Pool
+	threshold      time.Duration
+	interval       time.Duration
+	events         chan LongJobEvent
+	reported       map[string]struct{}
+}
+
+// NewWatchdog creates a Watchdog for the pool that reports jobs running longer than threshold, checking every
+// interval. An interval of zero or less uses DefaultWatchdogInterval.
+func NewWatchdog(pool *Pool, threshold time.Duration, interval time.Duration, watchdogLogger hclog.Logger) *Watchdog {
+	if watchdogLogger == nil {
+		watchdogLogger = hclog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultWatchdogInterval
+	}
+	return &Watchdog{
+		watchdogLogger: watchdogLogger,
+		pool:           pool,
+		threshold:      threshold,
+		interval:       interval,
+		events:         make(chan LongJobEvent, DefaultWatchdogBuffer),
+		reported:       make(map[string]struct{}),
+	}
+}
+
+// Events returns a channel of long-running job events. Events are dropped when the channel is full.
+func (wd *Watchdog) Events() <-chan LongJobEvent {
+	return wd.events
+}
+
+// Run checks the pool's running jobs every interval until ctx is canceled, then closes the events channel.
+func (wd *Watchdog) Run(ctx context.Context) {
+	defer close(wd.events)
+	ticker := time.NewTicker(wd.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			wd.check()
+		}
+	}
+}
+
+// check reports each running job that has crossed the threshold since the last check.
+func (wd *Watchdog) check() {
+	running := wd.pool.Running()
+	active := make(map[string]struct{}, len(running))
+	for _, job := range running {
+		active[job.JobID] = struct{}{}
+		if job.Age < wd.threshold {
+			continue
+		}
+		if _, ok := wd.reported[job.JobID]; ok {
+			continue
+		}
+		wd.reported[job.JobID] = struct{}{}
+		wd.watchdogLogger.Warn("Job running longer than threshold",
+			logger.KeyJobID, job.JobID,
+			logger.KeyWorkerID, job.WorkerID,
+			logger.KeyJobPlugin, job.Plugin,
+			logger.KeyJobType, job.Type,
+			logger.KeyRunningFor, job.Age.Round(time.Millisecond),
+			logger.KeyThreshold, wd.threshold)
+		select {
+		case wd.events <- LongJobEvent{Job: job, Threshold: wd.threshold, DetectedAt: timestamp.Now()}:
+		default:
+			wd.watchdogLogger.Debug("Watchdog events channel full, event dropped", logger.KeyJobID, job.JobID)
+		}
+	}
+	// forget jobs that have finished
+	for id := range wd.reported {
+		if _, ok := active[id]; !ok {
+			delete(wd.reported, id)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestWatchdogReportsLongJobOnce checks that a job running past the threshold is reported once, however many checks
+// see it, and that the events channel is closed when Run returns.
+func TestWatchdogReportsLongJobOnce(t *testing.T) {
+	pool := NewPool(1, false, 1, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	release := make(chan struct{})
+	job := NewJob(context.Background(), func(context.Context) (any, error) {
+		<-release
+		return nil, nil
+	}).WithType("hung")
+	if err := pool.Submit(job); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	wd := NewWatchdog(pool, 20*time.Millisecond, 5*time.Millisecond, hclog.NewNullLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	go wd.Run(ctx)
+	select {
+	case ev := <-wd.Events():
+		if ev.Job.JobID != job.ID || ev.Job.Type != "hung" || ev.Threshold != 20*time.Millisecond {
+			t.Errorf("got event %+v for job %s", ev, job.ID)
+		}
+		if ev.Job.Age < ev.Threshold {
+			t.Errorf("job reported after %s, before the threshold", ev.Job.Age)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("long job not reported")
+	}
+	// several more checks see the job still running
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	<-pool.Results()
+	cancel()
+	for ev := range wd.Events() {
+		t.Errorf("job %s reported again", ev.Job.JobID)
+	}
+}
+
+// TestWatchdogIgnoresShortJobs checks that jobs finishing within the threshold are not reported.
+func TestWatchdogIgnoresShortJobs(t *testing.T) {
+	pool := NewPool(2, false, 4, hclog.NewNullLogger())
+	pool.Run()
+	defer pool.Shutdown()
+	wd := NewWatchdog(pool, time.Second, 5*time.Millisecond, hclog.NewNullLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	go wd.Run(ctx)
+	for range 4 {
+		if err := pool.Submit(NewJob(context.Background(), func(context.Context) (any, error) {
+			time.Sleep(10 * time.Millisecond)
+			return nil, nil
+		})); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	for range 4 {
+		<-pool.Results()
+	}
+	cancel()
+	for ev := range wd.Events() {
+		t.Errorf("short job %s reported", ev.Job.JobID)
+	}
+}
@@ -1,23 +1,42 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
+	"runtime/pprof"
 	"time"
 
 	"github.com/bmj2728/PlugsConc/internal/logger"
 	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrJobTimedOut indicates that a job's deadline passed before its WorkUnit returned, or before an attempt started.
+// The worker abandons the job and moves on; the WorkUnit keeps running in the background until it observes its
+// canceled context.
+var ErrJobTimedOut = errors.New("job timed out")
+
 // Worker represents a worker that processes jobs from the jobs channel and sends results
 // to the results channel.
 type Worker struct {
 	workerLogger hclog.Logger
 	id           int
-	jobs         <-chan *Job
+	queues       []<-chan *Job // job queues, most urgent first; a queue is set to nil once closed and drained
 	results      chan<- *JobResult
 	metrics      chan<- *MetricResult
 	quit         chan struct{}
+	retire       <-chan struct{}    // closed when the pool shrinks and this worker should exit, nil when never retired
+	subscribers  []func(*JobResult) // receive every result instead of the results channel, nil when none are registered
+	chaos        *Chaos             // optional fault injection, nil when chaos mode is disabled
+	middleware   Middleware         // optional middleware chain, nil when none is registered
+	tracker      *jobTracker        // records running jobs for the owning pool, nil when not tracked
+	classes      *jobClasses        // admits the jobs of limited classes, nil when no class is limited
+	terminated   context.Context    // canceled when the owning pool is terminated, nil when not owned by a pool
+	resultLimit  *ResultLimit       // optional bound on result size, nil when results are unbounded
+	tracer       trace.Tracer       // records a span for every job
 }
 
 // NewWorker creates and initializes a new Worker with a unique ID, a channel of jobs to process,
@@ -33,13 +52,76 @@ func NewWorker(id int, jobs <-chan *Job,
 	return &Worker{
 		workerLogger: workerLogger,
 		id:           id,
-		jobs:         jobs,
+		queues:       []<-chan *Job{jobs},
 		results:      results,
 		quit:         quit,
 		metrics:      metrics,
+		tracer:       defaultTracer(),
 	}
 }
 
+// WithChaos enables fault injection for jobs executed by the worker and returns the updated Worker.
+func (w *Worker) WithChaos(chaos *Chaos) *Worker {
+	w.chaos = chaos
+	return w
+}
+
+// WithMiddleware wraps every job executed by the worker with the middleware and returns the updated Worker.
+func (w *Worker) WithMiddleware(middleware Middleware) *Worker {
+	w.middleware = middleware
+	return w
+}
+
+// WithResultLimit enforces limit on the results of the jobs the worker runs and returns the updated Worker.
+func (w *Worker) WithResultLimit(limit *ResultLimit) *Worker {
+	w.resultLimit = limit
+	return w
+}
+
+// withTracker records the jobs the worker runs in the owning pool's tracker and returns the updated Worker.
+func (w *Worker) withTracker(tracker *jobTracker) *Worker {
+	w.tracker = tracker
+	return w
+}
+
+// withClasses holds jobs back while their class is at its limit and returns the updated Worker.
+func (w *Worker) withClasses(classes *jobClasses) *Worker {
+	w.classes = classes
+	return w
+}
+
+// withTracer records the spans of the jobs the worker runs with tracer and returns the updated Worker.
+func (w *Worker) withTracer(tracer trace.Tracer) *Worker {
+	w.tracer = tracer
+	return w
+}
+
+// withTermination cancels the jobs the worker runs when ctx ends and returns the updated Worker.
+func (w *Worker) withTermination(ctx context.Context) *Worker {
+	w.terminated = ctx
+	return w
+}
+
+// withQueues makes the worker take jobs from up to priorityLevels queues, always preferring a queued job from an
+// earlier queue, and returns the updated Worker.
+func (w *Worker) withQueues(queues ...<-chan *Job) *Worker {
+	w.queues = queues
+	return w
+}
+
+// withRetire stops the worker, once its current job is done, when retire is closed and returns the updated Worker.
+func (w *Worker) withRetire(retire <-chan struct{}) *Worker {
+	w.retire = retire
+	return w
+}
+
+// withSubscribers delivers every result to subscribers instead of the results channel when any are given, and
+// returns the updated Worker.
+func (w *Worker) withSubscribers(subscribers []func(*JobResult)) *Worker {
+	w.subscribers = subscribers
+	return w
+}
+
 // Start begins the worker's execution loop, processing jobs from the channel and sending results
 // to the results channel.
 func (w *Worker) Start() {
@@ -47,81 +129,74 @@ func (w *Worker) Start() {
 	defer w.workerLogger.Debug("Worker stopped")
 
 	for {
+		// stop promptly once quit is closed, even if jobs are still queued, or once the worker is retired
 		select {
-		case job, ok := <-w.jobs:
-			if !ok {
-				return
-			}
-			// annotate job context
-			job.Ctx = WithWorkerID(job.Ctx, w.id)
-			job.SetStartedAt()
-
-			// ensure cancellation and panic safety
-			resultVal, err := func() (val any, err error) {
-				// choose which cancel func to call on exit
-				if job.CancelWithCause != nil {
-					// capture the final err as the cause
-					defer func() { job.CancelWithCause(err) }()
-				} else if job.Cancel != nil {
-					defer job.Cancel()
-				}
+		case <-w.quit:
+			return
+		case <-w.retire:
+			w.workerLogger.Debug("Worker retired")
+			return
+		default:
+		}
+		job, ok := w.next()
+		if !ok {
+			return
+		}
+		// a job whose class is at its limit is handed back to a worker once the class has room
+		if !w.classes.admit(job) {
+			continue
+		}
+		// cancel the job if the pool is terminated while it runs
+		if w.terminated != nil {
+			job.WithParent(w.terminated)
+		}
+		// annotate job context
+		job.Ctx = WithWorkerID(job.Ctx, w.id)
+		job.SetStartedAt()
+		w.tracker.started(job, w.id)
+		// the job span is in the context the WorkUnit receives, so plugin calls made with it join the trace
+		var span trace.Span
+		job.Ctx, span = w.tracer.Start(job.Ctx, SpanJob, trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(append(job.spanAttributes(), AttrWorkerID.Int(w.id))...))
 
-				// panic safety: convert panics to errors
-				defer func() {
-					if r := recover(); r != nil {
-						err = fmt.Errorf("panic: %v\nstack: %s", r, string(debug.Stack()))
-					}
-				}()
-
-				// retry loop
-				delay := time.Duration(job.RetryDelay) * time.Millisecond
-				for attempts := 0; ; attempts++ {
-					job.Metrics.Attempts = attempts
-
-					// if the job context is canceled, return immediately
-					//  the default case is to continue the loop
-					select {
-					case <-job.Ctx.Done():
-						job.SetFinishedAt()
-						return nil, job.Ctx.Err()
-					default:
-					}
-
-					// execute the job
-					v, e := job.Execute(job.Ctx)
-					// if the job succeeded, or we've reached the max retries, return the result/error
-					//  otherwise, retry the job with a delay between retries'
-					if e == nil || attempts >= job.MaxRetries {
-						job.SetFinishedAt()
-						return v, e
-					}
-
-					// log retry
-					w.workerLogger.
-						With(logger.KeyJobID, job.ID).
-						With(logger.KeyRetryCount, attempts+1).
-						Warn("Retrying job")
-
-					// wait for the retry delay before continuing the loop
-					if delay > 0 {
-						t := time.NewTimer(delay)
-						// if the job context is canceled, stop the timer and return immediately,
-						//  otherwise, wait for the timer to expire
-						select {
-						case <-job.Ctx.Done():
-							t.Stop()
-							job.SetFinishedAt()
-							return nil, job.Ctx.Err()
-						case <-t.C:
-						}
-					}
-				}
-			}()
+		// ensure cancellation and panic safety
+		// the job runs under pprof labels so CPU profiles attribute time to the job and its plugin
+		var resultVal any
+		var err error
+		pprof.Do(job.Ctx, job.ProfileLabels(), func(ctx context.Context) {
+			job.Ctx = ctx
+			resultVal, err = w.execute(job)
+		})
+		w.tracker.finished(job.ID)
+		w.classes.release(job)
+
+		resultVal, overflow, err := w.resultLimit.apply(job.ID, resultVal, err)
+		if overflow != nil {
+			w.workerLogger.Warn("Job result exceeded size limit", logger.KeyWorkerID, w.id, logger.KeyJobID, job.ID,
+				logger.KeyRequestID, job.RequestID, "size", overflow.Size, "policy", overflow.Policy)
+		}
+		span.SetAttributes(AttrRetries.Int(job.Metrics.Attempts), AttrDurationMs.Int64(job.Metrics.Duration.Milliseconds()))
+		endSpan(span, err)
+		result := NewJobResult(job, w.id, resultVal, err)
+		result.Overflow = overflow
+		if job.onComplete != nil {
+			job.onComplete(result)
+		}
+		for _, fn := range job.callbacks {
+			w.callback(fn, result)
+		}
 
+		metric := &MetricResult{isSuccess: err == nil, timedOut: errors.Is(err, ErrJobTimedOut)}
+		if len(w.subscribers) > 0 {
+			for _, fn := range w.subscribers {
+				w.callback(fn, result)
+			}
+			w.metrics <- metric
+		} else {
 			// Safely send the result or quit if the pool is terminated.
 			select {
-			case w.results <- NewJobResult(job, w.id, resultVal, err):
-				w.metrics <- NewMetricResult(err == nil)
+			case w.results <- result:
+				w.metrics <- metric
 				// Result sent successfully.
 			case <-w.quit:
 				// Pool was terminated while trying to send the result.
@@ -130,15 +205,217 @@ func (w *Worker) Start() {
 				w.workerLogger.Warn("Worker terminated before sending result")
 				return
 			}
+		}
+
+		attrs := []any{logger.KeyWorkerID, w.id, logger.KeyJobID, job.ID, logger.KeyRequestID, job.RequestID}
+		if err != nil {
+			w.workerLogger.With(attrs...).Error("Job failed", "error", err)
+		} else {
+			w.workerLogger.With(attrs...).Debug("Job completed")
+		}
+	}
+}
+
+// callback calls fn with result, recovering a panic so that a faulty result consumer cannot kill the worker.
+func (w *Worker) callback(fn func(*JobResult), result *JobResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.workerLogger.Error("Result callback panicked", logger.KeyWorkerID, w.id, logger.KeyJobID, result.JobID,
+				"panic", r)
+		}
+	}()
+	fn(result)
+}
 
-			attrs := []any{logger.KeyWorkerID, w.id, logger.KeyJobID, job.ID}
-			if err != nil {
-				w.workerLogger.With(attrs...).Error("Job failed", "error", err)
-			} else {
-				w.workerLogger.With(attrs...).Debug("Job completed")
+// next returns the next job, taking a held back job whose class has room, then the most urgent queued job, first. It
+// blocks until a job is queued and reports false once every queue is closed and drained and no job is held back, the
+// pool is terminated, or the worker is retired.
+func (w *Worker) next() (*Job, bool) {
+	ready := w.classes.readyJobs()
+	for {
+		select {
+		case job := <-ready:
+			return job, true
+		default:
+		}
+		open := false
+		for i, q := range w.queues {
+			if q == nil {
+				continue
+			}
+			select {
+			case job, ok := <-q:
+				if ok {
+					return job, true
+				}
+				w.queues[i] = nil
+				continue
+			default:
+			}
+			open = true
+		}
+		if !open {
+			if !w.classes.pending() {
+				return nil, false
+			}
+			// wait for the jobs still held back by their class
+			timer := time.NewTimer(classPollInterval)
+			select {
+			case job := <-ready:
+				timer.Stop()
+				return job, true
+			case <-timer.C:
+				continue
+			case <-w.quit:
+				timer.Stop()
+				return nil, false
+			case <-w.retire:
+				timer.Stop()
+				w.workerLogger.Debug("Worker retired")
+				return nil, false
 			}
+		}
+		// nothing is queued; wait on every queue and prefer the most urgent again once something arrives
+		var queues [priorityLevels]<-chan *Job
+		copy(queues[:], w.queues)
+		var job *Job
+		var ok bool
+		var from int
+		select {
+		case job = <-ready:
+			return job, true
+		case job, ok = <-queues[0]:
+		case job, ok = <-queues[1]:
+			from = 1
+		case job, ok = <-queues[2]:
+			from = 2
 		case <-w.quit:
-			return
+			return nil, false
+		case <-w.retire:
+			w.workerLogger.Debug("Worker retired")
+			return nil, false
 		}
+		if ok {
+			return job, true
+		}
+		w.queues[from] = nil
+	}
+}
+
+// execute runs the job's WorkUnit with retries, cancellation handling, and panic safety,
+// returning the final value and error.
+func (w *Worker) execute(job *Job) (val any, err error) {
+	// choose which cancel func to call on exit
+	if job.CancelWithCause != nil {
+		// capture the final err as the cause
+		defer func() { job.CancelWithCause(err) }()
+	} else if job.Cancel != nil {
+		defer job.Cancel()
+	}
+
+	// wrap the work unit with fault injection when chaos mode is enabled
+	execute := w.chaos.Wrap(job.ID, job.Execute)
+	// registered middleware wraps the whole execution, including any injected faults
+	if w.middleware != nil {
+		execute = w.middleware(execute)
+	}
+
+	// retry loop
+	delay := time.Duration(job.RetryDelay) * time.Millisecond
+	for attempts := 0; ; attempts++ {
+		job.Metrics.Attempts = attempts
+
+		// if the job context is canceled, return immediately
+		//  the default case is to continue the loop
+		select {
+		case <-job.Ctx.Done():
+			job.SetFinishedAt()
+			return nil, ctxErr(job.Ctx)
+		default:
+		}
+
+		// execute the job, abandoning it if its context ends first
+		v, e := w.attempt(job, execute)
+		if errors.Is(e, ErrJobTimedOut) {
+			job.SetFinishedAt()
+			return nil, e
+		}
+		// if the job succeeded, or we've reached the max retries, return the result/error
+		//  otherwise, retry the job with a delay between retries'
+		if e == nil || attempts >= job.MaxRetries {
+			job.SetFinishedAt()
+			return v, e
+		}
+
+		// log retry
+		trace.SpanFromContext(job.Ctx).AddEvent("retry", trace.WithAttributes(AttrRetries.Int(attempts+1),
+			attribute.String("error", e.Error())))
+		w.workerLogger.
+			With(logger.KeyJobID, job.ID, logger.KeyRequestID, job.RequestID).
+			With(logger.KeyRetryCount, attempts+1).
+			Warn("Retrying job")
+
+		// wait for the retry delay before continuing the loop
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			// if the job context is canceled, stop the timer and return immediately,
+			//  otherwise, wait for the timer to expire
+			select {
+			case <-job.Ctx.Done():
+				t.Stop()
+				job.SetFinishedAt()
+				return nil, ctxErr(job.Ctx)
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// attempt runs one attempt of the job's WorkUnit in its own goroutine, converting panics to errors, and waits for it
+// or for the job context to end. A WorkUnit that blocks past the job's deadline is abandoned and ErrJobTimedOut
+// returned, so a stuck plugin call cannot stall the worker; one still running when the job is canceled is abandoned
+// with the context's error.
+func (w *Worker) attempt(job *Job, execute WorkUnit) (any, error) {
+	type outcome struct {
+		val any
+		err error
+	}
+	// the abandoned goroutine must not read job.Ctx, which the worker rewrites once the job finishes
+	ctx := job.Ctx
+	done := make(chan outcome, 1)
+	go func() {
+		var o outcome
+		// panic safety: convert panics to errors
+		defer func() {
+			if r := recover(); r != nil {
+				o.err = fmt.Errorf("%w: %v\nstack: %s", ErrJobPanicked, r, string(debug.Stack()))
+			}
+			done <- o
+		}()
+		o.val, o.err = execute(ctx)
+	}()
+
+	select {
+	case o := <-done:
+		return o.val, o.err
+	case <-ctx.Done():
+	}
+	// prefer a result that raced the deadline
+	select {
+	case o := <-done:
+		return o.val, o.err
+	default:
+	}
+	w.workerLogger.Warn("Abandoning job still running after its context ended", logger.KeyWorkerID, w.id,
+		logger.KeyJobID, job.ID, logger.KeyRequestID, job.RequestID, logger.KeyError, context.Cause(ctx))
+	return nil, ctxErr(ctx)
+}
+
+// ctxErr returns the error of the ended job context ctx, wrapping a passed deadline in ErrJobTimedOut, so a job that
+// times out while queued or between retries counts as timed out like one abandoned mid-attempt.
+func ctxErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrJobTimedOut, context.Cause(ctx))
 	}
+	return ctx.Err()
 }
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestJobTimedOutBeforeAttempt checks that a job whose deadline passes while it is queued or waiting to be retried
+// fails with ErrJobTimedOut and is counted as timed out, like one abandoned mid-attempt.
+func TestJobTimedOutBeforeAttempt(t *testing.T) {
+	tests := map[string]func() *Job{
+		"queued": func() *Job {
+			return NewJob(context.Background(), func(context.Context) (any, error) {
+				return nil, nil
+			}).WithDeadline(time.Now().Add(-time.Second))
+		},
+		"between retries": func() *Job {
+			return NewJob(context.Background(), func(context.Context) (any, error) {
+				return nil, errors.New("try again")
+			}).WithRetry(3, 200).WithTimeout(50 * time.Millisecond)
+		},
+	}
+	for name, newJob := range tests {
+		t.Run(name, func(t *testing.T) {
+			pool := NewPool(1, false, 1, hclog.NewNullLogger())
+			pool.Run()
+			defer pool.Shutdown()
+			if err := pool.Submit(newJob()); err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			res := <-pool.Results()
+			if !errors.Is(res.Err, ErrJobTimedOut) {
+				t.Errorf("got error %v, want ErrJobTimedOut", res.Err)
+			}
+			// the metrics are collected asynchronously, after the result is sent
+			deadline := time.Now().Add(time.Second)
+			for pool.Metrics().TimedOutJobs() == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if got := pool.Metrics().TimedOutJobs(); got != 1 {
+				t.Errorf("got %d timed out jobs, want 1", got)
+			}
+		})
+	}
+}
@@ -1,70 +1,168 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"os/signal"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/bmj2728/PlugsConc/internal/checksum"
+	"github.com/bmj2728/PlugsConc/internal/agent"
+	"github.com/bmj2728/PlugsConc/internal/certify"
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/internal/election"
+	"github.com/bmj2728/PlugsConc/internal/history"
+	"github.com/bmj2728/PlugsConc/internal/jobtypes"
 	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/management"
+	"github.com/bmj2728/PlugsConc/internal/mq"
 	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/sbom"
+	"github.com/bmj2728/PlugsConc/internal/sla"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/bmj2728/PlugsConc/internal/tracing"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/bmj2728/PlugsConc/plugshost"
 	"github.com/bmj2728/PlugsConc/shared/pkg/animal"
-	"github.com/fsnotify/fsnotify"
+	"github.com/bmj2728/PlugsConc/shared/pkg/authprovider"
+	"github.com/bmj2728/PlugsConc/shared/pkg/callctx"
+
+	adminv1 "github.com/bmj2728/PlugsConc/shared/protogen/admin/v1"
 
 	"github.com/hashicorp/go-hclog"
-	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
 	ConfigDir  = "."
 	ConfigFile = "config.yaml"
+	// AgentTokenEnvVar holds the bearer token an agent presents to the primary host.
+	AgentTokenEnvVar = "PLUGSCONC_AGENT_TOKEN"
 )
 
-var catHandshake = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
-	MagicCookieKey:   "CAT_PLUGIN",
-	MagicCookieValue: "lFLmoCE3ckw6erJxYxcRd6keedUodVMctD3XOGj9bLMYsFZi1Qh0vKEJftppo5ek",
-}
-
-var dogHandshake = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
-	MagicCookieKey:   "DOG_PLUGIN",
-	MagicCookieValue: "2ggRd5S9bhHottawB6eXwghiOAhekGORmOfIczh5b1D3AYlmrRWIXdbqwDHDJmjq",
+// pluginCallAllowlist is the set of host context values forwarded to plugins as gRPC call metadata.
+var pluginCallAllowlist = callctx.Allowlist{
+	{Key: callctx.MetadataJobID, Extract: worker.LookupJobID},
+	callctx.TenantField,
+	callctx.TraceIDField,
+	callctx.RequestIDField,
+	callctx.TraceParentField,
+	callctx.TraceStateField,
 }
 
 func main() {
+	// logcheck mode builds the configured logging pipeline, verifies every sink, and exits
+	if len(os.Args) > 1 && os.Args[1] == "logcheck" {
+		os.Exit(runLogCheck(loadConfig()))
+	}
+	// plugins certify <dir> runs the certification suite against a candidate plugin and exits
+	if len(os.Args) > 3 && os.Args[1] == "plugins" && os.Args[2] == "certify" {
+		os.Exit(runCertify(os.Args[3]))
+	}
+	// plugins compat [flags] lists the plugin and host version combinations that have run successfully and exits
+	if len(os.Args) > 2 && os.Args[1] == "plugins" && os.Args[2] == "compat" {
+		os.Exit(runPluginsCompat(loadConfig(), os.Args[3:]))
+	}
+	// plugins verify [-deep] [name...] checks plugin launch details and checksums, dry-run launching them with -deep,
+	// and exits
+	if len(os.Args) > 2 && os.Args[1] == "plugins" && os.Args[2] == "verify" {
+		os.Exit(runPluginsVerify(loadConfig(), os.Args[3:]))
+	}
+	// plugins sbom [-format cyclonedx|spdx] [-o file] writes a bill of materials of the host and installed plugins and
+	// exits
+	if len(os.Args) > 2 && os.Args[1] == "plugins" && os.Args[2] == "sbom" {
+		os.Exit(runPluginsSBOM(loadConfig(), os.Args[3:]))
+	}
+	// plugins exec <name> <method> [json-args] launches a plugin, calls one of its methods, prints the results, and
+	// exits
+	if len(os.Args) > 2 && os.Args[1] == "plugins" && os.Args[2] == "exec" {
+		os.Exit(runPluginsExec(loadConfig(), os.Args[3:]))
+	}
+	// plugins describe <name> launches a gRPC plugin, prints the services and methods it serves, and exits
+	if len(os.Args) > 2 && os.Args[1] == "plugins" && os.Args[2] == "describe" {
+		os.Exit(runPluginsDescribe(loadConfig(), os.Args[3:]))
+	}
+	// jobs history [flags] queries the persistent job history store and exits
+	if len(os.Args) > 2 && os.Args[1] == "jobs" && os.Args[2] == "history" {
+		os.Exit(runJobsHistory(loadConfig(), os.Args[3:]))
+	}
+	// storage migrate [flags] applies, previews, or rolls back persisted schema migrations and exits
+	if len(os.Args) > 2 && os.Args[1] == "storage" && os.Args[2] == "migrate" {
+		os.Exit(runStorageMigrate(loadConfig(), os.Args[3:]))
+	}
+	// api catalog prints the machine-readable catalog of the services, plugin types, and capabilities this host
+	// supports and exits
+	if len(os.Args) > 2 && os.Args[1] == "api" && os.Args[2] == "catalog" {
+		os.Exit(runAPICatalog(loadConfig()))
+	}
+	// admin [flags] <command> [name] calls the admin API of a running host and exits
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(loadConfig(), os.Args[2:]))
+	}
+	// agent <host-url> pulls jobs from the primary host's dispatcher and runs them on a local pool
+	if len(os.Args) > 2 && os.Args[1] == "agent" {
+		os.Exit(runAgent(os.Args[2]))
+	}
+
 	/*
-		Logger Setup Example w/ config
+		Logging
 	*/
 
-	// Synchronous logger setup:
-	// multilogger is the primary logger for the application.
-	// It is a synchronous intercept logger that writes to console and can be configured to write to
-	// other io.Writers using sinks.
-	multiLogger := logger.MultiLogger("app-name", hclog.Info, hclog.ForceColor, true, false)
-	// Sets the default logger to the multilogger.
-	hclog.SetDefault(multiLogger)
-	//// Read in the configuration for the file logger.
-	//logRotator := logger.NewRotator(filepath.Join("./logs", "app.log"),
-	//	2,
-	//	25,
-	//	90,
-	//	true)
-	//// Asynchronous Logger Setup:
-	//// asyncI is an Intercept logger, the choice of io.Writer is up to the user.
-	//// This can take additional sinks, similar to the synchronous logger.
-	//asyncI := logger.AsyncInterceptLogger("async-app-logs", hclog.Info, logRotator, hclog.ColorOff, false, true)
-	//// This initializes the queue and worker for writing async logs
-	//q := logger.LogQueue(conf, asyncI)
-	//// This creates a specialized sink that gets attached to the synchronous logger and is
-	//// responsible for shipping logs to the queue.
-	//aLogs := logger.AsyncSink("async-sink", q, hclog.Info, hclog.ColorOff, true, true)
-	//// This registers the sink with the synchronous intercept logger.
-	//multiLogger.RegisterSink(aLogs)
-	//// We now have a multi-logger configures to write synchronously to the console and asynchronously to a file.
-	//multiLogger.Info("File logger initialized")
+	// the logging pipeline is assembled from the Logging config: the console logger, the file sinks, written
+	// synchronously or through the persistent log queue, the plugin files, and the logsink plugins attached once the
+	// plugin host has started
+	conf := loadConfig()
+	logs, err := logger.Build(conf)
+	if err != nil {
+		log.Printf("failed to build logging: %v", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := logs.Shutdown(); err != nil {
+			log.Printf("failed to shut down logging: %v", err)
+		}
+	}()
+	multiLogger := logs.Logger
+	// Sets the default logger to the multilogger, attaching stack traces to the configured levels.
+	hclog.SetDefault(logger.WithStackTraces(multiLogger, conf.Logging.StackDepth,
+		logger.ParseLevels(conf.Logging.StackTraces)...))
+	// slog records, from libraries and the standard log package alike, are written through the same pipeline
+	slog.SetDefault(slog.New(logger.HclogHandler(hclog.Default())))
+	// the console and sink levels can be changed at runtime with SIGHUP, which re-reads the config, or through the
+	// admin API
+	levels := logs.Levels
+	go reloadLevelsOnHangup(levels, multiLogger.Named("levels"))
+	// Fingerprint warn and error records so the most frequent errors can be summarized
+	errorFingerprints := logger.NewErrorFingerprinter(hclog.Warn)
+	multiLogger.RegisterSink(errorFingerprints)
+	if interval := conf.Logging.ErrorSummaryInterval; interval > 0 {
+		go errorFingerprints.RunSummaries(context.Background(), time.Duration(interval)*time.Millisecond,
+			logger.DefaultTopErrors, multiLogger.Named("errors"))
+	}
+	// trace job submission and execution, and the plugin calls jobs make, when enabled
+	defer setupTracing(conf, conf.General.Name, multiLogger.Named("tracing"))()
+	// keep the most recent records so incident captures include the logs leading up to them
+	var recentLogs *logger.RecentLogs
+	if conf.Incident.Enabled {
+		recentLogs = logger.NewRecentLogs(conf.Incident.RecentLogs, hclog.LevelFromString(conf.Logging.Level))
+		multiLogger.RegisterSink(recentLogs)
+	}
 
 	/*
 		Example General Worker Pool
@@ -90,247 +188,1152 @@ func main() {
 	//}
 
 	/*
-		Example File Watcher
+		Leader Election
 	*/
 
-	// Initialize plugin filewatcher
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		multiLogger.Error("Failed to create watcher", logger.KeyError, err)
-		multiLogger.Warn("Watching for changes will not work")
-	}
-	defer func(watcher *fsnotify.Watcher) {
-		err := watcher.Close()
+	// HA host pairs share the plugins directory and queue; only the leader launches plugins and processes the watcher
+	if conf.HA.Enabled {
+		hostname, err := os.Hostname()
 		if err != nil {
-			multiLogger.Error("Failed to close watcher", logger.KeyError, err)
-		}
-	}(watcher)
-
-	// Start generic watcher
-	// sig
-	go func(watcher *fsnotify.Watcher) {
-		for {
-			select {
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
-				log.Println("event:", event)
-				if event.Has(fsnotify.Write) {
-					multiLogger.Info("file changed:", "file", event.Name)
-				}
-				if event.Has(fsnotify.Create) {
-					multiLogger.Info("file created:", "file", event.Name)
-				}
-				if event.Has(fsnotify.Remove) {
-					multiLogger.Info("file removed:", "file", event.Name)
-				}
-				if event.Has(fsnotify.Rename) {
-					multiLogger.Info("file renamed:", "file", event.Name)
-				}
-				if event.Has(fsnotify.Chmod) {
-					multiLogger.Info("file mode changed:", "file", event.Name)
-				}
-			case err, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				multiLogger.Error("filewatcher error: ", logger.KeyError, err)
-			}
+			hostname = "host"
+		}
+		elector := election.NewElector(conf.HA.LockFile,
+			fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+			time.Duration(conf.HA.RetryInterval)*time.Millisecond,
+			multiLogger.Named("election"))
+		if err := elector.Campaign(context.Background()); err != nil {
+			multiLogger.Error("Failed to acquire leadership", logger.KeyError, err)
+			os.Exit(1)
 		}
-	}(watcher)
+		defer func() {
+			if err := elector.Resign(); err != nil {
+				multiLogger.Error("Failed to resign leadership", logger.KeyError, err)
+			}
+		}()
+	}
 
 	/*
-		Plugin Loading
+		Storage
 	*/
 
-	// Get the configured plugins directory
-	pluginsDir := "./plugins"
-	multiLogger.Info("Plugins directory", "dir", pluginsDir)
-	// Add the plugins directory to the file watcher using the absolute path
-	pAbs, err := filepath.Abs(pluginsDir)
+	// the leader brings the persisted schemas up to date before anything reads them
+	backend, err := openStorage(conf, multiLogger.Named("storage"))
 	if err != nil {
-		multiLogger.Error("Failed to get absolute path for plugins", logger.KeyError, err)
+		multiLogger.Error("Failed to open storage", logger.KeyError, err)
+		os.Exit(1)
 	}
-	err = watcher.Add(pAbs)
+	defer func() { _ = backend.Close() }()
+
+	/*
+		Plugin Host
+	*/
+
+	// the plugin host loads the plugins directory and owns each plugin's lifecycle: checksum verification, launch,
+	// health, restarts, and hot reload
+	host, err := plugshost.New(conf)
 	if err != nil {
-		multiLogger.Error("Failed to add plugins directory", logger.KeyError, err)
+		multiLogger.Error("Failed to create plugin host", logger.KeyError, err)
+		os.Exit(1)
+	}
+	host.WithGRPCDialOptions(pluginCallAllowlist.DialOptions()...)
+	// give every plugin a rotating log file of its own; closed with the logging after the host has shut down
+	if logs.PluginFiles != nil {
+		host.WithPluginLogFiles(logs.PluginFiles)
+	}
+	if _, err := host.WithStorage(backend); err != nil {
+		multiLogger.Error("Failed to open plugin compatibility matrix", logger.KeyError, err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := host.Shutdown(); err != nil {
+			multiLogger.Error("Failed to shut down plugin host", logger.KeyError, err)
+		}
+	}()
+	if err := host.Start(); err != nil {
+		multiLogger.Error("Failed to start plugins", logger.KeyError, err)
+		os.Exit(1)
+	}
+	// ship records to the configured logsink plugins; stopped, shipping what is buffered, before the host shuts down
+	stopSinks, err := logs.AttachPluginSinks(context.Background(), host.Dispense)
+	if err != nil {
+		multiLogger.Error("Failed to attach log sink plugins", logger.KeyError, err)
+	}
+	defer stopSinks()
+	// register the host's job types before its pool can rebuild persisted jobs of them; the dispatcher and job source
+	// plugins only accept jobs of registered types
+	jobtypes.RegisterVerifyChecksums(host.Manager(), func() []string { return pluginNames(host) },
+		multiLogger.Named("verify"))
+	jobtypes.RegisterHTTPCheck(nil)
+	// the host pool runs the jobs of this host; its metrics are served by the admin API and REST endpoints below
+	hostPool, closeHostQueue, err := newWorkerPool(conf, "host", host.Manager().KillRandom, multiLogger.Named("pool"))
+	if err != nil {
+		multiLogger.Error("Failed to open persistent job queue", logger.KeyError, err)
+		os.Exit(1)
+	}
+	// size the host pool to the latency and error rate of its jobs when configured
+	adaptive, err := newAdaptiveConcurrency(conf, hostPool, multiLogger.Named("adaptive"))
+	if err != nil {
+		multiLogger.Error("Failed to configure adaptive concurrency", logger.KeyError, err)
+		os.Exit(1)
+	}
+	// sample memory and GC pressure, throttling the host pool above the soft limit when configured
+	memory, err := newMemoryMonitor(conf, hostPool, multiLogger.Named("memory"))
+	if err != nil {
+		multiLogger.Error("Failed to configure memory monitor", logger.KeyError, err)
+		os.Exit(1)
+	}
+	// warn about host jobs running longer than the watchdog threshold, capturing incidents for them when configured
+	watchdog := newWatchdog(conf, hostPool, multiLogger.Named("watchdog"))
+	// record every job the host runs in the job history, pruned to the configured retention
+	jobs, err := newJobHistory(conf, backend, multiLogger.Named("history"))
+	if err != nil {
+		multiLogger.Error("Failed to open job history", logger.KeyError, err)
+		os.Exit(1)
+	}
+	hostPool.OnResult(func(res *worker.JobResult) {
+		if err := jobs.Record(res); err != nil {
+			multiLogger.Error("Failed to record job result", logger.KeyJobID, res.JobID, logger.KeyError, err)
+		}
+	})
+	hostPool.Run()
+	defer func() {
+		hostPool.Shutdown()
+		closeHostQueue()
+	}()
+	// the host pool's monitors are stopped before it shuts down
+	monitorCtx, stopMonitors := context.WithCancel(context.Background())
+	defer stopMonitors()
+	if adaptive != nil {
+		go adaptive.Run(monitorCtx)
+	}
+	if memory != nil {
+		go memory.Run(monitorCtx)
+	}
+	if watchdog != nil {
+		go watchdog.Run(monitorCtx)
+	}
+	// feed the host pool from every jobsource plugin, within the jobs capability of its manifest
+	stopSources, err := management.AttachJobSources(context.Background(), management.JobSourceOptions{
+		Catalog:  host.Catalog(),
+		Dispense: host.Dispense,
+		Pool:     hostPool,
+		Logger:   multiLogger.Named("jobsource"),
+	})
+	if err != nil {
+		multiLogger.Error("Failed to attach job source plugins", logger.KeyError, err)
+	}
+	defer stopSources()
+	// run the configured schedules, such as checksum re-verification, on the host pool until it shuts down
+	scheduler, err := newScheduler(conf, hostPool, multiLogger.Named("scheduler"))
+	if err != nil {
+		multiLogger.Error("Failed to schedule jobs", logger.KeyError, err)
+		os.Exit(1)
+	}
+	go scheduler.Run(context.Background())
+	// export the host pool's and plugins' metrics to every metricsink plugin; stopped before the host shuts down
+	if metricsConf := conf.Metrics; metricsConf.Enabled {
+		stopMetrics, err := management.AttachMetricSinks(context.Background(), management.MetricSinkOptions{
+			Catalog:  host.Catalog(),
+			Dispense: host.Dispense,
+			Interval: time.Duration(metricsConf.ExportInterval) * time.Millisecond,
+			Pool:     hostPool,
+			Manager:  host.Manager(),
+			Memory:   memory,
+			Logger:   multiLogger.Named("metrics"),
+		})
+		if err != nil {
+			multiLogger.Error("Failed to attach metric sink plugins", logger.KeyError, err)
+		}
+		defer stopMetrics()
+	}
+	// capture incident archives on demand through the debug endpoints and whenever the flap detector demotes a plugin
+	var incidents *management.IncidentCapturer
+	if incConf := conf.Incident; incConf.Enabled {
+		incidents = newIncidentCapturer(conf, management.IncidentOptions{
+			Catalog: host.Catalog(),
+			Pool:    hostPool,
+			Memory:  memory,
+			Errors:  errorFingerprints,
+			Logs:    recentLogs,
+			Logger:  multiLogger.Named("incident"),
+		})
+		if incConf.OnFlap {
+			host.FlapDetector().OnDisable(incidents.OnFlap)
+		}
+		if incConf.OnWatchdog && watchdog != nil {
+			go incidents.WatchLongJobs(monitorCtx, watchdog.Events())
+		}
+	}
+	if interval := conf.History.PruneInterval; interval > 0 {
+		go jobs.RunRetention(context.Background(), time.Duration(interval)*time.Millisecond)
+	}
+	// sample plugin availability and job outcomes into daily rollups for SLA dashboards, served by the REST endpoints
+	var slaReporter *sla.Reporter
+	if slaConf := conf.SLA; slaConf.Enabled {
+		slaReporter, err = newSLAReporter(conf, host.Manager(), backend, jobs, multiLogger.Named("sla"))
+		if err != nil {
+			multiLogger.Error("Failed to open SLA rollups", logger.KeyError, err)
+			os.Exit(1)
+		}
+		slaCtx, stopSLA := context.WithCancel(context.Background())
+		slaDone := make(chan struct{})
+		go func() {
+			defer close(slaDone)
+			slaReporter.Run(slaCtx, time.Duration(slaConf.SampleInterval)*time.Millisecond,
+				time.Duration(slaConf.ReportInterval)*time.Millisecond)
+		}()
+		// the final sample is persisted before the storage backend closes
+		defer func() {
+			stopSLA()
+			<-slaDone
+		}()
+	}
+
+	// the admin API lets operators manage the plugins of this host remotely instead of restarting it
+	if adminConf := conf.Admin; adminConf.Enabled {
+		lis, err := net.Listen("tcp", adminConf.Address)
+		if err != nil {
+			multiLogger.Error("Failed to listen for the admin API", logger.KeyError, err)
+			os.Exit(1)
+		}
+		if adminConf.Token == "" && adminConf.AuthPlugin == "" {
+			multiLogger.Warn("Admin API is serving without a token", "address", adminConf.Address)
+		}
+		admin := management.NewAdminServer(management.AdminOptions{
+			Token:       adminConf.Token,
+			Auth:        managementAuth(adminConf.AuthPlugin, host.Dispense),
+			Manager:     host.Manager(),
+			Catalog:     host.Catalog(),
+			Pool:        hostPool,
+			Levels:      levels,
+			DeadLetters: logs,
+			Logger:      multiLogger.Named("admin"),
+		})
+		go func() {
+			if err := admin.Serve(context.Background(), lis); err != nil {
+				multiLogger.Error("Admin API stopped", logger.KeyError, err)
+			}
+		}()
+	}
+	// the REST endpoints serve the same plugin and pool state as JSON to monitoring systems
+	if restConf := conf.REST; restConf.Enabled {
+		lis, err := net.Listen("tcp", restConf.Address)
+		if err != nil {
+			multiLogger.Error("Failed to listen for the REST endpoints", logger.KeyError, err)
+			os.Exit(1)
+		}
+		restLogger := multiLogger.Named("rest")
+		restAuth := managementAuth(restConf.AuthPlugin, host.Dispense)
+		if conf.Debug.Enabled && restConf.Token == "" && restAuth == nil {
+			restLogger.Warn("Debug endpoints are enabled without a REST token or auth plugin and refuse every request",
+				"address", restConf.Address)
+		}
+		// the debug endpoints share the listener and credentials, answering 404 unless debug.enabled is set
+		handler := http.NewServeMux()
+		handler.Handle(management.DebugPrefix, management.DebugHandler(management.DebugOptions{
+			Enabled:     conf.Debug.Enabled,
+			Token:       restConf.Token,
+			Auth:        restAuth,
+			Catalog:     host.Catalog(),
+			Pool:        hostPool,
+			Errors:      errorFingerprints,
+			Levels:      host.LogLevels(),
+			Janitor:     host.Janitor(),
+			Memory:      memory,
+			Incidents:   incidents,
+			HostVersion: conf.General.Version.String(),
+			Logger:      restLogger.Named("debug"),
+		}))
+		handler.Handle("/", management.RESTHandler(management.AdminOptions{
+			Token:       restConf.Token,
+			Auth:        restAuth,
+			Manager:     host.Manager(),
+			Catalog:     host.Catalog(),
+			Pool:        hostPool,
+			Degraded:    host.Degraded,
+			SLA:         slaReporter,
+			Janitor:     host.Janitor(),
+			Flap:        host.FlapDetector(),
+			HostVersion: conf.General.Version.String(),
+			Logger:      restLogger,
+		}))
+		restLogger.Info("Serving REST endpoints", "address", lis.Addr().String())
+		go func() {
+			if err := http.Serve(lis, handler); err != nil {
+				restLogger.Error("REST endpoints stopped", logger.KeyError, err)
+			}
+		}()
+	}
+
+	// the dispatcher serves queued jobs to remote worker agents and records the results they report in the job history
+	if agentsConf := conf.Agents; agentsConf.Enabled {
+		dispatchLogger := multiLogger.Named("dispatcher")
+		dispatcher, err := agent.NewDispatcher(agentsConf.Queue, os.Getenv(AgentTokenEnvVar), dispatchLogger)
+		if err != nil {
+			dispatchLogger.Error("Failed to open agent job queue", logger.KeyError, err)
+			os.Exit(1)
+		}
+		defer func() { _ = dispatcher.Close() }()
+		dispatcher.WithLease(time.Duration(agentsConf.Lease) * time.Millisecond).
+			WithMaxRequestBytes(int64(agentsConf.MaxRequestBytes))
+		go dispatcher.RunLeaseExpiry(context.Background(), time.Duration(agentsConf.RequeueInterval)*time.Millisecond)
+		go func() {
+			for res := range dispatcher.Results() {
+				if err := jobs.Record(res.JobResult()); err != nil {
+					dispatchLogger.Error("Failed to record remote job result", logger.KeyJobID, res.JobID,
+						logger.KeyAgentID, res.AgentID, logger.KeyError, err)
+				}
+			}
+		}()
+		lis, err := net.Listen("tcp", agentsConf.Address)
+		if err != nil {
+			dispatchLogger.Error("Failed to listen for agents", logger.KeyError, err)
+			os.Exit(1)
+		}
+		dispatchLogger.Info("Serving jobs to agents", "address", lis.Addr().String())
+		go func() {
+			if err := http.Serve(lis, dispatcher.Handler()); err != nil {
+				dispatchLogger.Error("Agent dispatcher stopped", logger.KeyError, err)
+			}
+		}()
+	}
+
+	if err := host.Degraded(); err != nil {
+		// the host keeps serving and starts the plugins once discovery succeeds; the demo below needs them now
+		multiLogger.Warn("Plugin host is degraded, skipping the plugin demo", logger.KeyError, err)
+		<-make(chan struct{})
 	}
 
-	// Load plugins
-	loader, err := registry.NewPluginLoader(pluginsDir, multiLogger)
+	cat, err := host.Dispense("cat")
 	if err != nil {
-		multiLogger.Error("Failed to create plugin loader", logger.KeyError, err)
+		multiLogger.Error("Failed to dispense cat", logger.KeyError, err)
 		os.Exit(1)
 	}
-	p, e := loader.Load()
-	if len(e) > 0 {
-		multiLogger.Error("Failed to load plugins", logger.KeyError, e)
+	meow := cat.(animal.Animal).Speak(true)
+	fmt.Printf("The cat says %s\n", meow)
+
+	// get the raw interface
+	raw, err := host.Dispense("dog-grpc")
+	if err != nil {
+		multiLogger.Error("Failed to dispense dog", logger.KeyError, err)
+		os.Exit(1)
 	}
-	for d, m := range p.GetManifests() {
-		multiLogger.Info("Plugin loaded", "manifest", m.Manifest(), "dir", d)
+	// coerce the raw interface to the animal.Animal interface
+	// we can now call the methods on the animal.Animal interface as if it was local code
+	gdog, ok := raw.(animal.Animal)
+	if !ok {
+		multiLogger.Error("Failed to assert raw as animal.Animal", logger.KeyError, err)
+		os.Exit(1)
 	}
 
-	var pluginMapImported = make(map[string]plugin.Plugin)
+	// end of actual plugin setup
 
-	for _, m := range p.GetManifests() {
+	gWoof := gdog.Speak(false)
 
-		// Generates the PluginMap
-		validType := registry.AvailablePluginTypesLookup.IsValidPluginType(m.Manifest().PluginData.Type)
-		if validType {
-			pt := registry.AvailablePluginTypes.GetByString(m.Manifest().PluginData.Type)
-			pluginMapImported[m.Manifest().PluginData.Name] = pt
-		}
+	fmt.Printf("The dog-grpc says %s\n", gWoof)
+
+	<-make(chan struct{})
+}
+
+// loadConfig loads ConfigFile with PLUGSCONC_* environment overrides applied, falling back to the defaults when the
+// file does not exist. An invalid config is reported and the process exits.
+func loadConfig() *config.Config {
+	conf, err := config.LoadWithEnv(ConfigFile)
+	if err != nil {
+		log.Printf("invalid config %s:\n%v", ConfigFile, err)
+		os.Exit(1)
+	}
+	return conf
+}
 
-		// Establish plugin root
-		pFolder, err := filepath.Abs(filepath.Join(pluginsDir, m.Manifest().PluginData.Name))
+// reloadLevelsOnHangup re-reads ConfigFile on every SIGHUP and applies its logging levels to the console logger and
+// the log sinks registered with levels. An invalid config leaves the levels unchanged.
+func reloadLevelsOnHangup(levels *logger.LevelController, l hclog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		conf, err := config.LoadWithEnv(ConfigFile)
 		if err != nil {
-			multiLogger.Error("Failed to get absolute path", logger.KeyError, err)
+			l.Error("Failed to re-read config, log levels unchanged", "file", ConfigFile, logger.KeyError, err)
+			continue
 		}
-		// Add this plugin dir to the file watcher
-		err = watcher.Add(pFolder)
+		changed, err := levels.Apply(conf.Logging)
 		if err != nil {
-			multiLogger.Error("Failed to add watcher", logger.KeyError, err)
+			l.Warn("Failed to apply some log levels", logger.KeyError, err)
 		}
+		l.Info("Applied log levels from config", "file", ConfigFile, "changed", changed, "levels", levels.Levels())
+	}
+}
 
-		// Convert Manifest to LaunchDetails
-		ld := m.Manifest().ToLaunchDetails()
-		if ld != nil {
-			multiLogger.Info("Plugin loaded", "launch_details", ld.HandshakeConfig)
+// runLogCheck runs the logging pipeline self-test, prints a report, and returns the process exit code. The logsink
+// plugins configured are launched from a plugin host to check them too.
+func runLogCheck(conf *config.Config) int {
+	var dispense func(name string) (any, error)
+	if len(conf.Logging.Sinks) > 0 {
+		host, err := plugshost.New(conf)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
 		}
-
-		fsCap := m.Manifest().Capabilities.Filesystem
-		for _, f := range fsCap {
-			multiLogger.Info("Filesystem capability detected", "filesystem", f)
+		defer func() { _ = host.Shutdown() }()
+		dispense = host.Dispense
+	}
+	code := 0
+	for _, res := range logger.SelfTest(conf, os.Stdout, dispense) {
+		fmt.Println(res.String())
+		if !res.OK() {
+			code = 1
 		}
+	}
+	return code
+}
 
-		network := m.Manifest().Capabilities.Network
-		if network != nil {
-			multiLogger.Info("Network capability detected", "network", *network)
-		}
+// runCertify certifies the plugin in dir, prints the report, and returns the process exit code.
+func runCertify(dir string) int {
+	report := certify.Certify(dir, logger.DefaultLogger().Named("certify"))
+	fmt.Print(report.String())
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}
 
-		procCap := m.Manifest().Capabilities.Process
-		for _, p := range procCap.Exec {
-			multiLogger.Info("Process capability detected", "exec", p)
+// runAPICatalog prints the APICatalog of this host build as JSON and returns the process exit code.
+func runAPICatalog(conf *config.Config) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(management.BuildAPICatalog(conf.General.Version.String())); err != nil {
+		log.Printf("failed to write API catalog: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// runAgent runs this process as a remote worker agent for the host at hostURL until interrupted,
+// returning the process exit code.
+func runAgent(hostURL string) int {
+	agentLogger := logger.DefaultLogger().Named("agent")
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "agent"
+	}
+	conf := loadConfig()
+	defer setupTracing(conf, conf.General.Name+"-agent", agentLogger.Named("tracing"))()
+	// agents run no plugins, so they run the host's job types that need none
+	jobtypes.RegisterHTTPCheck(nil)
+	pool, closeQueue, err := newWorkerPool(conf, "agent", nil, agentLogger.Named("pool"))
+	if err != nil {
+		agentLogger.Error("Failed to open persistent job queue", logger.KeyError, err)
+		return 1
+	}
+	// deferred first so the queue is closed after the pool has shut down
+	defer closeQueue()
+	adaptive, err := newAdaptiveConcurrency(conf, pool, agentLogger.Named("adaptive"))
+	if err != nil {
+		agentLogger.Error("Failed to configure adaptive concurrency", logger.KeyError, err)
+		return 1
+	}
+	memory, err := newMemoryMonitor(conf, pool, agentLogger.Named("memory"))
+	if err != nil {
+		agentLogger.Error("Failed to configure memory monitor", logger.KeyError, err)
+		return 1
+	}
+	// record every job the agent runs in its own job history before streaming the result to the host
+	backend, err := openStorage(conf, agentLogger.Named("storage"))
+	if err != nil {
+		agentLogger.Error("Failed to open storage", logger.KeyError, err)
+		return 1
+	}
+	defer func() { _ = backend.Close() }()
+	jobs, err := newJobHistory(conf, backend, agentLogger.Named("history"))
+	if err != nil {
+		agentLogger.Error("Failed to open job history", logger.KeyError, err)
+		return 1
+	}
+	// the agent shuts the pool down when it stops, after reporting or spooling the results of the jobs it holds
+	pool.Run()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if adaptive != nil {
+		go adaptive.Run(ctx)
+	}
+	if memory != nil {
+		go memory.Run(ctx)
+	}
+	if interval := conf.History.PruneInterval; interval > 0 {
+		go jobs.RunRetention(ctx, time.Duration(interval)*time.Millisecond)
+	}
+	if wd := newWatchdog(conf, pool, agentLogger.Named("watchdog")); wd != nil {
+		if incConf := conf.Incident; incConf.Enabled && incConf.OnWatchdog {
+			opts := management.IncidentOptions{Pool: pool, Memory: memory, Logger: agentLogger.Named("incident")}
+			// record the agent's logs for the captures when it logs through an intercept logger
+			if il, ok := agentLogger.(hclog.InterceptLogger); ok {
+				opts.Logs = logger.NewRecentLogs(incConf.RecentLogs, hclog.Info)
+				il.RegisterSink(opts.Logs)
+			}
+			go newIncidentCapturer(conf, opts).WatchLongJobs(ctx, wd.Events())
 		}
-		for _, p := range procCap.Kill {
-			multiLogger.Info("Process capability detected", "kill", p)
+		go wd.Run(ctx)
+	}
+	a := agent.NewAgent(fmt.Sprintf("%s-%d", hostname, os.Getpid()), hostURL, os.Getenv(AgentTokenEnvVar), pool,
+		agentLogger).WithResults(jobs.Tee(pool.Results()))
+	if path := conf.Agents.Spool; path != "" {
+		spool, err := agent.OpenResultSpool(path)
+		if err != nil {
+			agentLogger.Error("Failed to open result spool", logger.KeyError, err)
+			return 1
 		}
-		for _, p := range procCap.List {
-			multiLogger.Info("Process capability detected", "list", p)
+		defer func() { _ = spool.Close() }()
+		a.WithSpool(spool)
+	}
+	if err := a.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		agentLogger.Error("Agent failed", logger.KeyError, err)
+		return 1
+	}
+	return 0
+}
+
+// newAdaptiveConcurrency returns an AdaptiveConcurrency for pool configured by conf.Adaptive with its Middleware
+// registered on pool, which must not be running yet, or nil when it is disabled.
+func newAdaptiveConcurrency(conf *config.Config, pool *worker.Pool,
+	adaptiveLogger hclog.Logger) (*worker.AdaptiveConcurrency, error) {
+	acConf := conf.Adaptive
+	if !acConf.Enabled {
+		return nil, nil
+	}
+	adaptive, err := worker.NewAdaptiveConcurrency(pool, worker.AdaptiveConfig{
+		MinWorkers:     acConf.MinWorkers,
+		MaxWorkers:     acConf.MaxWorkers,
+		TargetLatency:  time.Duration(acConf.TargetLatency) * time.Millisecond,
+		MaxErrorRate:   acConf.MaxErrorRate,
+		Interval:       time.Duration(acConf.Interval) * time.Millisecond,
+		DecreaseFactor: acConf.DecreaseFactor,
+	}, adaptiveLogger)
+	if err != nil {
+		return nil, err
+	}
+	pool.Use(adaptive.Middleware())
+	return adaptive, nil
+}
+
+// newMemoryMonitor returns a MemoryMonitor configured by conf.Memory with its Middleware registered on pool, which
+// must not be running yet, or nil when it is disabled.
+func newMemoryMonitor(conf *config.Config, pool *worker.Pool,
+	memoryLogger hclog.Logger) (*worker.MemoryMonitor, error) {
+	memConf := conf.Memory
+	if !memConf.Enabled {
+		return nil, nil
+	}
+	memory, err := worker.NewMemoryMonitor(worker.MemoryConfig{
+		SoftLimit:       uint64(memConf.SoftLimit) << 20,
+		Throttle:        memConf.Throttle,
+		ThrottleWorkers: memConf.ThrottleWorkers,
+		Interval:        time.Duration(memConf.Interval) * time.Millisecond,
+	}, memoryLogger)
+	if err != nil {
+		return nil, err
+	}
+	pool.Use(memory.Middleware())
+	return memory, nil
+}
+
+// newWatchdog returns a Watchdog for pool with the threshold and interval of conf.Watchdog, or nil when it is
+// disabled.
+func newWatchdog(conf *config.Config, pool *worker.Pool, watchdogLogger hclog.Logger) *worker.Watchdog {
+	wdConf := conf.Watchdog
+	if !wdConf.Enabled {
+		return nil
+	}
+	return worker.NewWatchdog(pool,
+		time.Duration(wdConf.Threshold)*time.Millisecond,
+		time.Duration(wdConf.Interval)*time.Millisecond,
+		watchdogLogger)
+}
+
+// newWorkerPool returns a pool of one worker per CPU configured by the results limit, the chaos config, and the queue
+// config of the pool name, and a function closing its persistent queue once the pool has shut down. killer, when not
+// nil, lets chaos mode kill plugin connections.
+func newWorkerPool(conf *config.Config, name string, killer worker.PluginKiller,
+	poolLogger hclog.Logger) (*worker.Pool, func(), error) {
+	pool := worker.NewPool(runtime.GOMAXPROCS(0), true, 100, poolLogger).
+		WithResultLimit(worker.ResultLimit{
+			MaxBytes: conf.Results.MaxBytes,
+			Policy:   worker.OverflowPolicy(conf.Results.Policy),
+			SpillDir: conf.Results.SpillDir,
+		}).
+		WithChaos(worker.NewChaos(conf.Chaos, poolLogger.Named("chaos")).WithPluginKiller(killer))
+	closeQueue := func() {}
+	queueConf := conf.Queues.Pool(name)
+	if queueConf.Backend == config.QueuePersistent {
+		durable, err := worker.OpenDurableQueue(queueConf.Path, poolLogger.Named("queue"))
+		if err != nil {
+			return nil, nil, err
 		}
-		for _, p := range procCap.Signal {
-			multiLogger.Info("Process capability detected", "signal", p)
+		closeQueue = func() { _ = durable.Close() }
+		pool.WithDurableQueue(durable)
+	}
+	for class, limit := range queueConf.Classes {
+		pool.WithClassLimit(class, worker.ClassLimit{
+			MaxConcurrent: limit.MaxConcurrent,
+			Rate:          limit.Rate,
+			Burst:         limit.Burst,
+		})
+	}
+	return pool, closeQueue, nil
+}
+
+// setupTracing installs the OpenTelemetry tracer provider of the tracing config for service and returns a function
+// flushing the buffered spans at exit. It does nothing when tracing is disabled or cannot be set up.
+func setupTracing(conf *config.Config, service string, tracingLogger hclog.Logger) func() {
+	if !conf.Tracing.Enabled {
+		return func() {}
+	}
+	shutdown, err := tracing.Setup(context.Background(), conf.Tracing, service, conf.General.Version.String())
+	if err != nil {
+		tracingLogger.Error("Failed to set up tracing", logger.KeyError, err)
+		return func() {}
+	}
+	tracingLogger.Info("Tracing enabled", "exporter", conf.Tracing.Exporter, "sample_ratio", conf.Tracing.SampleRatio)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			tracingLogger.Warn("Failed to flush traces", logger.KeyError, err)
 		}
+	}
+}
 
+// managementAuth returns the auth provider of a management API authenticating through the authprovider plugin name,
+// dispensed with dispense, or nil when no plugin is named.
+func managementAuth(name string, dispense func(name string) (any, error)) authprovider.AuthProvider {
+	if name == "" {
+		return nil
 	}
+	return management.NewPluginAuth(name, dispense)
+}
 
-	cSHA, err := checksum.NewSHA256File("./plugins/cat")
+// newScheduler returns a Scheduler for pool with the jobs scheduled in conf.Schedules. The jobs are of the types
+// registered by jobtypes, so they can be persisted by a durable host queue.
+func newScheduler(conf *config.Config, pool *worker.Pool, schedulerLogger hclog.Logger) (*worker.Scheduler, error) {
+	scheduler := worker.NewScheduler(pool, schedulerLogger)
+	sc := conf.Schedules
+	if sc.VerifyChecksums == "" {
+		return scheduler, nil
+	}
+	schedule, err := worker.ParseSchedule(sc.VerifyChecksums)
 	if err != nil {
-		multiLogger.Error("Failed to load checksum", logger.KeyError, err)
-		return
+		return nil, err
+	}
+	err = scheduler.Add(worker.ScheduledJob{
+		Name:     jobtypes.VerifyChecksums,
+		Schedule: schedule,
+		Jitter:   time.Duration(sc.Jitter) * time.Millisecond,
+		NewJob: func(ctx context.Context) *worker.Job {
+			job, err := worker.NewSerializableJob(ctx, jobtypes.VerifyChecksums, jobtypes.VerifyChecksumsRequest{})
+			if err != nil {
+				schedulerLogger.Error("Failed to create scheduled job", logger.KeyJobType, jobtypes.VerifyChecksums,
+					logger.KeyError, err)
+				return nil
+			}
+			return job
+		},
+	})
+	return scheduler, err
+}
+
+// pluginNames returns the names of the plugins in the host's catalog.
+func pluginNames(host *plugshost.Host) []string {
+	details := host.Catalog().GetLaunchDetails()
+	names := make([]string, 0, len(details))
+	for _, ld := range details {
+		names = append(names, ld.Name())
 	}
-	err = cSHA.Parse()
+	return names
+}
+
+// newIncidentCapturer returns an IncidentCapturer for opts with the archive directory and timings of conf.Incident.
+func newIncidentCapturer(conf *config.Config, opts management.IncidentOptions) *management.IncidentCapturer {
+	opts.Dir = conf.Incident.Dir
+	opts.CPUProfile = time.Duration(conf.Incident.CPUProfile) * time.Millisecond
+	opts.Cooldown = time.Duration(conf.Incident.Cooldown) * time.Millisecond
+	return management.NewIncidentCapturer(opts)
+}
+
+// newJobHistory opens the job history in backend, bounded by the retention of conf.History.
+func newJobHistory(conf *config.Config, backend storage.Backend, historyLogger hclog.Logger) (*history.Store, error) {
+	return history.New(backend, history.Retention{
+		MaxAge:  time.Duration(conf.History.MaxAge) * 24 * time.Hour,
+		MaxRows: conf.History.MaxRows,
+	}, historyLogger)
+}
+
+// newSLAReporter returns a Reporter for the plugins of manager, keeping its rollups in backend and counting the job
+// outcomes recorded in jobs, the history the host pool writes.
+func newSLAReporter(conf *config.Config, manager *registry.PluginManager, backend storage.Backend, jobs *history.Store,
+	slaLogger hclog.Logger) (*sla.Reporter, error) {
+	reporter, err := sla.NewReporter(manager, backend, slaLogger)
 	if err != nil {
-		multiLogger.Error("Failed to parse checksum", logger.KeyError, err)
-		return
+		return nil, err
 	}
+	return reporter.
+		WithJobs(jobs).
+		WithWindows(conf.SLA.Windows...).
+		WithRetention(conf.SLA.RetentionDays), nil
+}
 
-	multiLogger.Info("Checksum parsed successfully", "hex", cSHA.Hash(), "file", cSHA.FileName())
-	multiLogger.Info("Checksum valid", "valid", cSHA.Compare())
+// migrator returns a Migrator for backend with the migrations of every persisted component registered.
+func migrator(conf *config.Config, backend storage.Backend, storageLogger hclog.Logger) *storage.Migrator {
+	mc := conf.Logging.MQ
+	return storage.NewMigrator(backend, storageLogger).
+		Register(history.Migrations(conf.Storage.DataDir)...).
+		Register(mq.Migrations(mc.Backend, mq.Options{File: mc.File})...).
+		Register(registry.CompatibilityMigrations()...)
+}
 
-	secConf, err := cSHA.SecConf()
+// openStorage opens the configured storage backend and applies any pending schema migrations.
+func openStorage(conf *config.Config, storageLogger hclog.Logger) (storage.Backend, error) {
+	backend, err := storage.Open(conf.Storage.Backend, conf.Storage.DataDir)
 	if err != nil {
-		multiLogger.Error("Failed to get secure config", logger.KeyError, err)
-		return
+		return nil, err
+	}
+	if _, err := migrator(conf, backend, storageLogger).Migrate(false); err != nil {
+		return nil, errors.Join(err, backend.Close())
 	}
+	return backend, nil
+}
 
-	// plumbing
-	catClient := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig:  catHandshake,
-		Logger:           multiLogger.Named("cat"),
-		Plugins:          pluginMapImported,
-		Cmd:              exec.Command("./plugins/cat/cat"),
-		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC},
-		AutoMTLS:         true,
-		SecureConfig:     secConf,
-	})
-	defer catClient.Kill()
+// runStorageMigrate applies pending schema migrations, or with -rollback-to reverts one component, printing each
+// step, and returns the process exit code. With -dry-run the steps are printed without being applied.
+func runStorageMigrate(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("storage migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the steps without applying them")
+	component := fs.String("component", "", "component to roll back, required with -rollback-to")
+	rollbackTo := fs.Int("rollback-to", -1, "schema version to roll the component back to")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *rollbackTo >= 0 && *component == "" {
+		fmt.Fprintln(os.Stderr, "-rollback-to requires -component")
+		return 2
+	}
 
-	rpcCatClient, err := catClient.Client()
+	backend, err := storage.Open(conf.Storage.Backend, conf.Storage.DataDir)
 	if err != nil {
-		multiLogger.Error("Failed to create catClient", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	defer func() { _ = backend.Close() }()
+	m := migrator(conf, backend, logger.DefaultLogger().Named("storage"))
 
-	cat, err := rpcCatClient.Dispense("cat")
+	var steps []storage.Step
+	if *rollbackTo >= 0 {
+		steps, err = m.Rollback(*component, *rollbackTo, *dryRun)
+	} else {
+		steps, err = m.Migrate(*dryRun)
+	}
 	if err != nil {
-		multiLogger.Error("Failed to dispense cat", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
-	meow := cat.(animal.Animal).Speak(true)
-	fmt.Printf("The cat says %s\n", meow)
+	if len(steps) == 0 {
+		fmt.Println("schemas are up to date")
+	}
+	for _, step := range steps {
+		fmt.Println(step.String())
+	}
+	return 0
+}
 
-	dSHA, err := checksum.NewSHA256File("./plugins/dog-grpc")
+// runJobsHistory queries the job history store with the given flags, prints the matching records, and returns the
+// process exit code.
+func runJobsHistory(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("jobs history", flag.ContinueOnError)
+	failed := fs.Bool("failed", false, "only show failed jobs")
+	since := fs.Duration("since", 0, "only show jobs finished within this duration, e.g. 1h")
+	jobType := fs.String("type", "", "only show jobs of this type")
+	pluginName := fs.String("plugin", "", "only show jobs for this plugin")
+	limit := fs.Int("limit", history.DefaultQueryLimit, "maximum number of jobs to show")
+	asJSON := fs.Bool("json", false, "print records as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	backend, err := openStorage(conf, logger.DefaultLogger().Named("storage"))
 	if err != nil {
-		multiLogger.Error("Failed to create SHA256File", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() { _ = backend.Close() }()
+	store, err := history.New(backend, history.Retention{}, logger.DefaultLogger().Named("history"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	err = dSHA.Parse()
+	filter := history.Filter{Type: *jobType, Plugin: *pluginName, Limit: *limit}
+	if *failed {
+		filter.Outcome = history.OutcomeFailed
+	}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+	records, err := store.Query(filter)
 	if err != nil {
-		multiLogger.Error("Failed to parse SHA256File", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	if *asJSON {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	for _, r := range records {
+		fmt.Printf("%s  %-9s  %-12s  %-12s  attempts=%d  duration=%s  %s\n",
+			r.FinishedAt.Format(time.RFC3339), r.Outcome, r.Type, r.Plugin, r.Attempts,
+			r.Duration.Round(time.Millisecond), r.Error)
+	}
+	return 0
+}
 
-	multiLogger.Info("SHA256File parsed successfully", "hex", dSHA.Hash(), "file", dSHA.FileName())
-	multiLogger.Info("SHA256File valid", "valid", dSHA.Compare())
+// runPluginsCompat prints the plugin compatibility matrix and returns the process exit code.
+func runPluginsCompat(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("plugins compat", flag.ContinueOnError)
+	pluginName := fs.String("plugin", "", "only show this plugin")
+	asJSON := fs.Bool("json", false, "print entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	secConf, err = dSHA.SecConf()
+	backend, err := openStorage(conf, logger.DefaultLogger().Named("storage"))
 	if err != nil {
-		multiLogger.Error("Failed to get secure config", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() { _ = backend.Close() }()
+	compat, err := registry.NewCompatibilityMatrix(backend, conf.General.Version.String(),
+		logger.DefaultLogger().Named("compat"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	entries, err := compat.Entries(*pluginName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	if *asJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	for _, c := range entries {
+		fmt.Printf("%-12s  %-10s  host=%-24s  runs=%d  last=%s\n", c.Plugin, c.PluginVersion, c.HostVersion, c.Runs,
+			c.LastSeen.Format(time.RFC3339))
+	}
+	return 0
+}
 
-	// plumbing
-	gDogClient := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig:  dogHandshake,
-		Plugins:          pluginMapImported,
-		Logger:           multiLogger.Named("dog-grpc"),
-		Cmd:              exec.Command("./plugins/dog-grpc/dog"),
-		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC, plugin.ProtocolGRPC},
-		AutoMTLS:         true,
-		SecureConfig:     secConf,
-	})
-	defer gDogClient.Kill()
+// runPluginsVerify verifies the named plugins, or every loaded plugin when none are named, and returns the process
+// exit code. With -deep each plugin is also dry-run launched and its handshake and Info reported.
+func runPluginsVerify(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("plugins verify", flag.ContinueOnError)
+	deep := fs.Bool("deep", false, "launch each plugin, complete its handshake, and query its info")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
 
-	// porcelain
-	rpcGDogClient, err := gDogClient.Client()
+	host, err := plugshost.New(conf)
 	if err != nil {
-		multiLogger.Error("Failed to create gDogClient", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
-	// get the raw interface
-	raw, err := rpcGDogClient.Dispense("dog-grpc")
+	defer func() { _ = host.Shutdown() }()
+	names := fs.Args()
+	if len(names) == 0 {
+		for _, ld := range host.Catalog().GetLaunchDetails() {
+			names = append(names, ld.Name())
+		}
+		sort.Strings(names)
+	}
+
+	code := 0
+	for _, name := range names {
+		if !*deep {
+			if err := host.Manager().Verify(name); err != nil {
+				fmt.Printf("FAIL  %-12s  %v\n", name, err)
+				code = 1
+				continue
+			}
+			fmt.Printf("PASS  %s\n", name)
+			continue
+		}
+		report, err := host.Manager().DryRunLaunch(name)
+		if err != nil {
+			fmt.Printf("FAIL  %-12s  %v\n", name, err)
+			code = 1
+			continue
+		}
+		info := "info=unavailable"
+		if report.Info != nil {
+			info = fmt.Sprintf("info=%s@%s", report.Info.Name, report.Info.Version)
+		}
+		fmt.Printf("PASS  %-12s  version=%s  protocol=%s  %s  duration=%s\n", report.Name, report.Version,
+			report.Protocol, info, report.Duration.Round(time.Millisecond))
+	}
+	return code
+}
+
+// runPluginsSBOM writes an SBOM of the host binary and the plugins installed in the plugins directory to stdout or
+// the -o file, and returns the process exit code.
+func runPluginsSBOM(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("plugins sbom", flag.ContinueOnError)
+	format := fs.String("format", sbom.FormatCycloneDX, "document format: "+strings.Join(sbom.Formats, ", "))
+	out := fs.String("o", "", "write the document to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !slices.Contains(sbom.Formats, *format) {
+		fmt.Fprintf(os.Stderr, "%v: %q\n", sbom.ErrUnsupportedFormat, *format)
+		return 2
+	}
+	host, err := plugshost.New(conf)
 	if err != nil {
-		multiLogger.Error("Failed to dispense dog", logger.KeyError, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
-	// coerce the raw interface to the animal.Animal interface
-	// we can now call the methods on the animal.Animal interface as if it was local code
-	gdog, ok := raw.(animal.Animal)
-	if !ok {
-		multiLogger.Error("Failed to assert raw as animal.Animal", logger.KeyError, err)
-		os.Exit(1)
+	defer func() { _ = host.Shutdown() }()
+	data, err := sbom.Build(conf.General.Version.String(), host.Catalog()).Encode(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*out, data, 0o644)
 	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
 
-	// end of actual plugin setup
+// runPluginsExec launches the named plugin, calls the named method of the interface it serves with the JSON-decoded
+// arguments, prints each result as JSON, and returns the process exit code. A method given as Service/Method, or one
+// the interface does not have, is called dynamically on a service the plugin describes, with the arguments as its
+// protojson request.
+func runPluginsExec(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("plugins exec", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Second, "cancel the call after this long")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: plugins exec [-timeout d] <name> <method> [json-args]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		fs.Usage()
+		return 2
+	}
+	name, method := fs.Arg(0), fs.Arg(1)
 
-	gWoof := gdog.Speak(false)
+	host, err := plugshost.New(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() { _ = host.Shutdown() }()
+	if err := host.Manager().Start(name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	var results []any
+	if !strings.Contains(method, "/") {
+		results, err = host.Manager().Exec(ctx, name, method, json.RawMessage(fs.Arg(2)))
+	}
+	if strings.Contains(method, "/") || errors.Is(err, registry.ErrMethodNotFound) {
+		res, dynErr := host.Manager().Invoke(ctx, name, method, json.RawMessage(fs.Arg(2)))
+		// a plugin that cannot be called dynamically keeps the error listing its interface's methods
+		if err == nil || !errors.Is(dynErr, registry.ErrDynamicUnsupported) {
+			results, err = []any{res}, dynErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, result := range results {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(string(data))
+	}
+	return 0
+}
 
-	fmt.Printf("The dog-grpc says %s\n", gWoof)
+// runPluginsDescribe launches the named gRPC plugin, prints the services and methods it describes as JSON, and
+// returns the process exit code.
+func runPluginsDescribe(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("plugins describe", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Second, "cancel the call after this long")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: plugins describe [-timeout d] <name>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	name := fs.Arg(0)
 
-	plugin.CleanupClients()
+	host, err := plugshost.New(conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() { _ = host.Shutdown() }()
+	if err := host.Manager().Start(name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	desc, err := host.Manager().Describe(ctx, name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	data, err := json.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
 
-	<-make(chan struct{})
+// runAdmin calls one admin API command, list, status, start, stop, reload, pool, groups, group, group-start,
+// group-stop, loglevels, loglevel, deadletters, or replay-deadletters, on the running host at -addr, prints the
+// response as JSON, and returns the process exit code.
+func runAdmin(conf *config.Config, args []string) int {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	addr := fs.String("addr", conf.Admin.Address, "admin API address of the host")
+	token := fs.String("token", conf.Admin.Token, "bearer token, defaulting to admin.token")
+	timeout := fs.Duration("timeout", 30*time.Second, "cancel the call after this long")
+	pluginType := fs.String("type", "", "list only plugins of this type")
+	language := fs.String("language", "", "list only plugins written in this language")
+	state := fs.String("state", "", "list only plugins in this state, e.g. running")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(),
+			"usage: admin [flags] list [query] | status <name> | start <name> | stop <name> | reload <name> |\n"+
+				"       unquarantine <name> | approve <name> | pool | groups | group <name> | group-start <name> |\n"+
+				"       group-stop <name> | loglevels | loglevel <target> <level> | deadletters |\n"+
+				"       replay-deadletters [id...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+	command, name := fs.Arg(0), fs.Arg(1)
+	switch command {
+	case "status", "start", "stop", "reload", "unquarantine", "approve", "group", "group-start", "group-stop":
+		if name == "" {
+			fs.Usage()
+			return 2
+		}
+	case "loglevel":
+		if fs.NArg() != 3 {
+			fs.Usage()
+			return 2
+		}
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer func() { _ = conn.Close() }()
+	client := adminv1.NewAdminClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, management.AdminTokenMetadata, "Bearer "+*token)
+	}
+	// the host keeps the request ID sent with the call, so a failure can be found in its logs
+	requestID := callctx.NewRequestID()
+	ctx = metadata.AppendToOutgoingContext(ctx, callctx.MetadataRequestID, requestID)
+
+	var res proto.Message
+	switch command {
+	case "list":
+		res, err = client.ListPlugins(ctx, &adminv1.ListPluginsRequest{
+			Type:     *pluginType,
+			Language: *language,
+			State:    *state,
+			Query:    strings.Join(fs.Args()[1:], " "),
+		})
+	case "status":
+		res, err = client.GetPluginStatus(ctx, &adminv1.GetPluginStatusRequest{Name: name})
+	case "start":
+		res, err = client.StartPlugin(ctx, &adminv1.StartPluginRequest{Name: name})
+	case "stop":
+		res, err = client.StopPlugin(ctx, &adminv1.StopPluginRequest{Name: name})
+	case "reload":
+		res, err = client.ReloadPlugin(ctx, &adminv1.ReloadPluginRequest{Name: name})
+	case "unquarantine":
+		res, err = client.UnquarantinePlugin(ctx, &adminv1.UnquarantinePluginRequest{Name: name})
+	case "approve":
+		res, err = client.ApprovePlugin(ctx, &adminv1.ApprovePluginRequest{Name: name})
+	case "pool":
+		res, err = client.GetPoolMetrics(ctx, &adminv1.GetPoolMetricsRequest{})
+	case "groups":
+		res, err = client.ListGroups(ctx, &adminv1.ListGroupsRequest{})
+	case "group":
+		res, err = client.GetGroupStatus(ctx, &adminv1.GetGroupStatusRequest{Name: name})
+	case "group-start":
+		res, err = client.StartGroup(ctx, &adminv1.StartGroupRequest{Name: name})
+	case "group-stop":
+		res, err = client.StopGroup(ctx, &adminv1.StopGroupRequest{Name: name})
+	case "loglevels":
+		res, err = client.GetLogLevels(ctx, &adminv1.GetLogLevelsRequest{})
+	case "loglevel":
+		res, err = client.SetLogLevel(ctx, &adminv1.SetLogLevelRequest{Target: name, Level: fs.Arg(2)})
+	case "deadletters":
+		res, err = client.ListDeadLetters(ctx, &adminv1.ListDeadLettersRequest{})
+	case "replay-deadletters":
+		res, err = client.ReplayDeadLetters(ctx, &adminv1.ReplayDeadLettersRequest{Ids: fs.Args()[1:]})
+	default:
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v (request ID %s)\n", err, requestID)
+		return 1
+	}
+	fmt.Println(protojson.MarshalOptions{Multiline: true, Indent: "  "}.Format(res))
+	return 0
 }
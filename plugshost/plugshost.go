@@ -0,0 +1,516 @@
+// Package plugshost bundles plugin discovery, the catalog, checksum-verified launching, supervision, hot reload,
+// and per-plugin loggers behind a single Host, so embedding PlugsConc takes a config and three calls.
+package plugshost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/internal/attestation"
+	"github.com/bmj2728/PlugsConc/internal/config"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/internal/replay"
+	"github.com/bmj2728/PlugsConc/internal/signature"
+	"github.com/bmj2728/PlugsConc/internal/storage"
+	"github.com/bmj2728/PlugsConc/internal/worker"
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+)
+
+// ErrHostStarted indicates that Start was called on a Host that is already running.
+// ErrPluginsDirUnavailable indicates that the plugins directory does not exist or cannot be read.
+var (
+	ErrHostStarted           = errors.New("plugin host already started")
+	ErrPluginsDirUnavailable = errors.New("plugins directory unavailable")
+)
+
+// Host loads the plugins in the configured directory into a catalog and manages their lifecycle: Start launches
+// the autostart plugins and begins supervising them, Dispense returns a plugin's client interface, and Shutdown
+// stops everything.
+type Host struct {
+	mu         sync.Mutex
+	conf       *config.Config
+	hostLogger hclog.Logger
+	catalog    *registry.PluginCatalog
+	manager    *registry.PluginManager
+	flap       *registry.FlapDetector
+	watcher    *fsnotify.Watcher
+	levels     *logger.LevelRegistry
+	pluginLogs *logger.PluginLogFiles // writes each plugin's records to a file of its own, nil without plugin files
+	janitor    *registry.Janitor      // removes stale plugin artifacts, nil without a runtime dir
+	health     *registry.HealthChecker
+	converger  *registry.Converger
+	trustStore *signature.TrustStore
+	attester   *attestation.Client   // remote attestation of plugin binaries, nil unless plugins.attestation is enabled
+	runtime    *registry.RuntimeDirs // nil without a runtime dir
+	recorder   *replay.Recorder      // records plugin calls, nil unless plugins.interactions.mode is record
+	reconcile  *worker.Pool          // runs hot reloads as reconcile jobs, nil unless plugins.hot_reload is set
+	cancel     context.CancelFunc    // stops the background goroutines, nil until Start
+	wg         sync.WaitGroup
+	degradedMu sync.RWMutex
+	degraded   error // why discovery has not loaded the plugins directory yet, nil once it has
+}
+
+// New loads the plugins in conf.Plugins.Dir and builds a Host for them, logging through hclog.Default(). A nil
+// conf uses config.DefaultConfig(). Plugins whose manifests fail to load are logged and skipped; only failing to
+// read the plugins directory or to create the file watcher is an error. With conf.Plugins.Discovery.Degraded set,
+// an unreadable plugins directory is not an error either: the Host is built with no plugins and reports itself
+// Degraded until Start's background discovery loads the directory.
+func New(conf *config.Config) (*Host, error) {
+	if conf == nil {
+		conf = config.DefaultConfig()
+	}
+	hostLogger := hclog.Default()
+	h := &Host{
+		conf:       conf,
+		hostLogger: hostLogger,
+		levels:     logger.NewLevelRegistry(),
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher: %w", err)
+	}
+	h.watcher = watcher
+
+	hostLogger.Info("Plugins directory", "dir", conf.Plugins.Dir)
+	if conf.Plugins.TrustStore != "" {
+		h.trustStore, err = signature.LoadTrustStore(conf.Plugins.TrustStore, hostLogger.Named("trust"))
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	if attConf := conf.Plugins.Attestation; attConf.Enabled {
+		h.attester = attestation.NewClient(attConf.URL, attConf.Token, hostLogger.Named("attestation")).
+			WithTimeout(time.Duration(attConf.Timeout) * time.Millisecond).
+			WithCacheTTL(time.Duration(attConf.CacheTTL) * time.Millisecond).
+			WithFallback(attConf.Fallback)
+		if attConf.CacheFile != "" {
+			if h.attester, err = h.attester.WithCacheFile(attConf.CacheFile); err != nil {
+				_ = watcher.Close()
+				return nil, err
+			}
+		}
+	}
+	h.catalog = registry.NewPluginCatalog(registry.NewManifests()).WithFileWatcher(watcher, h.watchEvents)
+
+	h.flap = registry.NewFlapDetector(registry.DefaultFlapWindow, registry.DefaultFlapThreshold, hostLogger.Named("flap"))
+	h.manager = registry.NewPluginManager(h.catalog, hostLogger.Named("plugins")).
+		WithFlapDetector(h.flap).
+		WithClientLogger(func(name string) hclog.Logger {
+			return h.pluginLogs.Register(name, h.levels.Register(name, hostLogger.Named(name)))
+		}).
+		WithReloadDebounce(time.Duration(conf.Plugins.ReloadDebounce)*time.Millisecond).
+		WithTrustStore(h.trustStore, conf.Plugins.RequireSignatures).
+		WithAttestation(h.attester).
+		WithGroups(conf.Plugins.Groups).
+		WithRestartPolicy(registry.RestartPolicy{
+			InitialBackoff: time.Duration(conf.Plugins.Restart.InitialBackoff) * time.Millisecond,
+			MaxBackoff:     time.Duration(conf.Plugins.Restart.MaxBackoff) * time.Millisecond,
+			MaxRestarts:    conf.Plugins.Restart.MaxRestarts,
+			ResetAfter:     time.Duration(conf.Plugins.Restart.ResetAfter) * time.Millisecond,
+		})
+	if conf.Logging.CollectPluginOutput {
+		h.manager.WithOutputCollection(nil)
+	}
+	if conf.Plugins.HotReload {
+		h.reconcile = worker.NewPool(conf.Plugins.ReloadWorkers, false, reconcileQueue, hostLogger.Named("reconcile")).
+			OnResult(func(*worker.JobResult) {}) // failed reloads are logged by the manager
+		h.manager.WithReconcilePool(h.reconcile, conf.Plugins.ReloadRate)
+	}
+	h.health = registry.NewHealthChecker(h.manager, registry.DefaultSuperviseInterval, hostLogger.Named("health")).
+		WithRestart(conf.Plugins.AutoRestart)
+	h.converger = registry.NewConverger(h.manager, time.Duration(conf.Plugins.Converge.Interval)*time.Millisecond,
+		hostLogger.Named("converge"))
+
+	switch conf.Plugins.Interactions.Mode {
+	case config.InteractionsRecord:
+		recorder, err := replay.NewRecorder(conf.Plugins.Interactions.Dir, hostLogger.Named("recorder"))
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		h.recorder = recorder
+		h.manager.WithRecorder(recorder)
+	case config.InteractionsReplay:
+		h.manager.WithReplayer(replay.NewReplayer(conf.Plugins.Interactions.Dir, hostLogger.Named("replayer")))
+	}
+
+	if conf.Plugins.RuntimeDir != "" {
+		h.runtime, err = registry.NewRuntimeDirs(conf.Plugins.RuntimeDir, hostLogger.Named("runtime"))
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		h.manager.WithRuntimeDirs(h.runtime)
+		h.janitor = registry.NewJanitor(h.runtime, h.catalog, time.Duration(conf.Janitor.MaxAge)*24*time.Hour,
+			hostLogger.Named("janitor"))
+	}
+
+	if err := h.discover(); err != nil {
+		if !conf.Plugins.Discovery.Degraded {
+			_ = watcher.Close()
+			return nil, err
+		}
+		hostLogger.Warn("Starting without plugins until the plugins directory is available", logger.KeyError, err)
+		h.setDegraded(err)
+	}
+	return h, nil
+}
+
+// reconcileQueue is the channel buffer of the reconcile pool. It cannot be sized by the number of installed plugins,
+// which is unknown until discovery has loaded the plugins directory.
+const reconcileQueue = 16
+
+// discover loads the plugins in the plugins directory into the catalog and watches it, then removes the runtime
+// directories of plugins that are no longer installed. It returns an error wrapping ErrPluginsDirUnavailable, and
+// changes nothing, when the directory cannot be read.
+func (h *Host) discover() error {
+	pluginsDir := h.conf.Plugins.Dir
+	if _, err := os.ReadDir(pluginsDir); err != nil {
+		return fmt.Errorf("%w: %w", ErrPluginsDirUnavailable, err)
+	}
+	loader, err := registry.NewPluginLoader(pluginsDir, h.hostLogger)
+	if err != nil {
+		return err
+	}
+	layout := registry.DefaultLayout.WithAllowed(h.conf.Plugins.Layout.Allow...)
+	layout.Strict = h.conf.Plugins.Layout.Strict
+	loader.WithTrustStore(h.trustStore, h.conf.Plugins.RequireSignatures).
+		WithAttestation(h.attester).
+		WithLayout(layout)
+	manifests, loadErrs := loader.Load()
+	if len(loadErrs) > 0 {
+		h.hostLogger.Error("Failed to load plugins", logger.KeyError, loadErrs)
+	}
+	h.catalog.AddManifests(manifests)
+	h.watch(pluginsDir)
+	installed := make([]string, 0, len(manifests.GetManifests()))
+	for dir, m := range manifests.GetManifests() {
+		// directories without a valid manifest are recorded by the loader but cannot be launched
+		if m.Manifest() == nil {
+			continue
+		}
+		h.register(dir, m)
+		installed = append(installed, m.Manifest().PluginData.Name)
+	}
+	// pruning only once the directory was read keeps an unavailable mount from looking like every plugin was removed
+	if h.runtime != nil {
+		if err := h.runtime.Prune(installed); err != nil {
+			h.hostLogger.Warn("Failed to remove runtime directories of uninstalled plugins", logger.KeyError, err)
+		}
+	}
+	return nil
+}
+
+// rediscover retries discover on the configured backoff until it succeeds or ctx is canceled, then clears the
+// degraded state and starts the autostart plugins.
+func (h *Host) rediscover(ctx context.Context) {
+	discovery := h.conf.Plugins.Discovery
+	wait := time.Duration(discovery.InitialBackoff) * time.Millisecond
+	maxWait := time.Duration(discovery.MaxBackoff) * time.Millisecond
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		err := h.discover()
+		if err == nil {
+			break
+		}
+		wait = min(2*wait, maxWait)
+		h.hostLogger.Warn("Plugins directory still unavailable", "attempt", attempt, "retry_in", wait.String(),
+			logger.KeyError, err)
+		h.setDegraded(err)
+		timer.Reset(wait)
+	}
+	h.setDegraded(nil)
+	h.hostLogger.Info("Plugins directory available, leaving degraded mode", "dir", h.conf.Plugins.Dir)
+	if err := errors.Join(h.autostart()...); err != nil {
+		h.hostLogger.Error("Failed to start plugins", logger.KeyError, err)
+	}
+}
+
+// setDegraded records why the Host is degraded, or clears it with nil.
+func (h *Host) setDegraded(err error) {
+	h.degradedMu.Lock()
+	defer h.degradedMu.Unlock()
+	h.degraded = err
+}
+
+// Degraded returns why the Host is running without its plugins, an error wrapping ErrPluginsDirUnavailable while the
+// plugins directory cannot be read, or nil once discovery has loaded it. A degraded Host keeps serving and retries
+// discovery in the background after Start.
+func (h *Host) Degraded() error {
+	h.degradedMu.RLock()
+	defer h.degradedMu.RUnlock()
+	return h.degraded
+}
+
+// register adds the plugin type and launch details of the manifest in dir to the catalog and watches its directory.
+func (h *Host) register(dir string, m *registry.ManifestEntry) {
+	manifest := m.Manifest()
+	name := manifest.PluginData.Name
+	if registry.AvailablePluginTypesLookup.IsValidPluginType(manifest.PluginData.Type) {
+		h.catalog.AddPlugin(name, registry.AvailablePluginTypes.GetByString(manifest.PluginData.Type))
+	}
+	h.watch(filepath.Join(h.conf.Plugins.Dir, name))
+
+	ld := m.ToLaunchDetails()
+	if ld == nil {
+		if m.Rejected() {
+			h.hostLogger.Error("Plugin not loaded", logger.KeyPluginName, name, "state", m.State().String(),
+				logger.KeyError, m.Err())
+		}
+		return
+	}
+	h.catalog.AddLaunchDetails(ld)
+	h.hostLogger.Info("Plugin loaded", logger.KeyPluginName, name, "dir", dir,
+		"capabilities", manifest.Capabilities)
+}
+
+// watch adds the absolute form of path to the file watcher, logging failures.
+func (h *Host) watch(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		h.hostLogger.Error("Failed to get absolute path", "path", path, logger.KeyError, err)
+		return
+	}
+	if err := h.catalog.AddWatch(abs); err != nil {
+		h.hostLogger.Error("Failed to watch plugin directory", "path", abs, logger.KeyError, err)
+	}
+}
+
+// WithGRPCDialOptions adds dial options used for the connection to gRPC plugins, such as the interceptors that
+// forward call context, and returns the updated Host. It must be called before Start.
+func (h *Host) WithGRPCDialOptions(opts ...grpc.DialOption) *Host {
+	h.manager.WithGRPCDialOptions(opts...)
+	return h
+}
+
+// WithAsyncPluginOutput writes the plugin output collected when logging.collect_plugin_output is set to w as JSON
+// lines, e.g. to a logger.AsyncWriter whose queue is worked by logger.LogQueue, instead of logging it directly, and
+// returns the updated Host. It must be called before Start.
+func (h *Host) WithAsyncPluginOutput(w io.Writer) *Host {
+	if h.conf.Logging.CollectPluginOutput {
+		h.manager.WithOutputCollection(w)
+	}
+	return h
+}
+
+// WithPluginLogFiles routes the records of every plugin's logger to files, a file per plugin, and returns the
+// updated Host. files must be registered as a sink on the intercept logger hclog.Default() logs to, and the Host
+// must not have started a plugin yet.
+func (h *Host) WithPluginLogFiles(files *logger.PluginLogFiles) *Host {
+	h.pluginLogs = files
+	return h
+}
+
+// WithStorage records plugin starts in a compatibility matrix kept in backend, warning before launching a plugin
+// version that has never run against this host version, and returns the updated Host. It must be called before Start;
+// the backend is owned by the caller, who closes it after Shutdown.
+func (h *Host) WithStorage(backend storage.Backend) (*Host, error) {
+	compat, err := registry.NewCompatibilityMatrix(backend, h.conf.General.Version.String(),
+		h.hostLogger.Named("compat"))
+	if err != nil {
+		return nil, err
+	}
+	h.manager.WithCompatibilityMatrix(compat)
+	return h, nil
+}
+
+// Start launches the plugins listed in the config's autostart list, or every loaded plugin when the list is empty,
+// and begins supervising them. The autostart plugins are marked enabled, at their pinned versions, in the catalog's
+// desired states, which the converger keeps them in when enabled. With hot reload enabled, changed plugins are
+// reloaded; otherwise file changes are only logged. Plugins that fail to start are reported together in the
+// returned error, and the rest keep running. A Degraded Host starts without plugins and retries discovery in the
+// background, starting the autostart plugins once the plugins directory has loaded.
+func (h *Host) Start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return ErrHostStarted
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	var errs []error
+	if h.Degraded() != nil {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			// the autostart plugins are started once the plugins directory can be read
+			h.rediscover(ctx)
+		}()
+	} else {
+		errs = h.autostart()
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		// check plugin health, restarting plugins that crash or fail their health checks until they flap
+		h.health.Run(ctx)
+	}()
+	if h.reconcile != nil {
+		h.reconcile.Run()
+	}
+	if err := h.catalog.StartWatching(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("watch plugins: %w", err))
+	}
+	if h.conf.Plugins.Converge.Enabled {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			// start, stop, and reload plugins that drifted from their desired state without an event noticing
+			h.converger.Run(ctx)
+		}()
+	}
+	if h.janitor != nil && h.conf.Janitor.Enabled {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.janitor.Run(ctx, time.Duration(h.conf.Janitor.Interval)*time.Millisecond)
+		}()
+	}
+	return errors.Join(errs...)
+}
+
+// autostart marks the plugins listed in the config's autostart list, or every loaded plugin when the list is empty,
+// enabled in the catalog's desired states and starts them, returning the errors of those that failed to start.
+func (h *Host) autostart() []error {
+	names := h.conf.Plugins.Autostart
+	if len(names) == 0 {
+		for _, ld := range h.catalog.GetLaunchDetails() {
+			names = append(names, ld.Name())
+		}
+	}
+	for _, name := range names {
+		h.catalog.SetDesiredState(name, registry.DesiredState{Enabled: true, Version: h.conf.Plugins.Converge.Pins[name]})
+	}
+	var errs []error
+	for _, name := range names {
+		if err := h.manager.Start(name); err != nil {
+			errs = append(errs, fmt.Errorf("start %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// watchEvents is the catalog's watch function: with hot reload enabled it reloads changed plugins, otherwise it only
+// logs the changes. It runs until ctx is canceled or the watcher is closed.
+func (h *Host) watchEvents(ctx context.Context, fw *fsnotify.Watcher) {
+	if !h.conf.Plugins.HotReload {
+		h.logEvents(ctx, fw)
+		return
+	}
+	err := h.manager.WatchAndReload(ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		h.hostLogger.Error("Plugin hot reload stopped", logger.KeyError, err)
+	}
+}
+
+// logEvents logs plugin file changes until ctx is canceled or the watcher is closed.
+func (h *Host) logEvents(ctx context.Context, fw *fsnotify.Watcher) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			h.hostLogger.Info("Plugin file changed", "file", event.Name, "op", event.Op.String())
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			h.hostLogger.Error("File watcher error", logger.KeyError, err)
+		}
+	}
+}
+
+// Dispense returns the client interface of the named plugin, starting it first if it is not running.
+func (h *Host) Dispense(name string) (any, error) {
+	raw, err := h.manager.Dispense(name)
+	if !errors.Is(err, registry.ErrPluginNotRunning) {
+		return raw, err
+	}
+	if err := h.manager.Start(name); err != nil {
+		return nil, err
+	}
+	return h.manager.Dispense(name)
+}
+
+// Shutdown stops supervision and hot reload, kills every running plugin, and closes the plugin recordings and the
+// file watcher. The Host cannot be started again afterwards.
+func (h *Host) Shutdown() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		h.cancel()
+		h.catalog.StopWatching()
+		h.wg.Wait()
+		if h.reconcile != nil {
+			h.reconcile.Shutdown()
+		}
+	}
+	h.manager.StopAll()
+	if h.recorder != nil {
+		if err := h.recorder.Close(); err != nil {
+			h.hostLogger.Warn("Failed to close plugin recordings", logger.KeyError, err)
+		}
+	}
+	return h.watcher.Close()
+}
+
+// Manager returns the PluginManager, for status queries and lifecycle operations on individual plugins.
+func (h *Host) Manager() *registry.PluginManager {
+	return h.manager
+}
+
+// Catalog returns the PluginCatalog of loaded plugins.
+func (h *Host) Catalog() *registry.PluginCatalog {
+	return h.catalog
+}
+
+// FlapDetector returns the FlapDetector tracking plugin crashes and restarts, for its reports and to be notified when
+// a plugin is demoted.
+func (h *Host) FlapDetector() *registry.FlapDetector {
+	return h.flap
+}
+
+// Janitor returns the Janitor removing stale plugin artifacts, for manual sweeps and its stats, or nil when plugins
+// have no runtime directory.
+func (h *Host) Janitor() *registry.Janitor {
+	return h.janitor
+}
+
+// Converger returns the Converger keeping plugins in their desired state, for manual passes and its stats. Desired
+// states are changed through the catalog.
+func (h *Host) Converger() *registry.Converger {
+	return h.converger
+}
+
+// HealthEvents returns the events of the plugin health checker: failed checks, recoveries, and restarts. Events are
+// dropped when the channel is full. It is closed when a started Host shuts down.
+func (h *Host) HealthEvents() <-chan registry.HealthEvent {
+	return h.health.Events()
+}
+
+// LogLevels returns the registry of plugin client loggers, whose levels can be changed at runtime.
+func (h *Host) LogLevels() *logger.LevelRegistry {
+	return h.levels
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/rpc"
 
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
 	"github.com/bmj2728/PlugsConc/shared/protogen/animal/v1"
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
@@ -28,6 +29,7 @@ type AnimalGRPCPlugin struct {
 
 func (ag *AnimalGRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
 	animalv1.RegisterAnimalServer(s, &GRPCServer{Impl: ag.Impl})
+	lifecycle.RegisterServer(s, ag.Impl)
 	return nil
 }
 
@@ -0,0 +1,58 @@
+// Package apiversion records the version of the shared plugin API: the interfaces, wire messages, and gRPC services
+// in shared/pkg and shared/protogen that the host and its plugins are compiled against. Plugins report the version
+// they linked through the lifecycle Info RPC, and the host refuses to dispense a plugin built against an incompatible
+// version rather than let calls fail with gob or proto decoding errors.
+package apiversion
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the shared plugin API in this tree, as major.minor.patch. The major version changes when
+// a wire message or service changes incompatibly, the minor version when one is added to, and the patch version for
+// fixes that do not change the wire format.
+const Version = "1.1.0"
+
+// ErrIncompatible indicates that a plugin was built against a shared API version the host cannot serve.
+var ErrIncompatible = errors.New("incompatible shared API version")
+
+// ErrMalformed indicates that a shared API version is not major.minor.patch.
+var ErrMalformed = errors.New("malformed shared API version")
+
+// Check returns nil when a host built against host can serve a plugin built against plugin: both have the same major
+// version and the plugin's minor version is not newer than the host's, since a newer plugin may send messages the
+// host does not know. Otherwise it returns ErrIncompatible, telling the plugin author which version to rebuild
+// against, or ErrMalformed if either version does not parse.
+func Check(host, plugin string) error {
+	hostMajor, hostMinor, err := parse(host)
+	if err != nil {
+		return err
+	}
+	pluginMajor, pluginMinor, err := parse(plugin)
+	if err != nil {
+		return err
+	}
+	if pluginMajor != hostMajor || pluginMinor > hostMinor {
+		return fmt.Errorf("%w: plugin was built against v%s, host uses v%s; rebuild your plugin against v%s",
+			ErrIncompatible, plugin, host, host)
+	}
+	return nil
+}
+
+// parse returns the major and minor parts of a major.minor.patch version, with or without a leading v.
+func parse(version string) (major, minor int, err error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrMalformed, version)
+	}
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		if nums[i], err = strconv.Atoi(part); err != nil || nums[i] < 0 {
+			return 0, 0, fmt.Errorf("%w: %q", ErrMalformed, version)
+		}
+	}
+	return nums[0], nums[1], nil
+}
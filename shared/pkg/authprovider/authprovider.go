@@ -0,0 +1,50 @@
+package authprovider
+
+import (
+	"context"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	authproviderv1 "github.com/bmj2728/PlugsConc/shared/protogen/authprovider/v1"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// Request describes a management API request awaiting authentication.
+type Request struct {
+	Token      string
+	Method     string
+	Path       string
+	RemoteAddr string
+}
+
+// Result is an authentication decision. Subject and Scopes identify the authenticated caller when Allowed is true;
+// Reason explains a denial.
+type Result struct {
+	Allowed bool
+	Subject string
+	Scopes  []string
+	Reason  string
+}
+
+// AuthProvider is implemented by plugins that authenticate the host's management API requests, e.g. by validating
+// static tokens or OIDC ID tokens. Authenticate returns an error only when no decision could be made.
+type AuthProvider interface {
+	Authenticate(req Request) (Result, error)
+}
+
+type AuthProviderGRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl AuthProvider
+}
+
+func (a *AuthProviderGRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	authproviderv1.RegisterAuthProviderServer(s, &GRPCServer{Impl: a.Impl})
+	lifecycle.RegisterServer(s, a.Impl)
+	return nil
+}
+
+func (a *AuthProviderGRPCPlugin) GRPCClient(_ context.Context,
+	_ *plugin.GRPCBroker,
+	c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: authproviderv1.NewAuthProviderClient(c)}, nil
+}
@@ -0,0 +1,53 @@
+package authprovider
+
+import (
+	"context"
+
+	authproviderv1 "github.com/bmj2728/PlugsConc/shared/protogen/authprovider/v1"
+)
+
+type GRPCClient struct {
+	client authproviderv1.AuthProviderClient
+}
+
+func (c *GRPCClient) Authenticate(req Request) (Result, error) {
+	resp, err := c.client.Authenticate(context.Background(), &authproviderv1.AuthenticateRequest{
+		Token:      req.Token,
+		Method:     req.Method,
+		Path:       req.Path,
+		RemoteAddr: req.RemoteAddr,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed: resp.GetAllowed(),
+		Subject: resp.GetSubject(),
+		Scopes:  resp.GetScopes(),
+		Reason:  resp.GetReason(),
+	}, nil
+}
+
+type GRPCServer struct {
+	Impl AuthProvider
+	authproviderv1.UnimplementedAuthProviderServer
+}
+
+func (s *GRPCServer) Authenticate(_ context.Context,
+	req *authproviderv1.AuthenticateRequest) (*authproviderv1.AuthenticateResponse, error) {
+	res, err := s.Impl.Authenticate(Request{
+		Token:      req.GetToken(),
+		Method:     req.GetMethod(),
+		Path:       req.GetPath(),
+		RemoteAddr: req.GetRemoteAddr(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &authproviderv1.AuthenticateResponse{
+		Allowed: res.Allowed,
+		Subject: res.Subject,
+		Scopes:  res.Scopes,
+		Reason:  res.Reason,
+	}, nil
+}
@@ -0,0 +1,238 @@
+// Package callctx propagates an allowlisted set of host context values into plugin call metadata.
+// Host contexts carry internals, e.g. loggers, worker state, and credentials, that must not reach third-party plugin
+// processes; only the fields named in an Allowlist are forwarded, and any other outgoing metadata is stripped.
+package callctx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bmj2728/utils/pkg/strutil"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataJobID carries the ID of the job making the plugin call.
+// MetadataTenant carries the tenant the call is made on behalf of.
+// MetadataTraceID carries the trace the call belongs to.
+// MetadataTraceParent carries the W3C traceparent of the OpenTelemetry span the call is made in.
+// MetadataTraceState carries the W3C tracestate accompanying MetadataTraceParent.
+// MetadataRequestID carries the correlation ID of the external request the call is made for.
+const (
+	MetadataJobID       = "plugsconc-job-id"
+	MetadataTenant      = "plugsconc-tenant"
+	MetadataTraceID     = "plugsconc-trace-id"
+	MetadataTraceParent = "traceparent"
+	MetadataTraceState  = "tracestate"
+	MetadataRequestID   = "plugsconc-request-id"
+)
+
+// HeaderRequestID is the HTTP header a request ID is accepted from and returned in.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of a request ID accepted from a client.
+const maxRequestIDLength = 128
+
+// ctxKey is a custom string-based type used as keys for storing and retrieving values in context.
+type ctxKey string
+
+const (
+	// ctxKeyTenant is the context key for storing or retrieving the tenant of a call.
+	ctxKeyTenant = ctxKey(MetadataTenant)
+	// ctxKeyTraceID is the context key for storing or retrieving the trace ID of a call.
+	ctxKeyTraceID = ctxKey(MetadataTraceID)
+	// ctxKeyRequestID is the context key for storing or retrieving the request ID of a call.
+	ctxKeyRequestID = ctxKey(MetadataRequestID)
+)
+
+// WithTenant returns a copy of the parent context with the tenant added as a value.
+func WithTenant(parent context.Context, tenant string) context.Context {
+	return context.WithValue(parent, ctxKeyTenant, tenant)
+}
+
+// TenantFromCtx retrieves the tenant from the context.
+func TenantFromCtx(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ctxKeyTenant).(string)
+	return val, ok && val != ""
+}
+
+// WithTraceID returns a copy of the parent context with the trace ID added as a value.
+func WithTraceID(parent context.Context, traceID string) context.Context {
+	return context.WithValue(parent, ctxKeyTraceID, traceID)
+}
+
+// TraceIDFromCtx retrieves the trace ID from the context.
+func TraceIDFromCtx(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ctxKeyTraceID).(string)
+	return val, ok && val != ""
+}
+
+// NewRequestID returns a new request ID, a time-ordered UUID.
+func NewRequestID() string {
+	return strutil.GenerateUUIDV7()
+}
+
+// ValidRequestID reports whether id, e.g. one presented by a client, is usable as a request ID: non-empty, at most
+// 128 characters, and made of letters, digits, and the characters '-', '_', '.', and ':', so it is safe to log and to
+// echo in headers.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r))
+	}) < 0
+}
+
+// WithRequestID returns a copy of the parent context with the request ID added as a value.
+func WithRequestID(parent context.Context, id string) context.Context {
+	return context.WithValue(parent, ctxKeyRequestID, id)
+}
+
+// RequestIDFromCtx retrieves the request ID from the context.
+func RequestIDFromCtx(ctx context.Context) (string, bool) {
+	val, ok := ctx.Value(ctxKeyRequestID).(string)
+	return val, ok && val != ""
+}
+
+// EnsureRequestID returns ctx and its request ID, adding a new one with NewRequestID when ctx has none. Entry points
+// call it so every request, job, and reconcile can be correlated across log records and plugin calls.
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if id, ok := RequestIDFromCtx(ctx); ok {
+		return ctx, id
+	}
+	id := NewRequestID()
+	return WithRequestID(ctx, id), id
+}
+
+// traceContext encodes span contexts as W3C Trace Context headers.
+var traceContext = propagation.TraceContext{}
+
+// TraceParentFromCtx returns the W3C traceparent of the OpenTelemetry span in the context.
+func TraceParentFromCtx(ctx context.Context) (string, bool) {
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(ctx, carrier)
+	val := carrier.Get(MetadataTraceParent)
+	return val, val != ""
+}
+
+// TraceStateFromCtx returns the W3C tracestate of the OpenTelemetry span in the context.
+func TraceStateFromCtx(ctx context.Context) (string, bool) {
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(ctx, carrier)
+	val := carrier.Get(MetadataTraceState)
+	return val, val != ""
+}
+
+// SpanContextFromIncoming returns ctx carrying, as the remote parent, the OpenTelemetry span context propagated
+// with a plugin's incoming call, so spans the plugin starts from it join the host's trace. Without one ctx is returned
+// unchanged.
+func SpanContextFromIncoming(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, key := range []string{MetadataTraceParent, MetadataTraceState} {
+		if val, ok := FromIncoming(ctx, key); ok {
+			carrier.Set(key, val)
+		}
+	}
+	return traceContext.Extract(ctx, carrier)
+}
+
+// Field maps a context value to the metadata key it is forwarded under.
+type Field struct {
+	Key     string
+	Extract func(ctx context.Context) (string, bool)
+}
+
+// TenantField forwards the tenant set with WithTenant.
+var TenantField = Field{Key: MetadataTenant, Extract: TenantFromCtx}
+
+// TraceIDField forwards the trace ID set with WithTraceID.
+var TraceIDField = Field{Key: MetadataTraceID, Extract: TraceIDFromCtx}
+
+// RequestIDField forwards the request ID set with WithRequestID.
+var RequestIDField = Field{Key: MetadataRequestID, Extract: RequestIDFromCtx}
+
+// TraceParentField forwards the W3C traceparent of the OpenTelemetry span the call is made in.
+var TraceParentField = Field{Key: MetadataTraceParent, Extract: TraceParentFromCtx}
+
+// TraceStateField forwards the W3C tracestate of the OpenTelemetry span the call is made in.
+var TraceStateField = Field{Key: MetadataTraceState, Extract: TraceStateFromCtx}
+
+// Allowlist is the set of context values forwarded to plugins.
+type Allowlist []Field
+
+// Metadata returns the allowlisted values present in ctx as gRPC metadata.
+func (a Allowlist) Metadata(ctx context.Context) metadata.MD {
+	md := metadata.MD{}
+	for _, f := range a {
+		if f.Extract == nil {
+			continue
+		}
+		if val, ok := f.Extract(ctx); ok {
+			md.Set(f.Key, val)
+		}
+	}
+	return md
+}
+
+// Outgoing returns a context for a plugin call that keeps ctx's cancellation and deadline but none of its values,
+// carrying only the allowlisted values as outgoing metadata. The returned cancel function must be called when the
+// call completes.
+func (a Allowlist) Outgoing(ctx context.Context) (context.Context, context.CancelFunc) {
+	stripped, cancel := context.WithCancelCause(context.Background())
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		stripped, cancelDeadline = context.WithDeadline(stripped, deadline)
+		prev := cancel
+		cancel = func(cause error) {
+			cancelDeadline()
+			prev(cause)
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	out := metadata.NewOutgoingContext(stripped, a.Metadata(ctx))
+	return out, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// UnaryClientInterceptor replaces the outgoing metadata of every unary plugin call with the allowlisted values
+// from the call's context, so nothing else set by host code reaches the plugin.
+func (a Allowlist) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption) error {
+		return invoker(metadata.NewOutgoingContext(ctx, a.Metadata(ctx)), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor.
+func (a Allowlist) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.NewOutgoingContext(ctx, a.Metadata(ctx)), desc, cc, method, opts...)
+	}
+}
+
+// DialOptions returns the gRPC dial options that install the allowlist's interceptors, suitable for
+// plugin.ClientConfig.GRPCDialOptions.
+func (a Allowlist) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(a.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(a.StreamClientInterceptor()),
+	}
+}
+
+// FromIncoming returns the propagated value for key in a plugin's incoming call context.
+func FromIncoming(ctx context.Context, key string) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	vals := md.Get(key)
+	if len(vals) == 0 {
+		return "", false
+	}
+	return vals[0], true
+}
@@ -3,6 +3,7 @@ package filelister
 import (
 	"context"
 
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
 	filelisterv1 "github.com/bmj2728/PlugsConc/shared/protogen/filelister/v1"
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
@@ -23,6 +24,7 @@ func (f *FileListerGRPCPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Ser
 			Impl:   f.Impl,
 			broker: broker,
 		})
+	lifecycle.RegisterServer(s, f.Impl)
 	return nil
 }
 
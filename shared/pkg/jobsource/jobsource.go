@@ -0,0 +1,45 @@
+package jobsource
+
+import (
+	"context"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	jobsourcev1 "github.com/bmj2728/PlugsConc/shared/protogen/jobsource/v1"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// Request asks the host to run a job of a registered type. Payload is encoded with the job type's codec and
+// Encoding names that codec, e.g. "json" or "proto".
+type Request struct {
+	Type       string
+	Encoding   string
+	Payload    []byte
+	MaxRetries int
+	RetryDelay int // milliseconds
+}
+
+// JobSource is implemented by plugins that produce work for the host's worker pool. Stream is called once by the
+// host with the job types the plugin may request and should call send for each job until ctx is canceled or the
+// source is exhausted. send blocks while the host applies backpressure and returns an error once the host stops
+// accepting jobs, at which point Stream should return.
+type JobSource interface {
+	Stream(ctx context.Context, jobTypes []string, send func(Request) error) error
+}
+
+type JobSourceGRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl JobSource
+}
+
+func (j *JobSourceGRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	jobsourcev1.RegisterJobSourceServer(s, &GRPCServer{Impl: j.Impl})
+	lifecycle.RegisterServer(s, j.Impl)
+	return nil
+}
+
+func (j *JobSourceGRPCPlugin) GRPCClient(_ context.Context,
+	_ *plugin.GRPCBroker,
+	c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: jobsourcev1.NewJobSourceClient(c)}, nil
+}
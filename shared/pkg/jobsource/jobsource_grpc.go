@@ -0,0 +1,58 @@
+package jobsource
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	jobsourcev1 "github.com/bmj2728/PlugsConc/shared/protogen/jobsource/v1"
+	"google.golang.org/grpc"
+)
+
+type GRPCClient struct {
+	client jobsourcev1.JobSourceClient
+}
+
+func (c *GRPCClient) Stream(ctx context.Context, jobTypes []string, send func(Request) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.client.Stream(ctx, &jobsourcev1.StreamRequest{JobTypes: jobTypes})
+	if err != nil {
+		return err
+	}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := send(Request{
+			Type:       req.GetJobType(),
+			Encoding:   req.GetEncoding(),
+			Payload:    req.GetPayload(),
+			MaxRetries: int(req.GetMaxRetries()),
+			RetryDelay: int(req.GetRetryDelayMs()),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+type GRPCServer struct {
+	Impl JobSource
+	jobsourcev1.UnimplementedJobSourceServer
+}
+
+func (s *GRPCServer) Stream(req *jobsourcev1.StreamRequest, stream grpc.ServerStreamingServer[jobsourcev1.JobRequest]) error {
+	return s.Impl.Stream(stream.Context(), req.GetJobTypes(), func(r Request) error {
+		return stream.Send(&jobsourcev1.JobRequest{
+			JobType:      r.Type,
+			Encoding:     r.Encoding,
+			Payload:      r.Payload,
+			MaxRetries:   int32(r.MaxRetries),
+			RetryDelayMs: int32(r.RetryDelay),
+		})
+	})
+}
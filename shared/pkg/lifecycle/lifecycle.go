@@ -0,0 +1,293 @@
+// Package lifecycle provides the optional lifecycle hooks shared by every gRPC plugin type. A plugin implementation
+// that also implements Warmer is warmed up by the host after the handshake and before it is marked running, and one
+// that implements Informer describes itself to the host's pre-deployment checks. Every plugin reports the shared API
+// version it was built against through the Info RPC, which the host checks before dispensing it, and enumerates the
+// gRPC services it serves through the Describe RPC, which lets the host call plugin types it does not know
+// statically.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/apiversion"
+	lifecyclev1 "github.com/bmj2728/PlugsConc/shared/protogen/lifecycle/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ErrNoInfo indicates that a plugin does not serve the Info RPC, i.e. it was built before the shared API was
+// versioned.
+var ErrNoInfo = errors.New("plugin does not provide info")
+
+// ErrNoDescription indicates that a plugin does not serve the Describe RPC, i.e. it was built against a shared API
+// older than v1.1.0.
+var ErrNoDescription = errors.New("plugin does not describe its services")
+
+// internalServices are the prefixes of the services go-plugin and grpc-go register on every plugin server, which
+// Describe leaves out: they are the plugin's transport, not its API, and calling them can shut the plugin down.
+var internalServices = []string{"plugin.", "grpc."}
+
+// Warmer is implemented by plugins that need to load models, fill caches, or open connections before serving, so
+// that their first real call is not slow. Warmup should return once the plugin is ready or ctx is done.
+type Warmer interface {
+	Warmup(ctx context.Context, pluginName string) error
+}
+
+// Info is a plugin's description of itself. APIVersion, the shared API version the plugin was built against, is
+// filled in by the lifecycle server; the other fields are empty unless the plugin implements Informer.
+type Info struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	APIVersion  string            `json:"api_version,omitempty"`
+}
+
+// Informer is implemented by plugins that describe themselves, e.g. with the version they were built as, so the
+// host can check what it launched.
+type Informer interface {
+	Info(ctx context.Context) (Info, error)
+}
+
+// Description is the list of gRPC services a plugin serves, as reported by its Describe RPC, sorted by name.
+// Files resolves the services and their messages, so requests and responses can be built without the plugin's
+// generated code; it is nil unless the Description was returned by Describe.
+type Description struct {
+	Services []Service            `json:"services"`
+	Files    *protoregistry.Files `json:"-"`
+}
+
+// Service is a gRPC service a plugin serves, by its full name, e.g. animal.v1.Animal.
+type Service struct {
+	Name    string   `json:"name"`
+	Methods []Method `json:"methods"`
+}
+
+// Method is a method of a Service, with the full names of its request and response messages. Their names are empty
+// when the plugin has not registered the service's proto file.
+type Method struct {
+	Name            string `json:"name"`
+	InputType       string `json:"input_type,omitempty"`
+	OutputType      string `json:"output_type,omitempty"`
+	ClientStreaming bool   `json:"client_streaming,omitempty"`
+	ServerStreaming bool   `json:"server_streaming,omitempty"`
+}
+
+// Method returns the named method, given as Service/Method with the service's full name, e.g.
+// animal.v1.Animal/Speak, or as a bare method name when exactly one service has a method of that name.
+func (d Description) Method(name string) (Service, Method, bool) {
+	serviceName, methodName, qualified := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+	if !qualified {
+		serviceName, methodName = "", name
+	}
+	var found []Service
+	var method Method
+	for _, svc := range d.Services {
+		if qualified && svc.Name != serviceName {
+			continue
+		}
+		for _, m := range svc.Methods {
+			if m.Name == methodName {
+				found, method = append(found, svc), m
+			}
+		}
+	}
+	if len(found) != 1 {
+		return Service{}, Method{}, false
+	}
+	return found[0], method, true
+}
+
+// RegisterServer registers the lifecycle service on s, serving the hooks impl implements and reporting the shared
+// API version. The plugin types in shared/pkg call it from their GRPCServer, so plugin authors only need to implement
+// the hooks. A plugin binary serving several plugin types registers the service once, for the first of them.
+func RegisterServer(s *grpc.Server, impl any) {
+	if _, ok := s.GetServiceInfo()[lifecyclev1.Lifecycle_ServiceDesc.ServiceName]; ok {
+		return
+	}
+	w, _ := impl.(Warmer)
+	i, _ := impl.(Informer)
+	lifecyclev1.RegisterLifecycleServer(s, &GRPCServer{Warmer: w, Informer: i, Server: s})
+}
+
+// Warmup calls the plugin's warm-up hook over conn. Plugins that do not implement the hook are treated as warm.
+func Warmup(ctx context.Context, conn grpc.ClientConnInterface, pluginName string) error {
+	_, err := lifecyclev1.NewLifecycleClient(conn).Warmup(ctx, &lifecyclev1.WarmupRequest{PluginName: pluginName})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	return err
+}
+
+// QueryInfo asks the plugin over conn to describe itself, failing with ErrNoInfo when it does not serve the Info RPC.
+func QueryInfo(ctx context.Context, conn grpc.ClientConnInterface) (Info, error) {
+	resp, err := lifecyclev1.NewLifecycleClient(conn).Info(ctx, &lifecyclev1.InfoRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return Info{}, ErrNoInfo
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{
+		Name:        resp.GetName(),
+		Version:     resp.GetVersion(),
+		Description: resp.GetDescription(),
+		Metadata:    resp.GetMetadata(),
+		APIVersion:  resp.GetApiVersion(),
+	}, nil
+}
+
+// Describe asks the plugin over conn for the services it serves, failing with ErrNoDescription when it does not
+// serve the Describe RPC.
+func Describe(ctx context.Context, conn grpc.ClientConnInterface) (Description, error) {
+	resp, err := lifecyclev1.NewLifecycleClient(conn).Describe(ctx, &lifecyclev1.DescribeRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return Description{}, ErrNoDescription
+	}
+	if err != nil {
+		return Description{}, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, data := range resp.GetFiles() {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, file); err != nil {
+			return Description{}, fmt.Errorf("decode plugin file descriptor: %w", err)
+		}
+		set.File = append(set.File, file)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return Description{}, fmt.Errorf("resolve plugin file descriptors: %w", err)
+	}
+	desc := Description{Services: make([]Service, 0, len(resp.GetServices())), Files: files}
+	for _, svc := range resp.GetServices() {
+		service := Service{Name: svc.GetName(), Methods: make([]Method, 0, len(svc.GetMethods()))}
+		for _, m := range svc.GetMethods() {
+			service.Methods = append(service.Methods, Method{
+				Name:            m.GetName(),
+				InputType:       m.GetInputType(),
+				OutputType:      m.GetOutputType(),
+				ClientStreaming: m.GetClientStreaming(),
+				ServerStreaming: m.GetServerStreaming(),
+			})
+		}
+		desc.Services = append(desc.Services, service)
+	}
+	return desc, nil
+}
+
+type GRPCServer struct {
+	Warmer   Warmer
+	Informer Informer
+	Server   *grpc.Server // the plugin's server, whose services Describe enumerates
+	lifecyclev1.UnimplementedLifecycleServer
+}
+
+func (s *GRPCServer) Warmup(ctx context.Context, req *lifecyclev1.WarmupRequest) (*lifecyclev1.WarmupResponse, error) {
+	if s.Warmer == nil {
+		return s.UnimplementedLifecycleServer.Warmup(ctx, req)
+	}
+	if err := s.Warmer.Warmup(ctx, req.GetPluginName()); err != nil {
+		return nil, err
+	}
+	return &lifecyclev1.WarmupResponse{}, nil
+}
+
+func (s *GRPCServer) Info(ctx context.Context, req *lifecyclev1.InfoRequest) (*lifecyclev1.InfoResponse, error) {
+	if s.Informer == nil {
+		return &lifecyclev1.InfoResponse{ApiVersion: apiversion.Version}, nil
+	}
+	info, err := s.Informer.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &lifecyclev1.InfoResponse{
+		Name:        info.Name,
+		Version:     info.Version,
+		Description: info.Description,
+		Metadata:    info.Metadata,
+		ApiVersion:  apiversion.Version,
+	}, nil
+}
+
+func (s *GRPCServer) Describe(ctx context.Context, req *lifecyclev1.DescribeRequest) (*lifecyclev1.DescribeResponse,
+	error) {
+	if s.Server == nil {
+		return s.UnimplementedLifecycleServer.Describe(ctx, req)
+	}
+	infos := s.Server.GetServiceInfo()
+	names := make([]string, 0, len(infos))
+	for name := range infos {
+		if !slices.ContainsFunc(internalServices, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	resp := &lifecyclev1.DescribeResponse{}
+	files := fileSet{seen: make(map[string]bool)}
+	for _, name := range names {
+		svc := &lifecyclev1.Service{Name: name}
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if err != nil || !ok {
+			// without the service's proto file only the method names are known
+			for _, m := range infos[name].Methods {
+				svc.Methods = append(svc.Methods, &lifecyclev1.Method{Name: m.Name,
+					ClientStreaming: m.IsClientStream, ServerStreaming: m.IsServerStream})
+			}
+			resp.Services = append(resp.Services, svc)
+			continue
+		}
+		for i := range sd.Methods().Len() {
+			m := sd.Methods().Get(i)
+			svc.Methods = append(svc.Methods, &lifecyclev1.Method{
+				Name:            string(m.Name()),
+				InputType:       string(m.Input().FullName()),
+				OutputType:      string(m.Output().FullName()),
+				ClientStreaming: m.IsStreamingClient(),
+				ServerStreaming: m.IsStreamingServer(),
+			})
+		}
+		resp.Services = append(resp.Services, svc)
+		if err := files.add(sd.ParentFile()); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	resp.Files = files.data
+	return resp, nil
+}
+
+// fileSet collects serialized file descriptors, each after the files it imports.
+type fileSet struct {
+	seen map[string]bool
+	data [][]byte
+}
+
+// add adds file and, ahead of it, the files it imports that are not in the set yet.
+func (fs *fileSet) add(file protoreflect.FileDescriptor) error {
+	if fs.seen[file.Path()] {
+		return nil
+	}
+	fs.seen[file.Path()] = true
+	for i := range file.Imports().Len() {
+		if err := fs.add(file.Imports().Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	data, err := proto.Marshal(protodesc.ToFileDescriptorProto(file))
+	if err != nil {
+		return fmt.Errorf("encode file descriptor %s: %w", file.Path(), err)
+	}
+	fs.data = append(fs.data, data)
+	return nil
+}
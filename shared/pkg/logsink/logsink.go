@@ -0,0 +1,47 @@
+package logsink
+
+import (
+	"context"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	logsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/logsink/v1"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// Record is a single log record shipped from the host to a log sink plugin. Attrs holds every attribute rendered as
+// a string; Fields holds the same attributes with their JSON types, as nil, bool, float64, string, []any, or
+// map[string]any values. Plugins built before Fields existed only receive Attrs.
+type Record struct {
+	Time    time.Time
+	Level   string
+	Logger  string
+	Message string
+	Attrs   map[string]string
+	Fields  map[string]any
+}
+
+// LogSink is implemented by plugins that ship the host's log records to an external system such as Loki,
+// Elasticsearch, or S3. Write receives records in batches and should return an error only when the batch could not
+// be shipped.
+type LogSink interface {
+	Write(records []Record) error
+}
+
+type LogSinkGRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl LogSink
+}
+
+func (l *LogSinkGRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	logsinkv1.RegisterLogSinkServer(s, &GRPCServer{Impl: l.Impl})
+	lifecycle.RegisterServer(s, l.Impl)
+	return nil
+}
+
+func (l *LogSinkGRPCPlugin) GRPCClient(_ context.Context,
+	_ *plugin.GRPCBroker,
+	c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: logsinkv1.NewLogSinkClient(c)}, nil
+}
@@ -0,0 +1,81 @@
+package logsink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	logsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/logsink/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type GRPCClient struct {
+	client logsinkv1.LogSinkClient
+}
+
+func (c *GRPCClient) Write(records []Record) error {
+	req := &logsinkv1.WriteRequest{Records: make([]*logsinkv1.LogRecord, 0, len(records))}
+	for _, r := range records {
+		req.Records = append(req.Records, &logsinkv1.LogRecord{
+			TimeUnixNano: r.Time.UnixNano(),
+			Level:        r.Level,
+			Logger:       r.Logger,
+			Message:      r.Message,
+			Attrs:        r.Attrs,
+			Fields:       toValues(r.Fields),
+		})
+	}
+	_, err := c.client.Write(context.Background(), req)
+	return err
+}
+
+type GRPCServer struct {
+	Impl LogSink
+	logsinkv1.UnimplementedLogSinkServer
+}
+
+func (s *GRPCServer) Write(_ context.Context, req *logsinkv1.WriteRequest) (*logsinkv1.WriteResponse, error) {
+	records := make([]Record, 0, len(req.GetRecords()))
+	for _, r := range req.GetRecords() {
+		records = append(records, Record{
+			Time:    time.Unix(0, r.GetTimeUnixNano()).UTC(),
+			Level:   r.GetLevel(),
+			Logger:  r.GetLogger(),
+			Message: r.GetMessage(),
+			Attrs:   r.GetAttrs(),
+			Fields:  fromValues(r.GetFields()),
+		})
+	}
+	if err := s.Impl.Write(records); err != nil {
+		return nil, err
+	}
+	return &logsinkv1.WriteResponse{Accepted: uint32(len(records))}, nil
+}
+
+// toValues converts record fields to protobuf values. Values without a JSON representation are sent as strings.
+func toValues(fields map[string]any) map[string]*structpb.Value {
+	if len(fields) == 0 {
+		return nil
+	}
+	values := make(map[string]*structpb.Value, len(fields))
+	for k, v := range fields {
+		value, err := structpb.NewValue(v)
+		if err != nil {
+			value = structpb.NewStringValue(fmt.Sprint(v))
+		}
+		values[k] = value
+	}
+	return values
+}
+
+// fromValues converts protobuf values back to record fields.
+func fromValues(values map[string]*structpb.Value) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	fields := make(map[string]any, len(values))
+	for k, v := range values {
+		fields[k] = v.AsInterface()
+	}
+	return fields
+}
@@ -0,0 +1,86 @@
+package metricsink
+
+import (
+	"context"
+	"time"
+
+	"github.com/bmj2728/PlugsConc/shared/pkg/lifecycle"
+	metricsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/metricsink/v1"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// PoolMetrics summarizes the host's worker pool.
+type PoolMetrics struct {
+	Workers           int
+	QueuedJobs        int
+	RunningJobs       int
+	JobsSubmitted     int
+	FailedSubmissions int
+	SuccessfulJobs    int
+	FailedJobs        int
+	TimedOutJobs      int
+}
+
+// PluginMetrics summarizes the lifecycle of one managed plugin. Quarantined is set once the plugin kept crashing
+// after its automatic restarts and is no longer restarted.
+type PluginMetrics struct {
+	Name        string
+	State       string
+	Restarts    int
+	Crashes     int
+	Quarantined bool
+}
+
+// HostMetrics summarizes the host's Go runtime. Used is the memory the runtime holds from the operating system,
+// compared against SoftLimit, the host's memory budget, or zero when none is configured. GCPauseRatio is the share of
+// the last sampling interval spent paused for garbage collection. Throttled is set while the host limits how many
+// jobs run at once because Used is above SoftLimit.
+type HostMetrics struct {
+	Goroutines    int
+	HeapAlloc     uint64
+	HeapInuse     uint64
+	HeapSys       uint64
+	Sys           uint64
+	Used          uint64
+	NextGC        uint64
+	NumGC         uint32
+	GCPauseTotal  time.Duration
+	LastGCPause   time.Duration
+	GCPauseRatio  float64
+	GCCPUFraction float64
+	SoftLimit     uint64
+	Throttled     bool
+}
+
+// Snapshot is a point-in-time view of the host's pool, plugin, and runtime metrics.
+type Snapshot struct {
+	Time    time.Time
+	Host    string
+	Pool    PoolMetrics
+	Plugins []PluginMetrics
+	Runtime HostMetrics
+}
+
+// MetricSink is implemented by plugins that export the host's metrics to a monitoring stack. Export is called
+// periodically with the latest snapshot and should return an error only when the snapshot could not be exported.
+type MetricSink interface {
+	Export(snapshot Snapshot) error
+}
+
+type MetricSinkGRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl MetricSink
+}
+
+func (m *MetricSinkGRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	metricsinkv1.RegisterMetricSinkServer(s, &GRPCServer{Impl: m.Impl})
+	lifecycle.RegisterServer(s, m.Impl)
+	return nil
+}
+
+func (m *MetricSinkGRPCPlugin) GRPCClient(_ context.Context,
+	_ *plugin.GRPCBroker,
+	c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: metricsinkv1.NewMetricSinkClient(c)}, nil
+}
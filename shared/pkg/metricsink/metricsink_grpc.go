@@ -0,0 +1,112 @@
+package metricsink
+
+import (
+	"context"
+	"time"
+
+	metricsinkv1 "github.com/bmj2728/PlugsConc/shared/protogen/metricsink/v1"
+)
+
+type GRPCClient struct {
+	client metricsinkv1.MetricSinkClient
+}
+
+func (c *GRPCClient) Export(snapshot Snapshot) error {
+	snap := &metricsinkv1.MetricsSnapshot{
+		TimeUnixNano: snapshot.Time.UnixNano(),
+		Host:         snapshot.Host,
+		Pool: &metricsinkv1.PoolMetrics{
+			Workers:           int32(snapshot.Pool.Workers),
+			QueuedJobs:        int32(snapshot.Pool.QueuedJobs),
+			RunningJobs:       int32(snapshot.Pool.RunningJobs),
+			JobsSubmitted:     int64(snapshot.Pool.JobsSubmitted),
+			FailedSubmissions: int64(snapshot.Pool.FailedSubmissions),
+			SuccessfulJobs:    int64(snapshot.Pool.SuccessfulJobs),
+			FailedJobs:        int64(snapshot.Pool.FailedJobs),
+			TimedOutJobs:      int64(snapshot.Pool.TimedOutJobs),
+		},
+		Plugins: make([]*metricsinkv1.PluginMetrics, 0, len(snapshot.Plugins)),
+		Runtime: &metricsinkv1.HostMetrics{
+			Goroutines:        int32(snapshot.Runtime.Goroutines),
+			HeapAllocBytes:    snapshot.Runtime.HeapAlloc,
+			HeapInuseBytes:    snapshot.Runtime.HeapInuse,
+			NumGc:             snapshot.Runtime.NumGC,
+			HeapSysBytes:      snapshot.Runtime.HeapSys,
+			SysBytes:          snapshot.Runtime.Sys,
+			UsedBytes:         snapshot.Runtime.Used,
+			NextGcBytes:       snapshot.Runtime.NextGC,
+			GcPauseTotalNanos: snapshot.Runtime.GCPauseTotal.Nanoseconds(),
+			LastGcPauseNanos:  snapshot.Runtime.LastGCPause.Nanoseconds(),
+			GcPauseRatio:      snapshot.Runtime.GCPauseRatio,
+			GcCpuFraction:     snapshot.Runtime.GCCPUFraction,
+			SoftLimitBytes:    snapshot.Runtime.SoftLimit,
+			Throttled:         snapshot.Runtime.Throttled,
+		},
+	}
+	for _, p := range snapshot.Plugins {
+		snap.Plugins = append(snap.Plugins, &metricsinkv1.PluginMetrics{
+			Name:        p.Name,
+			State:       p.State,
+			Restarts:    int32(p.Restarts),
+			Crashes:     int32(p.Crashes),
+			Quarantined: p.Quarantined,
+		})
+	}
+	_, err := c.client.Export(context.Background(), &metricsinkv1.ExportRequest{Snapshot: snap})
+	return err
+}
+
+type GRPCServer struct {
+	Impl MetricSink
+	metricsinkv1.UnimplementedMetricSinkServer
+}
+
+func (s *GRPCServer) Export(_ context.Context, req *metricsinkv1.ExportRequest) (*metricsinkv1.ExportResponse, error) {
+	snap := req.GetSnapshot()
+	pool := snap.GetPool()
+	rt := snap.GetRuntime()
+	snapshot := Snapshot{
+		Time: time.Unix(0, snap.GetTimeUnixNano()),
+		Host: snap.GetHost(),
+		Pool: PoolMetrics{
+			Workers:           int(pool.GetWorkers()),
+			QueuedJobs:        int(pool.GetQueuedJobs()),
+			RunningJobs:       int(pool.GetRunningJobs()),
+			JobsSubmitted:     int(pool.GetJobsSubmitted()),
+			FailedSubmissions: int(pool.GetFailedSubmissions()),
+			SuccessfulJobs:    int(pool.GetSuccessfulJobs()),
+			FailedJobs:        int(pool.GetFailedJobs()),
+			TimedOutJobs:      int(pool.GetTimedOutJobs()),
+		},
+		Plugins: make([]PluginMetrics, 0, len(snap.GetPlugins())),
+		Runtime: HostMetrics{
+			Goroutines:    int(rt.GetGoroutines()),
+			HeapAlloc:     rt.GetHeapAllocBytes(),
+			HeapInuse:     rt.GetHeapInuseBytes(),
+			HeapSys:       rt.GetHeapSysBytes(),
+			Sys:           rt.GetSysBytes(),
+			Used:          rt.GetUsedBytes(),
+			NextGC:        rt.GetNextGcBytes(),
+			NumGC:         rt.GetNumGc(),
+			GCPauseTotal:  time.Duration(rt.GetGcPauseTotalNanos()),
+			LastGCPause:   time.Duration(rt.GetLastGcPauseNanos()),
+			GCPauseRatio:  rt.GetGcPauseRatio(),
+			GCCPUFraction: rt.GetGcCpuFraction(),
+			SoftLimit:     rt.GetSoftLimitBytes(),
+			Throttled:     rt.GetThrottled(),
+		},
+	}
+	for _, p := range snap.GetPlugins() {
+		snapshot.Plugins = append(snapshot.Plugins, PluginMetrics{
+			Name:        p.GetName(),
+			State:       p.GetState(),
+			Restarts:    int(p.GetRestarts()),
+			Crashes:     int(p.GetCrashes()),
+			Quarantined: p.GetQuarantined(),
+		})
+	}
+	if err := s.Impl.Export(snapshot); err != nil {
+		return nil, err
+	}
+	return &metricsinkv1.ExportResponse{}, nil
+}
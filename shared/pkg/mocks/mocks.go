@@ -0,0 +1,149 @@
+// Package mocks provides in-memory fakes of the shared plugin interfaces. Registered into a catalog, a fake is served
+// in-process over the same go-plugin transport as a real plugin, so host features can be tested without building
+// plugin binaries. Every fake records the calls it receives and is safe for concurrent use.
+package mocks
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/bmj2728/PlugsConc/internal/registry"
+	"github.com/bmj2728/PlugsConc/shared/pkg/animal"
+	"github.com/bmj2728/PlugsConc/shared/pkg/filelister"
+	"github.com/bmj2728/PlugsConc/shared/pkg/logsink"
+	"github.com/bmj2728/PlugsConc/shared/pkg/metricsink"
+	"github.com/hashicorp/go-plugin"
+)
+
+// Mock is a fake that can be served as a plugin.
+type Mock interface {
+	// Plugin returns the go-plugin plugin type serving the fake.
+	Plugin() plugin.Plugin
+}
+
+// Register adds mock to catalog as an in-process plugin of the given name.
+func Register(catalog *registry.PluginCatalog, name string, mock Mock) {
+	catalog.AddInProcess(name, mock.Plugin())
+}
+
+// Animal is a fake animal.Animal that answers Speak with Sound, or LoudSound when asked to be loud.
+type Animal struct {
+	mu        sync.Mutex
+	Sound     string
+	LoudSound string
+	calls     []bool
+}
+
+// Speak records the call and returns the configured sound.
+func (a *Animal) Speak(isLoud bool) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, isLoud)
+	if isLoud {
+		return a.LoudSound
+	}
+	return a.Sound
+}
+
+// Calls returns the isLoud argument of every Speak call so far.
+func (a *Animal) Calls() []bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.calls)
+}
+
+// Plugin serves the fake over gRPC.
+func (a *Animal) Plugin() plugin.Plugin {
+	return &animal.AnimalGRPCPlugin{Impl: a}
+}
+
+// FileLister is a fake filelister.FileLister that lists the entries of Files for a path, or fails with Err.
+type FileLister struct {
+	mu    sync.Mutex
+	Files map[string][]string
+	Err   error
+	calls []string
+}
+
+// List records the call and returns the configured entries of path.
+func (f *FileLister) List(path string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, path)
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return slices.Clone(f.Files[path]), nil
+}
+
+// Calls returns the path of every List call so far.
+func (f *FileLister) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Plugin serves the fake over gRPC.
+func (f *FileLister) Plugin() plugin.Plugin {
+	return &filelister.FileListerGRPCPlugin{Impl: f}
+}
+
+// LogSink is a fake logsink.LogSink that keeps the records it is written, or fails with Err.
+type LogSink struct {
+	mu      sync.Mutex
+	Err     error
+	records []logsink.Record
+}
+
+// Write keeps the records unless the fake is set to fail.
+func (l *LogSink) Write(records []logsink.Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Err != nil {
+		return l.Err
+	}
+	l.records = append(l.records, records...)
+	return nil
+}
+
+// Records returns every record written so far.
+func (l *LogSink) Records() []logsink.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.records)
+}
+
+// Plugin serves the fake over gRPC.
+func (l *LogSink) Plugin() plugin.Plugin {
+	return &logsink.LogSinkGRPCPlugin{Impl: l}
+}
+
+// MetricSink is a fake metricsink.MetricSink that keeps the snapshots it is sent, or fails with Err.
+type MetricSink struct {
+	mu        sync.Mutex
+	Err       error
+	snapshots []metricsink.Snapshot
+}
+
+// Export keeps the snapshot unless the fake is set to fail.
+func (m *MetricSink) Export(snapshot metricsink.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.snapshots = append(m.snapshots, snapshot)
+	return nil
+}
+
+// Snapshots returns every snapshot exported so far.
+func (m *MetricSink) Snapshots() []metricsink.Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.snapshots)
+}
+
+// Plugin serves the fake over gRPC.
+func (m *MetricSink) Plugin() plugin.Plugin {
+	return &metricsink.MetricSinkGRPCPlugin{Impl: m}
+}
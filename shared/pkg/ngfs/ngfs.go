@@ -7,9 +7,12 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/bmj2728/PlugsConc/internal/capability"
 	"github.com/bmj2728/PlugsConc/internal/logger"
 	filesystemv1 "github.com/bmj2728/PlugsConc/shared/protogen/filesystem/v1"
 	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -45,6 +48,7 @@ import (
 type NGFS struct {
 	filesystemv1.UnimplementedFileSystemServer
 	fsLogger hclog.Logger
+	guard    *capability.FilesystemGuard // nil serves every request
 }
 
 func NewNGFS() *NGFS {
@@ -53,7 +57,42 @@ func NewNGFS() *NGFS {
 	}
 }
 
+// NewGuardedNGFS returns an NGFS that serves a plugin only the paths and permissions granted by its declared
+// filesystem capabilities, rejecting every other request with codes.PermissionDenied.
+func NewGuardedNGFS(caps []capability.FileSystemCapability) (*NGFS, error) {
+	guard, err := capability.NewFilesystemGuard(caps)
+	if err != nil {
+		return nil, err
+	}
+	n := NewNGFS()
+	n.guard = guard
+	return n, nil
+}
+
+// WithLogger logs through fsLogger, e.g. one carrying the name of the plugin served, and returns the updated NGFS.
+func (N *NGFS) WithLogger(fsLogger hclog.Logger) *NGFS {
+	if fsLogger != nil {
+		N.fsLogger = fsLogger
+	}
+	return N
+}
+
+// authorize consults the guard, if any, before a request for perm on path is served.
+func (N *NGFS) authorize(path string, perm string) error {
+	if N.guard == nil {
+		return nil
+	}
+	if err := N.guard.Check(path, perm); err != nil {
+		N.fsLogger.Warn("Filesystem request denied", "path", path, "permission", perm)
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
 func (N *NGFS) ReadDir(ctx context.Context, request *filesystemv1.ReadDirRequest) (*filesystemv1.ReadDirResponse, error) {
+	if err := N.authorize(request.Path, capability.PermList); err != nil {
+		return nil, err
+	}
 	r, err := os.OpenRoot(request.Path)
 	if err != nil {
 		N.fsLogger.Error("Failed to open root", logger.KeyError, err)
@@ -83,6 +122,9 @@ func (N *NGFS) ReadDir(ctx context.Context, request *filesystemv1.ReadDirRequest
 }
 
 func (N *NGFS) Stat(ctx context.Context, request *filesystemv1.StatRequest) (*filesystemv1.StatResponse, error) {
+	if err := N.authorize(request.Path, capability.PermRead); err != nil {
+		return nil, err
+	}
 	base, file := filepath.Split(request.Path)
 	r, err := os.OpenRoot(base)
 	if err != nil {
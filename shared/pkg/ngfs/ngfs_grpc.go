@@ -1 +1,32 @@
 package ngfs
+
+import (
+	filesystemv1 "github.com/bmj2728/PlugsConc/shared/protogen/filesystem/v1"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+)
+
+// BrokerID is the go-plugin broker ID under which the host serves a plugin its filesystem service, "ngfs" in ASCII,
+// far above the IDs the broker's NextId hands out.
+const BrokerID uint32 = 0x6e676673
+
+// ServeBroker serves fs to the plugin on the other end of broker under BrokerID in the background, until the plugin
+// exits.
+func ServeBroker(broker *plugin.GRPCBroker, fs *NGFS) {
+	go broker.AcceptAndServe(BrokerID, func(opts []grpc.ServerOption) *grpc.Server {
+		s := grpc.NewServer(opts...)
+		filesystemv1.RegisterFileSystemServer(s, fs)
+		return s
+	})
+}
+
+// DialBroker connects a plugin to the filesystem service the host serves it over broker, the broker its GRPCServer
+// was given. The service is served once the host has dispensed the plugin, so call it while handling a request
+// rather than at startup.
+func DialBroker(broker *plugin.GRPCBroker) (filesystemv1.FileSystemClient, error) {
+	conn, err := broker.Dial(BrokerID)
+	if err != nil {
+		return nil, err
+	}
+	return filesystemv1.NewFileSystemClient(conn), nil
+}
@@ -0,0 +1,137 @@
+package ngnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	networkv1 "github.com/bmj2728/PlugsConc/shared/protogen/network/v1"
+	"github.com/hashicorp/go-plugin"
+)
+
+// Dialer opens outbound connections through the host network service. Its Dial method has the signature of
+// net.Dialer.DialContext, so it can be used as the DialContext of an http.Transport.
+type Dialer struct {
+	client networkv1.NetworkClient
+}
+
+// NewDialer returns a Dialer using the host network service client.
+func NewDialer(client networkv1.NetworkClient) *Dialer {
+	return &Dialer{client: client}
+}
+
+// DialBroker returns a Dialer using the network service the host serves a plugin over broker, the broker its
+// GRPCServer was given. The service is served once the host has dispensed the plugin, so call it while handling a
+// request rather than at startup.
+func DialBroker(broker *plugin.GRPCBroker) (*Dialer, error) {
+	conn, err := broker.Dial(BrokerID)
+	if err != nil {
+		return nil, err
+	}
+	return NewDialer(networkv1.NewNetworkClient(conn)), nil
+}
+
+// Dial asks the host to connect to address over network, "tcp" or "udp", and returns the relayed connection once
+// the host has connected. A destination not allowed by the plugin's egress rules fails with codes.PermissionDenied.
+// Deadlines are not supported on the returned connection; cancel ctx to abort it instead.
+func (d *Dialer) Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := d.client.Dial(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	target := &networkv1.DialTarget{Protocol: network, Host: host, Port: int32(port)}
+	if err := stream.Send(&networkv1.DialFrame{Target: target}); err != nil {
+		cancel()
+		return nil, err
+	}
+	// the host acknowledges a successful dial by echoing the target
+	if _, err := stream.Recv(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return &conn{stream: stream, cancel: cancel, remote: addr{network: network, address: address}}, nil
+}
+
+// conn is a net.Conn relayed over a Dial stream.
+type conn struct {
+	stream  networkv1.Network_DialClient
+	cancel  context.CancelFunc
+	remote  addr
+	readMu  sync.Mutex
+	pending []byte
+	writeMu sync.Mutex
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	for len(c.pending) == 0 {
+		frame, err := c.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		c.pending = frame.GetData()
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	written := 0
+	for written < len(p) {
+		chunk := p[written:min(written+frameSize, len(p))]
+		if err := c.stream.Send(&networkv1.DialFrame{Data: append([]byte(nil), chunk...)}); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = net.ErrClosed
+			}
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return written, nil
+}
+
+// CloseWrite closes the plugin's side of the connection, letting the destination see EOF while replies can still
+// be read.
+func (c *conn) CloseWrite() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.stream.CloseSend()
+}
+
+func (c *conn) Close() error {
+	c.cancel()
+	return nil
+}
+
+func (c *conn) LocalAddr() net.Addr                { return addr{network: c.remote.network, address: "plugin"} }
+func (c *conn) RemoteAddr() net.Addr               { return c.remote }
+func (c *conn) SetDeadline(_ time.Time) error      { return nil }
+func (c *conn) SetReadDeadline(_ time.Time) error  { return nil }
+func (c *conn) SetWriteDeadline(_ time.Time) error { return nil }
+
+// addr is the net.Addr of a relayed connection.
+type addr struct {
+	network string
+	address string
+}
+
+func (a addr) Network() string { return a.network }
+func (a addr) String() string  { return a.address }
@@ -0,0 +1,159 @@
+// Package ngnet provides the host network service, which brokers plugin outbound connections, and the dialer
+// plugins use to reach it.
+package ngnet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/bmj2728/PlugsConc/internal/capability"
+	"github.com/bmj2728/PlugsConc/internal/logger"
+	networkv1 "github.com/bmj2728/PlugsConc/shared/protogen/network/v1"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// frameSize is the largest chunk of connection data sent in one frame.
+const frameSize = 32 * 1024
+
+// BrokerID is the go-plugin broker ID under which the host serves a plugin its network service, "ngnt" in ASCII,
+// far above the IDs the broker's NextId hands out.
+const BrokerID uint32 = 0x6e676e74
+
+// NGNet is the host network service for one plugin. It dials only destinations allowed by the plugin's declared
+// egress rules and relays connection data over the Dial stream; denied connections are logged with the plugin name.
+type NGNet struct {
+	networkv1.UnimplementedNetworkServer
+	pluginName string
+	guard      *capability.NetworkGuard
+	dialer     net.Dialer
+	netLogger  hclog.Logger
+}
+
+// NewNGNet returns the network service for the named plugin, enforcing its declared network capability. A nil
+// capability denies all egress.
+func NewNGNet(pluginName string, c *capability.NetworkCapability, netLogger hclog.Logger) (*NGNet, error) {
+	if netLogger == nil {
+		netLogger = logger.DefaultLogger().Named("ngnet")
+	}
+	guard, err := capability.NewNetworkGuard(c)
+	if err != nil {
+		return nil, err
+	}
+	return &NGNet{
+		pluginName: pluginName,
+		guard:      guard,
+		netLogger:  netLogger.With(logger.KeyPluginName, pluginName),
+	}, nil
+}
+
+// ServeBroker serves n to the plugin on the other end of broker under BrokerID in the background, until the plugin
+// exits.
+func ServeBroker(broker *plugin.GRPCBroker, n *NGNet) {
+	go broker.AcceptAndServe(BrokerID, func(opts []grpc.ServerOption) *grpc.Server {
+		s := grpc.NewServer(opts...)
+		networkv1.RegisterNetworkServer(s, n)
+		return s
+	})
+}
+
+// Dial checks the target in the first frame against the plugin's egress rules, dials it, acknowledges the
+// connection by echoing the target, and then relays data in both directions until either side closes.
+func (n *NGNet) Dial(stream grpc.BidiStreamingServer[networkv1.DialFrame, networkv1.DialFrame]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	target := first.GetTarget()
+	if target == nil {
+		return status.Error(codes.InvalidArgument, "first frame must carry a dial target")
+	}
+	address := net.JoinHostPort(target.GetHost(), strconv.Itoa(int(target.GetPort())))
+	if err := n.guard.CheckEgress(target.GetProtocol(), target.GetHost(), int(target.GetPort())); err != nil {
+		n.netLogger.Warn("Network egress denied", "protocol", target.GetProtocol(), "address", address)
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	conn, err := n.dialer.DialContext(stream.Context(), target.GetProtocol(), address)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer func() { _ = conn.Close() }()
+	n.netLogger.Debug("Network connection opened", "protocol", target.GetProtocol(), "address", address)
+
+	if err := stream.Send(&networkv1.DialFrame{Target: target}); err != nil {
+		return err
+	}
+	if len(first.GetData()) > 0 {
+		if _, err := conn.Write(first.GetData()); err != nil {
+			return status.Error(codes.Aborted, err.Error())
+		}
+	}
+
+	upstream := make(chan error, 1)
+	go func() {
+		err := relayToConn(stream, conn)
+		if err != nil {
+			// unblock the read below
+			_ = conn.Close()
+		}
+		upstream <- err
+	}()
+	go func() {
+		<-stream.Context().Done()
+		_ = conn.Close()
+	}()
+	downErr := relayToStream(conn, stream)
+	select {
+	case err := <-upstream:
+		if err != nil {
+			return err
+		}
+	default:
+	}
+	return downErr
+}
+
+// relayToConn writes the data of every frame received from the plugin to conn. When the plugin closes its side of
+// the stream, the write side of conn is closed too if it supports half-close.
+func relayToConn(stream grpc.BidiStreamingServer[networkv1.DialFrame, networkv1.DialFrame], conn net.Conn) error {
+	for {
+		frame, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+				_ = cw.CloseWrite()
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Write(frame.GetData()); err != nil {
+			return status.Error(codes.Aborted, err.Error())
+		}
+	}
+}
+
+// relayToStream sends everything read from conn to the plugin until conn is closed.
+func relayToStream(conn net.Conn, stream grpc.BidiStreamingServer[networkv1.DialFrame, networkv1.DialFrame]) error {
+	buf := make([]byte, frameSize)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&networkv1.DialFrame{Data: bytes.Clone(buf[:n])}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Aborted, err.Error())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: jobsource/v1/jobsource.proto
+
+package jobsourcev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobTypes      []string               `protobuf:"bytes,1,rep,name=job_types,json=jobTypes,proto3" json:"job_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_jobsource_v1_jobsource_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jobsource_v1_jobsource_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_jobsource_v1_jobsource_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamRequest) GetJobTypes() []string {
+	if x != nil {
+		return x.JobTypes
+	}
+	return nil
+}
+
+type JobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobType       string                 `protobuf:"bytes,1,opt,name=job_type,json=jobType,proto3" json:"job_type,omitempty"`
+	Encoding      string                 `protobuf:"bytes,2,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	MaxRetries    int32                  `protobuf:"varint,4,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	RetryDelayMs  int32                  `protobuf:"varint,5,opt,name=retry_delay_ms,json=retryDelayMs,proto3" json:"retry_delay_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_jobsource_v1_jobsource_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jobsource_v1_jobsource_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_jobsource_v1_jobsource_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JobRequest) GetJobType() string {
+	if x != nil {
+		return x.JobType
+	}
+	return ""
+}
+
+func (x *JobRequest) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *JobRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *JobRequest) GetMaxRetries() int32 {
+	if x != nil {
+		return x.MaxRetries
+	}
+	return 0
+}
+
+func (x *JobRequest) GetRetryDelayMs() int32 {
+	if x != nil {
+		return x.RetryDelayMs
+	}
+	return 0
+}
+
+var File_jobsource_v1_jobsource_proto protoreflect.FileDescriptor
+
+const file_jobsource_v1_jobsource_proto_rawDesc = "" +
+	"\n" +
+	"\x1cjobsource/v1/jobsource.proto\x12\fjobsource.v1\",\n" +
+	"\rStreamRequest\x12\x1b\n" +
+	"\tjob_types\x18\x01 \x03(\tR\bjobTypes\"\xa4\x01\n" +
+	"\n" +
+	"JobRequest\x12\x19\n" +
+	"\bjob_type\x18\x01 \x01(\tR\ajobType\x12\x1a\n" +
+	"\bencoding\x18\x02 \x01(\tR\bencoding\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x1f\n" +
+	"\vmax_retries\x18\x04 \x01(\x05R\n" +
+	"maxRetries\x12$\n" +
+	"\x0eretry_delay_ms\x18\x05 \x01(\x05R\fretryDelayMs2N\n" +
+	"\tJobSource\x12A\n" +
+	"\x06Stream\x12\x1b.jobsource.v1.StreamRequest\x1a\x18.jobsource.v1.JobRequest0\x01BGZEgithub.com/bmj2728/PlugsConc/shared/protogen/jobsource/v1;jobsourcev1b\x06proto3"
+
+var (
+	file_jobsource_v1_jobsource_proto_rawDescOnce sync.Once
+	file_jobsource_v1_jobsource_proto_rawDescData []byte
+)
+
+func file_jobsource_v1_jobsource_proto_rawDescGZIP() []byte {
+	file_jobsource_v1_jobsource_proto_rawDescOnce.Do(func() {
+		file_jobsource_v1_jobsource_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_jobsource_v1_jobsource_proto_rawDesc), len(file_jobsource_v1_jobsource_proto_rawDesc)))
+	})
+	return file_jobsource_v1_jobsource_proto_rawDescData
+}
+
+var file_jobsource_v1_jobsource_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_jobsource_v1_jobsource_proto_goTypes = []any{
+	(*StreamRequest)(nil), // 0: jobsource.v1.StreamRequest
+	(*JobRequest)(nil),    // 1: jobsource.v1.JobRequest
+}
+var file_jobsource_v1_jobsource_proto_depIdxs = []int32{
+	0, // 0: jobsource.v1.JobSource.Stream:input_type -> jobsource.v1.StreamRequest
+	1, // 1: jobsource.v1.JobSource.Stream:output_type -> jobsource.v1.JobRequest
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_jobsource_v1_jobsource_proto_init() }
+func file_jobsource_v1_jobsource_proto_init() {
+	if File_jobsource_v1_jobsource_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_jobsource_v1_jobsource_proto_rawDesc), len(file_jobsource_v1_jobsource_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_jobsource_v1_jobsource_proto_goTypes,
+		DependencyIndexes: file_jobsource_v1_jobsource_proto_depIdxs,
+		MessageInfos:      file_jobsource_v1_jobsource_proto_msgTypes,
+	}.Build()
+	File_jobsource_v1_jobsource_proto = out.File
+	file_jobsource_v1_jobsource_proto_goTypes = nil
+	file_jobsource_v1_jobsource_proto_depIdxs = nil
+}
@@ -0,0 +1,124 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: jobsource/v1/jobsource.proto
+
+package jobsourcev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	JobSource_Stream_FullMethodName = "/jobsource.v1.JobSource/Stream"
+)
+
+// JobSourceClient is the client API for JobSource service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type JobSourceClient interface {
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobRequest], error)
+}
+
+type jobSourceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobSourceClient(cc grpc.ClientConnInterface) JobSourceClient {
+	return &jobSourceClient{cc}
+}
+
+func (c *jobSourceClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobRequest], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JobSource_ServiceDesc.Streams[0], JobSource_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, JobRequest]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobSource_StreamClient = grpc.ServerStreamingClient[JobRequest]
+
+// JobSourceServer is the server API for JobSource service.
+// All implementations must embed UnimplementedJobSourceServer
+// for forward compatibility.
+type JobSourceServer interface {
+	Stream(*StreamRequest, grpc.ServerStreamingServer[JobRequest]) error
+	mustEmbedUnimplementedJobSourceServer()
+}
+
+// UnimplementedJobSourceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJobSourceServer struct{}
+
+func (UnimplementedJobSourceServer) Stream(*StreamRequest, grpc.ServerStreamingServer[JobRequest]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedJobSourceServer) mustEmbedUnimplementedJobSourceServer() {}
+func (UnimplementedJobSourceServer) testEmbeddedByValue()                   {}
+
+// UnsafeJobSourceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JobSourceServer will
+// result in compilation errors.
+type UnsafeJobSourceServer interface {
+	mustEmbedUnimplementedJobSourceServer()
+}
+
+func RegisterJobSourceServer(s grpc.ServiceRegistrar, srv JobSourceServer) {
+	// If the following call pancis, it indicates UnimplementedJobSourceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JobSource_ServiceDesc, srv)
+}
+
+func _JobSource_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JobSourceServer).Stream(m, &grpc.GenericServerStream[StreamRequest, JobRequest]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JobSource_StreamServer = grpc.ServerStreamingServer[JobRequest]
+
+// JobSource_ServiceDesc is the grpc.ServiceDesc for JobSource service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JobSource_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jobsource.v1.JobSource",
+	HandlerType: (*JobSourceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _JobSource_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "jobsource/v1/jobsource.proto",
+}
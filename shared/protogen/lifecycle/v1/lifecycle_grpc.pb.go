@@ -0,0 +1,199 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: lifecycle/v1/lifecycle.proto
+
+package lifecyclev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Lifecycle_Warmup_FullMethodName   = "/lifecycle.v1.Lifecycle/Warmup"
+	Lifecycle_Info_FullMethodName     = "/lifecycle.v1.Lifecycle/Info"
+	Lifecycle_Describe_FullMethodName = "/lifecycle.v1.Lifecycle/Describe"
+)
+
+// LifecycleClient is the client API for Lifecycle service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LifecycleClient interface {
+	Warmup(ctx context.Context, in *WarmupRequest, opts ...grpc.CallOption) (*WarmupResponse, error)
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	// enumerates the services and methods the plugin serves, beyond the plugin types the host knows statically
+	Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
+}
+
+type lifecycleClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLifecycleClient(cc grpc.ClientConnInterface) LifecycleClient {
+	return &lifecycleClient{cc}
+}
+
+func (c *lifecycleClient) Warmup(ctx context.Context, in *WarmupRequest, opts ...grpc.CallOption) (*WarmupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WarmupResponse)
+	err := c.cc.Invoke(ctx, Lifecycle_Warmup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifecycleClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, Lifecycle_Info_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifecycleClient) Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribeResponse)
+	err := c.cc.Invoke(ctx, Lifecycle_Describe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LifecycleServer is the server API for Lifecycle service.
+// All implementations must embed UnimplementedLifecycleServer
+// for forward compatibility.
+type LifecycleServer interface {
+	Warmup(context.Context, *WarmupRequest) (*WarmupResponse, error)
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	// enumerates the services and methods the plugin serves, beyond the plugin types the host knows statically
+	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
+	mustEmbedUnimplementedLifecycleServer()
+}
+
+// UnimplementedLifecycleServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLifecycleServer struct{}
+
+func (UnimplementedLifecycleServer) Warmup(context.Context, *WarmupRequest) (*WarmupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Warmup not implemented")
+}
+func (UnimplementedLifecycleServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedLifecycleServer) Describe(context.Context, *DescribeRequest) (*DescribeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Describe not implemented")
+}
+func (UnimplementedLifecycleServer) mustEmbedUnimplementedLifecycleServer() {}
+func (UnimplementedLifecycleServer) testEmbeddedByValue()                   {}
+
+// UnsafeLifecycleServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LifecycleServer will
+// result in compilation errors.
+type UnsafeLifecycleServer interface {
+	mustEmbedUnimplementedLifecycleServer()
+}
+
+func RegisterLifecycleServer(s grpc.ServiceRegistrar, srv LifecycleServer) {
+	// If the following call pancis, it indicates UnimplementedLifecycleServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Lifecycle_ServiceDesc, srv)
+}
+
+func _Lifecycle_Warmup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WarmupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).Warmup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifecycle_Warmup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).Warmup(ctx, req.(*WarmupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifecycle_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifecycle_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifecycle_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Lifecycle_Describe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).Describe(ctx, req.(*DescribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Lifecycle_ServiceDesc is the grpc.ServiceDesc for Lifecycle service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Lifecycle_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lifecycle.v1.Lifecycle",
+	HandlerType: (*LifecycleServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Warmup",
+			Handler:    _Lifecycle_Warmup_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _Lifecycle_Info_Handler,
+		},
+		{
+			MethodName: "Describe",
+			Handler:    _Lifecycle_Describe_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "lifecycle/v1/lifecycle.proto",
+}
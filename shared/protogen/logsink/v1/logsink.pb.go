@@ -0,0 +1,284 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: logsink/v1/logsink.proto
+
+package logsinkv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LogRecord struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	TimeUnixNano int64                  `protobuf:"varint,1,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	Level        string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	// the hclog logger name, written as @module in hclog's JSON output
+	Logger  string `protobuf:"bytes,3,opt,name=logger,proto3" json:"logger,omitempty"`
+	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// every attribute rendered as a string, kept for sinks built before fields
+	Attrs map[string]string `protobuf:"bytes,5,rep,name=attrs,proto3" json:"attrs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// the same attributes with their JSON types: numbers, booleans, strings, lists, and objects
+	Fields        map[string]*structpb.Value `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogRecord) Reset() {
+	*x = LogRecord{}
+	mi := &file_logsink_v1_logsink_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogRecord) ProtoMessage() {}
+
+func (x *LogRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_logsink_v1_logsink_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogRecord.ProtoReflect.Descriptor instead.
+func (*LogRecord) Descriptor() ([]byte, []int) {
+	return file_logsink_v1_logsink_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogRecord) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+func (x *LogRecord) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *LogRecord) GetLogger() string {
+	if x != nil {
+		return x.Logger
+	}
+	return ""
+}
+
+func (x *LogRecord) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LogRecord) GetAttrs() map[string]string {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+func (x *LogRecord) GetFields() map[string]*structpb.Value {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*LogRecord           `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_logsink_v1_logsink_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logsink_v1_logsink_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_logsink_v1_logsink_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WriteRequest) GetRecords() []*LogRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type WriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      uint32                 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteResponse) Reset() {
+	*x = WriteResponse{}
+	mi := &file_logsink_v1_logsink_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResponse) ProtoMessage() {}
+
+func (x *WriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_logsink_v1_logsink_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return file_logsink_v1_logsink_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WriteResponse) GetAccepted() uint32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+var File_logsink_v1_logsink_proto protoreflect.FileDescriptor
+
+const file_logsink_v1_logsink_proto_rawDesc = "" +
+	"\n" +
+	"\x18logsink/v1/logsink.proto\x12\n" +
+	"logsink.v1\x1a\x1cgoogle/protobuf/struct.proto\"\xf9\x02\n" +
+	"\tLogRecord\x12$\n" +
+	"\x0etime_unix_nano\x18\x01 \x01(\x03R\ftimeUnixNano\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12\x16\n" +
+	"\x06logger\x18\x03 \x01(\tR\x06logger\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x126\n" +
+	"\x05attrs\x18\x05 \x03(\v2 .logsink.v1.LogRecord.AttrsEntryR\x05attrs\x129\n" +
+	"\x06fields\x18\x06 \x03(\v2!.logsink.v1.LogRecord.FieldsEntryR\x06fields\x1a8\n" +
+	"\n" +
+	"AttrsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aQ\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value:\x028\x01\"?\n" +
+	"\fWriteRequest\x12/\n" +
+	"\arecords\x18\x01 \x03(\v2\x15.logsink.v1.LogRecordR\arecords\"+\n" +
+	"\rWriteResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\rR\baccepted2G\n" +
+	"\aLogSink\x12<\n" +
+	"\x05Write\x12\x18.logsink.v1.WriteRequest\x1a\x19.logsink.v1.WriteResponseBCZAgithub.com/bmj2728/PlugsConc/shared/protogen/logsink/v1;logsinkv1b\x06proto3"
+
+var (
+	file_logsink_v1_logsink_proto_rawDescOnce sync.Once
+	file_logsink_v1_logsink_proto_rawDescData []byte
+)
+
+func file_logsink_v1_logsink_proto_rawDescGZIP() []byte {
+	file_logsink_v1_logsink_proto_rawDescOnce.Do(func() {
+		file_logsink_v1_logsink_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_logsink_v1_logsink_proto_rawDesc), len(file_logsink_v1_logsink_proto_rawDesc)))
+	})
+	return file_logsink_v1_logsink_proto_rawDescData
+}
+
+var file_logsink_v1_logsink_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_logsink_v1_logsink_proto_goTypes = []any{
+	(*LogRecord)(nil),      // 0: logsink.v1.LogRecord
+	(*WriteRequest)(nil),   // 1: logsink.v1.WriteRequest
+	(*WriteResponse)(nil),  // 2: logsink.v1.WriteResponse
+	nil,                    // 3: logsink.v1.LogRecord.AttrsEntry
+	nil,                    // 4: logsink.v1.LogRecord.FieldsEntry
+	(*structpb.Value)(nil), // 5: google.protobuf.Value
+}
+var file_logsink_v1_logsink_proto_depIdxs = []int32{
+	3, // 0: logsink.v1.LogRecord.attrs:type_name -> logsink.v1.LogRecord.AttrsEntry
+	4, // 1: logsink.v1.LogRecord.fields:type_name -> logsink.v1.LogRecord.FieldsEntry
+	0, // 2: logsink.v1.WriteRequest.records:type_name -> logsink.v1.LogRecord
+	5, // 3: logsink.v1.LogRecord.FieldsEntry.value:type_name -> google.protobuf.Value
+	1, // 4: logsink.v1.LogSink.Write:input_type -> logsink.v1.WriteRequest
+	2, // 5: logsink.v1.LogSink.Write:output_type -> logsink.v1.WriteResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_logsink_v1_logsink_proto_init() }
+func file_logsink_v1_logsink_proto_init() {
+	if File_logsink_v1_logsink_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_logsink_v1_logsink_proto_rawDesc), len(file_logsink_v1_logsink_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_logsink_v1_logsink_proto_goTypes,
+		DependencyIndexes: file_logsink_v1_logsink_proto_depIdxs,
+		MessageInfos:      file_logsink_v1_logsink_proto_msgTypes,
+	}.Build()
+	File_logsink_v1_logsink_proto = out.File
+	file_logsink_v1_logsink_proto_goTypes = nil
+	file_logsink_v1_logsink_proto_depIdxs = nil
+}
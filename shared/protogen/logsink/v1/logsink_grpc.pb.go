@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: logsink/v1/logsink.proto
+
+package logsinkv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LogSink_Write_FullMethodName = "/logsink.v1.LogSink/Write"
+)
+
+// LogSinkClient is the client API for LogSink service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LogSinkClient interface {
+	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+}
+
+type logSinkClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogSinkClient(cc grpc.ClientConnInterface) LogSinkClient {
+	return &logSinkClient{cc}
+}
+
+func (c *logSinkClient) Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, LogSink_Write_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogSinkServer is the server API for LogSink service.
+// All implementations must embed UnimplementedLogSinkServer
+// for forward compatibility.
+type LogSinkServer interface {
+	Write(context.Context, *WriteRequest) (*WriteResponse, error)
+	mustEmbedUnimplementedLogSinkServer()
+}
+
+// UnimplementedLogSinkServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogSinkServer struct{}
+
+func (UnimplementedLogSinkServer) Write(context.Context, *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedLogSinkServer) mustEmbedUnimplementedLogSinkServer() {}
+func (UnimplementedLogSinkServer) testEmbeddedByValue()                 {}
+
+// UnsafeLogSinkServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogSinkServer will
+// result in compilation errors.
+type UnsafeLogSinkServer interface {
+	mustEmbedUnimplementedLogSinkServer()
+}
+
+func RegisterLogSinkServer(s grpc.ServiceRegistrar, srv LogSinkServer) {
+	// If the following call pancis, it indicates UnimplementedLogSinkServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogSink_ServiceDesc, srv)
+}
+
+func _LogSink_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogSinkServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogSink_Write_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogSinkServer).Write(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LogSink_ServiceDesc is the grpc.ServiceDesc for LogSink service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogSink_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logsink.v1.LogSink",
+	HandlerType: (*LogSinkServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Write",
+			Handler:    _LogSink_Write_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "logsink/v1/logsink.proto",
+}
@@ -0,0 +1,615 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: metricsink/v1/metricsink.proto
+
+package metricsinkv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PoolMetrics struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Workers           int32                  `protobuf:"varint,1,opt,name=workers,proto3" json:"workers,omitempty"`
+	QueuedJobs        int32                  `protobuf:"varint,2,opt,name=queued_jobs,json=queuedJobs,proto3" json:"queued_jobs,omitempty"`
+	RunningJobs       int32                  `protobuf:"varint,3,opt,name=running_jobs,json=runningJobs,proto3" json:"running_jobs,omitempty"`
+	JobsSubmitted     int64                  `protobuf:"varint,4,opt,name=jobs_submitted,json=jobsSubmitted,proto3" json:"jobs_submitted,omitempty"`
+	FailedSubmissions int64                  `protobuf:"varint,5,opt,name=failed_submissions,json=failedSubmissions,proto3" json:"failed_submissions,omitempty"`
+	SuccessfulJobs    int64                  `protobuf:"varint,6,opt,name=successful_jobs,json=successfulJobs,proto3" json:"successful_jobs,omitempty"`
+	FailedJobs        int64                  `protobuf:"varint,7,opt,name=failed_jobs,json=failedJobs,proto3" json:"failed_jobs,omitempty"`
+	TimedOutJobs      int64                  `protobuf:"varint,8,opt,name=timed_out_jobs,json=timedOutJobs,proto3" json:"timed_out_jobs,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *PoolMetrics) Reset() {
+	*x = PoolMetrics{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoolMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolMetrics) ProtoMessage() {}
+
+func (x *PoolMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolMetrics.ProtoReflect.Descriptor instead.
+func (*PoolMetrics) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PoolMetrics) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetQueuedJobs() int32 {
+	if x != nil {
+		return x.QueuedJobs
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetRunningJobs() int32 {
+	if x != nil {
+		return x.RunningJobs
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetJobsSubmitted() int64 {
+	if x != nil {
+		return x.JobsSubmitted
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetFailedSubmissions() int64 {
+	if x != nil {
+		return x.FailedSubmissions
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetSuccessfulJobs() int64 {
+	if x != nil {
+		return x.SuccessfulJobs
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetFailedJobs() int64 {
+	if x != nil {
+		return x.FailedJobs
+	}
+	return 0
+}
+
+func (x *PoolMetrics) GetTimedOutJobs() int64 {
+	if x != nil {
+		return x.TimedOutJobs
+	}
+	return 0
+}
+
+type PluginMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Restarts      int32                  `protobuf:"varint,3,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	Crashes       int32                  `protobuf:"varint,4,opt,name=crashes,proto3" json:"crashes,omitempty"`
+	Quarantined   bool                   `protobuf:"varint,5,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginMetrics) Reset() {
+	*x = PluginMetrics{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginMetrics) ProtoMessage() {}
+
+func (x *PluginMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginMetrics.ProtoReflect.Descriptor instead.
+func (*PluginMetrics) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PluginMetrics) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginMetrics) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *PluginMetrics) GetRestarts() int32 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *PluginMetrics) GetCrashes() int32 {
+	if x != nil {
+		return x.Crashes
+	}
+	return 0
+}
+
+func (x *PluginMetrics) GetQuarantined() bool {
+	if x != nil {
+		return x.Quarantined
+	}
+	return false
+}
+
+type HostMetrics struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Goroutines        int32                  `protobuf:"varint,1,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	HeapAllocBytes    uint64                 `protobuf:"varint,2,opt,name=heap_alloc_bytes,json=heapAllocBytes,proto3" json:"heap_alloc_bytes,omitempty"`
+	HeapInuseBytes    uint64                 `protobuf:"varint,3,opt,name=heap_inuse_bytes,json=heapInuseBytes,proto3" json:"heap_inuse_bytes,omitempty"`
+	NumGc             uint32                 `protobuf:"varint,4,opt,name=num_gc,json=numGc,proto3" json:"num_gc,omitempty"`
+	HeapSysBytes      uint64                 `protobuf:"varint,5,opt,name=heap_sys_bytes,json=heapSysBytes,proto3" json:"heap_sys_bytes,omitempty"`
+	SysBytes          uint64                 `protobuf:"varint,6,opt,name=sys_bytes,json=sysBytes,proto3" json:"sys_bytes,omitempty"`
+	UsedBytes         uint64                 `protobuf:"varint,7,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	NextGcBytes       uint64                 `protobuf:"varint,8,opt,name=next_gc_bytes,json=nextGcBytes,proto3" json:"next_gc_bytes,omitempty"`
+	GcPauseTotalNanos int64                  `protobuf:"varint,9,opt,name=gc_pause_total_nanos,json=gcPauseTotalNanos,proto3" json:"gc_pause_total_nanos,omitempty"`
+	LastGcPauseNanos  int64                  `protobuf:"varint,10,opt,name=last_gc_pause_nanos,json=lastGcPauseNanos,proto3" json:"last_gc_pause_nanos,omitempty"`
+	GcPauseRatio      float64                `protobuf:"fixed64,11,opt,name=gc_pause_ratio,json=gcPauseRatio,proto3" json:"gc_pause_ratio,omitempty"`
+	GcCpuFraction     float64                `protobuf:"fixed64,12,opt,name=gc_cpu_fraction,json=gcCpuFraction,proto3" json:"gc_cpu_fraction,omitempty"`
+	SoftLimitBytes    uint64                 `protobuf:"varint,13,opt,name=soft_limit_bytes,json=softLimitBytes,proto3" json:"soft_limit_bytes,omitempty"`
+	Throttled         bool                   `protobuf:"varint,14,opt,name=throttled,proto3" json:"throttled,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *HostMetrics) Reset() {
+	*x = HostMetrics{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostMetrics) ProtoMessage() {}
+
+func (x *HostMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostMetrics.ProtoReflect.Descriptor instead.
+func (*HostMetrics) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HostMetrics) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetHeapAllocBytes() uint64 {
+	if x != nil {
+		return x.HeapAllocBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetHeapInuseBytes() uint64 {
+	if x != nil {
+		return x.HeapInuseBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetNumGc() uint32 {
+	if x != nil {
+		return x.NumGc
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetHeapSysBytes() uint64 {
+	if x != nil {
+		return x.HeapSysBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetSysBytes() uint64 {
+	if x != nil {
+		return x.SysBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetNextGcBytes() uint64 {
+	if x != nil {
+		return x.NextGcBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetGcPauseTotalNanos() int64 {
+	if x != nil {
+		return x.GcPauseTotalNanos
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetLastGcPauseNanos() int64 {
+	if x != nil {
+		return x.LastGcPauseNanos
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetGcPauseRatio() float64 {
+	if x != nil {
+		return x.GcPauseRatio
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetGcCpuFraction() float64 {
+	if x != nil {
+		return x.GcCpuFraction
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetSoftLimitBytes() uint64 {
+	if x != nil {
+		return x.SoftLimitBytes
+	}
+	return 0
+}
+
+func (x *HostMetrics) GetThrottled() bool {
+	if x != nil {
+		return x.Throttled
+	}
+	return false
+}
+
+type MetricsSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TimeUnixNano  int64                  `protobuf:"varint,1,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	Host          string                 `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Pool          *PoolMetrics           `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	Plugins       []*PluginMetrics       `protobuf:"bytes,4,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Runtime       *HostMetrics           `protobuf:"bytes,5,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsSnapshot) Reset() {
+	*x = MetricsSnapshot{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsSnapshot) ProtoMessage() {}
+
+func (x *MetricsSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsSnapshot.ProtoReflect.Descriptor instead.
+func (*MetricsSnapshot) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MetricsSnapshot) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+func (x *MetricsSnapshot) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *MetricsSnapshot) GetPool() *PoolMetrics {
+	if x != nil {
+		return x.Pool
+	}
+	return nil
+}
+
+func (x *MetricsSnapshot) GetPlugins() []*PluginMetrics {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+func (x *MetricsSnapshot) GetRuntime() *HostMetrics {
+	if x != nil {
+		return x.Runtime
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      *MetricsSnapshot       `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExportRequest) GetSnapshot() *MetricsSnapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type ExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metricsink_v1_metricsink_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_metricsink_v1_metricsink_proto_rawDescGZIP(), []int{5}
+}
+
+var File_metricsink_v1_metricsink_proto protoreflect.FileDescriptor
+
+const file_metricsink_v1_metricsink_proto_rawDesc = "" +
+	"\n" +
+	"\x1emetricsink/v1/metricsink.proto\x12\rmetricsink.v1\"\xb1\x02\n" +
+	"\vPoolMetrics\x12\x18\n" +
+	"\aworkers\x18\x01 \x01(\x05R\aworkers\x12\x1f\n" +
+	"\vqueued_jobs\x18\x02 \x01(\x05R\n" +
+	"queuedJobs\x12!\n" +
+	"\frunning_jobs\x18\x03 \x01(\x05R\vrunningJobs\x12%\n" +
+	"\x0ejobs_submitted\x18\x04 \x01(\x03R\rjobsSubmitted\x12-\n" +
+	"\x12failed_submissions\x18\x05 \x01(\x03R\x11failedSubmissions\x12'\n" +
+	"\x0fsuccessful_jobs\x18\x06 \x01(\x03R\x0esuccessfulJobs\x12\x1f\n" +
+	"\vfailed_jobs\x18\a \x01(\x03R\n" +
+	"failedJobs\x12$\n" +
+	"\x0etimed_out_jobs\x18\b \x01(\x03R\ftimedOutJobs\"\x91\x01\n" +
+	"\rPluginMetrics\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x1a\n" +
+	"\brestarts\x18\x03 \x01(\x05R\brestarts\x12\x18\n" +
+	"\acrashes\x18\x04 \x01(\x05R\acrashes\x12 \n" +
+	"\vquarantined\x18\x05 \x01(\bR\vquarantined\"\x94\x04\n" +
+	"\vHostMetrics\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\x01 \x01(\x05R\n" +
+	"goroutines\x12(\n" +
+	"\x10heap_alloc_bytes\x18\x02 \x01(\x04R\x0eheapAllocBytes\x12(\n" +
+	"\x10heap_inuse_bytes\x18\x03 \x01(\x04R\x0eheapInuseBytes\x12\x15\n" +
+	"\x06num_gc\x18\x04 \x01(\rR\x05numGc\x12$\n" +
+	"\x0eheap_sys_bytes\x18\x05 \x01(\x04R\fheapSysBytes\x12\x1b\n" +
+	"\tsys_bytes\x18\x06 \x01(\x04R\bsysBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\a \x01(\x04R\tusedBytes\x12\"\n" +
+	"\rnext_gc_bytes\x18\b \x01(\x04R\vnextGcBytes\x12/\n" +
+	"\x14gc_pause_total_nanos\x18\t \x01(\x03R\x11gcPauseTotalNanos\x12-\n" +
+	"\x13last_gc_pause_nanos\x18\n" +
+	" \x01(\x03R\x10lastGcPauseNanos\x12$\n" +
+	"\x0egc_pause_ratio\x18\v \x01(\x01R\fgcPauseRatio\x12&\n" +
+	"\x0fgc_cpu_fraction\x18\f \x01(\x01R\rgcCpuFraction\x12(\n" +
+	"\x10soft_limit_bytes\x18\r \x01(\x04R\x0esoftLimitBytes\x12\x1c\n" +
+	"\tthrottled\x18\x0e \x01(\bR\tthrottled\"\xe9\x01\n" +
+	"\x0fMetricsSnapshot\x12$\n" +
+	"\x0etime_unix_nano\x18\x01 \x01(\x03R\ftimeUnixNano\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12.\n" +
+	"\x04pool\x18\x03 \x01(\v2\x1a.metricsink.v1.PoolMetricsR\x04pool\x126\n" +
+	"\aplugins\x18\x04 \x03(\v2\x1c.metricsink.v1.PluginMetricsR\aplugins\x124\n" +
+	"\aruntime\x18\x05 \x01(\v2\x1a.metricsink.v1.HostMetricsR\aruntime\"K\n" +
+	"\rExportRequest\x12:\n" +
+	"\bsnapshot\x18\x01 \x01(\v2\x1e.metricsink.v1.MetricsSnapshotR\bsnapshot\"\x10\n" +
+	"\x0eExportResponse2S\n" +
+	"\n" +
+	"MetricSink\x12E\n" +
+	"\x06Export\x12\x1c.metricsink.v1.ExportRequest\x1a\x1d.metricsink.v1.ExportResponseBIZGgithub.com/bmj2728/PlugsConc/shared/protogen/metricsink/v1;metricsinkv1b\x06proto3"
+
+var (
+	file_metricsink_v1_metricsink_proto_rawDescOnce sync.Once
+	file_metricsink_v1_metricsink_proto_rawDescData []byte
+)
+
+func file_metricsink_v1_metricsink_proto_rawDescGZIP() []byte {
+	file_metricsink_v1_metricsink_proto_rawDescOnce.Do(func() {
+		file_metricsink_v1_metricsink_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_metricsink_v1_metricsink_proto_rawDesc), len(file_metricsink_v1_metricsink_proto_rawDesc)))
+	})
+	return file_metricsink_v1_metricsink_proto_rawDescData
+}
+
+var file_metricsink_v1_metricsink_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_metricsink_v1_metricsink_proto_goTypes = []any{
+	(*PoolMetrics)(nil),     // 0: metricsink.v1.PoolMetrics
+	(*PluginMetrics)(nil),   // 1: metricsink.v1.PluginMetrics
+	(*HostMetrics)(nil),     // 2: metricsink.v1.HostMetrics
+	(*MetricsSnapshot)(nil), // 3: metricsink.v1.MetricsSnapshot
+	(*ExportRequest)(nil),   // 4: metricsink.v1.ExportRequest
+	(*ExportResponse)(nil),  // 5: metricsink.v1.ExportResponse
+}
+var file_metricsink_v1_metricsink_proto_depIdxs = []int32{
+	0, // 0: metricsink.v1.MetricsSnapshot.pool:type_name -> metricsink.v1.PoolMetrics
+	1, // 1: metricsink.v1.MetricsSnapshot.plugins:type_name -> metricsink.v1.PluginMetrics
+	2, // 2: metricsink.v1.MetricsSnapshot.runtime:type_name -> metricsink.v1.HostMetrics
+	3, // 3: metricsink.v1.ExportRequest.snapshot:type_name -> metricsink.v1.MetricsSnapshot
+	4, // 4: metricsink.v1.MetricSink.Export:input_type -> metricsink.v1.ExportRequest
+	5, // 5: metricsink.v1.MetricSink.Export:output_type -> metricsink.v1.ExportResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_metricsink_v1_metricsink_proto_init() }
+func file_metricsink_v1_metricsink_proto_init() {
+	if File_metricsink_v1_metricsink_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metricsink_v1_metricsink_proto_rawDesc), len(file_metricsink_v1_metricsink_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_metricsink_v1_metricsink_proto_goTypes,
+		DependencyIndexes: file_metricsink_v1_metricsink_proto_depIdxs,
+		MessageInfos:      file_metricsink_v1_metricsink_proto_msgTypes,
+	}.Build()
+	File_metricsink_v1_metricsink_proto = out.File
+	file_metricsink_v1_metricsink_proto_goTypes = nil
+	file_metricsink_v1_metricsink_proto_depIdxs = nil
+}
@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: metricsink/v1/metricsink.proto
+
+package metricsinkv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MetricSink_Export_FullMethodName = "/metricsink.v1.MetricSink/Export"
+)
+
+// MetricSinkClient is the client API for MetricSink service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MetricSinkClient interface {
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+}
+
+type metricSinkClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMetricSinkClient(cc grpc.ClientConnInterface) MetricSinkClient {
+	return &metricSinkClient{cc}
+}
+
+func (c *metricSinkClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportResponse)
+	err := c.cc.Invoke(ctx, MetricSink_Export_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetricSinkServer is the server API for MetricSink service.
+// All implementations must embed UnimplementedMetricSinkServer
+// for forward compatibility.
+type MetricSinkServer interface {
+	Export(context.Context, *ExportRequest) (*ExportResponse, error)
+	mustEmbedUnimplementedMetricSinkServer()
+}
+
+// UnimplementedMetricSinkServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMetricSinkServer struct{}
+
+func (UnimplementedMetricSinkServer) Export(context.Context, *ExportRequest) (*ExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedMetricSinkServer) mustEmbedUnimplementedMetricSinkServer() {}
+func (UnimplementedMetricSinkServer) testEmbeddedByValue()                    {}
+
+// UnsafeMetricSinkServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MetricSinkServer will
+// result in compilation errors.
+type UnsafeMetricSinkServer interface {
+	mustEmbedUnimplementedMetricSinkServer()
+}
+
+func RegisterMetricSinkServer(s grpc.ServiceRegistrar, srv MetricSinkServer) {
+	// If the following call pancis, it indicates UnimplementedMetricSinkServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MetricSink_ServiceDesc, srv)
+}
+
+func _MetricSink_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricSinkServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricSink_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricSinkServer).Export(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MetricSink_ServiceDesc is the grpc.ServiceDesc for MetricSink service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MetricSink_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "metricsink.v1.MetricSink",
+	HandlerType: (*MetricSinkServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    _MetricSink_Export_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "metricsink/v1/metricsink.proto",
+}
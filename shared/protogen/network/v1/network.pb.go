@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: network/v1/network.proto
+
+package networkv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DialTarget struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocol      string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"` // tcp or udp
+	Host          string                 `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Port          int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DialTarget) Reset() {
+	*x = DialTarget{}
+	mi := &file_network_v1_network_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DialTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DialTarget) ProtoMessage() {}
+
+func (x *DialTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_network_v1_network_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DialTarget.ProtoReflect.Descriptor instead.
+func (*DialTarget) Descriptor() ([]byte, []int) {
+	return file_network_v1_network_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DialTarget) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *DialTarget) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *DialTarget) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+type DialFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        *DialTarget            `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DialFrame) Reset() {
+	*x = DialFrame{}
+	mi := &file_network_v1_network_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DialFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DialFrame) ProtoMessage() {}
+
+func (x *DialFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_network_v1_network_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DialFrame.ProtoReflect.Descriptor instead.
+func (*DialFrame) Descriptor() ([]byte, []int) {
+	return file_network_v1_network_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DialFrame) GetTarget() *DialTarget {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *DialFrame) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_network_v1_network_proto protoreflect.FileDescriptor
+
+const file_network_v1_network_proto_rawDesc = "" +
+	"\n" +
+	"\x18network/v1/network.proto\x12\n" +
+	"network.v1\"P\n" +
+	"\n" +
+	"DialTarget\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\x05R\x04port\"O\n" +
+	"\tDialFrame\x12.\n" +
+	"\x06target\x18\x01 \x01(\v2\x16.network.v1.DialTargetR\x06target\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data2C\n" +
+	"\aNetwork\x128\n" +
+	"\x04Dial\x12\x15.network.v1.DialFrame\x1a\x15.network.v1.DialFrame(\x010\x01BCZAgithub.com/bmj2728/PlugsConc/shared/protogen/network/v1;networkv1b\x06proto3"
+
+var (
+	file_network_v1_network_proto_rawDescOnce sync.Once
+	file_network_v1_network_proto_rawDescData []byte
+)
+
+func file_network_v1_network_proto_rawDescGZIP() []byte {
+	file_network_v1_network_proto_rawDescOnce.Do(func() {
+		file_network_v1_network_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_network_v1_network_proto_rawDesc), len(file_network_v1_network_proto_rawDesc)))
+	})
+	return file_network_v1_network_proto_rawDescData
+}
+
+var file_network_v1_network_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_network_v1_network_proto_goTypes = []any{
+	(*DialTarget)(nil), // 0: network.v1.DialTarget
+	(*DialFrame)(nil),  // 1: network.v1.DialFrame
+}
+var file_network_v1_network_proto_depIdxs = []int32{
+	0, // 0: network.v1.DialFrame.target:type_name -> network.v1.DialTarget
+	1, // 1: network.v1.Network.Dial:input_type -> network.v1.DialFrame
+	1, // 2: network.v1.Network.Dial:output_type -> network.v1.DialFrame
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_network_v1_network_proto_init() }
+func file_network_v1_network_proto_init() {
+	if File_network_v1_network_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_network_v1_network_proto_rawDesc), len(file_network_v1_network_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_network_v1_network_proto_goTypes,
+		DependencyIndexes: file_network_v1_network_proto_depIdxs,
+		MessageInfos:      file_network_v1_network_proto_msgTypes,
+	}.Build()
+	File_network_v1_network_proto = out.File
+	file_network_v1_network_proto_goTypes = nil
+	file_network_v1_network_proto_depIdxs = nil
+}